@@ -3,11 +3,12 @@ package bootstrap
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/repository"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/auth"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/logger"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
 	"github.com/go-redis/redis/v8"
 )
@@ -18,6 +19,7 @@ type App struct {
 	server      *Server
 	db          *repository.DB
 	redisClient *redis.Client
+	logger      *slog.Logger
 }
 
 // NewApp creates a new application instance
@@ -28,54 +30,69 @@ func NewApp() (*App, error) {
 		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
+	// Setup structured logging
+	lg := logger.New(cfg.Log.Level, cfg.Log.Format)
+	slog.SetDefault(lg)
+
 	// Setup database
 	db, err := repository.NewPostgresDB(cfg.Postgres)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
-	log.Println("Connected to PostgreSQL database")
-
-	// Setup Redis client
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     cfg.Redis.GetAddr(),
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
-	})
+	lg.Info("Connected to PostgreSQL database")
 
-	// Test Redis connection
+	// Setup the WebSocket hub, with Redis unless running as a single in-memory instance
 	ctx := context.Background()
-	_, err = redisClient.Ping(ctx).Result()
-	if err != nil {
-		db.Close() // Close DB if Redis fails
-		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	var redisClient *redis.Client
+	var wsHub websocket.QuizHub
+	switch cfg.WebSocket.Mode {
+	case config.WebSocketModeRedis:
+		redisClient = redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.GetAddr(),
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+
+		// Test Redis connection
+		_, err = redisClient.Ping(ctx).Result()
+		if err != nil {
+			db.Close() // Close DB if Redis fails
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		lg.Info("Connected to Redis", "addr", cfg.Redis.GetAddr())
+
+		wsHub = websocket.NewRedisHub(redisClient, ctx, lg)
+	case config.WebSocketModeMemory:
+		lg.Warn("Using the in-memory WebSocket hub; events are not shared with other instances")
+		wsHub = websocket.NewMemoryHub(lg)
+	default:
+		db.Close()
+		return nil, fmt.Errorf("unknown websocket mode %q: must be %q or %q", cfg.WebSocket.Mode, config.WebSocketModeRedis, config.WebSocketModeMemory)
 	}
-	log.Println("Connected to Redis")
-
-	// Setup WebSocket hub
-	wsHub := websocket.NewRedisHub(redisClient, ctx)
 	go wsHub.Run(ctx)
-	log.Println("Started WebSocket hub")
+	lg.Info("Started WebSocket hub", "mode", cfg.WebSocket.Mode, "instanceId", wsHub.GetInstanceID())
 
 	// Initialize JWT manager
 	jwtManager := auth.NewJWTManager(cfg.JWT)
-	log.Println("Initialized JWT authentication manager")
+	lg.Info("Initialized JWT authentication manager")
 
 	// Initialize repositories, services, and handlers
 	repos := NewRepositories(db)
-	services := NewServices(repos, jwtManager, wsHub)
-	handlers := NewHandlers(services, wsHub)
+	services := NewServices(repos, jwtManager, wsHub, cfg, lg)
+	handlers := NewHandlers(services, wsHub, jwtManager, cfg.WebSocket, cfg.Server, cfg.Quiz, db, redisClient, lg)
 
 	// Setup router
-	router := SetupRouter(handlers, jwtManager)
+	router := SetupRouter(handlers, jwtManager, cfg)
 
 	// Setup server
-	server := NewServer(cfg, router)
+	server := NewServer(cfg, router, lg)
 
 	return &App{
 		config:      cfg,
 		server:      server,
 		db:          db,
 		redisClient: redisClient,
+		logger:      lg,
 	}, nil
 }
 
@@ -88,13 +105,13 @@ func (a *App) Start() {
 func (a *App) Stop() {
 	if a.redisClient != nil {
 		if err := a.redisClient.Close(); err != nil {
-			log.Printf("Error closing Redis client: %v", err)
+			a.logger.Error("Error closing Redis client", "error", err)
 		}
 	}
 
 	if a.db != nil {
 		if err := a.db.Close(); err != nil {
-			log.Printf("Error closing database connection: %v", err)
+			a.logger.Error("Error closing database connection", "error", err)
 		}
 	}
 }
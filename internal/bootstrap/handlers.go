@@ -1,8 +1,14 @@
 package bootstrap
 
 import (
+	"log/slog"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/handler"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/repository"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/auth"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
+	"github.com/go-redis/redis/v8"
 )
 
 // Handlers holds all handler instances
@@ -15,18 +21,32 @@ type Handlers struct {
 	WSHandler          *handler.WebSocketHandler
 	ParticipantHandler *handler.ParticipantHandler
 	StateHandler       *handler.StateHandler
+	HealthHandler      *handler.HealthHandler
+	AdminHandler       *handler.AdminHandler
 }
 
 // NewHandlers initializes all handlers
-func NewHandlers(services *Services, wsHub *websocket.RedisHub) *Handlers {
+func NewHandlers(
+	services *Services,
+	wsHub websocket.QuizHub,
+	jwtManager *auth.JWTManager,
+	wsConfig config.WebSocketConfig,
+	serverConfig config.ServerConfig,
+	quizConfig config.QuizConfig,
+	db *repository.DB,
+	redisClient *redis.Client,
+	logger *slog.Logger,
+) *Handlers {
 	return &Handlers{
 		UserHandler:        handler.NewUserHandler(services.UserService),
-		QuizHandler:        handler.NewQuizHandler(services.QuizService, services.QuestionService, services.UserService, services.ParticipantService),
+		QuizHandler:        handler.NewQuizHandler(services.QuizService, services.QuestionService, services.UserService, services.ParticipantService, services.StateService, services.TeamService, services.IntegrityService, services.WebhookService, services.PresenterService, services.ScoringService),
 		QuestionHandler:    handler.NewQuestionHandler(services.QuestionService, services.QuizService),
-		AnswerHandler:      handler.NewAnswerHandler(services.AnswerService),
-		LeaderboardHandler: handler.NewLeaderboardHandler(services.LeaderboardService, services.QuizService),
-		WSHandler:          handler.NewWebSocketHandler(wsHub, services.QuizService, services.UserService, services.ParticipantService, services.StateService),
+		AnswerHandler:      handler.NewAnswerHandler(services.AnswerService, logger),
+		LeaderboardHandler: handler.NewLeaderboardHandler(services.LeaderboardService, services.TeamLeaderboardService, services.QuizService, quizConfig),
+		WSHandler:          handler.NewWebSocketHandler(wsHub, services.QuizService, services.UserService, services.ParticipantService, services.StateService, services.AnswerService, jwtManager, wsConfig, serverConfig, logger),
 		ParticipantHandler: handler.NewParticipantHandler(services.ParticipantService, services.QuizService),
 		StateHandler:       handler.NewStateHandler(services.StateService),
+		HealthHandler:      handler.NewHealthHandler(db, redisClient, wsHub),
+		AdminHandler:       handler.NewAdminHandler(services.AdminService),
 	}
 }
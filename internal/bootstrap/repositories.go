@@ -14,6 +14,8 @@ type Repositories struct {
 	ParticipantRepo    repository.ParticipantRepository
 	AnswerRepo         repository.AnswerRepository
 	StateRepo          repository.StateRepository
+	TeamRepo           repository.TeamRepository
+	WebhookRepo        repository.WebhookRepository
 }
 
 // NewRepositories initializes all repositories
@@ -26,5 +28,7 @@ func NewRepositories(db *repository.DB) *Repositories {
 		ParticipantRepo:    repository.NewPostgresParticipantRepository(db),
 		AnswerRepo:         repository.NewPostgresAnswerRepository(db),
 		StateRepo:          repository.NewStateRepository(db.DB),
+		TeamRepo:           repository.NewPostgresTeamRepository(db),
+		WebhookRepo:        repository.NewPostgresWebhookRepository(db),
 	}
 }
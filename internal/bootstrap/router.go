@@ -3,39 +3,52 @@ package bootstrap
 import (
 	"time"
 
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/middleware"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/auth"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/metrics"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRouter configures the HTTP router
-func SetupRouter(handlers *Handlers, jwtManager *auth.JWTManager) *gin.Engine {
+func SetupRouter(handlers *Handlers, jwtManager *auth.JWTManager, cfg *config.Config) *gin.Engine {
 	router := gin.Default()
 
-	// Configure CORS
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
+	// Configure CORS from the origin allowlist
+	corsConfig := cors.Config{
+		AllowMethods:  []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:  []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		ExposeHeaders: []string{"Content-Length"},
+		MaxAge:        12 * time.Hour,
+	}
+	if cfg.Server.AllowsAnyOrigin() {
+		// Browsers refuse credentials with a wildcard origin, so they are only enabled for explicit origins
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOrigins = cfg.Server.GetAllowedOrigins()
+		corsConfig.AllowCredentials = true
+	}
+	router.Use(cors.New(corsConfig))
+
+	// Bound request bodies before any handler decodes them
+	router.Use(middleware.BodySizeLimitMiddleware(cfg.Server.MaxBodyBytes))
 
 	// Setup routes
-	setupRoutes(router, handlers, jwtManager)
+	setupRoutes(router, handlers, jwtManager, cfg)
 
 	return router
 }
 
 // setupRoutes configures all API routes
-func setupRoutes(router *gin.Engine, handlers *Handlers, jwtManager *auth.JWTManager) {
+func setupRoutes(router *gin.Engine, handlers *Handlers, jwtManager *auth.JWTManager, cfg *config.Config) {
 	// API routes base group
 	apiV1 := router.Group("/api/v1")
 
 	// Create auth middleware
 	authMiddleware := middleware.JWTAuthMiddleware(jwtManager)
+	optionalAuthMiddleware := middleware.OptionalJWTAuthMiddleware(jwtManager)
+	registeredOnly := middleware.RegisteredUserMiddleware()
 
 	// ========== User Module ==========
 	userRoutes := apiV1.Group("/users")
@@ -59,6 +72,10 @@ func setupRoutes(router *gin.Engine, handlers *Handlers, jwtManager *auth.JWTMan
 		// Public quiz routes
 		quizRoutes.POST("/:id/join", handlers.QuizHandler.JoinQuiz)
 		quizRoutes.POST("/join", handlers.QuizHandler.JoinQuizByCode)
+		quizRoutes.POST("/guest", middleware.IPRateLimitMiddleware(cfg.Server.GuestQuizzesPerHour, time.Hour), handlers.QuizHandler.CreateGuestQuiz)
+		quizRoutes.GET("/code/:code", handlers.QuizHandler.GetQuizByCode)
+		quizRoutes.GET("/:id/teams", handlers.QuizHandler.GetTeams)
+		quizRoutes.GET("/:id/timer", handlers.StateHandler.GetQuizTimer)
 
 		// Private quiz routes
 		quizPrivate := quizRoutes.Group("")
@@ -66,11 +83,35 @@ func setupRoutes(router *gin.Engine, handlers *Handlers, jwtManager *auth.JWTMan
 		{
 			quizPrivate.GET("/my", handlers.QuizHandler.GetCurrentUserQuizzes)
 			quizPrivate.GET("/:id", handlers.QuizHandler.GetQuiz)
-			quizPrivate.POST("", handlers.QuizHandler.CreateQuiz)
+			// Guest hosts may only run the quiz they were created with
+			quizPrivate.POST("", registeredOnly, handlers.QuizHandler.CreateQuiz)
+			quizPrivate.POST("/import", registeredOnly, handlers.QuizHandler.ImportQuiz)
 			quizPrivate.PUT("/:id", handlers.QuizHandler.UpdateQuiz)
+			quizPrivate.PUT("/:id/settings", handlers.QuizHandler.UpdateQuizSettings)
+			quizPrivate.POST("/:id/regenerate-code", handlers.QuizHandler.RegenerateCode)
+			quizPrivate.POST("/:id/clone", registeredOnly, handlers.QuizHandler.CloneQuiz)
+			quizPrivate.GET("/:id/export", handlers.QuizHandler.ExportQuiz)
+			quizPrivate.GET("/:id/participants/export", handlers.QuizHandler.ExportParticipants)
 			quizPrivate.DELETE("/:id", handlers.QuizHandler.DeleteQuiz)
+			quizPrivate.POST("/:id/archive", handlers.QuizHandler.ArchiveQuiz)
+			quizPrivate.DELETE("/:id/archive", handlers.QuizHandler.UnarchiveQuiz)
 			quizPrivate.POST("/:id/start", handlers.QuizHandler.StartQuiz)
+			quizPrivate.POST("/:id/lobby-start", handlers.QuizHandler.StartQuizWithCountdown)
 			quizPrivate.POST("/:id/end", handlers.QuizHandler.EndQuiz)
+			quizPrivate.GET("/:id/timeline", handlers.QuizHandler.GetQuizTimeline)
+			quizPrivate.GET("/:id/integrity", handlers.QuizHandler.GetIntegrityReport)
+			quizPrivate.GET("/:id/scoring", handlers.QuizHandler.GetScoringReport)
+			quizPrivate.GET("/:id/presenter", handlers.QuizHandler.GetPresenterView)
+			quizPrivate.POST("/:id/spectator-token", handlers.QuizHandler.IssueSpectatorToken)
+			quizPrivate.POST("/:id/leaderboard/freeze", handlers.QuizHandler.FreezeLeaderboard)
+			quizPrivate.POST("/:id/leaderboard/reveal", handlers.QuizHandler.RevealFinalLeaderboard)
+			quizPrivate.POST("/:id/teams", handlers.QuizHandler.CreateTeam)
+			quizPrivate.PUT("/:id/webhook", handlers.QuizHandler.SetWebhook)
+			quizPrivate.GET("/:id/webhook", handlers.QuizHandler.GetWebhook)
+			quizPrivate.DELETE("/:id/webhook", handlers.QuizHandler.DeleteWebhook)
+			quizPrivate.PUT("/:id/questions/order", handlers.QuizHandler.ReorderQuestions)
+			quizPrivate.PUT("/:id/questions/time-limit", handlers.QuizHandler.SetQuestionTimeLimits)
+			quizPrivate.POST("/:id/goto/:questionId", handlers.QuizHandler.GoToQuestion)
 		}
 	}
 
@@ -87,8 +128,12 @@ func setupRoutes(router *gin.Engine, handlers *Handlers, jwtManager *auth.JWTMan
 		questionPrivate.Use(authMiddleware)
 		{
 			questionPrivate.POST("", handlers.QuestionHandler.AddQuestion)
+			questionPrivate.GET("/:id/preview", handlers.QuestionHandler.PreviewQuestion)
 			questionPrivate.POST("/:id/start", handlers.QuestionHandler.StartQuestion)
 			questionPrivate.POST("/:id/end", handlers.QuestionHandler.EndQuestion)
+			questionPrivate.POST("/:id/extend", handlers.QuestionHandler.ExtendQuestion)
+			questionPrivate.POST("/:id/skip", handlers.QuestionHandler.SkipQuestion)
+			questionPrivate.POST("/:id/void", handlers.QuestionHandler.VoidQuestion)
 			questionPrivate.POST("/:id/move-next-question", handlers.QuestionHandler.MoveToNextQuestion)
 		}
 	}
@@ -98,7 +143,7 @@ func setupRoutes(router *gin.Engine, handlers *Handlers, jwtManager *auth.JWTMan
 	{
 		// All answer routes are currently public
 		answerRoutes.POST("", handlers.AnswerHandler.SubmitAnswer)
-		answerRoutes.GET("/question/:questionId/stats", handlers.AnswerHandler.GetAnswerStats)
+		answerRoutes.GET("/question/:questionId/stats", optionalAuthMiddleware, handlers.AnswerHandler.GetAnswerStats)
 		answerRoutes.GET("/participant/:participantId/question/:questionId", handlers.AnswerHandler.GetParticipantAnswer)
 
 		// Private answer routes if needed
@@ -121,6 +166,7 @@ func setupRoutes(router *gin.Engine, handlers *Handlers, jwtManager *auth.JWTMan
 		participantPrivate.Use(authMiddleware)
 		{
 			participantPrivate.DELETE("/:id", handlers.ParticipantHandler.RemoveParticipant)
+			participantPrivate.POST("/:id/kick", handlers.ParticipantHandler.KickParticipant)
 		}
 	}
 
@@ -129,6 +175,7 @@ func setupRoutes(router *gin.Engine, handlers *Handlers, jwtManager *auth.JWTMan
 	{
 		// Public leaderboard routes
 		leaderboardRoutes.GET("/quiz/:quizId", handlers.LeaderboardHandler.GetLeaderboard)
+		leaderboardRoutes.GET("/quiz/:quizId/teams", handlers.LeaderboardHandler.GetTeamLeaderboard)
 
 		// Private leaderboard routes if needed
 		leaderboardPrivate := leaderboardRoutes.Group("")
@@ -153,7 +200,26 @@ func setupRoutes(router *gin.Engine, handlers *Handlers, jwtManager *auth.JWTMan
 		}
 	}
 
+	// ========== Admin Module ==========
+	adminRoutes := apiV1.Group("/admin")
+	adminRoutes.Use(authMiddleware, middleware.AdminMiddleware(cfg.Admin.UserIDs))
+	{
+		adminRoutes.GET("/integrity/orphaned-options", handlers.AdminHandler.GetOrphanedOptions)
+		adminRoutes.DELETE("/integrity/orphaned-options", handlers.AdminHandler.DeleteOrphanedOptions)
+		adminRoutes.GET("/quizzes/stale", handlers.AdminHandler.GetStaleQuizzes)
+		adminRoutes.POST("/quizzes/:id/terminate", handlers.AdminHandler.TerminateQuiz)
+	}
+
 	// ========== WebSocket ==========
 	// WebSocket route (outside API versioning)
 	router.GET("/ws/:quizId/:type/:id", handlers.WSHandler.HandleConnection)
+
+	// ========== Health ==========
+	// Liveness and readiness probes (outside API versioning)
+	router.GET("/healthz", handlers.HealthHandler.Liveness)
+	router.GET("/readyz", handlers.HealthHandler.Readiness)
+
+	// ========== Metrics ==========
+	// Prometheus scrape endpoint (outside API versioning)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 }
@@ -3,7 +3,7 @@ package bootstrap
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -17,10 +17,11 @@ import (
 // Server represents the HTTP server
 type Server struct {
 	httpServer *http.Server
+	logger     *slog.Logger
 }
 
 // NewServer creates a new server instance
-func NewServer(cfg *config.Config, router *gin.Engine) *Server {
+func NewServer(cfg *config.Config, router *gin.Engine, logger *slog.Logger) *Server {
 	httpServer := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
 		Handler:      router,
@@ -31,6 +32,7 @@ func NewServer(cfg *config.Config, router *gin.Engine) *Server {
 
 	return &Server{
 		httpServer: httpServer,
+		logger:     logger,
 	}
 }
 
@@ -38,9 +40,10 @@ func NewServer(cfg *config.Config, router *gin.Engine) *Server {
 func (s *Server) Start() {
 	// Start the server in a goroutine
 	go func() {
-		log.Printf("Starting server on port %s", s.httpServer.Addr)
+		s.logger.Info("Starting server", "addr", s.httpServer.Addr)
 		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			s.logger.Error("Failed to start server", "error", err)
+			os.Exit(1)
 		}
 	}()
 
@@ -48,7 +51,7 @@ func (s *Server) Start() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+	s.logger.Info("Shutting down server...")
 
 	// Create a deadline for the shutdown
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -56,8 +59,9 @@ func (s *Server) Start() {
 
 	// Attempt graceful shutdown
 	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		s.logger.Error("Server forced to shutdown", "error", err)
+		os.Exit(1)
 	}
 
-	log.Println("Server exiting")
+	s.logger.Info("Server exiting")
 }
@@ -1,6 +1,9 @@
 package bootstrap
 
 import (
+	"log/slog"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/auth"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
@@ -8,27 +11,44 @@ import (
 
 // Services holds all service instances
 type Services struct {
-	UserService        service.UserService
-	ParticipantService service.ParticipantService
-	QuizService        service.QuizService
-	QuestionService    service.QuestionService
-	AnswerService      service.AnswerService
-	LeaderboardService service.LeaderboardService
-	StateService       service.StateService
+	UserService            service.UserService
+	ParticipantService     service.ParticipantService
+	QuizService            service.QuizService
+	QuestionService        service.QuestionService
+	AnswerService          service.AnswerService
+	LeaderboardService     service.LeaderboardService
+	StateService           service.StateService
+	TeamService            service.TeamService
+	TeamLeaderboardService service.TeamLeaderboardService
+	IntegrityService       service.IntegrityService
+	AdminService           service.AdminService
+	WebhookService         service.WebhookService
+	PresenterService       service.PresenterService
+	ScoringService         service.ScoringService
 }
 
 // NewServices initializes all services
-func NewServices(repos *Repositories, jwtManager *auth.JWTManager, wsHub *websocket.RedisHub) *Services {
-	leaderBoardSerice := service.NewLeaderboardService(repos.ParticipantRepo, wsHub)
-	stateService := service.NewStateService(repos.StateRepo, repos.QuizRepo, repos.QuestionRepo, repos.QuestionOptionRepo, repos.ParticipantRepo, wsHub)
+func NewServices(repos *Repositories, jwtManager *auth.JWTManager, wsHub websocket.QuizHub, cfg *config.Config, logger *slog.Logger) *Services {
+	teamLeaderboardService := service.NewTeamLeaderboardService(repos.TeamRepo, repos.ParticipantRepo)
+	leaderBoardSerice := service.NewLeaderboardService(repos.ParticipantRepo, repos.QuizRepo, teamLeaderboardService, wsHub)
+	webhookNotifier := service.NewWebhookNotifier(repos.WebhookRepo, cfg.Webhook, logger)
+	stateService := service.NewStateService(repos.StateRepo, repos.QuizRepo, repos.QuestionRepo, repos.QuestionOptionRepo, repos.ParticipantRepo, repos.AnswerRepo, wsHub, webhookNotifier, cfg.Quiz, logger)
+	answerService := service.NewAnswerService(repos.AnswerRepo, repos.QuestionRepo, repos.ParticipantRepo, repos.QuizRepo, leaderBoardSerice, repos.QuestionOptionRepo, repos.StateRepo, wsHub, stateService, logger)
 
 	return &Services{
-		UserService:        service.NewUserService(repos.UserRepo, jwtManager),
-		ParticipantService: service.NewParticipantService(repos.ParticipantRepo, repos.QuizRepo, wsHub),
-		QuizService:        service.NewQuizService(repos.QuizRepo, repos.UserRepo, repos.QuestionRepo, repos.QuestionOptionRepo, stateService, wsHub),
-		QuestionService:    service.NewQuestionService(repos.QuizRepo, repos.QuestionRepo, repos.QuestionOptionRepo, wsHub, stateService),
-		AnswerService:      service.NewAnswerService(repos.AnswerRepo, repos.QuestionRepo, repos.ParticipantRepo, repos.QuizRepo, leaderBoardSerice, repos.QuestionOptionRepo, wsHub),
-		LeaderboardService: leaderBoardSerice,
-		StateService:       stateService,
+		UserService:            service.NewUserService(repos.UserRepo, jwtManager),
+		ParticipantService:     service.NewParticipantService(repos.ParticipantRepo, repos.QuizRepo, repos.TeamRepo, wsHub, stateService, jwtManager, cfg.Quiz),
+		QuizService:            service.NewQuizService(repos.QuizRepo, repos.UserRepo, repos.QuestionRepo, repos.QuestionOptionRepo, repos.AnswerRepo, stateService, wsHub, cfg.Quiz),
+		QuestionService:        service.NewQuestionService(repos.QuizRepo, repos.QuestionRepo, repos.QuestionOptionRepo, repos.AnswerRepo, leaderBoardSerice, wsHub, stateService, cfg.Quiz),
+		AnswerService:          answerService,
+		LeaderboardService:     leaderBoardSerice,
+		StateService:           stateService,
+		TeamService:            service.NewTeamService(repos.TeamRepo, repos.QuizRepo),
+		TeamLeaderboardService: teamLeaderboardService,
+		IntegrityService:       service.NewIntegrityService(repos.QuizRepo, repos.QuestionRepo, repos.AnswerRepo, cfg.Integrity),
+		AdminService:           service.NewAdminService(repos.QuestionOptionRepo, repos.QuizRepo, stateService, cfg.Admin, logger),
+		WebhookService:         service.NewWebhookService(repos.WebhookRepo, repos.QuizRepo, cfg.Webhook),
+		PresenterService:       service.NewPresenterService(repos.QuizRepo, stateService, answerService, leaderBoardSerice),
+		ScoringService:         service.NewScoringService(repos.QuizRepo, repos.QuestionRepo, repos.AnswerRepo),
 	}
 }
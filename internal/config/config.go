@@ -12,10 +12,16 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	Server   ServerConfig
-	Postgres PostgresConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
+	Server    ServerConfig
+	Postgres  PostgresConfig
+	Redis     RedisConfig
+	JWT       JWTConfig
+	WebSocket WebSocketConfig
+	Integrity IntegrityConfig
+	Log       LogConfig
+	Quiz      QuizConfig
+	Admin     AdminConfig
+	Webhook   WebhookConfig
 }
 
 // ServerConfig represents HTTP server configuration
@@ -24,6 +30,20 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
 	IdleTimeout  time.Duration `mapstructure:"idle_timeout"`
+	// AllowedOrigins lists the browser origins allowed to call the API and open WebSockets; "*" allows any origin
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+	// MaxBodyBytes caps the size of request bodies; 0 disables the limit
+	MaxBodyBytes int64 `mapstructure:"max_body_bytes"`
+	// GuestQuizzesPerHour caps how many guest quizzes one client IP may create per hour; 0 disables the limit
+	GuestQuizzesPerHour int `mapstructure:"guest_quizzes_per_hour"`
+}
+
+// DefaultAllowedOrigins are the local development origins allowed when none are configured
+var DefaultAllowedOrigins = []string{
+	"http://localhost:3000",
+	"http://localhost:5173",
+	"http://127.0.0.1:3000",
+	"http://127.0.0.1:5173",
 }
 
 // PostgresConfig represents PostgreSQL database configuration
@@ -46,14 +66,116 @@ type RedisConfig struct {
 
 // JWTConfig represents JWT authentication configuration
 type JWTConfig struct {
-	Secret           string        `mapstructure:"secret"`
-	ExpirationTime   time.Duration `mapstructure:"expiration_time"`
-	RefreshSecret    string        `mapstructure:"refresh_secret"`
-	RefreshExpTime   time.Duration `mapstructure:"refresh_expiration_time"`
+	Secret         string        `mapstructure:"secret"`
+	ExpirationTime time.Duration `mapstructure:"expiration_time"`
+	RefreshSecret  string        `mapstructure:"refresh_secret"`
+	RefreshExpTime time.Duration `mapstructure:"refresh_expiration_time"`
+	// ParticipantExpTime is how long the token a participant receives at join time stays valid for WebSocket connections
+	ParticipantExpTime time.Duration `mapstructure:"participant_expiration_time"`
+	// GuestExpTime is how long the creator token of a guest host stays valid; guests cannot log in again
+	GuestExpTime     time.Duration `mapstructure:"guest_expiration_time"`
 	SigningAlgorithm string        `mapstructure:"signing_algorithm"`
 	Issuer           string        `mapstructure:"issuer"`
 }
 
+// WebSocketConfig represents WebSocket connection configuration
+type WebSocketConfig struct {
+	// Mode selects the hub: "redis" shares events across instances, "memory" keeps them in a single
+	// process without needing Redis
+	Mode string `mapstructure:"mode"`
+	// AnswerRateLimit is the maximum number of answer messages a client may send per second
+	AnswerRateLimit int `mapstructure:"answer_rate_limit"`
+	// StateSyncParticipantThreshold is the participant count above which the initial state sync
+	// omits the participant list; 0 always sends the full state
+	StateSyncParticipantThreshold int `mapstructure:"state_sync_participant_threshold"`
+	// StateSyncLeaderboardSize is the number of top participants included in a trimmed state sync
+	StateSyncLeaderboardSize int `mapstructure:"state_sync_leaderboard_size"`
+	// ParticipantMaxMessageBytes is the largest message read from a participant connection before it is closed
+	ParticipantMaxMessageBytes int `mapstructure:"participant_max_message_bytes"`
+	// CreatorMaxMessageBytes is the largest message read from a creator connection before it is closed
+	CreatorMaxMessageBytes int `mapstructure:"creator_max_message_bytes"`
+	// MaxAnswerPayloadBytes is the largest ANSWER payload accepted from a client; 0 disables the check
+	MaxAnswerPayloadBytes int `mapstructure:"max_answer_payload_bytes"`
+	// MaxSelectedOptions is the most option IDs an ANSWER may carry; 0 disables the check
+	MaxSelectedOptions int `mapstructure:"max_selected_options"`
+}
+
+// WebSocket hub modes
+const (
+	// WebSocketModeRedis relays events between instances through Redis pub/sub
+	WebSocketModeRedis = "redis"
+	// WebSocketModeMemory delivers events within a single instance and does not use Redis
+	WebSocketModeMemory = "memory"
+)
+
+// IntegrityConfig represents the opt-in answer integrity analytics configuration
+type IntegrityConfig struct {
+	// Enabled turns on the integrity report endpoint
+	Enabled bool `mapstructure:"enabled"`
+	// ClusterWindowMs is how close identical submissions must be to count as a cluster
+	ClusterWindowMs int `mapstructure:"cluster_window_ms"`
+	// MinClusterSize is the number of identical submissions needed to flag a cluster
+	MinClusterSize int `mapstructure:"min_cluster_size"`
+}
+
+// QuizConfig represents limits applied to quizzes
+type QuizConfig struct {
+	// MaxActivePerCreator is how many quizzes a creator may run at once; 0 disables the limit
+	MaxActivePerCreator int `mapstructure:"max_active_per_creator"`
+	// ReconnectGracePeriod is how long a disconnected participant keeps their slot toward maxParticipants
+	ReconnectGracePeriod time.Duration `mapstructure:"reconnect_grace_period"`
+	// PresenceDebounce is how long a participant's connection must settle before USER_JOINED or USER_LEFT
+	// is announced, so flapping connections collapse into one event; 0 announces every change at once
+	PresenceDebounce time.Duration `mapstructure:"presence_debounce"`
+	// StateCacheTTL is how long a computed quiz state is reused by other connects while the quiz does not
+	// change, so a class connecting at once queries the database once; 0 computes it for every caller.
+	// It is capped at 500ms so states changed on another instance are never reused past a timer tick.
+	StateCacheTTL time.Duration `mapstructure:"state_cache_ttl"`
+	// MaxPrefetchQuestions caps how many upcoming questions a client may prefetch at once
+	MaxPrefetchQuestions int `mapstructure:"max_prefetch_questions"`
+	// MaxLeaderboardLimit caps how many entries one leaderboard page may request
+	MaxLeaderboardLimit int `mapstructure:"max_leaderboard_limit"`
+	// MaxTitleLength caps quiz titles, in characters; 0 or more than the column allows uses the column size of 255
+	MaxTitleLength int `mapstructure:"max_title_length"`
+	// MaxDescriptionLength caps quiz descriptions, in characters; 0 disables the limit
+	MaxDescriptionLength int `mapstructure:"max_description_length"`
+	// MaxQuestionsPerQuiz caps how many questions a quiz may be created, imported or edited to have; 0 disables the limit
+	MaxQuestionsPerQuiz int `mapstructure:"max_questions_per_quiz"`
+	// CollapseNameWhitespace collapses runs of whitespace inside participant names, so "John  Doe" joins as
+	// "John Doe" and collides with it; disabled, names are only trimmed and compared as typed
+	CollapseNameWhitespace bool `mapstructure:"collapse_name_whitespace"`
+}
+
+// AdminConfig represents access to the operator endpoints
+type AdminConfig struct {
+	// UserIDs lists the IDs of the accounts allowed to call the admin endpoints; empty disables them for everyone.
+	// IDs are used rather than emails because registration does not verify email ownership
+	UserIDs []string `mapstructure:"user_ids"`
+	// StaleQuizThreshold is how long an active quiz may go without activity before it is listed as stale
+	StaleQuizThreshold time.Duration `mapstructure:"stale_quiz_threshold"`
+}
+
+// WebhookConfig represents delivery of quiz lifecycle webhooks
+type WebhookConfig struct {
+	// Enabled turns on webhook deliveries
+	Enabled bool `mapstructure:"enabled"`
+	// Timeout bounds a single delivery attempt
+	Timeout time.Duration `mapstructure:"timeout"`
+	// MaxAttempts is how many times a delivery is tried before it is dropped
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// AllowPrivateNetworks lets webhooks reach loopback, private and link-local addresses; off by default
+	// so a creator cannot point the server at its own network
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks"`
+}
+
+// LogConfig represents logging configuration
+type LogConfig struct {
+	// Level is the minimum level logged: debug, info, warn or error
+	Level string `mapstructure:"level"`
+	// Format is the output format: text or json
+	Format string `mapstructure:"format"`
+}
+
 // LoadConfig loads configuration from various sources in the following order of precedence:
 // 1. Environment variables (with or without APP_ prefix, highest priority)
 // 2. Config file specified by APP_CONFIG_FILE environment variable
@@ -66,6 +188,9 @@ func LoadConfig() (*Config, error) {
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv() // Read environment variables that match
 
+	// Set defaults for optional settings
+	setDefaults(v)
+
 	// Also support standard environment variables without the prefix
 	// These take precedence over the prefixed variables
 	bindEnvVariables(v)
@@ -90,6 +215,44 @@ func LoadConfig() (*Config, error) {
 	return config, nil
 }
 
+// setDefaults sets default values for settings that are optional in the config file
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("server.allowed_origins", DefaultAllowedOrigins)
+	v.SetDefault("server.max_body_bytes", 1<<20)
+	v.SetDefault("server.guest_quizzes_per_hour", 10)
+	v.SetDefault("jwt.participant_expiration_time", "4h")
+	v.SetDefault("jwt.guest_expiration_time", "3h")
+	v.SetDefault("websocket.mode", WebSocketModeRedis)
+	v.SetDefault("websocket.answer_rate_limit", 2)
+	v.SetDefault("websocket.state_sync_participant_threshold", 200)
+	v.SetDefault("websocket.state_sync_leaderboard_size", 10)
+	v.SetDefault("websocket.participant_max_message_bytes", 4096)
+	v.SetDefault("websocket.creator_max_message_bytes", 8192)
+	v.SetDefault("websocket.max_answer_payload_bytes", 512)
+	v.SetDefault("websocket.max_selected_options", 10)
+	v.SetDefault("integrity.enabled", false)
+	v.SetDefault("integrity.cluster_window_ms", 200)
+	v.SetDefault("integrity.min_cluster_size", 3)
+	v.SetDefault("log.level", "info")
+	v.SetDefault("log.format", "text")
+	v.SetDefault("quiz.max_active_per_creator", 10)
+	v.SetDefault("quiz.reconnect_grace_period", "60s")
+	v.SetDefault("quiz.presence_debounce", "2s")
+	v.SetDefault("quiz.state_cache_ttl", "500ms")
+	v.SetDefault("quiz.max_prefetch_questions", 5)
+	v.SetDefault("quiz.max_leaderboard_limit", 100)
+	v.SetDefault("quiz.max_questions_per_quiz", 100)
+	v.SetDefault("quiz.collapse_name_whitespace", true)
+	v.SetDefault("quiz.max_title_length", 255)
+	v.SetDefault("quiz.max_description_length", 2000)
+	v.SetDefault("admin.user_ids", []string{})
+	v.SetDefault("admin.stale_quiz_threshold", 2*time.Hour)
+	v.SetDefault("webhook.enabled", true)
+	v.SetDefault("webhook.timeout", "5s")
+	v.SetDefault("webhook.max_attempts", 3)
+	v.SetDefault("webhook.allow_private_networks", false)
+}
+
 // bindEnvVariables explicitly binds commonly used environment variables
 // to their respective config keys for better compatibility
 func bindEnvVariables(v *viper.Viper) {
@@ -98,6 +261,9 @@ func bindEnvVariables(v *viper.Viper) {
 	v.BindEnv("server.read_timeout", "SERVER_READ_TIMEOUT")
 	v.BindEnv("server.write_timeout", "SERVER_WRITE_TIMEOUT")
 	v.BindEnv("server.idle_timeout", "SERVER_IDLE_TIMEOUT")
+	v.BindEnv("server.allowed_origins", "SERVER_ALLOWED_ORIGINS") // comma-separated
+	v.BindEnv("server.max_body_bytes", "SERVER_MAX_BODY_BYTES")
+	v.BindEnv("server.guest_quizzes_per_hour", "SERVER_GUEST_QUIZZES_PER_HOUR")
 
 	// PostgreSQL environment variables
 	v.BindEnv("postgres.host", "POSTGRES_HOST")
@@ -118,8 +284,51 @@ func bindEnvVariables(v *viper.Viper) {
 	v.BindEnv("jwt.expiration_time", "JWT_EXPIRATION_TIME")
 	v.BindEnv("jwt.refresh_secret", "JWT_REFRESH_SECRET")
 	v.BindEnv("jwt.refresh_expiration_time", "JWT_REFRESH_EXPIRATION_TIME")
+	v.BindEnv("jwt.participant_expiration_time", "JWT_PARTICIPANT_EXPIRATION_TIME")
+	v.BindEnv("jwt.guest_expiration_time", "JWT_GUEST_EXPIRATION_TIME")
 	v.BindEnv("jwt.signing_algorithm", "JWT_SIGNING_ALGORITHM")
 	v.BindEnv("jwt.issuer", "JWT_ISSUER")
+
+	// WebSocket environment variables
+	v.BindEnv("websocket.mode", "WS_MODE")
+	v.BindEnv("websocket.answer_rate_limit", "WS_ANSWER_RATE_LIMIT")
+	v.BindEnv("websocket.state_sync_participant_threshold", "WS_STATE_SYNC_PARTICIPANT_THRESHOLD")
+	v.BindEnv("websocket.state_sync_leaderboard_size", "WS_STATE_SYNC_LEADERBOARD_SIZE")
+	v.BindEnv("websocket.participant_max_message_bytes", "WS_PARTICIPANT_MAX_MESSAGE_BYTES")
+	v.BindEnv("websocket.creator_max_message_bytes", "WS_CREATOR_MAX_MESSAGE_BYTES")
+	v.BindEnv("websocket.max_answer_payload_bytes", "WS_MAX_ANSWER_PAYLOAD_BYTES")
+	v.BindEnv("websocket.max_selected_options", "WS_MAX_SELECTED_OPTIONS")
+
+	// Integrity analytics environment variables
+	v.BindEnv("integrity.enabled", "INTEGRITY_ENABLED")
+	v.BindEnv("integrity.cluster_window_ms", "INTEGRITY_CLUSTER_WINDOW_MS")
+	v.BindEnv("integrity.min_cluster_size", "INTEGRITY_MIN_CLUSTER_SIZE")
+
+	// Logging environment variables
+	v.BindEnv("log.level", "LOG_LEVEL")
+	v.BindEnv("log.format", "LOG_FORMAT")
+
+	// Quiz limit environment variables
+	v.BindEnv("quiz.max_active_per_creator", "QUIZ_MAX_ACTIVE_PER_CREATOR")
+	v.BindEnv("quiz.reconnect_grace_period", "QUIZ_RECONNECT_GRACE_PERIOD")
+	v.BindEnv("quiz.presence_debounce", "QUIZ_PRESENCE_DEBOUNCE")
+	v.BindEnv("quiz.state_cache_ttl", "QUIZ_STATE_CACHE_TTL")
+	v.BindEnv("quiz.max_prefetch_questions", "QUIZ_MAX_PREFETCH_QUESTIONS")
+	v.BindEnv("quiz.max_leaderboard_limit", "QUIZ_MAX_LEADERBOARD_LIMIT")
+	v.BindEnv("quiz.max_questions_per_quiz", "QUIZ_MAX_QUESTIONS_PER_QUIZ")
+	v.BindEnv("quiz.collapse_name_whitespace", "QUIZ_COLLAPSE_NAME_WHITESPACE")
+	v.BindEnv("quiz.max_title_length", "QUIZ_MAX_TITLE_LENGTH")
+	v.BindEnv("quiz.max_description_length", "QUIZ_MAX_DESCRIPTION_LENGTH")
+
+	// Admin environment variables (comma-separated user IDs)
+	v.BindEnv("admin.user_ids", "ADMIN_USER_IDS")
+	v.BindEnv("admin.stale_quiz_threshold", "ADMIN_STALE_QUIZ_THRESHOLD")
+
+	// Webhook environment variables
+	v.BindEnv("webhook.enabled", "WEBHOOK_ENABLED")
+	v.BindEnv("webhook.timeout", "WEBHOOK_TIMEOUT")
+	v.BindEnv("webhook.max_attempts", "WEBHOOK_MAX_ATTEMPTS")
+	v.BindEnv("webhook.allow_private_networks", "WEBHOOK_ALLOW_PRIVATE_NETWORKS")
 }
 
 // getConfigFile returns the config file path from APP_CONFIG_FILE environment variable
@@ -132,6 +341,31 @@ func getConfigFile() string {
 	return "" // No config file specified
 }
 
+// GetAllowedOrigins returns the configured allowed origins, falling back to DefaultAllowedOrigins when empty
+func (s ServerConfig) GetAllowedOrigins() []string {
+	var origins []string
+	for _, origin := range s.AllowedOrigins {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	if len(origins) == 0 {
+		return DefaultAllowedOrigins
+	}
+	return origins
+}
+
+// AllowsAnyOrigin reports whether the allowed origins include the "*" wildcard
+func (s ServerConfig) AllowsAnyOrigin() bool {
+	for _, origin := range s.GetAllowedOrigins() {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
 // GetConnectionString returns a formatted PostgreSQL connection string
 func (p PostgresConfig) GetConnectionString() string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
@@ -13,6 +13,8 @@ type AnswerSubmitRequest struct {
 	QuestionID      string   `json:"questionId" binding:"required"`
 	SelectedOptions []string `json:"selectedOptions" binding:"required,min=1"`
 	TimeTaken       float64  `json:"timeTaken" binding:"required,min=0"`
+	// ClientToken optionally identifies the submission so retries return the original answer
+	ClientToken string `json:"clientToken" binding:"max=100"`
 }
 
 // AnswerResponse represents an answer in API responses
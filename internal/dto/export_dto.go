@@ -0,0 +1,86 @@
+package dto
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+// QuizExportVersion is the version of the quiz export format produced by QuizExportFromModel
+const QuizExportVersion = 1
+
+// QuizExport is the portable definition of a quiz used for backup and sharing.
+// It holds no participant, answer or session data, and its questions use the same shape as quiz creation.
+type QuizExport struct {
+	Version     int                  `json:"version"`
+	Title       string               `json:"title" binding:"required"`
+	Description string               `json:"description"`
+	Settings    model.QuizSettings   `json:"settings"`
+	Questions   []QuestionCreateData `json:"questions" binding:"required"`
+}
+
+// QuizExportFromModel converts a quiz and its questions, with options loaded, to the export format.
+// Questions and options keep the order they are given in.
+func QuizExportFromModel(quiz *model.Quiz, questions []*model.Question) QuizExport {
+	export := QuizExport{
+		Version:     QuizExportVersion,
+		Title:       quiz.Title,
+		Description: quiz.Description,
+		Settings:    quiz.Settings,
+		Questions:   make([]QuestionCreateData, len(questions)),
+	}
+
+	for i, question := range questions {
+		options := make([]OptionCreateData, len(question.Options))
+		for j, option := range question.Options {
+			options[j] = OptionCreateData{
+				Text:      option.Text,
+				IsCorrect: option.IsCorrect,
+			}
+		}
+
+		export.Questions[i] = QuestionCreateData{
+			Text:         question.Text,
+			Options:      options,
+			QuestionType: string(question.QuestionType),
+			TimeLimit:    question.TimeLimit,
+			ImageURL:     question.ImageURL,
+			Explanation:  question.Explanation,
+			Required:     question.Required,
+		}
+	}
+
+	return export
+}
+
+// RosterEntry is a participant's row in a quiz roster export
+type RosterEntry struct {
+	ParticipantID uuid.UUID  `json:"participantId"`
+	Name          string     `json:"name,omitempty"` // Omitted for anonymous quizzes
+	TeamID        *uuid.UUID `json:"teamId,omitempty"`
+	JoinedAt      time.Time  `json:"joinedAt"`
+	Score         int        `json:"score"`
+}
+
+// RosterFromModel lists a quiz's participants in join order, leaving out their names when anonymous is set
+func RosterFromModel(participants []*model.Participant, anonymous bool) []RosterEntry {
+	roster := make([]RosterEntry, len(participants))
+	for i, participant := range participants {
+		roster[i] = RosterEntry{
+			ParticipantID: participant.ID,
+			TeamID:        participant.TeamID,
+			JoinedAt:      participant.JoinedAt,
+			Score:         participant.Score,
+		}
+		if !anonymous {
+			roster[i].Name = participant.Name
+		}
+	}
+
+	sort.SliceStable(roster, func(i, j int) bool {
+		return roster[i].JoinedAt.Before(roster[j].JoinedAt)
+	})
+	return roster
+}
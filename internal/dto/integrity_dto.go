@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IntegrityReportDTO summarizes suspicious submission patterns across the questions of a quiz
+type IntegrityReportDTO struct {
+	QuizID         uuid.UUID              `json:"quizId"`
+	WindowMs       int                    `json:"windowMs"`
+	MinClusterSize int                    `json:"minClusterSize"`
+	Flagged        bool                   `json:"flagged"`
+	Questions      []QuestionIntegrityDTO `json:"questions"`
+}
+
+// QuestionIntegrityDTO lists the submission clusters found for a single question
+type QuestionIntegrityDTO struct {
+	QuestionID   uuid.UUID              `json:"questionId"`
+	TotalAnswers int                    `json:"totalAnswers"`
+	Flagged      bool                   `json:"flagged"`
+	Clusters     []SubmissionClusterDTO `json:"clusters"`
+}
+
+// SubmissionClusterDTO represents identical answers submitted by several participants within a short window
+type SubmissionClusterDTO struct {
+	SelectedOptions []string    `json:"selectedOptions"`
+	ParticipantIDs  []uuid.UUID `json:"participantIds"`
+	FirstAnsweredAt time.Time   `json:"firstAnsweredAt"`
+	SpreadMs        int64       `json:"spreadMs"`
+}
@@ -3,6 +3,7 @@ package dto
 import (
 	"time"
 
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
 	"github.com/google/uuid"
 )
 
@@ -10,6 +11,7 @@ import (
 type LeaderboardRequest struct {
 	QuizID string `uri:"quizId" binding:"required"`
 	Limit  int    `form:"limit,default=10"`
+	Offset int    `form:"offset,default=0"`
 }
 
 // LeaderboardEntry represents a single entry in the leaderboard
@@ -19,6 +21,24 @@ type LeaderboardEntry struct {
 	Name     string    `json:"name"`
 	Score    int       `json:"score"`
 	JoinedAt time.Time `json:"joinedAt"`
+	// AverageTime is the participant's mean answer time in seconds, the total of which breaks score ties
+	AverageTime float64 `json:"averageTime"`
+}
+
+// LeaderboardEntriesFromModel converts ranked participants to leaderboard entries
+func LeaderboardEntriesFromModel(participants []*model.Participant) []LeaderboardEntry {
+	entries := make([]LeaderboardEntry, 0, len(participants))
+	for _, participant := range participants {
+		entries = append(entries, LeaderboardEntry{
+			Rank:        participant.Rank,
+			ID:          participant.ID,
+			Name:        participant.Name,
+			Score:       participant.Score,
+			JoinedAt:    participant.JoinedAt,
+			AverageTime: participant.AverageAnswerTime(),
+		})
+	}
+	return entries
 }
 
 // LeaderboardResponse represents the response payload for a leaderboard request
@@ -28,3 +48,20 @@ type LeaderboardResponse struct {
 	TotalPlayers int                `json:"totalPlayers"`
 	Leaderboard  []LeaderboardEntry `json:"leaderboard"`
 }
+
+// TeamLeaderboardEntry represents a single team in the team leaderboard
+type TeamLeaderboardEntry struct {
+	Rank         int       `json:"rank"`
+	TeamID       uuid.UUID `json:"teamId"`
+	Name         string    `json:"name"`
+	TotalScore   int       `json:"totalScore"`
+	AverageScore float64   `json:"averageScore"`
+	MemberCount  int       `json:"memberCount"`
+}
+
+// TeamLeaderboardResponse represents the response payload for a team leaderboard request
+type TeamLeaderboardResponse struct {
+	QuizID    uuid.UUID              `json:"quizId"`
+	QuizTitle string                 `json:"quizTitle"`
+	Teams     []TeamLeaderboardEntry `json:"teams"`
+}
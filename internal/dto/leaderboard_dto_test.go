@@ -0,0 +1,24 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+func TestLeaderboardEntriesExposeAverageAnswerTime(t *testing.T) {
+	quizID := uuid.New()
+	answered := model.NewParticipant("Fast", quizID)
+	answered.TotalAnswerTime, answered.AnswerCount = 8, 2
+	idle := model.NewParticipant("Idle", quizID)
+
+	entries := LeaderboardEntriesFromModel([]*model.Participant{answered, idle})
+
+	if entries[0].AverageTime != 4 {
+		t.Errorf("average time of 2 answers totalling 8s is %v, want 4", entries[0].AverageTime)
+	}
+	if entries[1].AverageTime != 0 {
+		t.Errorf("participant without answers has an average time of %v, want 0", entries[1].AverageTime)
+	}
+}
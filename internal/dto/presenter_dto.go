@@ -0,0 +1,20 @@
+package dto
+
+import (
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+// PresenterViewDTO represents everything a creator's control screen shows during a live quiz
+type PresenterViewDTO struct {
+	QuizID           uuid.UUID               `json:"quizId"`
+	Title            string                  `json:"title"`
+	Status           string                  `json:"status"`
+	CurrentPhase     model.QuizPhase         `json:"currentPhase"`
+	ActiveQuestion   *ActiveQuestionStateDTO `json:"activeQuestion,omitempty"`
+	Timer            *TimerStateDTO          `json:"timer,omitempty"`
+	AnswerCounts     map[string]int          `json:"answerCounts,omitempty"`
+	ParticipantCount int                     `json:"participantCount"`
+	ConnectedCount   int                     `json:"connectedCount"`
+	Leaderboard      []LeaderboardEntry      `json:"leaderboard"`
+}
@@ -22,6 +22,9 @@ type QuestionCreateRequest struct {
 	Options      []OptionCreateData `json:"options" binding:"required,min=2,max=10"`
 	QuestionType string             `json:"questionType" binding:"required,oneof=SINGLE_CHOICE MULTIPLE_CHOICE"`
 	TimeLimit    int                `json:"timeLimit" binding:"required,min=5,max=60"`
+	ImageURL     string             `json:"imageUrl"`
+	Explanation  string             `json:"explanation"`
+	Required     bool               `json:"required"`
 }
 
 // QuestionCreateData represents a question to be created as part of a quiz
@@ -30,6 +33,9 @@ type QuestionCreateData struct {
 	Options      []OptionCreateData `json:"options" binding:"required,min=2,max=10"`
 	QuestionType string             `json:"questionType" binding:"required,oneof=SINGLE_CHOICE MULTIPLE_CHOICE"`
 	TimeLimit    int                `json:"timeLimit" binding:"required,min=5,max=60"`
+	ImageURL     string             `json:"imageUrl,omitempty"`
+	Explanation  string             `json:"explanation,omitempty"`
+	Required     bool               `json:"required,omitempty"`
 }
 
 // QuestionUpdateData represents question data for updating a quiz
@@ -39,6 +45,31 @@ type QuestionUpdateData struct {
 	TimeLimit    int          `json:"timeLimit" binding:"required"`
 	QuestionType string       `json:"questionType" binding:"required,oneof=SINGLE_CHOICE MULTIPLE_CHOICE"`
 	Options      []OptionData `json:"options" binding:"required"`
+	ImageURL     string       `json:"imageUrl"`
+	Explanation  string       `json:"explanation"`
+	Required     bool         `json:"required"`
+}
+
+// QuestionOrderRequest represents the request to reorder the questions of a quiz
+type QuestionOrderRequest struct {
+	QuestionIDs []uuid.UUID `json:"questionIds" binding:"required,min=1"`
+}
+
+// QuestionTimeLimitRequest represents the request to set the time limit of many questions of a quiz at once
+type QuestionTimeLimitRequest struct {
+	TimeLimit int `json:"timeLimit" binding:"required,min=5,max=60"`
+	// QuestionIDs limits the change to these questions; empty applies it to every question of the quiz
+	QuestionIDs []uuid.UUID `json:"questionIds"`
+}
+
+// QuestionExtendRequest represents the request to add time to a running question
+type QuestionExtendRequest struct {
+	Seconds int `json:"seconds" binding:"required,min=1,max=300"`
+}
+
+// QuestionVoidRequest represents the request to void a question that already ran
+type QuestionVoidRequest struct {
+	Policy string `json:"policy" binding:"required,oneof=REMOVE FULL_POINTS"`
 }
 
 // OptionResponse represents an option in API responses
@@ -57,16 +88,33 @@ type QuestionResponse struct {
 	QuestionType string           `json:"questionType"`
 	TimeLimit    int              `json:"timeLimit"`
 	Order        int              `json:"order"`
+	ImageURL     string           `json:"imageUrl,omitempty"`
+	Explanation  string           `json:"explanation,omitempty"` // Only set when correct answers are included
+	Required     bool             `json:"required"`
+	VoidedPoints *int             `json:"voidedPoints,omitempty"`
 	CreatedAt    time.Time        `json:"createdAt"`
 	UpdatedAt    time.Time        `json:"updatedAt"`
 }
 
 // ParticipantResponse represents a participant in API responses
 type ParticipantResponse struct {
-	ID     uuid.UUID `json:"id"`
-	QuizID uuid.UUID `json:"quizId"`
-	Name   string    `json:"name"`
-	Score  int       `json:"score"`
+	ID     uuid.UUID  `json:"id"`
+	QuizID uuid.UUID  `json:"quizId"`
+	Name   string     `json:"name"`
+	Score  int        `json:"score"`
+	TeamID *uuid.UUID `json:"teamId,omitempty"`
+}
+
+// ParticipantJoinResponse represents a newly joined participant with the token for their WebSocket connections
+type ParticipantJoinResponse struct {
+	ParticipantResponse
+	Token string `json:"token"`
+}
+
+// SpectatorTokenResponse represents the ID and token a spectator display connects with
+type SpectatorTokenResponse struct {
+	SpectatorID uuid.UUID `json:"spectatorId"`
+	Token       string    `json:"token"`
 }
 
 // QuestionAction represents the response for question actions (start/end)
@@ -82,7 +130,10 @@ func QuestionResponseFromModel(model *model.Question, includeCorrectAnswers bool
 		Text:         model.Text,
 		QuestionType: string(model.QuestionType),
 		TimeLimit:    model.TimeLimit,
+		VoidedPoints: model.VoidedPoints,
 		Order:        model.Order,
+		ImageURL:     model.ImageURL,
+		Required:     model.Required,
 		CreatedAt:    model.CreatedAt,
 		UpdatedAt:    model.UpdatedAt,
 	}
@@ -103,6 +154,11 @@ func QuestionResponseFromModel(model *model.Question, includeCorrectAnswers bool
 		options[i] = optResponse
 	}
 
+	// The explanation gives the correct answer away just like the options do
+	if includeCorrectAnswers {
+		response.Explanation = model.Explanation
+	}
+
 	response.Options = options
 	return response
 }
@@ -114,5 +170,6 @@ func ParticipantResponseFromModel(model *model.Participant) ParticipantResponse
 		QuizID: model.QuizID,
 		Name:   model.Name,
 		Score:  model.Score,
+		TeamID: model.TeamID,
 	}
 }
@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+func TestQuestionResponseIncludesTheExplanationWithCorrectAnswersOnly(t *testing.T) {
+	question := model.NewQuestion(uuid.New(), "Capital of France?", model.QuestionTypeSingleChoice, 20, 1)
+	question.Explanation = "Paris has been the capital since 987"
+	question.Options = []*model.QuestionOption{model.NewQuestionOption(question.ID, "Paris", true, 1)}
+
+	if response := QuestionResponseFromModel(question, false); response.Explanation != "" || response.Options[0].IsCorrect {
+		t.Errorf("response without correct answers gives them away: %+v", response)
+	}
+	if response := QuestionResponseFromModel(question, true); response.Explanation != question.Explanation || !response.Options[0].IsCorrect {
+		t.Errorf("response with correct answers = %+v, want the explanation and correct option", response)
+	}
+}
@@ -16,18 +16,48 @@ type QuizCreateRequest struct {
 	Questions   []QuestionCreateData `json:"questions" binding:"required"`
 }
 
+// GuestQuizCreateRequest represents the request to create a quiz without an account
+type GuestQuizCreateRequest struct {
+	QuizCreateRequest
+	HostName string `json:"hostName"`
+}
+
 // QuizUpdateRequest represents the request to update an existing quiz
 type QuizUpdateRequest struct {
 	ID          string               `json:"id" binding:"required"`
 	Title       string               `json:"title" binding:"required"`
 	Description string               `json:"description"`
 	Questions   []QuestionUpdateData `json:"questions"`
+	// Force allows editing question and option text of a quiz that already has recorded answers
+	Force bool `json:"force"`
+}
+
+// QuizSettingsRequest represents the request to update a quiz's settings
+type QuizSettingsRequest struct {
+	AllowLateJoin             bool                      `json:"allowLateJoin"`
+	MaxParticipants           int                       `json:"maxParticipants" binding:"min=0"`
+	Anonymous                 bool                      `json:"anonymous"`
+	RejectDisconnectedAnswers bool                      `json:"rejectDisconnectedAnswers"`
+	ManualAdvance             bool                      `json:"manualAdvance"`
+	DisableTimeBonus          bool                      `json:"disableTimeBonus"`
+	LateAnswerPolicy          model.LateAnswerPolicy    `json:"lateAnswerPolicy" binding:"omitempty,oneof=REJECT ACCEPT_NO_BONUS"`
+	Tiebreak                  model.LeaderboardTiebreak `json:"tiebreak" binding:"omitempty,oneof=TIME JOIN_ORDER RANDOM"`
+	TiebreakSeed              int64                     `json:"tiebreakSeed"`
+	ShuffleOptions            bool                      `json:"shuffleOptions"`
+	ShuffleQuestions          bool                      `json:"shuffleQuestions"`
+	ShuffleSeed               int64                     `json:"shuffleSeed"`
+}
+
+// ParticipantQuizSettings represents the quiz settings participants are allowed to see
+type ParticipantQuizSettings struct {
+	AllowLateJoin bool `json:"allowLateJoin"`
 }
 
 // QuizJoinByCodeRequest represents the request to join a quiz using a code
 type QuizJoinByCodeRequest struct {
-	Code string `json:"code" binding:"required"`
-	Name string `json:"name" binding:"required"`
+	Code   string     `json:"code" binding:"required"`
+	Name   string     `json:"name" binding:"required"`
+	TeamID *uuid.UUID `json:"teamId"`
 }
 
 // OptionData represents an option for updating a question
@@ -40,14 +70,26 @@ type OptionData struct {
 
 // QuizResponse represents a quiz in API responses
 type QuizResponse struct {
-	ID          uuid.UUID `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description,omitempty"`
-	CreatorID   uuid.UUID `json:"creatorId"`
-	Status      string    `json:"status"`
-	Code        string    `json:"code"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          uuid.UUID          `json:"id"`
+	Title       string             `json:"title"`
+	Description string             `json:"description,omitempty"`
+	CreatorID   uuid.UUID          `json:"creatorId"`
+	Status      string             `json:"status"`
+	Code        string             `json:"code"`
+	Settings    model.QuizSettings `json:"settings"`
+	CreatedAt   time.Time          `json:"createdAt"`
+	UpdatedAt   time.Time          `json:"updatedAt"`
+	ArchivedAt  *time.Time         `json:"archivedAt,omitempty"`
+}
+
+// QuizCodeLookupResponse represents the public summary of a quiz looked up by its join code
+type QuizCodeLookupResponse struct {
+	ID               uuid.UUID `json:"id"`
+	Title            string    `json:"title"`
+	Description      string    `json:"description,omitempty"`
+	Status           string    `json:"status"`
+	Code             string    `json:"code"`
+	ParticipantCount int       `json:"participantCount"`
 }
 
 // CreatorResponse represents a quiz creator in API responses
@@ -82,9 +124,42 @@ type QuizAction struct {
 
 // QuizJoinRequest represents the request to join a quiz
 type QuizJoinRequest struct {
+	Name   string     `json:"name" binding:"required"`
+	TeamID *uuid.UUID `json:"teamId"`
+}
+
+// ParticipantKickRequest represents the request to kick a participant
+type ParticipantKickRequest struct {
+	Ban bool `json:"ban"`
+}
+
+// TeamCreateRequest represents the request to add a team to a quiz
+type TeamCreateRequest struct {
 	Name string `json:"name" binding:"required"`
 }
 
+// WebhookRequest represents the request to register a quiz's webhook
+type WebhookRequest struct {
+	URL    string `json:"url" binding:"required"`
+	Secret string `json:"secret" binding:"required,min=16"`
+}
+
+// WebhookResponse represents a quiz's webhook in API responses, without its secret
+type WebhookResponse struct {
+	QuizID    uuid.UUID `json:"quizId"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TeamResponse represents a team in API responses
+type TeamResponse struct {
+	ID        uuid.UUID `json:"id"`
+	QuizID    uuid.UUID `json:"quizId"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
 // QuizResponseFromModel converts a Quiz model to a QuizResponse
 func QuizResponseFromModel(model *model.Quiz) QuizResponse {
 	return QuizResponse{
@@ -94,8 +169,17 @@ func QuizResponseFromModel(model *model.Quiz) QuizResponse {
 		CreatorID:   model.CreatorID,
 		Status:      string(model.Status),
 		Code:        model.Code,
+		Settings:    model.Settings,
 		CreatedAt:   model.CreatedAt,
 		UpdatedAt:   model.UpdatedAt,
+		ArchivedAt:  model.ArchivedAt,
+	}
+}
+
+// ParticipantQuizSettingsFromModel strips the settings participants should not see
+func ParticipantQuizSettingsFromModel(settings model.QuizSettings) ParticipantQuizSettings {
+	return ParticipantQuizSettings{
+		AllowLateJoin: settings.AllowLateJoin,
 	}
 }
 
@@ -107,3 +191,23 @@ func CreatorResponseFromModel(model *model.User) CreatorResponse {
 		Email: model.Email,
 	}
 }
+
+// TeamResponseFromModel converts a Team model to a TeamResponse
+func TeamResponseFromModel(model *model.Team) TeamResponse {
+	return TeamResponse{
+		ID:        model.ID,
+		QuizID:    model.QuizID,
+		Name:      model.Name,
+		CreatedAt: model.CreatedAt,
+	}
+}
+
+// WebhookResponseFromModel converts a QuizWebhook model to a WebhookResponse
+func WebhookResponseFromModel(model *model.QuizWebhook) WebhookResponse {
+	return WebhookResponse{
+		QuizID:    model.QuizID,
+		URL:       model.URL,
+		CreatedAt: model.CreatedAt,
+		UpdatedAt: model.UpdatedAt,
+	}
+}
@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"sort"
 	"time"
 
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
@@ -20,12 +21,22 @@ type QuizStateDTO struct {
 	SequenceNumber int64                          `json:"sequenceNumber"`
 	StartTime      *time.Time                     `json:"startTime,omitempty"`
 	EndTime        *time.Time                     `json:"endTime,omitempty"`
+	// LeaderboardFrozen reports that leaderboard updates are held back until the creator reveals them
+	LeaderboardFrozen bool `json:"leaderboardFrozen,omitempty"`
+
+	// Only set on trimmed state syncs, which omit the participant list
+	Trimmed          bool                  `json:"trimmed,omitempty"`
+	ParticipantCount int                   `json:"participantCount,omitempty"`
+	Leaderboard      []ParticipantStateDTO `json:"leaderboard,omitempty"`
 }
 
 // ActiveQuestionStateDTO represents the state of the currently active question
 type ActiveQuestionStateDTO struct {
 	QuestionID     uuid.UUID                `json:"questionId"`
 	QuestionText   string                   `json:"questionText"`
+	ImageURL       string                   `json:"imageUrl,omitempty"`
+	Explanation    string                   `json:"explanation,omitempty"` // Only set once the question has ended
+	Required       bool                     `json:"required,omitempty"`
 	Options        []QuestionOptionStateDTO `json:"options"`
 	QuestionType   string                   `json:"questionType"`
 	TimeLimit      int                      `json:"timeLimit"`
@@ -38,8 +49,9 @@ type ActiveQuestionStateDTO struct {
 // QuestionOptionStateDTO represents an option for a question
 type QuestionOptionStateDTO struct {
 	ID        uuid.UUID `json:"id"`
+	Label     string    `json:"label"`
 	Text      string    `json:"text"`
-	IsCorrect bool      `json:"isCorrect,omitempty"` // Only visible to creators
+	IsCorrect bool      `json:"isCorrect,omitempty"` // Only visible to creators, or to everyone once results are shown
 }
 
 // TimerStateDTO represents the current timer state for a quiz or question
@@ -47,9 +59,17 @@ type TimerStateDTO struct {
 	StartTime        time.Time `json:"startTime"`
 	DurationSeconds  int       `json:"durationSeconds"`
 	RemainingSeconds int       `json:"remainingSeconds"`
+	EndTime          time.Time `json:"endTime"`
 	IsRunning        bool      `json:"isRunning"`
 }
 
+// QuizTimerDTO represents the lightweight phase and countdown view of a quiz
+type QuizTimerDTO struct {
+	Phase            model.QuizPhase `json:"phase"`
+	RemainingSeconds int             `json:"remainingSeconds"`
+	EndTime          *time.Time      `json:"endTime"`
+}
+
 // ParticipantStateDTO represents the state of a participant in a quiz
 type ParticipantStateDTO struct {
 	ParticipantID     uuid.UUID   `json:"participantId"`
@@ -61,18 +81,40 @@ type ParticipantStateDTO struct {
 	Position          int         `json:"position,omitempty"`
 }
 
-// ToQuizStateDTO converts a quiz model and session to a QuizStateDTO
-func ToQuizStateDTO(quiz *model.Quiz, session *model.QuizSession, participants []*model.Participant, activeQuestion *model.Question, questionCount int) *QuizStateDTO {
+// LobbySnapshotDTO represents the host-oriented view of a quiz lobby
+type LobbySnapshotDTO struct {
+	QuizID           uuid.UUID             `json:"quizId"`
+	Status           string                `json:"status"`
+	ParticipantCount int                   `json:"participantCount"`
+	ConnectedCount   int                   `json:"connectedCount"`
+	Participants     []LobbyParticipantDTO `json:"participants"`
+}
+
+// LobbyParticipantDTO represents a participant in the lobby snapshot
+type LobbyParticipantDTO struct {
+	ParticipantID uuid.UUID  `json:"participantId"`
+	Name          string     `json:"name"`
+	TeamID        *uuid.UUID `json:"teamId,omitempty"`
+	JoinTime      string     `json:"joinTime"`
+	IsConnected   bool       `json:"isConnected"`
+}
+
+// ToQuizStateDTO converts a quiz model and session to a QuizStateDTO. The active question's correct
+// options are only marked when includeCorrectAnswers is set or once its results are shown.
+// includeCorrectAnswers is set for creators, who also see the options in their stored order when
+// the quiz shuffles them for participants.
+func ToQuizStateDTO(quiz *model.Quiz, session *model.QuizSession, participants []*model.Participant, activeQuestion *model.Question, questionCount int, includeCorrectAnswers bool) *QuizStateDTO {
 	state := &QuizStateDTO{
-		QuizID:         quiz.ID,
-		Title:          quiz.Title,
-		Status:         string(quiz.Status),
-		CurrentPhase:   model.QuizPhase(session.CurrentPhase),
-		SequenceNumber: 1, // Default value, should be updated
-		StartTime:      session.StartedAt,
-		EndTime:        session.EndedAt,
-		Participants:   make(map[string]ParticipantStateDTO),
-		ActiveCount:    0,
+		QuizID:            quiz.ID,
+		Title:             quiz.Title,
+		Status:            string(quiz.Status),
+		CurrentPhase:      model.QuizPhase(session.CurrentPhase),
+		SequenceNumber:    1, // Default value, should be updated
+		StartTime:         session.StartedAt,
+		EndTime:           session.EndedAt,
+		LeaderboardFrozen: session.LeaderboardFrozen,
+		Participants:      make(map[string]ParticipantStateDTO),
+		ActiveCount:       0,
 	}
 
 	// Add participants
@@ -88,18 +130,28 @@ func ToQuizStateDTO(quiz *model.Quiz, session *model.QuizSession, participants [
 
 	// Add active question if exists
 	if activeQuestion != nil && session.CurrentQuestionID != nil {
-		options := make([]QuestionOptionStateDTO, len(activeQuestion.Options))
-		for i, opt := range activeQuestion.Options {
+		showResults := session.CurrentPhase == model.QuizPhaseShowingResults
+		questionOptions := activeQuestion.Options
+		if !includeCorrectAnswers {
+			questionOptions = quiz.Settings.ParticipantOptions(activeQuestion)
+		}
+		options := make([]QuestionOptionStateDTO, len(questionOptions))
+		for i, opt := range questionOptions {
 			options[i] = QuestionOptionStateDTO{
-				ID:        opt.ID,
-				Text:      opt.Text,
-				IsCorrect: opt.IsCorrect,
+				ID:    opt.ID,
+				Label: opt.Label(),
+				Text:  opt.Text,
+			}
+			if includeCorrectAnswers || showResults {
+				options[i].IsCorrect = opt.IsCorrect
 			}
 		}
 
 		state.ActiveQuestion = &ActiveQuestionStateDTO{
 			QuestionID:     activeQuestion.ID,
 			QuestionText:   activeQuestion.Text,
+			ImageURL:       activeQuestion.ImageURL,
+			Required:       activeQuestion.Required,
 			Options:        options,
 			QuestionType:   string(activeQuestion.QuestionType),
 			TimeLimit:      activeQuestion.TimeLimit,
@@ -108,22 +160,99 @@ func ToQuizStateDTO(quiz *model.Quiz, session *model.QuizSession, participants [
 			TotalQuestions: questionCount,
 		}
 
+		// The explanation is revealed with the results, not while answers are still open
+		if showResults {
+			state.ActiveQuestion.Explanation = activeQuestion.Explanation
+		}
+
 		// Add timer if question is active
-		if session.CurrentQuestionStartedAt != nil && session.CurrentPhase == model.QuizPhaseQuestionActive {
-			elapsed := time.Since(*session.CurrentQuestionStartedAt).Seconds()
-			remaining := float64(activeQuestion.TimeLimit) - elapsed
-			if remaining < 0 {
-				remaining = 0
-			}
+		state.Timer = NewTimerState(session, activeQuestion)
+	}
 
-			state.Timer = &TimerStateDTO{
-				StartTime:        *session.CurrentQuestionStartedAt,
-				DurationSeconds:  activeQuestion.TimeLimit,
-				RemainingSeconds: int(remaining),
-				IsRunning:        remaining > 0,
-			}
-		}
+	return state
+}
+
+// NewTimerState computes the countdown of the session's active question from its server-side deadline,
+// the same one TIMER_UPDATE counts down to. It returns nil unless a question is active.
+func NewTimerState(session *model.QuizSession, activeQuestion *model.Question) *TimerStateDTO {
+	if activeQuestion == nil || session.CurrentQuestionStartedAt == nil || session.CurrentPhase != model.QuizPhaseQuestionActive {
+		return nil
 	}
 
+	endTime, _ := session.CurrentQuestionDeadline(activeQuestion.TimeLimit)
+	remaining := model.RemainingSeconds(endTime, time.Now())
+
+	return &TimerStateDTO{
+		StartTime:        *session.CurrentQuestionStartedAt,
+		DurationSeconds:  activeQuestion.TimeLimit + session.CurrentQuestionExtraSeconds,
+		RemainingSeconds: remaining,
+		EndTime:          endTime,
+		IsRunning:        remaining > 0,
+	}
+}
+
+// RefreshQuizStateTimer returns a copy of a previously computed state with its countdown recomputed for now
+func RefreshQuizStateTimer(state *QuizStateDTO, now time.Time) *QuizStateDTO {
+	if state.Timer == nil {
+		return state
+	}
+
+	refreshed := *state
+	timer := *state.Timer
+	timer.RemainingSeconds = model.RemainingSeconds(timer.EndTime, now)
+	timer.IsRunning = timer.RemainingSeconds > 0
+	refreshed.Timer = &timer
+
+	return &refreshed
+}
+
+// NewQuizTimer builds the lightweight timer view of a quiz; without an active question it reports
+// the phase with no remaining time
+func NewQuizTimer(session *model.QuizSession, activeQuestion *model.Question) *QuizTimerDTO {
+	timer := &QuizTimerDTO{Phase: session.CurrentPhase}
+
+	if state := NewTimerState(session, activeQuestion); state != nil {
+		timer.RemainingSeconds = state.RemainingSeconds
+		timer.EndTime = &state.EndTime
+	}
+
+	return timer
+}
+
+// StateSyncPayload returns the state to send on connect: the full state, or a trimmed one keeping the
+// topN participants when the quiz has more than threshold participants. A threshold of 0 never trims.
+func StateSyncPayload(state *QuizStateDTO, threshold int, topN int) *QuizStateDTO {
+	if threshold > 0 && len(state.Participants) > threshold {
+		return TrimQuizState(state, topN)
+	}
 	return state
 }
+
+// TrimQuizState returns a copy of the state without the full participant list.
+// It keeps the participant counts, the active question and the topN participants by score.
+func TrimQuizState(state *QuizStateDTO, topN int) *QuizStateDTO {
+	trimmed := *state
+	trimmed.Trimmed = true
+	trimmed.ParticipantCount = len(state.Participants)
+	trimmed.Participants = map[string]ParticipantStateDTO{}
+
+	leaderboard := make([]ParticipantStateDTO, 0, len(state.Participants))
+	for _, p := range state.Participants {
+		leaderboard = append(leaderboard, p)
+	}
+	sort.Slice(leaderboard, func(i, j int) bool {
+		if leaderboard[i].Score != leaderboard[j].Score {
+			return leaderboard[i].Score > leaderboard[j].Score
+		}
+		return leaderboard[i].Nickname < leaderboard[j].Nickname
+	})
+	if topN >= 0 && len(leaderboard) > topN {
+		leaderboard = leaderboard[:topN]
+	}
+	for i := range leaderboard {
+		leaderboard[i].Position = i + 1
+	}
+	trimmed.Leaderboard = leaderboard
+
+	return &trimmed
+}
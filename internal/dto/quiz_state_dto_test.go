@@ -0,0 +1,108 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+// stateWithParticipants builds a quiz state whose participant i scores i points
+func stateWithParticipants(count int) *QuizStateDTO {
+	state := &QuizStateDTO{QuizID: uuid.New(), Participants: make(map[string]ParticipantStateDTO, count)}
+	for i := 0; i < count; i++ {
+		participant := ParticipantStateDTO{ParticipantID: uuid.New(), Nickname: fmt.Sprintf("p%04d", i), Score: i}
+		state.Participants[participant.ParticipantID.String()] = participant
+	}
+	return state
+}
+
+func TestStateSyncPayloadTrimsLargeQuizzes(t *testing.T) {
+	state := stateWithParticipants(1000)
+	state.ActiveQuestion = &ActiveQuestionStateDTO{QuestionID: uuid.New()}
+
+	payload := StateSyncPayload(state, 200, 10)
+
+	if !payload.Trimmed {
+		t.Fatal("state of a quiz above the threshold was not trimmed")
+	}
+	if len(payload.Participants) != 0 {
+		t.Errorf("trimmed state lists %d participants", len(payload.Participants))
+	}
+	if payload.ParticipantCount != 1000 {
+		t.Errorf("trimmed state counts %d participants, want 1000", payload.ParticipantCount)
+	}
+	if payload.ActiveQuestion == nil || payload.ActiveQuestion.QuestionID != state.ActiveQuestion.QuestionID {
+		t.Error("trimmed state lost the active question")
+	}
+	if len(payload.Leaderboard) != 10 {
+		t.Fatalf("trimmed leaderboard has %d entries, want 10", len(payload.Leaderboard))
+	}
+	for i, entry := range payload.Leaderboard {
+		if entry.Score != 999-i || entry.Position != i+1 {
+			t.Errorf("leaderboard entry %d is %q with score %d at position %d", i, entry.Nickname, entry.Score, entry.Position)
+		}
+	}
+	if len(state.Participants) != 1000 {
+		t.Error("trimming changed the computed state")
+	}
+
+	full, _ := json.Marshal(state)
+	trimmed, _ := json.Marshal(payload)
+	if len(trimmed)*10 > len(full) {
+		t.Errorf("trimmed sync is %d bytes, the full one %d", len(trimmed), len(full))
+	}
+}
+
+func TestStateSyncPayloadKeepsSmallQuizzesWhole(t *testing.T) {
+	tests := []struct {
+		name         string
+		participants int
+		threshold    int
+	}{
+		{name: "below threshold", participants: 50, threshold: 200},
+		{name: "at threshold", participants: 200, threshold: 200},
+		{name: "trimming disabled", participants: 1000, threshold: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := stateWithParticipants(tt.participants)
+			payload := StateSyncPayload(state, tt.threshold, 10)
+			if payload.Trimmed || len(payload.Participants) != tt.participants {
+				t.Errorf("got a trimmed state with %d participants, want all %d", len(payload.Participants), tt.participants)
+			}
+		})
+	}
+}
+
+func TestTimerStateCountsDownToTheSessionDeadline(t *testing.T) {
+	question := model.NewQuestion(uuid.New(), "Question", model.QuestionTypeSingleChoice, 30, 1)
+	session := model.NewQuizSession(question.QuizID)
+	startedAt := time.Now().Add(-10*time.Second - 400*time.Millisecond)
+	session.CurrentQuestionID = &question.ID
+	session.CurrentQuestionStartedAt = &startedAt
+	session.CurrentQuestionExtraSeconds = 5
+	session.CurrentPhase = model.QuizPhaseQuestionActive
+
+	timer := NewTimerState(session, question)
+	if timer == nil {
+		t.Fatal("running question has no timer")
+	}
+	deadline, _ := session.CurrentQuestionDeadline(question.TimeLimit)
+	if want := startedAt.Add(35 * time.Second); !deadline.Equal(want) || !timer.EndTime.Equal(want) {
+		t.Errorf("timer ends at %v with the session deadline at %v, want both at %v", timer.EndTime, deadline, want)
+	}
+	// 24.6 seconds are left, which counts as 25 until the last fraction runs out
+	if timer.DurationSeconds != 35 || timer.RemainingSeconds != 25 || !timer.IsRunning {
+		t.Errorf("timer = %+v, want 25 of 35 seconds remaining", timer)
+	}
+
+	startedAt = time.Now().Add(-time.Minute)
+	if timer := NewTimerState(session, question); timer.RemainingSeconds != 0 || timer.IsRunning {
+		t.Errorf("timer past its deadline = %+v, want it stopped at zero", timer)
+	}
+}
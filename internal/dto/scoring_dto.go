@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+// ScoringReportDTO summarizes the points awarded across the questions of a quiz
+type ScoringReportDTO struct {
+	QuizID uuid.UUID `json:"quizId"`
+	ScoringSummaryDTO
+	Questions []QuestionScoringDTO `json:"questions"`
+}
+
+// QuestionScoringDTO summarizes the points awarded for a single question
+type QuestionScoringDTO struct {
+	QuestionID uuid.UUID `json:"questionId"`
+	Order      int       `json:"order"`
+	Voided     bool      `json:"voided"`
+	ScoringSummaryDTO
+}
+
+// ScoringSummaryDTO holds scoring aggregates over a set of answers
+type ScoringSummaryDTO struct {
+	TotalAnswers   int     `json:"totalAnswers"`
+	CorrectAnswers int     `json:"correctAnswers"`
+	AverageScore   float64 `json:"averageScore"`
+	TimeBonusCount int     `json:"timeBonusCount"`
+	TimeBonusRate  float64 `json:"timeBonusRate"`
+	// PointsDistribution counts the answers by the points they were awarded
+	PointsDistribution map[int]int `json:"pointsDistribution"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QuizTimelineDTO represents the chronology of a quiz run reconstructed from its event log
+type QuizTimelineDTO struct {
+	QuizID    uuid.UUID             `json:"quizId"`
+	StartedAt *time.Time            `json:"startedAt,omitempty"`
+	EndedAt   *time.Time            `json:"endedAt,omitempty"`
+	Questions []QuestionTimelineDTO `json:"questions"`
+	Entries   []TimelineEntryDTO    `json:"entries"`
+}
+
+// QuestionTimelineDTO represents when a single question was shown during a quiz run
+type QuestionTimelineDTO struct {
+	QuestionID      uuid.UUID  `json:"questionId"`
+	StartedAt       time.Time  `json:"startedAt"`
+	EndedAt         *time.Time `json:"endedAt,omitempty"`
+	DurationSeconds float64    `json:"durationSeconds,omitempty"`
+	Skipped         bool       `json:"skipped,omitempty"`
+}
+
+// TimelineEntryDTO represents a single lifecycle event in a quiz timeline
+type TimelineEntryDTO struct {
+	SequenceNumber int64      `json:"sequenceNumber"`
+	EventType      string     `json:"eventType"`
+	QuestionID     *uuid.UUID `json:"questionId,omitempty"`
+	Timestamp      time.Time  `json:"timestamp"`
+}
@@ -22,16 +22,17 @@ type UserLoginRequest struct {
 
 // UserResponse represents a user in API responses
 type UserResponse struct {
-	ID    uuid.UUID `json:"id"`
-	Name  string    `json:"name"`
-	Email string    `json:"email"`
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Email   string    `json:"email"`
+	IsGuest bool      `json:"isGuest,omitempty"`
 }
 
 // UserLoginResponse represents the response for user login with token
 type UserLoginResponse struct {
 	User         UserResponse `json:"user"`
 	AccessToken  string       `json:"accessToken"`
-	RefreshToken string       `json:"refreshToken"`
+	RefreshToken string       `json:"refreshToken,omitempty"` // Not issued to guests
 	TokenType    string       `json:"tokenType"`
 	ExpiresIn    int64        `json:"expiresIn"` // Expiration time in seconds
 }
@@ -39,8 +40,9 @@ type UserLoginResponse struct {
 // UserResponseFromModel converts a User model to a UserResponse
 func UserResponseFromModel(model *model.User) UserResponse {
 	return UserResponse{
-		ID:    model.ID,
-		Name:  model.Name,
-		Email: model.Email,
+		ID:      model.ID,
+		Name:    model.Name,
+		Email:   model.Email,
+		IsGuest: model.IsGuest,
 	}
 }
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AdminHandler handles operator maintenance requests
+type AdminHandler struct {
+	adminService service.AdminService
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(adminService service.AdminService) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+	}
+}
+
+// GetOrphanedOptions lists question options whose question no longer exists
+func (h *AdminHandler) GetOrphanedOptions(c *gin.Context) {
+	options, err := h.adminService.FindOrphanedOptions(c)
+	if err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to check options", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, response.MessageListFetched, map[string]interface{}{
+		"options": options,
+		"count":   len(options),
+	})
+}
+
+// DeleteOrphanedOptions deletes question options whose question no longer exists
+func (h *AdminHandler) DeleteOrphanedOptions(c *gin.Context) {
+	deleted, err := h.adminService.CleanupOrphanedOptions(c)
+	if err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to delete orphaned options", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, response.MessageDeleted, map[string]interface{}{
+		"deleted": deleted,
+	})
+}
+
+// GetStaleQuizzes lists active quizzes without activity for longer than the olderThan duration,
+// or the configured threshold when it is omitted
+func (h *AdminHandler) GetStaleQuizzes(c *gin.Context) {
+	var threshold time.Duration
+	if raw := c.Query("olderThan"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			response.WithError(c, http.StatusBadRequest, "Invalid olderThan", "olderThan must be a positive duration such as 30m or 2h")
+			return
+		}
+		threshold = parsed
+	}
+
+	quizzes, err := h.adminService.FindStaleQuizzes(c, threshold)
+	if err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to find stale quizzes", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, response.MessageListFetched, map[string]interface{}{
+		"quizzes": quizzes,
+		"count":   len(quizzes),
+	})
+}
+
+// TerminateQuiz ends an active quiz
+func (h *AdminHandler) TerminateQuiz(c *gin.Context) {
+	quizID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", err.Error())
+		return
+	}
+
+	if err := h.adminService.TerminateQuiz(c, quizID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrQuizNotFound):
+			response.WithError(c, http.StatusNotFound, "Quiz not found", err.Error())
+		case errors.Is(err, service.ErrQuizNotActive):
+			response.WithError(c, http.StatusConflict, "Quiz is not active", err.Error())
+		default:
+			response.WithError(c, http.StatusInternalServerError, "Failed to terminate quiz", err.Error())
+		}
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, "Quiz terminated successfully", nil)
+}
@@ -1,11 +1,14 @@
 package handler
 
 import (
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/middleware"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/logger"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/response"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -14,12 +17,14 @@ import (
 // AnswerHandler handles answer-related HTTP requests
 type AnswerHandler struct {
 	answerService service.AnswerService
+	logger        *slog.Logger
 }
 
 // NewAnswerHandler creates a new answer handler
-func NewAnswerHandler(answerService service.AnswerService) *AnswerHandler {
+func NewAnswerHandler(answerService service.AnswerService, log *slog.Logger) *AnswerHandler {
 	return &AnswerHandler{
 		answerService: answerService,
+		logger:        logger.OrDefault(log),
 	}
 }
 
@@ -35,7 +40,7 @@ func (h *AnswerHandler) SubmitAnswer(c *gin.Context) {
 	// Parse participant ID from the request
 	participantID, err := uuid.Parse(request.ParticipantID)
 	if err != nil {
-		log.Printf("Error parsing participant ID: %v\n", err)
+		h.logger.Warn("Error parsing participant ID", "participantId", request.ParticipantID, "error", err)
 		response.WithError(c, http.StatusBadRequest, "Invalid participant ID", "The provided participant ID is not valid")
 		return
 	}
@@ -43,15 +48,20 @@ func (h *AnswerHandler) SubmitAnswer(c *gin.Context) {
 	// Parse question ID from the request
 	questionID, err := uuid.Parse(request.QuestionID)
 	if err != nil {
-		log.Printf("Error parsing question ID: %v\n", err)
+		h.logger.Warn("Error parsing question ID", "questionId", request.QuestionID, "error", err)
 		response.WithError(c, http.StatusBadRequest, "Invalid question ID", "The provided question ID is not valid")
 		return
 	}
 
 	// Submit the answer
-	answer, err := h.answerService.SubmitAnswer(c, participantID, questionID, request.SelectedOptions)
+	answer, err := h.answerService.Submit(c, participantID, questionID, request.SelectedOptions, request.ClientToken)
 	if err != nil {
-		log.Printf("Error submitting answer: %v\n", err)
+		h.logger.Warn("Error submitting answer", "participantId", participantID, "questionId", questionID, "error", err)
+		if errors.Is(err, service.ErrNotConnected) || errors.Is(err, service.ErrAnswerTooLate) ||
+			errors.Is(err, service.ErrQuestionNotActive) {
+			response.WithError(c, http.StatusConflict, "Failed to submit answer", err.Error())
+			return
+		}
 		response.WithError(c, http.StatusBadRequest, "Failed to submit answer", err.Error())
 		return
 	}
@@ -59,7 +69,7 @@ func (h *AnswerHandler) SubmitAnswer(c *gin.Context) {
 	// Create a response using the updated DTO
 	answerResponse, err := dto.AnswerResponseFromModel(answer)
 	if err != nil {
-		log.Printf("Error processing answer data: %v\n", err)
+		h.logger.Error("Error processing answer data", "answerId", answer.ID, "error", err)
 		response.WithError(c, http.StatusInternalServerError, "Failed to process answer data", err.Error())
 		return
 	}
@@ -78,8 +88,15 @@ func (h *AnswerHandler) GetAnswerStats(c *gin.Context) {
 		return
 	}
 
-	stats, err := h.answerService.GetAnswerStats(c, questionID)
+	// Anonymous requests are treated as participants
+	userID := middleware.GetAuthUserID(c)
+
+	stats, err := h.answerService.GetAnswerStatsForUser(c, questionID, userID)
 	if err != nil {
+		if errors.Is(err, service.ErrStatsNotAvailable) {
+			response.WithError(c, http.StatusForbidden, "Access denied", err.Error())
+			return
+		}
 		response.WithError(c, http.StatusInternalServerError, "Failed to retrieve answer statistics", err.Error())
 		return
 	}
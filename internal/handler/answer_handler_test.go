@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// submitCall is a call to stubAnswerService.Submit
+type submitCall struct {
+	participantID   uuid.UUID
+	questionID      uuid.UUID
+	selectedOptions []string
+	clientToken     string
+}
+
+// stubAnswerService records Submit calls; its other AnswerService methods are not implemented
+type stubAnswerService struct {
+	service.AnswerService
+	calls []submitCall
+	err   error
+}
+
+func (s *stubAnswerService) Submit(ctx context.Context, participantID uuid.UUID, questionID uuid.UUID, selectedOptions []string, clientToken string) (*model.Answer, error) {
+	s.calls = append(s.calls, submitCall{participantID, questionID, selectedOptions, clientToken})
+	if s.err != nil {
+		return nil, s.err
+	}
+	return model.NewAnswer(participantID, questionID, selectedOptions, 2, true)
+}
+
+// postAnswer sends an answer submission to the handler and returns the response
+func postAnswer(t *testing.T, answers service.AnswerService, body map[string]interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/answers", NewAnswerHandler(answers, nil).SubmitAnswer)
+
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("marshal body: %v", err)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/answers", bytes.NewReader(raw)))
+	return recorder
+}
+
+func TestSubmitAnswerGoesThroughAnswerServiceSubmit(t *testing.T) {
+	answers := &stubAnswerService{}
+	participantID, questionID, optionID := uuid.New(), uuid.New(), uuid.New().String()
+
+	recorder := postAnswer(t, answers, map[string]interface{}{
+		"participantId":   participantID.String(),
+		"questionId":      questionID.String(),
+		"selectedOptions": []string{optionID},
+		"timeTaken":       2,
+		"clientToken":     "retry-1",
+	})
+
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("submission returned %d: %s", recorder.Code, recorder.Body)
+	}
+	if len(answers.calls) != 1 {
+		t.Fatalf("Submit was called %d times, want once", len(answers.calls))
+	}
+	call := answers.calls[0]
+	if call.participantID != participantID || call.questionID != questionID ||
+		len(call.selectedOptions) != 1 || call.selectedOptions[0] != optionID || call.clientToken != "retry-1" {
+		t.Errorf("Submit was called with %+v", call)
+	}
+}
+
+func TestSubmitAnswerMapsSubmitErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: service.ErrQuestionNotActive, want: http.StatusConflict},
+		{err: service.ErrAnswerTooLate, want: http.StatusConflict},
+		{err: service.ErrNotConnected, want: http.StatusConflict},
+		{err: service.ErrInvalidOption, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			recorder := postAnswer(t, &stubAnswerService{err: tt.err}, map[string]interface{}{
+				"participantId":   uuid.New().String(),
+				"questionId":      uuid.New().String(),
+				"selectedOptions": []string{uuid.New().String()},
+				"timeTaken":       1,
+			})
+			if recorder.Code != tt.want {
+				t.Errorf("submission returned %d, want %d", recorder.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/repository"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/response"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// readinessTimeout bounds each dependency check so probes don't hang
+const readinessTimeout = 2 * time.Second
+
+// HealthHandler handles liveness and readiness probes
+type HealthHandler struct {
+	db          *repository.DB
+	redisClient *redis.Client
+	wsHub       websocket.QuizHub
+}
+
+// NewHealthHandler creates a new health handler; redisClient is nil when the in-memory hub is used
+func NewHealthHandler(db *repository.DB, redisClient *redis.Client, wsHub websocket.QuizHub) *HealthHandler {
+	return &HealthHandler{
+		db:          db,
+		redisClient: redisClient,
+		wsHub:       wsHub,
+	}
+}
+
+// Liveness reports that the process is up
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	response.WithSuccess(c, http.StatusOK, "OK", map[string]string{
+		"status": "ok",
+	})
+}
+
+// Readiness reports whether the service's dependencies are reachable
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := map[string]string{
+		"postgres": "ok",
+		"redis":    "ok",
+		"pubsub":   "ok",
+	}
+	var failing []string
+
+	if err := h.db.PingContext(ctx); err != nil {
+		checks["postgres"] = err.Error()
+		failing = append(failing, "postgres")
+	}
+
+	if h.redisClient == nil {
+		// The in-memory hub runs without Redis
+		checks["redis"] = "disabled"
+		checks["pubsub"] = "disabled"
+	} else if err := h.redisClient.Ping(ctx).Err(); err != nil {
+		checks["redis"] = err.Error()
+		failing = append(failing, "redis")
+		checks["pubsub"] = "skipped: redis unreachable"
+	} else if err := h.wsHub.SelfTest(ctx); err != nil {
+		// Redis answers but real-time events would not reach other instances
+		checks["pubsub"] = err.Error()
+		failing = append(failing, "pubsub")
+	}
+
+	if len(failing) > 0 {
+		body := response.NewErrorResponse("Service not ready", "unavailable: "+strings.Join(failing, ", "))
+		body.Data = checks
+		c.JSON(http.StatusServiceUnavailable, body)
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, "Ready", checks)
+}
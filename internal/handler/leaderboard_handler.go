@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/response"
@@ -13,18 +14,24 @@ import (
 
 // LeaderboardHandler handles leaderboard-related HTTP requests
 type LeaderboardHandler struct {
-	leaderboardService service.LeaderboardService
-	quizService        service.QuizService
+	leaderboardService     service.LeaderboardService
+	teamLeaderboardService service.TeamLeaderboardService
+	quizService            service.QuizService
+	maxLimit               int
 }
 
 // NewLeaderboardHandler creates a new leaderboard handler
 func NewLeaderboardHandler(
 	leaderboardService service.LeaderboardService,
+	teamLeaderboardService service.TeamLeaderboardService,
 	quizService service.QuizService,
+	cfg config.QuizConfig,
 ) *LeaderboardHandler {
 	return &LeaderboardHandler{
-		leaderboardService: leaderboardService,
-		quizService:        quizService,
+		leaderboardService:     leaderboardService,
+		teamLeaderboardService: teamLeaderboardService,
+		quizService:            quizService,
+		maxLimit:               cfg.MaxLeaderboardLimit,
 	}
 }
 
@@ -39,45 +46,88 @@ func (h *LeaderboardHandler) GetLeaderboard(c *gin.Context) {
 		return
 	}
 
-	// Get limit parameter if provided, default to 10
+	// Check the quiz exists before querying its leaderboard
+	quiz, err := h.quizService.GetQuiz(c, quizID)
+	if err != nil {
+		response.WithError(c, http.StatusNotFound, "Quiz not found", "The specified quiz could not be found")
+		return
+	}
+
+	// Get limit parameter if provided, default to 10; larger pages than the configured maximum are capped
 	limitStr := c.DefaultQuery("limit", "10")
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil || limit <= 0 {
-		limit = 10
+		response.WithError(c, http.StatusBadRequest, "Invalid limit", "limit must be a positive number")
+		return
+	}
+	if h.maxLimit > 0 && limit > h.maxLimit {
+		limit = h.maxLimit
 	}
 	request.Limit = limit
 
-	participants, err := h.leaderboardService.GetLeaderboard(c, quizID, limit)
+	// Get offset parameter if provided, default to 0; it must start a page so the reported page matches the rows
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		response.WithError(c, http.StatusBadRequest, "Invalid offset", "offset must be a non-negative number")
+		return
+	}
+	if offset%limit != 0 {
+		response.WithError(c, http.StatusBadRequest, "Invalid offset", "offset must be a multiple of limit ("+strconv.Itoa(limit)+")")
+		return
+	}
+	request.Offset = offset
+
+	participants, err := h.leaderboardService.GetLeaderboard(c, quizID, limit, offset)
 	if err != nil {
 		response.WithError(c, http.StatusInternalServerError, "Failed to get leaderboard", err.Error())
 		return
 	}
 
-	// Get quiz information for the response
-	quiz, err := h.quizService.GetQuiz(c, quizID)
+	total, err := h.leaderboardService.CountParticipants(c, quizID)
 	if err != nil {
-		response.WithError(c, http.StatusNotFound, "Quiz not found", "The specified quiz could not be found")
+		response.WithError(c, http.StatusInternalServerError, "Failed to get leaderboard", err.Error())
 		return
 	}
 
-	// Format response using the DTO
-	var entries []dto.LeaderboardEntry
-	for i, participant := range participants {
-		entries = append(entries, dto.LeaderboardEntry{
-			Rank:     i + 1,
-			ID:       participant.ID,
-			Name:     participant.Name,
-			Score:    participant.Score,
-			JoinedAt: participant.JoinedAt,
-		})
-	}
+	// Format response using the DTO; entries tied on score and answer time share a rank and the next rank skips
+	entries := dto.LeaderboardEntriesFromModel(participants)
 
 	leaderboardResponse := dto.LeaderboardResponse{
 		QuizID:       quizID,
 		QuizTitle:    quiz.Title,
-		TotalPlayers: len(participants),
+		TotalPlayers: total,
 		Leaderboard:  entries,
 	}
 
-	response.WithSuccess(c, http.StatusOK, "Leaderboard fetched successfully", leaderboardResponse)
+	response.WithPagination(c, "Leaderboard fetched successfully", leaderboardResponse, total, limit, offset/limit+1)
+}
+
+// GetTeamLeaderboard retrieves the team standings for a quiz played in team mode
+func (h *LeaderboardHandler) GetTeamLeaderboard(c *gin.Context) {
+	quizID, err := uuid.Parse(c.Param("quizId"))
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get quiz information for the response
+	quiz, err := h.quizService.GetQuiz(c, quizID)
+	if err != nil {
+		response.WithError(c, http.StatusNotFound, "Quiz not found", "The specified quiz could not be found")
+		return
+	}
+
+	teams, err := h.teamLeaderboardService.GetTeamLeaderboard(c, quizID)
+	if err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to get team leaderboard", err.Error())
+		return
+	}
+
+	teamLeaderboardResponse := dto.TeamLeaderboardResponse{
+		QuizID:    quizID,
+		QuizTitle: quiz.Title,
+		Teams:     teams,
+	}
+
+	response.WithSuccess(c, http.StatusOK, "Team leaderboard fetched successfully", teamLeaderboardResponse)
 }
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// stubLeaderboardService records the page requested; its other LeaderboardService methods are not implemented
+type stubLeaderboardService struct {
+	service.LeaderboardService
+	called bool
+	limit  int
+	offset int
+}
+
+func (s *stubLeaderboardService) GetLeaderboard(ctx context.Context, quizID uuid.UUID, limit int, offset int) ([]*model.Participant, error) {
+	s.called = true
+	s.limit = limit
+	s.offset = offset
+	return nil, nil
+}
+
+func (s *stubLeaderboardService) CountParticipants(ctx context.Context, quizID uuid.UUID) (int, error) {
+	s.called = true
+	return 500, nil
+}
+
+// getLeaderboard serves a leaderboard request for the given path with a maximum page size of 100
+func getLeaderboard(leaderboard *stubLeaderboardService, quizzes *stubQuizService, path string) *httptest.ResponseRecorder {
+	handler := NewLeaderboardHandler(leaderboard, nil, quizzes, config.QuizConfig{MaxLeaderboardLimit: 100})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/leaderboard/:quizId", handler.GetLeaderboard)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	return recorder
+}
+
+func TestGetLeaderboardLimit(t *testing.T) {
+	quiz := model.NewQuiz("Quiz", "", uuid.New())
+	quizzes := &stubQuizService{quizzes: map[uuid.UUID]*model.Quiz{quiz.ID: quiz}}
+
+	tests := []struct {
+		name      string
+		limit     string
+		wantCode  int
+		wantLimit int
+	}{
+		{name: "default", limit: "", wantCode: http.StatusOK, wantLimit: 10},
+		{name: "within the maximum", limit: "50", wantCode: http.StatusOK, wantLimit: 50},
+		{name: "at the maximum", limit: "100", wantCode: http.StatusOK, wantLimit: 100},
+		{name: "over the maximum", limit: "100000", wantCode: http.StatusOK, wantLimit: 100},
+		{name: "zero", limit: "0", wantCode: http.StatusBadRequest},
+		{name: "negative", limit: "-5", wantCode: http.StatusBadRequest},
+		{name: "not a number", limit: "all", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaderboard := &stubLeaderboardService{}
+			path := "/leaderboard/" + quiz.ID.String()
+			if tt.limit != "" {
+				path += "?limit=" + tt.limit
+			}
+			recorder := getLeaderboard(leaderboard, quizzes, path)
+
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("limit %q returned %d, want %d: %s", tt.limit, recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			if leaderboard.limit != tt.wantLimit {
+				t.Errorf("fetched %d entries, want %d", leaderboard.limit, tt.wantLimit)
+			}
+
+			// The response reports the limit actually applied
+			var body struct {
+				Pagination struct {
+					PerPage int `json:"perPage"`
+				} `json:"pagination"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if body.Pagination.PerPage != tt.wantLimit {
+				t.Errorf("response reports a limit of %d, want %d", body.Pagination.PerPage, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestGetLeaderboardOffset(t *testing.T) {
+	quiz := model.NewQuiz("Quiz", "", uuid.New())
+	quizzes := &stubQuizService{quizzes: map[uuid.UUID]*model.Quiz{quiz.ID: quiz}}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantCode   int
+		wantOffset int
+		wantPage   int
+	}{
+		{name: "default", query: "", wantCode: http.StatusOK, wantOffset: 0, wantPage: 1},
+		{name: "second page", query: "?limit=10&offset=10", wantCode: http.StatusOK, wantOffset: 10, wantPage: 2},
+		{name: "page of the capped limit", query: "?limit=1000&offset=300", wantCode: http.StatusOK, wantOffset: 300, wantPage: 4},
+		{name: "inside a page", query: "?limit=10&offset=5", wantCode: http.StatusBadRequest},
+		{name: "negative", query: "?offset=-10", wantCode: http.StatusBadRequest},
+		{name: "not a number", query: "?offset=next", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaderboard := &stubLeaderboardService{}
+			recorder := getLeaderboard(leaderboard, quizzes, "/leaderboard/"+quiz.ID.String()+tt.query)
+
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("%q returned %d, want %d: %s", tt.query, recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				if leaderboard.called {
+					t.Error("leaderboard was queried for a rejected offset")
+				}
+				return
+			}
+			if leaderboard.offset != tt.wantOffset {
+				t.Errorf("fetched from offset %d, want %d", leaderboard.offset, tt.wantOffset)
+			}
+
+			var body struct {
+				Pagination struct {
+					CurrentPage int `json:"currentPage"`
+				} `json:"pagination"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if body.Pagination.CurrentPage != tt.wantPage {
+				t.Errorf("response reports page %d, want %d", body.Pagination.CurrentPage, tt.wantPage)
+			}
+		})
+	}
+}
+
+func TestGetLeaderboardOfAMissingQuizSkipsTheQueries(t *testing.T) {
+	leaderboard := &stubLeaderboardService{}
+	quizzes := &stubQuizService{quizzes: map[uuid.UUID]*model.Quiz{}}
+
+	recorder := getLeaderboard(leaderboard, quizzes, "/leaderboard/"+uuid.NewString())
+
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("missing quiz returned %d, want %d: %s", recorder.Code, http.StatusNotFound, recorder.Body)
+	}
+	if leaderboard.called {
+		t.Error("leaderboard was queried for a quiz that does not exist")
+	}
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// requireQuizOwner loads a quiz and checks that userID created it, writing the error response otherwise.
+// A quiz owned by someone else gets the same 404 as a missing one, so callers cannot use
+// creator-only endpoints to probe which quiz IDs exist.
+func requireQuizOwner(c *gin.Context, quizService service.QuizService, quizID uuid.UUID, userID uuid.UUID) (*model.Quiz, bool) {
+	quiz, err := quizService.GetQuiz(c, quizID)
+	if err != nil || quiz.CreatorID != userID {
+		response.WithError(c, http.StatusNotFound, "Quiz not found", service.ErrQuizNotFound.Error())
+		return nil, false
+	}
+
+	return quiz, true
+}
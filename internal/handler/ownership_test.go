@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/middleware"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// stubQuizService serves fixed quizzes and records started ones; its other QuizService methods are not implemented
+type stubQuizService struct {
+	service.QuizService
+	quizzes map[uuid.UUID]*model.Quiz
+	started []uuid.UUID
+}
+
+func (s *stubQuizService) GetQuiz(ctx context.Context, id uuid.UUID) (*model.Quiz, error) {
+	quiz, ok := s.quizzes[id]
+	if !ok {
+		return nil, service.ErrQuizNotFound
+	}
+	return quiz, nil
+}
+
+func (s *stubQuizService) GetQuizByCode(ctx context.Context, code string) (*model.Quiz, error) {
+	for _, quiz := range s.quizzes {
+		if quiz.Code == code {
+			return quiz, nil
+		}
+	}
+	return nil, service.ErrQuizNotFound
+}
+
+func (s *stubQuizService) StartQuiz(ctx context.Context, id uuid.UUID) error {
+	s.started = append(s.started, id)
+	return nil
+}
+
+func (s *stubQuestionService) GetQuestion(ctx context.Context, id uuid.UUID) (*model.Question, error) {
+	question, ok := s.questions[id]
+	if !ok {
+		return nil, service.ErrQuestionNotFound
+	}
+	return question, nil
+}
+
+func (s *stubQuestionService) StartQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID, force bool) error {
+	s.started = append(s.started, questionID)
+	return nil
+}
+
+// errorBody is the part of an error response that does not change between requests
+type errorBody struct {
+	Message string `json:"message"`
+	Error   string `json:"error"`
+}
+
+// serveAs sends a request to handler authenticated as userID and returns the response
+func serveAs(t *testing.T, userID uuid.UUID, method, route, path string, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Handle(method, route, func(c *gin.Context) {
+		c.Set(middleware.AuthUserKey, &model.User{ID: userID})
+	}, handler)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+	return recorder
+}
+
+func decodeErrorBody(t *testing.T, recorder *httptest.ResponseRecorder) errorBody {
+	t.Helper()
+
+	var body errorBody
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return body
+}
+
+func TestQuizOwnershipChecksDoNotRevealOtherCreatorsQuizzes(t *testing.T) {
+	owner, stranger := uuid.New(), uuid.New()
+	quiz := model.NewQuiz("Owned", "", owner)
+	quizzes := &stubQuizService{quizzes: map[uuid.UUID]*model.Quiz{quiz.ID: quiz}}
+	handler := (&QuizHandler{quizService: quizzes}).StartQuiz
+
+	missing := serveAs(t, owner, http.MethodPost, "/quizzes/:id/start", "/quizzes/"+uuid.New().String()+"/start", handler)
+	foreign := serveAs(t, stranger, http.MethodPost, "/quizzes/:id/start", "/quizzes/"+quiz.ID.String()+"/start", handler)
+
+	if missing.Code != http.StatusNotFound || foreign.Code != http.StatusNotFound {
+		t.Fatalf("missing quiz returned %d and another creator's quiz %d, want 404 for both", missing.Code, foreign.Code)
+	}
+	if decodeErrorBody(t, missing) != decodeErrorBody(t, foreign) {
+		t.Errorf("responses differ: %s vs %s", missing.Body, foreign.Body)
+	}
+	if len(quizzes.started) != 0 {
+		t.Error("a quiz was started by someone who does not own it")
+	}
+
+	if owned := serveAs(t, owner, http.MethodPost, "/quizzes/:id/start", "/quizzes/"+quiz.ID.String()+"/start", handler); owned.Code != http.StatusOK {
+		t.Errorf("owner starting their quiz got %d: %s", owned.Code, owned.Body)
+	}
+}
+
+func TestQuestionOwnershipChecksAnswerNotFound(t *testing.T) {
+	owner, stranger := uuid.New(), uuid.New()
+	quiz := model.NewQuiz("Owned", "", owner)
+	question := model.NewQuestion(quiz.ID, "Question", model.QuestionTypeSingleChoice, 30, 1)
+	questions := &stubQuestionService{questions: map[uuid.UUID]*model.Question{question.ID: question}}
+	quizzes := &stubQuizService{quizzes: map[uuid.UUID]*model.Quiz{quiz.ID: quiz}}
+	handler := NewQuestionHandler(questions, quizzes).StartQuestion
+
+	missing := serveAs(t, owner, http.MethodPost, "/questions/:id/start", "/questions/"+uuid.New().String()+"/start", handler)
+	foreign := serveAs(t, stranger, http.MethodPost, "/questions/:id/start", "/questions/"+question.ID.String()+"/start", handler)
+
+	if missing.Code != http.StatusNotFound || foreign.Code != http.StatusNotFound {
+		t.Errorf("missing question returned %d and another creator's question %d, want 404 for both", missing.Code, foreign.Code)
+	}
+	if len(questions.started) != 0 {
+		t.Error("a question was started by someone who does not own its quiz")
+	}
+}
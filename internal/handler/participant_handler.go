@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/middleware"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/response"
 	"github.com/gin-gonic/gin"
@@ -103,7 +105,7 @@ func (h *ParticipantHandler) RemoveParticipant(c *gin.Context) {
 		return
 	}
 
-	if quiz.Status != "waiting" {
+	if quiz.Status != model.QuizStatusWaiting {
 		response.WithError(c, http.StatusBadRequest, "Cannot remove participant", "Participants cannot be removed once the quiz has started")
 		return
 	}
@@ -117,3 +119,50 @@ func (h *ParticipantHandler) RemoveParticipant(c *gin.Context) {
 
 	response.WithSuccess(c, http.StatusOK, "Participant successfully removed", nil)
 }
+
+// KickParticipant removes a participant at any point of a quiz, optionally banning them
+func (h *ParticipantHandler) KickParticipant(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid participant ID", "The provided participant ID is not valid")
+		return
+	}
+
+	// The ban flag is optional, so an empty body kicks without banning
+	var request dto.ParticipantKickRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			response.WithError(c, http.StatusBadRequest, "Invalid request data", err.Error())
+			return
+		}
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	participant, err := h.participantService.GetParticipantByID(c, id)
+	if err != nil {
+		response.WithError(c, http.StatusNotFound, "Participant not found", err.Error())
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, participant.QuizID, userID); !ok {
+		return
+	}
+
+	if err := h.participantService.KickParticipant(c, id, request.Ban); err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to kick participant", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, "Participant successfully kicked", map[string]interface{}{
+		"participantId": id,
+		"banned":        request.Ban,
+	})
+}
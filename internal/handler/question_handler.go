@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/middleware"
@@ -50,15 +52,7 @@ func (h *QuestionHandler) AddQuestion(c *gin.Context) {
 	}
 
 	// Verify quiz ownership
-	quiz, err := h.quizService.GetQuiz(c, quizID)
-	if err != nil {
-		response.WithError(c, http.StatusNotFound, "Quiz not found", err.Error())
-		return
-	}
-
-	// Check if the authenticated user is the quiz creator
-	if quiz.CreatorID != userID {
-		response.WithError(c, http.StatusForbidden, "Access denied", "Only the quiz creator can add questions")
+	if _, ok := requireQuizOwner(c, h.quizService, quizID, userID); !ok {
 		return
 	}
 
@@ -70,8 +64,15 @@ func (h *QuestionHandler) AddQuestion(c *gin.Context) {
 		request.Options,
 		request.QuestionType,
 		request.TimeLimit,
+		request.ImageURL,
+		request.Explanation,
+		request.Required,
 	)
 	if err != nil {
+		if errors.Is(err, service.ErrQuizHasAnswers) {
+			response.WithError(c, http.StatusConflict, "Failed to create question", err.Error())
+			return
+		}
 		response.WithError(c, http.StatusBadRequest, "Failed to create question", err.Error())
 		return
 	}
@@ -91,7 +92,17 @@ func (h *QuestionHandler) GetQuestions(c *gin.Context) {
 		return
 	}
 
-	questions, err := h.questionService.GetQuestions(c, quizID)
+	// Editors use the canonical creator order; replay tools can ask for the order of the run
+	var questions []*model.Question
+	switch c.DefaultQuery("order", "canonical") {
+	case "canonical":
+		questions, err = h.questionService.GetQuestions(c, quizID)
+	case "run":
+		questions, err = h.questionService.GetQuestionsInRunOrder(c, quizID)
+	default:
+		response.WithError(c, http.StatusBadRequest, "Invalid order", "order must be 'canonical' or 'run'")
+		return
+	}
 	if err != nil {
 		response.WithError(c, http.StatusInternalServerError, "Failed to retrieve questions", err.Error())
 		return
@@ -128,8 +139,9 @@ func (h *QuestionHandler) GetQuestion(c *gin.Context) {
 	})
 }
 
-// StartQuestion begins a question in a quiz
-func (h *QuestionHandler) StartQuestion(c *gin.Context) {
+// PreviewQuestion returns a question with its correct answers to the quiz's creator and shows it on their
+// other screens, without starting it or notifying participants
+func (h *QuestionHandler) PreviewQuestion(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
@@ -152,20 +164,62 @@ func (h *QuestionHandler) StartQuestion(c *gin.Context) {
 	}
 
 	// Verify quiz ownership
-	quiz, err := h.quizService.GetQuiz(c, question.QuizID)
+	if _, ok := requireQuizOwner(c, h.quizService, question.QuizID, userID); !ok {
+		return
+	}
+
+	preview, err := h.questionService.PreviewQuestion(c, question.QuizID, id)
+	if err != nil {
+		if errors.Is(err, service.ErrQuestionNotFound) {
+			response.WithError(c, http.StatusNotFound, "Question not found", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusInternalServerError, "Failed to preview question", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, response.MessageFetched, map[string]interface{}{
+		"question": dto.QuestionResponseFromModel(preview, true),
+	})
+}
+
+// StartQuestion begins a question in a quiz
+func (h *QuestionHandler) StartQuestion(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid question ID", "The provided question ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Get the question to determine quiz ID
+	question, err := h.questionService.GetQuestion(c, id)
 	if err != nil {
-		response.WithError(c, http.StatusNotFound, "Quiz not found", err.Error())
+		response.WithError(c, http.StatusNotFound, "Question not found", err.Error())
 		return
 	}
 
-	// Check if the authenticated user is the quiz creator
-	if quiz.CreatorID != userID {
-		response.WithError(c, http.StatusForbidden, "Access denied", "Only the quiz creator can start questions")
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, question.QuizID, userID); !ok {
 		return
 	}
 
+	// Restarting a question that already ran must be requested explicitly with ?force=true
+	force := c.Query("force") == "true"
+
 	// Start the question
-	if err := h.questionService.StartQuestion(c, question.QuizID, id); err != nil {
+	if err := h.questionService.StartQuestion(c, question.QuizID, id, force); err != nil {
+		if errors.Is(err, service.ErrQuestionAlreadyRun) {
+			response.WithError(c, http.StatusConflict, "Failed to start question", err.Error())
+			return
+		}
 		response.WithError(c, http.StatusBadRequest, "Failed to start question", err.Error())
 		return
 	}
@@ -200,15 +254,7 @@ func (h *QuestionHandler) EndQuestion(c *gin.Context) {
 	}
 
 	// Verify quiz ownership
-	quiz, err := h.quizService.GetQuiz(c, question.QuizID)
-	if err != nil {
-		response.WithError(c, http.StatusNotFound, "Quiz not found", err.Error())
-		return
-	}
-
-	// Check if the authenticated user is the quiz creator
-	if quiz.CreatorID != userID {
-		response.WithError(c, http.StatusForbidden, "Access denied", "Only the quiz creator can end questions")
+	if _, ok := requireQuizOwner(c, h.quizService, question.QuizID, userID); !ok {
 		return
 	}
 
@@ -224,6 +270,147 @@ func (h *QuestionHandler) EndQuestion(c *gin.Context) {
 	response.WithSuccess(c, http.StatusOK, "Question ended successfully", questionAction)
 }
 
+// SkipQuestion ends the running question without revealing its answers and moves to the next one
+func (h *QuestionHandler) SkipQuestion(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid question ID", "The provided question ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Get the question to determine quiz ID
+	question, err := h.questionService.GetQuestion(c, id)
+	if err != nil {
+		response.WithError(c, http.StatusNotFound, "Question not found", err.Error())
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, question.QuizID, userID); !ok {
+		return
+	}
+
+	if err := h.questionService.SkipQuestion(c, question.QuizID, id); err != nil {
+		if errors.Is(err, service.ErrQuestionNotActive) || errors.Is(err, service.ErrQuizNotActive) {
+			response.WithError(c, http.StatusConflict, "Failed to skip question", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusBadRequest, "Failed to skip question", err.Error())
+		return
+	}
+
+	questionAction := dto.QuestionAction{
+		Message: "Question skipped successfully",
+	}
+	response.WithSuccess(c, http.StatusOK, "Question skipped successfully", questionAction)
+}
+
+// ExtendQuestion adds time to a question while it is running
+func (h *QuestionHandler) ExtendQuestion(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid question ID", "The provided question ID is not valid")
+		return
+	}
+
+	var request dto.QuestionExtendRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid request data", err.Error())
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Get the question to determine quiz ID
+	question, err := h.questionService.GetQuestion(c, id)
+	if err != nil {
+		response.WithError(c, http.StatusNotFound, "Question not found", err.Error())
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, question.QuizID, userID); !ok {
+		return
+	}
+
+	if err := h.questionService.ExtendQuestionTime(c, question.QuizID, id, request.Seconds); err != nil {
+		if errors.Is(err, service.ErrQuestionNotActive) {
+			response.WithError(c, http.StatusConflict, "Failed to extend question", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusBadRequest, "Failed to extend question", err.Error())
+		return
+	}
+
+	questionAction := dto.QuestionAction{
+		Message: "Question time extended successfully",
+	}
+	response.WithSuccess(c, http.StatusOK, "Question time extended successfully", questionAction)
+}
+
+// VoidQuestion rescores a question that already ran so it counts the same for every participant
+func (h *QuestionHandler) VoidQuestion(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid question ID", "The provided question ID is not valid")
+		return
+	}
+
+	var request dto.QuestionVoidRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid request data", err.Error())
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Get the question to determine quiz ID
+	question, err := h.questionService.GetQuestion(c, id)
+	if err != nil {
+		response.WithError(c, http.StatusNotFound, "Question not found", err.Error())
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, question.QuizID, userID); !ok {
+		return
+	}
+
+	if err := h.questionService.VoidQuestion(c, question.QuizID, id, model.VoidPolicy(request.Policy)); err != nil {
+		if errors.Is(err, service.ErrQuestionVoided) || errors.Is(err, service.ErrQuestionRunning) {
+			response.WithError(c, http.StatusConflict, "Failed to void question", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusBadRequest, "Failed to void question", err.Error())
+		return
+	}
+
+	questionAction := dto.QuestionAction{
+		Message: "Question voided successfully",
+	}
+	response.WithSuccess(c, http.StatusOK, "Question voided successfully", questionAction)
+}
+
 // GetNextQuestion retrieves the next question in sequence
 func (h *QuestionHandler) GetNextQuestion(c *gin.Context) {
 	quizIDStr := c.Param("quizId")
@@ -233,6 +420,31 @@ func (h *QuestionHandler) GetNextQuestion(c *gin.Context) {
 		return
 	}
 
+	// Clients preloading media can ask for several upcoming questions with ?count=N
+	if countStr := c.Query("count"); countStr != "" {
+		count, err := strconv.Atoi(countStr)
+		if err != nil || count < 1 {
+			response.WithError(c, http.StatusBadRequest, "Invalid count", "count must be a positive number")
+			return
+		}
+
+		questions, err := h.questionService.GetUpcomingQuestions(c, quizID, count)
+		if err != nil {
+			response.WithError(c, http.StatusNotFound, "No next question available", err.Error())
+			return
+		}
+
+		// Don't include the correct answers when prefetching questions
+		questionResponses := make([]dto.QuestionResponse, len(questions))
+		for i, question := range questions {
+			questionResponses[i] = dto.QuestionResponseFromModel(question, false)
+		}
+		response.WithSuccess(c, http.StatusOK, "Upcoming questions fetched successfully", map[string]interface{}{
+			"questions": questionResponses,
+		})
+		return
+	}
+
 	question, err := h.questionService.GetNextQuestion(c, quizID)
 	if err != nil {
 		response.WithError(c, http.StatusNotFound, "No next question available", err.Error())
@@ -263,15 +475,7 @@ func (h *QuestionHandler) MoveToNextQuestion(c *gin.Context) {
 	}
 
 	// Verify quiz ownership
-	quiz, err := h.quizService.GetQuiz(c, quizID)
-	if err != nil {
-		response.WithError(c, http.StatusNotFound, "Quiz not found", err.Error())
-		return
-	}
-
-	// Check if the authenticated user is the quiz creator
-	if quiz.CreatorID != userID {
-		response.WithError(c, http.StatusForbidden, "Access denied", "Only the quiz creator can move to the next question")
+	if _, ok := requireQuizOwner(c, h.quizService, quizID, userID); !ok {
 		return
 	}
 
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// stubQuestionService serves fixed questions and records the ones started; its other QuestionService
+// methods are not implemented
+type stubQuestionService struct {
+	service.QuestionService
+	questions map[uuid.UUID]*model.Question
+	upcoming  []*model.Question
+	lastCount int
+	started   []uuid.UUID
+}
+
+func (s *stubQuestionService) GetUpcomingQuestions(ctx context.Context, quizID uuid.UUID, count int) ([]*model.Question, error) {
+	s.lastCount = count
+	return s.upcoming, nil
+}
+
+// answeredQuestion builds a question whose option and explanation give the answer away
+func answeredQuestion(quizID uuid.UUID, order int) *model.Question {
+	question := model.NewQuestion(quizID, "Question", model.QuestionTypeSingleChoice, 30, order)
+	question.Explanation = "Because"
+	question.Options = []*model.QuestionOption{
+		model.NewQuestionOption(question.ID, "Right", true, 1),
+		model.NewQuestionOption(question.ID, "Wrong", false, 2),
+	}
+	return question
+}
+
+func TestGetNextQuestionPrefetchStripsAnswers(t *testing.T) {
+	quizID := uuid.New()
+	questions := &stubQuestionService{upcoming: []*model.Question{answeredQuestion(quizID, 2), answeredQuestion(quizID, 3)}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/quizzes/:quizId/questions/next", NewQuestionHandler(questions, nil).GetNextQuestion)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/quizzes/"+quizID.String()+"/questions/next?count=2", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("prefetch returned %d: %s", recorder.Code, recorder.Body)
+	}
+	if questions.lastCount != 2 {
+		t.Errorf("asked the service for %d questions, want 2", questions.lastCount)
+	}
+
+	var body struct {
+		Data struct {
+			Questions []map[string]interface{} `json:"questions"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(body.Data.Questions) != 2 {
+		t.Fatalf("got %d questions, want 2", len(body.Data.Questions))
+	}
+	for _, question := range body.Data.Questions {
+		if _, ok := question["explanation"]; ok {
+			t.Error("prefetched question includes its explanation")
+		}
+		for _, option := range question["options"].([]interface{}) {
+			if _, ok := option.(map[string]interface{})["isCorrect"]; ok {
+				t.Error("prefetched option says whether it is correct")
+			}
+		}
+	}
+}
+
+func TestGetNextQuestionRejectsInvalidPrefetchCounts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/quizzes/:quizId/questions/next", NewQuestionHandler(&stubQuestionService{}, nil).GetNextQuestion)
+
+	for _, count := range []string{"0", "-1", "many"} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/quizzes/"+uuid.New().String()+"/questions/next?count="+count, nil))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("count=%s returned %d, want 400", count, recorder.Code)
+		}
+	}
+}
@@ -1,10 +1,18 @@
 package handler
 
 import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/middleware"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/response"
 	"github.com/gin-gonic/gin"
@@ -17,6 +25,12 @@ type QuizHandler struct {
 	questionService    service.QuestionService
 	userService        service.UserService
 	participantService service.ParticipantService
+	stateService       service.StateService
+	teamService        service.TeamService
+	integrityService   service.IntegrityService
+	webhookService     service.WebhookService
+	presenterService   service.PresenterService
+	scoringService     service.ScoringService
 }
 
 // NewQuizHandler creates a new quiz handler
@@ -25,12 +39,24 @@ func NewQuizHandler(
 	questionService service.QuestionService,
 	userService service.UserService,
 	participantService service.ParticipantService,
+	stateService service.StateService,
+	teamService service.TeamService,
+	integrityService service.IntegrityService,
+	webhookService service.WebhookService,
+	presenterService service.PresenterService,
+	scoringService service.ScoringService,
 ) *QuizHandler {
 	return &QuizHandler{
 		quizService:        quizService,
 		questionService:    questionService,
 		userService:        userService,
 		participantService: participantService,
+		stateService:       stateService,
+		teamService:        teamService,
+		integrityService:   integrityService,
+		webhookService:     webhookService,
+		presenterService:   presenterService,
+		scoringService:     scoringService,
 	}
 }
 
@@ -60,30 +86,119 @@ func (h *QuizHandler) CreateQuiz(c *gin.Context) {
 	// Create the quiz with questions
 	quiz, err := h.quizService.CreateQuizWithQuestions(c, request.Title, request.Description, creatorID, request.Questions)
 	if err != nil {
+		if errors.Is(err, service.ErrCodeUnavailable) {
+			response.WithError(c, http.StatusServiceUnavailable, "Failed to create quiz", err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrInvalidContent) {
+			response.WithError(c, http.StatusBadRequest, "Failed to create quiz", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusInternalServerError, "Failed to create quiz", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusCreated, response.MessageCreated, h.createdQuizData(c, quiz, dto.CreatorResponseFromModel(creator)))
+}
+
+// CreateGuestQuiz creates a quiz for a host without an account, along with a temporary creator identity
+// whose short-lived token is returned with the quiz
+func (h *QuizHandler) CreateGuestQuiz(c *gin.Context) {
+	var request dto.GuestQuizCreateRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid request data", err.Error())
+		return
+	}
+
+	quiz, guest, err := h.quizService.CreateGuestQuiz(c, request.HostName, request.Title, request.Description, request.Questions)
+	if err != nil {
+		if errors.Is(err, service.ErrCodeUnavailable) {
+			response.WithError(c, http.StatusServiceUnavailable, "Failed to create quiz", err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrInvalidContent) {
+			response.WithError(c, http.StatusBadRequest, "Failed to create quiz", err.Error())
+			return
+		}
 		response.WithError(c, http.StatusInternalServerError, "Failed to create quiz", err.Error())
 		return
 	}
 
+	token, err := h.userService.IssueGuestToken(guest)
+	if err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to create guest host", err.Error())
+		return
+	}
+
+	data := h.createdQuizData(c, quiz, dto.CreatorResponseFromModel(guest))
+	data["accessToken"] = token.AccessToken
+	data["tokenType"] = token.TokenType
+	data["expiresIn"] = token.ExpiresIn
+
+	response.WithSuccess(c, http.StatusCreated, response.MessageCreated, data)
+}
+
+// createdQuizData builds the response of a newly created quiz with its creator and questions
+func (h *QuizHandler) createdQuizData(c *gin.Context, quiz *model.Quiz, creator dto.CreatorResponse) map[string]interface{} {
 	// Get the created questions for the response
 	questions, _ := h.questionService.GetQuestions(c, quiz.ID)
 
-	// Convert to DTOs
-	quizResponse := dto.QuizResponseFromModel(quiz)
-	creatorResponse := dto.CreatorResponseFromModel(creator)
-
 	var questionResponses []dto.QuestionResponse
 	for _, q := range questions {
 		questionResponses = append(questionResponses, dto.QuestionResponseFromModel(q, true))
 	}
 
-	// Create response data
-	data := map[string]interface{}{
-		"quiz":      quizResponse,
-		"creator":   creatorResponse,
+	return map[string]interface{}{
+		"quiz":      dto.QuizResponseFromModel(quiz),
+		"creator":   creator,
 		"questions": questionResponses,
 	}
+}
+
+// CloneQuiz copies one of the caller's quizzes, with its questions and options, into a new waiting quiz
+func (h *QuizHandler) CloneQuiz(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
 
-	response.WithSuccess(c, http.StatusCreated, response.MessageCreated, data)
+	// Get the authenticated user ID from the JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Only the creator may clone a quiz, since the copy includes the correct answers
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	clone, err := h.quizService.CloneQuiz(c, id, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrCodeUnavailable) {
+			response.WithError(c, http.StatusServiceUnavailable, "Failed to clone quiz", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusInternalServerError, "Failed to clone quiz", err.Error())
+		return
+	}
+
+	// Get the copied questions for the response
+	questions, _ := h.questionService.GetQuestions(c, clone.ID)
+
+	var questionResponses []dto.QuestionResponse
+	for _, q := range questions {
+		questionResponses = append(questionResponses, dto.QuestionResponseFromModel(q, true))
+	}
+
+	response.WithSuccess(c, http.StatusCreated, response.MessageCreated, map[string]interface{}{
+		"quiz":      dto.QuizResponseFromModel(clone),
+		"questions": questionResponses,
+	})
 }
 
 // GetQuiz retrieves quiz details
@@ -176,20 +291,16 @@ func (h *QuizHandler) StartQuiz(c *gin.Context) {
 		return
 	}
 
-	// Verify ownership by getting the quiz first
-	quiz, err := h.quizService.GetQuiz(c, id)
-	if err != nil {
-		response.WithError(c, http.StatusNotFound, "Quiz not found", err.Error())
-		return
-	}
-
-	// Check if the authenticated user is the quiz creator
-	if quiz.CreatorID != userID {
-		response.WithError(c, http.StatusForbidden, "Access denied", "Only the quiz creator can start this quiz")
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
 		return
 	}
 
 	if err := h.quizService.StartQuiz(c, id); err != nil {
+		if errors.Is(err, service.ErrActiveQuizLimit) {
+			response.WithError(c, http.StatusConflict, "Failed to start quiz", err.Error())
+			return
+		}
 		response.WithError(c, http.StatusBadRequest, "Failed to start quiz", err.Error())
 		return
 	}
@@ -200,8 +311,11 @@ func (h *QuizHandler) StartQuiz(c *gin.Context) {
 	response.WithSuccess(c, http.StatusOK, "Quiz started successfully", quizAction)
 }
 
-// EndQuiz ends a quiz session
-func (h *QuizHandler) EndQuiz(c *gin.Context) {
+// maxLobbyCountdownSeconds bounds how long a lobby countdown may run
+const maxLobbyCountdownSeconds = 300
+
+// StartQuizWithCountdown counts down in the lobby, then starts the quiz automatically
+func (h *QuizHandler) StartQuizWithCountdown(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
@@ -209,6 +323,13 @@ func (h *QuizHandler) EndQuiz(c *gin.Context) {
 		return
 	}
 
+	// Get the countdown length, default to 10 seconds
+	seconds, err := strconv.Atoi(c.DefaultQuery("seconds", "10"))
+	if err != nil || seconds <= 0 || seconds > maxLobbyCountdownSeconds {
+		response.WithError(c, http.StatusBadRequest, "Invalid countdown", "seconds must be between 1 and 300")
+		return
+	}
+
 	// Get authenticated user ID from JWT context
 	userID := middleware.GetAuthUserID(c)
 	if userID == uuid.Nil {
@@ -216,16 +337,46 @@ func (h *QuizHandler) EndQuiz(c *gin.Context) {
 		return
 	}
 
-	// Verify ownership by getting the quiz first
-	quiz, err := h.quizService.GetQuiz(c, id)
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	if err := h.quizService.StartQuizAfterCountdown(c, id, seconds); err != nil {
+		if errors.Is(err, service.ErrActiveQuizLimit) ||
+			errors.Is(err, service.ErrQuizAlreadyStarted) ||
+			errors.Is(err, service.ErrCountdownInProgress) {
+			response.WithError(c, http.StatusConflict, "Failed to start countdown", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusBadRequest, "Failed to start countdown", err.Error())
+		return
+	}
+
+	quizAction := dto.QuizAction{
+		Message: "Lobby countdown started",
+	}
+	response.WithSuccess(c, http.StatusAccepted, "Lobby countdown started", quizAction)
+}
+
+// EndQuiz ends a quiz session
+func (h *QuizHandler) EndQuiz(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
 	if err != nil {
-		response.WithError(c, http.StatusNotFound, "Quiz not found", err.Error())
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
 		return
 	}
 
-	// Check if the authenticated user is the quiz creator
-	if quiz.CreatorID != userID {
-		response.WithError(c, http.StatusForbidden, "Access denied", "Only the quiz creator can end this quiz")
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
 		return
 	}
 
@@ -240,6 +391,78 @@ func (h *QuizHandler) EndQuiz(c *gin.Context) {
 	response.WithSuccess(c, http.StatusOK, "Quiz ended successfully", quizAction)
 }
 
+// FreezeLeaderboard holds back leaderboard updates of a live quiz until the creator reveals them
+func (h *QuizHandler) FreezeLeaderboard(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	if err := h.stateService.FreezeLeaderboard(c, id); err != nil {
+		if errors.Is(err, service.ErrLeaderboardFrozen) {
+			response.WithError(c, http.StatusConflict, "Failed to freeze leaderboard", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusBadRequest, "Failed to freeze leaderboard", err.Error())
+		return
+	}
+
+	quizAction := dto.QuizAction{
+		Message: "Leaderboard frozen successfully",
+	}
+	response.WithSuccess(c, http.StatusOK, "Leaderboard frozen successfully", quizAction)
+}
+
+// RevealFinalLeaderboard unfreezes the leaderboard and publishes the standings with each participant's movement
+func (h *QuizHandler) RevealFinalLeaderboard(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	if err := h.stateService.RevealFinalLeaderboard(c, id); err != nil {
+		if errors.Is(err, service.ErrLeaderboardNotFrozen) {
+			response.WithError(c, http.StatusConflict, "Failed to reveal leaderboard", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusBadRequest, "Failed to reveal leaderboard", err.Error())
+		return
+	}
+
+	quizAction := dto.QuizAction{
+		Message: "Leaderboard revealed successfully",
+	}
+	response.WithSuccess(c, http.StatusOK, "Leaderboard revealed successfully", quizAction)
+}
+
 // JoinQuiz allows a user to join a quiz as a participant
 func (h *QuizHandler) JoinQuiz(c *gin.Context) {
 	idStr := c.Param("id")
@@ -256,14 +479,21 @@ func (h *QuizHandler) JoinQuiz(c *gin.Context) {
 		return
 	}
 
-	participant, err := h.participantService.JoinQuiz(c, id, request.Name)
+	participant, err := h.participantService.JoinQuiz(c, id, request.Name, request.TeamID)
 	if err != nil {
+		if errors.Is(err, service.ErrParticipantBanned) {
+			response.WithError(c, http.StatusForbidden, "Failed to join quiz", err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrQuizFull) {
+			response.WithError(c, http.StatusConflict, "Failed to join quiz", err.Error())
+			return
+		}
 		response.WithError(c, http.StatusBadRequest, "Failed to join quiz", err.Error())
 		return
 	}
 
-	participantResponse := dto.ParticipantResponseFromModel(participant)
-	response.WithSuccess(c, http.StatusOK, "Successfully joined quiz", participantResponse)
+	h.respondJoined(c, participant)
 }
 
 // JoinQuizByCode allows a user to join a quiz using a code
@@ -275,17 +505,110 @@ func (h *QuizHandler) JoinQuizByCode(c *gin.Context) {
 		return
 	}
 
-	participant, err := h.participantService.JoinQuizByCode(c, request.Code, request.Name)
+	participant, err := h.participantService.JoinQuizByCode(c, request.Code, request.Name, request.TeamID)
 	if err != nil {
+		if errors.Is(err, service.ErrParticipantBanned) {
+			response.WithError(c, http.StatusForbidden, "Failed to join quiz", err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrQuizFull) {
+			response.WithError(c, http.StatusConflict, "Failed to join quiz", err.Error())
+			return
+		}
 		response.WithError(c, http.StatusBadRequest, "Failed to join quiz", err.Error())
 		return
 	}
 
-	participantResponse := dto.ParticipantResponseFromModel(participant)
-	response.WithSuccess(c, http.StatusOK, "Successfully joined quiz", participantResponse)
+	h.respondJoined(c, participant)
+}
+
+// IssueSpectatorToken creates a token for a display that shows the quiz without taking part in it
+func (h *QuizHandler) IssueSpectatorToken(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	spectatorID, token, err := h.participantService.IssueSpectatorToken(c, id)
+	if err != nil {
+		if errors.Is(err, service.ErrQuizNotFound) {
+			response.WithError(c, http.StatusNotFound, "Quiz not found", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusInternalServerError, "Failed to issue spectator token", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, "Spectator token issued successfully", dto.SpectatorTokenResponse{
+		SpectatorID: spectatorID,
+		Token:       token,
+	})
+}
+
+// respondJoined returns a newly joined participant along with the token for their WebSocket connections
+func (h *QuizHandler) respondJoined(c *gin.Context, participant *model.Participant) {
+	token, err := h.participantService.IssueToken(participant)
+	if err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to join quiz", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, "Successfully joined quiz", dto.ParticipantJoinResponse{
+		ParticipantResponse: dto.ParticipantResponseFromModel(participant),
+		Token:               token,
+	})
+}
+
+// GetQuizByCode returns the public summary of a quiz so a join screen can validate a code
+func (h *QuizHandler) GetQuizByCode(c *gin.Context) {
+	code := c.Param("code")
+
+	quiz, err := h.quizService.GetQuizByCode(c, code)
+	if err != nil {
+		if errors.Is(err, service.ErrQuizNotFound) {
+			response.WithError(c, http.StatusNotFound, "Quiz not found", "No quiz exists with the provided code")
+			return
+		}
+		response.WithError(c, http.StatusInternalServerError, "Failed to get quiz", err.Error())
+		return
+	}
+
+	participantCount, err := h.participantService.CountParticipantsByQuizID(c, quiz.ID)
+	if err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to get participants", err.Error())
+		return
+	}
+
+	// Only expose what a join screen needs: no creator, questions or answers
+	response.WithSuccess(c, http.StatusOK, response.MessageFetched, dto.QuizCodeLookupResponse{
+		ID:               quiz.ID,
+		Title:            quiz.Title,
+		Description:      quiz.Description,
+		Status:           string(quiz.Status),
+		Code:             quiz.Code,
+		ParticipantCount: participantCount,
+	})
 }
 
-// GetCurrentUserQuizzes retrieves all quizzes created by the authenticated user
+// maxQuizListLimit caps the page size of the quiz listing
+const maxQuizListLimit = 100
+
+// GetCurrentUserQuizzes retrieves the quizzes created by the authenticated user, optionally filtered by
+// status and title and paged with limit and offset. Without a limit every matching quiz is returned.
 func (h *QuizHandler) GetCurrentUserQuizzes(c *gin.Context) {
 	// Get authenticated user ID from JWT context
 	userID := middleware.GetAuthUserID(c)
@@ -294,8 +617,47 @@ func (h *QuizHandler) GetCurrentUserQuizzes(c *gin.Context) {
 		return
 	}
 
+	filter := model.QuizFilter{
+		Status: model.QuizStatus(c.Query("status")),
+		Search: strings.TrimSpace(c.Query("search")),
+	}
+	if includeArchived := c.Query("includeArchived"); includeArchived != "" {
+		value, err := strconv.ParseBool(includeArchived)
+		if err != nil {
+			response.WithError(c, http.StatusBadRequest, "Invalid includeArchived", "includeArchived must be true or false")
+			return
+		}
+		filter.IncludeArchived = value
+	}
+	switch filter.Status {
+	case "", model.QuizStatusWaiting, model.QuizStatusActive, model.QuizStatusCompleted:
+	default:
+		response.WithError(c, http.StatusBadRequest, "Invalid status", "status must be WAITING, ACTIVE or COMPLETED")
+		return
+	}
+
+	limitStr, paginated := c.GetQuery("limit")
+	if paginated {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			response.WithError(c, http.StatusBadRequest, "Invalid limit", "limit must be a positive number")
+			return
+		}
+		if limit > maxQuizListLimit {
+			limit = maxQuizListLimit
+		}
+		filter.Limit = limit
+
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			response.WithError(c, http.StatusBadRequest, "Invalid offset", "offset must be zero or a positive number")
+			return
+		}
+		filter.Offset = offset
+	}
+
 	// Get quizzes created by the user
-	quizzes, err := h.quizService.GetQuizzesByCreatorID(c, userID)
+	quizzes, total, err := h.quizService.GetQuizzesByCreatorID(c, userID, filter)
 	if err != nil {
 		response.WithError(c, http.StatusInternalServerError, "Failed to get quizzes", err.Error())
 		return
@@ -307,7 +669,11 @@ func (h *QuizHandler) GetCurrentUserQuizzes(c *gin.Context) {
 		quizResponses = append(quizResponses, dto.QuizResponseFromModel(quiz))
 	}
 
-	response.WithSuccess(c, http.StatusOK, "Quizzes retrieved successfully", quizResponses)
+	if !paginated {
+		response.WithSuccess(c, http.StatusOK, "Quizzes retrieved successfully", quizResponses)
+		return
+	}
+	response.WithPagination(c, "Quizzes retrieved successfully", quizResponses, total, filter.Limit, filter.Offset/filter.Limit+1)
 }
 
 // UpdateQuiz handles updating an existing quiz
@@ -326,16 +692,8 @@ func (h *QuizHandler) UpdateQuiz(c *gin.Context) {
 		return
 	}
 
-	// Get the quiz to verify ownership
-	quiz, err := h.quizService.GetQuiz(c, id)
-	if err != nil {
-		response.WithError(c, http.StatusNotFound, "Quiz not found", err.Error())
-		return
-	}
-
-	// Check if the authenticated user is the quiz creator
-	if quiz.CreatorID != userID {
-		response.WithError(c, http.StatusForbidden, "Access denied", "Only the quiz creator can update this quiz")
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
 		return
 	}
 
@@ -348,8 +706,12 @@ func (h *QuizHandler) UpdateQuiz(c *gin.Context) {
 	}
 
 	// Update the quiz with questions
-	updatedQuiz, err := h.quizService.UpdateQuizWithQuestions(c, id, request.Title, request.Description, request.Questions)
+	updatedQuiz, err := h.quizService.UpdateQuizWithQuestions(c, id, request.Title, request.Description, request.Questions, request.Force)
 	if err != nil {
+		if errors.Is(err, service.ErrQuizHasAnswers) || errors.Is(err, service.ErrQuestionHasAnswers) {
+			response.WithError(c, http.StatusConflict, "Failed to update quiz", err.Error())
+			return
+		}
 		response.WithError(c, http.StatusBadRequest, "Failed to update quiz", err.Error())
 		return
 	}
@@ -390,16 +752,8 @@ func (h *QuizHandler) DeleteQuiz(c *gin.Context) {
 		return
 	}
 
-	// Get the quiz to verify ownership
-	quiz, err := h.quizService.GetQuiz(c, id)
-	if err != nil {
-		response.WithError(c, http.StatusNotFound, "Quiz not found", err.Error())
-		return
-	}
-
-	// Check if the authenticated user is the quiz creator
-	if quiz.CreatorID != userID {
-		response.WithError(c, http.StatusForbidden, "Access denied", "Only the quiz creator can delete this quiz")
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
 		return
 	}
 
@@ -411,3 +765,729 @@ func (h *QuizHandler) DeleteQuiz(c *gin.Context) {
 
 	response.WithSuccess(c, http.StatusOK, "Quiz deleted successfully", nil)
 }
+
+// ExportParticipants returns the roster of a quiz, with each participant's join time and score, as JSON
+// or as a CSV download. Names are left out for anonymous quizzes.
+func (h *QuizHandler) ExportParticipants(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		response.WithError(c, http.StatusBadRequest, "Invalid format", "format must be json or csv")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	quiz, ok := requireQuizOwner(c, h.quizService, id, userID)
+	if !ok {
+		return
+	}
+
+	participants, err := h.participantService.GetParticipantsByQuizID(c, id)
+	if err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to export participants", err.Error())
+		return
+	}
+
+	anonymous := quiz.Settings.Anonymous
+	roster := dto.RosterFromModel(participants, anonymous)
+
+	if format == "json" {
+		response.WithSuccess(c, http.StatusOK, response.MessageListFetched, roster)
+		return
+	}
+
+	header := []string{"participantId"}
+	if !anonymous {
+		header = append(header, "name")
+	}
+	header = append(header, "teamId", "joinedAt", "score")
+
+	// Build the whole file before answering so a failed write is reported instead of a truncated 200
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(header)
+	for _, entry := range roster {
+		teamID := ""
+		if entry.TeamID != nil {
+			teamID = entry.TeamID.String()
+		}
+
+		row := []string{entry.ParticipantID.String()}
+		if !anonymous {
+			row = append(row, entry.Name)
+		}
+		row = append(row, teamID, entry.JoinedAt.UTC().Format(time.RFC3339), strconv.Itoa(entry.Score))
+		writer.Write(row)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to export participants", err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="quiz-%s-participants.csv"`, id))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// ArchiveQuiz hides a quiz from its creator's quiz list without deleting its results
+func (h *QuizHandler) ArchiveQuiz(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	archivedQuiz, err := h.quizService.ArchiveQuiz(c, id)
+	if err != nil {
+		if errors.Is(err, service.ErrQuizRunning) {
+			response.WithError(c, http.StatusConflict, "Failed to archive quiz", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusInternalServerError, "Failed to archive quiz", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, "Quiz archived successfully", dto.QuizResponseFromModel(archivedQuiz))
+}
+
+// UnarchiveQuiz returns an archived quiz to its creator's quiz list
+func (h *QuizHandler) UnarchiveQuiz(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	unarchivedQuiz, err := h.quizService.UnarchiveQuiz(c, id)
+	if err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to unarchive quiz", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, "Quiz unarchived successfully", dto.QuizResponseFromModel(unarchivedQuiz))
+}
+
+// ExportQuiz returns the portable definition of a quiz for its creator
+func (h *QuizHandler) ExportQuiz(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	quiz, ok := requireQuizOwner(c, h.quizService, id, userID)
+	if !ok {
+		return
+	}
+
+	questions, err := h.questionService.GetQuestions(c, id)
+	if err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to export quiz", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, response.MessageFetched, dto.QuizExportFromModel(quiz, questions))
+}
+
+// ImportQuiz creates a new quiz owned by the caller from a quiz previously exported with ExportQuiz
+func (h *QuizHandler) ImportQuiz(c *gin.Context) {
+	var request dto.QuizExport
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid request data", err.Error())
+		return
+	}
+
+	// Get the authenticated user ID from the JWT context
+	creatorID := middleware.GetAuthUserID(c)
+	if creatorID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	creator, err := h.userService.GetUserByID(c, creatorID)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "User not found", "The authenticated user could not be found")
+		return
+	}
+
+	quiz, err := h.quizService.ImportQuiz(c, request, creatorID)
+	if err != nil {
+		if errors.Is(err, service.ErrCodeUnavailable) {
+			response.WithError(c, http.StatusServiceUnavailable, "Failed to import quiz", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusBadRequest, "Failed to import quiz", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusCreated, response.MessageCreated, h.createdQuizData(c, quiz, dto.CreatorResponseFromModel(creator)))
+}
+
+// GetQuizTimeline returns the chronology of a quiz run for its creator
+func (h *QuizHandler) GetQuizTimeline(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	timeline, err := h.stateService.GetQuizTimeline(c, id)
+	if err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to get quiz timeline", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, response.MessageFetched, timeline)
+}
+
+// CreateTeam adds a team to a quiz, enabling team mode
+func (h *QuizHandler) CreateTeam(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	var request dto.TeamCreateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid request data", err.Error())
+		return
+	}
+
+	team, err := h.teamService.CreateTeam(c, id, request.Name)
+	if err != nil {
+		if errors.Is(err, service.ErrTeamNameTaken) {
+			response.WithError(c, http.StatusConflict, "Failed to create team", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusBadRequest, "Failed to create team", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusCreated, response.MessageCreated, dto.TeamResponseFromModel(team))
+}
+
+// SetWebhook registers the endpoint notified of a quiz's lifecycle events
+func (h *QuizHandler) SetWebhook(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	var request dto.WebhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid request data", err.Error())
+		return
+	}
+
+	webhook, err := h.webhookService.SetQuizWebhook(c, id, request.URL, request.Secret)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWebhookURL) || errors.Is(err, service.ErrWebhookDestinationBlocked) {
+			response.WithError(c, http.StatusBadRequest, "Failed to save webhook", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusInternalServerError, "Failed to save webhook", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, "Webhook saved successfully", dto.WebhookResponseFromModel(webhook))
+}
+
+// GetWebhook returns the webhook registered for a quiz, without its secret
+func (h *QuizHandler) GetWebhook(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetQuizWebhook(c, id)
+	if err != nil {
+		response.WithError(c, http.StatusNotFound, "Webhook not found", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, response.MessageFetched, dto.WebhookResponseFromModel(webhook))
+}
+
+// DeleteWebhook stops notifying a quiz's webhook
+func (h *QuizHandler) DeleteWebhook(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteQuizWebhook(c, id); err != nil {
+		response.WithError(c, http.StatusNotFound, "Webhook not found", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, "Webhook deleted successfully", nil)
+}
+
+// GetTeams lists the teams participants can join in a quiz
+func (h *QuizHandler) GetTeams(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	teams, err := h.teamService.GetTeamsByQuizID(c, id)
+	if err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to get teams", err.Error())
+		return
+	}
+
+	teamResponses := make([]dto.TeamResponse, 0, len(teams))
+	for _, team := range teams {
+		teamResponses = append(teamResponses, dto.TeamResponseFromModel(team))
+	}
+
+	response.WithSuccess(c, http.StatusOK, response.MessageListFetched, teamResponses)
+}
+
+// ReorderQuestions reassigns the order of a quiz's questions
+func (h *QuizHandler) ReorderQuestions(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	var request dto.QuestionOrderRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid request data", err.Error())
+		return
+	}
+
+	questions, err := h.questionService.ReorderQuestions(c, id, request.QuestionIDs)
+	if err != nil {
+		if errors.Is(err, service.ErrQuizHasAnswers) {
+			response.WithError(c, http.StatusConflict, "Failed to reorder questions", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusBadRequest, "Failed to reorder questions", err.Error())
+		return
+	}
+
+	var questionResponses []dto.QuestionResponse
+	for _, q := range questions {
+		questionResponses = append(questionResponses, dto.QuestionResponseFromModel(q, true))
+	}
+
+	response.WithSuccess(c, http.StatusOK, "Questions reordered successfully", map[string]interface{}{
+		"questions": questionResponses,
+	})
+}
+
+// SetQuestionTimeLimits sets the time limit of many questions of a quiz at once
+func (h *QuizHandler) SetQuestionTimeLimits(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	var request dto.QuestionTimeLimitRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid request data", err.Error())
+		return
+	}
+
+	questions, err := h.questionService.SetQuestionTimeLimits(c, id, request.QuestionIDs, request.TimeLimit)
+	if err != nil {
+		if errors.Is(err, service.ErrQuizHasAnswers) {
+			response.WithError(c, http.StatusConflict, "Failed to update time limits", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusBadRequest, "Failed to update time limits", err.Error())
+		return
+	}
+
+	var questionResponses []dto.QuestionResponse
+	for _, q := range questions {
+		questionResponses = append(questionResponses, dto.QuestionResponseFromModel(q, true))
+	}
+
+	response.WithSuccess(c, http.StatusOK, "Time limits updated successfully", map[string]interface{}{
+		"questions": questionResponses,
+	})
+}
+
+// GoToQuestion jumps to a specific question of a live quiz
+func (h *QuizHandler) GoToQuestion(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	questionID, err := uuid.Parse(c.Param("questionId"))
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid question ID", "The provided question ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	// Jumping back to a question that already ran must be requested explicitly with ?force=true
+	force := c.Query("force") == "true"
+
+	if err := h.stateService.GoToQuestion(c, id, questionID, force); err != nil {
+		if errors.Is(err, service.ErrQuestionAlreadyRun) {
+			response.WithError(c, http.StatusConflict, "Failed to go to question", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusBadRequest, "Failed to go to question", err.Error())
+		return
+	}
+
+	quizAction := dto.QuizAction{
+		Message: "Question started successfully",
+	}
+	response.WithSuccess(c, http.StatusOK, "Question started successfully", quizAction)
+}
+
+// GetIntegrityReport returns suspicious submission patterns of a quiz for its creator
+func (h *QuizHandler) GetIntegrityReport(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	report, err := h.integrityService.GetIntegrityReport(c, id)
+	if err != nil {
+		if errors.Is(err, service.ErrIntegrityDisabled) {
+			response.WithError(c, http.StatusNotFound, "Integrity report unavailable", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusInternalServerError, "Failed to get integrity report", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, response.MessageFetched, report)
+}
+
+// GetScoringReport returns how points were awarded across the questions of a quiz for its creator
+func (h *QuizHandler) GetScoringReport(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	report, err := h.scoringService.GetScoringReport(c, id)
+	if err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to get scoring report", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, response.MessageFetched, report)
+}
+
+// GetPresenterView returns everything the creator's control screen needs in a single call
+func (h *QuizHandler) GetPresenterView(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership; the view exposes the correct options of the active question
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	view, err := h.presenterService.GetPresenterView(c, id)
+	if err != nil {
+		response.WithError(c, http.StatusInternalServerError, "Failed to get presenter view", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, response.MessageFetched, view)
+}
+
+// UpdateQuizSettings replaces the settings of a quiz and notifies connected clients
+func (h *QuizHandler) UpdateQuizSettings(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	var request dto.QuizSettingsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid request data", err.Error())
+		return
+	}
+
+	updatedQuiz, err := h.quizService.UpdateQuizSettings(c, id, model.QuizSettings{
+		AllowLateJoin:             request.AllowLateJoin,
+		MaxParticipants:           request.MaxParticipants,
+		Anonymous:                 request.Anonymous,
+		RejectDisconnectedAnswers: request.RejectDisconnectedAnswers,
+		ManualAdvance:             request.ManualAdvance,
+		DisableTimeBonus:          request.DisableTimeBonus,
+		LateAnswerPolicy:          request.LateAnswerPolicy,
+		Tiebreak:                  request.Tiebreak,
+		TiebreakSeed:              request.TiebreakSeed,
+		ShuffleOptions:            request.ShuffleOptions,
+		ShuffleQuestions:          request.ShuffleQuestions,
+		ShuffleSeed:               request.ShuffleSeed,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrQuizCompleted) {
+			response.WithError(c, http.StatusConflict, "Failed to update settings", err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrInvalidSettings) {
+			response.WithError(c, http.StatusBadRequest, "Failed to update settings", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusInternalServerError, "Failed to update settings", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, response.MessageUpdated, dto.QuizResponseFromModel(updatedQuiz))
+}
+
+// RegenerateCode replaces the join code of a quiz that has not started yet
+func (h *QuizHandler) RegenerateCode(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
+		return
+	}
+
+	// Get authenticated user ID from JWT context
+	userID := middleware.GetAuthUserID(c)
+	if userID == uuid.Nil {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+		return
+	}
+
+	// Verify quiz ownership
+	if _, ok := requireQuizOwner(c, h.quizService, id, userID); !ok {
+		return
+	}
+
+	updatedQuiz, err := h.quizService.RegenerateCode(c, id)
+	if err != nil {
+		if errors.Is(err, service.ErrQuizAlreadyStarted) {
+			response.WithError(c, http.StatusConflict, "Failed to regenerate code", err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrCodeUnavailable) {
+			response.WithError(c, http.StatusServiceUnavailable, "Failed to regenerate code", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusInternalServerError, "Failed to regenerate code", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, "Quiz code regenerated successfully", dto.QuizResponseFromModel(updatedQuiz))
+}
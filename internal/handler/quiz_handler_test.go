@@ -0,0 +1,297 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// stubPresenterService serves an empty presenter view and counts the requests for it
+type stubPresenterService struct {
+	service.PresenterService
+	calls int
+}
+
+func (s *stubPresenterService) GetPresenterView(ctx context.Context, quizID uuid.UUID) (*dto.PresenterViewDTO, error) {
+	s.calls++
+	return &dto.PresenterViewDTO{QuizID: quizID}, nil
+}
+
+func TestGetPresenterViewIsForTheCreatorOnly(t *testing.T) {
+	owner, stranger := uuid.New(), uuid.New()
+	quiz := model.NewQuiz("Owned", "", owner)
+	presenter := &stubPresenterService{}
+	handler := (&QuizHandler{
+		quizService:      &stubQuizService{quizzes: map[uuid.UUID]*model.Quiz{quiz.ID: quiz}},
+		presenterService: presenter,
+	}).GetPresenterView
+	path := "/quizzes/" + quiz.ID.String() + "/presenter"
+
+	if foreign := serveAs(t, stranger, http.MethodGet, "/quizzes/:id/presenter", path, handler); foreign.Code != http.StatusNotFound {
+		t.Errorf("another creator's presenter view returned %d, want 404", foreign.Code)
+	}
+	if presenter.calls != 0 {
+		t.Fatal("the presenter view, correct answers included, was assembled for someone who does not own the quiz")
+	}
+
+	if owned := serveAs(t, owner, http.MethodGet, "/quizzes/:id/presenter", path, handler); owned.Code != http.StatusOK {
+		t.Errorf("owner's presenter view returned %d: %s", owned.Code, owned.Body)
+	}
+}
+
+// listingQuizService lists the quizzes it holds and records the filter it was asked for
+type listingQuizService struct {
+	service.QuizService
+	quizzes []*model.Quiz
+	filter  model.QuizFilter
+}
+
+func (s *listingQuizService) GetQuizzesByCreatorID(ctx context.Context, creatorID uuid.UUID, filter model.QuizFilter) ([]*model.Quiz, int, error) {
+	s.filter = filter
+	return s.quizzes, 12, nil
+}
+
+func TestGetCurrentUserQuizzesFiltersAndPages(t *testing.T) {
+	creator := uuid.New()
+	quiz := model.NewQuiz("World History", "", creator)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantCode   int
+		wantFilter model.QuizFilter
+		wantPage   *response.Pagination
+	}{
+		{name: "no params lists everything", query: "", wantCode: http.StatusOK},
+		{
+			name:       "status and search",
+			query:      "?status=ACTIVE&search=+history+",
+			wantCode:   http.StatusOK,
+			wantFilter: model.QuizFilter{Status: model.QuizStatusActive, Search: "history"},
+		},
+		{
+			name:       "paged",
+			query:      "?limit=5&offset=10",
+			wantCode:   http.StatusOK,
+			wantFilter: model.QuizFilter{Limit: 5, Offset: 10},
+			wantPage:   &response.Pagination{Total: 12, PerPage: 5, CurrentPage: 3, LastPage: 3},
+		},
+		{name: "unknown status", query: "?status=PAUSED", wantCode: http.StatusBadRequest},
+		{name: "bad limit", query: "?limit=0", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quizService := &listingQuizService{quizzes: []*model.Quiz{quiz}}
+			handler := (&QuizHandler{quizService: quizService}).GetCurrentUserQuizzes
+
+			recorder := serveAs(t, creator, http.MethodGet, "/quizzes", "/quizzes"+tt.query, handler)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("listing returned %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			if quizService.filter != tt.wantFilter {
+				t.Errorf("service was asked for %+v, want %+v", quizService.filter, tt.wantFilter)
+			}
+
+			var body struct {
+				Data       []dto.QuizResponse   `json:"data"`
+				Pagination *response.Pagination `json:"pagination"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if len(body.Data) != 1 || body.Data[0].ID != quiz.ID {
+				t.Errorf("listing returned %+v, want the service's quiz", body.Data)
+			}
+			if fmt.Sprint(body.Pagination) != fmt.Sprint(tt.wantPage) {
+				t.Errorf("pagination = %+v, want %+v", body.Pagination, tt.wantPage)
+			}
+		})
+	}
+}
+
+func TestExportParticipantsFormats(t *testing.T) {
+	owner := uuid.New()
+	joined := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		anonymous bool
+		format    string
+		want      string
+	}{
+		{name: "json", format: "json"},
+		{name: "anonymous json", format: "json", anonymous: true},
+		{
+			name:   "csv",
+			format: "csv",
+			want:   "participantId,name,teamId,joinedAt,score\n{ann},Ann,,2026-03-01T09:00:00Z,120\n{bob},\"Bob, Jr.\",,2026-03-01T09:01:00Z,80\n",
+		},
+		{
+			name:      "anonymous csv",
+			format:    "csv",
+			anonymous: true,
+			want:      "participantId,teamId,joinedAt,score\n{ann},,2026-03-01T09:00:00Z,120\n{bob},,2026-03-01T09:01:00Z,80\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quiz := model.NewQuiz("Roster", "", owner)
+			quiz.Settings.Anonymous = tt.anonymous
+			// Stored out of join order
+			bob := model.NewParticipant("Bob, Jr.", quiz.ID)
+			bob.JoinedAt, bob.Score = joined.Add(time.Minute), 80
+			ann := model.NewParticipant("Ann", quiz.ID)
+			ann.JoinedAt, ann.Score = joined, 120
+			handler := (&QuizHandler{
+				quizService:        &stubQuizService{quizzes: map[uuid.UUID]*model.Quiz{quiz.ID: quiz}},
+				participantService: &stubParticipantService{participants: map[uuid.UUID]*model.Participant{bob.ID: bob, ann.ID: ann}},
+			}).ExportParticipants
+			path := "/quizzes/" + quiz.ID.String() + "/participants/export?format=" + tt.format
+
+			recorder := serveAs(t, owner, http.MethodGet, "/quizzes/:id/participants/export", path, handler)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("export returned %d: %s", recorder.Code, recorder.Body)
+			}
+
+			if tt.format == "csv" {
+				want := strings.NewReplacer("{ann}", ann.ID.String(), "{bob}", bob.ID.String()).Replace(tt.want)
+				if got := recorder.Body.String(); got != want {
+					t.Errorf("CSV export =\n%s\nwant\n%s", got, want)
+				}
+				if got := recorder.Header().Get("Content-Disposition"); !strings.Contains(got, "attachment") {
+					t.Errorf("CSV export is not a download: Content-Disposition %q", got)
+				}
+				return
+			}
+
+			var body struct {
+				Data []map[string]interface{} `json:"data"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if len(body.Data) != 2 || body.Data[0]["participantId"] != ann.ID.String() || body.Data[1]["participantId"] != bob.ID.String() {
+				t.Fatalf("JSON export = %v, want Ann then Bob in join order", body.Data)
+			}
+			for i, want := range []*model.Participant{ann, bob} {
+				entry := body.Data[i]
+				if entry["score"] != float64(want.Score) || entry["joinedAt"] != want.JoinedAt.Format(time.RFC3339) {
+					t.Errorf("JSON entry %d = %v, want %s's score and join time", i, entry, want.Name)
+				}
+				if name, named := entry["name"]; named == tt.anonymous || (named && name != want.Name) {
+					t.Errorf("JSON entry %d has name %v with anonymous %v", i, name, tt.anonymous)
+				}
+			}
+		})
+	}
+}
+
+func TestExportParticipantsIsForTheCreatorOnly(t *testing.T) {
+	quiz := model.NewQuiz("Roster", "", uuid.New())
+	handler := (&QuizHandler{
+		quizService:        &stubQuizService{quizzes: map[uuid.UUID]*model.Quiz{quiz.ID: quiz}},
+		participantService: &stubParticipantService{},
+	}).ExportParticipants
+	path := "/quizzes/" + quiz.ID.String() + "/participants/export"
+
+	if recorder := serveAs(t, uuid.New(), http.MethodGet, "/quizzes/:id/participants/export", path, handler); recorder.Code != http.StatusNotFound {
+		t.Errorf("another user's roster export returned %d, want 404", recorder.Code)
+	}
+	if recorder := serveAs(t, quiz.CreatorID, http.MethodGet, "/quizzes/:id/participants/export", path+"?format=xml", handler); recorder.Code != http.StatusBadRequest {
+		t.Errorf("roster export as XML returned %d, want 400", recorder.Code)
+	}
+}
+
+// joinFailingParticipantService fails every join with err
+type joinFailingParticipantService struct {
+	service.ParticipantService
+	err error
+}
+
+func (s *joinFailingParticipantService) JoinQuiz(ctx context.Context, quizID uuid.UUID, name string, teamID *uuid.UUID) (*model.Participant, error) {
+	return nil, s.err
+}
+
+func (s *joinFailingParticipantService) JoinQuizByCode(ctx context.Context, code string, name string, teamID *uuid.UUID) (*model.Participant, error) {
+	return nil, s.err
+}
+
+func TestJoinAFullQuizConflicts(t *testing.T) {
+	handler := &QuizHandler{participantService: &joinFailingParticipantService{err: service.ErrQuizFull}}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/quizzes/:id/join", handler.JoinQuiz)
+	router.POST("/quizzes/join", handler.JoinQuizByCode)
+
+	for path, body := range map[string]string{
+		"/quizzes/" + uuid.NewString() + "/join": `{"name":"Ann"}`,
+		"/quizzes/join":                          `{"code":"ABC123","name":"Ann"}`,
+	} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		if recorder.Code != http.StatusConflict {
+			t.Errorf("joining a full quiz through %s returned %d, want 409: %s", path, recorder.Code, recorder.Body)
+		}
+	}
+}
+
+// countingParticipantService counts a quiz's participants and refuses to load them
+type countingParticipantService struct {
+	stubParticipantService
+}
+
+func (s *countingParticipantService) GetParticipantsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.Participant, error) {
+	return nil, errors.New("participants were loaded to be counted")
+}
+
+func TestGetQuizByCodeCountsParticipants(t *testing.T) {
+	quiz := model.NewQuiz("Quiz", "", uuid.New())
+	ann := model.NewParticipant("Ann", quiz.ID)
+	bob := model.NewParticipant("Bob", quiz.ID)
+	other := model.NewParticipant("Cid", uuid.New())
+	handler := &QuizHandler{
+		quizService: &stubQuizService{quizzes: map[uuid.UUID]*model.Quiz{quiz.ID: quiz}},
+		participantService: &countingParticipantService{stubParticipantService{
+			participants: map[uuid.UUID]*model.Participant{ann.ID: ann, bob.ID: bob, other.ID: other},
+		}},
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/quizzes/code/:code", handler.GetQuizByCode)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/quizzes/code/"+quiz.Code, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("lookup returned %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+
+	var body struct {
+		Data struct {
+			ParticipantCount int `json:"participantCount"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Data.ParticipantCount != 2 {
+		t.Errorf("lookup reports %d participants, want 2", body.Data.ParticipantCount)
+	}
+}
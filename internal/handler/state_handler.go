@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
@@ -31,8 +32,8 @@ func (h *StateHandler) GetQuizState(c *gin.Context) {
 		return
 	}
 
-	// Get the quiz state
-	quizState, err := h.stateService.GetQuizState(c.Request.Context(), quizID)
+	// Get the quiz state; the endpoint is public, so correct answers stay hidden until results are shown
+	quizState, err := h.stateService.GetQuizState(c.Request.Context(), quizID, false)
 	if err != nil {
 		response.WithError(c, http.StatusInternalServerError, "Failed to get quiz state", err.Error())
 		return
@@ -42,6 +43,29 @@ func (h *StateHandler) GetQuizState(c *gin.Context) {
 	response.WithSuccess(c, http.StatusOK, "Get quiz states successfully", quizState)
 }
 
+// GetQuizTimer handles requests for just the current phase and remaining time of a quiz
+func (h *StateHandler) GetQuizTimer(c *gin.Context) {
+	// Parse quiz ID from path
+	quizIDStr := c.Param("id")
+	quizID, err := uuid.Parse(quizIDStr)
+	if err != nil {
+		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", err.Error())
+		return
+	}
+
+	timer, err := h.stateService.GetQuizTimer(c.Request.Context(), quizID)
+	if err != nil {
+		if errors.Is(err, service.ErrQuizNotFound) {
+			response.WithError(c, http.StatusNotFound, "Quiz not found", err.Error())
+			return
+		}
+		response.WithError(c, http.StatusInternalServerError, "Failed to get quiz timer", err.Error())
+		return
+	}
+
+	response.WithSuccess(c, http.StatusOK, response.MessageFetched, timer)
+}
+
 // GetActiveParticipants handles requests to get the active participants for a quiz
 func (h *StateHandler) GetActiveParticipants(c *gin.Context) {
 	// Parse quiz ID from path
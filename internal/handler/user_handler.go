@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
@@ -33,7 +34,7 @@ func (h *UserHandler) RegisterUser(c *gin.Context) {
 	user, err := h.userService.Register(c, request.Name, request.Email, request.Password)
 	if err != nil {
 		// Check for common registration errors and return appropriate status
-		if err.Error() == "email already in use" {
+		if errors.Is(err, service.ErrEmailTaken) {
 			response.WithError(c, http.StatusConflict, "Registration failed", err.Error())
 			return
 		}
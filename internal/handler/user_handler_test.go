@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// stubUserService fails registrations with err and serves fixed users; its other UserService methods are not implemented
+type stubUserService struct {
+	service.UserService
+	err   error
+	users map[uuid.UUID]*model.User
+}
+
+func (s *stubUserService) GetUserByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (s *stubUserService) Register(ctx context.Context, name string, email string, password string) (*model.User, error) {
+	return nil, s.err
+}
+
+func TestRegisterUserWithATakenEmailConflicts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/users/register", NewUserHandler(&stubUserService{err: service.ErrEmailTaken}).RegisterUser)
+
+	body := `{"name":"Ann","email":"ann@example.com","password":"secret-1"}`
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/users/register", bytes.NewBufferString(body)))
+
+	if recorder.Code != http.StatusConflict {
+		t.Errorf("duplicate registration returned %d, want 409: %s", recorder.Code, recorder.Body)
+	}
+}
@@ -3,10 +3,18 @@ package handler
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/middleware"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/auth"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/logger"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/response"
 	ws "github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
 	"github.com/gin-gonic/gin"
@@ -16,20 +24,30 @@ import (
 
 // WebSocketHandler handles WebSocket connections
 type WebSocketHandler struct {
-	hub                *ws.RedisHub
+	hub                ws.QuizHub
 	quizService        service.QuizService
 	userService        service.UserService
 	participantService service.ParticipantService
 	stateService       service.StateService
+	answerService      service.AnswerService
+	jwtManager         *auth.JWTManager
+	wsConfig           config.WebSocketConfig
+	upgrader           websocket.Upgrader
+	logger             *slog.Logger
 }
 
 // NewWebSocketHandler creates a new WebSocket handler
 func NewWebSocketHandler(
-	hub *ws.RedisHub,
+	hub ws.QuizHub,
 	quizService service.QuizService,
 	userService service.UserService,
 	participantService service.ParticipantService,
 	stateService service.StateService,
+	answerService service.AnswerService,
+	jwtManager *auth.JWTManager,
+	wsConfig config.WebSocketConfig,
+	serverConfig config.ServerConfig,
+	log *slog.Logger,
 ) *WebSocketHandler {
 	return &WebSocketHandler{
 		hub:                hub,
@@ -37,15 +55,48 @@ func NewWebSocketHandler(
 		userService:        userService,
 		participantService: participantService,
 		stateService:       stateService,
+		answerService:      answerService,
+		jwtManager:         jwtManager,
+		wsConfig:           wsConfig,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     newOriginChecker(serverConfig),
+		},
+		logger: logger.OrDefault(log),
 	}
 }
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for now
-	},
+// newOriginChecker returns a CheckOrigin function accepting the configured allowed origins.
+// Requests without an Origin header do not come from a browser and are accepted.
+func newOriginChecker(serverConfig config.ServerConfig) func(r *http.Request) bool {
+	if serverConfig.AllowsAnyOrigin() {
+		return func(r *http.Request) bool { return true }
+	}
+
+	allowed := make(map[string]bool)
+	for _, origin := range serverConfig.GetAllowedOrigins() {
+		allowed[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		return origin == "" || allowed[strings.ToLower(origin)]
+	}
+}
+
+// connectionToken returns the token sent with a connection request. Browsers cannot set headers on
+// WebSocket requests, so the token query parameter is checked before the Authorization header.
+func connectionToken(c *gin.Context) string {
+	if token := c.Query("token"); token != "" {
+		return token
+	}
+
+	fields := strings.Fields(c.GetHeader(middleware.AuthorizationHeaderKey))
+	if len(fields) < 2 || fields[0] != middleware.BearerToken {
+		return ""
+	}
+	return fields[1]
 }
 
 // HandleConnection upgrades an HTTP connection to WebSocket
@@ -54,30 +105,45 @@ func (h *WebSocketHandler) HandleConnection(c *gin.Context) {
 	quizIDStr := c.Param("quizId")
 	quizID, err := uuid.Parse(quizIDStr)
 	if err != nil {
-		log.Printf("Error parsing quiz ID: %v\n", err)
+		h.logger.Warn("Error parsing quiz ID", "quizId", quizIDStr, "error", err)
 		response.WithError(c, http.StatusBadRequest, "Invalid quiz ID", "The provided quiz ID is not valid")
 		return
 	}
 
 	// Get connection type and ID from the URL
-	connectionType := c.Param("type") // "user" or "participant"
+	connectionType := c.Param("type") // "user", "participant" or "spectator"
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		log.Printf("Error parsing ID: %v\n", err)
-		response.WithError(c, http.StatusBadRequest, "Invalid ID", "The provided user or participant ID is not valid")
+		h.logger.Warn("Error parsing connection ID", "quizId", quizID, "id", idStr, "error", err)
+		response.WithError(c, http.StatusBadRequest, "Invalid ID", "The provided user, participant or spectator ID is not valid")
+		return
+	}
+
+	token := connectionToken(c)
+	if token == "" {
+		response.WithError(c, http.StatusUnauthorized, "Unauthorized", "A token is required to connect")
 		return
 	}
 
-	// Variable to track if this is a creator connection
+	// Variables to track if this is a creator or spectator connection
 	isCreator := false
+	isSpectator := false
 
 	// Validate the connection based on type
 	if connectionType == "user" {
+		// The token must belong to the user the connection claims to be
+		claims, err := h.jwtManager.ValidateToken(token)
+		if err != nil || claims.UserID != id {
+			h.logger.Warn("Invalid user token", "quizId", quizID, "userId", id, "error", err)
+			response.WithError(c, http.StatusUnauthorized, "Authentication failed", "Invalid or expired token")
+			return
+		}
+
 		// Get user to validate
 		user, err := h.userService.GetUserByID(c, id)
 		if err != nil {
-			log.Printf("Error getting user: %v\n", err)
+			h.logger.Warn("Error getting user", "quizId", quizID, "userId", id, "error", err)
 			response.WithError(c, http.StatusUnauthorized, "Authentication failed", "User not found")
 			return
 		}
@@ -85,43 +151,70 @@ func (h *WebSocketHandler) HandleConnection(c *gin.Context) {
 		// Check if user is the creator of this quiz
 		quiz, err := h.quizService.GetQuiz(c, quizID)
 		if err != nil {
-			log.Printf("Error getting quiz: %v\n", err)
+			h.logger.Warn("Error getting quiz", "quizId", quizID, "error", err)
 			response.WithError(c, http.StatusNotFound, "Quiz not found", "The specified quiz could not be found")
 			return
 		}
 
+		// Someone else's quiz looks the same as a missing one so its existence is not leaked
 		if quiz.CreatorID != user.ID {
-			response.WithError(c, http.StatusUnauthorized, "Authorization failed", "User is not the creator of this quiz")
+			response.WithError(c, http.StatusNotFound, "Quiz not found", "The specified quiz could not be found")
 			return
 		}
 
 		isCreator = true
 
 	} else if connectionType == "participant" {
+		// The token is issued at join time for one participant of one quiz
+		claims, err := h.jwtManager.ValidateParticipantToken(token)
+		if err != nil || claims.ParticipantID != id || claims.QuizID != quizID {
+			h.logger.Warn("Invalid participant token", "quizId", quizID, "participantId", id, "error", err)
+			response.WithError(c, http.StatusUnauthorized, "Authentication failed", "Invalid or expired token")
+			return
+		}
+
 		// Get participant to validate
 		participant, err := h.participantService.GetParticipantByID(c, id)
 		if err != nil {
-			log.Printf("Error getting participant: %v\n", err)
+			h.logger.Warn("Error getting participant", "quizId", quizID, "participantId", id, "error", err)
 			response.WithError(c, http.StatusUnauthorized, "Authentication failed", "Participant not found")
 			return
 		}
 
 		if participant.QuizID != quizID {
-			log.Printf("Participant %s is not authorized for quiz %s\n", participant.ID, quizID)
+			h.logger.Warn("Participant is not authorized for quiz", "quizId", quizID, "participantId", participant.ID)
 			response.WithError(c, http.StatusUnauthorized, "Authorization failed", "Participant not authorized for this quiz")
 			return
 		}
 
+	} else if connectionType == "spectator" {
+		// The token is issued by the creator for one display of one quiz
+		claims, err := h.jwtManager.ValidateSpectatorToken(token)
+		if err != nil || claims.SpectatorID != id || claims.QuizID != quizID {
+			h.logger.Warn("Invalid spectator token", "quizId", quizID, "spectatorId", id, "error", err)
+			response.WithError(c, http.StatusUnauthorized, "Authentication failed", "Invalid or expired token")
+			return
+		}
+
+		// The quiz may have been deleted since the token was issued
+		if _, err := h.quizService.GetQuiz(c, quizID); err != nil {
+			h.logger.Warn("Error getting quiz", "quizId", quizID, "error", err)
+			response.WithError(c, http.StatusNotFound, "Quiz not found", "The specified quiz could not be found")
+			return
+		}
+
+		isSpectator = true
+
 	} else {
-		log.Printf("Invalid connection type: %s\n", connectionType)
-		response.WithError(c, http.StatusBadRequest, "Invalid connection type", "Connection type must be 'user' or 'participant'")
+		h.logger.Warn("Invalid connection type", "quizId", quizID, "type", connectionType)
+		response.WithError(c, http.StatusBadRequest, "Invalid connection type", "Connection type must be 'user', 'participant' or 'spectator'")
 		return
 	}
 
 	// Upgrade connection to WebSocket
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		log.Printf("Error upgrading connection: %v\n", err)
+		h.logger.Error("Error upgrading connection", "quizId", quizID, "id", id, "error", err)
 		response.WithError(c, http.StatusInternalServerError, "Connection error", "Failed to upgrade connection to WebSocket")
 		return
 	}
@@ -132,25 +225,41 @@ func (h *WebSocketHandler) HandleConnection(c *gin.Context) {
 	// Create a detached background context for the WebSocket connection
 	wsCtx, cancel := context.WithCancel(context.Background())
 
+	// Creators and participants send different messages, so their connections are bounded separately;
+	// spectators send even less than participants
+	maxMessageBytes := h.wsConfig.ParticipantMaxMessageBytes
+	if isCreator {
+		maxMessageBytes = h.wsConfig.CreatorMaxMessageBytes
+	}
+
 	// Create a new client
 	client := &ws.Client{
-		ID:        clientID,
-		UserID:    id,
-		QuizID:    quizID,
-		IsCreator: isCreator,
-		Conn:      conn,
-		Send:      make(chan []byte, 256),
-		Hub:       h.hub,
-		Ctx:       wsCtx,
-		Cancel:    cancel,
-	}
-
-	// Record the connection in our state system if this is a participant
-	if !isCreator {
+		ID:            clientID,
+		UserID:        id,
+		QuizID:        quizID,
+		IsCreator:     isCreator,
+		IsSpectator:   isSpectator,
+		Conn:          conn,
+		Send:          make(chan []byte, 256),
+		Hub:           h.hub,
+		Ctx:           wsCtx,
+		Cancel:        cancel,
+		AnswerLimiter: ws.NewRateLimiter(h.wsConfig.AnswerRateLimit),
+		Logger:        h.logger,
+		SubmitAnswer:  h.answerService.Submit,
+
+		MaxMessageBytes:       maxMessageBytes,
+		MaxAnswerPayloadBytes: h.wsConfig.MaxAnswerPayloadBytes,
+		MaxSelectedOptions:    h.wsConfig.MaxSelectedOptions,
+	}
+
+	// Record the connection in our state system if this is a participant; spectators are not tracked
+	if !isCreator && !isSpectator {
 		instanceID := h.hub.GetInstanceID()
-		err = h.stateService.UpdateParticipantConnection(c, id, quizID, true, instanceID)
+		connectedAt := time.Now()
+		err = h.stateService.UpdateParticipantConnection(c, id, quizID, true, instanceID, "", connectedAt)
 		if err != nil {
-			log.Printf("Error recording participant connection: %v", err)
+			h.logger.Error("Error recording participant connection", "quizId", quizID, "participantId", id, "error", err)
 			// Continue despite error - this is not critical
 		}
 
@@ -159,50 +268,82 @@ func (h *WebSocketHandler) HandleConnection(c *gin.Context) {
 			// Wait for context cancellation (which happens when the connection closes)
 			<-wsCtx.Done()
 
-			// Mark participant as disconnected
+			// The participant's records went away with a deleted quiz or with the kick, and the kick
+			// already told the room, so there is no connection to record and no USER_LEFT to send
+			if reason := client.DisconnectReason(); reason == model.DisconnectReasonQuizDeleted || reason == model.DisconnectReasonKicked {
+				return
+			}
+
+			// Mark participant as disconnected, recording why the connection ended
 			ctx := context.Background()
-			err := h.stateService.UpdateParticipantConnection(ctx, participantID, quizID, false, instanceID)
+			err := h.stateService.UpdateParticipantConnection(ctx, participantID, quizID, false, instanceID, client.DisconnectReason(), connectedAt)
 			if err != nil {
-				log.Printf("Error updating participant disconnection: %v", err)
+				h.logger.Error("Error updating participant disconnection", "quizId", quizID, "participantId", participantID, "error", err)
 			}
 		}(id, quizID, instanceID)
 	} else {
-		// For creator connections, we don't need to track connections in the same way,
+		// For creator and spectator connections, we don't need to track connections in the same way,
 		// but we might want to register the instance
 		instanceID := h.hub.GetInstanceID()
 		err = h.stateService.RegisterInstance(c, instanceID)
 		if err != nil {
-			log.Printf("Error registering instance: %v", err)
+			h.logger.Error("Error registering instance", "instanceId", instanceID, "error", err)
 			// Continue despite error
 		}
 	}
 
+	// Register client with the hub before subscribing, so the quiz's subscription cannot be
+	// released in between for having no clients
+	h.hub.GetRegisterChan() <- client
+
 	// Subscribe to Redis events for this quiz if not already subscribed
 	if err := h.hub.SubscribeToQuiz(quizID); err != nil {
+		h.hub.GetUnregisterChan() <- client
 		response.WithError(c, http.StatusInternalServerError, "Subscription error", "Failed to subscribe to quiz events")
 		conn.Close()
 		cancel()
 		return
 	}
 
-	// Register client with the hub
-	h.hub.GetRegisterChan() <- client
-
 	// Start goroutines for reading and writing
 	go client.ReadPump()
 	go client.WritePump()
 
-	// Get current quiz state and send it to the client for initial synchronization
-	if state, err := h.stateService.GetQuizState(c, quizID); err == nil {
-		// Send full state to the client
-		stateEvent := ws.Event{
+	// Send the current quiz state to the client for initial synchronization
+	for _, event := range h.initialEvents(c, quizID, isCreator) {
+		if jsonData, err := json.Marshal(event); err == nil {
+			client.Send <- jsonData
+		}
+	}
+}
+
+// initialEvents returns the events a newly connected client is sent to synchronise with the quiz
+func (h *WebSocketHandler) initialEvents(ctx context.Context, quizID uuid.UUID, isCreator bool) []ws.Event {
+	var events []ws.Event
+
+	// Only creators see the active question's correct answers, as in the QUESTION_START they receive live
+	if state, err := h.stateService.GetQuizState(ctx, quizID, isCreator); err == nil {
+		// Large quizzes get a trimmed state so the initial frame stays small;
+		// the full participant list is available from the participants endpoint
+		state = dto.StateSyncPayload(state, h.wsConfig.StateSyncParticipantThreshold, h.wsConfig.StateSyncLeaderboardSize)
+		events = append(events, ws.Event{
 			Type:    ws.EventStateSync,
 			Payload: state,
-		}
+		})
+	}
 
-		// Marshal to JSON
-		if jsonData, err := json.Marshal(stateEvent); err == nil {
-			client.Send <- jsonData
+	// Creators also get a host-oriented view of the lobby
+	if isCreator {
+		snapshot, err := h.stateService.GetLobbySnapshot(ctx, quizID)
+		if err != nil {
+			h.logger.Error("Error building lobby snapshot", "quizId", quizID, "error", err)
+			return events
 		}
+		events = append(events, ws.Event{
+			Type:    ws.EventLobbySnapshot,
+			Payload: snapshot,
+		})
 	}
+
+	return events
 }
@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/service"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/auth"
+	ws "github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// stubStateService serves a fixed quiz state and lobby snapshot; its other StateService methods are not implemented
+type stubStateService struct {
+	service.StateService
+	snapshotCalls int
+}
+
+func (s *stubStateService) GetQuizState(ctx context.Context, quizID uuid.UUID, forCreator bool) (*dto.QuizStateDTO, error) {
+	return &dto.QuizStateDTO{QuizID: quizID, Participants: map[string]dto.ParticipantStateDTO{}}, nil
+}
+
+func (s *stubStateService) GetLobbySnapshot(ctx context.Context, quizID uuid.UUID) (*dto.LobbySnapshotDTO, error) {
+	s.snapshotCalls++
+	return &dto.LobbySnapshotDTO{QuizID: quizID}, nil
+}
+
+// eventTypes returns the types of events in order
+func eventTypes(events []ws.Event) []ws.EventType {
+	types := make([]ws.EventType, 0, len(events))
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestInitialEventsIncludeTheLobbySnapshotForCreatorsOnly(t *testing.T) {
+	tests := []struct {
+		name      string
+		isCreator bool
+		want      []ws.EventType
+	}{
+		{name: "creator", isCreator: true, want: []ws.EventType{ws.EventStateSync, ws.EventLobbySnapshot}},
+		{name: "participant", isCreator: false, want: []ws.EventType{ws.EventStateSync}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stateService := &stubStateService{}
+			handler := &WebSocketHandler{stateService: stateService}
+			quizID := uuid.New()
+
+			events := handler.initialEvents(context.Background(), quizID, tt.isCreator)
+
+			got := eventTypes(events)
+			if len(got) != len(tt.want) {
+				t.Fatalf("client was sent %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("client was sent %v, want %v", got, tt.want)
+				}
+			}
+			if !tt.isCreator && stateService.snapshotCalls != 0 {
+				t.Error("a lobby snapshot was built for a participant")
+			}
+			if tt.isCreator {
+				snapshot := events[1].Payload.(*dto.LobbySnapshotDTO)
+				if snapshot.QuizID != quizID {
+					t.Error("lobby snapshot is of another quiz")
+				}
+			}
+		})
+	}
+}
+
+func TestOriginChecker(t *testing.T) {
+	explicit := config.ServerConfig{AllowedOrigins: []string{"https://quiz.example.com/", " https://admin.example.com"}}
+
+	tests := []struct {
+		name   string
+		server config.ServerConfig
+		origin string
+		want   bool
+	}{
+		{name: "allowed origin", server: explicit, origin: "https://quiz.example.com", want: true},
+		{name: "allowed origin in another case", server: explicit, origin: "https://Quiz.Example.com", want: true},
+		{name: "trimmed allowed origin", server: explicit, origin: "https://admin.example.com", want: true},
+		{name: "disallowed origin", server: explicit, origin: "https://evil.example.com", want: false},
+		{name: "allowed host over another scheme", server: explicit, origin: "http://quiz.example.com", want: false},
+		{name: "no origin header", server: explicit, origin: "", want: true},
+		{name: "default localhost origin", server: config.ServerConfig{}, origin: "http://localhost:3000", want: true},
+		{name: "non-local origin by default", server: config.ServerConfig{}, origin: "https://quiz.example.com", want: false},
+		{name: "wildcard", server: config.ServerConfig{AllowedOrigins: []string{"*"}}, origin: "https://anywhere.example.com", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ws/quiz", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			if got := newOriginChecker(tt.server)(req); got != tt.want {
+				t.Errorf("CheckOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+// stubParticipantService serves fixed participants; its other ParticipantService methods are not implemented
+type stubParticipantService struct {
+	service.ParticipantService
+	participants map[uuid.UUID]*model.Participant
+}
+
+func (s *stubParticipantService) GetParticipantByID(ctx context.Context, id uuid.UUID) (*model.Participant, error) {
+	participant, ok := s.participants[id]
+	if !ok {
+		return nil, errors.New("participant not found")
+	}
+	return participant, nil
+}
+
+func (s *stubParticipantService) GetParticipantsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.Participant, error) {
+	var participants []*model.Participant
+	for _, participant := range s.participants {
+		if participant.QuizID == quizID {
+			participants = append(participants, participant)
+		}
+	}
+	return participants, nil
+}
+
+func (s *stubParticipantService) CountParticipantsByQuizID(ctx context.Context, quizID uuid.UUID) (int, error) {
+	var count int
+	for _, participant := range s.participants {
+		if participant.QuizID == quizID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func TestHandleConnectionRejectsInvalidTokens(t *testing.T) {
+	jwtConfig := config.JWTConfig{Secret: "test-secret", ExpirationTime: time.Hour, ParticipantExpTime: time.Hour}
+	jwtManager := auth.NewJWTManager(jwtConfig)
+	expiredConfig := jwtConfig
+	expiredConfig.ExpirationTime = -time.Minute
+	expiredConfig.ParticipantExpTime = -time.Minute
+	expiredManager := auth.NewJWTManager(expiredConfig)
+	forgedConfig := jwtConfig
+	forgedConfig.Secret = "another-secret"
+	forgedManager := auth.NewJWTManager(forgedConfig)
+
+	creator := &model.User{ID: uuid.New(), Email: "host@example.com"}
+	stranger := &model.User{ID: uuid.New(), Email: "stranger@example.com"}
+	quiz := &model.Quiz{ID: uuid.New(), CreatorID: creator.ID}
+	otherQuiz := &model.Quiz{ID: uuid.New(), CreatorID: stranger.ID}
+	participant := &model.Participant{ID: uuid.New(), QuizID: quiz.ID}
+	spectatorID := uuid.New()
+
+	must := func(token string, err error) string {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("generate token: %v", err)
+		}
+		return token
+	}
+	creatorToken := must(jwtManager.GenerateToken(creator.ID, creator.Email))
+	strangerToken := must(jwtManager.GenerateToken(stranger.ID, stranger.Email))
+	participantToken := must(jwtManager.GenerateParticipantToken(participant.ID, quiz.ID))
+	spectatorToken := must(jwtManager.GenerateSpectatorToken(spectatorID, quiz.ID))
+
+	tests := []struct {
+		name     string
+		kind     string
+		id       uuid.UUID
+		quizID   uuid.UUID
+		token    string
+		wantCode int
+	}{
+		{name: "creator without a token", kind: "user", id: creator.ID, quizID: quiz.ID, wantCode: http.StatusUnauthorized},
+		{name: "creator with another user's token", kind: "user", id: creator.ID, quizID: quiz.ID, token: strangerToken, wantCode: http.StatusUnauthorized},
+		{name: "creator with a participant token", kind: "user", id: participant.ID, quizID: quiz.ID, token: participantToken, wantCode: http.StatusUnauthorized},
+		{name: "creator with an expired token", kind: "user", id: creator.ID, quizID: quiz.ID, token: must(expiredManager.GenerateToken(creator.ID, creator.Email)), wantCode: http.StatusUnauthorized},
+		{name: "creator with a forged token", kind: "user", id: creator.ID, quizID: quiz.ID, token: must(forgedManager.GenerateToken(creator.ID, creator.Email)), wantCode: http.StatusUnauthorized},
+		{name: "user of someone else's quiz", kind: "user", id: stranger.ID, quizID: quiz.ID, token: strangerToken, wantCode: http.StatusNotFound},
+		{name: "participant without a token", kind: "participant", id: participant.ID, quizID: quiz.ID, wantCode: http.StatusUnauthorized},
+		{name: "participant with a user token", kind: "participant", id: creator.ID, quizID: quiz.ID, token: creatorToken, wantCode: http.StatusUnauthorized},
+		{name: "participant with another participant's token", kind: "participant", id: uuid.New(), quizID: quiz.ID, token: participantToken, wantCode: http.StatusUnauthorized},
+		{name: "participant token for another quiz", kind: "participant", id: participant.ID, quizID: otherQuiz.ID, token: participantToken, wantCode: http.StatusUnauthorized},
+		{name: "participant with an expired token", kind: "participant", id: participant.ID, quizID: quiz.ID, token: must(expiredManager.GenerateParticipantToken(participant.ID, quiz.ID)), wantCode: http.StatusUnauthorized},
+		{name: "spectator with a participant token", kind: "spectator", id: participant.ID, quizID: quiz.ID, token: participantToken, wantCode: http.StatusUnauthorized},
+		{name: "spectator token for another quiz", kind: "spectator", id: spectatorID, quizID: otherQuiz.ID, token: spectatorToken, wantCode: http.StatusUnauthorized},
+		{name: "participant with a spectator token", kind: "participant", id: spectatorID, quizID: quiz.ID, token: spectatorToken, wantCode: http.StatusUnauthorized},
+	}
+
+	gin.SetMode(gin.TestMode)
+	handler := NewWebSocketHandler(
+		nil,
+		&stubQuizService{quizzes: map[uuid.UUID]*model.Quiz{quiz.ID: quiz, otherQuiz.ID: otherQuiz}},
+		&stubUserService{users: map[uuid.UUID]*model.User{creator.ID: creator, stranger.ID: stranger}},
+		&stubParticipantService{participants: map[uuid.UUID]*model.Participant{participant.ID: participant}},
+		&stubStateService{},
+		nil,
+		jwtManager,
+		config.WebSocketConfig{},
+		config.ServerConfig{},
+		nil,
+	)
+	router := gin.New()
+	router.GET("/ws/:quizId/:type/:id", handler.HandleConnection)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/ws/" + tt.quizID.String() + "/" + tt.kind + "/" + tt.id.String()
+			if tt.token != "" {
+				path += "?token=" + tt.token
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+			if recorder.Code != tt.wantCode {
+				t.Errorf("connection returned %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+		})
+	}
+
+	// Valid tokens get past authentication to the upgrade, which a plain HTTP request then fails
+	valid := []struct {
+		kind  string
+		id    uuid.UUID
+		token string
+	}{
+		{kind: "user", id: creator.ID, token: creatorToken},
+		{kind: "participant", id: participant.ID, token: participantToken},
+		{kind: "spectator", id: spectatorID, token: spectatorToken},
+	}
+	for _, v := range valid {
+		path := "/ws/" + quiz.ID.String() + "/" + v.kind + "/" + v.id.String() + "?token=" + v.token
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		if recorder.Code == http.StatusUnauthorized || recorder.Code == http.StatusNotFound {
+			t.Errorf("%s connection with a valid token was rejected with %d: %s", v.kind, recorder.Code, recorder.Body)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AdminMiddleware restricts a route group to the authenticated users whose ID is in userIDs.
+// The check is on the user ID rather than the email, since anyone can register an unverified email.
+// It must run after JWTAuthMiddleware.
+func AdminMiddleware(userIDs []string) gin.HandlerFunc {
+	admins := make(map[uuid.UUID]bool, len(userIDs))
+	for _, raw := range userIDs {
+		// Entries that are not valid IDs match nobody
+		if id, err := uuid.Parse(strings.TrimSpace(raw)); err == nil && id != uuid.Nil {
+			admins[id] = true
+		}
+	}
+
+	return func(c *gin.Context) {
+		user := GetAuthUser(c)
+		if user == nil {
+			response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+			c.Abort()
+			return
+		}
+
+		if !admins[user.ID] {
+			response.WithError(c, http.StatusForbidden, "Access denied", "Administrator access required")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
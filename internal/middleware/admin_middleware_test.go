@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestAdminMiddleware(t *testing.T) {
+	admin := uuid.New()
+	tests := []struct {
+		name     string
+		user     *model.User
+		wantCode int
+	}{
+		{name: "configured user", user: &model.User{ID: admin, Email: "someone@example.com"}, wantCode: http.StatusOK},
+		// Registration does not verify emails, so an operator's address on another account grants nothing
+		{name: "other user with the operator's email", user: &model.User{ID: uuid.New(), Email: "ops@example.com"}, wantCode: http.StatusForbidden},
+		{name: "unauthenticated", user: nil, wantCode: http.StatusUnauthorized},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.user != nil {
+					c.Set(AuthUserKey, tt.user)
+				}
+			})
+			router.Use(AdminMiddleware([]string{" " + admin.String() + " ", "ops@example.com", ""}))
+			router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if recorder.Code != tt.wantCode {
+				t.Errorf("request returned %d, want %d", recorder.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// BodySizeLimitMiddleware rejects request bodies larger than maxBytes so an oversized payload cannot
+// exhaust memory while it is decoded. Bodies that announce their size are refused up front with 413;
+// others fail to read past the limit. A non-positive maxBytes disables the limit.
+func BodySizeLimitMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			response.WithError(c, http.StatusRequestEntityTooLarge, "Request too large",
+				fmt.Sprintf("Request body must be at most %d bytes", maxBytes))
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBodySizeLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxBytes int64
+		body     string
+		// chunked hides the body's length so only reading it can find it too large
+		chunked  bool
+		wantCode int
+	}{
+		{name: "within the limit", maxBytes: 16, body: strings.Repeat("a", 16), wantCode: http.StatusOK},
+		{name: "declared over the limit", maxBytes: 16, body: strings.Repeat("a", 17), wantCode: http.StatusRequestEntityTooLarge},
+		{name: "streamed over the limit", maxBytes: 16, body: strings.Repeat("a", 17), chunked: true, wantCode: http.StatusBadRequest},
+		{name: "without a limit", maxBytes: 0, body: strings.Repeat("a", 1024), wantCode: http.StatusOK},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(BodySizeLimitMiddleware(tt.maxBytes))
+			router.POST("/", func(c *gin.Context) {
+				if _, err := io.ReadAll(c.Request.Body); err != nil {
+					c.Status(http.StatusBadRequest)
+					return
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantCode {
+				t.Errorf("request returned %d, want %d", recorder.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisteredUserMiddleware refuses guest hosts, whose token only lets them run the quiz they were created with.
+// It must run after JWTAuthMiddleware.
+func RegisteredUserMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := GetAuthUser(c)
+		if user == nil {
+			response.WithError(c, http.StatusUnauthorized, "Unauthorized", "Authentication required")
+			c.Abort()
+			return
+		}
+
+		if user.IsGuest {
+			response.WithError(c, http.StatusForbidden, "Access denied", "Guest hosts can only run the quiz they created; register to create more")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
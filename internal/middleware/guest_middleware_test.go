@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRegisteredUserMiddlewareRefusesGuestTokens(t *testing.T) {
+	jwtManager := auth.NewJWTManager(config.JWTConfig{
+		Secret:         "test-secret",
+		ExpirationTime: time.Hour,
+		GuestExpTime:   time.Hour,
+		Issuer:         "test",
+	})
+	userToken, err := jwtManager.GenerateToken(uuid.New(), "host@example.com")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	guestToken, err := jwtManager.GenerateGuestToken(uuid.New(), "guest@example.com")
+	if err != nil {
+		t.Fatalf("GenerateGuestToken: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(JWTAuthMiddleware(jwtManager))
+	// Guests may use routes that are not restricted to registered users
+	router.GET("/own", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/quizzes", RegisteredUserMiddleware(), func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	tests := []struct {
+		name     string
+		token    string
+		method   string
+		path     string
+		wantCode int
+	}{
+		{name: "registered user creates a quiz", token: userToken, method: http.MethodPost, path: "/quizzes", wantCode: http.StatusCreated},
+		{name: "guest creates a quiz", token: guestToken, method: http.MethodPost, path: "/quizzes", wantCode: http.StatusForbidden},
+		{name: "guest uses an unrestricted route", token: guestToken, method: http.MethodGet, path: "/own", wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(AuthorizationHeaderKey, BearerToken+" "+tt.token)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantCode {
+				t.Errorf("request returned %d, want %d", recorder.Code, tt.wantCode)
+			}
+		})
+	}
+}
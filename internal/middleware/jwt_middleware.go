@@ -63,8 +63,9 @@ func JWTAuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
 
 		// Create a user object from claims
 		user := &model.User{
-			ID:    claims.UserID,
-			Email: claims.Email,
+			ID:      claims.UserID,
+			Email:   claims.Email,
+			IsGuest: claims.IsGuest(),
 		}
 
 		// Set user in context
@@ -73,6 +74,31 @@ func JWTAuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
 	}
 }
 
+// OptionalJWTAuthMiddleware sets the authenticated user when a valid bearer token is sent,
+// but lets anonymous requests through. Invalid tokens are treated as anonymous.
+func OptionalJWTAuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fields := strings.Fields(c.GetHeader(AuthorizationHeaderKey))
+		if len(fields) < 2 || fields[0] != BearerToken {
+			c.Next()
+			return
+		}
+
+		claims, err := jwtManager.ValidateToken(fields[1])
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		c.Set(AuthUserKey, &model.User{
+			ID:      claims.UserID,
+			Email:   claims.Email,
+			IsGuest: claims.IsGuest(),
+		})
+		c.Next()
+	}
+}
+
 // GetAuthUser retrieves the authenticated user from the context
 func GetAuthUser(c *gin.Context) *model.User {
 	user, exists := c.Get(AuthUserKey)
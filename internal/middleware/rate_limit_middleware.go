@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// clientWindow counts the requests a client made in the window starting at start
+type clientWindow struct {
+	start time.Time
+	count int
+}
+
+// IPRateLimitMiddleware allows each client IP at most limit requests per window and refuses the rest
+// with 429 until the client's window ends. Counts are kept per instance. A non-positive limit disables it.
+func IPRateLimitMiddleware(limit int, window time.Duration) gin.HandlerFunc {
+	var mu sync.Mutex
+	clients := make(map[string]*clientWindow)
+	lastPrune := time.Now()
+
+	return func(c *gin.Context) {
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		now := time.Now()
+		mu.Lock()
+		// Forget finished windows now and then so the map only holds recent clients
+		if now.Sub(lastPrune) >= window {
+			for ip, w := range clients {
+				if now.Sub(w.start) >= window {
+					delete(clients, ip)
+				}
+			}
+			lastPrune = now
+		}
+
+		ip := c.ClientIP()
+		w, ok := clients[ip]
+		if !ok || now.Sub(w.start) >= window {
+			w = &clientWindow{start: now}
+			clients[ip] = w
+		}
+		w.count++
+		allowed := w.count <= limit
+		retryAfter := w.start.Add(window).Sub(now)
+		mu.Unlock()
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			response.WithError(c, http.StatusTooManyRequests, "Too many requests",
+				fmt.Sprintf("At most %d requests are allowed every %s", limit, window))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
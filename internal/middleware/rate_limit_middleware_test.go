@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIPRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(IPRateLimitMiddleware(2, time.Hour))
+	router.POST("/", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	post := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = remoteAddr
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	for i := 0; i < 2; i++ {
+		if got := post("203.0.113.7:1234").Code; got != http.StatusCreated {
+			t.Fatalf("request %d within the limit returned %d", i+1, got)
+		}
+	}
+	limited := post("203.0.113.7:5678")
+	if limited.Code != http.StatusTooManyRequests || limited.Header().Get("Retry-After") == "" {
+		t.Errorf("request over the limit returned %d with Retry-After %q, want 429 with a Retry-After",
+			limited.Code, limited.Header().Get("Retry-After"))
+	}
+	if got := post("198.51.100.2:1234").Code; got != http.StatusCreated {
+		t.Errorf("another client was limited too: %d", got)
+	}
+}
+
+func TestIPRateLimitMiddlewareDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(IPRateLimitMiddleware(0, time.Hour))
+	router.POST("/", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	for i := 0; i < 5; i++ {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", nil))
+		if recorder.Code != http.StatusCreated {
+			t.Fatalf("request %d returned %d without a limit", i+1, recorder.Code)
+		}
+	}
+}
@@ -7,6 +7,12 @@ import (
 	"github.com/google/uuid"
 )
 
+// CorrectAnswerPoints is the base score of a correct answer, before any speed bonus
+const CorrectAnswerPoints = 100
+
+// TimeBonusPoints are added to a correct answer given in less than half the question's time limit
+const TimeBonusPoints = 20
+
 // Answer represents a participant's answer to a question
 type Answer struct {
 	ID              uuid.UUID `json:"id" db:"id"`
@@ -17,7 +23,8 @@ type Answer struct {
 	AnsweredAt      time.Time `json:"answeredAt" db:"answered_at"`
 	TimeTaken       float64   `json:"timeTaken" db:"time_taken"` // Time taken in seconds
 	IsCorrect       bool      `json:"isCorrect" db:"is_correct"`
-	Score           int       `json:"score" db:"score"`
+	Score           int       `json:"score" db:"score"`                        // Points awarded for the answer, including any speed bonus
+	ClientToken     string    `json:"clientToken,omitempty" db:"client_token"` // Optional token making retried submissions idempotent
 }
 
 // SetSelectedOptions sets the selected options and updates the JSON representation
@@ -58,7 +65,7 @@ func (a *Answer) GetSelectedOptions() ([]string, error) {
 func NewAnswer(participantID, questionID uuid.UUID, selectedOptions []string, timeTaken float64, isCorrect bool) (*Answer, error) {
 	score := 0
 	if isCorrect {
-		score = 100
+		score = CorrectAnswerPoints
 	}
 
 	answer := &Answer{
@@ -8,11 +8,24 @@ import (
 
 // Participant represents a user who joins and participates in quizzes
 type Participant struct {
-	ID       uuid.UUID `json:"id" db:"id"`
-	Name     string    `json:"name" db:"name"`
-	QuizID   uuid.UUID `json:"quizId" db:"quiz_id"`
-	Score    int       `json:"score" db:"score"`
-	JoinedAt time.Time `json:"joinedAt" db:"joined_at"`
+	ID       uuid.UUID  `json:"id" db:"id"`
+	Name     string     `json:"name" db:"name"`
+	QuizID   uuid.UUID  `json:"quizId" db:"quiz_id"`
+	Score    int        `json:"score" db:"score"`
+	TeamID   *uuid.UUID `json:"teamId,omitempty" db:"team_id"`
+	JoinedAt time.Time  `json:"joinedAt" db:"joined_at"`
+	Rank     int        `json:"rank,omitempty" db:"-"` // Competition rank, only set when loaded as part of a leaderboard
+	// TotalAnswerTime and AnswerCount aggregate the participant's answers; only set when loaded as part of a leaderboard
+	TotalAnswerTime float64 `json:"totalAnswerTime,omitempty" db:"-"`
+	AnswerCount     int     `json:"answerCount,omitempty" db:"-"`
+}
+
+// AverageAnswerTime returns how long the participant took per answer in seconds, or 0 if they never answered
+func (p *Participant) AverageAnswerTime() float64 {
+	if p.AnswerCount == 0 {
+		return 0
+	}
+	return p.TotalAnswerTime / float64(p.AnswerCount)
 }
 
 // NewParticipant creates a new participant for a quiz
@@ -24,4 +37,22 @@ func NewParticipant(name string, quizID uuid.UUID) *Participant {
 		Score:    0,
 		JoinedAt: time.Now(),
 	}
-}
\ No newline at end of file
+}
+
+// QuizBan records a participant a creator removed from a quiz and barred from rejoining under the same name
+type QuizBan struct {
+	QuizID        uuid.UUID `json:"quizId" db:"quiz_id"`
+	ParticipantID uuid.UUID `json:"participantId" db:"participant_id"`
+	Name          string    `json:"name" db:"name"`
+	BannedAt      time.Time `json:"bannedAt" db:"banned_at"`
+}
+
+// NewQuizBan creates a ban for the given participant
+func NewQuizBan(participant *Participant) *QuizBan {
+	return &QuizBan{
+		QuizID:        participant.QuizID,
+		ParticipantID: participant.ID,
+		Name:          participant.Name,
+		BannedAt:      time.Now(),
+	}
+}
@@ -16,14 +16,32 @@ const (
 	QuestionTypeMultipleChoice QuestionType = "MULTIPLE_CHOICE"
 )
 
+// VoidPolicy decides how a voided question is scored
+type VoidPolicy string
+
+const (
+	// VoidPolicyRemove takes the question's points away from everyone
+	VoidPolicyRemove VoidPolicy = "REMOVE"
+	// VoidPolicyFullPoints credits everyone the points of a correct answer
+	VoidPolicyFullPoints VoidPolicy = "FULL_POINTS"
+)
+
 // Question represents a quiz question
 type Question struct {
-	ID           uuid.UUID         `json:"id" db:"id"`
-	QuizID       uuid.UUID         `json:"quizId" db:"quiz_id"`
-	Text         string            `json:"text" db:"text"`
-	QuestionType QuestionType      `json:"questionType" db:"question_type"`
-	TimeLimit    int               `json:"timeLimit" db:"time_limit"`
-	Order        int               `json:"order" db:"order"`
+	ID           uuid.UUID    `json:"id" db:"id"`
+	QuizID       uuid.UUID    `json:"quizId" db:"quiz_id"`
+	Text         string       `json:"text" db:"text"`
+	QuestionType QuestionType `json:"questionType" db:"question_type"`
+	TimeLimit    int          `json:"timeLimit" db:"time_limit"`
+	Order        int          `json:"order" db:"order"`
+	// ImageURL is an optional http or https image shown with the question; empty when there is none
+	ImageURL string `json:"imageUrl,omitempty" db:"image_url"`
+	// Explanation optionally says why the correct answer is correct; it is only shown once the question ends
+	Explanation string `json:"explanation,omitempty" db:"explanation"`
+	// Required marks a question every participant is expected to answer, such as a survey question
+	Required bool `json:"required" db:"is_required"`
+	// VoidedPoints is set once the question is voided: every participant is credited exactly this many points for it
+	VoidedPoints *int              `json:"voidedPoints,omitempty" db:"voided_points"`
 	CreatedAt    time.Time         `json:"createdAt" db:"created_at"`
 	UpdatedAt    time.Time         `json:"updatedAt" db:"updated_at"`
 	Options      []*QuestionOption `json:"options" db:"-"` // Will be loaded separately from DB
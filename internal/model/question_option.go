@@ -29,3 +29,21 @@ func NewQuestionOption(questionID uuid.UUID, text string, isCorrect bool, displa
 		UpdatedAt:    time.Now(),
 	}
 }
+
+// Label returns the option's letter (A, B, C, ...) derived from its canonical display order,
+// so it stays attached to the same option however the options are arranged on screen
+func (o *QuestionOption) Label() string {
+	n := o.DisplayOrder
+	if n < 1 {
+		return ""
+	}
+
+	// Orders past Z continue as AA, AB, ...
+	label := ""
+	for n > 0 {
+		n--
+		label = string(rune('A'+n%26)) + label
+		n /= 26
+	}
+	return label
+}
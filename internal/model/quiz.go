@@ -1,6 +1,7 @@
 package model
 
 import (
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -30,16 +31,89 @@ const (
 	QuizPhaseShowingResults QuizPhase = "SHOWING_RESULTS"
 )
 
+// LateAnswerPolicy decides what happens to an answer that arrives while a quiz is showing results
+type LateAnswerPolicy string
+
+const (
+	// LateAnswerPolicyReject refuses the answer; an empty policy behaves the same
+	LateAnswerPolicyReject LateAnswerPolicy = "REJECT"
+	// LateAnswerPolicyAcceptNoBonus records and scores the answer without the time bonus
+	LateAnswerPolicyAcceptNoBonus LateAnswerPolicy = "ACCEPT_NO_BONUS"
+)
+
 // Quiz represents a quiz that can be joined by users
 type Quiz struct {
-	ID          uuid.UUID  `json:"id" db:"id"`
-	Title       string     `json:"title" db:"title"`
-	Description string     `json:"description" db:"description"`
-	CreatorID   uuid.UUID  `json:"creatorId" db:"creator_id"`
-	Status      QuizStatus `json:"status" db:"status"`
-	Code        string     `json:"code" db:"code"`
-	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
-	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
+	ID          uuid.UUID    `json:"id" db:"id"`
+	Title       string       `json:"title" db:"title"`
+	Description string       `json:"description" db:"description"`
+	CreatorID   uuid.UUID    `json:"creatorId" db:"creator_id"`
+	Status      QuizStatus   `json:"status" db:"status"`
+	Code        string       `json:"code" db:"code"`
+	Settings    QuizSettings `json:"settings" db:"settings"`
+	CreatedAt   time.Time    `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time    `json:"updatedAt" db:"updated_at"`
+	// ArchivedAt is when the creator archived the quiz; nil if it is not archived
+	ArchivedAt *time.Time `json:"archivedAt,omitempty" db:"archived_at"`
+}
+
+// LeaderboardTiebreak decides the order of participants tied on score
+type LeaderboardTiebreak string
+
+const (
+	// LeaderboardTiebreakTime ranks the fastest total answer time first; an empty tiebreak behaves the same
+	LeaderboardTiebreakTime LeaderboardTiebreak = "TIME"
+	// LeaderboardTiebreakJoinOrder ranks the participant who joined first first
+	LeaderboardTiebreakJoinOrder LeaderboardTiebreak = "JOIN_ORDER"
+	// LeaderboardTiebreakRandom ranks tied participants in a shuffled order derived from the quiz's tiebreak seed
+	LeaderboardTiebreakRandom LeaderboardTiebreak = "RANDOM"
+)
+
+// QuizSettings holds per-quiz options a creator can change before and during a quiz
+type QuizSettings struct {
+	// AllowLateJoin lets participants join after the quiz has started
+	AllowLateJoin bool `json:"allowLateJoin"`
+	// MaxParticipants caps how many participants may join; 0 means unlimited
+	MaxParticipants int `json:"maxParticipants"`
+	// Anonymous hides which participants have answered from the host's live view
+	Anonymous bool `json:"anonymous"`
+	// RejectDisconnectedAnswers refuses answers from participants whose WebSocket connection has dropped
+	RejectDisconnectedAnswers bool `json:"rejectDisconnectedAnswers"`
+	// ManualAdvance leaves questions open when their time runs out; the countdown is only visual
+	// and the host ends each question themselves
+	ManualAdvance bool `json:"manualAdvance"`
+	// DisableTimeBonus scores correct answers on correctness alone, without the bonus for answering fast
+	DisableTimeBonus bool `json:"disableTimeBonus"`
+	// LateAnswerPolicy handles answers submitted after the question ended, while results are shown
+	LateAnswerPolicy LateAnswerPolicy `json:"lateAnswerPolicy,omitempty"`
+	// Tiebreak orders participants tied on score in the leaderboard
+	Tiebreak LeaderboardTiebreak `json:"tiebreak,omitempty"`
+	// TiebreakSeed makes the random tiebreak reproducible across recomputations
+	TiebreakSeed int64 `json:"tiebreakSeed,omitempty"`
+	// ShuffleOptions shows each question's options to participants in a shuffled order; creators keep the stored one
+	ShuffleOptions bool `json:"shuffleOptions"`
+	// ShuffleQuestions runs the questions in a shuffled order instead of their stored one
+	ShuffleQuestions bool `json:"shuffleQuestions"`
+	// ShuffleSeed makes both shuffles the same for every participant and stable across settings saves
+	ShuffleSeed int64 `json:"shuffleSeed,omitempty"`
+}
+
+// QuizFilter narrows down and pages a list of quizzes
+type QuizFilter struct {
+	// Status keeps only quizzes in this status; empty keeps all
+	Status QuizStatus
+	// Search keeps only quizzes whose title contains it, ignoring case; empty keeps all
+	Search string
+	// IncludeArchived also keeps archived quizzes, which are left out otherwise
+	IncludeArchived bool
+	// Limit caps how many quizzes are returned; 0 returns all of them
+	Limit  int
+	Offset int
+}
+
+// QuizActivity pairs a quiz with the last time anything happened in it
+type QuizActivity struct {
+	Quiz           *Quiz     `json:"quiz"`
+	LastActivityAt time.Time `json:"lastActivityAt"`
 }
 
 // QuizSession represents the current state of an active quiz
@@ -53,6 +127,40 @@ type QuizSession struct {
 	CurrentQuestionStartedAt *time.Time `json:"currentQuestionStartedAt" db:"current_question_started_at"`
 	CurrentQuestionEndedAt   *time.Time `json:"currentQuestionEndedAt" db:"current_question_ended_at"`
 	NextQuestionID           *uuid.UUID `json:"nextQuestionId" db:"next_question_id"`
+	// CurrentQuestionExtraSeconds is how much time the creator added to the running question
+	CurrentQuestionExtraSeconds int `json:"currentQuestionExtraSeconds" db:"current_question_extra_seconds"`
+	// LeaderboardFrozen holds back leaderboard broadcasts until the creator reveals the standings
+	LeaderboardFrozen bool `json:"leaderboardFrozen" db:"leaderboard_frozen"`
+	// FrozenLeaderboard is the standings when the leaderboard was frozen, compared against on reveal
+	FrozenLeaderboard []LeaderboardStanding `json:"-" db:"frozen_leaderboard"`
+}
+
+// CurrentQuestionDeadline returns when the running question's time runs out on the server clock: its start
+// plus its time limit and the time the creator added. ok is false while no question has started.
+// Every countdown, synced or broadcast, is derived from it so clients never see diverging timers.
+func (s *QuizSession) CurrentQuestionDeadline(timeLimit int) (deadline time.Time, ok bool) {
+	if s.CurrentQuestionStartedAt == nil {
+		return time.Time{}, false
+	}
+	duration := time.Duration(timeLimit+s.CurrentQuestionExtraSeconds) * time.Second
+	return s.CurrentQuestionStartedAt.Add(duration), true
+}
+
+// RemainingSeconds returns the whole seconds left until deadline at now, rounded up so that it only
+// reaches zero once the time is up
+func RemainingSeconds(deadline time.Time, now time.Time) int {
+	remaining := deadline.Sub(now)
+	if remaining <= 0 {
+		return 0
+	}
+	return int(math.Ceil(remaining.Seconds()))
+}
+
+// LeaderboardStanding is a participant's place in a leaderboard snapshot
+type LeaderboardStanding struct {
+	ParticipantID uuid.UUID `json:"participantId"`
+	Rank          int       `json:"rank"`
+	Score         int       `json:"score"`
 }
 
 // NewQuiz creates a new quiz with the given title, description, and creator ID
@@ -63,14 +171,15 @@ func NewQuiz(title string, description string, creatorID uuid.UUID) *Quiz {
 		Description: description,
 		CreatorID:   creatorID,
 		Status:      QuizStatusWaiting,
-		Code:        generateQuizCode(),
+		Code:        GenerateQuizCode(),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 }
 
-// generateQuizCode generates a random alphanumeric code for a quiz
-func generateQuizCode() string {
+// GenerateQuizCode generates a random alphanumeric code for a quiz.
+// Codes are not guaranteed to be unique; callers must check for collisions.
+func GenerateQuizCode() string {
 	const charset = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // Removed similar looking chars
 	const codeLength = 6
 
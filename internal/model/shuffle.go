@@ -0,0 +1,71 @@
+package model
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// ShuffledQuestions returns the questions in the run order of a quiz that shuffles its questions:
+// a permutation of their canonical order derived from seed, so every instance agrees on it
+func ShuffledQuestions(questions []*Question, seed int64) []*Question {
+	ordered := make([]*Question, len(questions))
+	copy(ordered, questions)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Order < ordered[j].Order
+	})
+
+	r := rand.New(rand.NewSource(seed))
+	r.Shuffle(len(ordered), func(i, j int) {
+		ordered[i], ordered[j] = ordered[j], ordered[i]
+	})
+	return ordered
+}
+
+// QuestionAfter returns the question following currentID in ordered, or the first one when currentID is nil.
+// It returns nil when there is no such question.
+func QuestionAfter(ordered []*Question, currentID *uuid.UUID) *Question {
+	if currentID == nil {
+		if len(ordered) == 0 {
+			return nil
+		}
+		return ordered[0]
+	}
+
+	for i, question := range ordered {
+		if question.ID == *currentID {
+			if i+1 < len(ordered) {
+				return ordered[i+1]
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// ShuffledOptions returns a question's options in the order participants see them when a quiz shuffles
+// its options. The permutation is derived from seed and the question, so every participant, replay and
+// state sync of the quiz agree on it. Options keep their IDs and labels, so answers are checked as usual.
+func ShuffledOptions(question *Question, seed int64) []*QuestionOption {
+	ordered := make([]*QuestionOption, len(question.Options))
+	copy(ordered, question.Options)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].DisplayOrder < ordered[j].DisplayOrder
+	})
+
+	r := rand.New(rand.NewSource(seed ^ int64(binary.BigEndian.Uint64(question.ID[:8]))))
+	r.Shuffle(len(ordered), func(i, j int) {
+		ordered[i], ordered[j] = ordered[j], ordered[i]
+	})
+	return ordered
+}
+
+// ParticipantOptions returns a question's options in the order these settings show them to participants
+func (s QuizSettings) ParticipantOptions(question *Question) []*QuestionOption {
+	if !s.ShuffleOptions {
+		return question.Options
+	}
+	return ShuffledOptions(question, s.ShuffleSeed)
+}
@@ -16,13 +16,37 @@ type QuizEvent struct {
 	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
 }
 
+// DisconnectReason describes why a participant's connection ended
+type DisconnectReason string
+
+const (
+	// DisconnectReasonClosed means the client closed the connection cleanly
+	DisconnectReasonClosed DisconnectReason = "CLOSED"
+
+	// DisconnectReasonError means the connection failed with a read or write error
+	DisconnectReasonError DisconnectReason = "ERROR"
+
+	// DisconnectReasonKicked means the quiz creator removed the participant
+	DisconnectReasonKicked DisconnectReason = "KICKED"
+
+	// DisconnectReasonTimeout means the client stopped responding to pings
+	DisconnectReasonTimeout DisconnectReason = "TIMEOUT"
+
+	// DisconnectReasonQuizDeleted means the creator deleted the quiz
+	DisconnectReasonQuizDeleted DisconnectReason = "QUIZ_DELETED"
+
+	// DisconnectReasonReplaced means the participant opened a newer connection on another server instance
+	DisconnectReasonReplaced DisconnectReason = "REPLACED"
+)
+
 // ParticipantConnection tracks the connection status of participants
 type ParticipantConnection struct {
-	ParticipantID uuid.UUID `json:"participantId" db:"participant_id"`
-	QuizID        uuid.UUID `json:"quizId" db:"quiz_id"`
-	IsConnected   bool      `json:"isConnected" db:"is_connected"`
-	LastSeen      time.Time `json:"lastSeen" db:"last_seen"`
-	InstanceID    string    `json:"instanceId" db:"instance_id"`
+	ParticipantID    uuid.UUID        `json:"participantId" db:"participant_id"`
+	QuizID           uuid.UUID        `json:"quizId" db:"quiz_id"`
+	IsConnected      bool             `json:"isConnected" db:"is_connected"`
+	LastSeen         time.Time        `json:"lastSeen" db:"last_seen"`
+	InstanceID       string           `json:"instanceId" db:"instance_id"`
+	DisconnectReason DisconnectReason `json:"disconnectReason,omitempty" db:"disconnect_reason"`
 }
 
 // ServerInstance represents a server instance in a distributed deployment
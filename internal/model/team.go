@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Team represents a group of participants playing together in a quiz
+type Team struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	QuizID    uuid.UUID `json:"quizId" db:"quiz_id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// NewTeam creates a new team for a quiz
+func NewTeam(name string, quizID uuid.UUID) *Team {
+	return &Team{
+		ID:        uuid.New(),
+		QuizID:    quizID,
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,7 +14,9 @@ type User struct {
 	Name         string    `json:"name" db:"name"`
 	Email        string    `json:"email" db:"email"`
 	PasswordHash string    `json:"-" db:"password_hash"`
-	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+	// IsGuest marks a temporary host account created without registration; it cannot log in
+	IsGuest   bool      `json:"isGuest" db:"is_guest"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
 }
 
 // NewUser creates a new user with the given details
@@ -32,8 +35,24 @@ func NewUser(name string, email string, password string) (*User, error) {
 	}, nil
 }
 
-// ComparePassword checks if the provided password matches the stored hash
+// NewGuestUser creates a guest host with a placeholder email and no usable password
+func NewGuestUser(name string) *User {
+	id := uuid.New()
+	return &User{
+		ID:        id,
+		Name:      name,
+		Email:     fmt.Sprintf("guest-%s@guest.invalid", id),
+		IsGuest:   true,
+		CreatedAt: time.Now(),
+	}
+}
+
+// ComparePassword checks if the provided password matches the stored hash.
+// Guests have no password and never match.
 func (u *User) ComparePassword(password string) bool {
+	if u.IsGuest {
+		return false
+	}
 	err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password))
 	return err == nil
 }
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QuizWebhook is an external endpoint notified of a quiz's lifecycle events
+type QuizWebhook struct {
+	QuizID uuid.UUID `json:"quizId" db:"quiz_id"`
+	URL    string    `json:"url" db:"url"`
+	// Secret signs each delivery and is never returned by the API
+	Secret    string    `json:"-" db:"secret"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// NewQuizWebhook creates a webhook for a quiz
+func NewQuizWebhook(quizID uuid.UUID, url string, secret string) *QuizWebhook {
+	now := time.Now()
+	return &QuizWebhook{
+		QuizID:    quizID,
+		URL:       url,
+		Secret:    secret,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
@@ -22,9 +22,13 @@ func NewPostgresAnswerRepository(db *DB) *PostgresAnswerRepository {
 // CreateAnswer creates a new answer
 func (r *PostgresAnswerRepository) CreateAnswer(ctx context.Context, answer *model.Answer) error {
 	query := `
-		INSERT INTO answers (id, participant_id, question_id, selected_option, selected_options_json, answered_at, time_taken, is_correct, score)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO answers (id, participant_id, question_id, selected_option, selected_options_json, answered_at, time_taken, is_correct, score, client_token)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
+
+	// Store an absent token as NULL so the unique index only applies to real tokens
+	clientToken := sql.NullString{String: answer.ClientToken, Valid: answer.ClientToken != ""}
+
 	_, err := r.db.ExecContext(
 		ctx,
 		query,
@@ -37,6 +41,7 @@ func (r *PostgresAnswerRepository) CreateAnswer(ctx context.Context, answer *mod
 		answer.TimeTaken,
 		answer.IsCorrect,
 		answer.Score,
+		clientToken,
 	)
 	return err
 }
@@ -44,7 +49,7 @@ func (r *PostgresAnswerRepository) CreateAnswer(ctx context.Context, answer *mod
 // GetAnswersByQuestionID retrieves all answers for a question
 func (r *PostgresAnswerRepository) GetAnswersByQuestionID(ctx context.Context, questionID uuid.UUID) ([]*model.Answer, error) {
 	query := `
-		SELECT id, participant_id, question_id, selected_option, selected_options_json, answered_at, time_taken, is_correct, score
+		SELECT id, participant_id, question_id, selected_option, selected_options_json, answered_at, time_taken, is_correct, score, client_token
 		FROM answers
 		WHERE question_id = $1
 	`
@@ -59,6 +64,7 @@ func (r *PostgresAnswerRepository) GetAnswersByQuestionID(ctx context.Context, q
 	for rows.Next() {
 		var answer model.Answer
 		var selectedJSON sql.NullString
+		var clientToken sql.NullString
 		if err := rows.Scan(
 			&answer.ID,
 			&answer.ParticipantID,
@@ -69,9 +75,11 @@ func (r *PostgresAnswerRepository) GetAnswersByQuestionID(ctx context.Context, q
 			&answer.TimeTaken,
 			&answer.IsCorrect,
 			&answer.Score,
+			&clientToken,
 		); err != nil {
 			return nil, err
 		}
+		answer.ClientToken = clientToken.String
 
 		// Set the selected JSON if it's not null
 		if selectedJSON.Valid {
@@ -95,7 +103,7 @@ func (r *PostgresAnswerRepository) GetAnswersByQuestionID(ctx context.Context, q
 // GetAnswersByParticipantID retrieves all answers for a participant
 func (r *PostgresAnswerRepository) GetAnswersByParticipantID(ctx context.Context, participantID uuid.UUID) ([]*model.Answer, error) {
 	query := `
-		SELECT id, participant_id, question_id, selected_option, selected_options_json, answered_at, time_taken, is_correct, score
+		SELECT id, participant_id, question_id, selected_option, selected_options_json, answered_at, time_taken, is_correct, score, client_token
 		FROM answers
 		WHERE participant_id = $1
 	`
@@ -111,6 +119,7 @@ func (r *PostgresAnswerRepository) GetAnswersByParticipantID(ctx context.Context
 		var answer model.Answer
 		var selectedOption sql.NullString
 		var selectedJSON sql.NullString
+		var clientToken sql.NullString
 		if err := rows.Scan(
 			&answer.ID,
 			&answer.ParticipantID,
@@ -121,9 +130,11 @@ func (r *PostgresAnswerRepository) GetAnswersByParticipantID(ctx context.Context
 			&answer.TimeTaken,
 			&answer.IsCorrect,
 			&answer.Score,
+			&clientToken,
 		); err != nil {
 			return nil, err
 		}
+		answer.ClientToken = clientToken.String
 
 		// Set the selected JSON if it's not null
 		if selectedJSON.Valid {
@@ -152,15 +163,32 @@ func (r *PostgresAnswerRepository) GetAnswersByParticipantID(ctx context.Context
 // GetAnswerByParticipantAndQuestion retrieves a participant's answer for a specific question
 func (r *PostgresAnswerRepository) GetAnswerByParticipantAndQuestion(ctx context.Context, participantID uuid.UUID, questionID uuid.UUID) (*model.Answer, error) {
 	query := `
-		SELECT id, participant_id, question_id, selected_option, selected_options_json, answered_at, time_taken, is_correct, score
+		SELECT id, participant_id, question_id, selected_option, selected_options_json, answered_at, time_taken, is_correct, score, client_token
 		FROM answers
 		WHERE participant_id = $1 AND question_id = $2
 	`
 
+	return scanAnswerRow(r.db.QueryRowContext(ctx, query, participantID, questionID))
+}
+
+// GetAnswerByClientToken retrieves a participant's answer to a question submitted with the given client token
+func (r *PostgresAnswerRepository) GetAnswerByClientToken(ctx context.Context, participantID uuid.UUID, questionID uuid.UUID, clientToken string) (*model.Answer, error) {
+	query := `
+		SELECT id, participant_id, question_id, selected_option, selected_options_json, answered_at, time_taken, is_correct, score, client_token
+		FROM answers
+		WHERE participant_id = $1 AND question_id = $2 AND client_token = $3
+	`
+
+	return scanAnswerRow(r.db.QueryRowContext(ctx, query, participantID, questionID, clientToken))
+}
+
+// scanAnswerRow scans a single answer row
+func scanAnswerRow(row *sql.Row) (*model.Answer, error) {
 	var answer model.Answer
 	var selectedOption sql.NullString
 	var selectedJSON sql.NullString
-	err := r.db.QueryRowContext(ctx, query, participantID, questionID).Scan(
+	var clientToken sql.NullString
+	err := row.Scan(
 		&answer.ID,
 		&answer.ParticipantID,
 		&answer.QuestionID,
@@ -170,6 +198,7 @@ func (r *PostgresAnswerRepository) GetAnswerByParticipantAndQuestion(ctx context
 		&answer.TimeTaken,
 		&answer.IsCorrect,
 		&answer.Score,
+		&clientToken,
 	)
 
 	if err != nil {
@@ -178,6 +207,7 @@ func (r *PostgresAnswerRepository) GetAnswerByParticipantAndQuestion(ctx context
 		}
 		return nil, err
 	}
+	answer.ClientToken = clientToken.String
 
 	// Set the selected JSON if it's not null
 	if selectedJSON.Valid {
@@ -196,3 +226,36 @@ func (r *PostgresAnswerRepository) GetAnswerByParticipantAndQuestion(ctx context
 
 	return &answer, nil
 }
+
+// CountAnswersByQuestionID counts the answers recorded for a question
+func (r *PostgresAnswerRepository) CountAnswersByQuestionID(ctx context.Context, questionID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM answers
+		WHERE question_id = $1
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, questionID).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CountAnswersByQuizID counts the answers recorded for all questions of a quiz
+func (r *PostgresAnswerRepository) CountAnswersByQuizID(ctx context.Context, quizID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM answers a
+		JOIN questions q ON q.id = a.question_id
+		WHERE q.quiz_id = $1
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, quizID).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
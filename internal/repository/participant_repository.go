@@ -4,11 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
 	"github.com/google/uuid"
 )
 
+// ErrQuizFull is returned when a quiz has no slot left for another participant
+var ErrQuizFull = errors.New("quiz is full")
+
 // PostgresParticipantRepository implements ParticipantRepository interface for PostgreSQL
 type PostgresParticipantRepository struct {
 	db *DB
@@ -22,8 +26,8 @@ func NewPostgresParticipantRepository(db *DB) *PostgresParticipantRepository {
 // CreateParticipant creates a new participant
 func (r *PostgresParticipantRepository) CreateParticipant(ctx context.Context, participant *model.Participant) error {
 	query := `
-		INSERT INTO participants (id, name, quiz_id, score, joined_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO participants (id, name, quiz_id, score, team_id, joined_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 	_, err := r.db.ExecContext(
 		ctx,
@@ -32,15 +36,48 @@ func (r *PostgresParticipantRepository) CreateParticipant(ctx context.Context, p
 		participant.Name,
 		participant.QuizID,
 		participant.Score,
+		participant.TeamID,
 		participant.JoinedAt,
 	)
 	return err
 }
 
+// CreateParticipantWithinLimit creates a participant unless the quiz already has maxParticipants slot holders
+// (see CountSlotHoldersByQuizID), in which case it returns ErrQuizFull. Joins to the same quiz are serialized
+// with an advisory lock so concurrent joins cannot overshoot the cap. A non-positive maxParticipants means no cap.
+func (r *PostgresParticipantRepository) CreateParticipantWithinLimit(ctx context.Context, participant *model.Participant, maxParticipants int, graceCutoff time.Time) error {
+	lockQuery := `SELECT pg_advisory_xact_lock(hashtext('participants:' || $1::text))`
+	insertQuery := `
+		INSERT INTO participants (id, name, quiz_id, score, team_id, joined_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	return r.db.Transaction(ctx, func(tx *sql.Tx) error {
+		if maxParticipants > 0 {
+			if _, err := tx.ExecContext(ctx, lockQuery, participant.QuizID); err != nil {
+				return err
+			}
+
+			var holders int
+			if err := tx.QueryRowContext(ctx, slotHoldersQuery, participant.QuizID, graceCutoff).Scan(&holders); err != nil {
+				return err
+			}
+			if holders >= maxParticipants {
+				return ErrQuizFull
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, insertQuery,
+			participant.ID, participant.Name, participant.QuizID, participant.Score, participant.TeamID, participant.JoinedAt,
+		)
+		return err
+	})
+}
+
 // GetParticipantByID retrieves a participant by their ID
 func (r *PostgresParticipantRepository) GetParticipantByID(ctx context.Context, id uuid.UUID) (*model.Participant, error) {
 	query := `
-		SELECT id, name, quiz_id, score, joined_at
+		SELECT id, name, quiz_id, score, team_id, joined_at
 		FROM participants
 		WHERE id = $1
 	`
@@ -51,6 +88,7 @@ func (r *PostgresParticipantRepository) GetParticipantByID(ctx context.Context,
 		&participant.Name,
 		&participant.QuizID,
 		&participant.Score,
+		&participant.TeamID,
 		&participant.JoinedAt,
 	)
 
@@ -67,7 +105,7 @@ func (r *PostgresParticipantRepository) GetParticipantByID(ctx context.Context,
 // GetParticipantsByQuizID retrieves all participants for a quiz
 func (r *PostgresParticipantRepository) GetParticipantsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.Participant, error) {
 	query := `
-		SELECT id, name, quiz_id, score, joined_at
+		SELECT id, name, quiz_id, score, team_id, joined_at
 		FROM participants
 		WHERE quiz_id = $1
 	`
@@ -86,6 +124,7 @@ func (r *PostgresParticipantRepository) GetParticipantsByQuizID(ctx context.Cont
 			&participant.Name,
 			&participant.QuizID,
 			&participant.Score,
+			&participant.TeamID,
 			&participant.JoinedAt,
 		); err != nil {
 			return nil, err
@@ -100,6 +139,18 @@ func (r *PostgresParticipantRepository) GetParticipantsByQuizID(ctx context.Cont
 	return participants, nil
 }
 
+// participantScoreExpr derives a participant's total from their answer scores, crediting
+// voided questions with their voided points instead of what was earned on them
+const participantScoreExpr = `
+	(SELECT COALESCE(SUM(a.score), 0)
+		FROM answers a
+		JOIN questions q ON q.id = a.question_id
+		WHERE a.participant_id = participants.id AND q.voided_points IS NULL)
+	+ (SELECT COALESCE(SUM(q.voided_points), 0)
+		FROM questions q
+		WHERE q.quiz_id = participants.quiz_id AND q.voided_points IS NOT NULL)
+`
+
 // UpdateParticipantScore updates a participant's score
 func (r *PostgresParticipantRepository) UpdateParticipantScore(ctx context.Context, participantID uuid.UUID, score int) error {
 	query := `
@@ -126,16 +177,49 @@ func (r *PostgresParticipantRepository) UpdateParticipantScore(ctx context.Conte
 }
 
 // GetLeaderboard retrieves the top participants by score for a quiz
-func (r *PostgresParticipantRepository) GetLeaderboard(ctx context.Context, quizID uuid.UUID, limit int) ([]*model.Participant, error) {
+func (r *PostgresParticipantRepository) GetLeaderboard(ctx context.Context, quizID uuid.UUID, limit int, offset int) ([]*model.Participant, error) {
+	// Equal scores are ordered by the quiz's tiebreak setting:
+	//   - TIME (the default) puts the fastest total answer time first and participants who never answered last;
+	//     participants tied on score and time share a rank.
+	//   - JOIN_ORDER puts the participant who joined first first, giving every participant their own rank.
+	//   - RANDOM orders by a hash of the quiz's tiebreak seed and the participant ID, giving every participant
+	//     their own rank in an order that stays the same as long as the seed does.
+	// Answer times only count questions that are not voided, matching how the score is computed.
+	// Remaining ties are broken by join time and ID so pages never overlap or skip participants.
+	// Ranks are computed over the whole quiz so they are consistent across pages.
 	query := `
-		SELECT id, name, quiz_id, score, joined_at
-		FROM participants
-		WHERE quiz_id = $1
-		ORDER BY score DESC
-		LIMIT $2
+		WITH tiebreak AS (
+			SELECT COALESCE(NULLIF(settings->>'tiebreak', ''), 'TIME') AS mode,
+				COALESCE(settings->>'tiebreakSeed', '0') AS seed
+			FROM quizzes
+			WHERE id = $1
+		)
+		SELECT p.id, p.name, p.quiz_id, p.score, p.team_id, p.joined_at,
+			COALESCE(t.total_time, 0), COALESCE(t.answer_count, 0),
+			RANK() OVER (
+				ORDER BY p.score DESC,
+					CASE WHEN tb.mode = 'TIME' THEN t.total_time END ASC NULLS LAST,
+					CASE WHEN tb.mode = 'RANDOM' THEN md5(tb.seed || p.id::text) END ASC,
+					CASE WHEN tb.mode = 'JOIN_ORDER' THEN p.joined_at END ASC,
+					CASE WHEN tb.mode = 'JOIN_ORDER' THEN p.id END ASC
+			) AS rank
+		FROM participants p
+		CROSS JOIN tiebreak tb
+		LEFT JOIN LATERAL (
+			SELECT SUM(a.time_taken) AS total_time, COUNT(*) AS answer_count
+			FROM answers a
+			JOIN questions q ON q.id = a.question_id
+			WHERE a.participant_id = p.id AND q.voided_points IS NULL
+		) t ON true
+		WHERE p.quiz_id = $1
+		ORDER BY p.score DESC,
+			CASE WHEN tb.mode = 'TIME' THEN t.total_time END ASC NULLS LAST,
+			CASE WHEN tb.mode = 'RANDOM' THEN md5(tb.seed || p.id::text) END ASC,
+			p.joined_at ASC, p.id ASC
+		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, quizID, limit)
+	rows, err := r.db.QueryContext(ctx, query, quizID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -149,7 +233,11 @@ func (r *PostgresParticipantRepository) GetLeaderboard(ctx context.Context, quiz
 			&participant.Name,
 			&participant.QuizID,
 			&participant.Score,
+			&participant.TeamID,
 			&participant.JoinedAt,
+			&participant.TotalAnswerTime,
+			&participant.AnswerCount,
+			&participant.Rank,
 		); err != nil {
 			return nil, err
 		}
@@ -163,6 +251,46 @@ func (r *PostgresParticipantRepository) GetLeaderboard(ctx context.Context, quiz
 	return participants, nil
 }
 
+// CountParticipantsByQuizID counts the participants of a quiz
+func (r *PostgresParticipantRepository) CountParticipantsByQuizID(ctx context.Context, quizID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM participants
+		WHERE quiz_id = $1
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, quizID).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// slotHoldersQuery counts the participants of quiz $1 still holding a slot at grace cutoff $2
+const slotHoldersQuery = `
+	SELECT COUNT(*)
+	FROM participants p
+	LEFT JOIN participant_connections pc ON pc.participant_id = p.id AND pc.quiz_id = p.quiz_id
+	WHERE p.quiz_id = $1
+	AND (
+		pc.is_connected = true
+		OR (pc.is_connected = false AND pc.last_seen > $2)
+		OR (pc.participant_id IS NULL AND p.joined_at > $2)
+	)
+`
+
+// CountSlotHoldersByQuizID counts the participants of a quiz still holding a slot: those connected,
+// those disconnected since graceCutoff, and those who joined since graceCutoff without connecting yet
+func (r *PostgresParticipantRepository) CountSlotHoldersByQuizID(ctx context.Context, quizID uuid.UUID, graceCutoff time.Time) (int, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, slotHoldersQuery, quizID, graceCutoff).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
 // DeleteParticipant removes a participant by ID
 func (r *PostgresParticipantRepository) DeleteParticipant(ctx context.Context, id uuid.UUID) error {
 	query := `
@@ -186,3 +314,74 @@ func (r *PostgresParticipantRepository) DeleteParticipant(ctx context.Context, i
 
 	return nil
 }
+
+// BanParticipant records the ban and removes the banned participant in one transaction, so a ban is
+// never left behind for a participant who is still in the quiz
+func (r *PostgresParticipantRepository) BanParticipant(ctx context.Context, ban *model.QuizBan) error {
+	banQuery := `
+		INSERT INTO quiz_bans (quiz_id, participant_id, name, banned_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (quiz_id, participant_id) DO NOTHING
+	`
+	deleteQuery := `
+		DELETE FROM participants
+		WHERE id = $1
+	`
+
+	return r.db.Transaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, banQuery, ban.QuizID, ban.ParticipantID, ban.Name, ban.BannedAt); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, deleteQuery, ban.ParticipantID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return errors.New("participant not found")
+		}
+
+		return nil
+	})
+}
+
+// IsNameBanned reports whether a banned participant of the quiz used the given name, ignoring case
+func (r *PostgresParticipantRepository) IsNameBanned(ctx context.Context, quizID uuid.UUID, name string) (bool, error) {
+	query := `
+		SELECT EXISTS(SELECT 1 FROM quiz_bans WHERE quiz_id = $1 AND LOWER(name) = LOWER($2))
+	`
+
+	var banned bool
+	if err := r.db.QueryRowContext(ctx, query, quizID, name).Scan(&banned); err != nil {
+		return false, err
+	}
+
+	return banned, nil
+}
+
+// RecomputeParticipantScore recalculates a participant's score from their answers and returns the new total
+func (r *PostgresParticipantRepository) RecomputeParticipantScore(ctx context.Context, participantID uuid.UUID) (int, error) {
+	query := `
+		UPDATE participants
+		SET score = ` + participantScoreExpr + `
+		WHERE id = $1
+		RETURNING score
+	`
+
+	var score int
+	err := r.db.QueryRowContext(ctx, query, participantID).Scan(&score)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.New("participant not found")
+		}
+		return 0, err
+	}
+
+	return score, nil
+}
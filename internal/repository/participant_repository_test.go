@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+)
+
+// seedTestParticipant stores a participant of a quiz with a score, joined at joinedAt
+func seedTestParticipant(t *testing.T, db *DB, quiz *model.Quiz, name string, score int, joinedAt time.Time) *model.Participant {
+	t.Helper()
+
+	participant := model.NewParticipant(name, quiz.ID)
+	participant.Score = score
+	participant.JoinedAt = joinedAt
+	if err := NewPostgresParticipantRepository(db).CreateParticipant(context.Background(), participant); err != nil {
+		t.Fatalf("seed participant: %v", err)
+	}
+	return participant
+}
+
+func TestGetLeaderboardBreaksTiesByJoinTimeWithSharedRanks(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresParticipantRepository(db)
+	quiz := seedTestQuiz(t, db, model.QuizSettings{})
+
+	joined := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	// Seeded out of order so the result can't come from insertion order
+	late := seedTestParticipant(t, db, quiz, "Late", 100, joined.Add(2*time.Minute))
+	last := seedTestParticipant(t, db, quiz, "Last", 50, joined)
+	early := seedTestParticipant(t, db, quiz, "Early", 100, joined.Add(time.Minute))
+
+	want := []struct {
+		participant *model.Participant
+		rank        int
+	}{
+		{participant: early, rank: 1},
+		{participant: late, rank: 1},
+		{participant: last, rank: 3},
+	}
+
+	// The order must not change between requests
+	for attempt := 0; attempt < 3; attempt++ {
+		leaderboard, err := repo.GetLeaderboard(context.Background(), quiz.ID, 10, 0)
+		if err != nil {
+			t.Fatalf("GetLeaderboard: %v", err)
+		}
+		if len(leaderboard) != len(want) {
+			t.Fatalf("leaderboard has %d entries, want %d", len(leaderboard), len(want))
+		}
+		for i, w := range want {
+			if leaderboard[i].ID != w.participant.ID || leaderboard[i].Rank != w.rank {
+				t.Errorf("attempt %d: entry %d is %s ranked %d, want %s ranked %d",
+					attempt, i, leaderboard[i].Name, leaderboard[i].Rank, w.participant.Name, w.rank)
+			}
+		}
+	}
+}
+
+func TestCreateParticipantWithinLimitDoesNotOvershootTheCap(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewPostgresParticipantRepository(db)
+	quiz := seedTestQuiz(t, db, model.QuizSettings{MaxParticipants: 2})
+	graceCutoff := time.Now().Add(-time.Minute)
+
+	const joiners = 8
+	errs := make(chan error, joiners)
+	for i := 0; i < joiners; i++ {
+		go func(i int) {
+			errs <- repo.CreateParticipantWithinLimit(ctx, model.NewParticipant("Player "+strconv.Itoa(i), quiz.ID), 2, graceCutoff)
+		}(i)
+	}
+
+	var full int
+	for i := 0; i < joiners; i++ {
+		err := <-errs
+		switch {
+		case errors.Is(err, ErrQuizFull):
+			full++
+		case err != nil:
+			t.Fatalf("CreateParticipantWithinLimit: %v", err)
+		}
+	}
+	if full != joiners-2 {
+		t.Errorf("%d of %d concurrent joins were refused, want %d", full, joiners, joiners-2)
+	}
+
+	count, err := repo.CountParticipantsByQuizID(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("CountParticipantsByQuizID: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("quiz capped at 2 has %d participants", count)
+	}
+}
+
+func TestBanParticipantRemovesThemAndMatchesTheNameIgnoringCase(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewPostgresParticipantRepository(db)
+	quiz := seedTestQuiz(t, db, model.QuizSettings{})
+	participant := seedTestParticipant(t, db, quiz, "Bob", 0, time.Now())
+
+	if err := repo.BanParticipant(ctx, model.NewQuizBan(participant)); err != nil {
+		t.Fatalf("BanParticipant: %v", err)
+	}
+	if _, err := repo.GetParticipantByID(ctx, participant.ID); err == nil {
+		t.Error("banned participant is still stored")
+	}
+
+	banned, err := repo.IsNameBanned(ctx, quiz.ID, "bOB")
+	if err != nil {
+		t.Fatalf("IsNameBanned: %v", err)
+	}
+	if !banned {
+		t.Error("ban on Bob does not match bOB")
+	}
+
+	// A ban for a participant who is already gone is rolled back rather than left behind
+	ghost := model.NewParticipant("Eve", quiz.ID)
+	if err := repo.BanParticipant(ctx, model.NewQuizBan(ghost)); err == nil {
+		t.Fatal("BanParticipant succeeded for a participant that does not exist")
+	}
+	if banned, err := repo.IsNameBanned(ctx, quiz.ID, "Eve"); err != nil || banned {
+		t.Errorf("IsNameBanned(Eve) = %v, %v after a failed ban, want false", banned, err)
+	}
+}
+
+func TestRecomputeParticipantScoreSumsAnswers(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewPostgresParticipantRepository(db)
+	quiz := seedTestQuiz(t, db, model.QuizSettings{})
+	first := seedTestQuestion(t, db, quiz, 1)
+	second := seedTestQuestion(t, db, quiz, 2)
+	seedTestQuestion(t, db, quiz, 3) // Not answered
+
+	// The stored total has drifted from the answers
+	participant := seedTestParticipant(t, db, quiz, "Ann", 999, time.Now())
+	seedTestAnswer(t, db, participant, first, true, 1)
+	seedTestAnswer(t, db, participant, second, false, 1)
+
+	score, err := repo.RecomputeParticipantScore(ctx, participant.ID)
+	if err != nil {
+		t.Fatalf("RecomputeParticipantScore: %v", err)
+	}
+	if score != model.CorrectAnswerPoints {
+		t.Errorf("recomputed score is %d, want %d", score, model.CorrectAnswerPoints)
+	}
+	stored, err := repo.GetParticipantByID(ctx, participant.ID)
+	if err != nil {
+		t.Fatalf("GetParticipantByID: %v", err)
+	}
+	if stored.Score != score {
+		t.Errorf("stored score is %d, want the recomputed %d", stored.Score, score)
+	}
+}
+
+func TestGetLeaderboardBreaksScoreTiesByTotalAnswerTime(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresParticipantRepository(db)
+	quiz := seedTestQuiz(t, db, model.QuizSettings{})
+	first := seedTestQuestion(t, db, quiz, 1)
+	second := seedTestQuestion(t, db, quiz, 2)
+
+	// Tied on score; the slower one joined first so join order cannot explain the result
+	joined := time.Now().Add(-time.Hour)
+	slow := seedTestParticipant(t, db, quiz, "Slow", 2*model.CorrectAnswerPoints, joined)
+	fast := seedTestParticipant(t, db, quiz, "Fast", 2*model.CorrectAnswerPoints, joined.Add(time.Minute))
+	seedTestAnswer(t, db, slow, first, true, 9)
+	seedTestAnswer(t, db, slow, second, true, 7)
+	seedTestAnswer(t, db, fast, first, true, 3)
+	seedTestAnswer(t, db, fast, second, true, 5)
+
+	leaderboard, err := repo.GetLeaderboard(context.Background(), quiz.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("GetLeaderboard: %v", err)
+	}
+	if len(leaderboard) != 2 {
+		t.Fatalf("leaderboard has %d entries, want 2", len(leaderboard))
+	}
+	if leaderboard[0].ID != fast.ID || leaderboard[0].Rank != 1 || leaderboard[1].ID != slow.ID || leaderboard[1].Rank != 2 {
+		t.Errorf("leaderboard is %s ranked %d, %s ranked %d; want Fast 1, Slow 2",
+			leaderboard[0].Name, leaderboard[0].Rank, leaderboard[1].Name, leaderboard[1].Rank)
+	}
+	if got := leaderboard[0].AverageAnswerTime(); got != 4 {
+		t.Errorf("Fast's average answer time is %v, want 4", got)
+	}
+	if got := leaderboard[1].AverageAnswerTime(); got != 8 {
+		t.Errorf("Slow's average answer time is %v, want 8", got)
+	}
+}
+
+func TestGetLeaderboardLeavesVoidedQuestionsOutOfAnswerTime(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewPostgresParticipantRepository(db)
+	quiz := seedTestQuiz(t, db, model.QuizSettings{})
+	voided := seedTestQuestion(t, db, quiz, 1)
+	counted := seedTestQuestion(t, db, quiz, 2)
+
+	// Fast only looks slow because of the voided question
+	joined := time.Now().Add(-time.Hour)
+	slow := seedTestParticipant(t, db, quiz, "Slow", 0, joined)
+	fast := seedTestParticipant(t, db, quiz, "Fast", 0, joined.Add(time.Minute))
+	seedTestAnswer(t, db, slow, voided, true, 1)
+	seedTestAnswer(t, db, slow, counted, true, 5)
+	seedTestAnswer(t, db, fast, voided, true, 20)
+	seedTestAnswer(t, db, fast, counted, true, 3)
+	if err := NewPostgresQuestionRepository(db).VoidQuestion(ctx, voided.ID, model.CorrectAnswerPoints); err != nil {
+		t.Fatalf("VoidQuestion: %v", err)
+	}
+
+	// Answers to another quiz's questions do not count either
+	other := seedTestQuiz(t, db, model.QuizSettings{})
+	seedTestAnswer(t, db, seedTestParticipant(t, db, other, "Other", 0, joined), seedTestQuestion(t, db, other, 1), true, 1)
+
+	leaderboard, err := repo.GetLeaderboard(ctx, quiz.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("GetLeaderboard: %v", err)
+	}
+	if len(leaderboard) != 2 {
+		t.Fatalf("leaderboard has %d entries, want 2", len(leaderboard))
+	}
+	if leaderboard[0].Score != leaderboard[1].Score {
+		t.Fatalf("scores are %d and %d, want a tie", leaderboard[0].Score, leaderboard[1].Score)
+	}
+	if leaderboard[0].ID != fast.ID || leaderboard[1].ID != slow.ID {
+		t.Errorf("leaderboard is %s, %s; want Fast, Slow", leaderboard[0].Name, leaderboard[1].Name)
+	}
+	if got := leaderboard[0].AverageAnswerTime(); got != 3 {
+		t.Errorf("Fast's average answer time is %v, want 3", got)
+	}
+}
+
+func TestGetLeaderboardTiebreakModes(t *testing.T) {
+	joined := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+
+	tests := []struct {
+		name     string
+		settings model.QuizSettings
+		// want returns the expected order of the tied participants
+		want func(fast, slow, idle *model.Participant) []*model.Participant
+		// sharedRanks is set when tied participants may share a rank
+		sharedRanks bool
+	}{
+		{
+			name:     "time",
+			settings: model.QuizSettings{Tiebreak: model.LeaderboardTiebreakTime},
+			want: func(fast, slow, idle *model.Participant) []*model.Participant {
+				return []*model.Participant{fast, slow, idle}
+			},
+		},
+		{
+			name:     "join order",
+			settings: model.QuizSettings{Tiebreak: model.LeaderboardTiebreakJoinOrder},
+			want: func(fast, slow, idle *model.Participant) []*model.Participant {
+				return []*model.Participant{idle, slow, fast}
+			},
+		},
+		{
+			name:     "random with a seed",
+			settings: model.QuizSettings{Tiebreak: model.LeaderboardTiebreakRandom, TiebreakSeed: 4242},
+			want: func(fast, slow, idle *model.Participant) []*model.Participant {
+				tied := []*model.Participant{fast, slow, idle}
+				sort.Slice(tied, func(i, j int) bool {
+					return seededHash(4242, tied[i]) < seededHash(4242, tied[j])
+				})
+				return tied
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			repo := NewPostgresParticipantRepository(db)
+			quiz := seedTestQuiz(t, db, tt.settings)
+			question := seedTestQuestion(t, db, quiz, 1)
+
+			// Tied on score; join order is the reverse of answer speed so the modes disagree
+			idle := seedTestParticipant(t, db, quiz, "Idle", model.CorrectAnswerPoints, joined)
+			slow := seedTestParticipant(t, db, quiz, "Slow", model.CorrectAnswerPoints, joined.Add(time.Minute))
+			fast := seedTestParticipant(t, db, quiz, "Fast", model.CorrectAnswerPoints, joined.Add(2*time.Minute))
+			trailing := seedTestParticipant(t, db, quiz, "Trailing", 0, joined)
+			seedTestAnswer(t, db, slow, question, false, 9)
+			seedTestAnswer(t, db, fast, question, false, 3)
+
+			want := append(tt.want(fast, slow, idle), trailing)
+
+			// Recomputing gives the same order every time
+			for attempt := 0; attempt < 3; attempt++ {
+				leaderboard, err := repo.GetLeaderboard(context.Background(), quiz.ID, 10, 0)
+				if err != nil {
+					t.Fatalf("GetLeaderboard: %v", err)
+				}
+				if len(leaderboard) != len(want) {
+					t.Fatalf("leaderboard has %d entries, want %d", len(leaderboard), len(want))
+				}
+				for i, w := range want {
+					if leaderboard[i].ID != w.ID || leaderboard[i].Rank != i+1 {
+						t.Errorf("attempt %d: entry %d is %s ranked %d, want %s ranked %d",
+							attempt, i, leaderboard[i].Name, leaderboard[i].Rank, w.Name, i+1)
+					}
+				}
+			}
+		})
+	}
+}
+
+// seededHash is the key the RANDOM tiebreak orders a participant by
+func seededHash(seed int64, participant *model.Participant) string {
+	sum := md5.Sum([]byte(strconv.FormatInt(seed, 10) + participant.ID.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+)
+
+// openTestDB connects to the database in TEST_DATABASE_URL, which must have the versioned migrations applied.
+// Tests using it are skipped when the variable is unset.
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		t.Fatalf("ping test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return &DB{db}
+}
+
+// seedTestQuiz stores a quiz with its session, owned by a new guest user that is deleted with everything
+// it owns when the test ends
+func seedTestQuiz(t *testing.T, db *DB, settings model.QuizSettings) *model.Quiz {
+	t.Helper()
+
+	ctx := context.Background()
+	creator := model.NewGuestUser("Test host")
+	if err := NewPostgresUserRepository(db).CreateUser(ctx, creator); err != nil {
+		t.Fatalf("seed creator: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := db.ExecContext(context.Background(), `DELETE FROM users WHERE id = $1`, creator.ID); err != nil {
+			t.Errorf("delete seeded creator: %v", err)
+		}
+	})
+
+	quiz := model.NewQuiz("Test quiz", "", creator.ID)
+	quiz.Settings = settings
+	if err := NewPostgresQuizRepository(db).CreateQuizWithContent(ctx, quiz, model.NewQuizSession(quiz.ID), nil); err != nil {
+		t.Fatalf("seed quiz: %v", err)
+	}
+	return quiz
+}
@@ -78,6 +78,46 @@ func (r *PostgresQuestionOptionRepository) GetQuestionOptionsByQuestionID(ctx co
 	return options, nil
 }
 
+// GetQuestionOptionsByQuizID retrieves the options of every question of a quiz in a single query
+func (r *PostgresQuestionOptionRepository) GetQuestionOptionsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.QuestionOption, error) {
+	query := `
+		SELECT o.id, o.question_id, o.text, o.is_correct, o.display_order, o.created_at, o.updated_at
+		FROM question_options o
+		JOIN questions q ON q.id = o.question_id
+		WHERE q.quiz_id = $1
+		ORDER BY q."order" ASC, o.display_order ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, quizID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var options []*model.QuestionOption
+	for rows.Next() {
+		var option model.QuestionOption
+		if err := rows.Scan(
+			&option.ID,
+			&option.QuestionID,
+			&option.Text,
+			&option.IsCorrect,
+			&option.DisplayOrder,
+			&option.CreatedAt,
+			&option.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		options = append(options, &option)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
+
 // UpdateQuestionOption updates an existing question option
 func (r *PostgresQuestionOptionRepository) UpdateQuestionOption(ctx context.Context, option *model.QuestionOption) error {
 	query := `
@@ -149,3 +189,62 @@ func (r *PostgresQuestionOptionRepository) DeleteQuestionOptionsByQuestionID(ctx
 
 	return nil
 }
+
+// GetOrphanedQuestionOptions retrieves options whose question no longer exists
+func (r *PostgresQuestionOptionRepository) GetOrphanedQuestionOptions(ctx context.Context) ([]*model.QuestionOption, error) {
+	query := `
+		SELECT o.id, o.question_id, o.text, o.is_correct, o.display_order, o.created_at, o.updated_at
+		FROM question_options o
+		WHERE NOT EXISTS (SELECT 1 FROM questions q WHERE q.id = o.question_id)
+		ORDER BY o.question_id, o.display_order ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var options []*model.QuestionOption
+	for rows.Next() {
+		var option model.QuestionOption
+		if err := rows.Scan(
+			&option.ID,
+			&option.QuestionID,
+			&option.Text,
+			&option.IsCorrect,
+			&option.DisplayOrder,
+			&option.CreatedAt,
+			&option.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		options = append(options, &option)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
+
+// DeleteOrphanedQuestionOptions deletes options whose question no longer exists and returns how many were removed
+func (r *PostgresQuestionOptionRepository) DeleteOrphanedQuestionOptions(ctx context.Context) (int, error) {
+	query := `
+		DELETE FROM question_options o
+		WHERE NOT EXISTS (SELECT 1 FROM questions q WHERE q.id = o.question_id)
+	`
+
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(deleted), nil
+}
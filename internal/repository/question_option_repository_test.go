@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+// seedOrphanedOption stores an option of a question that does not exist. The foreign key would refuse it,
+// so triggers are switched off for the insert, which needs a superuser and a single connection.
+func seedOrphanedOption(t *testing.T, db *DB) *model.QuestionOption {
+	t.Helper()
+
+	ctx := context.Background()
+	db.SetMaxOpenConns(1)
+	if _, err := db.ExecContext(ctx, `SET session_replication_role = replica`); err != nil {
+		t.Skipf("cannot bypass foreign keys on the test database: %v", err)
+	}
+	defer db.ExecContext(ctx, `SET session_replication_role = DEFAULT`)
+
+	option := model.NewQuestionOption(uuid.New(), "Orphan", true, 1)
+	if err := NewPostgresQuestionOptionRepository(db).CreateQuestionOption(ctx, option); err != nil {
+		t.Fatalf("seed orphaned option: %v", err)
+	}
+	return option
+}
+
+// containsOption reports whether options includes the option with the given ID
+func containsOption(options []*model.QuestionOption, id uuid.UUID) bool {
+	for _, option := range options {
+		if option.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDeleteOrphanedQuestionOptions(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewPostgresQuestionOptionRepository(db)
+
+	quiz := seedTestQuiz(t, db, model.QuizSettings{})
+	question := model.NewQuestion(quiz.ID, "Kept question", model.QuestionTypeSingleChoice, 30, 1)
+	if err := NewPostgresQuestionRepository(db).CreateQuestion(ctx, question); err != nil {
+		t.Fatalf("seed question: %v", err)
+	}
+	kept := model.NewQuestionOption(question.ID, "Kept", true, 1)
+	if err := repo.CreateQuestionOption(ctx, kept); err != nil {
+		t.Fatalf("seed option: %v", err)
+	}
+	orphan := seedOrphanedOption(t, db)
+
+	orphans, err := repo.GetOrphanedQuestionOptions(ctx)
+	if err != nil {
+		t.Fatalf("GetOrphanedQuestionOptions: %v", err)
+	}
+	if !containsOption(orphans, orphan.ID) {
+		t.Error("seeded orphan was not found")
+	}
+	if containsOption(orphans, kept.ID) {
+		t.Error("option of an existing question was reported as orphaned")
+	}
+
+	deleted, err := repo.DeleteOrphanedQuestionOptions(ctx)
+	if err != nil {
+		t.Fatalf("DeleteOrphanedQuestionOptions: %v", err)
+	}
+	if deleted < 1 {
+		t.Errorf("deleted %d orphans, want at least the seeded one", deleted)
+	}
+
+	orphans, err = repo.GetOrphanedQuestionOptions(ctx)
+	if err != nil {
+		t.Fatalf("GetOrphanedQuestionOptions after cleanup: %v", err)
+	}
+	if len(orphans) != 0 {
+		t.Errorf("%d orphans left after cleanup", len(orphans))
+	}
+	options, err := repo.GetQuestionOptionsByQuestionID(ctx, question.ID)
+	if err != nil {
+		t.Fatalf("GetQuestionOptionsByQuestionID: %v", err)
+	}
+	if !containsOption(options, kept.ID) {
+		t.Error("cleanup removed an option of an existing question")
+	}
+}
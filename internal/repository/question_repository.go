@@ -23,8 +23,8 @@ func NewPostgresQuestionRepository(db *DB) *PostgresQuestionRepository {
 // CreateQuestion creates a new question
 func (r *PostgresQuestionRepository) CreateQuestion(ctx context.Context, question *model.Question) error {
 	query := `
-		INSERT INTO questions (id, quiz_id, text, time_limit, "order", question_type, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO questions (id, quiz_id, text, time_limit, "order", question_type, image_url, explanation, is_required, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 	_, err := r.db.ExecContext(
 		ctx,
@@ -35,16 +35,51 @@ func (r *PostgresQuestionRepository) CreateQuestion(ctx context.Context, questio
 		question.TimeLimit,
 		question.Order,
 		question.QuestionType,
+		question.ImageURL,
+		question.Explanation,
+		question.Required,
 		question.CreatedAt,
 		question.UpdatedAt,
 	)
 	return err
 }
 
+// questionColumns lists the question columns read by scanQuestion, in order
+const questionColumns = `id, quiz_id, text, time_limit, "order", question_type, image_url, explanation, is_required, voided_points, created_at, updated_at`
+
+// scanQuestion scans a question selected with questionColumns
+func scanQuestion(row rowScanner) (*model.Question, error) {
+	var q model.Question
+	var voidedPoints sql.NullInt64
+	if err := row.Scan(
+		&q.ID,
+		&q.QuizID,
+		&q.Text,
+		&q.TimeLimit,
+		&q.Order,
+		&q.QuestionType,
+		&q.ImageURL,
+		&q.Explanation,
+		&q.Required,
+		&voidedPoints,
+		&q.CreatedAt,
+		&q.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if voidedPoints.Valid {
+		points := int(voidedPoints.Int64)
+		q.VoidedPoints = &points
+	}
+
+	return &q, nil
+}
+
 // GetQuestionsByQuizID retrieves all questions for a quiz
 func (r *PostgresQuestionRepository) GetQuestionsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.Question, error) {
 	query := `
-		SELECT id, quiz_id, text, time_limit, "order", question_type, created_at, updated_at
+		SELECT ` + questionColumns + `
 		FROM questions
 		WHERE quiz_id = $1
 		ORDER BY "order" ASC
@@ -58,20 +93,11 @@ func (r *PostgresQuestionRepository) GetQuestionsByQuizID(ctx context.Context, q
 
 	var questions []*model.Question
 	for rows.Next() {
-		var q model.Question
-		if err := rows.Scan(
-			&q.ID,
-			&q.QuizID,
-			&q.Text,
-			&q.TimeLimit,
-			&q.Order,
-			&q.QuestionType,
-			&q.CreatedAt,
-			&q.UpdatedAt,
-		); err != nil {
+		q, err := scanQuestion(rows)
+		if err != nil {
 			return nil, err
 		}
-		questions = append(questions, &q)
+		questions = append(questions, q)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -84,22 +110,12 @@ func (r *PostgresQuestionRepository) GetQuestionsByQuizID(ctx context.Context, q
 // GetQuestionByID retrieves a question by its ID
 func (r *PostgresQuestionRepository) GetQuestionByID(ctx context.Context, id uuid.UUID) (*model.Question, error) {
 	query := `
-		SELECT id, quiz_id, text, time_limit, "order", question_type, created_at, updated_at
+		SELECT ` + questionColumns + `
 		FROM questions
 		WHERE id = $1
 	`
 
-	var q model.Question
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&q.ID,
-		&q.QuizID,
-		&q.Text,
-		&q.TimeLimit,
-		&q.Order,
-		&q.QuestionType,
-		&q.CreatedAt,
-		&q.UpdatedAt,
-	)
+	q, err := scanQuestion(r.db.QueryRowContext(ctx, query, id))
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -108,30 +124,20 @@ func (r *PostgresQuestionRepository) GetQuestionByID(ctx context.Context, id uui
 		return nil, err
 	}
 
-	return &q, nil
+	return q, nil
 }
 
 // GetNextQuestion retrieves the next question after the current one
 func (r *PostgresQuestionRepository) GetNextQuestion(ctx context.Context, quizID uuid.UUID, currentOrder int) (*model.Question, error) {
 	query := `
-		SELECT id, quiz_id, text, time_limit, "order", question_type, created_at, updated_at
+		SELECT ` + questionColumns + `
 		FROM questions
 		WHERE quiz_id = $1 AND "order" > $2
 		ORDER BY "order" ASC
 		LIMIT 1
 	`
 
-	var q model.Question
-	err := r.db.QueryRowContext(ctx, query, quizID, currentOrder).Scan(
-		&q.ID,
-		&q.QuizID,
-		&q.Text,
-		&q.TimeLimit,
-		&q.Order,
-		&q.QuestionType,
-		&q.CreatedAt,
-		&q.UpdatedAt,
-	)
+	q, err := scanQuestion(r.db.QueryRowContext(ctx, query, quizID, currentOrder))
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -140,15 +146,16 @@ func (r *PostgresQuestionRepository) GetNextQuestion(ctx context.Context, quizID
 		return nil, err
 	}
 
-	return &q, nil
+	return q, nil
 }
 
 // UpdateQuestion updates an existing question
 func (r *PostgresQuestionRepository) UpdateQuestion(ctx context.Context, question *model.Question) error {
 	query := `
 		UPDATE questions
-		SET text = $1, time_limit = $2, "order" = $3, question_type = $4, updated_at = $5
-		WHERE id = $6
+		SET text = $1, time_limit = $2, "order" = $3, question_type = $4, image_url = $5, explanation = $6,
+			is_required = $7, updated_at = $8
+		WHERE id = $9
 	`
 
 	result, err := r.db.ExecContext(
@@ -158,6 +165,9 @@ func (r *PostgresQuestionRepository) UpdateQuestion(ctx context.Context, questio
 		question.TimeLimit,
 		question.Order,
 		question.QuestionType,
+		question.ImageURL,
+		question.Explanation,
+		question.Required,
 		time.Now(),
 		question.ID,
 	)
@@ -177,6 +187,65 @@ func (r *PostgresQuestionRepository) UpdateQuestion(ctx context.Context, questio
 	return nil
 }
 
+// UpdateQuestionOrder reassigns the order of a quiz's questions in a single transaction.
+// questionIDs lists the questions in their new order, starting at 1.
+func (r *PostgresQuestionRepository) UpdateQuestionOrder(ctx context.Context, quizID uuid.UUID, questionIDs []uuid.UUID) error {
+	query := `
+		UPDATE questions
+		SET "order" = $1, updated_at = $2
+		WHERE id = $3 AND quiz_id = $4
+	`
+
+	return r.db.Transaction(ctx, func(tx *sql.Tx) error {
+		now := time.Now()
+		for i, questionID := range questionIDs {
+			result, err := tx.ExecContext(ctx, query, i+1, now, questionID, quizID)
+			if err != nil {
+				return err
+			}
+
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if rowsAffected == 0 {
+				return errors.New("question not found")
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateQuestionTimeLimits sets the time limit of the given questions of a quiz atomically
+func (r *PostgresQuestionRepository) UpdateQuestionTimeLimits(ctx context.Context, quizID uuid.UUID, questionIDs []uuid.UUID, timeLimit int) error {
+	query := `
+		UPDATE questions
+		SET time_limit = $1, updated_at = $2
+		WHERE id = $3 AND quiz_id = $4
+	`
+
+	return r.db.Transaction(ctx, func(tx *sql.Tx) error {
+		now := time.Now()
+		for _, questionID := range questionIDs {
+			result, err := tx.ExecContext(ctx, query, timeLimit, now, questionID, quizID)
+			if err != nil {
+				return err
+			}
+
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if rowsAffected == 0 {
+				return errors.New("question not found")
+			}
+		}
+		return nil
+	})
+}
+
 // DeleteQuestion deletes a question
 func (r *PostgresQuestionRepository) DeleteQuestion(ctx context.Context, id uuid.UUID) error {
 	query := `
@@ -200,3 +269,38 @@ func (r *PostgresQuestionRepository) DeleteQuestion(ctx context.Context, id uuid
 
 	return nil
 }
+
+// VoidQuestion atomically marks a question as voided with the points everyone is credited for it
+// and applies the resulting score change to each participant
+func (r *PostgresQuestionRepository) VoidQuestion(ctx context.Context, id uuid.UUID, points int) error {
+	voidQuery := `
+		UPDATE questions
+		SET voided_points = $1, updated_at = $2
+		WHERE id = $3 AND voided_points IS NULL
+	`
+	scoreQuery := `
+		UPDATE participants
+		SET score = ` + participantScoreExpr + `
+		WHERE quiz_id = (SELECT quiz_id FROM questions WHERE id = $1)
+	`
+
+	return r.db.Transaction(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, voidQuery, points, time.Now(), id)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		// A concurrent void already rescored the question
+		if rowsAffected == 0 {
+			return errors.New("question not found or already voided")
+		}
+
+		_, err = tx.ExecContext(ctx, scoreQuery, id)
+		return err
+	})
+}
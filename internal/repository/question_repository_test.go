@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+// seedTestQuestion stores a question of a quiz
+func seedTestQuestion(t *testing.T, db *DB, quiz *model.Quiz, order int) *model.Question {
+	t.Helper()
+
+	question := model.NewQuestion(quiz.ID, "Question", model.QuestionTypeSingleChoice, 30, order)
+	if err := NewPostgresQuestionRepository(db).CreateQuestion(context.Background(), question); err != nil {
+		t.Fatalf("seed question: %v", err)
+	}
+	return question
+}
+
+// seedTestAnswer stores a participant's answer to a question, scored as correct or not, given after timeTaken seconds
+func seedTestAnswer(t *testing.T, db *DB, participant *model.Participant, question *model.Question, correct bool, timeTaken float64) {
+	t.Helper()
+
+	answer, err := model.NewAnswer(participant.ID, question.ID, []string{"option"}, timeTaken, correct)
+	if err != nil {
+		t.Fatalf("new answer: %v", err)
+	}
+	if err := NewPostgresAnswerRepository(db).CreateAnswer(context.Background(), answer); err != nil {
+		t.Fatalf("seed answer: %v", err)
+	}
+}
+
+func TestVoidQuestionRescoresParticipants(t *testing.T) {
+	tests := []struct {
+		name   string
+		points int
+	}{
+		{name: "remove", points: 0},
+		{name: "full points", points: model.CorrectAnswerPoints},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			ctx := context.Background()
+			quiz := seedTestQuiz(t, db, model.QuizSettings{})
+			voided := seedTestQuestion(t, db, quiz, 1)
+			kept := seedTestQuestion(t, db, quiz, 2)
+			right := seedTestParticipant(t, db, quiz, "Right", 2*model.CorrectAnswerPoints, time.Now())
+			wrong := seedTestParticipant(t, db, quiz, "Wrong", model.CorrectAnswerPoints, time.Now())
+			seedTestAnswer(t, db, right, voided, true, 1)
+			seedTestAnswer(t, db, right, kept, true, 1)
+			seedTestAnswer(t, db, wrong, voided, false, 1)
+			seedTestAnswer(t, db, wrong, kept, true, 1)
+
+			if err := NewPostgresQuestionRepository(db).VoidQuestion(ctx, voided.ID, tt.points); err != nil {
+				t.Fatalf("VoidQuestion: %v", err)
+			}
+
+			participants := NewPostgresParticipantRepository(db)
+			for _, p := range []*model.Participant{right, wrong} {
+				stored, err := participants.GetParticipantByID(ctx, p.ID)
+				if err != nil {
+					t.Fatalf("GetParticipantByID: %v", err)
+				}
+				if want := model.CorrectAnswerPoints + tt.points; stored.Score != want {
+					t.Errorf("%s scored %d after the void, want %d", p.Name, stored.Score, want)
+				}
+			}
+
+			if err := NewPostgresQuestionRepository(db).VoidQuestion(ctx, voided.ID, tt.points); err == nil {
+				t.Error("voided a question twice")
+			}
+		})
+	}
+}
+
+func TestUpdateQuestionTimeLimitsIsAllOrNothing(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewPostgresQuestionRepository(db)
+	quiz := seedTestQuiz(t, db, model.QuizSettings{})
+	first := seedTestQuestion(t, db, quiz, 1)
+	second := seedTestQuestion(t, db, quiz, 2)
+	foreign := seedTestQuestion(t, db, seedTestQuiz(t, db, model.QuizSettings{}), 1)
+
+	limits := func() []int {
+		t.Helper()
+		questions, err := repo.GetQuestionsByQuizID(ctx, quiz.ID)
+		if err != nil {
+			t.Fatalf("GetQuestionsByQuizID: %v", err)
+		}
+		var limits []int
+		for _, question := range questions {
+			limits = append(limits, question.TimeLimit)
+		}
+		return limits
+	}
+
+	// A question of another quiz fails the whole batch
+	if err := repo.UpdateQuestionTimeLimits(ctx, quiz.ID, []uuid.UUID{first.ID, foreign.ID}, 20); err == nil {
+		t.Fatal("UpdateQuestionTimeLimits changed a question of another quiz")
+	}
+	if got := limits(); len(got) != 2 || got[0] != 30 || got[1] != 30 {
+		t.Errorf("time limits after the failed batch are %v, want both unchanged at 30", got)
+	}
+
+	if err := repo.UpdateQuestionTimeLimits(ctx, quiz.ID, []uuid.UUID{first.ID, second.ID}, 20); err != nil {
+		t.Fatalf("UpdateQuestionTimeLimits: %v", err)
+	}
+	if got := limits(); len(got) != 2 || got[0] != 20 || got[1] != 20 {
+		t.Errorf("time limits are %v, want both at 20", got)
+	}
+}
@@ -3,13 +3,25 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
 	"github.com/google/uuid"
 )
 
+// ErrQuizSessionNotFound is returned when a quiz has no session row
+var ErrQuizSessionNotFound = errors.New("quiz session not found")
+
+// ErrQuizStatusChanged is returned when a status transition finds the quiz no longer in the expected status
+var ErrQuizStatusChanged = errors.New("quiz status changed concurrently")
+
+// ErrActiveQuizLimit is returned when activating a quiz would exceed its creator's active quiz limit
+var ErrActiveQuizLimit = errors.New("creator has too many active quizzes")
+
 // PostgresQuizRepository implements QuizRepository interface for PostgreSQL
 type PostgresQuizRepository struct {
 	db *DB
@@ -23,10 +35,16 @@ func NewPostgresQuizRepository(db *DB) *PostgresQuizRepository {
 // CreateQuiz creates a new quiz
 func (r *PostgresQuizRepository) CreateQuiz(ctx context.Context, quiz *model.Quiz) error {
 	query := `
-		INSERT INTO quizzes (id, title, description, creator_id, status, code, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO quizzes (id, title, description, creator_id, status, code, settings, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
-	_, err := r.db.ExecContext(
+
+	settings, err := json.Marshal(quiz.Settings)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(
 		ctx,
 		query,
 		quiz.ID,
@@ -35,39 +53,55 @@ func (r *PostgresQuizRepository) CreateQuiz(ctx context.Context, quiz *model.Qui
 		quiz.CreatorID,
 		quiz.Status,
 		quiz.Code,
+		settings,
 		quiz.CreatedAt,
 		quiz.UpdatedAt,
 	)
 	return err
 }
 
-// GetQuizByID retrieves a quiz by its ID
-func (r *PostgresQuizRepository) GetQuizByID(ctx context.Context, id uuid.UUID) (*model.Quiz, error) {
-	query := `
-		SELECT id, title, description, creator_id, status, code, created_at, updated_at
-		FROM quizzes
-		WHERE id = $1
-	`
+// quizColumns lists the quiz columns read by scanQuiz, in order
+const quizColumns = `id, title, description, creator_id, status, code, settings, created_at, updated_at, archived_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// extraColumns scans columns selected after the ones a scan function knows about into dest
+type extraColumns struct {
+	rowScanner
+	dest []interface{}
+}
+
+// Scan scans the row into dest followed by the extra destinations
+func (e extraColumns) Scan(dest ...interface{}) error {
+	return e.rowScanner.Scan(append(dest, e.dest...)...)
+}
 
+// scanQuiz scans a quiz selected with quizColumns
+func scanQuiz(row rowScanner) (*model.Quiz, error) {
 	var quiz model.Quiz
 	var description sql.NullString // Use sql.NullString to handle NULL values
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	var settings []byte
+	var archivedAt sql.NullTime
+	if err := row.Scan(
 		&quiz.ID,
 		&quiz.Title,
 		&description,
 		&quiz.CreatorID,
 		&quiz.Status,
 		&quiz.Code,
+		&settings,
 		&quiz.CreatedAt,
 		&quiz.UpdatedAt,
-	)
-
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("quiz not found")
-		}
+		&archivedAt,
+	); err != nil {
 		return nil, err
 	}
+	if archivedAt.Valid {
+		quiz.ArchivedAt = &archivedAt.Time
+	}
 
 	// Convert NullString to string
 	if description.Valid {
@@ -76,30 +110,43 @@ func (r *PostgresQuizRepository) GetQuizByID(ctx context.Context, id uuid.UUID)
 		quiz.Description = ""
 	}
 
+	if len(settings) > 0 {
+		if err := json.Unmarshal(settings, &quiz.Settings); err != nil {
+			return nil, err
+		}
+	}
+
 	return &quiz, nil
 }
 
+// GetQuizByID retrieves a quiz by its ID
+func (r *PostgresQuizRepository) GetQuizByID(ctx context.Context, id uuid.UUID) (*model.Quiz, error) {
+	query := `
+		SELECT ` + quizColumns + `
+		FROM quizzes
+		WHERE id = $1
+	`
+
+	quiz, err := scanQuiz(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("quiz not found")
+		}
+		return nil, err
+	}
+
+	return quiz, nil
+}
+
 // GetQuizByCode retrieves a quiz by its code
 func (r *PostgresQuizRepository) GetQuizByCode(ctx context.Context, code string) (*model.Quiz, error) {
 	query := `
-		SELECT id, title, description, creator_id, status, code, created_at, updated_at
+		SELECT ` + quizColumns + `
 		FROM quizzes
 		WHERE code = $1
 	`
 
-	var quiz model.Quiz
-	var description sql.NullString // Use sql.NullString to handle NULL values
-	err := r.db.QueryRowContext(ctx, query, code).Scan(
-		&quiz.ID,
-		&quiz.Title,
-		&description,
-		&quiz.CreatorID,
-		&quiz.Status,
-		&quiz.Code,
-		&quiz.CreatedAt,
-		&quiz.UpdatedAt,
-	)
-
+	quiz, err := scanQuiz(r.db.QueryRowContext(ctx, query, code))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errors.New("quiz not found")
@@ -107,26 +154,85 @@ func (r *PostgresQuizRepository) GetQuizByCode(ctx context.Context, code string)
 		return nil, err
 	}
 
-	// Convert NullString to string
-	if description.Valid {
-		quiz.Description = description.String
-	} else {
-		quiz.Description = ""
+	return quiz, nil
+}
+
+// QuizCodeExists reports whether any quiz uses the given code
+func (r *PostgresQuizRepository) QuizCodeExists(ctx context.Context, code string) (bool, error) {
+	query := `
+		SELECT EXISTS(SELECT 1 FROM quizzes WHERE code = $1)
+	`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, code).Scan(&exists); err != nil {
+		return false, err
 	}
 
-	return &quiz, nil
+	return exists, nil
+}
+
+// UpdateQuizCode replaces a quiz's join code
+func (r *PostgresQuizRepository) UpdateQuizCode(ctx context.Context, id uuid.UUID, code string) error {
+	query := `
+		UPDATE quizzes
+		SET code = $1, updated_at = $2
+		WHERE id = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, code, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("quiz not found")
+	}
+
+	return nil
+}
+
+// creatorQuizConditions builds the WHERE clause and arguments selecting a user's quizzes that match filter
+func creatorQuizConditions(creatorID uuid.UUID, filter model.QuizFilter) (string, []interface{}) {
+	conditions := []string{"creator_id = $1"}
+	args := []interface{}{creatorID}
+
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if !filter.IncludeArchived {
+		conditions = append(conditions, "archived_at IS NULL")
+	}
+	if filter.Search != "" {
+		// Match the search text literally, not as a LIKE pattern
+		escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(filter.Search)
+		args = append(args, "%"+escaped+"%")
+		conditions = append(conditions, fmt.Sprintf("title ILIKE $%d", len(args)))
+	}
+
+	return strings.Join(conditions, " AND "), args
 }
 
-// GetQuizzesByCreatorID retrieves all quizzes created by a user
-func (r *PostgresQuizRepository) GetQuizzesByCreatorID(ctx context.Context, creatorID uuid.UUID) ([]*model.Quiz, error) {
+// GetQuizzesByCreatorID retrieves the quizzes created by a user that match filter, newest first
+func (r *PostgresQuizRepository) GetQuizzesByCreatorID(ctx context.Context, creatorID uuid.UUID, filter model.QuizFilter) ([]*model.Quiz, error) {
+	where, args := creatorQuizConditions(creatorID, filter)
 	query := `
-		SELECT id, title, description, creator_id, status, code, created_at, updated_at
+		SELECT ` + quizColumns + `
 		FROM quizzes
-		WHERE creator_id = $1
-		ORDER BY created_at DESC
+		WHERE ` + where + `
+		ORDER BY created_at DESC, id
 	`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit, filter.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
 
-	rows, err := r.db.QueryContext(ctx, query, creatorID)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -134,36 +240,94 @@ func (r *PostgresQuizRepository) GetQuizzesByCreatorID(ctx context.Context, crea
 
 	var quizzes []*model.Quiz
 	for rows.Next() {
-		var quiz model.Quiz
-		var description sql.NullString // Use sql.NullString to handle NULL values
-		if err := rows.Scan(
-			&quiz.ID,
-			&quiz.Title,
-			&description,
-			&quiz.CreatorID,
-			&quiz.Status,
-			&quiz.Code,
-			&quiz.CreatedAt,
-			&quiz.UpdatedAt,
-		); err != nil {
+		quiz, err := scanQuiz(rows)
+		if err != nil {
 			return nil, err
 		}
+		quizzes = append(quizzes, quiz)
+	}
 
-		// Convert NullString to string
-		if description.Valid {
-			quiz.Description = description.String
-		} else {
-			quiz.Description = ""
-		}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return quizzes, nil
+}
+
+// GetStaleActiveQuizzes retrieves the active quizzes with no event, question start or update since cutoff,
+// least recently active first
+func (r *PostgresQuizRepository) GetStaleActiveQuizzes(ctx context.Context, cutoff time.Time) ([]*model.QuizActivity, error) {
+	// GREATEST ignores NULLs, so quizzes without a session or events fall back to their update time
+	query := `
+		SELECT ` + quizColumns + `, last_activity_at
+		FROM (
+			SELECT q.*, GREATEST(q.updated_at, s.started_at, s.current_question_started_at, e.last_event_at) AS last_activity_at
+			FROM quizzes q
+			LEFT JOIN quiz_sessions s ON s.quiz_id = q.id
+			LEFT JOIN (
+				SELECT quiz_id, MAX(created_at) AS last_event_at
+				FROM quiz_events
+				GROUP BY quiz_id
+			) e ON e.quiz_id = q.id
+			WHERE q.status = $1
+		) activity
+		WHERE last_activity_at < $2
+		ORDER BY last_activity_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, model.QuizStatusActive, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-		quizzes = append(quizzes, &quiz)
+	var stale []*model.QuizActivity
+	for rows.Next() {
+		var lastActivityAt time.Time
+		quiz, err := scanQuiz(extraColumns{rowScanner: rows, dest: []interface{}{&lastActivityAt}})
+		if err != nil {
+			return nil, err
+		}
+		stale = append(stale, &model.QuizActivity{Quiz: quiz, LastActivityAt: lastActivityAt})
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return quizzes, nil
+	return stale, nil
+}
+
+// CountQuizzesByCreatorID counts the quizzes created by a user that match filter, ignoring its limit and offset
+func (r *PostgresQuizRepository) CountQuizzesByCreatorID(ctx context.Context, creatorID uuid.UUID, filter model.QuizFilter) (int, error) {
+	where, args := creatorQuizConditions(creatorID, filter)
+	query := `
+		SELECT COUNT(*)
+		FROM quizzes
+		WHERE ` + where
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CountActiveQuizzesByCreator counts the quizzes of a user that are currently active
+func (r *PostgresQuizRepository) CountActiveQuizzesByCreator(ctx context.Context, creatorID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM quizzes
+		WHERE creator_id = $1 AND status = $2
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, creatorID, model.QuizStatusActive).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
 }
 
 // UpdateQuizStatus updates the status of a quiz
@@ -223,6 +387,61 @@ func (r *PostgresQuizRepository) UpdateQuiz(ctx context.Context, quiz *model.Qui
 	return nil
 }
 
+// SetQuizArchived archives a quiz at archivedAt, or unarchives it when archivedAt is nil
+func (r *PostgresQuizRepository) SetQuizArchived(ctx context.Context, id uuid.UUID, archivedAt *time.Time) error {
+	query := `
+		UPDATE quizzes
+		SET archived_at = $1, updated_at = $2
+		WHERE id = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, archivedAt, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("quiz not found")
+	}
+
+	return nil
+}
+
+// UpdateQuizSettings replaces a quiz's settings
+func (r *PostgresQuizRepository) UpdateQuizSettings(ctx context.Context, id uuid.UUID, settings model.QuizSettings) error {
+	query := `
+		UPDATE quizzes
+		SET settings = $1, updated_at = $2
+		WHERE id = $3
+	`
+
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx, query, settingsJSON, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("quiz not found")
+	}
+
+	return nil
+}
+
 // DeleteQuiz deletes a quiz and all its related data
 func (r *PostgresQuizRepository) DeleteQuiz(ctx context.Context, id uuid.UUID) error {
 	// Due to the ON DELETE CASCADE constraints set up in the database,
@@ -250,6 +469,87 @@ func (r *PostgresQuizRepository) DeleteQuiz(ctx context.Context, id uuid.UUID) e
 	return nil
 }
 
+// CreateQuizWithContent atomically creates a quiz, its session and its questions with their options
+func (r *PostgresQuizRepository) CreateQuizWithContent(ctx context.Context, quiz *model.Quiz, session *model.QuizSession, questions []*model.Question) error {
+	return r.db.Transaction(ctx, func(tx *sql.Tx) error {
+		return insertQuizContent(ctx, tx, quiz, session, questions)
+	})
+}
+
+// CreateGuestQuizWithContent atomically creates a guest host together with their quiz, its session and
+// its questions, so a quiz that cannot be saved leaves no guest behind
+func (r *PostgresQuizRepository) CreateGuestQuizWithContent(ctx context.Context, guest *model.User, quiz *model.Quiz, session *model.QuizSession, questions []*model.Question) error {
+	userQuery := `
+		INSERT INTO users (id, name, email, password_hash, is_guest, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	return r.db.Transaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, userQuery,
+			guest.ID, guest.Name, guest.Email, guest.PasswordHash, guest.IsGuest, guest.CreatedAt,
+		); err != nil {
+			return err
+		}
+
+		return insertQuizContent(ctx, tx, quiz, session, questions)
+	})
+}
+
+// insertQuizContent writes a quiz, its session and its questions with their options within tx
+func insertQuizContent(ctx context.Context, tx *sql.Tx, quiz *model.Quiz, session *model.QuizSession, questions []*model.Question) error {
+	settings, err := json.Marshal(quiz.Settings)
+	if err != nil {
+		return err
+	}
+
+	quizQuery := `
+		INSERT INTO quizzes (id, title, description, creator_id, status, code, settings, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	sessionQuery := `
+		INSERT INTO quiz_sessions (quiz_id, status, current_phase)
+		VALUES ($1, $2, $3)
+	`
+	questionQuery := `
+		INSERT INTO questions (id, quiz_id, text, time_limit, "order", question_type, image_url, explanation, is_required, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	optionQuery := `
+		INSERT INTO question_options (id, question_id, text, is_correct, display_order, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	if _, err := tx.ExecContext(ctx, quizQuery,
+		quiz.ID, quiz.Title, quiz.Description, quiz.CreatorID, quiz.Status, quiz.Code, settings, quiz.CreatedAt, quiz.UpdatedAt,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, sessionQuery, session.QuizID, session.Status, session.CurrentPhase); err != nil {
+		return err
+	}
+
+	for _, question := range questions {
+		if _, err := tx.ExecContext(ctx, questionQuery,
+			question.ID, question.QuizID, question.Text, question.TimeLimit, question.Order, question.QuestionType,
+			question.ImageURL, question.Explanation, question.Required, question.CreatedAt, question.UpdatedAt,
+		); err != nil {
+			return err
+		}
+
+		for _, option := range question.Options {
+			if _, err := tx.ExecContext(ctx, optionQuery,
+				option.ID, option.QuestionID, option.Text, option.IsCorrect, option.DisplayOrder,
+				option.CreatedAt, option.UpdatedAt,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 // CreateQuizSession creates a new quiz session
 func (r *PostgresQuizRepository) CreateQuizSession(ctx context.Context, session *model.QuizSession) error {
 	query := `
@@ -271,12 +571,14 @@ func (r *PostgresQuizRepository) CreateQuizSession(ctx context.Context, session
 func (r *PostgresQuizRepository) GetQuizSession(ctx context.Context, quizID uuid.UUID) (*model.QuizSession, error) {
 	query := `
 		SELECT quiz_id, current_question_id, status, current_phase, started_at, ended_at,
-		       current_question_started_at, current_question_ended_at, next_question_id
+		       current_question_started_at, current_question_ended_at, next_question_id,
+		       current_question_extra_seconds, leaderboard_frozen, frozen_leaderboard
 		FROM quiz_sessions
 		WHERE quiz_id = $1
 	`
 
 	var session model.QuizSession
+	var frozenLeaderboard []byte
 	err := r.db.QueryRowContext(ctx, query, quizID).Scan(
 		&session.QuizID,
 		&session.CurrentQuestionID,
@@ -287,15 +589,24 @@ func (r *PostgresQuizRepository) GetQuizSession(ctx context.Context, quizID uuid
 		&session.CurrentQuestionStartedAt,
 		&session.CurrentQuestionEndedAt,
 		&session.NextQuestionID,
+		&session.CurrentQuestionExtraSeconds,
+		&session.LeaderboardFrozen,
+		&frozenLeaderboard,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("quiz session not found")
+			return nil, ErrQuizSessionNotFound
 		}
 		return nil, err
 	}
 
+	if len(frozenLeaderboard) > 0 {
+		if err := json.Unmarshal(frozenLeaderboard, &session.FrozenLeaderboard); err != nil {
+			return nil, err
+		}
+	}
+
 	return &session, nil
 }
 
@@ -310,8 +621,9 @@ func (r *PostgresQuizRepository) UpdateQuizSession(ctx context.Context, session
 			ended_at = $5,
 			current_question_started_at = $6,
 			current_question_ended_at = $7,
-			next_question_id = $8
-		WHERE quiz_id = $9
+			next_question_id = $8,
+			current_question_extra_seconds = $9
+		WHERE quiz_id = $10
 	`
 
 	result, err := r.db.ExecContext(
@@ -325,6 +637,7 @@ func (r *PostgresQuizRepository) UpdateQuizSession(ctx context.Context, session
 		session.CurrentQuestionStartedAt,
 		session.CurrentQuestionEndedAt,
 		session.NextQuestionID,
+		session.CurrentQuestionExtraSeconds,
 		session.QuizID,
 	)
 
@@ -338,8 +651,143 @@ func (r *PostgresQuizRepository) UpdateQuizSession(ctx context.Context, session
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("quiz session not found")
+		return ErrQuizSessionNotFound
+	}
+
+	return nil
+}
+
+// SetLeaderboardFrozen freezes or unfreezes a quiz's leaderboard, storing the standings to compare against on reveal.
+// Only the freeze columns are written so regular session updates never clear a freeze.
+func (r *PostgresQuizRepository) SetLeaderboardFrozen(ctx context.Context, quizID uuid.UUID, frozen bool, standings []model.LeaderboardStanding) error {
+	query := `
+		UPDATE quiz_sessions
+		SET leaderboard_frozen = $1, frozen_leaderboard = $2
+		WHERE quiz_id = $3
+	`
+
+	var standingsJSON []byte
+	if standings != nil {
+		var err error
+		if standingsJSON, err = json.Marshal(standings); err != nil {
+			return err
+		}
+	}
+
+	result, err := r.db.ExecContext(ctx, query, frozen, standingsJSON, quizID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrQuizSessionNotFound
 	}
 
 	return nil
 }
+
+// UpdateQuizStatusWithSession moves a quiz from one status to another and saves its session in one transaction.
+// The session row is created if it is missing. Only one of several concurrent identical transitions succeeds;
+// the others get ErrQuizStatusChanged.
+func (r *PostgresQuizRepository) UpdateQuizStatusWithSession(ctx context.Context, from model.QuizStatus, to model.QuizStatus, session *model.QuizSession) error {
+	return r.db.Transaction(ctx, func(tx *sql.Tx) error {
+		return updateQuizStatusWithSession(ctx, tx, from, to, session)
+	})
+}
+
+// ActivateQuizWithSession moves a waiting quiz to active and saves its session in one transaction, unless its
+// creator already runs maxActivePerCreator active quizzes. Activations of the same creator are serialized by a
+// transaction-scoped advisory lock, so concurrent starts can't both slip under the limit. A limit of 0 or less
+// disables the check.
+func (r *PostgresQuizRepository) ActivateQuizWithSession(ctx context.Context, session *model.QuizSession, maxActivePerCreator int) error {
+	lockQuery := `
+		SELECT pg_advisory_xact_lock(hashtext(creator_id::text))
+		FROM quizzes
+		WHERE id = $1
+	`
+
+	countQuery := `
+		SELECT COUNT(*)
+		FROM quizzes
+		WHERE creator_id = (SELECT creator_id FROM quizzes WHERE id = $1) AND status = $2 AND id <> $1
+	`
+
+	return r.db.Transaction(ctx, func(tx *sql.Tx) error {
+		if maxActivePerCreator > 0 {
+			if _, err := tx.ExecContext(ctx, lockQuery, session.QuizID); err != nil {
+				return err
+			}
+
+			var activeCount int
+			if err := tx.QueryRowContext(ctx, countQuery, session.QuizID, model.QuizStatusActive).Scan(&activeCount); err != nil {
+				return err
+			}
+			if activeCount >= maxActivePerCreator {
+				return ErrActiveQuizLimit
+			}
+		}
+
+		return updateQuizStatusWithSession(ctx, tx, model.QuizStatusWaiting, model.QuizStatusActive, session)
+	})
+}
+
+// updateQuizStatusWithSession moves a quiz from one status to another and upserts its session within tx
+func updateQuizStatusWithSession(ctx context.Context, tx *sql.Tx, from model.QuizStatus, to model.QuizStatus, session *model.QuizSession) error {
+	statusQuery := `
+		UPDATE quizzes
+		SET status = $1, updated_at = $2
+		WHERE id = $3 AND status = $4
+	`
+
+	sessionQuery := `
+		INSERT INTO quiz_sessions (quiz_id, current_question_id, status, current_phase, started_at, ended_at,
+		                           current_question_started_at, current_question_ended_at, next_question_id,
+		                           current_question_extra_seconds)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (quiz_id) DO UPDATE
+		SET current_question_id = EXCLUDED.current_question_id,
+			status = EXCLUDED.status,
+			current_phase = EXCLUDED.current_phase,
+			started_at = EXCLUDED.started_at,
+			ended_at = EXCLUDED.ended_at,
+			current_question_started_at = EXCLUDED.current_question_started_at,
+			current_question_ended_at = EXCLUDED.current_question_ended_at,
+			next_question_id = EXCLUDED.next_question_id,
+			current_question_extra_seconds = EXCLUDED.current_question_extra_seconds
+	`
+
+	result, err := tx.ExecContext(ctx, statusQuery, to, time.Now(), session.QuizID, from)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrQuizStatusChanged
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		sessionQuery,
+		session.QuizID,
+		session.CurrentQuestionID,
+		session.Status,
+		session.CurrentPhase,
+		session.StartedAt,
+		session.EndedAt,
+		session.CurrentQuestionStartedAt,
+		session.CurrentQuestionEndedAt,
+		session.NextQuestionID,
+		session.CurrentQuestionExtraSeconds,
+	)
+	return err
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+// seedCreatorQuiz stores another quiz of creator's in the given status
+func seedCreatorQuiz(t *testing.T, db *DB, creator *model.Quiz, status model.QuizStatus) *model.Quiz {
+	t.Helper()
+	return seedTitledQuiz(t, db, creator, "Test quiz", status)
+}
+
+// seedTitledQuiz stores another quiz of creator's with the given title and status
+func seedTitledQuiz(t *testing.T, db *DB, creator *model.Quiz, title string, status model.QuizStatus) *model.Quiz {
+	t.Helper()
+
+	quiz := model.NewQuiz(title, "", creator.CreatorID)
+	quiz.Status = status
+	session := model.NewQuizSession(quiz.ID)
+	session.Status = status
+	if err := NewPostgresQuizRepository(db).CreateQuizWithContent(context.Background(), quiz, session, nil); err != nil {
+		t.Fatalf("seed quiz: %v", err)
+	}
+	return quiz
+}
+
+func TestCountActiveQuizzesByCreator(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresQuizRepository(db)
+	first := seedTestQuiz(t, db, model.QuizSettings{})
+	seedCreatorQuiz(t, db, first, model.QuizStatusActive)
+	seedCreatorQuiz(t, db, first, model.QuizStatusActive)
+	seedCreatorQuiz(t, db, first, model.QuizStatusCompleted)
+	// Another creator's active quiz
+	other := seedTestQuiz(t, db, model.QuizSettings{})
+	seedCreatorQuiz(t, db, other, model.QuizStatusActive)
+
+	count, err := repo.CountActiveQuizzesByCreator(context.Background(), first.CreatorID)
+	if err != nil {
+		t.Fatalf("CountActiveQuizzesByCreator: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("creator has %d active quizzes, want 2", count)
+	}
+}
+
+func TestActivateQuizWithSessionAllowsOneOfTwoConcurrentStartsAtTheLimit(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresQuizRepository(db)
+	first := seedTestQuiz(t, db, model.QuizSettings{})
+	second := seedCreatorQuiz(t, db, first, model.QuizStatusWaiting)
+
+	errs := make(chan error, 2)
+	for _, quiz := range []*model.Quiz{first, second} {
+		go func(quizID uuid.UUID) {
+			session := model.NewQuizSession(quizID)
+			session.Status = model.QuizStatusActive
+			errs <- repo.ActivateQuizWithSession(context.Background(), session, 1)
+		}(quiz.ID)
+	}
+
+	var limited int
+	for i := 0; i < 2; i++ {
+		err := <-errs
+		switch {
+		case errors.Is(err, ErrActiveQuizLimit):
+			limited++
+		case err != nil:
+			t.Fatalf("ActivateQuizWithSession: %v", err)
+		}
+	}
+	if limited != 1 {
+		t.Errorf("%d of two concurrent starts hit the limit, want 1", limited)
+	}
+
+	count, err := repo.CountActiveQuizzesByCreator(context.Background(), first.CreatorID)
+	if err != nil {
+		t.Fatalf("CountActiveQuizzesByCreator: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("creator has %d active quizzes, want 1", count)
+	}
+}
+
+func TestGetQuizzesByCreatorIDFiltersByStatusAndTitle(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewPostgresQuizRepository(db)
+	first := seedTestQuiz(t, db, model.QuizSettings{}) // "Test quiz", waiting
+	history := seedTitledQuiz(t, db, first, "World History", model.QuizStatusActive)
+	seedTitledQuiz(t, db, first, "Geography", model.QuizStatusActive)
+	discount := seedTitledQuiz(t, db, first, "50% off history", model.QuizStatusCompleted)
+	seedTitledQuiz(t, db, first, "500 questions", model.QuizStatusCompleted)
+	// Another creator's matching quiz
+	other := seedTestQuiz(t, db, model.QuizSettings{})
+	seedTitledQuiz(t, db, other, "History", model.QuizStatusActive)
+
+	tests := []struct {
+		name   string
+		filter model.QuizFilter
+		want   []*model.Quiz
+	}{
+		{name: "status", filter: model.QuizFilter{Status: model.QuizStatusWaiting}, want: []*model.Quiz{first}},
+		{name: "title ignoring case", filter: model.QuizFilter{Search: "HISTORY"}, want: []*model.Quiz{discount, history}},
+		{name: "status and title", filter: model.QuizFilter{Status: model.QuizStatusActive, Search: "history"}, want: []*model.Quiz{history}},
+		{name: "wildcards taken literally", filter: model.QuizFilter{Search: "50%"}, want: []*model.Quiz{discount}},
+		{name: "no match", filter: model.QuizFilter{Search: "chemistry"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quizzes, err := repo.GetQuizzesByCreatorID(ctx, first.CreatorID, tt.filter)
+			if err != nil {
+				t.Fatalf("GetQuizzesByCreatorID: %v", err)
+			}
+			var got, want []string
+			for _, quiz := range quizzes {
+				got = append(got, quiz.Title)
+			}
+			for _, quiz := range tt.want {
+				want = append(want, quiz.Title)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("quizzes = %q, want %q newest first", got, want)
+			}
+
+			count, err := repo.CountQuizzesByCreatorID(ctx, first.CreatorID, tt.filter)
+			if err != nil {
+				t.Fatalf("CountQuizzesByCreatorID: %v", err)
+			}
+			if count != len(tt.want) {
+				t.Errorf("counted %d quizzes, want %d", count, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestGetQuizzesByCreatorIDLeavesOutArchivedQuizzes(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewPostgresQuizRepository(db)
+	kept := seedTestQuiz(t, db, model.QuizSettings{})
+	archived := seedTitledQuiz(t, db, kept, "Archived", model.QuizStatusCompleted)
+	archivedAt := time.Now()
+	if err := repo.SetQuizArchived(ctx, archived.ID, &archivedAt); err != nil {
+		t.Fatalf("SetQuizArchived: %v", err)
+	}
+
+	for _, tt := range []struct {
+		filter model.QuizFilter
+		want   int
+	}{
+		{filter: model.QuizFilter{}, want: 1},
+		{filter: model.QuizFilter{IncludeArchived: true}, want: 2},
+	} {
+		quizzes, err := repo.GetQuizzesByCreatorID(ctx, kept.CreatorID, tt.filter)
+		if err != nil {
+			t.Fatalf("GetQuizzesByCreatorID: %v", err)
+		}
+		count, err := repo.CountQuizzesByCreatorID(ctx, kept.CreatorID, tt.filter)
+		if err != nil {
+			t.Fatalf("CountQuizzesByCreatorID: %v", err)
+		}
+		if len(quizzes) != tt.want || count != tt.want {
+			t.Errorf("with %+v listed %d and counted %d quizzes, want %d", tt.filter, len(quizzes), count, tt.want)
+		}
+		if !tt.filter.IncludeArchived && len(quizzes) > 0 && quizzes[0].ID != kept.ID {
+			t.Errorf("default listing returned %q, want the quiz that is not archived", quizzes[0].Title)
+		}
+	}
+}
+
+func TestGetStaleActiveQuizzesUsesTheLatestActivity(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewPostgresQuizRepository(db)
+	owner := seedTestQuiz(t, db, model.QuizSettings{})
+	idle := seedCreatorQuiz(t, db, owner, model.QuizStatusActive)
+	busy := seedCreatorQuiz(t, db, owner, model.QuizStatusActive)
+	finished := seedCreatorQuiz(t, db, owner, model.QuizStatusCompleted)
+
+	longAgo := time.Now().Add(-2 * time.Hour)
+	for _, quiz := range []*model.Quiz{idle, busy, finished} {
+		if _, err := db.ExecContext(ctx, `UPDATE quizzes SET updated_at = $2 WHERE id = $1`, quiz.ID, longAgo); err != nil {
+			t.Fatalf("age quiz: %v", err)
+		}
+		if _, err := db.ExecContext(ctx, `UPDATE quiz_sessions SET started_at = $2 WHERE quiz_id = $1`, quiz.ID, longAgo); err != nil {
+			t.Fatalf("age session: %v", err)
+		}
+	}
+	// The busy quiz recorded an event a minute ago
+	if _, err := db.ExecContext(ctx,
+		`INSERT INTO quiz_events (quiz_id, event_type, payload, sequence_number, created_at) VALUES ($1, 'QUESTION_START', '{}', 1, $2)`,
+		busy.ID, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("seed event: %v", err)
+	}
+
+	stale, err := repo.GetStaleActiveQuizzes(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("GetStaleActiveQuizzes: %v", err)
+	}
+
+	// Other tests' quizzes may be stale too
+	found := make(map[uuid.UUID]*model.QuizActivity)
+	for _, activity := range stale {
+		found[activity.Quiz.ID] = activity
+	}
+	activity, ok := found[idle.ID]
+	if !ok {
+		t.Fatal("the quiz idle for two hours is not listed")
+	}
+	if diff := activity.LastActivityAt.Sub(longAgo); diff < -time.Second || diff > time.Second {
+		t.Errorf("idle quiz was last active at %v, want %v", activity.LastActivityAt, longAgo)
+	}
+	if _, ok := found[busy.ID]; ok {
+		t.Error("the quiz with a recent event is listed")
+	}
+	if _, ok := found[finished.ID]; ok {
+		t.Error("a completed quiz is listed")
+	}
+}
@@ -19,12 +19,34 @@ type QuizRepository interface {
 	// GetQuizByCode retrieves a quiz by its code
 	GetQuizByCode(ctx context.Context, code string) (*model.Quiz, error)
 
-	// GetQuizzesByCreatorID retrieves all quizzes created by a user
-	GetQuizzesByCreatorID(ctx context.Context, creatorID uuid.UUID) ([]*model.Quiz, error)
+	// QuizCodeExists reports whether any quiz uses the given code
+	QuizCodeExists(ctx context.Context, code string) (bool, error)
+
+	// UpdateQuizCode replaces a quiz's join code
+	UpdateQuizCode(ctx context.Context, id uuid.UUID, code string) error
+
+	// GetQuizzesByCreatorID retrieves the quizzes created by a user that match filter, newest first
+	GetQuizzesByCreatorID(ctx context.Context, creatorID uuid.UUID, filter model.QuizFilter) ([]*model.Quiz, error)
+
+	// CountQuizzesByCreatorID counts the quizzes created by a user that match filter, ignoring its limit and offset
+	CountQuizzesByCreatorID(ctx context.Context, creatorID uuid.UUID, filter model.QuizFilter) (int, error)
+
+	// CountActiveQuizzesByCreator counts the quizzes of a user that are currently active
+	CountActiveQuizzesByCreator(ctx context.Context, creatorID uuid.UUID) (int, error)
+
+	// GetStaleActiveQuizzes retrieves the active quizzes with no event, question start or update since cutoff,
+	// least recently active first
+	GetStaleActiveQuizzes(ctx context.Context, cutoff time.Time) ([]*model.QuizActivity, error)
 
 	// UpdateQuizStatus updates the status of a quiz
 	UpdateQuizStatus(ctx context.Context, id uuid.UUID, status model.QuizStatus) error
 
+	// CreateQuizWithContent atomically creates a quiz, its session and its questions with their options
+	CreateQuizWithContent(ctx context.Context, quiz *model.Quiz, session *model.QuizSession, questions []*model.Question) error
+
+	// CreateGuestQuizWithContent atomically creates a guest host along with their quiz, its session and its questions
+	CreateGuestQuizWithContent(ctx context.Context, guest *model.User, quiz *model.Quiz, session *model.QuizSession, questions []*model.Question) error
+
 	// CreateQuizSession creates a new quiz session
 	CreateQuizSession(ctx context.Context, session *model.QuizSession) error
 
@@ -34,9 +56,27 @@ type QuizRepository interface {
 	// UpdateQuizSession updates a quiz session
 	UpdateQuizSession(ctx context.Context, session *model.QuizSession) error
 
+	// UpdateQuizStatusWithSession atomically moves a quiz from one status to another and saves its session,
+	// creating it if missing. It returns ErrQuizStatusChanged if the quiz is no longer in the from status.
+	UpdateQuizStatusWithSession(ctx context.Context, from model.QuizStatus, to model.QuizStatus, session *model.QuizSession) error
+
+	// ActivateQuizWithSession atomically moves a waiting quiz to active and saves its session. It returns
+	// ErrActiveQuizLimit if the creator already runs maxActivePerCreator active quizzes (0 disables the check)
+	// and ErrQuizStatusChanged if the quiz is no longer waiting.
+	ActivateQuizWithSession(ctx context.Context, session *model.QuizSession, maxActivePerCreator int) error
+
+	// SetLeaderboardFrozen freezes or unfreezes a quiz's leaderboard with the standings to compare against on reveal
+	SetLeaderboardFrozen(ctx context.Context, quizID uuid.UUID, frozen bool, standings []model.LeaderboardStanding) error
+
 	// UpdateQuiz updates a quiz's title and description
 	UpdateQuiz(ctx context.Context, quiz *model.Quiz) error
 
+	// SetQuizArchived archives a quiz at archivedAt, or unarchives it when archivedAt is nil
+	SetQuizArchived(ctx context.Context, id uuid.UUID, archivedAt *time.Time) error
+
+	// UpdateQuizSettings replaces a quiz's settings
+	UpdateQuizSettings(ctx context.Context, id uuid.UUID, settings model.QuizSettings) error
+
 	// DeleteQuiz deletes a quiz and all its related data
 	DeleteQuiz(ctx context.Context, id uuid.UUID) error
 }
@@ -58,8 +98,18 @@ type QuestionRepository interface {
 	// UpdateQuestion updates an existing question
 	UpdateQuestion(ctx context.Context, question *model.Question) error
 
+	// UpdateQuestionOrder reassigns the order of a quiz's questions atomically
+	UpdateQuestionOrder(ctx context.Context, quizID uuid.UUID, questionIDs []uuid.UUID) error
+
+	// UpdateQuestionTimeLimits sets the time limit of the given questions of a quiz atomically
+	UpdateQuestionTimeLimits(ctx context.Context, quizID uuid.UUID, questionIDs []uuid.UUID, timeLimit int) error
+
 	// DeleteQuestion deletes a question
 	DeleteQuestion(ctx context.Context, id uuid.UUID) error
+
+	// VoidQuestion atomically marks a question as voided with the points everyone is credited for it
+	// and recomputes the scores of the quiz's participants
+	VoidQuestion(ctx context.Context, id uuid.UUID, points int) error
 }
 
 // QuestionOptionRepository defines operations for question option management
@@ -70,6 +120,9 @@ type QuestionOptionRepository interface {
 	// GetQuestionOptionsByQuestionID retrieves all options for a question
 	GetQuestionOptionsByQuestionID(ctx context.Context, questionID uuid.UUID) ([]*model.QuestionOption, error)
 
+	// GetQuestionOptionsByQuizID retrieves the options of all questions of a quiz
+	GetQuestionOptionsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.QuestionOption, error)
+
 	// UpdateQuestionOption updates an existing question option
 	UpdateQuestionOption(ctx context.Context, option *model.QuestionOption) error
 
@@ -78,17 +131,23 @@ type QuestionOptionRepository interface {
 
 	// DeleteQuestionOptionsByQuestionID deletes all options for a question
 	DeleteQuestionOptionsByQuestionID(ctx context.Context, questionID uuid.UUID) error
+
+	// GetOrphanedQuestionOptions retrieves options whose question no longer exists
+	GetOrphanedQuestionOptions(ctx context.Context) ([]*model.QuestionOption, error)
+
+	// DeleteOrphanedQuestionOptions deletes options whose question no longer exists and returns how many were removed
+	DeleteOrphanedQuestionOptions(ctx context.Context) (int, error)
 }
 
 // UserRepository defines operations for user management
 type UserRepository interface {
-	// CreateUser creates a new user
+	// CreateUser creates a new user, returning ErrEmailTaken if the email is already registered
 	CreateUser(ctx context.Context, user *model.User) error
 
 	// GetUserByID retrieves a user by their ID
 	GetUserByID(ctx context.Context, id uuid.UUID) (*model.User, error)
 
-	// GetUserByEmail retrieves a user by their email
+	// GetUserByEmail retrieves a user by their email, ignoring case
 	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
 }
 
@@ -106,11 +165,56 @@ type ParticipantRepository interface {
 	// UpdateParticipantScore updates a participant's score
 	UpdateParticipantScore(ctx context.Context, participantID uuid.UUID, score int) error
 
-	// GetLeaderboard retrieves the top participants by score for a quiz
-	GetLeaderboard(ctx context.Context, quizID uuid.UUID, limit int) ([]*model.Participant, error)
+	// RecomputeParticipantScore recalculates a participant's score from their answers and returns the new total
+	RecomputeParticipantScore(ctx context.Context, participantID uuid.UUID) (int, error)
+
+	// GetLeaderboard retrieves a page of participants ranked by score, then by the quiz's tiebreak setting,
+	// setting each one's competition rank and answer time totals
+	GetLeaderboard(ctx context.Context, quizID uuid.UUID, limit int, offset int) ([]*model.Participant, error)
+
+	// CountParticipantsByQuizID counts the participants of a quiz
+	CountParticipantsByQuizID(ctx context.Context, quizID uuid.UUID) (int, error)
+
+	// CreateParticipantWithinLimit atomically creates a participant unless the quiz already has maxParticipants
+	// slot holders, returning ErrQuizFull; a non-positive maxParticipants means no cap
+	CreateParticipantWithinLimit(ctx context.Context, participant *model.Participant, maxParticipants int, graceCutoff time.Time) error
+
+	// CountSlotHoldersByQuizID counts the participants of a quiz that are connected or disconnected
+	// (or joined without connecting) more recently than graceCutoff
+	CountSlotHoldersByQuizID(ctx context.Context, quizID uuid.UUID, graceCutoff time.Time) (int, error)
 
 	// DeleteParticipant removes a participant by ID
 	DeleteParticipant(ctx context.Context, id uuid.UUID) error
+
+	// BanParticipant records the ban and removes the banned participant in one transaction
+	BanParticipant(ctx context.Context, ban *model.QuizBan) error
+
+	// IsNameBanned reports whether a banned participant of the quiz used the given name, ignoring case
+	IsNameBanned(ctx context.Context, quizID uuid.UUID, name string) (bool, error)
+}
+
+// TeamRepository defines operations for team management
+type TeamRepository interface {
+	// CreateTeam creates a new team
+	CreateTeam(ctx context.Context, team *model.Team) error
+
+	// GetTeamByID retrieves a team by its ID
+	GetTeamByID(ctx context.Context, id uuid.UUID) (*model.Team, error)
+
+	// GetTeamsByQuizID retrieves all teams for a quiz
+	GetTeamsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.Team, error)
+}
+
+// WebhookRepository defines operations for quiz webhook management
+type WebhookRepository interface {
+	// SaveQuizWebhook creates a quiz's webhook or replaces the existing one
+	SaveQuizWebhook(ctx context.Context, webhook *model.QuizWebhook) error
+
+	// GetQuizWebhook retrieves the webhook registered for a quiz
+	GetQuizWebhook(ctx context.Context, quizID uuid.UUID) (*model.QuizWebhook, error)
+
+	// DeleteQuizWebhook removes the webhook registered for a quiz
+	DeleteQuizWebhook(ctx context.Context, quizID uuid.UUID) error
 }
 
 // AnswerRepository defines operations for answer management
@@ -126,6 +230,15 @@ type AnswerRepository interface {
 
 	// GetAnswerByParticipantAndQuestion retrieves a participant's answer for a specific question
 	GetAnswerByParticipantAndQuestion(ctx context.Context, participantID uuid.UUID, questionID uuid.UUID) (*model.Answer, error)
+
+	// GetAnswerByClientToken retrieves a participant's answer to a question submitted with the given client token
+	GetAnswerByClientToken(ctx context.Context, participantID uuid.UUID, questionID uuid.UUID, clientToken string) (*model.Answer, error)
+
+	// CountAnswersByQuestionID counts the answers recorded for a question
+	CountAnswersByQuestionID(ctx context.Context, questionID uuid.UUID) (int, error)
+
+	// CountAnswersByQuizID counts the answers recorded for all questions of a quiz
+	CountAnswersByQuizID(ctx context.Context, quizID uuid.UUID) (int, error)
 }
 
 // StateRepository defines methods for managing quiz state
@@ -133,9 +246,11 @@ type StateRepository interface {
 	// Quiz Events
 	StoreEvent(ctx context.Context, event *model.QuizEvent) error
 	GetMissedEvents(ctx context.Context, quizID uuid.UUID, lastSequence int64, limit int) ([]*model.QuizEvent, error)
+	GetEventsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.QuizEvent, error)
 
 	// Participant Connections
 	UpdateParticipantConnection(ctx context.Context, conn *model.ParticipantConnection) error
+	GetParticipantConnection(ctx context.Context, participantID, quizID uuid.UUID) (*model.ParticipantConnection, error)
 	GetActiveParticipantConnections(ctx context.Context, quizID uuid.UUID, cutoffTime time.Time) ([]*model.ParticipantConnection, error)
 
 	// Instance Management
@@ -10,6 +10,9 @@ import (
 	"github.com/google/uuid"
 )
 
+// ErrParticipantConnectionNotFound is returned when a participant has never connected to a quiz
+var ErrParticipantConnectionNotFound = errors.New("participant connection not found")
+
 // stateRepositoryImpl implements the StateRepository interface
 type stateRepositoryImpl struct {
 	db *sql.DB
@@ -91,15 +94,60 @@ func (r *stateRepositoryImpl) GetMissedEvents(
 	return events, nil
 }
 
+// GetEventsByQuizID retrieves the full event log of a quiz ordered by sequence number
+func (r *stateRepositoryImpl) GetEventsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.QuizEvent, error) {
+	query := `
+		SELECT id, quiz_id, event_type, payload, sequence_number, created_at
+		FROM quiz_events
+		WHERE quiz_id = $1
+		ORDER BY sequence_number ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, quizID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*model.QuizEvent
+	for rows.Next() {
+		event := &model.QuizEvent{}
+		err := rows.Scan(
+			&event.ID,
+			&event.QuizID,
+			&event.EventType,
+			&event.Payload,
+			&event.SequenceNumber,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
 // UpdateParticipantConnection updates or creates a participant connection
 func (r *stateRepositoryImpl) UpdateParticipantConnection(ctx context.Context, conn *model.ParticipantConnection) error {
 	query := `
-		INSERT INTO participant_connections (participant_id, quiz_id, is_connected, last_seen, instance_id)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO participant_connections (participant_id, quiz_id, is_connected, last_seen, instance_id, disconnect_reason)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		ON CONFLICT (participant_id, quiz_id) DO UPDATE
-		SET is_connected = $3, last_seen = $4, instance_id = $5
+		SET is_connected = $3, last_seen = $4, instance_id = $5, disconnect_reason = $6
 	`
 
+	// Only disconnected records carry a reason
+	disconnectReason := sql.NullString{
+		String: string(conn.DisconnectReason),
+		Valid:  !conn.IsConnected && conn.DisconnectReason != "",
+	}
+
 	_, err := r.db.ExecContext(
 		ctx,
 		query,
@@ -108,12 +156,43 @@ func (r *stateRepositoryImpl) UpdateParticipantConnection(ctx context.Context, c
 		conn.IsConnected,
 		conn.LastSeen,
 		conn.InstanceID,
+		disconnectReason,
 	)
 
 	return err
 }
 
-// GetActiveParticipantConnections retrieves all active participant connections for a quiz
+// GetParticipantConnection retrieves the connection record of a participant in a quiz
+func (r *stateRepositoryImpl) GetParticipantConnection(ctx context.Context, participantID, quizID uuid.UUID) (*model.ParticipantConnection, error) {
+	query := `
+		SELECT participant_id, quiz_id, is_connected, last_seen, instance_id, disconnect_reason
+		FROM participant_connections
+		WHERE participant_id = $1 AND quiz_id = $2
+	`
+
+	conn := &model.ParticipantConnection{}
+	var disconnectReason sql.NullString
+	err := r.db.QueryRowContext(ctx, query, participantID, quizID).Scan(
+		&conn.ParticipantID,
+		&conn.QuizID,
+		&conn.IsConnected,
+		&conn.LastSeen,
+		&conn.InstanceID,
+		&disconnectReason,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrParticipantConnectionNotFound
+		}
+		return nil, err
+	}
+	conn.DisconnectReason = model.DisconnectReason(disconnectReason.String)
+
+	return conn, nil
+}
+
+// GetActiveParticipantConnections retrieves all active participant connections for a quiz.
+// A participant has a single connection record, so they are counted once however many instances they reached.
 func (r *stateRepositoryImpl) GetActiveParticipantConnections(
 	ctx context.Context,
 	quizID uuid.UUID,
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+// PostgresTeamRepository implements TeamRepository interface for PostgreSQL
+type PostgresTeamRepository struct {
+	db *DB
+}
+
+// NewPostgresTeamRepository creates a new PostgreSQL team repository
+func NewPostgresTeamRepository(db *DB) *PostgresTeamRepository {
+	return &PostgresTeamRepository{db: db}
+}
+
+// CreateTeam creates a new team
+func (r *PostgresTeamRepository) CreateTeam(ctx context.Context, team *model.Team) error {
+	query := `
+		INSERT INTO teams (id, quiz_id, name, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		team.ID,
+		team.QuizID,
+		team.Name,
+		team.CreatedAt,
+	)
+	return err
+}
+
+// GetTeamByID retrieves a team by its ID
+func (r *PostgresTeamRepository) GetTeamByID(ctx context.Context, id uuid.UUID) (*model.Team, error) {
+	query := `
+		SELECT id, quiz_id, name, created_at
+		FROM teams
+		WHERE id = $1
+	`
+
+	var team model.Team
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&team.ID,
+		&team.QuizID,
+		&team.Name,
+		&team.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("team not found")
+		}
+		return nil, err
+	}
+
+	return &team, nil
+}
+
+// GetTeamsByQuizID retrieves all teams for a quiz
+func (r *PostgresTeamRepository) GetTeamsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.Team, error) {
+	query := `
+		SELECT id, quiz_id, name, created_at
+		FROM teams
+		WHERE quiz_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, quizID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teams []*model.Team
+	for rows.Next() {
+		var team model.Team
+		if err := rows.Scan(
+			&team.ID,
+			&team.QuizID,
+			&team.Name,
+			&team.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		teams = append(teams, &team)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
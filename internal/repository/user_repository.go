@@ -7,8 +7,15 @@ import (
 
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// ErrEmailTaken is returned when a user is created with an email that is already registered
+var ErrEmailTaken = errors.New("email already in use")
+
+// uniqueViolation is the PostgreSQL error code for a unique constraint violation
+const uniqueViolation = "23505"
+
 // PostgresUserRepository implements UserRepository interface for PostgreSQL
 type PostgresUserRepository struct {
 	db *DB
@@ -22,8 +29,8 @@ func NewPostgresUserRepository(db *DB) *PostgresUserRepository {
 // CreateUser creates a new user
 func (r *PostgresUserRepository) CreateUser(ctx context.Context, user *model.User) error {
 	query := `
-		INSERT INTO users (id, name, email, password_hash, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users (id, name, email, password_hash, is_guest, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 	_, err := r.db.ExecContext(
 		ctx,
@@ -32,15 +39,22 @@ func (r *PostgresUserRepository) CreateUser(ctx context.Context, user *model.Use
 		user.Name,
 		user.Email,
 		user.PasswordHash,
+		user.IsGuest,
 		user.CreatedAt,
 	)
+
+	// The unique email index settles concurrent registrations of the same address
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return ErrEmailTaken
+	}
 	return err
 }
 
 // GetUserByID retrieves a user by their ID
 func (r *PostgresUserRepository) GetUserByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
 	query := `
-		SELECT id, name, email, password_hash, created_at
+		SELECT id, name, email, password_hash, is_guest, created_at
 		FROM users
 		WHERE id = $1
 	`
@@ -51,6 +65,7 @@ func (r *PostgresUserRepository) GetUserByID(ctx context.Context, id uuid.UUID)
 		&user.Name,
 		&user.Email,
 		&user.PasswordHash,
+		&user.IsGuest,
 		&user.CreatedAt,
 	)
 
@@ -64,12 +79,12 @@ func (r *PostgresUserRepository) GetUserByID(ctx context.Context, id uuid.UUID)
 	return &user, nil
 }
 
-// GetUserByEmail retrieves a user by their email
+// GetUserByEmail retrieves a user by their email, ignoring case
 func (r *PostgresUserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	query := `
-		SELECT id, name, email, password_hash, created_at
+		SELECT id, name, email, password_hash, is_guest, created_at
 		FROM users
-		WHERE email = $1
+		WHERE LOWER(email) = LOWER($1)
 	`
 
 	var user model.User
@@ -78,6 +93,7 @@ func (r *PostgresUserRepository) GetUserByEmail(ctx context.Context, email strin
 		&user.Name,
 		&user.Email,
 		&user.PasswordHash,
+		&user.IsGuest,
 		&user.CreatedAt,
 	)
 
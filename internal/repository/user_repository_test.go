@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+// seedTestUser stores a registered user with a new email, deleted when the test ends
+func seedTestUser(t *testing.T, db *DB, email string) *model.User {
+	t.Helper()
+
+	user, err := model.NewUser("Test user", email, "secret")
+	if err != nil {
+		t.Fatalf("new user: %v", err)
+	}
+	if err := NewPostgresUserRepository(db).CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := db.ExecContext(context.Background(), `DELETE FROM users WHERE id = $1`, user.ID); err != nil {
+			t.Errorf("delete seeded user: %v", err)
+		}
+	})
+	return user
+}
+
+func TestCreateUserRejectsADuplicateEmail(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewPostgresUserRepository(db)
+	email := uuid.New().String() + "@example.com"
+	registered := seedTestUser(t, db, email)
+
+	stored, err := repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		t.Fatalf("GetUserByEmail: %v", err)
+	}
+	if stored.ID != registered.ID || !stored.ComparePassword("secret") {
+		t.Error("stored user does not match the registered one")
+	}
+
+	// The unique index ignores case
+	duplicate, err := model.NewUser("Duplicate", strings.ToUpper(email), "other")
+	if err != nil {
+		t.Fatalf("new user: %v", err)
+	}
+	if err := repo.CreateUser(ctx, duplicate); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("CreateUser with a taken email returned %v, want ErrEmailTaken", err)
+	}
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+// PostgresWebhookRepository implements WebhookRepository interface for PostgreSQL
+type PostgresWebhookRepository struct {
+	db *DB
+}
+
+// NewPostgresWebhookRepository creates a new PostgreSQL webhook repository
+func NewPostgresWebhookRepository(db *DB) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{db: db}
+}
+
+// SaveQuizWebhook creates a quiz's webhook or replaces the existing one
+func (r *PostgresWebhookRepository) SaveQuizWebhook(ctx context.Context, webhook *model.QuizWebhook) error {
+	query := `
+		INSERT INTO quiz_webhooks (quiz_id, url, secret, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (quiz_id) DO UPDATE
+		SET url = EXCLUDED.url, secret = EXCLUDED.secret, updated_at = EXCLUDED.updated_at
+		RETURNING created_at
+	`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		webhook.QuizID,
+		webhook.URL,
+		webhook.Secret,
+		webhook.CreatedAt,
+		webhook.UpdatedAt,
+	).Scan(&webhook.CreatedAt)
+}
+
+// GetQuizWebhook retrieves the webhook registered for a quiz
+func (r *PostgresWebhookRepository) GetQuizWebhook(ctx context.Context, quizID uuid.UUID) (*model.QuizWebhook, error) {
+	query := `
+		SELECT quiz_id, url, secret, created_at, updated_at
+		FROM quiz_webhooks
+		WHERE quiz_id = $1
+	`
+
+	var webhook model.QuizWebhook
+	err := r.db.QueryRowContext(ctx, query, quizID).Scan(
+		&webhook.QuizID,
+		&webhook.URL,
+		&webhook.Secret,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("webhook not found")
+		}
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// DeleteQuizWebhook removes the webhook registered for a quiz
+func (r *PostgresWebhookRepository) DeleteQuizWebhook(ctx context.Context, quizID uuid.UUID) error {
+	query := `DELETE FROM quiz_webhooks WHERE quiz_id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, quizID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("webhook not found")
+	}
+
+	return nil
+}
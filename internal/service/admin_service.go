@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/repository"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// adminServiceImpl implements AdminService interface
+type adminServiceImpl struct {
+	questionOptionRepo repository.QuestionOptionRepository
+	quizRepo           repository.QuizRepository
+	stateService       StateService
+	config             config.AdminConfig
+	logger             *slog.Logger
+}
+
+// NewAdminService creates a new admin service
+func NewAdminService(
+	questionOptionRepo repository.QuestionOptionRepository,
+	quizRepo repository.QuizRepository,
+	stateService StateService,
+	cfg config.AdminConfig,
+	log *slog.Logger,
+) AdminService {
+	return &adminServiceImpl{
+		questionOptionRepo: questionOptionRepo,
+		quizRepo:           quizRepo,
+		stateService:       stateService,
+		config:             cfg,
+		logger:             logger.OrDefault(log),
+	}
+}
+
+// FindOrphanedOptions lists question options whose question no longer exists
+func (s *adminServiceImpl) FindOrphanedOptions(ctx context.Context) ([]*model.QuestionOption, error) {
+	options, err := s.questionOptionRepo.GetOrphanedQuestionOptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if options == nil {
+		options = []*model.QuestionOption{}
+	}
+
+	return options, nil
+}
+
+// CleanupOrphanedOptions deletes question options whose question no longer exists
+func (s *adminServiceImpl) CleanupOrphanedOptions(ctx context.Context) (int, error) {
+	deleted, err := s.questionOptionRepo.DeleteOrphanedQuestionOptions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if deleted > 0 {
+		s.logger.Warn("Deleted orphaned question options", "count", deleted)
+	}
+
+	return deleted, nil
+}
+
+// FindStaleQuizzes lists the active quizzes without activity for longer than threshold
+func (s *adminServiceImpl) FindStaleQuizzes(ctx context.Context, threshold time.Duration) ([]*model.QuizActivity, error) {
+	if threshold <= 0 {
+		threshold = s.config.StaleQuizThreshold
+	}
+
+	stale, err := s.quizRepo.GetStaleActiveQuizzes(ctx, time.Now().Add(-threshold))
+	if err != nil {
+		return nil, err
+	}
+
+	if stale == nil {
+		stale = []*model.QuizActivity{}
+	}
+
+	return stale, nil
+}
+
+// TerminateQuiz ends an active quiz on behalf of an operator
+func (s *adminServiceImpl) TerminateQuiz(ctx context.Context, quizID uuid.UUID) error {
+	if err := s.stateService.EndQuiz(ctx, quizID); err != nil {
+		return err
+	}
+
+	s.logger.Warn("Quiz terminated by an operator", "quizID", quizID)
+	return nil
+}
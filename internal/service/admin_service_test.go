@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
+)
+
+// adminService creates an admin service over the environment's store
+func (e *testEnv) adminService(cfg config.AdminConfig) *adminServiceImpl {
+	return NewAdminService(e.optionRepo, e.quizRepo, e.state, cfg, nil).(*adminServiceImpl)
+}
+
+func TestCleanupOrphanedOptionsRemovesOnlyOrphans(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	admin := env.adminService(config.AdminConfig{})
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	kept := env.seedQuestion(t, quiz, 1)
+	lost := env.seedQuestion(t, quiz, 2, "A", "B", "C")
+
+	// Drop a question without its options, as a partial failure would
+	env.store.mu.Lock()
+	delete(env.store.questions, lost.ID)
+	env.store.mu.Unlock()
+
+	orphans, err := admin.FindOrphanedOptions(ctx)
+	if err != nil {
+		t.Fatalf("FindOrphanedOptions: %v", err)
+	}
+	if len(orphans) != 3 {
+		t.Fatalf("found %d orphans, want the 3 options of the dropped question", len(orphans))
+	}
+	for _, option := range orphans {
+		if option.QuestionID != lost.ID {
+			t.Error("an option of an existing question was reported as orphaned")
+		}
+	}
+
+	deleted, err := admin.CleanupOrphanedOptions(ctx)
+	if err != nil {
+		t.Fatalf("CleanupOrphanedOptions: %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("deleted %d options, want 3", deleted)
+	}
+	if orphans, _ := admin.FindOrphanedOptions(ctx); len(orphans) != 0 {
+		t.Errorf("%d orphans left after cleanup", len(orphans))
+	}
+	if got := len(env.storedOptions(t, kept)); got != 2 {
+		t.Errorf("kept question has %d options after cleanup, want 2", got)
+	}
+}
+
+// idleQuiz seeds an active quiz that was started and last updated the given time ago
+func (e *testEnv) idleQuiz(t *testing.T, idle time.Duration) *model.Quiz {
+	t.Helper()
+
+	quiz := e.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	e.setSession(t, quiz.ID, func(session *model.QuizSession) {
+		startedAt := time.Now().Add(-idle)
+		session.StartedAt = &startedAt
+	})
+	e.store.mu.Lock()
+	e.store.quizzes[quiz.ID].UpdatedAt = time.Now().Add(-idle)
+	e.store.mu.Unlock()
+	return quiz
+}
+
+func TestFindStaleQuizzesListsOnlyIdleActiveQuizzes(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	admin := env.adminService(config.AdminConfig{StaleQuizThreshold: 30 * time.Minute})
+	stalest := env.idleQuiz(t, 3*time.Hour)
+	stale := env.idleQuiz(t, 2*time.Hour)
+	env.idleQuiz(t, 10*time.Minute)
+	// Started long ago, but an event was recorded since
+	busy := env.idleQuiz(t, 3*time.Hour)
+	event := model.NewQuizEvent(busy.ID, string(websocket.EventQuestionStart), []byte(`{}`), 1)
+	event.CreatedAt = time.Now().Add(-5 * time.Minute)
+	if err := env.stateRepo.StoreEvent(ctx, event); err != nil {
+		t.Fatalf("StoreEvent: %v", err)
+	}
+	finished := env.idleQuiz(t, 3*time.Hour)
+	env.store.mu.Lock()
+	env.store.quizzes[finished.ID].Status = model.QuizStatusCompleted
+	env.store.mu.Unlock()
+
+	listed, err := admin.FindStaleQuizzes(ctx, time.Hour)
+	if err != nil {
+		t.Fatalf("FindStaleQuizzes: %v", err)
+	}
+	if len(listed) != 2 || listed[0].Quiz.ID != stalest.ID || listed[1].Quiz.ID != stale.ID {
+		t.Fatalf("listed %d stale quizzes, want the two idle for over an hour, least recently active first", len(listed))
+	}
+	if idle := time.Since(listed[1].LastActivityAt); idle < 2*time.Hour || idle > 2*time.Hour+time.Minute {
+		t.Errorf("stale quiz was last active %v ago, want 2h", idle)
+	}
+
+	// Without a threshold the configured one applies
+	listed, err = admin.FindStaleQuizzes(ctx, 0)
+	if err != nil {
+		t.Fatalf("FindStaleQuizzes: %v", err)
+	}
+	if len(listed) != 2 {
+		t.Errorf("listed %d quizzes idle for over the configured 30 minutes, want 2", len(listed))
+	}
+}
+
+func TestTerminateQuizEndsAStaleQuiz(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	admin := env.adminService(config.AdminConfig{})
+	stale := env.idleQuiz(t, 2*time.Hour)
+
+	if err := admin.TerminateQuiz(ctx, stale.ID); err != nil {
+		t.Fatalf("TerminateQuiz: %v", err)
+	}
+	if got := env.quizStatus(t, stale.ID); got != model.QuizStatusCompleted {
+		t.Errorf("terminated quiz is %s, want COMPLETED", got)
+	}
+	if got := len(env.hub.events(websocket.EventQuizEnd)); got != 1 {
+		t.Errorf("terminating sent %d QUIZ_END events, want 1", got)
+	}
+	listed, err := admin.FindStaleQuizzes(ctx, time.Hour)
+	if err != nil {
+		t.Fatalf("FindStaleQuizzes: %v", err)
+	}
+	if len(listed) != 0 {
+		t.Errorf("terminated quiz is still listed as stale")
+	}
+
+	waiting := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	if err := admin.TerminateQuiz(ctx, waiting.ID); !errors.Is(err, ErrQuizNotActive) {
+		t.Errorf("TerminateQuiz of a quiz that never started = %v, want %v", err, ErrQuizNotActive)
+	}
+}
@@ -3,14 +3,30 @@ package service
 import (
 	"context"
 	"errors"
+	"log/slog"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/repository"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/logger"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/metrics"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
 	"github.com/google/uuid"
 )
 
+// Errors
+var (
+	ErrStatsNotAvailable = errors.New("answer statistics are only available after the question has ended")
+	ErrTooManyOptions    = errors.New("more options selected than the question has")
+	ErrNotConnected      = errors.New("reconnect to the quiz before answering")
+	ErrAnswerTooLate     = errors.New("the question has ended and its results are being shown")
+)
+
+// answerLockReportInterval is the minimum time between answer-lock reports sent to creators for a question
+const answerLockReportInterval = 500 * time.Millisecond
+
 // answerServiceImpl implements AnswerService interface
 type answerServiceImpl struct {
 	answerRepo         repository.AnswerRepository
@@ -19,7 +35,14 @@ type answerServiceImpl struct {
 	quizRepo           repository.QuizRepository
 	leaderboardService LeaderboardService
 	questionOptionRepo repository.QuestionOptionRepository
-	wsHub              *websocket.RedisHub
+	stateRepo          repository.StateRepository
+	wsHub              EventHub
+	stateService       StateService
+	logger             *slog.Logger
+
+	// pendingLockReports holds the questions with an answer-lock report scheduled
+	pendingLockReports map[uuid.UUID]struct{}
+	lockReportsMu      sync.Mutex
 }
 
 // NewAnswerService creates a new answer service
@@ -30,7 +53,10 @@ func NewAnswerService(
 	quizRepo repository.QuizRepository,
 	leaderboardService LeaderboardService,
 	questionOptionRepo repository.QuestionOptionRepository,
-	wsHub *websocket.RedisHub,
+	stateRepo repository.StateRepository,
+	wsHub EventHub,
+	stateService StateService,
+	log *slog.Logger,
 ) AnswerService {
 	return &answerServiceImpl{
 		answerRepo:         answerRepo,
@@ -39,12 +65,29 @@ func NewAnswerService(
 		quizRepo:           quizRepo,
 		leaderboardService: leaderboardService,
 		questionOptionRepo: questionOptionRepo,
+		stateRepo:          stateRepo,
 		wsHub:              wsHub,
+		stateService:       stateService,
+		logger:             logger.OrDefault(log),
+		pendingLockReports: make(map[uuid.UUID]struct{}),
 	}
 }
 
-// SubmitAnswer records a participant's answer to a question
-func (s *answerServiceImpl) SubmitAnswer(ctx context.Context, participantID uuid.UUID, questionID uuid.UUID, selectedOptionIDs []string) (*model.Answer, error) {
+// Submit validates, records and scores a participant's answer to a question and reports it to creators.
+// A repeated submission with the same non-empty client token returns the original answer.
+func (s *answerServiceImpl) Submit(ctx context.Context, participantID uuid.UUID, questionID uuid.UUID, selectedOptionIDs []string, clientToken string) (*model.Answer, error) {
+	startedAt := time.Now()
+	defer func() {
+		metrics.AnswerSubmissionDuration.Observe(time.Since(startedAt).Seconds())
+	}()
+
+	// A retry of an answer that was already recorded is not an error
+	if clientToken != "" {
+		if answer, err := s.answerRepo.GetAnswerByClientToken(ctx, participantID, questionID, clientToken); err == nil {
+			return answer, nil
+		}
+	}
+
 	// Verify participant exists
 	_, err := s.participantRepo.GetParticipantByID(ctx, participantID)
 	if err != nil {
@@ -57,22 +100,47 @@ func (s *answerServiceImpl) SubmitAnswer(ctx context.Context, participantID uuid
 		return nil, errors.New("question not found")
 	}
 
+	// A skipped or voided question no longer takes answers
+	if question.VoidedPoints != nil {
+		return nil, ErrQuestionVoided
+	}
+
 	// Get quiz session
 	session, err := s.quizRepo.GetQuizSession(ctx, question.QuizID)
 	if err != nil {
 		return nil, err
 	}
 
+	quiz, err := s.quizRepo.GetQuizByID(ctx, question.QuizID)
+	if err != nil {
+		return nil, err
+	}
+
+	staleConn, err := s.checkConnectedPolicy(ctx, quiz, participantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the session's current question takes answers, and only while it runs or its results are shown
+	if session.CurrentQuestionID == nil || *session.CurrentQuestionID != questionID {
+		return nil, ErrQuestionNotActive
+	}
+	if session.CurrentPhase != model.QuizPhaseQuestionActive && session.CurrentPhase != model.QuizPhaseShowingResults {
+		return nil, ErrQuestionNotActive
+	}
+
+	// An answer that was in flight when the question ended is handled by the quiz's late answer policy
+	lateAnswer := session.CurrentPhase == model.QuizPhaseShowingResults
+	if lateAnswer && quiz.Settings.LateAnswerPolicy != model.LateAnswerPolicyAcceptNoBonus {
+		return nil, ErrAnswerTooLate
+	}
+
 	// get question options
 	options, err := s.questionOptionRepo.GetQuestionOptionsByQuestionID(ctx, questionID)
 	if err != nil {
 		return nil, err
 	}
-
-	// Check if this question is active
-	// if session.CurrentQuestionID == nil || *session.CurrentQuestionID != questionID {
-	// 	return nil, errors.New("question is not active")
-	// }
+	question.Options = options
 
 	// Check if participant has already answered this question
 	existingAnswer, err := s.answerRepo.GetAnswerByParticipantAndQuestion(ctx, participantID, questionID)
@@ -91,6 +159,11 @@ func (s *answerServiceImpl) SubmitAnswer(ctx context.Context, participantID uuid
 		return nil, errors.New("no option selected")
 	}
 
+	// A selection can never be larger than the question's option list
+	if len(selectedOptionIDs) > len(options) {
+		return nil, ErrTooManyOptions
+	}
+
 	// For single choice questions, ensure only one option is selected
 	if question.QuestionType == model.QuestionTypeSingleChoice && len(selectedOptionIDs) > 1 {
 		return nil, errors.New("only one option can be selected for single choice questions")
@@ -116,31 +189,170 @@ func (s *answerServiceImpl) SubmitAnswer(ctx context.Context, participantID uuid
 	if err != nil {
 		return nil, err
 	}
+	answer.ClientToken = clientToken
+
+	// Award a time-based bonus for answering correctly in less than half the time limit.
+	// It is stored with the answer so each question's contribution to the score can be reversed.
+	// Late answers accepted while results are shown never earn it, nor does any answer when the quiz disables it.
+	if isCorrect && !lateAnswer && !quiz.Settings.DisableTimeBonus && timeTaken < float64(question.TimeLimit)/2 {
+		answer.Score += model.TimeBonusPoints
+		metrics.TimeBonusesAwarded.Inc()
+	}
 
 	if err := s.answerRepo.CreateAnswer(ctx, answer); err != nil {
+		// A concurrent retry with the same token may have been stored first
+		if clientToken != "" {
+			if original, lookupErr := s.answerRepo.GetAnswerByClientToken(ctx, participantID, questionID, clientToken); lookupErr == nil {
+				return original, nil
+			}
+		}
 		return nil, err
 	}
 
-	// Update participant's score
+	result := metrics.ResultIncorrect
 	if isCorrect {
-		// Calculate a time-based bonus
-		timeBonus := 0
-		if timeTaken < float64(question.TimeLimit)/2 {
-			// If answered in less than half the time limit, award a bonus
-			timeBonus = 20
+		result = metrics.ResultCorrect
+	}
+	metrics.AnswersSubmitted.WithLabelValues(result).Inc()
+	metrics.AnswerPoints.Observe(float64(answer.Score))
+
+	// Report the answer to host dashboards on every instance, whichever channel it arrived on
+	clientAnswer := websocket.NewEvent(websocket.EventClientAnswer, map[string]interface{}{
+		"participantId":   participantID.String(),
+		"questionId":      questionID.String(),
+		"selectedOptions": selectedOptionIDs,
+		"timeTaken":       timeTaken,
+		"clientToken":     clientToken,
+	})
+	if err := s.wsHub.PublishToCreators(question.QuizID, clientAnswer); err != nil {
+		s.logger.Error("Error publishing client answer; delivering it to this instance only", "quizId", question.QuizID, "questionId", questionID, "error", err)
+		s.wsHub.BroadcastToCreators(question.QuizID, clientAnswer)
+	}
+	// A participant answering after their connection dropped is back, so presence shows them again
+	if staleConn != nil {
+		if err := s.stateService.UpdateParticipantConnection(ctx, participantID, question.QuizID, true, staleConn.InstanceID, "", time.Now()); err != nil {
+			s.logger.Error("Failed to mark participant reconnected", "quizId", question.QuizID, "participantId", participantID, "error", err)
 		}
-		totalScore := answer.Score + timeBonus
+	}
+
+	s.scheduleAnswerLockReport(question.QuizID, questionID)
+	s.reportAnswerCount(ctx, question.QuizID, questionID)
 
-		if err := s.leaderboardService.UpdateParticipantScore(ctx, participantID, totalScore); err != nil {
+	// Update participant's score from their recorded answers
+	if answer.Score > 0 {
+		_, err := s.leaderboardService.RecomputeScore(ctx, participantID)
+		// The score may have been written even if reading it back failed
+		s.stateService.InvalidateQuizState(question.QuizID)
+		if err != nil {
 			// Log the error but continue (non-critical failure)
-			// In a real app, we would use a proper logger
-			// logger.Error("Failed to update participant score", "error", err)
+			s.logger.Error("Failed to update participant score", "quizId", question.QuizID, "participantId", participantID, "error", err)
+		} else if err := s.leaderboardService.BroadcastLeaderboard(ctx, question.QuizID); err != nil {
+			s.logger.Error("Failed to broadcast leaderboard", "quizId", question.QuizID, "error", err)
 		}
 	}
 
 	return answer, nil
 }
 
+// checkConnectedPolicy rejects answers from a participant whose WebSocket connection dropped when the
+// quiz is set to do so. Otherwise it returns the dropped connection, so the participant can be marked
+// reconnected once the answer is recorded. Participants that never opened a connection answer over HTTP
+// only and are accepted as they are.
+func (s *answerServiceImpl) checkConnectedPolicy(ctx context.Context, quiz *model.Quiz, participantID uuid.UUID) (*model.ParticipantConnection, error) {
+	conn, err := s.stateRepo.GetParticipantConnection(ctx, participantID, quiz.ID)
+	if err != nil || conn == nil || conn.IsConnected {
+		return nil, nil
+	}
+	if quiz.Settings.RejectDisconnectedAnswers {
+		return nil, ErrNotConnected
+	}
+
+	return conn, nil
+}
+
+// scheduleAnswerLockReport reports who has answered a question to creators, throttled to one
+// report per answerLockReportInterval per question so bursts of answers produce a single update
+func (s *answerServiceImpl) scheduleAnswerLockReport(quizID uuid.UUID, questionID uuid.UUID) {
+	s.lockReportsMu.Lock()
+	defer s.lockReportsMu.Unlock()
+
+	if _, pending := s.pendingLockReports[questionID]; pending {
+		return
+	}
+	s.pendingLockReports[questionID] = struct{}{}
+
+	time.AfterFunc(answerLockReportInterval, func() {
+		s.lockReportsMu.Lock()
+		delete(s.pendingLockReports, questionID)
+		s.lockReportsMu.Unlock()
+
+		s.reportAnswerLocks(context.Background(), quizID, questionID)
+	})
+}
+
+// reportAnswerLocks sends creators the participants who have answered a question, in answer order.
+// The list is read from the database so it covers answers handled by every instance; anonymous
+// quizzes only get the count.
+func (s *answerServiceImpl) reportAnswerLocks(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) {
+	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		s.logger.Error("Failed to load quiz for answer-lock report", "quizId", quizID, "error", err)
+		return
+	}
+
+	answers, err := s.answerRepo.GetAnswersByQuestionID(ctx, questionID)
+	if err != nil {
+		s.logger.Error("Failed to load answers for answer-lock report", "quizId", quizID, "questionId", questionID, "error", err)
+		return
+	}
+
+	payload := map[string]interface{}{
+		"questionId":    questionID.String(),
+		"answeredCount": len(answers),
+	}
+
+	if !quiz.Settings.Anonymous {
+		sort.Slice(answers, func(i, j int) bool {
+			return answers[i].AnsweredAt.Before(answers[j].AnsweredAt)
+		})
+		participantIDs := make([]string, len(answers))
+		for i, answer := range answers {
+			participantIDs[i] = answer.ParticipantID.String()
+		}
+		payload["participantIds"] = participantIDs
+	}
+
+	if err := s.wsHub.PublishToCreators(quizID, websocket.NewEvent(websocket.EventAnswerLockUpdate, payload)); err != nil {
+		s.logger.Error("Failed to publish answer-lock report", "quizId", quizID, "questionId", questionID, "error", err)
+	}
+}
+
+// reportAnswerCount sends creators how many participants have answered a question out of those connected.
+// Both counts are read from the database after the answer is stored, so concurrent answers on any
+// instance are each included in the count published after them.
+func (s *answerServiceImpl) reportAnswerCount(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) {
+	answeredCount, err := s.answerRepo.CountAnswersByQuestionID(ctx, questionID)
+	if err != nil {
+		s.logger.Error("Failed to count answers for answer count update", "quizId", quizID, "questionId", questionID, "error", err)
+		return
+	}
+
+	// Consider connections seen within the last 30 seconds, as the quiz state does
+	connections, err := s.stateRepo.GetActiveParticipantConnections(ctx, quizID, time.Now().Add(-30*time.Second))
+	if err != nil {
+		s.logger.Error("Failed to load connections for answer count update", "quizId", quizID, "questionId", questionID, "error", err)
+		return
+	}
+
+	if err := s.wsHub.PublishToCreators(quizID, websocket.NewEvent(websocket.EventAnswerCountUpdate, map[string]interface{}{
+		"questionId":     questionID.String(),
+		"answeredCount":  answeredCount,
+		"connectedCount": len(connections),
+	})); err != nil {
+		s.logger.Error("Failed to publish answer count update", "quizId", quizID, "questionId", questionID, "error", err)
+	}
+}
+
 // GetAnswerStats retrieves statistics for answers to a question
 func (s *answerServiceImpl) GetAnswerStats(ctx context.Context, questionID uuid.UUID) (map[string]int, error) {
 	// Get the question to retrieve options
@@ -184,6 +396,41 @@ func (s *answerServiceImpl) GetAnswerStats(ctx context.Context, questionID uuid.
 	return stats, nil
 }
 
+// GetAnswerStatsForUser retrieves answer statistics for a question on behalf of userID.
+// The quiz creator may see them at any time; everyone else only once the question is no longer active.
+func (s *answerServiceImpl) GetAnswerStatsForUser(ctx context.Context, questionID uuid.UUID, userID uuid.UUID) (map[string]int, error) {
+	question, err := s.questionRepo.GetQuestionByID(ctx, questionID)
+	if err != nil {
+		return nil, errors.New("question not found")
+	}
+
+	quiz, err := s.quizRepo.GetQuizByID(ctx, question.QuizID)
+	if err != nil {
+		return nil, ErrQuizNotFound
+	}
+
+	if quiz.CreatorID != userID {
+		// Questions of a quiz that hasn't started have not been shown yet
+		if quiz.Status == model.QuizStatusWaiting {
+			return nil, ErrStatsNotAvailable
+		}
+
+		// Hide the distribution while participants can still answer the question
+		if quiz.Status == model.QuizStatusActive {
+			session, err := s.quizRepo.GetQuizSession(ctx, quiz.ID)
+			if err != nil {
+				return nil, err
+			}
+			if session.CurrentQuestionID != nil && *session.CurrentQuestionID == questionID &&
+				session.CurrentQuestionEndedAt == nil {
+				return nil, ErrStatsNotAvailable
+			}
+		}
+	}
+
+	return s.GetAnswerStats(ctx, questionID)
+}
+
 // GetParticipantAnswer retrieves a participant's answer to a specific question
 func (s *answerServiceImpl) GetParticipantAnswer(ctx context.Context, participantID uuid.UUID, questionID uuid.UUID) (*model.Answer, error) {
 	answer, err := s.answerRepo.GetAnswerByParticipantAndQuestion(ctx, participantID, questionID)
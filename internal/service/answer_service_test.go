@@ -0,0 +1,693 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
+	"github.com/google/uuid"
+	gorilla "github.com/gorilla/websocket"
+)
+
+func TestSubmitReportsClientAnswerToCreatorsWithoutRedis(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1)
+	participant := env.seedParticipant(t, quiz, "Alice")
+	env.runQuestion(t, question, time.Second)
+
+	if _, err := env.answers.Submit(context.Background(), participant.ID, question.ID, []string{correctOption(question)}, "token-1"); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	events := env.hub.events(websocket.EventClientAnswer)
+	if len(events) != 1 {
+		t.Fatalf("got %d CLIENT_ANSWER events, want 1", len(events))
+	}
+	event := events[0]
+	if event.Audience != hubAudienceCreators || event.QuizID != quiz.ID {
+		t.Errorf("CLIENT_ANSWER sent to %s of quiz %s, want creators of quiz %s", event.Audience, event.QuizID, quiz.ID)
+	}
+	if !event.Published {
+		t.Error("CLIENT_ANSWER was broadcast to this instance only, want it published to hosts on every instance")
+	}
+	payload := event.payload()
+	if payload["participantId"] != participant.ID.String() || payload["questionId"] != question.ID.String() {
+		t.Errorf("CLIENT_ANSWER payload = %v", payload)
+	}
+	if payload["clientToken"] != "token-1" {
+		t.Errorf("clientToken = %v, want token-1", payload["clientToken"])
+	}
+}
+
+func TestSubmitReportsClientAnswerLocallyWhenPublishingFails(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1)
+	participant := env.seedParticipant(t, quiz, "Alice")
+	env.runQuestion(t, question, time.Second)
+	env.hub.publishErr = errors.New("redis: connection refused")
+
+	if _, err := env.answers.Submit(context.Background(), participant.ID, question.ID, []string{correctOption(question)}, "token-1"); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	events := env.hub.events(websocket.EventClientAnswer)
+	if len(events) != 1 || events[0].Published || events[0].Audience != hubAudienceCreators {
+		t.Errorf("CLIENT_ANSWER was sent as %+v, want one broadcast to this instance's creators", events)
+	}
+}
+
+func TestConcurrentAnswersReportCountToCreatorsOnly(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1)
+	env.runQuestion(t, question, time.Second)
+
+	const participantCount = 20
+	participants := make([]*model.Participant, participantCount)
+	for i := range participants {
+		participants[i] = env.seedParticipant(t, quiz, fmt.Sprintf("Player %d", i))
+		conn := model.NewParticipantConnection(participants[i].ID, quiz.ID, "instance-1")
+		if err := env.stateRepo.UpdateParticipantConnection(context.Background(), conn); err != nil {
+			t.Fatalf("UpdateParticipantConnection: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, participantCount)
+	for _, participant := range participants {
+		wg.Add(1)
+		go func(participant *model.Participant) {
+			defer wg.Done()
+			if _, err := env.answers.Submit(context.Background(), participant.ID, question.ID, []string{wrongOption(question)}, ""); err != nil {
+				errs <- err
+			}
+		}(participant)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	events := env.hub.events(websocket.EventAnswerCountUpdate)
+	if len(events) != participantCount {
+		t.Fatalf("got %d ANSWER_COUNT_UPDATE events, want one per answer (%d)", len(events), participantCount)
+	}
+
+	seen := make(map[int]bool)
+	for _, event := range events {
+		if event.reachesParticipants() {
+			t.Fatalf("ANSWER_COUNT_UPDATE sent to %s, want creators only", event.Audience)
+		}
+		payload := event.payload()
+		if payload["connectedCount"] != participantCount {
+			t.Errorf("connectedCount = %v, want %d", payload["connectedCount"], participantCount)
+		}
+		seen[payload["answeredCount"].(int)] = true
+	}
+
+	// Every answer is stored before its count is read, so the last report includes all of them
+	if !seen[participantCount] {
+		t.Errorf("no report counted all %d answers; counts seen: %v", participantCount, seen)
+	}
+	for count := range seen {
+		if count < 1 || count > participantCount {
+			t.Errorf("answeredCount %d is out of range", count)
+		}
+	}
+}
+
+func TestAnswerLockReportListsParticipantsInAnswerOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		anonymous bool
+	}{
+		{name: "named", anonymous: false},
+		{name: "anonymous", anonymous: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{Anonymous: tt.anonymous})
+			question := env.seedQuestion(t, quiz, 1)
+			env.runQuestion(t, question, time.Second)
+
+			ctx := context.Background()
+			first := env.seedParticipant(t, quiz, "First")
+			second := env.seedParticipant(t, quiz, "Second")
+			env.seedParticipant(t, quiz, "Idle")
+
+			var want []string
+			for _, participant := range []*model.Participant{first, second} {
+				if _, err := env.answers.Submit(ctx, participant.ID, question.ID, []string{correctOption(question)}, ""); err != nil {
+					t.Fatalf("Submit: %v", err)
+				}
+				want = append(want, participant.ID.String())
+
+				env.answers.reportAnswerLocks(ctx, quiz.ID, question.ID)
+				events := env.hub.events(websocket.EventAnswerLockUpdate)
+				event := events[len(events)-1]
+				if event.reachesParticipants() {
+					t.Fatalf("ANSWER_LOCK_UPDATE sent to %s, want creators only", event.Audience)
+				}
+
+				payload := event.payload()
+				if payload["answeredCount"] != len(want) {
+					t.Errorf("answeredCount = %v, want %d", payload["answeredCount"], len(want))
+				}
+				ids, listed := payload["participantIds"].([]string)
+				if tt.anonymous {
+					if listed {
+						t.Errorf("anonymous quiz lists participants %v", ids)
+					}
+					continue
+				}
+				if fmt.Sprint(ids) != fmt.Sprint(want) {
+					t.Errorf("participantIds = %v, want %v", ids, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAnswerLockReportsAreThrottledPerQuestion(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1)
+
+	for i := 0; i < 5; i++ {
+		env.answers.scheduleAnswerLockReport(quiz.ID, question.ID)
+	}
+
+	env.answers.lockReportsMu.Lock()
+	pending := len(env.answers.pendingLockReports)
+	env.answers.lockReportsMu.Unlock()
+	if pending != 1 {
+		t.Fatalf("%d reports pending after a burst, want 1", pending)
+	}
+
+	deadline := time.Now().Add(5 * answerLockReportInterval)
+	for len(env.hub.events(websocket.EventAnswerLockUpdate)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := len(env.hub.events(websocket.EventAnswerLockUpdate)); got != 1 {
+		t.Errorf("got %d ANSWER_LOCK_UPDATE events for a burst, want 1", got)
+	}
+}
+
+func TestSubmitScoresCorrectAnswersUnderShuffle(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings model.QuizSettings
+	}{
+		{name: "stored order", settings: model.QuizSettings{}},
+		{name: "shuffled options", settings: model.QuizSettings{ShuffleOptions: true, ShuffleSeed: 42}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			quiz := env.seedQuiz(t, model.QuizStatusActive, tt.settings)
+			question := env.seedQuestion(t, quiz, 1, "Right", "Wrong", "Also wrong", "Still wrong")
+			env.runQuestion(t, question, time.Second)
+			right := env.seedParticipant(t, quiz, "Right")
+			wrong := env.seedParticipant(t, quiz, "Wrong")
+
+			ctx := context.Background()
+			answer, err := env.answers.Submit(ctx, right.ID, question.ID, []string{correctOption(question)}, "")
+			if err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+			if !answer.IsCorrect || answer.Score != model.CorrectAnswerPoints+model.TimeBonusPoints {
+				t.Errorf("correct answer recorded as correct=%t with score %d", answer.IsCorrect, answer.Score)
+			}
+			if got := env.participantScore(t, right.ID); got != answer.Score {
+				t.Errorf("participant score = %d, want %d", got, answer.Score)
+			}
+
+			answer, err = env.answers.Submit(ctx, wrong.ID, question.ID, []string{wrongOption(question)}, "")
+			if err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+			if answer.IsCorrect || answer.Score != 0 {
+				t.Errorf("wrong answer recorded as correct=%t with score %d", answer.IsCorrect, answer.Score)
+			}
+		})
+	}
+}
+
+func TestLateAnswerPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    model.LateAnswerPolicy
+		wantErr   error
+		wantScore int
+	}{
+		{name: "default rejects", policy: "", wantErr: ErrAnswerTooLate},
+		{name: "reject", policy: model.LateAnswerPolicyReject, wantErr: ErrAnswerTooLate},
+		{name: "accept without bonus", policy: model.LateAnswerPolicyAcceptNoBonus, wantScore: model.CorrectAnswerPoints},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{LateAnswerPolicy: tt.policy})
+			question := env.seedQuestion(t, quiz, 1)
+			participant := env.seedParticipant(t, quiz, "Player")
+			env.runQuestion(t, question, time.Second)
+			env.setSession(t, quiz.ID, func(session *model.QuizSession) {
+				endedAt := time.Now()
+				session.CurrentQuestionEndedAt = &endedAt
+				session.CurrentPhase = model.QuizPhaseShowingResults
+			})
+
+			answer, err := env.answers.Submit(context.Background(), participant.ID, question.ID, []string{correctOption(question)}, "")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Submit: got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+			if answer.Score != tt.wantScore {
+				t.Errorf("late answer scored %d, want %d without the time bonus", answer.Score, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestSubmitRejectsAnswersToQuestionsOtherThanTheCurrentOne(t *testing.T) {
+	tests := []struct {
+		name  string
+		phase model.QuizPhase
+	}{
+		{name: "while another question runs", phase: model.QuizPhaseQuestionActive},
+		{name: "while another question's results are shown", phase: model.QuizPhaseShowingResults},
+		{name: "between questions", phase: model.QuizPhaseBetweenQuestions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			// Late answers are accepted, so only the current question check can turn these down
+			quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{LateAnswerPolicy: model.LateAnswerPolicyAcceptNoBonus})
+			current := env.seedQuestion(t, quiz, 1)
+			other := env.seedQuestion(t, quiz, 2)
+			participant := env.seedParticipant(t, quiz, "Player")
+			env.runQuestion(t, current, time.Second)
+			env.setSession(t, quiz.ID, func(session *model.QuizSession) {
+				session.CurrentPhase = tt.phase
+			})
+
+			if _, err := env.answers.Submit(context.Background(), participant.ID, other.ID, []string{correctOption(other)}, ""); !errors.Is(err, ErrQuestionNotActive) {
+				t.Errorf("answer to another question: got %v, want ErrQuestionNotActive", err)
+			}
+			if tt.phase == model.QuizPhaseBetweenQuestions {
+				if _, err := env.answers.Submit(context.Background(), participant.ID, current.ID, []string{correctOption(current)}, ""); !errors.Is(err, ErrQuestionNotActive) {
+					t.Errorf("answer between questions: got %v, want ErrQuestionNotActive", err)
+				}
+			}
+			if got := env.participantScore(t, participant.ID); got != 0 {
+				t.Errorf("rejected answers scored %d points", got)
+			}
+		})
+	}
+}
+
+func TestDisconnectedParticipantAnswerPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		reject bool
+	}{
+		{name: "accept and reconnect", reject: false},
+		{name: "reject", reject: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{RejectDisconnectedAnswers: tt.reject})
+			question := env.seedQuestion(t, quiz, 1)
+			participant := env.seedParticipant(t, quiz, "Dropped")
+			env.runQuestion(t, question, time.Second)
+
+			connectedAt := time.Now().Add(-10 * time.Second)
+			if err := env.state.UpdateParticipantConnection(ctx, participant.ID, quiz.ID, true, "instance-1", "", connectedAt); err != nil {
+				t.Fatalf("connect: %v", err)
+			}
+			if err := env.state.UpdateParticipantConnection(ctx, participant.ID, quiz.ID, false, "instance-1", model.DisconnectReasonTimeout, connectedAt); err != nil {
+				t.Fatalf("disconnect: %v", err)
+			}
+			reconnects := len(env.hub.events(websocket.EventUserReconnected))
+
+			_, err := env.answers.Submit(ctx, participant.ID, question.ID, []string{correctOption(question)}, "")
+			conn, connErr := env.stateRepo.GetParticipantConnection(ctx, participant.ID, quiz.ID)
+			if connErr != nil {
+				t.Fatalf("GetParticipantConnection: %v", connErr)
+			}
+
+			if tt.reject {
+				if !errors.Is(err, ErrNotConnected) {
+					t.Fatalf("Submit: got %v, want ErrNotConnected", err)
+				}
+				if conn.IsConnected {
+					t.Error("rejected answer marked the participant connected")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+			if !conn.IsConnected || conn.InstanceID != "instance-1" {
+				t.Errorf("connection after the answer: connected=%t on %q, want connected on instance-1", conn.IsConnected, conn.InstanceID)
+			}
+			if got := len(env.hub.events(websocket.EventUserReconnected)) - reconnects; got != 1 {
+				t.Errorf("got %d USER_RECONNECTED events after the answer, want 1", got)
+			}
+			counts := env.hub.events(websocket.EventAnswerCountUpdate)
+			if got := counts[len(counts)-1].payload()["connectedCount"]; got != 1 {
+				t.Errorf("connectedCount = %v, want the reconnected participant counted", got)
+			}
+		})
+	}
+}
+
+func TestAnswerStatsHiddenFromParticipantsMidQuestion(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1)
+	participant := env.seedParticipant(t, quiz, "Ann")
+	env.seedAnswer(t, participant, question, correctOption(question))
+	env.runQuestion(t, question, 0)
+
+	if _, err := env.answers.GetAnswerStatsForUser(ctx, question.ID, participant.ID); !errors.Is(err, ErrStatsNotAvailable) {
+		t.Errorf("participant mid-question got %v, want ErrStatsNotAvailable", err)
+	}
+	if stats, err := env.answers.GetAnswerStatsForUser(ctx, question.ID, quiz.CreatorID); err != nil || stats[correctOption(question)] != 1 {
+		t.Errorf("creator mid-question got %v, %v; want the distribution", stats, err)
+	}
+
+	if err := env.state.EndQuestion(ctx, quiz.ID); err != nil {
+		t.Fatalf("EndQuestion: %v", err)
+	}
+	stats, err := env.answers.GetAnswerStatsForUser(ctx, question.ID, participant.ID)
+	if err != nil {
+		t.Fatalf("participant after the question ended: %v", err)
+	}
+	if stats[correctOption(question)] != 1 || stats[wrongOption(question)] != 0 {
+		t.Errorf("participant after the question ended got %v", stats)
+	}
+}
+
+func TestAnswerStatsHiddenBeforeTheQuizStarts(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1)
+
+	if _, err := env.answers.GetAnswerStatsForUser(context.Background(), question.ID, uuid.New()); !errors.Is(err, ErrStatsNotAvailable) {
+		t.Errorf("stats of a waiting quiz got %v, want ErrStatsNotAvailable", err)
+	}
+	if _, err := env.answers.GetAnswerStatsForUser(context.Background(), question.ID, quiz.CreatorID); err != nil {
+		t.Errorf("creator of a waiting quiz got %v", err)
+	}
+}
+
+// answeredCounts returns the answeredCount of every ANSWER_COUNT_UPDATE sent, in order
+func (h *fakeHub) answeredCounts(t *testing.T) []int {
+	t.Helper()
+
+	var counts []int
+	for _, event := range h.events(websocket.EventAnswerCountUpdate) {
+		if event.Audience != hubAudienceCreators {
+			t.Errorf("ANSWER_COUNT_UPDATE sent to %s", event.Audience)
+		}
+		counts = append(counts, event.payload()["answeredCount"].(int))
+	}
+	return counts
+}
+
+func TestSubmittedAnswersIncrementTheHostsLiveCount(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1)
+	first := env.seedParticipant(t, quiz, "Ann")
+	second := env.seedParticipant(t, quiz, "Bob")
+	env.runQuestion(t, question, time.Second)
+
+	// Submit is what the HTTP handler calls, with no WebSocket involved
+	if _, err := env.answers.Submit(ctx, first.ID, question.ID, []string{correctOption(question)}, ""); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if got := env.hub.answeredCounts(t); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("after one answer the host was sent counts %v, want [1]", got)
+	}
+
+	if _, err := env.answers.Submit(ctx, second.ID, question.ID, []string{wrongOption(question)}, ""); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if got := env.hub.answeredCounts(t); len(got) != 2 || got[1] != 2 {
+		t.Errorf("after two answers the host was sent counts %v, want [1 2]", got)
+	}
+	if got := len(env.hub.events(websocket.EventClientAnswer)); got != 2 {
+		t.Errorf("host was sent %d CLIENT_ANSWER events, want one per answer", got)
+	}
+}
+
+func TestSubmitRejectsMoreOptionsThanTheQuestionHas(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1)
+	participant := env.seedParticipant(t, quiz, "Player")
+	env.runQuestion(t, question, time.Second)
+
+	selected := []string{correctOption(question), wrongOption(question)}
+	for i := 0; i < 1000; i++ {
+		selected = append(selected, uuid.New().String())
+	}
+	if _, err := env.answers.Submit(context.Background(), participant.ID, question.ID, selected, ""); !errors.Is(err, ErrTooManyOptions) {
+		t.Errorf("oversized selection: got %v, want ErrTooManyOptions", err)
+	}
+	if got := env.store.callCount("CreateAnswer"); got != 0 {
+		t.Errorf("oversized selection was stored %d times", got)
+	}
+}
+
+// wsParticipant is a participant connected over a real WebSocket to a client that submits through the answer service
+type wsParticipant struct {
+	client *websocket.Client
+	peer   *gorilla.Conn
+}
+
+// connectParticipant opens a WebSocket for participant whose client is registered with hub and answers
+// through the test environment's answer service. It returns once the hub delivers to the client.
+func (e *testEnv) connectParticipant(t *testing.T, hub *websocket.MemoryHub, participant *model.Participant) *wsParticipant {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	client := &websocket.Client{
+		ID:           uuid.New(),
+		QuizID:       participant.QuizID,
+		UserID:       participant.ID,
+		Hub:          hub,
+		Send:         make(chan []byte, 16),
+		Ctx:          ctx,
+		Cancel:       cancel,
+		SubmitAnswer: e.answers.Submit,
+	}
+
+	upgrader := gorilla.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		client.Conn = conn
+		hub.GetRegisterChan() <- client
+		go client.ReadPump()
+	}))
+	t.Cleanup(server.Close)
+
+	peer, _, err := gorilla.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { peer.Close() })
+
+	// Registration is asynchronous; probe until the hub reaches the client
+	conn := &wsParticipant{client: client, peer: peer}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		hub.SendToClient(participant.ID, participant.QuizID, websocket.NewEvent("probe", nil))
+		select {
+		case <-client.Send:
+			return conn
+		case <-time.After(10 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("client was never registered with the hub")
+		}
+	}
+}
+
+// answer sends an ANSWER message selecting options and returns the event the client is sent in reply
+func (p *wsParticipant) answer(t *testing.T, questionID uuid.UUID, options ...string) (websocket.EventType, map[string]interface{}) {
+	t.Helper()
+
+	message, err := json.Marshal(map[string]interface{}{
+		"type":    "ANSWER",
+		"payload": map[string]interface{}{"questionId": questionID.String(), "selectedOptions": options},
+	})
+	if err != nil {
+		t.Fatalf("marshal answer: %v", err)
+	}
+	if err := p.peer.WriteMessage(gorilla.TextMessage, message); err != nil {
+		t.Fatalf("write answer: %v", err)
+	}
+
+	select {
+	case raw := <-p.client.Send:
+		var reply struct {
+			Type    websocket.EventType    `json:"type"`
+			Payload map[string]interface{} `json:"payload"`
+		}
+		if err := json.Unmarshal(raw, &reply); err != nil {
+			t.Fatalf("decode reply %s: %v", raw, err)
+		}
+		return reply.Type, reply.Payload
+	case <-time.After(2 * time.Second):
+		t.Fatal("no reply to the answer")
+		return "", nil
+	}
+}
+
+func TestAnswersSubmittedOverWebSocketAreScoredAndStored(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1)
+	upcoming := env.seedQuestion(t, quiz, 2)
+	ann := env.seedParticipant(t, quiz, "Ann")
+	bob := env.seedParticipant(t, quiz, "Bob")
+	env.runQuestion(t, question, time.Second)
+
+	hub := websocket.NewMemoryHub(nil)
+	hubCtx, stopHub := context.WithCancel(ctx)
+	t.Cleanup(stopHub)
+	go hub.Run(hubCtx)
+	annConn := env.connectParticipant(t, hub, ann)
+	bobConn := env.connectParticipant(t, hub, bob)
+
+	tests := []struct {
+		name        string
+		conn        *wsParticipant
+		participant *model.Participant
+		option      string
+		wantCorrect bool
+	}{
+		{name: "correct answer", conn: annConn, participant: ann, option: correctOption(question), wantCorrect: true},
+		{name: "wrong answer", conn: bobConn, participant: bob, option: wrongOption(question), wantCorrect: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventType, payload := tt.conn.answer(t, question.ID, tt.option)
+			if eventType != websocket.EventAnswerReceived {
+				t.Fatalf("answer was answered with %s %v, want %s", eventType, payload, websocket.EventAnswerReceived)
+			}
+
+			stored, err := env.answerRepo.GetAnswerByParticipantAndQuestion(ctx, tt.participant.ID, question.ID)
+			if err != nil {
+				t.Fatalf("answer was not stored: %v", err)
+			}
+			if stored.IsCorrect != tt.wantCorrect || payload["isCorrect"] != tt.wantCorrect {
+				t.Errorf("answer is stored as correct %v and confirmed as %v, want %v", stored.IsCorrect, payload["isCorrect"], tt.wantCorrect)
+			}
+			if payload["answerId"] != stored.ID.String() || payload["score"] != float64(stored.Score) {
+				t.Errorf("confirmation %v does not match the stored answer %s scoring %d", payload, stored.ID, stored.Score)
+			}
+			if got := env.participantScore(t, tt.participant.ID); got != stored.Score {
+				t.Errorf("participant score is %d, want the %d points of the answer", got, stored.Score)
+			}
+		})
+	}
+
+	if scored := env.participantScore(t, ann.ID); scored < model.CorrectAnswerPoints {
+		t.Errorf("correct answer scored %d points", scored)
+	}
+
+	// Answers to a question that is not running are rejected over the socket too
+	eventType, payload := annConn.answer(t, upcoming.ID, correctOption(upcoming))
+	if eventType != websocket.EventError || payload["code"] != websocket.ErrorCodeAnswerRejected {
+		t.Errorf("answer to an inactive question was answered with %s %v", eventType, payload)
+	}
+	if _, err := env.answerRepo.GetAnswerByParticipantAndQuestion(ctx, ann.ID, upcoming.ID); err == nil {
+		t.Error("answer to an inactive question was stored")
+	}
+}
+
+func TestDisableTimeBonusScoresFastAndSlowAnswersEqually(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings model.QuizSettings
+		wantFast int
+	}{
+		{name: "time bonus", settings: model.QuizSettings{}, wantFast: model.CorrectAnswerPoints + model.TimeBonusPoints},
+		{name: "time bonus disabled", settings: model.QuizSettings{DisableTimeBonus: true}, wantFast: model.CorrectAnswerPoints},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			quiz := env.seedQuiz(t, model.QuizStatusActive, tt.settings)
+			question := env.seedQuestion(t, quiz, 1)
+			fast := env.seedParticipant(t, quiz, "Fast")
+			slow := env.seedParticipant(t, quiz, "Slow")
+
+			// Answered 1 and 25 seconds into the 30 second question
+			env.runQuestion(t, question, time.Second)
+			fastAnswer, err := env.answers.Submit(ctx, fast.ID, question.ID, []string{correctOption(question)}, "")
+			if err != nil {
+				t.Fatalf("Submit fast answer: %v", err)
+			}
+			env.runQuestion(t, question, 25*time.Second)
+			slowAnswer, err := env.answers.Submit(ctx, slow.ID, question.ID, []string{correctOption(question)}, "")
+			if err != nil {
+				t.Fatalf("Submit slow answer: %v", err)
+			}
+
+			if fastAnswer.TimeTaken >= slowAnswer.TimeTaken {
+				t.Fatalf("fast answer took %.1fs and slow one %.1fs", fastAnswer.TimeTaken, slowAnswer.TimeTaken)
+			}
+			if fastAnswer.Score != tt.wantFast {
+				t.Errorf("fast answer scored %d, want %d", fastAnswer.Score, tt.wantFast)
+			}
+			if slowAnswer.Score != model.CorrectAnswerPoints {
+				t.Errorf("slow answer scored %d, want the base %d", slowAnswer.Score, model.CorrectAnswerPoints)
+			}
+			if got := env.participantScore(t, fast.ID); got != tt.wantFast {
+				t.Errorf("fast participant's score = %d, want %d", got, tt.wantFast)
+			}
+		})
+	}
+}
@@ -0,0 +1,1581 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/repository"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/auth"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
+	"github.com/google/uuid"
+)
+
+var (
+	_ repository.QuizRepository           = (*fakeQuizRepo)(nil)
+	_ repository.QuestionRepository       = (*fakeQuestionRepo)(nil)
+	_ repository.QuestionOptionRepository = (*fakeQuestionOptionRepo)(nil)
+	_ repository.UserRepository           = (*fakeUserRepo)(nil)
+	_ repository.ParticipantRepository    = (*fakeParticipantRepo)(nil)
+	_ repository.TeamRepository           = (*fakeTeamRepo)(nil)
+	_ repository.WebhookRepository        = (*fakeWebhookRepo)(nil)
+	_ repository.AnswerRepository         = (*fakeAnswerRepo)(nil)
+	_ repository.StateRepository          = (*fakeStateRepo)(nil)
+	_ EventHub                            = (*fakeHub)(nil)
+	_ WebhookNotifier                     = (*fakeWebhookNotifier)(nil)
+)
+
+// testEnv wires the services to fake repositories and a fake hub, the way bootstrap wires the real ones
+type testEnv struct {
+	store    *fakeStore
+	hub      *fakeHub
+	webhooks *fakeWebhookNotifier
+
+	quizRepo        *fakeQuizRepo
+	questionRepo    *fakeQuestionRepo
+	optionRepo      *fakeQuestionOptionRepo
+	participantRepo *fakeParticipantRepo
+	answerRepo      *fakeAnswerRepo
+	stateRepo       *fakeStateRepo
+
+	jwt          *auth.JWTManager
+	leaderboard  LeaderboardService
+	state        *stateServiceImpl
+	answers      *answerServiceImpl
+	questions    *questionServiceImpl
+	quizzes      *quizServiceImpl
+	participants *participantServiceImpl
+}
+
+// testQuizConfig is the quiz configuration used by newTestEnv: the defaults, without debouncing or caching
+// so every change is visible at once
+func testQuizConfig() config.QuizConfig {
+	return config.QuizConfig{
+		MaxActivePerCreator:    10,
+		ReconnectGracePeriod:   60 * time.Second,
+		MaxPrefetchQuestions:   5,
+		MaxLeaderboardLimit:    100,
+		MaxTitleLength:         255,
+		MaxDescriptionLength:   2000,
+		MaxQuestionsPerQuiz:    100,
+		CollapseNameWhitespace: true,
+	}
+}
+
+// newTestEnv creates a test environment; cfg tweaks the quiz configuration before the services are built
+func newTestEnv(t *testing.T, cfg ...func(*config.QuizConfig)) *testEnv {
+	t.Helper()
+
+	quizConfig := testQuizConfig()
+	for _, apply := range cfg {
+		apply(&quizConfig)
+	}
+
+	store := newFakeStore()
+	env := &testEnv{
+		store:           store,
+		hub:             newFakeHub(),
+		webhooks:        &fakeWebhookNotifier{},
+		quizRepo:        &fakeQuizRepo{store},
+		questionRepo:    &fakeQuestionRepo{store},
+		optionRepo:      &fakeQuestionOptionRepo{store},
+		participantRepo: &fakeParticipantRepo{store},
+		answerRepo:      &fakeAnswerRepo{store},
+		stateRepo:       &fakeStateRepo{store},
+		jwt: auth.NewJWTManager(config.JWTConfig{
+			Secret:             "test-secret",
+			ExpirationTime:     time.Hour,
+			RefreshSecret:      "test-refresh-secret",
+			RefreshExpTime:     time.Hour,
+			ParticipantExpTime: time.Hour,
+			GuestExpTime:       time.Hour,
+			Issuer:             "test",
+		}),
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	teamRepo := &fakeTeamRepo{store}
+
+	env.leaderboard = NewLeaderboardService(env.participantRepo, env.quizRepo, NewTeamLeaderboardService(teamRepo, env.participantRepo), env.hub)
+	env.state = NewStateService(env.stateRepo, env.quizRepo, env.questionRepo, env.optionRepo, env.participantRepo, env.answerRepo, env.hub, env.webhooks, quizConfig, log).(*stateServiceImpl)
+	env.answers = NewAnswerService(env.answerRepo, env.questionRepo, env.participantRepo, env.quizRepo, env.leaderboard, env.optionRepo, env.stateRepo, env.hub, env.state, log).(*answerServiceImpl)
+	env.questions = NewQuestionService(env.quizRepo, env.questionRepo, env.optionRepo, env.answerRepo, env.leaderboard, env.hub, env.state, quizConfig).(*questionServiceImpl)
+	env.quizzes = NewQuizService(env.quizRepo, &fakeUserRepo{store}, env.questionRepo, env.optionRepo, env.answerRepo, env.state, env.hub, quizConfig).(*quizServiceImpl)
+	env.participants = NewParticipantService(env.participantRepo, env.quizRepo, teamRepo, env.hub, env.state, env.jwt, quizConfig).(*participantServiceImpl)
+
+	// Stop the question timers a test leaves running
+	t.Cleanup(func() {
+		env.state.timersMu.Lock()
+		defer env.state.timersMu.Unlock()
+		for _, cancel := range env.state.questionTimers {
+			cancel()
+		}
+	})
+
+	return env
+}
+
+// seedQuiz stores a quiz with its session in the given status
+func (e *testEnv) seedQuiz(t *testing.T, status model.QuizStatus, settings model.QuizSettings) *model.Quiz {
+	t.Helper()
+
+	quiz := model.NewQuiz("Test quiz", "", uuid.New())
+	quiz.Status = status
+	quiz.Settings = settings
+	session := model.NewQuizSession(quiz.ID)
+	session.Status = status
+	if status != model.QuizStatusWaiting {
+		startedAt := time.Now().Add(-time.Minute)
+		session.StartedAt = &startedAt
+	}
+
+	if err := e.quizRepo.CreateQuizWithContent(context.Background(), quiz, session, nil); err != nil {
+		t.Fatalf("seed quiz: %v", err)
+	}
+	return quiz
+}
+
+// seedQuestion stores a single choice question of a quiz with options; the first option is the correct one
+func (e *testEnv) seedQuestion(t *testing.T, quiz *model.Quiz, order int, optionTexts ...string) *model.Question {
+	t.Helper()
+
+	if len(optionTexts) == 0 {
+		optionTexts = []string{"Right", "Wrong"}
+	}
+
+	question := model.NewQuestion(quiz.ID, "Question", model.QuestionTypeSingleChoice, 30, order)
+	for i, text := range optionTexts {
+		question.Options = append(question.Options, model.NewQuestionOption(question.ID, text, i == 0, i+1))
+	}
+
+	ctx := context.Background()
+	if err := e.questionRepo.CreateQuestion(ctx, question); err != nil {
+		t.Fatalf("seed question: %v", err)
+	}
+	for _, option := range question.Options {
+		if err := e.optionRepo.CreateQuestionOption(ctx, option); err != nil {
+			t.Fatalf("seed option: %v", err)
+		}
+	}
+	return question
+}
+
+// seedParticipant stores a participant of a quiz
+func (e *testEnv) seedParticipant(t *testing.T, quiz *model.Quiz, name string) *model.Participant {
+	t.Helper()
+
+	participant := model.NewParticipant(name, quiz.ID)
+	if err := e.participantRepo.CreateParticipant(context.Background(), participant); err != nil {
+		t.Fatalf("seed participant: %v", err)
+	}
+	return participant
+}
+
+// seedAnswer stores a participant's answer to a question
+func (e *testEnv) seedAnswer(t *testing.T, participant *model.Participant, question *model.Question, selectedOptions ...string) *model.Answer {
+	t.Helper()
+
+	answer, err := model.NewAnswer(participant.ID, question.ID, selectedOptions, 1, len(selectedOptions) == 1 && selectedOptions[0] == correctOption(question))
+	if err != nil {
+		t.Fatalf("seed answer: %v", err)
+	}
+	if err := e.answerRepo.CreateAnswer(context.Background(), answer); err != nil {
+		t.Fatalf("seed answer: %v", err)
+	}
+	return answer
+}
+
+// setSession applies change to a quiz's stored session
+func (e *testEnv) setSession(t *testing.T, quizID uuid.UUID, change func(*model.QuizSession)) {
+	t.Helper()
+
+	ctx := context.Background()
+	session, err := e.quizRepo.GetQuizSession(ctx, quizID)
+	if err != nil {
+		t.Fatalf("get session: %v", err)
+	}
+	change(session)
+	if err := e.quizRepo.UpdateQuizSession(ctx, session); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+}
+
+// runQuestion marks a question as running in its quiz's session, started the given time ago
+func (e *testEnv) runQuestion(t *testing.T, question *model.Question, startedAgo time.Duration) {
+	t.Helper()
+
+	e.setSession(t, question.QuizID, func(session *model.QuizSession) {
+		startedAt := time.Now().Add(-startedAgo)
+		session.CurrentQuestionID = &question.ID
+		session.CurrentQuestionStartedAt = &startedAt
+		session.CurrentQuestionEndedAt = nil
+		session.CurrentPhase = model.QuizPhaseQuestionActive
+	})
+}
+
+// correctOption returns the ID of a seeded question's correct option
+func correctOption(question *model.Question) string {
+	for _, option := range question.Options {
+		if option.IsCorrect {
+			return option.ID.String()
+		}
+	}
+	return ""
+}
+
+// wrongOption returns the ID of one of a seeded question's incorrect options
+func wrongOption(question *model.Question) string {
+	for _, option := range question.Options {
+		if !option.IsCorrect {
+			return option.ID.String()
+		}
+	}
+	return ""
+}
+
+// participantScore returns a participant's stored score
+func (e *testEnv) participantScore(t *testing.T, participantID uuid.UUID) int {
+	t.Helper()
+
+	participant, err := e.participantRepo.GetParticipantByID(context.Background(), participantID)
+	if err != nil {
+		t.Fatalf("get participant: %v", err)
+	}
+	return participant.Score
+}
+
+// fakeStore is an in-memory database shared by the fake repositories below.
+// Records are copied in and out like rows, so services can't change stored data by mutating what they read.
+type fakeStore struct {
+	mu sync.Mutex
+
+	quizzes      map[uuid.UUID]*model.Quiz
+	sessions     map[uuid.UUID]*model.QuizSession
+	questions    map[uuid.UUID]*model.Question
+	options      map[uuid.UUID]*model.QuestionOption
+	participants map[uuid.UUID]*model.Participant
+	answers      map[uuid.UUID]*model.Answer
+	users        map[uuid.UUID]*model.User
+	teams        map[uuid.UUID]*model.Team
+	webhooks     map[uuid.UUID]*model.QuizWebhook
+	bans         []*model.QuizBan
+	events       []*model.QuizEvent
+	connections  map[[2]uuid.UUID]*model.ParticipantConnection
+	instances    map[string]*model.ServerInstance
+	sequences    map[uuid.UUID]int64
+
+	// calls counts the calls made to each repository method, keyed by method name
+	calls map[string]int
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		quizzes:      make(map[uuid.UUID]*model.Quiz),
+		sessions:     make(map[uuid.UUID]*model.QuizSession),
+		questions:    make(map[uuid.UUID]*model.Question),
+		options:      make(map[uuid.UUID]*model.QuestionOption),
+		participants: make(map[uuid.UUID]*model.Participant),
+		answers:      make(map[uuid.UUID]*model.Answer),
+		users:        make(map[uuid.UUID]*model.User),
+		teams:        make(map[uuid.UUID]*model.Team),
+		webhooks:     make(map[uuid.UUID]*model.QuizWebhook),
+		connections:  make(map[[2]uuid.UUID]*model.ParticipantConnection),
+		instances:    make(map[string]*model.ServerInstance),
+		sequences:    make(map[uuid.UUID]int64),
+		calls:        make(map[string]int),
+	}
+}
+
+// lock takes the store lock and records a call to the named method; callers must unlock
+func (s *fakeStore) lock(method string) {
+	s.mu.Lock()
+	s.calls[method]++
+}
+
+// callCount returns how many times the named repository method was called
+func (s *fakeStore) callCount(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// resetCalls forgets the calls recorded so far
+func (s *fakeStore) resetCalls() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = make(map[string]int)
+}
+
+func copyQuiz(q *model.Quiz) *model.Quiz {
+	c := *q
+	return &c
+}
+
+func copySession(s *model.QuizSession) *model.QuizSession {
+	c := *s
+	c.FrozenLeaderboard = append([]model.LeaderboardStanding(nil), s.FrozenLeaderboard...)
+	return &c
+}
+
+func copyQuestion(q *model.Question) *model.Question {
+	c := *q
+	c.Options = nil
+	if q.VoidedPoints != nil {
+		points := *q.VoidedPoints
+		c.VoidedPoints = &points
+	}
+	return &c
+}
+
+func copyOption(o *model.QuestionOption) *model.QuestionOption {
+	c := *o
+	return &c
+}
+
+func copyParticipant(p *model.Participant) *model.Participant {
+	c := *p
+	return &c
+}
+
+func copyAnswer(a *model.Answer) *model.Answer {
+	c := *a
+	c.SelectedOptions = append([]string(nil), a.SelectedOptions...)
+	return &c
+}
+
+// fakeQuizRepo implements repository.QuizRepository on a fakeStore
+type fakeQuizRepo struct{ s *fakeStore }
+
+func (r *fakeQuizRepo) CreateQuiz(ctx context.Context, quiz *model.Quiz) error {
+	r.s.lock("CreateQuiz")
+	defer r.s.mu.Unlock()
+	r.s.quizzes[quiz.ID] = copyQuiz(quiz)
+	return nil
+}
+
+func (r *fakeQuizRepo) GetQuizByID(ctx context.Context, id uuid.UUID) (*model.Quiz, error) {
+	r.s.lock("GetQuizByID")
+	defer r.s.mu.Unlock()
+	quiz, ok := r.s.quizzes[id]
+	if !ok {
+		return nil, errors.New("quiz not found")
+	}
+	return copyQuiz(quiz), nil
+}
+
+func (r *fakeQuizRepo) GetQuizByCode(ctx context.Context, code string) (*model.Quiz, error) {
+	r.s.lock("GetQuizByCode")
+	defer r.s.mu.Unlock()
+	for _, quiz := range r.s.quizzes {
+		if quiz.Code == code {
+			return copyQuiz(quiz), nil
+		}
+	}
+	return nil, errors.New("quiz not found")
+}
+
+func (r *fakeQuizRepo) QuizCodeExists(ctx context.Context, code string) (bool, error) {
+	r.s.lock("QuizCodeExists")
+	defer r.s.mu.Unlock()
+	for _, quiz := range r.s.quizzes {
+		if quiz.Code == code {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeQuizRepo) UpdateQuizCode(ctx context.Context, id uuid.UUID, code string) error {
+	r.s.lock("UpdateQuizCode")
+	defer r.s.mu.Unlock()
+	quiz, ok := r.s.quizzes[id]
+	if !ok {
+		return errors.New("quiz not found")
+	}
+	quiz.Code = code
+	return nil
+}
+
+// creatorQuizzes returns the quizzes of a creator matching filter, newest first. Callers must hold the lock.
+func (r *fakeQuizRepo) creatorQuizzes(creatorID uuid.UUID, filter model.QuizFilter) []*model.Quiz {
+	var quizzes []*model.Quiz
+	for _, quiz := range r.s.quizzes {
+		if quiz.CreatorID != creatorID {
+			continue
+		}
+		if filter.Status != "" && quiz.Status != filter.Status {
+			continue
+		}
+		if filter.Search != "" && !strings.Contains(strings.ToLower(quiz.Title), strings.ToLower(filter.Search)) {
+			continue
+		}
+		if !filter.IncludeArchived && quiz.ArchivedAt != nil {
+			continue
+		}
+		quizzes = append(quizzes, copyQuiz(quiz))
+	}
+	sort.Slice(quizzes, func(i, j int) bool {
+		return quizzes[i].CreatedAt.After(quizzes[j].CreatedAt)
+	})
+	return quizzes
+}
+
+func (r *fakeQuizRepo) GetQuizzesByCreatorID(ctx context.Context, creatorID uuid.UUID, filter model.QuizFilter) ([]*model.Quiz, error) {
+	r.s.lock("GetQuizzesByCreatorID")
+	defer r.s.mu.Unlock()
+	quizzes := r.creatorQuizzes(creatorID, filter)
+	if filter.Offset >= len(quizzes) {
+		return []*model.Quiz{}, nil
+	}
+	quizzes = quizzes[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(quizzes) {
+		quizzes = quizzes[:filter.Limit]
+	}
+	return quizzes, nil
+}
+
+func (r *fakeQuizRepo) CountQuizzesByCreatorID(ctx context.Context, creatorID uuid.UUID, filter model.QuizFilter) (int, error) {
+	r.s.lock("CountQuizzesByCreatorID")
+	defer r.s.mu.Unlock()
+	return len(r.creatorQuizzes(creatorID, filter)), nil
+}
+
+func (r *fakeQuizRepo) CountActiveQuizzesByCreator(ctx context.Context, creatorID uuid.UUID) (int, error) {
+	r.s.lock("CountActiveQuizzesByCreator")
+	defer r.s.mu.Unlock()
+	count := 0
+	for _, quiz := range r.s.quizzes {
+		if quiz.CreatorID == creatorID && quiz.Status == model.QuizStatusActive {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeQuizRepo) GetStaleActiveQuizzes(ctx context.Context, cutoff time.Time) ([]*model.QuizActivity, error) {
+	r.s.lock("GetStaleActiveQuizzes")
+	defer r.s.mu.Unlock()
+	var stale []*model.QuizActivity
+	for _, quiz := range r.s.quizzes {
+		if quiz.Status != model.QuizStatusActive {
+			continue
+		}
+		last := quiz.UpdatedAt
+		if session, ok := r.s.sessions[quiz.ID]; ok {
+			for _, t := range []*time.Time{session.StartedAt, session.CurrentQuestionStartedAt} {
+				if t != nil && t.After(last) {
+					last = *t
+				}
+			}
+		}
+		for _, event := range r.s.events {
+			if event.QuizID == quiz.ID && event.CreatedAt.After(last) {
+				last = event.CreatedAt
+			}
+		}
+		if last.Before(cutoff) {
+			stale = append(stale, &model.QuizActivity{Quiz: copyQuiz(quiz), LastActivityAt: last})
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].LastActivityAt.Before(stale[j].LastActivityAt)
+	})
+	return stale, nil
+}
+
+func (r *fakeQuizRepo) UpdateQuizStatus(ctx context.Context, id uuid.UUID, status model.QuizStatus) error {
+	r.s.lock("UpdateQuizStatus")
+	defer r.s.mu.Unlock()
+	quiz, ok := r.s.quizzes[id]
+	if !ok {
+		return errors.New("quiz not found")
+	}
+	quiz.Status = status
+	quiz.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *fakeQuizRepo) CreateQuizWithContent(ctx context.Context, quiz *model.Quiz, session *model.QuizSession, questions []*model.Question) error {
+	r.s.lock("CreateQuizWithContent")
+	defer r.s.mu.Unlock()
+	r.s.quizzes[quiz.ID] = copyQuiz(quiz)
+	r.s.sessions[quiz.ID] = copySession(session)
+	for _, question := range questions {
+		r.s.questions[question.ID] = copyQuestion(question)
+		for _, option := range question.Options {
+			r.s.options[option.ID] = copyOption(option)
+		}
+	}
+	return nil
+}
+
+func (r *fakeQuizRepo) CreateGuestQuizWithContent(ctx context.Context, guest *model.User, quiz *model.Quiz, session *model.QuizSession, questions []*model.Question) error {
+	r.s.lock("CreateGuestQuizWithContent")
+	c := *guest
+	r.s.users[guest.ID] = &c
+	r.s.mu.Unlock()
+	return r.CreateQuizWithContent(ctx, quiz, session, questions)
+}
+
+func (r *fakeQuizRepo) CreateQuizSession(ctx context.Context, session *model.QuizSession) error {
+	r.s.lock("CreateQuizSession")
+	defer r.s.mu.Unlock()
+	r.s.sessions[session.QuizID] = copySession(session)
+	return nil
+}
+
+func (r *fakeQuizRepo) GetQuizSession(ctx context.Context, quizID uuid.UUID) (*model.QuizSession, error) {
+	r.s.lock("GetQuizSession")
+	defer r.s.mu.Unlock()
+	session, ok := r.s.sessions[quizID]
+	if !ok {
+		return nil, repository.ErrQuizSessionNotFound
+	}
+	return copySession(session), nil
+}
+
+func (r *fakeQuizRepo) UpdateQuizSession(ctx context.Context, session *model.QuizSession) error {
+	r.s.lock("UpdateQuizSession")
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.sessions[session.QuizID]; !ok {
+		return repository.ErrQuizSessionNotFound
+	}
+	r.s.sessions[session.QuizID] = copySession(session)
+	return nil
+}
+
+func (r *fakeQuizRepo) UpdateQuizStatusWithSession(ctx context.Context, from model.QuizStatus, to model.QuizStatus, session *model.QuizSession) error {
+	r.s.lock("UpdateQuizStatusWithSession")
+	defer r.s.mu.Unlock()
+	quiz, ok := r.s.quizzes[session.QuizID]
+	if !ok {
+		return errors.New("quiz not found")
+	}
+	if quiz.Status != from {
+		return repository.ErrQuizStatusChanged
+	}
+	quiz.Status = to
+	quiz.UpdatedAt = time.Now()
+	r.s.sessions[session.QuizID] = copySession(session)
+	return nil
+}
+
+func (r *fakeQuizRepo) ActivateQuizWithSession(ctx context.Context, session *model.QuizSession, maxActivePerCreator int) error {
+	r.s.lock("ActivateQuizWithSession")
+	defer r.s.mu.Unlock()
+	quiz, ok := r.s.quizzes[session.QuizID]
+	if !ok {
+		return errors.New("quiz not found")
+	}
+	if maxActivePerCreator > 0 {
+		active := 0
+		for _, other := range r.s.quizzes {
+			if other.ID != quiz.ID && other.CreatorID == quiz.CreatorID && other.Status == model.QuizStatusActive {
+				active++
+			}
+		}
+		if active >= maxActivePerCreator {
+			return repository.ErrActiveQuizLimit
+		}
+	}
+	if quiz.Status != model.QuizStatusWaiting {
+		return repository.ErrQuizStatusChanged
+	}
+	quiz.Status = model.QuizStatusActive
+	quiz.UpdatedAt = time.Now()
+	r.s.sessions[session.QuizID] = copySession(session)
+	return nil
+}
+
+func (r *fakeQuizRepo) SetLeaderboardFrozen(ctx context.Context, quizID uuid.UUID, frozen bool, standings []model.LeaderboardStanding) error {
+	r.s.lock("SetLeaderboardFrozen")
+	defer r.s.mu.Unlock()
+	session, ok := r.s.sessions[quizID]
+	if !ok {
+		return repository.ErrQuizSessionNotFound
+	}
+	session.LeaderboardFrozen = frozen
+	session.FrozenLeaderboard = append([]model.LeaderboardStanding(nil), standings...)
+	return nil
+}
+
+func (r *fakeQuizRepo) UpdateQuiz(ctx context.Context, quiz *model.Quiz) error {
+	r.s.lock("UpdateQuiz")
+	defer r.s.mu.Unlock()
+	stored, ok := r.s.quizzes[quiz.ID]
+	if !ok {
+		return errors.New("quiz not found")
+	}
+	stored.Title = quiz.Title
+	stored.Description = quiz.Description
+	stored.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *fakeQuizRepo) SetQuizArchived(ctx context.Context, id uuid.UUID, archivedAt *time.Time) error {
+	r.s.lock("SetQuizArchived")
+	defer r.s.mu.Unlock()
+	quiz, ok := r.s.quizzes[id]
+	if !ok {
+		return errors.New("quiz not found")
+	}
+	quiz.ArchivedAt = archivedAt
+	return nil
+}
+
+func (r *fakeQuizRepo) UpdateQuizSettings(ctx context.Context, id uuid.UUID, settings model.QuizSettings) error {
+	r.s.lock("UpdateQuizSettings")
+	defer r.s.mu.Unlock()
+	quiz, ok := r.s.quizzes[id]
+	if !ok {
+		return errors.New("quiz not found")
+	}
+	quiz.Settings = settings
+	return nil
+}
+
+func (r *fakeQuizRepo) DeleteQuiz(ctx context.Context, id uuid.UUID) error {
+	r.s.lock("DeleteQuiz")
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.quizzes[id]; !ok {
+		return errors.New("quiz not found")
+	}
+	delete(r.s.quizzes, id)
+	delete(r.s.sessions, id)
+	for questionID, question := range r.s.questions {
+		if question.QuizID == id {
+			delete(r.s.questions, questionID)
+		}
+	}
+	for participantID, participant := range r.s.participants {
+		if participant.QuizID == id {
+			delete(r.s.participants, participantID)
+		}
+	}
+	return nil
+}
+
+// fakeQuestionRepo implements repository.QuestionRepository on a fakeStore
+type fakeQuestionRepo struct{ s *fakeStore }
+
+func (r *fakeQuestionRepo) CreateQuestion(ctx context.Context, question *model.Question) error {
+	r.s.lock("CreateQuestion")
+	defer r.s.mu.Unlock()
+	r.s.questions[question.ID] = copyQuestion(question)
+	return nil
+}
+
+// quizQuestions returns the questions of a quiz in order. Callers must hold the lock.
+func (r *fakeQuestionRepo) quizQuestions(quizID uuid.UUID) []*model.Question {
+	questions := []*model.Question{}
+	for _, question := range r.s.questions {
+		if question.QuizID == quizID {
+			questions = append(questions, copyQuestion(question))
+		}
+	}
+	sort.Slice(questions, func(i, j int) bool {
+		return questions[i].Order < questions[j].Order
+	})
+	return questions
+}
+
+func (r *fakeQuestionRepo) GetQuestionsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.Question, error) {
+	r.s.lock("GetQuestionsByQuizID")
+	defer r.s.mu.Unlock()
+	return r.quizQuestions(quizID), nil
+}
+
+func (r *fakeQuestionRepo) GetQuestionByID(ctx context.Context, id uuid.UUID) (*model.Question, error) {
+	r.s.lock("GetQuestionByID")
+	defer r.s.mu.Unlock()
+	question, ok := r.s.questions[id]
+	if !ok {
+		return nil, errors.New("question not found")
+	}
+	return copyQuestion(question), nil
+}
+
+func (r *fakeQuestionRepo) GetNextQuestion(ctx context.Context, quizID uuid.UUID, currentOrder int) (*model.Question, error) {
+	r.s.lock("GetNextQuestion")
+	defer r.s.mu.Unlock()
+	for _, question := range r.quizQuestions(quizID) {
+		if question.Order > currentOrder {
+			return question, nil
+		}
+	}
+	return nil, errors.New("no more questions")
+}
+
+func (r *fakeQuestionRepo) UpdateQuestion(ctx context.Context, question *model.Question) error {
+	r.s.lock("UpdateQuestion")
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.questions[question.ID]; !ok {
+		return errors.New("question not found")
+	}
+	r.s.questions[question.ID] = copyQuestion(question)
+	return nil
+}
+
+func (r *fakeQuestionRepo) UpdateQuestionOrder(ctx context.Context, quizID uuid.UUID, questionIDs []uuid.UUID) error {
+	r.s.lock("UpdateQuestionOrder")
+	defer r.s.mu.Unlock()
+	for _, id := range questionIDs {
+		if question, ok := r.s.questions[id]; !ok || question.QuizID != quizID {
+			return errors.New("question not found")
+		}
+	}
+	for i, id := range questionIDs {
+		r.s.questions[id].Order = i + 1
+	}
+	return nil
+}
+
+func (r *fakeQuestionRepo) UpdateQuestionTimeLimits(ctx context.Context, quizID uuid.UUID, questionIDs []uuid.UUID, timeLimit int) error {
+	r.s.lock("UpdateQuestionTimeLimits")
+	defer r.s.mu.Unlock()
+	for _, id := range questionIDs {
+		if question, ok := r.s.questions[id]; !ok || question.QuizID != quizID {
+			return errors.New("question not found")
+		}
+	}
+	for _, id := range questionIDs {
+		r.s.questions[id].TimeLimit = timeLimit
+	}
+	return nil
+}
+
+func (r *fakeQuestionRepo) DeleteQuestion(ctx context.Context, id uuid.UUID) error {
+	r.s.lock("DeleteQuestion")
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.questions[id]; !ok {
+		return errors.New("question not found")
+	}
+	delete(r.s.questions, id)
+	return nil
+}
+
+func (r *fakeQuestionRepo) VoidQuestion(ctx context.Context, id uuid.UUID, points int) error {
+	r.s.lock("VoidQuestion")
+	defer r.s.mu.Unlock()
+	question, ok := r.s.questions[id]
+	if !ok || question.VoidedPoints != nil {
+		return errors.New("question not found or already voided")
+	}
+	question.VoidedPoints = &points
+	for _, participant := range r.s.participants {
+		if participant.QuizID == question.QuizID {
+			participant.Score = r.s.participantScore(participant)
+		}
+	}
+	return nil
+}
+
+// participantScore derives a participant's total from their answers like participantScoreExpr.
+// Callers must hold the lock.
+func (s *fakeStore) participantScore(participant *model.Participant) int {
+	score := 0
+	for _, answer := range s.answers {
+		if answer.ParticipantID != participant.ID {
+			continue
+		}
+		if question, ok := s.questions[answer.QuestionID]; ok && question.VoidedPoints == nil {
+			score += answer.Score
+		}
+	}
+	for _, question := range s.questions {
+		if question.QuizID == participant.QuizID && question.VoidedPoints != nil {
+			score += *question.VoidedPoints
+		}
+	}
+	return score
+}
+
+// fakeQuestionOptionRepo implements repository.QuestionOptionRepository on a fakeStore
+type fakeQuestionOptionRepo struct{ s *fakeStore }
+
+func (r *fakeQuestionOptionRepo) CreateQuestionOption(ctx context.Context, option *model.QuestionOption) error {
+	r.s.lock("CreateQuestionOption")
+	defer r.s.mu.Unlock()
+	r.s.options[option.ID] = copyOption(option)
+	return nil
+}
+
+func sortOptions(options []*model.QuestionOption) {
+	sort.Slice(options, func(i, j int) bool {
+		return options[i].DisplayOrder < options[j].DisplayOrder
+	})
+}
+
+func (r *fakeQuestionOptionRepo) GetQuestionOptionsByQuestionID(ctx context.Context, questionID uuid.UUID) ([]*model.QuestionOption, error) {
+	r.s.lock("GetQuestionOptionsByQuestionID")
+	defer r.s.mu.Unlock()
+	options := []*model.QuestionOption{}
+	for _, option := range r.s.options {
+		if option.QuestionID == questionID {
+			options = append(options, copyOption(option))
+		}
+	}
+	sortOptions(options)
+	return options, nil
+}
+
+func (r *fakeQuestionOptionRepo) GetQuestionOptionsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.QuestionOption, error) {
+	r.s.lock("GetQuestionOptionsByQuizID")
+	defer r.s.mu.Unlock()
+	options := []*model.QuestionOption{}
+	for _, option := range r.s.options {
+		if question, ok := r.s.questions[option.QuestionID]; ok && question.QuizID == quizID {
+			options = append(options, copyOption(option))
+		}
+	}
+	sortOptions(options)
+	return options, nil
+}
+
+func (r *fakeQuestionOptionRepo) UpdateQuestionOption(ctx context.Context, option *model.QuestionOption) error {
+	r.s.lock("UpdateQuestionOption")
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.options[option.ID]; !ok {
+		return errors.New("question option not found")
+	}
+	r.s.options[option.ID] = copyOption(option)
+	return nil
+}
+
+func (r *fakeQuestionOptionRepo) DeleteQuestionOption(ctx context.Context, id uuid.UUID) error {
+	r.s.lock("DeleteQuestionOption")
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.options[id]; !ok {
+		return errors.New("question option not found")
+	}
+	delete(r.s.options, id)
+	return nil
+}
+
+func (r *fakeQuestionOptionRepo) DeleteQuestionOptionsByQuestionID(ctx context.Context, questionID uuid.UUID) error {
+	r.s.lock("DeleteQuestionOptionsByQuestionID")
+	defer r.s.mu.Unlock()
+	for id, option := range r.s.options {
+		if option.QuestionID == questionID {
+			delete(r.s.options, id)
+		}
+	}
+	return nil
+}
+
+func (r *fakeQuestionOptionRepo) GetOrphanedQuestionOptions(ctx context.Context) ([]*model.QuestionOption, error) {
+	r.s.lock("GetOrphanedQuestionOptions")
+	defer r.s.mu.Unlock()
+	var orphans []*model.QuestionOption
+	for _, option := range r.s.options {
+		if _, ok := r.s.questions[option.QuestionID]; !ok {
+			orphans = append(orphans, copyOption(option))
+		}
+	}
+	return orphans, nil
+}
+
+func (r *fakeQuestionOptionRepo) DeleteOrphanedQuestionOptions(ctx context.Context) (int, error) {
+	r.s.lock("DeleteOrphanedQuestionOptions")
+	defer r.s.mu.Unlock()
+	removed := 0
+	for id, option := range r.s.options {
+		if _, ok := r.s.questions[option.QuestionID]; !ok {
+			delete(r.s.options, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// fakeUserRepo implements repository.UserRepository on a fakeStore
+type fakeUserRepo struct{ s *fakeStore }
+
+func (r *fakeUserRepo) CreateUser(ctx context.Context, user *model.User) error {
+	r.s.lock("CreateUser")
+	defer r.s.mu.Unlock()
+	for _, existing := range r.s.users {
+		if strings.EqualFold(existing.Email, user.Email) {
+			return repository.ErrEmailTaken
+		}
+	}
+	c := *user
+	r.s.users[user.ID] = &c
+	return nil
+}
+
+func (r *fakeUserRepo) GetUserByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	r.s.lock("GetUserByID")
+	defer r.s.mu.Unlock()
+	user, ok := r.s.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	c := *user
+	return &c, nil
+}
+
+func (r *fakeUserRepo) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	r.s.lock("GetUserByEmail")
+	defer r.s.mu.Unlock()
+	for _, user := range r.s.users {
+		if strings.EqualFold(user.Email, email) {
+			c := *user
+			return &c, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+// fakeParticipantRepo implements repository.ParticipantRepository on a fakeStore
+type fakeParticipantRepo struct{ s *fakeStore }
+
+func (r *fakeParticipantRepo) CreateParticipant(ctx context.Context, participant *model.Participant) error {
+	r.s.lock("CreateParticipant")
+	defer r.s.mu.Unlock()
+	r.s.participants[participant.ID] = copyParticipant(participant)
+	return nil
+}
+
+func (r *fakeParticipantRepo) GetParticipantByID(ctx context.Context, id uuid.UUID) (*model.Participant, error) {
+	r.s.lock("GetParticipantByID")
+	defer r.s.mu.Unlock()
+	participant, ok := r.s.participants[id]
+	if !ok {
+		return nil, errors.New("participant not found")
+	}
+	return copyParticipant(participant), nil
+}
+
+// quizParticipants returns the participants of a quiz in join order. Callers must hold the lock.
+func (r *fakeParticipantRepo) quizParticipants(quizID uuid.UUID) []*model.Participant {
+	participants := []*model.Participant{}
+	for _, participant := range r.s.participants {
+		if participant.QuizID == quizID {
+			participants = append(participants, copyParticipant(participant))
+		}
+	}
+	sort.Slice(participants, func(i, j int) bool {
+		if !participants[i].JoinedAt.Equal(participants[j].JoinedAt) {
+			return participants[i].JoinedAt.Before(participants[j].JoinedAt)
+		}
+		return participants[i].ID.String() < participants[j].ID.String()
+	})
+	return participants
+}
+
+func (r *fakeParticipantRepo) GetParticipantsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.Participant, error) {
+	r.s.lock("GetParticipantsByQuizID")
+	defer r.s.mu.Unlock()
+	return r.quizParticipants(quizID), nil
+}
+
+func (r *fakeParticipantRepo) UpdateParticipantScore(ctx context.Context, participantID uuid.UUID, score int) error {
+	r.s.lock("UpdateParticipantScore")
+	defer r.s.mu.Unlock()
+	participant, ok := r.s.participants[participantID]
+	if !ok {
+		return errors.New("participant not found")
+	}
+	participant.Score += score
+	return nil
+}
+
+func (r *fakeParticipantRepo) RecomputeParticipantScore(ctx context.Context, participantID uuid.UUID) (int, error) {
+	r.s.lock("RecomputeParticipantScore")
+	defer r.s.mu.Unlock()
+	participant, ok := r.s.participants[participantID]
+	if !ok {
+		return 0, errors.New("participant not found")
+	}
+	participant.Score = r.s.participantScore(participant)
+	return participant.Score, nil
+}
+
+// GetLeaderboard ranks by score, then by total answer time on questions that are not voided with participants
+// who never answered last, which is the default TIME tiebreak
+func (r *fakeParticipantRepo) GetLeaderboard(ctx context.Context, quizID uuid.UUID, limit int, offset int) ([]*model.Participant, error) {
+	r.s.lock("GetLeaderboard")
+	defer r.s.mu.Unlock()
+	participants := r.quizParticipants(quizID)
+	for _, participant := range participants {
+		for _, answer := range r.s.answers {
+			if question, ok := r.s.questions[answer.QuestionID]; ok && question.VoidedPoints != nil {
+				continue
+			}
+			if answer.ParticipantID == participant.ID {
+				participant.TotalAnswerTime += answer.TimeTaken
+				participant.AnswerCount++
+			}
+		}
+	}
+
+	less := func(a, b *model.Participant) bool {
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if (a.AnswerCount == 0) != (b.AnswerCount == 0) {
+			return a.AnswerCount > 0
+		}
+		return a.TotalAnswerTime < b.TotalAnswerTime
+	}
+	sort.SliceStable(participants, func(i, j int) bool {
+		return less(participants[i], participants[j])
+	})
+	for i, participant := range participants {
+		participant.Rank = i + 1
+		if i > 0 && !less(participants[i-1], participant) {
+			participant.Rank = participants[i-1].Rank
+		}
+	}
+
+	if offset >= len(participants) {
+		return []*model.Participant{}, nil
+	}
+	participants = participants[offset:]
+	if limit > 0 && limit < len(participants) {
+		participants = participants[:limit]
+	}
+	return participants, nil
+}
+
+func (r *fakeParticipantRepo) CountParticipantsByQuizID(ctx context.Context, quizID uuid.UUID) (int, error) {
+	r.s.lock("CountParticipantsByQuizID")
+	defer r.s.mu.Unlock()
+	return len(r.quizParticipants(quizID)), nil
+}
+
+func (r *fakeParticipantRepo) CreateParticipantWithinLimit(ctx context.Context, participant *model.Participant, maxParticipants int, graceCutoff time.Time) error {
+	r.s.lock("CreateParticipantWithinLimit")
+	defer r.s.mu.Unlock()
+	if maxParticipants > 0 && r.slotHolders(participant.QuizID, graceCutoff) >= maxParticipants {
+		return repository.ErrQuizFull
+	}
+	r.s.participants[participant.ID] = copyParticipant(participant)
+	return nil
+}
+
+func (r *fakeParticipantRepo) CountSlotHoldersByQuizID(ctx context.Context, quizID uuid.UUID, graceCutoff time.Time) (int, error) {
+	r.s.lock("CountSlotHoldersByQuizID")
+	defer r.s.mu.Unlock()
+	return r.slotHolders(quizID, graceCutoff), nil
+}
+
+// slotHolders counts the participants of a quiz holding a slot; the caller holds the store lock
+func (r *fakeParticipantRepo) slotHolders(quizID uuid.UUID, graceCutoff time.Time) int {
+	count := 0
+	for _, participant := range r.quizParticipants(quizID) {
+		conn, ok := r.s.connections[[2]uuid.UUID{participant.ID, quizID}]
+		switch {
+		case ok && conn.IsConnected:
+			count++
+		case ok && conn.LastSeen.After(graceCutoff):
+			count++
+		case !ok && participant.JoinedAt.After(graceCutoff):
+			count++
+		}
+	}
+	return count
+}
+
+func (r *fakeParticipantRepo) DeleteParticipant(ctx context.Context, id uuid.UUID) error {
+	r.s.lock("DeleteParticipant")
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.participants[id]; !ok {
+		return errors.New("participant not found")
+	}
+	r.s.deleteParticipant(id)
+	return nil
+}
+
+// deleteParticipant removes a participant with their answers and connections; the caller holds mu
+func (s *fakeStore) deleteParticipant(id uuid.UUID) {
+	delete(s.participants, id)
+	for answerID, answer := range s.answers {
+		if answer.ParticipantID == id {
+			delete(s.answers, answerID)
+		}
+	}
+	for key := range s.connections {
+		if key[0] == id {
+			delete(s.connections, key)
+		}
+	}
+}
+
+func (r *fakeParticipantRepo) BanParticipant(ctx context.Context, ban *model.QuizBan) error {
+	r.s.lock("BanParticipant")
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.participants[ban.ParticipantID]; !ok {
+		return errors.New("participant not found")
+	}
+	c := *ban
+	r.s.bans = append(r.s.bans, &c)
+	r.s.deleteParticipant(ban.ParticipantID)
+	return nil
+}
+
+func (r *fakeParticipantRepo) IsNameBanned(ctx context.Context, quizID uuid.UUID, name string) (bool, error) {
+	r.s.lock("IsNameBanned")
+	defer r.s.mu.Unlock()
+	for _, ban := range r.s.bans {
+		if ban.QuizID == quizID && strings.EqualFold(ban.Name, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeTeamRepo implements repository.TeamRepository on a fakeStore
+type fakeTeamRepo struct{ s *fakeStore }
+
+func (r *fakeTeamRepo) CreateTeam(ctx context.Context, team *model.Team) error {
+	r.s.lock("CreateTeam")
+	defer r.s.mu.Unlock()
+	c := *team
+	r.s.teams[team.ID] = &c
+	return nil
+}
+
+func (r *fakeTeamRepo) GetTeamByID(ctx context.Context, id uuid.UUID) (*model.Team, error) {
+	r.s.lock("GetTeamByID")
+	defer r.s.mu.Unlock()
+	team, ok := r.s.teams[id]
+	if !ok {
+		return nil, errors.New("team not found")
+	}
+	c := *team
+	return &c, nil
+}
+
+func (r *fakeTeamRepo) GetTeamsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.Team, error) {
+	r.s.lock("GetTeamsByQuizID")
+	defer r.s.mu.Unlock()
+	teams := []*model.Team{}
+	for _, team := range r.s.teams {
+		if team.QuizID == quizID {
+			c := *team
+			teams = append(teams, &c)
+		}
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		return teams[i].CreatedAt.Before(teams[j].CreatedAt)
+	})
+	return teams, nil
+}
+
+// fakeWebhookRepo implements repository.WebhookRepository on a fakeStore
+type fakeWebhookRepo struct{ s *fakeStore }
+
+func (r *fakeWebhookRepo) SaveQuizWebhook(ctx context.Context, webhook *model.QuizWebhook) error {
+	r.s.lock("SaveQuizWebhook")
+	defer r.s.mu.Unlock()
+	c := *webhook
+	r.s.webhooks[webhook.QuizID] = &c
+	return nil
+}
+
+func (r *fakeWebhookRepo) GetQuizWebhook(ctx context.Context, quizID uuid.UUID) (*model.QuizWebhook, error) {
+	r.s.lock("GetQuizWebhook")
+	defer r.s.mu.Unlock()
+	webhook, ok := r.s.webhooks[quizID]
+	if !ok {
+		return nil, errors.New("webhook not found")
+	}
+	c := *webhook
+	return &c, nil
+}
+
+func (r *fakeWebhookRepo) DeleteQuizWebhook(ctx context.Context, quizID uuid.UUID) error {
+	r.s.lock("DeleteQuizWebhook")
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.webhooks[quizID]; !ok {
+		return errors.New("webhook not found")
+	}
+	delete(r.s.webhooks, quizID)
+	return nil
+}
+
+// fakeAnswerRepo implements repository.AnswerRepository on a fakeStore
+type fakeAnswerRepo struct{ s *fakeStore }
+
+func (r *fakeAnswerRepo) CreateAnswer(ctx context.Context, answer *model.Answer) error {
+	r.s.lock("CreateAnswer")
+	defer r.s.mu.Unlock()
+	// Mirror the UNIQUE(participant_id, question_id) constraint
+	for _, existing := range r.s.answers {
+		if existing.ParticipantID == answer.ParticipantID && existing.QuestionID == answer.QuestionID {
+			return errors.New("duplicate answer")
+		}
+	}
+	r.s.answers[answer.ID] = copyAnswer(answer)
+	return nil
+}
+
+func (r *fakeAnswerRepo) GetAnswersByQuestionID(ctx context.Context, questionID uuid.UUID) ([]*model.Answer, error) {
+	r.s.lock("GetAnswersByQuestionID")
+	defer r.s.mu.Unlock()
+	answers := []*model.Answer{}
+	for _, answer := range r.s.answers {
+		if answer.QuestionID == questionID {
+			answers = append(answers, copyAnswer(answer))
+		}
+	}
+	return answers, nil
+}
+
+func (r *fakeAnswerRepo) GetAnswersByParticipantID(ctx context.Context, participantID uuid.UUID) ([]*model.Answer, error) {
+	r.s.lock("GetAnswersByParticipantID")
+	defer r.s.mu.Unlock()
+	answers := []*model.Answer{}
+	for _, answer := range r.s.answers {
+		if answer.ParticipantID == participantID {
+			answers = append(answers, copyAnswer(answer))
+		}
+	}
+	return answers, nil
+}
+
+func (r *fakeAnswerRepo) GetAnswerByParticipantAndQuestion(ctx context.Context, participantID uuid.UUID, questionID uuid.UUID) (*model.Answer, error) {
+	r.s.lock("GetAnswerByParticipantAndQuestion")
+	defer r.s.mu.Unlock()
+	for _, answer := range r.s.answers {
+		if answer.ParticipantID == participantID && answer.QuestionID == questionID {
+			return copyAnswer(answer), nil
+		}
+	}
+	return nil, errors.New("answer not found")
+}
+
+func (r *fakeAnswerRepo) GetAnswerByClientToken(ctx context.Context, participantID uuid.UUID, questionID uuid.UUID, clientToken string) (*model.Answer, error) {
+	r.s.lock("GetAnswerByClientToken")
+	defer r.s.mu.Unlock()
+	for _, answer := range r.s.answers {
+		if answer.ParticipantID == participantID && answer.QuestionID == questionID && answer.ClientToken == clientToken {
+			return copyAnswer(answer), nil
+		}
+	}
+	return nil, errors.New("answer not found")
+}
+
+func (r *fakeAnswerRepo) CountAnswersByQuestionID(ctx context.Context, questionID uuid.UUID) (int, error) {
+	r.s.lock("CountAnswersByQuestionID")
+	defer r.s.mu.Unlock()
+	count := 0
+	for _, answer := range r.s.answers {
+		if answer.QuestionID == questionID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeAnswerRepo) CountAnswersByQuizID(ctx context.Context, quizID uuid.UUID) (int, error) {
+	r.s.lock("CountAnswersByQuizID")
+	defer r.s.mu.Unlock()
+	count := 0
+	for _, answer := range r.s.answers {
+		if question, ok := r.s.questions[answer.QuestionID]; ok && question.QuizID == quizID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// fakeStateRepo implements repository.StateRepository on a fakeStore
+type fakeStateRepo struct{ s *fakeStore }
+
+func (r *fakeStateRepo) StoreEvent(ctx context.Context, event *model.QuizEvent) error {
+	r.s.lock("StoreEvent")
+	defer r.s.mu.Unlock()
+	c := *event
+	c.ID = int64(len(r.s.events) + 1)
+	c.Payload = append([]byte(nil), event.Payload...)
+	r.s.events = append(r.s.events, &c)
+	return nil
+}
+
+func (r *fakeStateRepo) GetMissedEvents(ctx context.Context, quizID uuid.UUID, lastSequence int64, limit int) ([]*model.QuizEvent, error) {
+	r.s.lock("GetMissedEvents")
+	defer r.s.mu.Unlock()
+	events := []*model.QuizEvent{}
+	for _, event := range r.quizEvents(quizID) {
+		if event.SequenceNumber > lastSequence && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *fakeStateRepo) GetEventsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.QuizEvent, error) {
+	r.s.lock("GetEventsByQuizID")
+	defer r.s.mu.Unlock()
+	return r.quizEvents(quizID), nil
+}
+
+// quizEvents returns the stored events of a quiz in sequence order. Callers must hold the lock.
+func (r *fakeStateRepo) quizEvents(quizID uuid.UUID) []*model.QuizEvent {
+	events := []*model.QuizEvent{}
+	for _, event := range r.s.events {
+		if event.QuizID == quizID {
+			c := *event
+			events = append(events, &c)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].SequenceNumber < events[j].SequenceNumber
+	})
+	return events
+}
+
+func (r *fakeStateRepo) UpdateParticipantConnection(ctx context.Context, conn *model.ParticipantConnection) error {
+	r.s.lock("UpdateParticipantConnection")
+	defer r.s.mu.Unlock()
+	c := *conn
+	r.s.connections[[2]uuid.UUID{conn.ParticipantID, conn.QuizID}] = &c
+	return nil
+}
+
+func (r *fakeStateRepo) GetParticipantConnection(ctx context.Context, participantID, quizID uuid.UUID) (*model.ParticipantConnection, error) {
+	r.s.lock("GetParticipantConnection")
+	defer r.s.mu.Unlock()
+	conn, ok := r.s.connections[[2]uuid.UUID{participantID, quizID}]
+	if !ok {
+		return nil, repository.ErrParticipantConnectionNotFound
+	}
+	c := *conn
+	return &c, nil
+}
+
+func (r *fakeStateRepo) GetActiveParticipantConnections(ctx context.Context, quizID uuid.UUID, cutoffTime time.Time) ([]*model.ParticipantConnection, error) {
+	r.s.lock("GetActiveParticipantConnections")
+	defer r.s.mu.Unlock()
+	connections := []*model.ParticipantConnection{}
+	for key, conn := range r.s.connections {
+		if key[1] == quizID && conn.IsConnected && conn.LastSeen.After(cutoffTime) {
+			c := *conn
+			connections = append(connections, &c)
+		}
+	}
+	return connections, nil
+}
+
+func (r *fakeStateRepo) RegisterInstance(ctx context.Context, instance *model.ServerInstance) error {
+	r.s.lock("RegisterInstance")
+	defer r.s.mu.Unlock()
+	c := *instance
+	r.s.instances[instance.InstanceID] = &c
+	return nil
+}
+
+func (r *fakeStateRepo) UpdateInstanceHeartbeat(ctx context.Context, instanceID string) error {
+	r.s.lock("UpdateInstanceHeartbeat")
+	defer r.s.mu.Unlock()
+	instance, ok := r.s.instances[instanceID]
+	if !ok {
+		return errors.New("instance not found")
+	}
+	instance.LastHeartbeat = time.Now()
+	return nil
+}
+
+func (r *fakeStateRepo) GetActiveInstances(ctx context.Context, cutoffTime time.Time) ([]*model.ServerInstance, error) {
+	r.s.lock("GetActiveInstances")
+	defer r.s.mu.Unlock()
+	instances := []*model.ServerInstance{}
+	for _, instance := range r.s.instances {
+		if instance.LastHeartbeat.After(cutoffTime) {
+			c := *instance
+			instances = append(instances, &c)
+		}
+	}
+	return instances, nil
+}
+
+func (r *fakeStateRepo) IncrementSequenceNumber(ctx context.Context, quizID uuid.UUID) (int64, error) {
+	r.s.lock("IncrementSequenceNumber")
+	defer r.s.mu.Unlock()
+	r.s.sequences[quizID]++
+	return r.s.sequences[quizID], nil
+}
+
+// Audiences a fakeHub records events for
+const (
+	hubAudienceQuiz         = "quiz"
+	hubAudienceCreators     = "creators"
+	hubAudienceParticipants = "participants"
+)
+
+// sentEvent is an event a service handed to the fakeHub
+type sentEvent struct {
+	QuizID    uuid.UUID
+	Audience  string
+	Published bool // false when broadcast to this instance's clients only
+	Event     websocket.Event
+}
+
+// fakeHub implements EventHub by recording every event instead of delivering it
+type fakeHub struct {
+	mu           sync.Mutex
+	sent         []sentEvent
+	disconnected []disconnectCall
+	closed       []uuid.UUID
+	replaced     []uuid.UUID
+	acksStarted  int
+	timers       int
+	// deadlines are the end times the question timers counted down to
+	deadlines []time.Time
+
+	// publishErr fails every Publish call, as a Redis outage outlasting the retries would
+	publishErr error
+
+	// countdownCompletes is returned by StartCountdownBroadcast
+	countdownCompletes bool
+}
+
+// disconnectCall is a DisconnectUser call the fakeHub received
+type disconnectCall struct {
+	QuizID uuid.UUID
+	UserID uuid.UUID
+	Reason model.DisconnectReason
+}
+
+func newFakeHub() *fakeHub {
+	return &fakeHub{}
+}
+
+func (h *fakeHub) record(quizID uuid.UUID, audience string, published bool, event websocket.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sent = append(h.sent, sentEvent{QuizID: quizID, Audience: audience, Published: published, Event: event})
+}
+
+func (h *fakeHub) BroadcastToQuiz(quizID uuid.UUID, event websocket.Event) {
+	h.record(quizID, hubAudienceQuiz, false, event)
+}
+
+func (h *fakeHub) BroadcastToCreators(quizID uuid.UUID, event websocket.Event) {
+	h.record(quizID, hubAudienceCreators, false, event)
+}
+
+func (h *fakeHub) BroadcastToParticipants(quizID uuid.UUID, event websocket.Event) {
+	h.record(quizID, hubAudienceParticipants, false, event)
+}
+
+// publish records a published event unless publishing is set to fail
+func (h *fakeHub) publish(quizID uuid.UUID, audience string, event websocket.Event) error {
+	h.mu.Lock()
+	err := h.publishErr
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	h.record(quizID, audience, true, event)
+	return nil
+}
+
+func (h *fakeHub) PublishToQuiz(quizID uuid.UUID, event websocket.Event) error {
+	return h.publish(quizID, hubAudienceQuiz, event)
+}
+
+func (h *fakeHub) PublishToCreators(quizID uuid.UUID, event websocket.Event) error {
+	return h.publish(quizID, hubAudienceCreators, event)
+}
+
+func (h *fakeHub) PublishToParticipants(quizID uuid.UUID, event websocket.Event) error {
+	return h.publish(quizID, hubAudienceParticipants, event)
+}
+
+func (h *fakeHub) CloseQuiz(quizID uuid.UUID) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = append(h.closed, quizID)
+	return nil
+}
+
+func (h *fakeHub) DisconnectUser(quizID uuid.UUID, userID uuid.UUID, reason model.DisconnectReason) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.disconnected = append(h.disconnected, disconnectCall{QuizID: quizID, UserID: userID, Reason: reason})
+}
+
+func (h *fakeHub) ReplaceConnection(quizID uuid.UUID, participantID uuid.UUID) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.replaced = append(h.replaced, participantID)
+	return nil
+}
+
+func (h *fakeHub) KickParticipant(quizID uuid.UUID, participantID uuid.UUID, event websocket.Event) error {
+	h.record(quizID, hubAudienceQuiz, true, event)
+	h.DisconnectUser(quizID, participantID, model.DisconnectReasonKicked)
+	return nil
+}
+
+func (h *fakeHub) StartQuestionAcks(quizID uuid.UUID, questionID uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.acksStarted++
+}
+
+func (h *fakeHub) ClearQuestionAcks(quizID uuid.UUID) {}
+
+// StartTimerBroadcastUntil ticks nothing; it only waits like the real timer would
+func (h *fakeHub) StartTimerBroadcastUntil(ctx context.Context, quizID uuid.UUID, endTime time.Time, totalSeconds int) {
+	h.mu.Lock()
+	h.timers++
+	h.deadlines = append(h.deadlines, endTime)
+	h.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Until(endTime)):
+	}
+}
+
+func (h *fakeHub) StartCountdownBroadcast(ctx context.Context, quizID uuid.UUID, seconds int) bool {
+	return h.countdownCompletes
+}
+
+// events returns the events of the given type handed to the hub, in order
+func (h *fakeHub) events(eventType websocket.EventType) []sentEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var events []sentEvent
+	for _, sent := range h.sent {
+		if sent.Event.Type == eventType {
+			events = append(events, sent)
+		}
+	}
+	return events
+}
+
+// reachesParticipants reports whether an event handed to the hub is delivered to participant clients
+func (e sentEvent) reachesParticipants() bool {
+	return e.Audience != hubAudienceCreators
+}
+
+// payload returns the event's payload as a map, or nil if it is not one
+func (e sentEvent) payload() map[string]interface{} {
+	payload, _ := e.Event.Payload.(map[string]interface{})
+	return payload
+}
+
+// fakeWebhookNotifier implements WebhookNotifier by recording the events it is asked to deliver
+type fakeWebhookNotifier struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (n *fakeWebhookNotifier) Notify(quizID uuid.UUID, eventType string, payload interface{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, eventType)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Errors
+var (
+	ErrIntegrityDisabled = errors.New("integrity analytics are disabled")
+)
+
+// integrityServiceImpl implements IntegrityService interface
+type integrityServiceImpl struct {
+	quizRepo     repository.QuizRepository
+	questionRepo repository.QuestionRepository
+	answerRepo   repository.AnswerRepository
+	config       config.IntegrityConfig
+}
+
+// NewIntegrityService creates a new integrity service
+func NewIntegrityService(
+	quizRepo repository.QuizRepository,
+	questionRepo repository.QuestionRepository,
+	answerRepo repository.AnswerRepository,
+	cfg config.IntegrityConfig,
+) IntegrityService {
+	return &integrityServiceImpl{
+		quizRepo:     quizRepo,
+		questionRepo: questionRepo,
+		answerRepo:   answerRepo,
+		config:       cfg,
+	}
+}
+
+// GetIntegrityReport finds clusters of identical answers submitted within the configured window.
+// The report is a heuristic and never affects scoring.
+func (s *integrityServiceImpl) GetIntegrityReport(ctx context.Context, quizID uuid.UUID) (*dto.IntegrityReportDTO, error) {
+	if !s.config.Enabled {
+		return nil, ErrIntegrityDisabled
+	}
+
+	if _, err := s.quizRepo.GetQuizByID(ctx, quizID); err != nil {
+		return nil, ErrQuizNotFound
+	}
+
+	questions, err := s.questionRepo.GetQuestionsByQuizID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	window := time.Duration(s.config.ClusterWindowMs) * time.Millisecond
+	report := &dto.IntegrityReportDTO{
+		QuizID:         quizID,
+		WindowMs:       s.config.ClusterWindowMs,
+		MinClusterSize: s.config.MinClusterSize,
+		Questions:      []dto.QuestionIntegrityDTO{},
+	}
+
+	for _, question := range questions {
+		answers, err := s.answerRepo.GetAnswersByQuestionID(ctx, question.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		clusters := findSubmissionClusters(answers, window, s.config.MinClusterSize)
+		report.Questions = append(report.Questions, dto.QuestionIntegrityDTO{
+			QuestionID:   question.ID,
+			TotalAnswers: len(answers),
+			Flagged:      len(clusters) > 0,
+			Clusters:     clusters,
+		})
+		if len(clusters) > 0 {
+			report.Flagged = true
+		}
+	}
+
+	return report, nil
+}
+
+// findSubmissionClusters groups answers by their selected options and returns every run of at least
+// minSize identical answers whose submissions all fall within window of the run's first answer
+func findSubmissionClusters(answers []*model.Answer, window time.Duration, minSize int) []dto.SubmissionClusterDTO {
+	clusters := []dto.SubmissionClusterDTO{}
+	if minSize < 2 {
+		minSize = 2
+	}
+
+	// Group answers with the same selection, ignoring option order
+	groups := make(map[string][]*model.Answer)
+	var keys []string
+	for _, answer := range answers {
+		selected, err := answer.GetSelectedOptions()
+		if err != nil {
+			continue
+		}
+		sorted := append([]string(nil), selected...)
+		sort.Strings(sorted)
+		key := strings.Join(sorted, ",")
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], answer)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		group := groups[key]
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].AnsweredAt.Before(group[j].AnsweredAt)
+		})
+
+		for start := 0; start < len(group); {
+			end := start + 1
+			for end < len(group) && group[end].AnsweredAt.Sub(group[start].AnsweredAt) <= window {
+				end++
+			}
+
+			if end-start < minSize {
+				start++
+				continue
+			}
+
+			run := group[start:end]
+			participantIDs := make([]uuid.UUID, len(run))
+			for i, answer := range run {
+				participantIDs[i] = answer.ParticipantID
+			}
+			clusters = append(clusters, dto.SubmissionClusterDTO{
+				SelectedOptions: strings.Split(key, ","),
+				ParticipantIDs:  participantIDs,
+				FirstAnsweredAt: run[0].AnsweredAt,
+				SpreadMs:        run[len(run)-1].AnsweredAt.Sub(run[0].AnsweredAt).Milliseconds(),
+			})
+			start = end
+		}
+	}
+
+	return clusters
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+)
+
+// testIntegrityConfig flags three identical answers submitted within 50ms
+func testIntegrityConfig() config.IntegrityConfig {
+	return config.IntegrityConfig{Enabled: true, ClusterWindowMs: 50, MinClusterSize: 3}
+}
+
+// seedAnswersAt stores one answer per offset from base, each by a new participant selecting option
+func (e *testEnv) seedAnswersAt(t *testing.T, quiz *model.Quiz, question *model.Question, option string, base time.Time, offsets ...time.Duration) {
+	t.Helper()
+
+	for _, offset := range offsets {
+		answer, err := model.NewAnswer(e.seedParticipant(t, quiz, "Player").ID, question.ID, []string{option}, 1, false)
+		if err != nil {
+			t.Fatalf("NewAnswer: %v", err)
+		}
+		answer.AnsweredAt = base.Add(offset)
+		if err := e.answerRepo.CreateAnswer(context.Background(), answer); err != nil {
+			t.Fatalf("CreateAnswer: %v", err)
+		}
+	}
+}
+
+func TestIntegrityReportFlagsClusteredIdenticalAnswers(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	clustered := env.seedQuestion(t, quiz, 1)
+	spread := env.seedQuestion(t, quiz, 2)
+	base := time.Now()
+
+	// Four identical answers within 30ms, and one submitted well after them
+	env.seedAnswersAt(t, quiz, clustered, correctOption(clustered), base, 0, 10*time.Millisecond, 20*time.Millisecond, 30*time.Millisecond, time.Second)
+	// Identical answers a second apart, and a burst of different answers
+	env.seedAnswersAt(t, quiz, spread, correctOption(spread), base, 0, time.Second, 2*time.Second, 3*time.Second)
+	env.seedAnswersAt(t, quiz, spread, wrongOption(spread), base, 5*time.Millisecond)
+
+	report, err := NewIntegrityService(env.quizRepo, env.questionRepo, env.answerRepo, testIntegrityConfig()).GetIntegrityReport(context.Background(), quiz.ID)
+	if err != nil {
+		t.Fatalf("GetIntegrityReport: %v", err)
+	}
+	if !report.Flagged || len(report.Questions) != 2 {
+		t.Fatalf("report flagged %v with %d questions, want a flagged report of both", report.Flagged, len(report.Questions))
+	}
+
+	for _, question := range report.Questions {
+		switch question.QuestionID {
+		case clustered.ID:
+			if !question.Flagged || len(question.Clusters) != 1 {
+				t.Fatalf("clustered question flagged %v with %d clusters, want one", question.Flagged, len(question.Clusters))
+			}
+			cluster := question.Clusters[0]
+			if len(cluster.ParticipantIDs) != 4 || cluster.SpreadMs != 30 {
+				t.Errorf("cluster has %d participants over %dms, want 4 over 30ms", len(cluster.ParticipantIDs), cluster.SpreadMs)
+			}
+		case spread.ID:
+			if question.Flagged || len(question.Clusters) != 0 {
+				t.Errorf("spread question flagged %v with %d clusters", question.Flagged, len(question.Clusters))
+			}
+		}
+	}
+}
+
+func TestIntegrityReportIsOptIn(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+
+	integrity := NewIntegrityService(env.quizRepo, env.questionRepo, env.answerRepo, config.IntegrityConfig{})
+	if _, err := integrity.GetIntegrityReport(context.Background(), quiz.ID); !errors.Is(err, ErrIntegrityDisabled) {
+		t.Errorf("disabled report returned %v, want ErrIntegrityDisabled", err)
+	}
+	if got := env.store.callCount("GetAnswersByQuestionID"); got != 0 {
+		t.Errorf("disabled report read answers %d times", got)
+	}
+}
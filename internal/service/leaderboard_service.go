@@ -11,30 +11,39 @@ import (
 
 // leaderboardServiceImpl implements LeaderboardService interface
 type leaderboardServiceImpl struct {
-	participantRepo repository.ParticipantRepository
-	wsHub           *websocket.RedisHub
+	participantRepo        repository.ParticipantRepository
+	quizRepo               repository.QuizRepository
+	teamLeaderboardService TeamLeaderboardService
+	wsHub                  EventHub
 }
 
 // NewLeaderboardService creates a new leaderboard service
 func NewLeaderboardService(
 	participantRepo repository.ParticipantRepository,
-	wsHub *websocket.RedisHub,
+	quizRepo repository.QuizRepository,
+	teamLeaderboardService TeamLeaderboardService,
+	wsHub EventHub,
 ) LeaderboardService {
 	return &leaderboardServiceImpl{
-		participantRepo: participantRepo,
-		wsHub:           wsHub,
+		participantRepo:        participantRepo,
+		quizRepo:               quizRepo,
+		teamLeaderboardService: teamLeaderboardService,
+		wsHub:                  wsHub,
 	}
 }
 
-// GetLeaderboard retrieves the top participants by score
-func (s *leaderboardServiceImpl) GetLeaderboard(ctx context.Context, quizID uuid.UUID, limit int) ([]*model.Participant, error) {
+// GetLeaderboard retrieves a page of participants ranked by score
+func (s *leaderboardServiceImpl) GetLeaderboard(ctx context.Context, quizID uuid.UUID, limit int, offset int) ([]*model.Participant, error) {
 	// If limit is not specified or is invalid, set a default
 	if limit <= 0 {
 		limit = 10
 	}
+	if offset < 0 {
+		offset = 0
+	}
 
 	// Get participants sorted by score
-	participants, err := s.participantRepo.GetLeaderboard(ctx, quizID, limit)
+	participants, err := s.participantRepo.GetLeaderboard(ctx, quizID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -42,7 +51,13 @@ func (s *leaderboardServiceImpl) GetLeaderboard(ctx context.Context, quizID uuid
 	return participants, nil
 }
 
-// UpdateParticipantScore updates a participant's total score and broadcasts the updated leaderboard
+// CountParticipants counts the participants ranked on a quiz's leaderboard
+func (s *leaderboardServiceImpl) CountParticipants(ctx context.Context, quizID uuid.UUID) (int, error) {
+	return s.participantRepo.CountParticipantsByQuizID(ctx, quizID)
+}
+
+// UpdateParticipantScore updates a participant's total score and broadcasts the updated leaderboard.
+// While the leaderboard is frozen the score is still stored, only the broadcast is held back.
 func (s *leaderboardServiceImpl) UpdateParticipantScore(ctx context.Context, participantID uuid.UUID, additionalScore int) error {
 	// Update the participant's score
 	if err := s.participantRepo.UpdateParticipantScore(ctx, participantID, additionalScore); err != nil {
@@ -55,30 +70,52 @@ func (s *leaderboardServiceImpl) UpdateParticipantScore(ctx context.Context, par
 		return err
 	}
 
+	return s.BroadcastLeaderboard(ctx, participant.QuizID)
+}
+
+// RecomputeScore recalculates a participant's total score from their answers, which are the source of truth
+func (s *leaderboardServiceImpl) RecomputeScore(ctx context.Context, participantID uuid.UUID) (int, error) {
+	return s.participantRepo.RecomputeParticipantScore(ctx, participantID)
+}
+
+// BroadcastLeaderboard sends the current top of the leaderboard to all clients in the quiz,
+// unless the creator froze the leaderboard for a reveal
+func (s *leaderboardServiceImpl) BroadcastLeaderboard(ctx context.Context, quizID uuid.UUID) error {
+	if session, err := s.quizRepo.GetQuizSession(ctx, quizID); err == nil && session.LeaderboardFrozen {
+		return nil
+	}
+
 	// Get the updated leaderboard
-	leaderboard, err := s.GetLeaderboard(ctx, participant.QuizID, 10)
+	leaderboard, err := s.GetLeaderboard(ctx, quizID, 10, 0)
 	if err != nil {
 		return err
 	}
 
 	// Prepare leaderboard data for broadcasting
 	var leaderboardData []map[string]interface{}
-	for i, participant := range leaderboard {
+	for _, participant := range leaderboard {
 		leaderboardData = append(leaderboardData, map[string]interface{}{
-			"rank":  i + 1,
+			"rank":  participant.Rank,
 			"id":    participant.ID.String(),
 			"name":  participant.Name,
 			"score": participant.Score,
 		})
 	}
 
+	payload := map[string]interface{}{
+		"leaderboard": leaderboardData,
+	}
+
+	// Include team standings when the quiz is played in teams
+	if teams, err := s.teamLeaderboardService.GetTeamLeaderboard(ctx, quizID); err == nil && len(teams) > 0 {
+		payload["teams"] = teams
+	}
+
 	// Broadcast updated leaderboard to all clients in the quiz
-	s.wsHub.BroadcastToQuiz(participant.QuizID, websocket.Event{
-		Type: websocket.EventLeaderboardUpdate,
-		Payload: map[string]interface{}{
-			"leaderboard": leaderboardData,
-		},
+	s.wsHub.BroadcastToQuiz(quizID, websocket.Event{
+		Type:    websocket.EventLeaderboardUpdate,
+		Payload: payload,
 	})
 
 	return nil
-}
\ No newline at end of file
+}
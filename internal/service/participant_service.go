@@ -3,38 +3,72 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/repository"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/auth"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
 	"github.com/google/uuid"
 )
 
+// Errors
+var (
+	ErrParticipantBanned = errors.New("you have been banned from this quiz")
+	ErrInvalidName       = errors.New("name must not be blank")
+	ErrQuizFull          = errors.New("quiz is full")
+)
+
 // participantServiceImpl implements ParticipantService interface
 type participantServiceImpl struct {
 	participantRepo repository.ParticipantRepository
 	quizRepo        repository.QuizRepository
-	wsHub           *websocket.RedisHub
+	teamRepo        repository.TeamRepository
+	wsHub           EventHub
+	stateCache      QuizStateCache
+	jwtManager      *auth.JWTManager
+	reconnectGrace  time.Duration
+	collapseNames   bool
 }
 
 // NewParticipantService creates a new participant service
 func NewParticipantService(
 	participantRepo repository.ParticipantRepository,
 	quizRepo repository.QuizRepository,
-	wsHub *websocket.RedisHub,
+	teamRepo repository.TeamRepository,
+	wsHub EventHub,
+	stateCache QuizStateCache,
+	jwtManager *auth.JWTManager,
+	cfg config.QuizConfig,
 ) ParticipantService {
 	return &participantServiceImpl{
 		participantRepo: participantRepo,
 		quizRepo:        quizRepo,
+		teamRepo:        teamRepo,
 		wsHub:           wsHub,
+		stateCache:      stateCache,
+		jwtManager:      jwtManager,
+		reconnectGrace:  cfg.ReconnectGracePeriod,
+		collapseNames:   cfg.CollapseNameWhitespace,
+	}
+}
+
+// normalizeName trims a participant name and, when configured, collapses runs of whitespace inside it
+func (s *participantServiceImpl) normalizeName(name string) string {
+	if s.collapseNames {
+		return strings.Join(strings.Fields(name), " ")
 	}
+	return strings.TrimSpace(name)
 }
 
 // JoinQuiz allows a user to join a quiz as a participant
-func (s *participantServiceImpl) JoinQuiz(ctx context.Context, quizID uuid.UUID, name string) (*model.Participant, error) {
+func (s *participantServiceImpl) JoinQuiz(ctx context.Context, quizID uuid.UUID, name string, teamID *uuid.UUID) (*model.Participant, error) {
 	// Validate inputs
+	name = s.normalizeName(name)
 	if name == "" {
-		return nil, errors.New("name is required")
+		return nil, ErrInvalidName
 	}
 
 	// Check if quiz exists and is in waiting state
@@ -43,29 +77,58 @@ func (s *participantServiceImpl) JoinQuiz(ctx context.Context, quizID uuid.UUID,
 		return nil, errors.New("quiz not found")
 	}
 
-	if quiz.Status != model.QuizStatusWaiting {
+	if quiz.Status == model.QuizStatusCompleted {
+		return nil, errors.New("cannot join a quiz that has already ended")
+	}
+
+	if quiz.Status != model.QuizStatusWaiting && !quiz.Settings.AllowLateJoin {
 		return nil, errors.New("cannot join a quiz that has already started")
 	}
 
+	// Banned participants may not come back under the same name
+	banned, err := s.participantRepo.IsNameBanned(ctx, quizID, name)
+	if err != nil {
+		return nil, err
+	}
+	if banned {
+		return nil, ErrParticipantBanned
+	}
+
 	// Check if name is already taken in this quiz
 	participants, err := s.participantRepo.GetParticipantsByQuizID(ctx, quizID)
 	if err != nil {
 		return nil, err
 	}
 
+	// Names stored before normalization are compared the same way as the new one, ignoring case
 	for _, p := range participants {
-		if p.Name == name {
+		if strings.EqualFold(s.normalizeName(p.Name), name) {
 			return nil, errors.New("name is already taken in this quiz")
 		}
 	}
 
+	// Validate the team if one was chosen; participants without a team play solo
+	if teamID != nil {
+		team, err := s.teamRepo.GetTeamByID(ctx, *teamID)
+		if err != nil || team.QuizID != quizID {
+			return nil, ErrTeamNotFound
+		}
+	}
+
 	// Create new participant
 	participant := model.NewParticipant(name, quizID)
+	participant.TeamID = teamID
 
-	// Save to repository
-	if err := s.participantRepo.CreateParticipant(ctx, participant); err != nil {
+	// The cap is checked as the participant is saved so concurrent joins cannot overshoot it.
+	// Participants who dropped within the grace window keep their slot so they can reconnect.
+	err = s.participantRepo.CreateParticipantWithinLimit(ctx, participant, quiz.Settings.MaxParticipants, time.Now().Add(-s.reconnectGrace))
+	if errors.Is(err, repository.ErrQuizFull) {
+		return nil, ErrQuizFull
+	}
+	if err != nil {
 		return nil, err
 	}
+	s.stateCache.InvalidateQuizState(quizID)
 
 	// Broadcast participant joined event
 	s.wsHub.BroadcastToQuiz(quizID, websocket.Event{
@@ -73,6 +136,7 @@ func (s *participantServiceImpl) JoinQuiz(ctx context.Context, quizID uuid.UUID,
 		Payload: map[string]interface{}{
 			"participantId": participant.ID.String(),
 			"name":          participant.Name,
+			"teamId":        participant.TeamID,
 		},
 	})
 
@@ -80,7 +144,7 @@ func (s *participantServiceImpl) JoinQuiz(ctx context.Context, quizID uuid.UUID,
 }
 
 // JoinQuizByCode allows a user to join a quiz using its code
-func (s *participantServiceImpl) JoinQuizByCode(ctx context.Context, code string, name string) (*model.Participant, error) {
+func (s *participantServiceImpl) JoinQuizByCode(ctx context.Context, code string, name string, teamID *uuid.UUID) (*model.Participant, error) {
 	// Check if quiz exists and is in waiting state
 	quiz, err := s.quizRepo.GetQuizByCode(ctx, code)
 	if err != nil {
@@ -88,7 +152,7 @@ func (s *participantServiceImpl) JoinQuizByCode(ctx context.Context, code string
 	}
 
 	// Use the existing JoinQuiz functionality with the retrieved quiz ID
-	return s.JoinQuiz(ctx, quiz.ID, name)
+	return s.JoinQuiz(ctx, quiz.ID, name, teamID)
 }
 
 // GetParticipantByID retrieves a participant by ID
@@ -111,6 +175,11 @@ func (s *participantServiceImpl) GetParticipantsByQuizID(ctx context.Context, qu
 	return participants, nil
 }
 
+// CountParticipantsByQuizID counts the participants of a quiz without loading them
+func (s *participantServiceImpl) CountParticipantsByQuizID(ctx context.Context, quizID uuid.UUID) (int, error) {
+	return s.participantRepo.CountParticipantsByQuizID(ctx, quizID)
+}
+
 // RemoveParticipant removes a participant from a quiz
 func (s *participantServiceImpl) RemoveParticipant(ctx context.Context, id uuid.UUID) error {
 	// First get the participant to broadcast the removal event
@@ -123,6 +192,10 @@ func (s *participantServiceImpl) RemoveParticipant(ctx context.Context, id uuid.
 	if err := s.participantRepo.DeleteParticipant(ctx, id); err != nil {
 		return err
 	}
+	s.stateCache.InvalidateQuizState(participant.QuizID)
+
+	// Close any open connection of the removed participant
+	s.wsHub.DisconnectUser(participant.QuizID, id, model.DisconnectReasonKicked)
 
 	// Broadcast participant left event
 	s.wsHub.BroadcastToQuiz(participant.QuizID, websocket.Event{
@@ -130,8 +203,67 @@ func (s *participantServiceImpl) RemoveParticipant(ctx context.Context, id uuid.
 		Payload: map[string]interface{}{
 			"participantId": id.String(),
 			"name":          participant.Name,
+			"reason":        string(model.DisconnectReasonKicked),
 		},
 	})
 
 	return nil
 }
+
+// KickParticipant removes a participant at any point of a quiz, closing their connection and,
+// when ban is set, keeping them from rejoining under the same name
+func (s *participantServiceImpl) KickParticipant(ctx context.Context, id uuid.UUID, ban bool) error {
+	participant, err := s.participantRepo.GetParticipantByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if ban {
+		// Names stored before normalization are banned the way a rejoin would be compared
+		quizBan := model.NewQuizBan(participant)
+		quizBan.Name = s.normalizeName(participant.Name)
+		err = s.participantRepo.BanParticipant(ctx, quizBan)
+	} else {
+		err = s.participantRepo.DeleteParticipant(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+	s.stateCache.InvalidateQuizState(participant.QuizID)
+
+	// Tell the room on every instance, then close the participant's connections wherever they are held.
+	// Clients on this instance are kicked even if the event cannot reach the other instances.
+	s.wsHub.KickParticipant(participant.QuizID, id, websocket.NewEvent(websocket.EventUserKicked, map[string]interface{}{
+		"participantId": id.String(),
+		"name":          participant.Name,
+		"banned":        ban,
+	}))
+
+	return nil
+}
+
+// IssueToken creates the token a participant presents to open WebSocket connections to their quiz
+func (s *participantServiceImpl) IssueToken(participant *model.Participant) (string, error) {
+	token, err := s.jwtManager.GenerateParticipantToken(participant.ID, participant.QuizID)
+	if err != nil {
+		return "", errors.New("failed to generate participant token")
+	}
+
+	return token, nil
+}
+
+// IssueSpectatorToken creates the ID and token a spectator display presents to watch a quiz.
+// Spectators are not stored; the token alone ties the display to the quiz.
+func (s *participantServiceImpl) IssueSpectatorToken(ctx context.Context, quizID uuid.UUID) (uuid.UUID, string, error) {
+	if _, err := s.quizRepo.GetQuizByID(ctx, quizID); err != nil {
+		return uuid.Nil, "", ErrQuizNotFound
+	}
+
+	spectatorID := uuid.New()
+	token, err := s.jwtManager.GenerateSpectatorToken(spectatorID, quizID)
+	if err != nil {
+		return uuid.Nil, "", errors.New("failed to generate spectator token")
+	}
+
+	return spectatorID, token, nil
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
+)
+
+// disconnectReasons returns the reasons the hub was asked to close a participant's connections with
+func (h *fakeHub) disconnectReasons(participant *model.Participant) []model.DisconnectReason {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var reasons []model.DisconnectReason
+	for _, call := range h.disconnected {
+		if call.UserID == participant.ID {
+			reasons = append(reasons, call.Reason)
+		}
+	}
+	return reasons
+}
+
+func TestDisconnectReasonIsStoredAndAnnounced(t *testing.T) {
+	reasons := []model.DisconnectReason{
+		model.DisconnectReasonClosed,
+		model.DisconnectReasonError,
+		model.DisconnectReasonTimeout,
+	}
+
+	for _, reason := range reasons {
+		t.Run(string(reason), func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+			participant := env.seedParticipant(t, quiz, "Player")
+
+			connectedAt := time.Now()
+			if err := env.state.UpdateParticipantConnection(ctx, participant.ID, quiz.ID, true, "instance-1", "", connectedAt); err != nil {
+				t.Fatalf("connect: %v", err)
+			}
+			if err := env.state.UpdateParticipantConnection(ctx, participant.ID, quiz.ID, false, "instance-1", reason, connectedAt); err != nil {
+				t.Fatalf("disconnect: %v", err)
+			}
+
+			conn, err := env.stateRepo.GetParticipantConnection(ctx, participant.ID, quiz.ID)
+			if err != nil {
+				t.Fatalf("GetParticipantConnection: %v", err)
+			}
+			if conn.IsConnected || conn.DisconnectReason != reason {
+				t.Errorf("stored connection: connected=%t reason=%q, want disconnected with %q", conn.IsConnected, conn.DisconnectReason, reason)
+			}
+
+			left := env.hub.events(websocket.EventUserLeft)
+			if len(left) != 1 {
+				t.Fatalf("got %d USER_LEFT events, want 1", len(left))
+			}
+			if got := left[0].payload()["reason"]; got != string(reason) {
+				t.Errorf("USER_LEFT reason = %v, want %s", got, reason)
+			}
+		})
+	}
+}
+
+func TestRemoveParticipantAnnouncesKickOnce(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	participant := env.seedParticipant(t, quiz, "Player")
+
+	if err := env.participants.RemoveParticipant(context.Background(), participant.ID); err != nil {
+		t.Fatalf("RemoveParticipant: %v", err)
+	}
+
+	if got := env.hub.disconnectReasons(participant); len(got) != 1 || got[0] != model.DisconnectReasonKicked {
+		t.Errorf("connections closed with %v, want [%s]", got, model.DisconnectReasonKicked)
+	}
+
+	left := env.hub.events(websocket.EventUserLeft)
+	if len(left) != 1 {
+		t.Fatalf("got %d USER_LEFT events, want 1", len(left))
+	}
+	if got := left[0].payload()["reason"]; got != string(model.DisconnectReasonKicked) {
+		t.Errorf("USER_LEFT reason = %v, want %s", got, model.DisconnectReasonKicked)
+	}
+}
+
+func TestKickParticipantClosesConnectionAsKicked(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	participant := env.seedParticipant(t, quiz, "Player")
+
+	if err := env.participants.KickParticipant(context.Background(), participant.ID, false); err != nil {
+		t.Fatalf("KickParticipant: %v", err)
+	}
+
+	if got := env.hub.disconnectReasons(participant); len(got) != 1 || got[0] != model.DisconnectReasonKicked {
+		t.Errorf("connections closed with %v, want [%s]", got, model.DisconnectReasonKicked)
+	}
+	kicked := env.hub.events(websocket.EventUserKicked)
+	if len(kicked) != 1 {
+		t.Fatalf("got %d USER_KICKED events, want 1", len(kicked))
+	}
+	// Only a published kick reaches a participant connected to another instance
+	if !kicked[0].Published {
+		t.Error("USER_KICKED was only broadcast to this instance")
+	}
+	if got := len(env.hub.events(websocket.EventUserLeft)); got != 0 {
+		t.Errorf("kick also sent %d USER_LEFT events", got)
+	}
+}
+
+func TestKickedAndBannedParticipantCannotRejoinInAnotherCase(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	participant := env.seedParticipant(t, quiz, "Bob")
+
+	if err := env.participants.KickParticipant(context.Background(), participant.ID, true); err != nil {
+		t.Fatalf("KickParticipant: %v", err)
+	}
+	if _, err := env.participants.GetParticipantByID(context.Background(), participant.ID); err == nil {
+		t.Error("banned participant is still in the quiz")
+	}
+
+	for _, name := range []string{"Bob", "bob", " BOB "} {
+		if _, err := env.participants.JoinQuiz(context.Background(), quiz.ID, name, nil); !errors.Is(err, ErrParticipantBanned) {
+			t.Errorf("JoinQuiz(%q) after a ban = %v, want %v", name, err, ErrParticipantBanned)
+		}
+	}
+}
+
+func TestKickParticipantWithoutBanLetsTheNameRejoin(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	participant := env.seedParticipant(t, quiz, "Bob")
+
+	if err := env.participants.KickParticipant(context.Background(), participant.ID, false); err != nil {
+		t.Fatalf("KickParticipant: %v", err)
+	}
+	if got := env.store.callCount("BanParticipant"); got != 0 {
+		t.Errorf("kick without a ban recorded %d bans", got)
+	}
+	if _, err := env.participants.JoinQuiz(context.Background(), quiz.ID, "Bob", nil); err != nil {
+		t.Errorf("JoinQuiz after a kick without a ban: %v", err)
+	}
+}
+
+// setConnection stores a participant's connection, last seen the given time ago
+func (e *testEnv) setConnection(t *testing.T, participant *model.Participant, connected bool, lastSeenAgo time.Duration) {
+	t.Helper()
+
+	conn := &model.ParticipantConnection{
+		ParticipantID: participant.ID,
+		QuizID:        participant.QuizID,
+		IsConnected:   connected,
+		LastSeen:      time.Now().Add(-lastSeenAgo),
+		InstanceID:    "instance-1",
+	}
+	if err := e.stateRepo.UpdateParticipantConnection(context.Background(), conn); err != nil {
+		t.Fatalf("set connection: %v", err)
+	}
+}
+
+func TestDisconnectedParticipantKeepsTheirSlotDuringTheGracePeriod(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.QuizConfig) { cfg.ReconnectGracePeriod = time.Minute })
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{MaxParticipants: 2})
+	env.setConnection(t, env.seedParticipant(t, quiz, "Ann"), true, 0)
+	dropped := env.seedParticipant(t, quiz, "Bob")
+
+	// Bob dropped seconds ago, so his slot is reserved
+	env.setConnection(t, dropped, false, 10*time.Second)
+	if _, err := env.participants.JoinQuiz(ctx, quiz.ID, "Cat", nil); !errors.Is(err, ErrQuizFull) {
+		t.Fatalf("newcomer joining while a participant is within the grace period got %v, want ErrQuizFull", err)
+	}
+
+	// Once the grace period has passed the slot is free
+	env.setConnection(t, dropped, false, 2*time.Minute)
+	if _, err := env.participants.JoinQuiz(ctx, quiz.ID, "Cat", nil); err != nil {
+		t.Fatalf("newcomer could not take a slot freed after the grace period: %v", err)
+	}
+
+	// The quiz is full again with Cat counted as a fresh joiner
+	if _, err := env.participants.JoinQuiz(ctx, quiz.ID, "Dan", nil); !errors.Is(err, ErrQuizFull) {
+		t.Errorf("joining a full quiz got %v, want ErrQuizFull", err)
+	}
+}
+
+func TestConcurrentJoinsDoNotOvershootTheParticipantCap(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{MaxParticipants: 3})
+
+	const joiners = 12
+	var wg sync.WaitGroup
+	errs := make(chan error, joiners)
+	for i := 0; i < joiners; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := env.participants.JoinQuiz(context.Background(), quiz.ID, fmt.Sprintf("Player %d", i), nil)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	joined := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			joined++
+		case !errors.Is(err, ErrQuizFull):
+			t.Errorf("join failed with %v, want ErrQuizFull", err)
+		}
+	}
+	if joined != 3 {
+		t.Errorf("%d participants joined a quiz capped at 3", joined)
+	}
+}
+
+func TestParticipantWhoNeverConnectedHoldsTheirSlotForTheGracePeriod(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.QuizConfig) { cfg.ReconnectGracePeriod = time.Minute })
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{MaxParticipants: 1})
+	joined := env.seedParticipant(t, quiz, "Ann")
+
+	if _, err := env.participants.JoinQuiz(ctx, quiz.ID, "Bob", nil); err == nil {
+		t.Fatal("newcomer took the slot of a participant who just joined")
+	}
+
+	env.store.mu.Lock()
+	env.store.participants[joined.ID].JoinedAt = time.Now().Add(-2 * time.Minute)
+	env.store.mu.Unlock()
+	if _, err := env.participants.JoinQuiz(ctx, quiz.ID, "Bob", nil); err != nil {
+		t.Errorf("slot of a participant who never connected was not freed: %v", err)
+	}
+}
+
+func TestIssuedParticipantTokenIdentifiesTheParticipantAndQuiz(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	participant := env.seedParticipant(t, quiz, "Ann")
+
+	token, err := env.participants.IssueToken(participant)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+
+	claims, err := env.jwt.ValidateParticipantToken(token)
+	if err != nil {
+		t.Fatalf("issued token does not validate: %v", err)
+	}
+	if claims.ParticipantID != participant.ID || claims.QuizID != quiz.ID {
+		t.Errorf("token is for participant %s of quiz %s, want %s of %s", claims.ParticipantID, claims.QuizID, participant.ID, quiz.ID)
+	}
+	if _, err := env.jwt.ValidateToken(token); err == nil {
+		t.Error("participant token is accepted as a user token")
+	}
+}
+
+func TestJoinQuizNormalizesNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		collapse bool
+		existing string
+		joining  string
+		wantName string
+		wantErr  error
+		taken    bool
+	}{
+		{name: "empty", collapse: true, joining: "", wantErr: ErrInvalidName},
+		{name: "whitespace only", collapse: true, joining: " \t\n ", wantErr: ErrInvalidName},
+		{name: "surrounding whitespace", collapse: true, joining: "  Ann ", wantName: "Ann"},
+		{name: "surrounding whitespace without collapsing", joining: "  Ann ", wantName: "Ann"},
+		{name: "internal whitespace collapsed", collapse: true, joining: "John \t Doe", wantName: "John Doe"},
+		{name: "internal whitespace kept", joining: "John  Doe", wantName: "John  Doe"},
+		{name: "collides once collapsed", collapse: true, existing: "John Doe", joining: "John  Doe", taken: true},
+		{name: "collides with a stored name once collapsed", collapse: true, existing: "John  Doe", joining: "John Doe", taken: true},
+		{name: "distinct without collapsing", existing: "John Doe", joining: "John  Doe", wantName: "John  Doe"},
+		{name: "collides once trimmed", existing: "Ann", joining: " Ann ", taken: true},
+		{name: "collides ignoring case", collapse: true, existing: "Ann", joining: "ANN", taken: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.QuizConfig) { cfg.CollapseNameWhitespace = tt.collapse })
+			quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+			if tt.existing != "" {
+				env.seedParticipant(t, quiz, tt.existing)
+			}
+
+			participant, err := env.participants.JoinQuiz(context.Background(), quiz.ID, tt.joining, nil)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("JoinQuiz(%q) = %v, want %v", tt.joining, err, tt.wantErr)
+				}
+			case tt.taken:
+				if err == nil || !strings.Contains(err.Error(), "already taken") {
+					t.Errorf("JoinQuiz(%q) next to %q = %v, want the name taken", tt.joining, tt.existing, err)
+				}
+			case err != nil:
+				t.Fatalf("JoinQuiz(%q): %v", tt.joining, err)
+			case participant.Name != tt.wantName:
+				t.Errorf("JoinQuiz(%q) joined as %q, want %q", tt.joining, participant.Name, tt.wantName)
+			}
+		})
+	}
+}
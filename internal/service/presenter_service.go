@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// presenterLeaderboardSize is how many leaders the presenter view includes
+const presenterLeaderboardSize = 10
+
+// presenterServiceImpl implements PresenterService interface
+type presenterServiceImpl struct {
+	quizRepo           repository.QuizRepository
+	stateService       StateService
+	answerService      AnswerService
+	leaderboardService LeaderboardService
+}
+
+// NewPresenterService creates a new presenter service
+func NewPresenterService(
+	quizRepo repository.QuizRepository,
+	stateService StateService,
+	answerService AnswerService,
+	leaderboardService LeaderboardService,
+) PresenterService {
+	return &presenterServiceImpl{
+		quizRepo:           quizRepo,
+		stateService:       stateService,
+		answerService:      answerService,
+		leaderboardService: leaderboardService,
+	}
+}
+
+// GetPresenterView assembles the state, live answer counts and leaderboard of a quiz concurrently.
+// It is meant for the quiz's creator only, as the active question includes its correct options.
+func (s *presenterServiceImpl) GetPresenterView(ctx context.Context, quizID uuid.UUID) (*dto.PresenterViewDTO, error) {
+	session, err := s.quizRepo.GetQuizSession(ctx, quizID)
+	if err != nil {
+		return nil, ErrQuizNotFound
+	}
+
+	var (
+		wg           sync.WaitGroup
+		state        *dto.QuizStateDTO
+		stateErr     error
+		answerCounts map[string]int
+		countsErr    error
+		leaders      []*model.Participant
+		leadersErr   error
+	)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		state, stateErr = s.stateService.GetQuizState(ctx, quizID, true)
+	}()
+	go func() {
+		defer wg.Done()
+		leaders, leadersErr = s.leaderboardService.GetLeaderboard(ctx, quizID, presenterLeaderboardSize, 0)
+	}()
+
+	if session.CurrentQuestionID != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			answerCounts, countsErr = s.answerService.GetAnswerStats(ctx, *session.CurrentQuestionID)
+		}()
+	}
+
+	wg.Wait()
+
+	for _, err := range []error{stateErr, countsErr, leadersErr} {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &dto.PresenterViewDTO{
+		QuizID:           state.QuizID,
+		Title:            state.Title,
+		Status:           state.Status,
+		CurrentPhase:     state.CurrentPhase,
+		ActiveQuestion:   state.ActiveQuestion,
+		Timer:            state.Timer,
+		AnswerCounts:     answerCounts,
+		ParticipantCount: len(state.Participants),
+		ConnectedCount:   state.ActiveCount,
+		Leaderboard:      dto.LeaderboardEntriesFromModel(leaders),
+	}, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+func TestPresenterViewOfAnActiveQuiz(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1, "Right", "Wrong", "Also wrong")
+	ann := env.seedParticipant(t, quiz, "Ann")
+	bob := env.seedParticipant(t, quiz, "Bob")
+	cat := env.seedParticipant(t, quiz, "Cat")
+	for participant, score := range map[*model.Participant]int{ann: 300, bob: 100} {
+		if err := env.participantRepo.UpdateParticipantScore(ctx, participant.ID, score); err != nil {
+			t.Fatalf("set score: %v", err)
+		}
+	}
+	env.setConnection(t, ann, true, 0)
+	env.setConnection(t, bob, true, 0)
+	env.setConnection(t, cat, false, time.Minute)
+	env.runQuestion(t, question, 5*time.Second)
+	env.seedAnswer(t, ann, question, correctOption(question))
+	env.seedAnswer(t, bob, question, wrongOption(question))
+
+	view, err := NewPresenterService(env.quizRepo, env.state, env.answers, env.leaderboard).GetPresenterView(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("GetPresenterView: %v", err)
+	}
+
+	if view.QuizID != quiz.ID || view.Status != string(model.QuizStatusActive) || view.CurrentPhase != model.QuizPhaseQuestionActive {
+		t.Errorf("view is of quiz %s, status %s, phase %s", view.QuizID, view.Status, view.CurrentPhase)
+	}
+
+	if view.ActiveQuestion == nil || view.ActiveQuestion.QuestionID != question.ID {
+		t.Fatalf("view's active question = %+v, want %s", view.ActiveQuestion, question.ID)
+	}
+	for _, option := range view.ActiveQuestion.Options {
+		if want := option.ID.String() == correctOption(question); option.IsCorrect != want {
+			t.Errorf("option %s isCorrect = %v, want %v", option.Text, option.IsCorrect, want)
+		}
+	}
+	if view.Timer == nil {
+		t.Error("view has no timer for the running question")
+	}
+
+	wantCounts := map[string]int{correctOption(question): 1, wrongOption(question): 1}
+	if len(view.AnswerCounts) != len(question.Options) {
+		t.Errorf("view counts answers for %d options, want %d", len(view.AnswerCounts), len(question.Options))
+	}
+	for _, option := range question.Options {
+		if got := view.AnswerCounts[option.ID.String()]; got != wantCounts[option.ID.String()] {
+			t.Errorf("option %s has %d answers, want %d", option.Text, got, wantCounts[option.ID.String()])
+		}
+	}
+
+	if view.ParticipantCount != 3 || view.ConnectedCount != 2 {
+		t.Errorf("view counts %d participants with %d connected, want 3 with 2", view.ParticipantCount, view.ConnectedCount)
+	}
+
+	if len(view.Leaderboard) != 3 {
+		t.Fatalf("leaderboard has %d entries, want 3", len(view.Leaderboard))
+	}
+	if view.Leaderboard[0].ID != ann.ID || view.Leaderboard[1].ID != bob.ID {
+		t.Errorf("leaderboard starts with %s and %s, want Ann then Bob", view.Leaderboard[0].Name, view.Leaderboard[1].Name)
+	}
+}
+
+func TestPresenterViewBetweenQuestions(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	env.seedQuestion(t, quiz, 1)
+	env.seedParticipant(t, quiz, "Ann")
+
+	view, err := NewPresenterService(env.quizRepo, env.state, env.answers, env.leaderboard).GetPresenterView(context.Background(), quiz.ID)
+	if err != nil {
+		t.Fatalf("GetPresenterView: %v", err)
+	}
+	if view.ActiveQuestion != nil || view.AnswerCounts != nil {
+		t.Errorf("view without a running question has question %+v and counts %v", view.ActiveQuestion, view.AnswerCounts)
+	}
+	if view.ParticipantCount != 1 || len(view.Leaderboard) != 1 {
+		t.Errorf("view counts %d participants with %d leaders, want 1 of each", view.ParticipantCount, len(view.Leaderboard))
+	}
+}
+
+func TestPresenterViewOfUnknownQuiz(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := NewPresenterService(env.quizRepo, env.state, env.answers, env.leaderboard).GetPresenterView(context.Background(), uuid.New())
+	if !errors.Is(err, ErrQuizNotFound) {
+		t.Errorf("presenter view of an unknown quiz returned %v, want ErrQuizNotFound", err)
+	}
+}
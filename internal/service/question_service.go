@@ -4,20 +4,29 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/repository"
-	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
 	"github.com/google/uuid"
 )
 
 // Error definitions for the question service
 var (
-	ErrQuestionNotFound = errors.New("question not found")
-	ErrNoQuestions      = errors.New("no questions available")
-	ErrEmptyOptions     = errors.New("question must have options")
-	ErrInvalidOption    = errors.New("invalid option selected")
+	ErrQuestionNotFound   = errors.New("question not found")
+	ErrNoQuestions        = errors.New("no questions available")
+	ErrEmptyOptions       = errors.New("question must have options")
+	ErrInvalidOption      = errors.New("invalid option selected")
+	ErrOrderMismatch      = errors.New("question order must list every question of the quiz exactly once")
+	ErrQuestionNotActive  = errors.New("question is not currently active")
+	ErrQuestionAlreadyRun = errors.New("question has already been run; pass force to restart it and discard its results view")
+	ErrQuestionVoided     = errors.New("question has already been voided")
+	ErrQuestionRunning    = errors.New("end the question before voiding it")
+	ErrInvalidVoidPolicy  = errors.New("void policy must be REMOVE or FULL_POINTS")
+	ErrInvalidImageURL    = errors.New("question image URL must be an absolute http or https URL")
 )
 
 // questionServiceImpl implements QuestionService interface
@@ -25,8 +34,12 @@ type questionServiceImpl struct {
 	quizRepo           repository.QuizRepository
 	questionRepo       repository.QuestionRepository
 	questionOptionRepo repository.QuestionOptionRepository
-	wsHub              *websocket.RedisHub
+	answerRepo         repository.AnswerRepository
+	leaderboardService LeaderboardService
+	wsHub              EventHub
 	stateService       StateService
+	maxPrefetch        int
+	maxQuestions       int
 }
 
 // NewQuestionService creates a new question service
@@ -34,28 +47,63 @@ func NewQuestionService(
 	quizRepo repository.QuizRepository,
 	questionRepo repository.QuestionRepository,
 	questionOptionRepo repository.QuestionOptionRepository,
-	wsHub *websocket.RedisHub,
+	answerRepo repository.AnswerRepository,
+	leaderboardService LeaderboardService,
+	wsHub EventHub,
 	stateService StateService,
+	cfg config.QuizConfig,
 ) QuestionService {
 	return &questionServiceImpl{
 		quizRepo:           quizRepo,
 		questionRepo:       questionRepo,
 		questionOptionRepo: questionOptionRepo,
+		answerRepo:         answerRepo,
+		leaderboardService: leaderboardService,
 		wsHub:              wsHub,
 		stateService:       stateService,
+		maxPrefetch:        cfg.MaxPrefetchQuestions,
+		maxQuestions:       cfg.MaxQuestionsPerQuiz,
 	}
 }
 
+// isHTTPURL reports whether raw is an absolute http or https URL
+func isHTTPURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// validateImageURL checks an optional question image URL
+func validateImageURL(imageURL string) error {
+	if imageURL != "" && !isHTTPURL(imageURL) {
+		return ErrInvalidImageURL
+	}
+	return nil
+}
+
 // AddQuestion adds a question to a quiz
-func (s *questionServiceImpl) AddQuestion(ctx context.Context, quizID uuid.UUID, text string, options []dto.OptionCreateData, questionType string, timeLimit int) (*model.Question, error) {
-	// Validate inputs
-	if text == "" {
-		return nil, errors.New("question text is required")
+func (s *questionServiceImpl) AddQuestion(ctx context.Context, quizID uuid.UUID, text string, options []dto.OptionCreateData, questionType string, timeLimit int, imageURL string, explanation string, required bool) (*model.Question, error) {
+	// Validate inputs, holding them to the same content rules as questions created with their quiz
+	text, explanation, err := sanitizeQuestionFields("", text, explanation)
+	if err != nil {
+		return nil, err
+	}
+
+	imageURL = strings.TrimSpace(imageURL)
+	if err := validateImageURL(imageURL); err != nil {
+		return nil, err
 	}
 
 	if len(options) < 2 {
 		return nil, errors.New("question must have at least 2 options")
 	}
+	cleanedOptions := make([]dto.OptionCreateData, len(options))
+	for i, option := range options {
+		if option.Text, err = sanitizeOptionText(fmt.Sprintf("options[%d].text", i), option.Text); err != nil {
+			return nil, err
+		}
+		cleanedOptions[i] = option
+	}
+	options = cleanedOptions
 
 	// Validate question type
 	var qType model.QuestionType
@@ -69,7 +117,7 @@ func (s *questionServiceImpl) AddQuestion(ctx context.Context, quizID uuid.UUID,
 	}
 
 	// Check if quiz exists
-	_, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	_, err = s.quizRepo.GetQuizByID(ctx, quizID)
 	if err != nil {
 		return nil, errors.New("quiz not found")
 	}
@@ -80,9 +128,20 @@ func (s *questionServiceImpl) AddQuestion(ctx context.Context, quizID uuid.UUID,
 		return nil, err
 	}
 	order := len(existingQuestions) + 1
+	if s.maxQuestions > 0 && order > s.maxQuestions {
+		return nil, fmt.Errorf("%w: a quiz may have at most %d questions", ErrInvalidContent, s.maxQuestions)
+	}
+
+	// Adding a question is a structural edit, so reject it once answers are recorded
+	if err := s.ensureNoAnswers(ctx, quizID); err != nil {
+		return nil, err
+	}
 
 	// Create the question
 	question := model.NewQuestion(quizID, text, qType, timeLimit, order)
+	question.ImageURL = imageURL
+	question.Explanation = explanation
+	question.Required = required
 
 	// Save to database
 	if err := s.questionRepo.CreateQuestion(ctx, question); err != nil {
@@ -126,6 +185,7 @@ func (s *questionServiceImpl) AddQuestion(ctx context.Context, quizID uuid.UUID,
 			return nil, err
 		}
 	}
+	s.stateService.InvalidateQuizState(quizID)
 
 	// Fetch the complete question with options
 	return s.GetQuestion(ctx, question.ID)
@@ -145,17 +205,168 @@ func (s *questionServiceImpl) GetQuestions(ctx context.Context, quizID uuid.UUID
 		return nil, err
 	}
 
-	// Load options for each question
+	// Load the options of all questions at once and distribute them
+	options, err := s.questionOptionRepo.GetQuestionOptionsByQuizID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	optionsByQuestion := make(map[uuid.UUID][]*model.QuestionOption, len(questions))
+	for _, option := range options {
+		optionsByQuestion[option.QuestionID] = append(optionsByQuestion[option.QuestionID], option)
+	}
+	for _, question := range questions {
+		question.Options = optionsByQuestion[question.ID]
+	}
+
+	return questions, nil
+}
+
+// GetQuestionsInRunOrder retrieves all questions of a quiz in the order they were first shown during the run,
+// followed by the questions not shown yet in canonical order
+func (s *questionServiceImpl) GetQuestionsInRunOrder(ctx context.Context, quizID uuid.UUID) ([]*model.Question, error) {
+	questions, err := s.GetQuestions(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline, err := s.stateService.GetQuizTimeline(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]*model.Question, len(questions))
 	for _, question := range questions {
-		options, err := s.questionOptionRepo.GetQuestionOptionsByQuestionID(ctx, question.ID)
-		if err != nil {
-			return nil, err
+		byID[question.ID] = question
+	}
+
+	ordered := make([]*model.Question, 0, len(questions))
+	for _, shown := range timeline.Questions {
+		// Questions revisited with goto keep their first position; deleted ones are skipped
+		if question, ok := byID[shown.QuestionID]; ok {
+			ordered = append(ordered, question)
+			delete(byID, shown.QuestionID)
+		}
+	}
+	for _, question := range questions {
+		if _, pending := byID[question.ID]; pending {
+			ordered = append(ordered, question)
 		}
-		fmt.Println("Options: ", options)
-		question.Options = options
 	}
 
-	return questions, nil
+	return ordered, nil
+}
+
+// ReorderQuestions reassigns the order of a quiz's questions to match questionIDs
+func (s *questionServiceImpl) ReorderQuestions(ctx context.Context, quizID uuid.UUID, questionIDs []uuid.UUID) ([]*model.Question, error) {
+	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		return nil, ErrQuizNotFound
+	}
+
+	// Only allow reordering before the quiz has started
+	if quiz.Status != model.QuizStatusWaiting {
+		return nil, errors.New("cannot reorder questions of a quiz that has already started or completed")
+	}
+
+	existingQuestions, err := s.questionRepo.GetQuestionsByQuizID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The new order must contain exactly the quiz's current questions
+	if len(questionIDs) != len(existingQuestions) {
+		return nil, ErrOrderMismatch
+	}
+	existing := make(map[uuid.UUID]struct{}, len(existingQuestions))
+	for _, q := range existingQuestions {
+		existing[q.ID] = struct{}{}
+	}
+	for _, id := range questionIDs {
+		if _, ok := existing[id]; !ok {
+			return nil, ErrOrderMismatch
+		}
+		// Remove as we go so duplicates are rejected
+		delete(existing, id)
+	}
+
+	// Reordering is a structural edit, so reject it once answers are recorded
+	if err := s.ensureNoAnswers(ctx, quizID); err != nil {
+		return nil, err
+	}
+
+	if err := s.questionRepo.UpdateQuestionOrder(ctx, quizID, questionIDs); err != nil {
+		return nil, err
+	}
+
+	return s.GetQuestions(ctx, quizID)
+}
+
+// SetQuestionTimeLimits sets the time limit of the listed questions, or of all questions when none are listed
+func (s *questionServiceImpl) SetQuestionTimeLimits(ctx context.Context, quizID uuid.UUID, questionIDs []uuid.UUID, timeLimit int) ([]*model.Question, error) {
+	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		return nil, ErrQuizNotFound
+	}
+
+	// Only allow changing time limits before the quiz has started
+	if quiz.Status != model.QuizStatusWaiting {
+		return nil, errors.New("cannot change time limits of a quiz that has already started or completed")
+	}
+
+	existingQuestions, err := s.questionRepo.GetQuestionsByQuizID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[uuid.UUID]struct{}, len(existingQuestions))
+	for _, q := range existingQuestions {
+		existing[q.ID] = struct{}{}
+	}
+
+	// Without a list, every question of the quiz gets the new limit
+	targets := make([]uuid.UUID, 0, len(existingQuestions))
+	if len(questionIDs) == 0 {
+		for _, q := range existingQuestions {
+			targets = append(targets, q.ID)
+		}
+	} else {
+		seen := make(map[uuid.UUID]struct{}, len(questionIDs))
+		for _, id := range questionIDs {
+			if _, ok := existing[id]; !ok {
+				return nil, fmt.Errorf("%w: %s", ErrQuestionNotFound, id)
+			}
+			if _, dup := seen[id]; dup {
+				continue
+			}
+			seen[id] = struct{}{}
+			targets = append(targets, id)
+		}
+	}
+
+	// Time limits affect scoring, so they are structural like the question order
+	if err := s.ensureNoAnswers(ctx, quizID); err != nil {
+		return nil, err
+	}
+
+	if err := s.questionRepo.UpdateQuestionTimeLimits(ctx, quizID, targets, timeLimit); err != nil {
+		return nil, err
+	}
+	s.stateService.InvalidateQuizState(quizID)
+
+	return s.GetQuestions(ctx, quizID)
+}
+
+// ensureNoAnswers returns ErrQuizHasAnswers if any answers were recorded for the quiz
+func (s *questionServiceImpl) ensureNoAnswers(ctx context.Context, quizID uuid.UUID) error {
+	answerCount, err := s.answerRepo.CountAnswersByQuizID(ctx, quizID)
+	if err != nil {
+		return err
+	}
+	if answerCount > 0 {
+		return ErrQuizHasAnswers
+	}
+	return nil
 }
 
 // GetQuestion retrieves a question by ID
@@ -192,6 +403,19 @@ func (s *questionServiceImpl) GetNextQuestion(ctx context.Context, quizID uuid.U
 		return nil, err
 	}
 
+	// Shuffled quizzes follow their seeded run order
+	if quiz.Settings.ShuffleQuestions {
+		questions, err := s.questionRepo.GetQuestionsByQuizID(ctx, quizID)
+		if err != nil {
+			return nil, err
+		}
+		nextQuestion := model.QuestionAfter(model.ShuffledQuestions(questions, quiz.Settings.ShuffleSeed), session.CurrentQuestionID)
+		if nextQuestion == nil {
+			return nil, ErrNoQuestions
+		}
+		return s.GetQuestion(ctx, nextQuestion.ID)
+	}
+
 	// If no current question, get the first question
 	if session.CurrentQuestionID == nil {
 		questions, err := s.questionRepo.GetQuestionsByQuizID(ctx, quizID)
@@ -236,10 +460,81 @@ func (s *questionServiceImpl) GetNextQuestion(ctx context.Context, quizID uuid.U
 	return s.GetQuestion(ctx, nextQuestion.ID)
 }
 
-// StartQuestion starts a question by delegating to the state service
-func (s *questionServiceImpl) StartQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) error {
+// GetUpcomingQuestions retrieves up to count questions that follow the current one and were not shown yet,
+// in the order they will run. count is capped at the configured prefetch maximum.
+func (s *questionServiceImpl) GetUpcomingQuestions(ctx context.Context, quizID uuid.UUID, count int) ([]*model.Question, error) {
+	if count > s.maxPrefetch {
+		count = s.maxPrefetch
+	}
+
+	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		return nil, ErrQuizNotFound
+	}
+	if quiz.Status != model.QuizStatusActive {
+		return nil, ErrQuizNotActive
+	}
+
+	session, err := s.quizRepo.GetQuizSession(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := s.GetQuestions(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Shuffled quizzes prefetch in their run order
+	if quiz.Settings.ShuffleQuestions {
+		questions = model.ShuffledQuestions(questions, quiz.Settings.ShuffleSeed)
+	}
+
+	// Only questions after the current one are upcoming
+	start := 0
+	if session.CurrentQuestionID != nil {
+		for i, question := range questions {
+			if question.ID == *session.CurrentQuestionID {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	// Skip questions already shown, e.g. after jumping around with goto
+	timeline, err := s.stateService.GetQuizTimeline(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+	shown := make(map[uuid.UUID]bool, len(timeline.Questions))
+	for _, question := range timeline.Questions {
+		shown[question.QuestionID] = true
+	}
+
+	upcoming := make([]*model.Question, 0, count)
+	for _, question := range questions[start:] {
+		if len(upcoming) >= count {
+			break
+		}
+		if !shown[question.ID] {
+			upcoming = append(upcoming, question)
+		}
+	}
+
+	return upcoming, nil
+}
+
+// StartQuestion starts a question by delegating to the state service.
+// A question that already ran is only restarted when force is set.
+func (s *questionServiceImpl) StartQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID, force bool) error {
 	// Delegate to state service
-	return s.stateService.StartQuestion(ctx, quizID, questionID)
+	return s.stateService.StartQuestion(ctx, quizID, questionID, force)
+}
+
+// PreviewQuestion shows a question to the quiz's creators by delegating to the state service
+func (s *questionServiceImpl) PreviewQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) (*model.Question, error) {
+	// Delegate to state service
+	return s.stateService.PreviewQuestion(ctx, quizID, questionID)
 }
 
 // EndQuestion ends the current question by delegating to the state service
@@ -248,6 +543,74 @@ func (s *questionServiceImpl) EndQuestion(ctx context.Context, quizID uuid.UUID)
 	return s.stateService.EndQuestion(ctx, quizID)
 }
 
+// ExtendQuestionTime adds time to the question if it is the one currently running
+func (s *questionServiceImpl) ExtendQuestionTime(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID, extraSeconds int) error {
+	session, err := s.quizRepo.GetQuizSession(ctx, quizID)
+	if err != nil {
+		return err
+	}
+	if session.CurrentQuestionID == nil || *session.CurrentQuestionID != questionID {
+		return ErrQuestionNotActive
+	}
+
+	// Delegate to state service
+	return s.stateService.ExtendQuestionTime(ctx, quizID, extraSeconds)
+}
+
+// SkipQuestion skips the given question if it is the one currently running
+func (s *questionServiceImpl) SkipQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) error {
+	session, err := s.quizRepo.GetQuizSession(ctx, quizID)
+	if err != nil {
+		return err
+	}
+	if session.CurrentQuestionID == nil || *session.CurrentQuestionID != questionID {
+		return ErrQuestionNotActive
+	}
+
+	// Delegate to state service
+	return s.stateService.SkipQuestion(ctx, quizID)
+}
+
+// VoidQuestion rescores a broken question so it counts the same for everyone: REMOVE takes its points
+// away from all participants and FULL_POINTS credits all of them a correct answer's base points.
+// The change is computed from each participant's recorded answer score and the corrected leaderboard is broadcast.
+func (s *questionServiceImpl) VoidQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID, policy model.VoidPolicy) error {
+	var points int
+	switch policy {
+	case model.VoidPolicyRemove:
+		points = 0
+	case model.VoidPolicyFullPoints:
+		points = model.CorrectAnswerPoints
+	default:
+		return ErrInvalidVoidPolicy
+	}
+
+	question, err := s.questionRepo.GetQuestionByID(ctx, questionID)
+	if err != nil || question.QuizID != quizID {
+		return ErrQuestionNotFound
+	}
+	if question.VoidedPoints != nil {
+		return ErrQuestionVoided
+	}
+
+	// Answers could still change the question's scores while it runs
+	session, err := s.quizRepo.GetQuizSession(ctx, quizID)
+	if err != nil {
+		return err
+	}
+	if session.CurrentQuestionID != nil && *session.CurrentQuestionID == questionID &&
+		session.CurrentPhase == model.QuizPhaseQuestionActive {
+		return ErrQuestionRunning
+	}
+
+	if err := s.questionRepo.VoidQuestion(ctx, questionID, points); err != nil {
+		return err
+	}
+	s.stateService.InvalidateQuizState(quizID)
+
+	return s.leaderboardService.BroadcastLeaderboard(ctx, quizID)
+}
+
 // MoveToNextQuestion moves to the next question by delegating to the state service
 func (s *questionServiceImpl) MoveToNextQuestion(ctx context.Context, quizID uuid.UUID) error {
 	// Delegate to state service
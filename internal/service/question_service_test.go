@@ -0,0 +1,678 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
+	"github.com/google/uuid"
+)
+
+func TestReorderQuestionsPersistsOrderForGetNextQuestion(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	first := env.seedQuestion(t, quiz, 1)
+	second := env.seedQuestion(t, quiz, 2)
+	third := env.seedQuestion(t, quiz, 3)
+
+	if _, err := env.questions.ReorderQuestions(ctx, quiz.ID, []uuid.UUID{third.ID, first.ID, second.ID}); err != nil {
+		t.Fatalf("ReorderQuestions: %v", err)
+	}
+
+	wantOrder := map[uuid.UUID]int{third.ID: 1, first.ID: 2, second.ID: 3}
+	for id, want := range wantOrder {
+		stored, err := env.questionRepo.GetQuestionByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetQuestionByID: %v", err)
+		}
+		if stored.Order != want {
+			t.Errorf("question stored at order %d, want %d", stored.Order, want)
+		}
+	}
+
+	if err := env.quizRepo.UpdateQuizStatus(ctx, quiz.ID, model.QuizStatusActive); err != nil {
+		t.Fatalf("UpdateQuizStatus: %v", err)
+	}
+	next, err := env.questions.GetNextQuestion(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("GetNextQuestion before the first question: %v", err)
+	}
+	if next.ID != third.ID {
+		t.Error("first question is not the one reordered to the front")
+	}
+
+	env.runQuestion(t, third, 0)
+	next, err = env.questions.GetNextQuestion(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("GetNextQuestion: %v", err)
+	}
+	if next.ID != first.ID {
+		t.Error("question after the first does not follow the new order")
+	}
+}
+
+func TestReorderQuestionsRejectsMismatchedIDs(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	first := env.seedQuestion(t, quiz, 1)
+	second := env.seedQuestion(t, quiz, 2)
+
+	tests := []struct {
+		name string
+		ids  []uuid.UUID
+	}{
+		{name: "missing question", ids: []uuid.UUID{second.ID}},
+		{name: "duplicate question", ids: []uuid.UUID{second.ID, second.ID}},
+		{name: "foreign question", ids: []uuid.UUID{second.ID, uuid.New()}},
+		{name: "extra question", ids: []uuid.UUID{second.ID, first.ID, uuid.New()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := env.questions.ReorderQuestions(context.Background(), quiz.ID, tt.ids); !errors.Is(err, ErrOrderMismatch) {
+				t.Errorf("ReorderQuestions returned %v, want ErrOrderMismatch", err)
+			}
+		})
+	}
+	if got := env.store.callCount("UpdateQuestionOrder"); got != 0 {
+		t.Errorf("mismatched orders were written %d times", got)
+	}
+}
+
+func TestReorderQuestionsOnlyWhileWaiting(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	first := env.seedQuestion(t, quiz, 1)
+	second := env.seedQuestion(t, quiz, 2)
+
+	if _, err := env.questions.ReorderQuestions(context.Background(), quiz.ID, []uuid.UUID{second.ID, first.ID}); err == nil {
+		t.Fatal("reordered the questions of a running quiz")
+	}
+	if got := env.store.callCount("UpdateQuestionOrder"); got != 0 {
+		t.Errorf("order was written %d times", got)
+	}
+}
+
+func TestReorderQuestionsOnceAnswered(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	first := env.seedQuestion(t, quiz, 1)
+	second := env.seedQuestion(t, quiz, 2)
+	env.seedAnswer(t, env.seedParticipant(t, quiz, "Ann"), first, correctOption(first))
+
+	if _, err := env.questions.ReorderQuestions(context.Background(), quiz.ID, []uuid.UUID{second.ID, first.ID}); !errors.Is(err, ErrQuizHasAnswers) {
+		t.Errorf("ReorderQuestions returned %v, want ErrQuizHasAnswers", err)
+	}
+}
+
+func TestGetQuestionsLoadsOptionsInOneQuery(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	seeded := map[uuid.UUID]*model.Question{}
+	for order := 1; order <= 5; order++ {
+		question := env.seedQuestion(t, quiz, order, "A", "B", "C")
+		seeded[question.ID] = question
+	}
+	env.store.resetCalls()
+
+	questions, err := env.questions.GetQuestions(context.Background(), quiz.ID)
+	if err != nil {
+		t.Fatalf("GetQuestions: %v", err)
+	}
+
+	if got := env.store.callCount("GetQuestionOptionsByQuizID"); got != 1 {
+		t.Errorf("loaded quiz options %d times, want once", got)
+	}
+	if got := env.store.callCount("GetQuestionOptionsByQuestionID"); got != 0 {
+		t.Errorf("loaded options per question %d times", got)
+	}
+	if len(questions) != len(seeded) {
+		t.Fatalf("got %d questions, want %d", len(questions), len(seeded))
+	}
+	for _, question := range questions {
+		if len(question.Options) != 3 {
+			t.Errorf("question %d has %d options, want 3", question.Order, len(question.Options))
+		}
+		for _, option := range question.Options {
+			if option.QuestionID != question.ID {
+				t.Errorf("question %d was given an option of another question", question.Order)
+			}
+		}
+	}
+}
+
+// showQuestion runs a question of a started quiz through the state service, so it is on the quiz's timeline
+func (e *testEnv) showQuestion(t *testing.T, question *model.Question) {
+	t.Helper()
+
+	ctx := context.Background()
+	if err := e.state.StartQuestion(ctx, question.QuizID, question.ID, false); err != nil {
+		t.Fatalf("StartQuestion: %v", err)
+	}
+	if err := e.state.EndQuestion(ctx, question.QuizID); err != nil {
+		t.Fatalf("EndQuestion: %v", err)
+	}
+}
+
+// questionIDs returns the IDs of questions in order
+func questionIDs(questions []*model.Question) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(questions))
+	for _, question := range questions {
+		ids = append(ids, question.ID)
+	}
+	return ids
+}
+
+func TestGetQuestionsOrderingsOfAShuffledQuiz(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{ManualAdvance: true, ShuffleQuestions: true, ShuffleSeed: 42})
+	var canonical []uuid.UUID
+	for order := 1; order <= 5; order++ {
+		canonical = append(canonical, env.seedQuestion(t, quiz, order).ID)
+	}
+
+	// Run the first three questions of the shuffled order
+	if err := env.state.StartQuiz(ctx, quiz.ID); err != nil {
+		t.Fatalf("StartQuiz: %v", err)
+	}
+	var shown []uuid.UUID
+	for i := 0; i < 3; i++ {
+		next, err := env.questions.GetNextQuestion(ctx, quiz.ID)
+		if err != nil {
+			t.Fatalf("GetNextQuestion: %v", err)
+		}
+		env.showQuestion(t, next)
+		shown = append(shown, next.ID)
+	}
+
+	creatorOrder, err := env.questions.GetQuestions(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("GetQuestions: %v", err)
+	}
+	runOrder, err := env.questions.GetQuestionsInRunOrder(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("GetQuestionsInRunOrder: %v", err)
+	}
+
+	if got := questionIDs(creatorOrder); !equalIDs(got, canonical) {
+		t.Error("canonical ordering does not follow the creator's order")
+	}
+	got := questionIDs(runOrder)
+	if len(got) != len(canonical) {
+		t.Fatalf("run ordering has %d questions, want %d", len(got), len(canonical))
+	}
+	if !equalIDs(got[:3], shown) {
+		t.Error("run ordering does not start with the questions in the order they were shown")
+	}
+	if equalIDs(shown, canonical[:3]) {
+		t.Fatal("shuffle seed kept the creator's order, so the orderings cannot be told apart")
+	}
+
+	// Questions not shown yet follow in the creator's order
+	var pending []uuid.UUID
+	for _, id := range canonical {
+		if !containsID(shown, id) {
+			pending = append(pending, id)
+		}
+	}
+	if !equalIDs(got[3:], pending) {
+		t.Error("questions not shown yet are not in the creator's order")
+	}
+}
+
+func equalIDs(a, b []uuid.UUID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGetUpcomingQuestions(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.QuizConfig) { cfg.MaxPrefetchQuestions = 3 })
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{ManualAdvance: true})
+	var questions []*model.Question
+	for order := 1; order <= 8; order++ {
+		questions = append(questions, env.seedQuestion(t, quiz, order))
+	}
+	if err := env.state.StartQuiz(ctx, quiz.ID); err != nil {
+		t.Fatalf("StartQuiz: %v", err)
+	}
+
+	// Run 1 and 3, then go back to 2: 3 was shown already and is not upcoming
+	env.showQuestion(t, questions[0])
+	env.showQuestion(t, questions[2])
+	env.showQuestion(t, questions[1])
+
+	tests := []struct {
+		name  string
+		count int
+		want  []uuid.UUID
+	}{
+		{name: "within the cap", count: 2, want: questionIDs(questions[3:5])},
+		{name: "over the cap", count: 10, want: questionIDs(questions[3:6])},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upcoming, err := env.questions.GetUpcomingQuestions(ctx, quiz.ID, tt.count)
+			if err != nil {
+				t.Fatalf("GetUpcomingQuestions: %v", err)
+			}
+			if got := questionIDs(upcoming); !equalIDs(got, tt.want) {
+				t.Errorf("got questions %v, want %v", got, tt.want)
+			}
+			for _, question := range upcoming {
+				if len(question.Options) == 0 {
+					t.Error("upcoming question was returned without its options")
+				}
+			}
+		})
+	}
+}
+
+// playTwoQuestions runs two questions of an active quiz: Ann answers both correctly, Bob only the second.
+// It returns the quiz, the first question and the two participants.
+func (e *testEnv) playTwoQuestions(t *testing.T) (*model.Quiz, *model.Question, *model.Participant, *model.Participant) {
+	t.Helper()
+
+	quiz := e.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	first := e.seedQuestion(t, quiz, 1)
+	second := e.seedQuestion(t, quiz, 2)
+	ann := e.seedParticipant(t, quiz, "Ann")
+	bob := e.seedParticipant(t, quiz, "Bob")
+
+	answers := []struct {
+		question    *model.Question
+		participant *model.Participant
+		option      string
+	}{
+		{first, ann, correctOption(first)},
+		{first, bob, wrongOption(first)},
+		{second, ann, correctOption(second)},
+		{second, bob, correctOption(second)},
+	}
+	for i, a := range answers {
+		if i%2 == 0 {
+			e.runQuestion(t, a.question, time.Second)
+		}
+		if _, err := e.answers.Submit(context.Background(), a.participant.ID, a.question.ID, []string{a.option}, ""); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	e.setSession(t, quiz.ID, func(session *model.QuizSession) {
+		session.CurrentPhase = model.QuizPhaseBetweenQuestions
+	})
+
+	return quiz, first, ann, bob
+}
+
+// answerScore returns the points a participant was given for a question
+func (e *testEnv) answerScore(t *testing.T, participant *model.Participant, question *model.Question) int {
+	t.Helper()
+
+	answer, err := e.answerRepo.GetAnswerByParticipantAndQuestion(context.Background(), participant.ID, question.ID)
+	if err != nil {
+		t.Fatalf("get answer: %v", err)
+	}
+	return answer.Score
+}
+
+func TestVoidQuestionPolicies(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy model.VoidPolicy
+		// credit is what everyone is given for the voided question
+		credit int
+	}{
+		{name: "remove", policy: model.VoidPolicyRemove, credit: 0},
+		{name: "full points", policy: model.VoidPolicyFullPoints, credit: model.CorrectAnswerPoints},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			quiz, first, ann, bob := env.playTwoQuestions(t)
+			annFirst := env.answerScore(t, ann, first)
+			if annFirst == 0 {
+				t.Fatal("Ann's correct answer scored no points")
+			}
+			annBefore, bobBefore := env.participantScore(t, ann.ID), env.participantScore(t, bob.ID)
+
+			if err := env.questions.VoidQuestion(ctx, quiz.ID, first.ID, tt.policy); err != nil {
+				t.Fatalf("VoidQuestion: %v", err)
+			}
+
+			if got, want := env.participantScore(t, ann.ID), annBefore-annFirst+tt.credit; got != want {
+				t.Errorf("Ann's score is %d, want %d", got, want)
+			}
+			if got, want := env.participantScore(t, bob.ID), bobBefore+tt.credit; got != want {
+				t.Errorf("Bob's score is %d, want %d", got, want)
+			}
+
+			updates := env.hub.events(websocket.EventLeaderboardUpdate)
+			if len(updates) == 0 {
+				t.Fatal("corrected leaderboard was not broadcast")
+			}
+			broadcast := updates[len(updates)-1].payload()["leaderboard"].([]map[string]interface{})
+			for _, entry := range broadcast {
+				if entry["id"] == ann.ID.String() && entry["score"] != env.participantScore(t, ann.ID) {
+					t.Errorf("leaderboard shows Ann with %v points", entry["score"])
+				}
+			}
+
+			if err := env.questions.VoidQuestion(ctx, quiz.ID, first.ID, tt.policy); !errors.Is(err, ErrQuestionVoided) {
+				t.Errorf("voiding again returned %v, want ErrQuestionVoided", err)
+			}
+		})
+	}
+}
+
+func TestVoidQuestionRejections(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1)
+	env.runQuestion(t, question, time.Second)
+
+	if err := env.questions.VoidQuestion(ctx, quiz.ID, question.ID, model.VoidPolicy("HALF")); !errors.Is(err, ErrInvalidVoidPolicy) {
+		t.Errorf("unknown policy returned %v, want ErrInvalidVoidPolicy", err)
+	}
+	if err := env.questions.VoidQuestion(ctx, quiz.ID, question.ID, model.VoidPolicyRemove); !errors.Is(err, ErrQuestionRunning) {
+		t.Errorf("voiding the running question returned %v, want ErrQuestionRunning", err)
+	}
+	if err := env.questions.VoidQuestion(ctx, uuid.New(), question.ID, model.VoidPolicyRemove); !errors.Is(err, ErrQuestionNotFound) {
+		t.Errorf("voiding through another quiz returned %v, want ErrQuestionNotFound", err)
+	}
+	if got := env.store.callCount("VoidQuestion"); got != 0 {
+		t.Errorf("rejected voids were stored %d times", got)
+	}
+}
+
+func TestRecomputeScoreMatchesTotalsAfterAVoid(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz, first, ann, bob := env.playTwoQuestions(t)
+	if err := env.questions.VoidQuestion(ctx, quiz.ID, first.ID, model.VoidPolicyFullPoints); err != nil {
+		t.Fatalf("VoidQuestion: %v", err)
+	}
+
+	for _, participant := range []*model.Participant{ann, bob} {
+		total := env.participantScore(t, participant.ID)
+
+		// Knock the stored total off so the recomputation has to come from the answers
+		env.store.mu.Lock()
+		env.store.participants[participant.ID].Score = -1
+		env.store.mu.Unlock()
+
+		score, err := env.leaderboard.RecomputeScore(ctx, participant.ID)
+		if err != nil {
+			t.Fatalf("RecomputeScore: %v", err)
+		}
+		if score != total || env.participantScore(t, participant.ID) != total {
+			t.Errorf("%s recomputed to %d, want the %d the void left", participant.Name, score, total)
+		}
+	}
+
+	// Both are credited the voided question and scored the same on the second one
+	if env.participantScore(t, ann.ID) != env.participantScore(t, bob.ID) {
+		t.Errorf("Ann has %d points and Bob %d after voiding the only question they differ on",
+			env.participantScore(t, ann.ID), env.participantScore(t, bob.ID))
+	}
+}
+
+// timeLimits returns the stored time limit of each question
+func (e *testEnv) timeLimits(t *testing.T, questions ...*model.Question) []int {
+	t.Helper()
+
+	limits := make([]int, len(questions))
+	for i, question := range questions {
+		stored, err := e.questionRepo.GetQuestionByID(context.Background(), question.ID)
+		if err != nil {
+			t.Fatalf("GetQuestionByID: %v", err)
+		}
+		limits[i] = stored.TimeLimit
+	}
+	return limits
+}
+
+func TestSetQuestionTimeLimits(t *testing.T) {
+	t.Run("every question", func(t *testing.T) {
+		env := newTestEnv(t)
+		quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+		questions := []*model.Question{env.seedQuestion(t, quiz, 1), env.seedQuestion(t, quiz, 2), env.seedQuestion(t, quiz, 3)}
+
+		updated, err := env.questions.SetQuestionTimeLimits(context.Background(), quiz.ID, nil, 20)
+		if err != nil {
+			t.Fatalf("SetQuestionTimeLimits: %v", err)
+		}
+		if got := env.timeLimits(t, questions...); fmt.Sprint(got) != "[20 20 20]" {
+			t.Errorf("stored time limits are %v, want every question at 20", got)
+		}
+		if len(updated) != 3 || updated[0].TimeLimit != 20 {
+			t.Errorf("SetQuestionTimeLimits returned %d questions, want all 3 with the new limit", len(updated))
+		}
+		if got := env.store.callCount("UpdateQuestionTimeLimits"); got != 1 {
+			t.Errorf("time limits were saved in %d batches, want 1", got)
+		}
+	})
+
+	t.Run("a subset", func(t *testing.T) {
+		env := newTestEnv(t)
+		quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+		first, second, third := env.seedQuestion(t, quiz, 1), env.seedQuestion(t, quiz, 2), env.seedQuestion(t, quiz, 3)
+
+		if _, err := env.questions.SetQuestionTimeLimits(context.Background(), quiz.ID, []uuid.UUID{first.ID, third.ID, first.ID}, 45); err != nil {
+			t.Fatalf("SetQuestionTimeLimits: %v", err)
+		}
+		if got := env.timeLimits(t, first, second, third); fmt.Sprint(got) != fmt.Sprint([]int{45, second.TimeLimit, 45}) {
+			t.Errorf("stored time limits are %v, want only the listed questions changed", got)
+		}
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			setup   func(env *testEnv, quiz *model.Quiz, question *model.Question) []uuid.UUID
+			status  model.QuizStatus
+			wantErr error
+		}{
+			{
+				name:    "question of another quiz",
+				status:  model.QuizStatusWaiting,
+				wantErr: ErrQuestionNotFound,
+				setup: func(env *testEnv, quiz *model.Quiz, question *model.Question) []uuid.UUID {
+					other := env.seedQuestion(t, env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{}), 1)
+					return []uuid.UUID{question.ID, other.ID}
+				},
+			},
+			{
+				name:    "quiz with answers",
+				status:  model.QuizStatusWaiting,
+				wantErr: ErrQuizHasAnswers,
+				setup: func(env *testEnv, quiz *model.Quiz, question *model.Question) []uuid.UUID {
+					env.seedAnswer(t, env.seedParticipant(t, quiz, "Ann"), question, correctOption(question))
+					return nil
+				},
+			},
+			{name: "started quiz", status: model.QuizStatusActive},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				env := newTestEnv(t)
+				quiz := env.seedQuiz(t, tt.status, model.QuizSettings{})
+				question := env.seedQuestion(t, quiz, 1)
+				var ids []uuid.UUID
+				if tt.setup != nil {
+					ids = tt.setup(env, quiz, question)
+				}
+
+				_, err := env.questions.SetQuestionTimeLimits(context.Background(), quiz.ID, ids, 20)
+				if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+					t.Errorf("SetQuestionTimeLimits = %v, want %v", err, tt.wantErr)
+				}
+				if got := env.timeLimits(t, question); got[0] != question.TimeLimit {
+					t.Errorf("refused change stored a time limit of %d", got[0])
+				}
+			})
+		}
+	})
+}
+
+func TestSkipQuestionChangesNoScores(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	first := env.seedQuestion(t, quiz, 1)
+	second := env.seedQuestion(t, quiz, 2)
+	ann := env.seedParticipant(t, quiz, "Ann")
+	bob := env.seedParticipant(t, quiz, "Bob")
+
+	env.runQuestion(t, first, time.Second)
+	if _, err := env.answers.Submit(ctx, ann.ID, first.ID, []string{correctOption(first)}, ""); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	annBefore, bobBefore := env.participantScore(t, ann.ID), env.participantScore(t, bob.ID)
+	if annBefore == 0 {
+		t.Fatal("Ann's correct answer scored no points")
+	}
+
+	env.runQuestion(t, second, time.Second)
+	if _, err := env.answers.Submit(ctx, ann.ID, second.ID, []string{correctOption(second)}, ""); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if env.participantScore(t, ann.ID) == annBefore {
+		t.Fatal("Ann's correct answer to the second question scored no points")
+	}
+
+	if err := env.questions.SkipQuestion(ctx, quiz.ID, first.ID); !errors.Is(err, ErrQuestionNotActive) {
+		t.Errorf("skipping a question that is not running returned %v, want ErrQuestionNotActive", err)
+	}
+	if err := env.questions.SkipQuestion(ctx, quiz.ID, second.ID); err != nil {
+		t.Fatalf("SkipQuestion: %v", err)
+	}
+
+	// The answer given before the skip no longer counts, and answers still in flight are refused
+	if _, err := env.answers.Submit(ctx, bob.ID, second.ID, []string{correctOption(second)}, ""); !errors.Is(err, ErrQuestionVoided) {
+		t.Errorf("answering the skipped question returned %v, want ErrQuestionVoided", err)
+	}
+	if got := env.participantScore(t, ann.ID); got != annBefore {
+		t.Errorf("Ann's score is %d after the skip, want the %d from before the skipped question", got, annBefore)
+	}
+	if got := env.participantScore(t, bob.ID); got != bobBefore {
+		t.Errorf("Bob's score is %d after the skip, want %d", got, bobBefore)
+	}
+
+	// The skip ends the question without revealing its answers
+	if got := len(env.hub.events(websocket.EventQuestionEnd)); got != 0 {
+		t.Errorf("skipped question was ended with %d QUESTION_END events", got)
+	}
+	skipped := env.hub.events(websocket.EventQuestionSkipped)
+	if len(skipped) != 1 || skipped[0].payload()["questionId"] != second.ID.String() {
+		t.Fatalf("QUESTION_SKIPPED events = %v, want one for the skipped question", skipped)
+	}
+	if _, ok := skipped[0].payload()["correctOptions"]; ok {
+		t.Error("QUESTION_SKIPPED revealed the correct options")
+	}
+	session, err := env.quizRepo.GetQuizSession(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("GetQuizSession: %v", err)
+	}
+	if session.CurrentPhase != model.QuizPhaseBetweenQuestions || session.CurrentQuestionEndedAt == nil {
+		t.Errorf("session after the skip is in phase %s with end %v, want an ended question in %s",
+			session.CurrentPhase, session.CurrentQuestionEndedAt, model.QuizPhaseBetweenQuestions)
+	}
+}
+
+func TestAddQuestionEnforcesContentLimits(t *testing.T) {
+	options := func() []dto.OptionCreateData {
+		return []dto.OptionCreateData{{Text: "Yes", IsCorrect: true}, {Text: "No"}}
+	}
+	tests := []struct {
+		name        string
+		seeded      int
+		text        string
+		options     []dto.OptionCreateData
+		explanation string
+		wantField   string
+	}{
+		{name: "up to the question cap", seeded: 1, text: "Question", options: options()},
+		{name: "over the question cap", seeded: 2, text: "Question", options: options(), wantField: "at most 2 questions"},
+		{name: "over-length text", text: strings.Repeat("q", maxQuestionTextLength+1), options: options(), wantField: "text"},
+		{name: "text of control characters only", text: "\x00\x1b", options: options(), wantField: "text"},
+		{name: "over-length option text", text: "Question", options: func() []dto.OptionCreateData {
+			o := options()
+			o[1].Text = strings.Repeat("o", maxOptionTextLength+1)
+			return o
+		}(), wantField: "options[1].text"},
+		{name: "over-length explanation", text: "Question", options: options(), explanation: strings.Repeat("e", maxExplanationLength+1), wantField: "explanation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.QuizConfig) { cfg.MaxQuestionsPerQuiz = 2 })
+			quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+			for i := 0; i < tt.seeded; i++ {
+				env.seedQuestion(t, quiz, i+1)
+			}
+
+			_, err := env.questions.AddQuestion(context.Background(), quiz.ID, tt.text, tt.options, string(model.QuestionTypeSingleChoice), 20, "", tt.explanation, false)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("AddQuestion: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidContent) || !strings.Contains(err.Error(), tt.wantField) {
+				t.Errorf("AddQuestion returned %v, want ErrInvalidContent naming %s", err, tt.wantField)
+			}
+			if got := env.store.callCount("CreateQuestion"); got != tt.seeded {
+				t.Error("rejected question was saved")
+			}
+		})
+	}
+}
+
+func TestAddQuestionStripsControlCharacters(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	options := []dto.OptionCreateData{{Text: " Ye\x00s ", IsCorrect: true}, {Text: "No"}}
+
+	question, err := env.questions.AddQuestion(context.Background(), quiz.ID, "  Line one\nline\x00 two ", options, string(model.QuestionTypeSingleChoice), 20, "", "Because\x07", false)
+	if err != nil {
+		t.Fatalf("AddQuestion: %v", err)
+	}
+	if question.Text != "Line one\nline two" || question.Explanation != "Because" {
+		t.Errorf("question was saved as %q with explanation %q", question.Text, question.Explanation)
+	}
+	if question.Options[0].Text != "Yes" {
+		t.Errorf("option was saved as %q", question.Options[0].Text)
+	}
+	if options[0].Text != " Ye\x00s " {
+		t.Error("sanitizing changed the caller's options")
+	}
+}
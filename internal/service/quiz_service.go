@@ -3,20 +3,46 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/repository"
-	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
 	"github.com/google/uuid"
 )
 
 // Errors
 var (
-	ErrQuizNotFound       = errors.New("quiz not found")
-	ErrQuizAlreadyStarted = errors.New("quiz has already started")
-	ErrQuizNotActive      = errors.New("quiz is not active")
+	ErrQuizNotFound             = errors.New("quiz not found")
+	ErrQuizAlreadyStarted       = errors.New("quiz has already started")
+	ErrQuizNotActive            = errors.New("quiz is not active")
+	ErrQuizHasAnswers           = errors.New("quiz already has recorded answers; duplicate the quiz to add, remove or reorder questions or change correct answers")
+	ErrQuestionHasAnswers       = errors.New("quiz already has recorded answers; set force to edit question or option text")
+	ErrActiveQuizLimit          = errors.New("too many active quizzes; end a running quiz before starting another")
+	ErrQuizCompleted            = errors.New("quiz has already ended")
+	ErrQuizRunning              = errors.New("quiz is running; end it before archiving")
+	ErrCodeUnavailable          = errors.New("could not generate a unique quiz code")
+	ErrCountdownInProgress      = errors.New("a lobby countdown is already running for this quiz")
+	ErrUnsupportedExportVersion = errors.New("unsupported quiz export version")
+	ErrInvalidContent           = errors.New("invalid quiz content")
+	ErrInvalidSettings          = errors.New("invalid quiz settings")
+)
+
+// maxCodeAttempts is how many random codes are tried before giving up on finding a free one
+const maxCodeAttempts = 5
+
+// Length limits of quiz content, in characters. Title and description limits are configurable.
+const (
+	titleColumnLength     = 255 // the size of the quizzes.title column
+	maxQuestionTextLength = 1000
+	maxOptionTextLength   = 500
+	maxExplanationLength  = 2000
 )
 
 // quizServiceImpl implements QuizService interface
@@ -25,8 +51,11 @@ type quizServiceImpl struct {
 	userRepo           repository.UserRepository
 	questionRepo       repository.QuestionRepository
 	questionOptionRepo repository.QuestionOptionRepository
+	answerRepo         repository.AnswerRepository
 	stateService       StateService
-	wsHub              *websocket.RedisHub
+	wsHub              EventHub
+	config             config.QuizConfig
+	generateCode       func() string
 }
 
 // NewQuizService creates a new quiz service
@@ -35,29 +64,44 @@ func NewQuizService(
 	userRepo repository.UserRepository,
 	questionRepo repository.QuestionRepository,
 	questionOptionRepo repository.QuestionOptionRepository,
+	answerRepo repository.AnswerRepository,
 	stateService StateService,
-	wsHub *websocket.RedisHub,
+	wsHub EventHub,
+	cfg config.QuizConfig,
 ) QuizService {
 	return &quizServiceImpl{
 		quizRepo:           quizRepo,
 		userRepo:           userRepo,
 		questionRepo:       questionRepo,
 		questionOptionRepo: questionOptionRepo,
+		answerRepo:         answerRepo,
 		stateService:       stateService,
 		wsHub:              wsHub,
+		config:             cfg,
+		generateCode:       model.GenerateQuizCode,
 	}
 }
 
 // CreateQuiz creates a new quiz with the specified creator
 func (s *quizServiceImpl) CreateQuiz(ctx context.Context, title string, description string, creatorID uuid.UUID) (*model.Quiz, error) {
+	title, description, err := s.sanitizeQuizDetails(title, description)
+	if err != nil {
+		return nil, err
+	}
+
 	// Verify user exists
 	creator, err := s.userRepo.GetUserByID(ctx, creatorID)
 	if err != nil {
 		return nil, errors.New("creator not found")
 	}
 
-	// Create the quiz
+	// Create the quiz with a join code no other quiz is using
 	quiz := model.NewQuiz(title, description, creator.ID)
+	code, err := s.uniqueQuizCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	quiz.Code = code
 
 	// Create quiz session
 	session := model.NewQuizSession(quiz.ID)
@@ -75,33 +119,113 @@ func (s *quizServiceImpl) CreateQuiz(ctx context.Context, title string, descript
 
 // CreateQuizWithQuestions creates a new quiz with questions
 func (s *quizServiceImpl) CreateQuizWithQuestions(ctx context.Context, title string, description string, creatorID uuid.UUID, questions []dto.QuestionCreateData) (*model.Quiz, error) {
-	if len(questions) == 0 {
-		return nil, errors.New("at least one question is required")
-	}
-
-	// Verify user exists
 	creator, err := s.userRepo.GetUserByID(ctx, creatorID)
 	if err != nil {
 		return nil, errors.New("creator not found")
 	}
 
-	// Create the quiz
-	quiz := model.NewQuiz(title, description, creator.ID)
+	quiz, built, err := s.buildQuizWithQuestions(ctx, title, description, creator, questions)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create quiz session
-	session := model.NewQuizSession(quiz.ID)
+	// Everything is written in one transaction so an invalid question leaves no partial quiz behind
+	if err := s.quizRepo.CreateQuizWithContent(ctx, quiz, model.NewQuizSession(quiz.ID), built); err != nil {
+		return nil, err
+	}
 
-	// Save quiz to database
-	if err := s.quizRepo.CreateQuiz(ctx, quiz); err != nil {
+	return quiz, nil
+}
+
+// ImportQuiz creates a new waiting quiz owned by creatorID from an exported quiz definition,
+// with a fresh ID and join code. Exports of another format version are rejected.
+func (s *quizServiceImpl) ImportQuiz(ctx context.Context, export dto.QuizExport, creatorID uuid.UUID) (*model.Quiz, error) {
+	if export.Version != dto.QuizExportVersion {
+		return nil, fmt.Errorf("%w %d; this server reads version %d", ErrUnsupportedExportVersion, export.Version, dto.QuizExportVersion)
+	}
+	// A hand-edited export gets the same checks and seeds as settings saved through the API
+	settings, err := normalizeQuizSettings(export.Settings, model.QuizSettings{})
+	if err != nil {
 		return nil, err
 	}
 
-	// Save quiz session to database
-	if err := s.quizRepo.CreateQuizSession(ctx, session); err != nil {
+	creator, err := s.userRepo.GetUserByID(ctx, creatorID)
+	if err != nil {
+		return nil, errors.New("creator not found")
+	}
+
+	quiz, built, err := s.buildQuizWithQuestions(ctx, export.Title, export.Description, creator, export.Questions)
+	if err != nil {
 		return nil, err
 	}
+	quiz.Settings = settings
+
+	if err := s.quizRepo.CreateQuizWithContent(ctx, quiz, model.NewQuizSession(quiz.ID), built); err != nil {
+		return nil, err
+	}
+
+	return quiz, nil
+}
+
+// defaultGuestName is shown as the host of guest quizzes created without a name
+const defaultGuestName = "Guest"
+
+// CreateGuestQuiz creates a quiz with questions together with the temporary guest host who owns it.
+// Nothing is stored unless both can be, so rejected content leaves no guest behind.
+func (s *quizServiceImpl) CreateGuestQuiz(ctx context.Context, hostName string, title string, description string, questions []dto.QuestionCreateData) (*model.Quiz, *model.User, error) {
+	hostName = strings.TrimSpace(hostName)
+	if hostName == "" {
+		hostName = defaultGuestName
+	}
+	guest := model.NewGuestUser(hostName)
+
+	quiz, built, err := s.buildQuizWithQuestions(ctx, title, description, guest, questions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.quizRepo.CreateGuestQuizWithContent(ctx, guest, quiz, model.NewQuizSession(quiz.ID), built); err != nil {
+		return nil, nil, err
+	}
+
+	return quiz, guest, nil
+}
+
+// buildQuizWithQuestions validates new quiz content and builds the quiz, with a unique join code,
+// and its questions and options without saving them
+func (s *quizServiceImpl) buildQuizWithQuestions(ctx context.Context, title string, description string, creator *model.User, questions []dto.QuestionCreateData) (*model.Quiz, []*model.Question, error) {
+	if len(questions) == 0 {
+		return nil, nil, errors.New("at least one question is required")
+	}
+	if err := s.checkQuestionCount(len(questions)); err != nil {
+		return nil, nil, err
+	}
+
+	// Validate a cleaned copy so the caller's data is left untouched
+	title, description, err := s.sanitizeQuizDetails(title, description)
+	if err != nil {
+		return nil, nil, err
+	}
+	questions, err = sanitizeQuestionContent(questions)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, q := range questions {
+		if err := validateImageURL(q.ImageURL); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Create the quiz with a join code no other quiz is using
+	quiz := model.NewQuiz(title, description, creator.ID)
+	code, err := s.uniqueQuizCode(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	quiz.Code = code
 
 	// Create questions
+	built := make([]*model.Question, len(questions))
 	for i, q := range questions {
 		// Validate at least one option is marked as correct
 		hasCorrectOption := false
@@ -113,7 +237,7 @@ func (s *quizServiceImpl) CreateQuizWithQuestions(ctx context.Context, title str
 		}
 
 		if !hasCorrectOption {
-			return nil, errors.New("question must have at least one correct option")
+			return nil, nil, errors.New("question must have at least one correct option")
 		}
 
 		// Parse question type
@@ -124,23 +248,75 @@ func (s *quizServiceImpl) CreateQuizWithQuestions(ctx context.Context, title str
 
 		// Create question with order based on array position
 		question := model.NewQuestion(quiz.ID, q.Text, questionType, q.TimeLimit, i+1)
-
-		// Save question to database
-		if err := s.questionRepo.CreateQuestion(ctx, question); err != nil {
-			return nil, err
-		}
+		question.ImageURL = q.ImageURL
+		question.Explanation = q.Explanation
+		question.Required = q.Required
 
 		// Add options for the question
 		for idx, optData := range q.Options {
-			option := model.NewQuestionOption(question.ID, optData.Text, optData.IsCorrect, idx+1)
-			// Save option to database
-			if err := s.questionOptionRepo.CreateQuestionOption(ctx, option); err != nil {
-				return nil, err
-			}
+			question.Options = append(question.Options,
+				model.NewQuestionOption(question.ID, optData.Text, optData.IsCorrect, idx+1))
 		}
+
+		built[i] = question
 	}
 
-	return quiz, nil
+	return quiz, built, nil
+}
+
+// CloneQuiz deep-copies a quiz, its questions and their options under fresh IDs and a new join code.
+// Participants, answers and session progress are not copied; the clone starts out waiting.
+func (s *quizServiceImpl) CloneQuiz(ctx context.Context, quizID uuid.UUID, newCreatorID uuid.UUID) (*model.Quiz, error) {
+	source, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		return nil, ErrQuizNotFound
+	}
+
+	creator, err := s.userRepo.GetUserByID(ctx, newCreatorID)
+	if err != nil {
+		return nil, errors.New("creator not found")
+	}
+
+	questions, err := s.questionRepo.GetQuestionsByQuizID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+	options, err := s.questionOptionRepo.GetQuestionOptionsByQuizID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+	optionsByQuestion := make(map[uuid.UUID][]*model.QuestionOption, len(questions))
+	for _, option := range options {
+		optionsByQuestion[option.QuestionID] = append(optionsByQuestion[option.QuestionID], option)
+	}
+
+	clone := model.NewQuiz(source.Title, source.Description, creator.ID)
+	clone.Settings = source.Settings
+	code, err := s.uniqueQuizCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clone.Code = code
+
+	clonedQuestions := make([]*model.Question, len(questions))
+	for i, question := range questions {
+		cloned := model.NewQuestion(clone.ID, question.Text, question.QuestionType, question.TimeLimit, question.Order)
+		cloned.ImageURL = question.ImageURL
+		cloned.Explanation = question.Explanation
+		cloned.Required = question.Required
+		for _, option := range optionsByQuestion[question.ID] {
+			cloned.Options = append(cloned.Options,
+				model.NewQuestionOption(cloned.ID, option.Text, option.IsCorrect, option.DisplayOrder))
+		}
+		clonedQuestions[i] = cloned
+	}
+
+	// Everything is written in one transaction so a failure leaves no partial copy behind
+	if err := s.quizRepo.CreateQuizWithContent(ctx, clone, model.NewQuizSession(clone.ID), clonedQuestions); err != nil {
+		return nil, err
+	}
+
+	return clone, nil
 }
 
 // GetQuiz retrieves a quiz by ID
@@ -161,13 +337,47 @@ func (s *quizServiceImpl) GetQuizByCode(ctx context.Context, code string) (*mode
 	return quiz, nil
 }
 
-// StartQuiz starts a quiz session
+// StartQuiz starts a quiz session unless its creator already runs the maximum number of active quizzes.
+// Starting a quiz that is already active is a successful no-op.
 func (s *quizServiceImpl) StartQuiz(ctx context.Context, quizID uuid.UUID) error {
-	// Delegate to state service
+	// Delegate to state service, which enforces the active quiz limit as it activates the quiz
 	return s.stateService.StartQuiz(ctx, quizID)
 }
 
-// EndQuiz ends a quiz session
+// StartQuizAfterCountdown counts down in the lobby, then starts the quiz, subject to the same active quiz limit.
+// A creator already at the limit is turned away before the countdown; the limit is checked again when it ends.
+func (s *quizServiceImpl) StartQuizAfterCountdown(ctx context.Context, quizID uuid.UUID, seconds int) error {
+	if err := s.checkActiveQuizLimit(ctx, quizID); err != nil {
+		return err
+	}
+
+	// Delegate to state service
+	return s.stateService.StartLobbyCountdown(ctx, quizID, seconds)
+}
+
+// checkActiveQuizLimit rejects counting down to another quiz once its creator runs the configured maximum
+func (s *quizServiceImpl) checkActiveQuizLimit(ctx context.Context, quizID uuid.UUID) error {
+	if s.config.MaxActivePerCreator <= 0 {
+		return nil
+	}
+
+	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		return ErrQuizNotFound
+	}
+
+	activeCount, err := s.quizRepo.CountActiveQuizzesByCreator(ctx, quiz.CreatorID)
+	if err != nil {
+		return err
+	}
+	if activeCount >= s.config.MaxActivePerCreator {
+		return ErrActiveQuizLimit
+	}
+
+	return nil
+}
+
+// EndQuiz ends a quiz session; ending a quiz that is already completed is a successful no-op
 func (s *quizServiceImpl) EndQuiz(ctx context.Context, quizID uuid.UUID) error {
 	// Delegate to state service
 	return s.stateService.EndQuiz(ctx, quizID)
@@ -182,17 +392,32 @@ func (s *quizServiceImpl) GetQuizSession(ctx context.Context, quizID uuid.UUID)
 	return session, nil
 }
 
-// GetQuizzesByCreatorID retrieves all quizzes created by a user
-func (s *quizServiceImpl) GetQuizzesByCreatorID(ctx context.Context, creatorID uuid.UUID) ([]*model.Quiz, error) {
-	quizzes, err := s.quizRepo.GetQuizzesByCreatorID(ctx, creatorID)
+// GetQuizzesByCreatorID retrieves the quizzes created by a user that match filter and how many match in total
+func (s *quizServiceImpl) GetQuizzesByCreatorID(ctx context.Context, creatorID uuid.UUID, filter model.QuizFilter) ([]*model.Quiz, int, error) {
+	quizzes, err := s.quizRepo.GetQuizzesByCreatorID(ctx, creatorID, filter)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	// Without a limit every match was returned, so there is nothing more to count
+	if filter.Limit <= 0 {
+		return quizzes, len(quizzes), nil
+	}
+
+	total, err := s.quizRepo.CountQuizzesByCreatorID(ctx, creatorID, filter)
+	if err != nil {
+		return nil, 0, err
 	}
-	return quizzes, nil
+	return quizzes, total, nil
 }
 
 // UpdateQuiz updates an existing quiz
 func (s *quizServiceImpl) UpdateQuiz(ctx context.Context, quizID uuid.UUID, title string, description string) (*model.Quiz, error) {
+	title, description, err := s.sanitizeQuizDetails(title, description)
+	if err != nil {
+		return nil, err
+	}
+
 	// Check if quiz exists and get the current data
 	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
 	if err != nil {
@@ -208,6 +433,7 @@ func (s *quizServiceImpl) UpdateQuiz(ctx context.Context, quizID uuid.UUID, titl
 	if err := s.quizRepo.UpdateQuiz(ctx, quiz); err != nil {
 		return nil, err
 	}
+	s.stateService.InvalidateQuizState(quizID)
 
 	return quiz, nil
 }
@@ -278,6 +504,9 @@ func (s *quizServiceImpl) updateExistingQuestion(
 
 	// Update the question
 	existingQuestion.Text = questionData.Text
+	existingQuestion.ImageURL = questionData.ImageURL
+	existingQuestion.Explanation = questionData.Explanation
+	existingQuestion.Required = questionData.Required
 	existingQuestion.TimeLimit = questionData.TimeLimit
 	existingQuestion.QuestionType = questionType
 	existingQuestion.Order = questionOrder
@@ -296,7 +525,7 @@ func (s *quizServiceImpl) updateExistingQuestion(
 	return nil
 }
 
-// updateQuestionOptions updates options for a question
+// updateQuestionOptions updates options for a question in place, keeping option IDs stable
 func (s *quizServiceImpl) updateQuestionOptions(
 	ctx context.Context,
 	questionID uuid.UUID,
@@ -308,6 +537,9 @@ func (s *quizServiceImpl) updateQuestionOptions(
 		return err
 	}
 
+	// Reuse existing options for entries sent without an ID so their IDs are preserved
+	optionsData = matchOptionsByText(optionsData, existingOptions)
+
 	// Create a map of existing option IDs for easy lookup
 	existingOptionMap := make(map[string]*model.QuestionOption)
 	for _, opt := range existingOptions {
@@ -345,6 +577,154 @@ func (s *quizServiceImpl) updateQuestionOptions(
 	return nil
 }
 
+// matchOptionsByText assigns the ID of an unclaimed existing option with the same text
+// to every option sent without an ID
+func matchOptionsByText(optionsData []dto.OptionData, existingOptions []*model.QuestionOption) []dto.OptionData {
+	claimed := make(map[string]struct{})
+	for _, optData := range optionsData {
+		if optData.ID != nil && *optData.ID != "" {
+			claimed[*optData.ID] = struct{}{}
+		}
+	}
+
+	matched := make([]dto.OptionData, len(optionsData))
+	for i, optData := range optionsData {
+		matched[i] = optData
+		if optData.ID != nil && *optData.ID != "" {
+			continue
+		}
+
+		for _, existing := range existingOptions {
+			existingID := existing.ID.String()
+			if _, taken := claimed[existingID]; taken {
+				continue
+			}
+			if strings.TrimSpace(existing.Text) == strings.TrimSpace(optData.Text) {
+				claimed[existingID] = struct{}{}
+				matched[i].ID = &existingID
+				break
+			}
+		}
+	}
+
+	return matched
+}
+
+// compareOptions reports how applying optionsData would change a question's options.
+// Adding, removing or reordering options or changing which are correct is structural,
+// while changing option text alone is cosmetic.
+func compareOptions(existingOptions []*model.QuestionOption, optionsData []dto.OptionData) (structural bool, cosmetic bool) {
+	if len(existingOptions) != len(optionsData) {
+		return true, false
+	}
+
+	existingOptionMap := make(map[string]*model.QuestionOption, len(existingOptions))
+	for _, opt := range existingOptions {
+		existingOptionMap[opt.ID.String()] = opt
+	}
+
+	seen := make(map[string]struct{}, len(optionsData))
+	for j, optData := range optionsData {
+		if optData.ID == nil {
+			return true, false
+		}
+		existing, ok := existingOptionMap[*optData.ID]
+		if _, dup := seen[*optData.ID]; !ok || dup {
+			return true, false
+		}
+		seen[*optData.ID] = struct{}{}
+
+		// Mirror the display order updateExistingOption would assign
+		displayOrder := j + 1
+		if optData.DisplayOrder > 0 {
+			displayOrder = optData.DisplayOrder
+		}
+
+		if existing.IsCorrect != optData.IsCorrect || existing.DisplayOrder != displayOrder {
+			return true, false
+		}
+		if existing.Text != optData.Text {
+			cosmetic = true
+		}
+	}
+
+	return false, cosmetic
+}
+
+// checkEditAgainstAnswers rejects edits that would invalidate answers already recorded for the quiz.
+// Structural edits are always rejected once answers exist; cosmetic edits require force.
+func (s *quizServiceImpl) checkEditAgainstAnswers(
+	ctx context.Context,
+	quizID uuid.UUID,
+	existingQuestions []*model.Question,
+	questions []dto.QuestionUpdateData,
+	force bool,
+) error {
+	answerCount, err := s.answerRepo.CountAnswersByQuizID(ctx, quizID)
+	if err != nil {
+		return err
+	}
+	if answerCount == 0 {
+		return nil
+	}
+
+	// Adding or removing questions is structural
+	if len(questions) != len(existingQuestions) {
+		return ErrQuizHasAnswers
+	}
+
+	existingQuestionMap := make(map[string]*model.Question, len(existingQuestions))
+	for _, q := range existingQuestions {
+		existingQuestionMap[q.ID.String()] = q
+	}
+
+	cosmetic := false
+	seen := make(map[string]struct{}, len(questions))
+	for i, questionData := range questions {
+		if questionData.ID == nil || *questionData.ID == "" {
+			return ErrQuizHasAnswers
+		}
+		existingQuestion, ok := existingQuestionMap[*questionData.ID]
+		if _, dup := seen[*questionData.ID]; !ok || dup {
+			return ErrQuizHasAnswers
+		}
+		seen[*questionData.ID] = struct{}{}
+
+		// Reordering questions or changing how they are scored is structural
+		questionType := model.QuestionTypeSingleChoice
+		if questionData.QuestionType == string(model.QuestionTypeMultipleChoice) {
+			questionType = model.QuestionTypeMultipleChoice
+		}
+		if existingQuestion.Order != i+1 ||
+			existingQuestion.QuestionType != questionType ||
+			existingQuestion.TimeLimit != questionData.TimeLimit {
+			return ErrQuizHasAnswers
+		}
+		if existingQuestion.Text != questionData.Text || existingQuestion.ImageURL != questionData.ImageURL ||
+			existingQuestion.Explanation != questionData.Explanation || existingQuestion.Required != questionData.Required {
+			cosmetic = true
+		}
+
+		existingOptions, err := s.questionOptionRepo.GetQuestionOptionsByQuestionID(ctx, existingQuestion.ID)
+		if err != nil {
+			return err
+		}
+		structuralOptions, cosmeticOptions := compareOptions(existingOptions, matchOptionsByText(questionData.Options, existingOptions))
+		if structuralOptions {
+			return ErrQuizHasAnswers
+		}
+		if cosmeticOptions {
+			cosmetic = true
+		}
+	}
+
+	if cosmetic && !force {
+		return ErrQuestionHasAnswers
+	}
+
+	return nil
+}
+
 // updateExistingOption updates an existing option
 func (s *quizServiceImpl) updateExistingOption(
 	ctx context.Context,
@@ -419,6 +799,9 @@ func (s *quizServiceImpl) createNewQuestion(
 
 	// Create question with order based on array position
 	question := model.NewQuestion(quizID, questionData.Text, questionType, questionData.TimeLimit, questionOrder)
+	question.ImageURL = questionData.ImageURL
+	question.Explanation = questionData.Explanation
+	question.Required = questionData.Required
 
 	// Save the question first to ensure it has an ID
 	if err := s.questionRepo.CreateQuestion(ctx, question); err != nil {
@@ -444,12 +827,43 @@ func (s *quizServiceImpl) createNewQuestion(
 }
 
 // UpdateQuizWithQuestions updates an existing quiz with its questions
-func (s *quizServiceImpl) UpdateQuizWithQuestions(ctx context.Context, quizID uuid.UUID, title string, description string, questions []dto.QuestionUpdateData) (*model.Quiz, error) {
+func (s *quizServiceImpl) UpdateQuizWithQuestions(ctx context.Context, quizID uuid.UUID, title string, description string, questions []dto.QuestionUpdateData, force bool) (*model.Quiz, error) {
+	title, description, err := s.sanitizeQuizDetails(title, description)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkQuestionCount(len(questions)); err != nil {
+		return nil, err
+	}
+
+	// Validate a cleaned copy so the caller's data is left untouched, and compare it against the stored text
+	questions, err = sanitizeQuestionUpdates(questions)
+	if err != nil {
+		return nil, err
+	}
+
 	// Validate and get quiz
 	quiz, err := s.validateQuizForUpdate(ctx, quizID, title)
 	if err != nil {
 		return nil, err
 	}
+	for _, q := range questions {
+		if err := validateImageURL(q.ImageURL); err != nil {
+			return nil, err
+		}
+	}
+
+	// Get existing questions to track which ones to keep, update, or delete
+	existingQuestions, err := s.questionRepo.GetQuestionsByQuizID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't change questions underneath answers that were already recorded
+	if err := s.checkEditAgainstAnswers(ctx, quizID, existingQuestions, questions, force); err != nil {
+		return nil, err
+	}
 
 	// Update quiz basic fields
 	quiz.Title = title
@@ -461,12 +875,6 @@ func (s *quizServiceImpl) UpdateQuizWithQuestions(ctx context.Context, quizID uu
 		return nil, err
 	}
 
-	// Get existing questions to track which ones to keep, update, or delete
-	existingQuestions, err := s.questionRepo.GetQuestionsByQuizID(ctx, quizID)
-	if err != nil {
-		return nil, err
-	}
-
 	// Create a map of existing question IDs for easy lookup
 	existingQuestionMap := make(map[string]*model.Question)
 	for _, q := range existingQuestions {
@@ -509,6 +917,7 @@ func (s *quizServiceImpl) UpdateQuizWithQuestions(ctx context.Context, quizID uu
 			}
 		}
 	}
+	s.stateService.InvalidateQuizState(quizID)
 
 	return quiz, nil
 }
@@ -531,5 +940,310 @@ func (s *quizServiceImpl) DeleteQuiz(ctx context.Context, quizID uuid.UUID) erro
 		return err
 	}
 
+	// Drop the connections still open for the quiz so clients stop using data that no longer exists.
+	// Clients on this instance are closed even if the event cannot reach the other instances.
+	s.wsHub.CloseQuiz(quizID)
+
+	return nil
+}
+
+// ArchiveQuiz archives a quiz that is not running. Archiving an already archived quiz keeps its original time.
+func (s *quizServiceImpl) ArchiveQuiz(ctx context.Context, quizID uuid.UUID) (*model.Quiz, error) {
+	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		return nil, ErrQuizNotFound
+	}
+
+	if quiz.Status == model.QuizStatusActive {
+		return nil, ErrQuizRunning
+	}
+
+	if quiz.ArchivedAt != nil {
+		return quiz, nil
+	}
+
+	archivedAt := time.Now()
+	if err := s.quizRepo.SetQuizArchived(ctx, quizID, &archivedAt); err != nil {
+		return nil, err
+	}
+	quiz.ArchivedAt = &archivedAt
+
+	return quiz, nil
+}
+
+// UnarchiveQuiz returns an archived quiz to its creator's quiz list; it does nothing for a quiz that is not archived
+func (s *quizServiceImpl) UnarchiveQuiz(ctx context.Context, quizID uuid.UUID) (*model.Quiz, error) {
+	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		return nil, ErrQuizNotFound
+	}
+
+	if quiz.ArchivedAt == nil {
+		return quiz, nil
+	}
+
+	if err := s.quizRepo.SetQuizArchived(ctx, quizID, nil); err != nil {
+		return nil, err
+	}
+	quiz.ArchivedAt = nil
+
+	return quiz, nil
+}
+
+// UpdateQuizSettings replaces the settings of a quiz that has not ended and broadcasts them
+func (s *quizServiceImpl) UpdateQuizSettings(ctx context.Context, quizID uuid.UUID, settings model.QuizSettings) (*model.Quiz, error) {
+	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		return nil, ErrQuizNotFound
+	}
+
+	if quiz.Status == model.QuizStatusCompleted {
+		return nil, ErrQuizCompleted
+	}
+
+	settings, err = normalizeQuizSettings(settings, quiz.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.quizRepo.UpdateQuizSettings(ctx, quizID, settings); err != nil {
+		return nil, err
+	}
+	quiz.Settings = settings
+
+	// Keep every connected control surface and participant in sync
+	if err := s.stateService.PublishSettingsUpdate(ctx, quizID, settings); err != nil {
+		return nil, err
+	}
+
+	return quiz, nil
+}
+
+// normalizeQuizSettings validates settings about to be stored and fills in their seeds, reusing the seeds
+// of current where settings gives none
+func normalizeQuizSettings(settings model.QuizSettings, current model.QuizSettings) (model.QuizSettings, error) {
+	if settings.MaxParticipants < 0 {
+		return settings, fmt.Errorf("%w: maxParticipants must not be negative", ErrInvalidSettings)
+	}
+	switch settings.LateAnswerPolicy {
+	case "", model.LateAnswerPolicyReject, model.LateAnswerPolicyAcceptNoBonus:
+	default:
+		return settings, fmt.Errorf("%w: unknown lateAnswerPolicy %q", ErrInvalidSettings, settings.LateAnswerPolicy)
+	}
+	switch settings.Tiebreak {
+	case "", model.LeaderboardTiebreakTime, model.LeaderboardTiebreakJoinOrder, model.LeaderboardTiebreakRandom:
+	default:
+		return settings, fmt.Errorf("%w: unknown tiebreak %q", ErrInvalidSettings, settings.Tiebreak)
+	}
+
+	// A random tiebreak keeps its seed unless a new one is given, so saving the settings again
+	// does not reshuffle tied participants
+	if settings.Tiebreak == model.LeaderboardTiebreakRandom {
+		if settings.TiebreakSeed == 0 {
+			settings.TiebreakSeed = current.TiebreakSeed
+		}
+		for settings.TiebreakSeed == 0 {
+			settings.TiebreakSeed = rand.Int63()
+		}
+	} else {
+		settings.TiebreakSeed = 0
+	}
+
+	// Shuffles keep their seed the same way, so participants do not see options or questions move around
+	if settings.ShuffleOptions || settings.ShuffleQuestions {
+		if settings.ShuffleSeed == 0 {
+			settings.ShuffleSeed = current.ShuffleSeed
+		}
+		for settings.ShuffleSeed == 0 {
+			settings.ShuffleSeed = rand.Int63()
+		}
+	} else {
+		settings.ShuffleSeed = 0
+	}
+
+	return settings, nil
+}
+
+// RegenerateCode replaces the join code of a quiz that has not started yet
+func (s *quizServiceImpl) RegenerateCode(ctx context.Context, quizID uuid.UUID) (*model.Quiz, error) {
+	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		return nil, ErrQuizNotFound
+	}
+
+	if quiz.Status != model.QuizStatusWaiting {
+		return nil, ErrQuizAlreadyStarted
+	}
+
+	code, err := s.uniqueQuizCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.quizRepo.UpdateQuizCode(ctx, quizID, code); err != nil {
+		return nil, err
+	}
+	quiz.Code = code
+
+	return quiz, nil
+}
+
+// uniqueQuizCode generates join codes until one is not used by any quiz
+func (s *quizServiceImpl) uniqueQuizCode(ctx context.Context) (string, error) {
+	for attempt := 0; attempt < maxCodeAttempts; attempt++ {
+		code := s.generateCode()
+
+		exists, err := s.quizRepo.QuizCodeExists(ctx, code)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return code, nil
+		}
+	}
+
+	return "", ErrCodeUnavailable
+}
+
+// checkQuestionCount rejects quiz content with more questions than the configured maximum
+func (s *quizServiceImpl) checkQuestionCount(count int) error {
+	if s.config.MaxQuestionsPerQuiz > 0 && count > s.config.MaxQuestionsPerQuiz {
+		return fmt.Errorf("%w: questions must have at most %d entries", ErrInvalidContent, s.config.MaxQuestionsPerQuiz)
+	}
+	return nil
+}
+
+// sanitizeQuizDetails strips control characters and surrounding whitespace from a quiz's title and
+// description and checks them against the configured length limits. Errors name the offending field.
+func (s *quizServiceImpl) sanitizeQuizDetails(title string, description string) (string, string, error) {
+	title = sanitizeText(title, false)
+	if title == "" {
+		return "", "", fmt.Errorf("%w: title is required", ErrInvalidContent)
+	}
+	maxTitle := s.config.MaxTitleLength
+	if maxTitle <= 0 || maxTitle > titleColumnLength {
+		maxTitle = titleColumnLength
+	}
+	if err := checkLength("title", title, maxTitle); err != nil {
+		return "", "", err
+	}
+
+	// Descriptions may span several lines
+	description = sanitizeText(description, true)
+	if s.config.MaxDescriptionLength > 0 {
+		if err := checkLength("description", description, s.config.MaxDescriptionLength); err != nil {
+			return "", "", err
+		}
+	}
+
+	return title, description, nil
+}
+
+// sanitizeQuestionContent strips control characters and surrounding whitespace from the text of new
+// questions and their options and checks it against the length limits. Errors name the offending field.
+func sanitizeQuestionContent(questions []dto.QuestionCreateData) ([]dto.QuestionCreateData, error) {
+	cleaned := make([]dto.QuestionCreateData, len(questions))
+	for i, q := range questions {
+		prefix := fmt.Sprintf("questions[%d].", i)
+		var err error
+		if q.Text, q.Explanation, err = sanitizeQuestionFields(prefix, q.Text, q.Explanation); err != nil {
+			return nil, err
+		}
+
+		options := make([]dto.OptionCreateData, len(q.Options))
+		for j, option := range q.Options {
+			if option.Text, err = sanitizeOptionText(fmt.Sprintf("%soptions[%d].text", prefix, j), option.Text); err != nil {
+				return nil, err
+			}
+			options[j] = option
+		}
+		q.Options = options
+		q.ImageURL = strings.TrimSpace(q.ImageURL)
+
+		cleaned[i] = q
+	}
+
+	return cleaned, nil
+}
+
+// sanitizeQuestionUpdates cleans and checks the questions of a quiz update like sanitizeQuestionContent,
+// so edits are held to the same rules as newly created questions
+func sanitizeQuestionUpdates(questions []dto.QuestionUpdateData) ([]dto.QuestionUpdateData, error) {
+	cleaned := make([]dto.QuestionUpdateData, len(questions))
+	for i, q := range questions {
+		prefix := fmt.Sprintf("questions[%d].", i)
+		var err error
+		if q.Text, q.Explanation, err = sanitizeQuestionFields(prefix, q.Text, q.Explanation); err != nil {
+			return nil, err
+		}
+
+		options := make([]dto.OptionData, len(q.Options))
+		for j, option := range q.Options {
+			if option.Text, err = sanitizeOptionText(fmt.Sprintf("%soptions[%d].text", prefix, j), option.Text); err != nil {
+				return nil, err
+			}
+			options[j] = option
+		}
+		q.Options = options
+		q.ImageURL = strings.TrimSpace(q.ImageURL)
+
+		cleaned[i] = q
+	}
+
+	return cleaned, nil
+}
+
+// sanitizeQuestionFields cleans and checks a question's text and explanation, naming them with prefix in errors
+func sanitizeQuestionFields(prefix string, text string, explanation string) (string, string, error) {
+	field := prefix + "text"
+	text = sanitizeText(text, true)
+	if text == "" {
+		return "", "", fmt.Errorf("%w: %s is required", ErrInvalidContent, field)
+	}
+	if err := checkLength(field, text, maxQuestionTextLength); err != nil {
+		return "", "", err
+	}
+
+	// Explanations may span several lines
+	explanation = sanitizeText(explanation, true)
+	if err := checkLength(prefix+"explanation", explanation, maxExplanationLength); err != nil {
+		return "", "", err
+	}
+
+	return text, explanation, nil
+}
+
+// sanitizeOptionText cleans and checks an option's text, naming it field in errors
+func sanitizeOptionText(field string, text string) (string, error) {
+	text = sanitizeText(text, false)
+	if text == "" {
+		return "", fmt.Errorf("%w: %s is required", ErrInvalidContent, field)
+	}
+	if err := checkLength(field, text, maxOptionTextLength); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+// sanitizeText removes control characters, keeping line breaks and tabs when multiline is set,
+// and trims surrounding whitespace
+func sanitizeText(text string, multiline bool) string {
+	text = strings.Map(func(r rune) rune {
+		if multiline && (r == '\n' || r == '\t') {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+	return strings.TrimSpace(text)
+}
+
+// checkLength reports an error naming field when text is longer than max characters
+func checkLength(field string, text string, max int) error {
+	if utf8.RuneCountInString(text) > max {
+		return fmt.Errorf("%w: %s must be at most %d characters", ErrInvalidContent, field, max)
+	}
 	return nil
 }
@@ -0,0 +1,1175 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
+	"github.com/google/uuid"
+)
+
+// questionUpdate builds the update data that resends a seeded question unchanged
+func questionUpdate(question *model.Question) dto.QuestionUpdateData {
+	questionID := question.ID.String()
+	update := dto.QuestionUpdateData{
+		ID:           &questionID,
+		Text:         question.Text,
+		TimeLimit:    question.TimeLimit,
+		QuestionType: string(question.QuestionType),
+	}
+	for _, option := range question.Options {
+		optionID := option.ID.String()
+		update.Options = append(update.Options, dto.OptionData{ID: &optionID, Text: option.Text, IsCorrect: option.IsCorrect})
+	}
+	return update
+}
+
+// storedOptions returns a question's stored options by text
+func (e *testEnv) storedOptions(t *testing.T, question *model.Question) map[string]*model.QuestionOption {
+	t.Helper()
+
+	options, err := e.optionRepo.GetQuestionOptionsByQuestionID(context.Background(), question.ID)
+	if err != nil {
+		t.Fatalf("get options: %v", err)
+	}
+	byText := make(map[string]*model.QuestionOption, len(options))
+	for _, option := range options {
+		byText[option.Text] = option
+	}
+	return byText
+}
+
+func TestUpdateQuizWithQuestionsPreservesOptionIDs(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1, "Paris", "Lyon", "Nice")
+	original := env.storedOptions(t, question)
+
+	update := questionUpdate(question)
+	// Edit Paris in place, resend Lyon without its ID, drop Nice and add Lille
+	update.Options[0].Text = "Paris, France"
+	update.Options[1].ID = nil
+	update.Options[2] = dto.OptionData{Text: "Lille"}
+
+	if _, err := env.quizzes.UpdateQuizWithQuestions(context.Background(), quiz.ID, quiz.Title, "", []dto.QuestionUpdateData{update}, false); err != nil {
+		t.Fatalf("UpdateQuizWithQuestions: %v", err)
+	}
+
+	stored := env.storedOptions(t, question)
+	if len(stored) != 3 {
+		t.Fatalf("question has %d options, want 3", len(stored))
+	}
+	if got := stored["Paris, France"]; got == nil || got.ID != original["Paris"].ID {
+		t.Error("option edited by ID did not keep its ID")
+	}
+	if got := stored["Lyon"]; got == nil || got.ID != original["Lyon"].ID {
+		t.Error("option resent without an ID did not keep the ID of the option with the same text")
+	}
+	if _, ok := stored["Nice"]; ok {
+		t.Error("option left out of the update was not deleted")
+	}
+	if got := stored["Lille"]; got == nil || got.ID == original["Nice"].ID {
+		t.Error("new option was not created with a new ID")
+	}
+	if got := env.store.callCount("DeleteQuestionOptionsByQuestionID"); got != 0 {
+		t.Errorf("options were bulk deleted %d times", got)
+	}
+}
+
+func TestUpdateQuizWithQuestionsBlocksOptionEditsOnceAnswered(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1)
+	env.seedAnswer(t, env.seedParticipant(t, quiz, "Ann"), question, correctOption(question))
+	original := env.storedOptions(t, question)
+
+	update := questionUpdate(question)
+	update.Options[0].Text = "Right, fixed typo"
+	updates := []dto.QuestionUpdateData{update}
+
+	if _, err := env.quizzes.UpdateQuizWithQuestions(context.Background(), quiz.ID, quiz.Title, "", updates, false); !errors.Is(err, ErrQuestionHasAnswers) {
+		t.Fatalf("unforced option edit returned %v, want ErrQuestionHasAnswers", err)
+	}
+	if _, ok := env.storedOptions(t, question)["Right, fixed typo"]; ok {
+		t.Fatal("rejected edit was applied")
+	}
+
+	if _, err := env.quizzes.UpdateQuizWithQuestions(context.Background(), quiz.ID, quiz.Title, "", updates, true); err != nil {
+		t.Fatalf("forced option edit: %v", err)
+	}
+	if got := env.storedOptions(t, question)["Right, fixed typo"]; got == nil || got.ID != original["Right"].ID {
+		t.Error("forced edit did not update the answered option in place")
+	}
+}
+
+func TestUpdateQuizWithQuestionsOnceAnswered(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(updates []dto.QuestionUpdateData) []dto.QuestionUpdateData
+		force   bool
+		wantErr error
+	}{
+		{
+			name: "unchanged",
+			edit: func(updates []dto.QuestionUpdateData) []dto.QuestionUpdateData { return updates },
+		},
+		{
+			name: "question typo",
+			edit: func(updates []dto.QuestionUpdateData) []dto.QuestionUpdateData {
+				updates[0].Text = "Fixed question"
+				return updates
+			},
+			wantErr: ErrQuestionHasAnswers,
+		},
+		{
+			name: "forced question typo",
+			edit: func(updates []dto.QuestionUpdateData) []dto.QuestionUpdateData {
+				updates[0].Text = "Fixed question"
+				return updates
+			},
+			force: true,
+		},
+		{
+			name: "add question",
+			edit: func(updates []dto.QuestionUpdateData) []dto.QuestionUpdateData {
+				extra := updates[1]
+				extra.ID = nil
+				return append(updates, extra)
+			},
+			force:   true,
+			wantErr: ErrQuizHasAnswers,
+		},
+		{
+			name: "remove question",
+			edit: func(updates []dto.QuestionUpdateData) []dto.QuestionUpdateData {
+				return updates[:1]
+			},
+			force:   true,
+			wantErr: ErrQuizHasAnswers,
+		},
+		{
+			name: "reorder questions",
+			edit: func(updates []dto.QuestionUpdateData) []dto.QuestionUpdateData {
+				return []dto.QuestionUpdateData{updates[1], updates[0]}
+			},
+			force:   true,
+			wantErr: ErrQuizHasAnswers,
+		},
+		{
+			name: "change correct answer",
+			edit: func(updates []dto.QuestionUpdateData) []dto.QuestionUpdateData {
+				updates[0].Options[0].IsCorrect = false
+				updates[0].Options[1].IsCorrect = true
+				return updates
+			},
+			force:   true,
+			wantErr: ErrQuizHasAnswers,
+		},
+		{
+			name: "add option",
+			edit: func(updates []dto.QuestionUpdateData) []dto.QuestionUpdateData {
+				updates[0].Options = append(updates[0].Options, dto.OptionData{Text: "Maybe"})
+				return updates
+			},
+			force:   true,
+			wantErr: ErrQuizHasAnswers,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+			first := env.seedQuestion(t, quiz, 1)
+			second := env.seedQuestion(t, quiz, 2)
+			env.seedAnswer(t, env.seedParticipant(t, quiz, "Ann"), first, correctOption(first))
+
+			updates := tt.edit([]dto.QuestionUpdateData{questionUpdate(first), questionUpdate(second)})
+			_, err := env.quizzes.UpdateQuizWithQuestions(context.Background(), quiz.ID, quiz.Title, "", updates, tt.force)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateQuizWithQuestions returned %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && env.store.callCount("UpdateQuestion") != 0 {
+				t.Error("questions were changed by a rejected edit")
+			}
+		})
+	}
+}
+
+// seedCreatorQuiz stores a quiz of the given creator with its session in the given status
+func (e *testEnv) seedCreatorQuiz(t *testing.T, creatorID uuid.UUID, status model.QuizStatus) *model.Quiz {
+	t.Helper()
+
+	quiz := model.NewQuiz("Test quiz", "", creatorID)
+	quiz.Status = status
+	session := model.NewQuizSession(quiz.ID)
+	session.Status = status
+	if status != model.QuizStatusWaiting {
+		startedAt := time.Now().Add(-time.Minute)
+		session.StartedAt = &startedAt
+	}
+	if err := e.quizRepo.CreateQuizWithContent(context.Background(), quiz, session, nil); err != nil {
+		t.Fatalf("seed quiz: %v", err)
+	}
+	return quiz
+}
+
+// quizStatus returns a quiz's stored status
+func (e *testEnv) quizStatus(t *testing.T, quizID uuid.UUID) model.QuizStatus {
+	t.Helper()
+
+	quiz, err := e.quizRepo.GetQuizByID(context.Background(), quizID)
+	if err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+	return quiz.Status
+}
+
+func TestStartQuizEnforcesTheActiveQuizCap(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.QuizConfig) { cfg.MaxActivePerCreator = 2 })
+	ctx := context.Background()
+	creatorID := uuid.New()
+	running := env.seedCreatorQuiz(t, creatorID, model.QuizStatusActive)
+	env.seedCreatorQuiz(t, creatorID, model.QuizStatusActive)
+	env.seedCreatorQuiz(t, creatorID, model.QuizStatusCompleted)
+	next := env.seedCreatorQuiz(t, creatorID, model.QuizStatusWaiting)
+
+	if err := env.quizzes.StartQuiz(ctx, next.ID); !errors.Is(err, ErrActiveQuizLimit) {
+		t.Fatalf("starting a quiz beyond the cap returned %v, want ErrActiveQuizLimit", err)
+	}
+	if got := env.quizStatus(t, next.ID); got != model.QuizStatusWaiting {
+		t.Errorf("rejected quiz is %s, want WAITING", got)
+	}
+	// Repeating the start of a running quiz doesn't count it against the cap
+	if err := env.quizzes.StartQuiz(ctx, running.ID); err != nil {
+		t.Errorf("restarting a running quiz at the cap: %v", err)
+	}
+
+	// Other creators have their own allowance
+	other := env.seedCreatorQuiz(t, uuid.New(), model.QuizStatusWaiting)
+	if err := env.quizzes.StartQuiz(ctx, other.ID); err != nil {
+		t.Errorf("another creator's quiz was rejected: %v", err)
+	}
+
+	if err := env.quizzes.EndQuiz(ctx, running.ID); err != nil {
+		t.Fatalf("EndQuiz: %v", err)
+	}
+	if err := env.quizzes.StartQuiz(ctx, next.ID); err != nil {
+		t.Errorf("starting a quiz after one ended: %v", err)
+	}
+}
+
+func TestStartQuizWithoutAnActiveQuizCap(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.QuizConfig) { cfg.MaxActivePerCreator = 0 })
+	creatorID := uuid.New()
+	for i := 0; i < 3; i++ {
+		env.seedCreatorQuiz(t, creatorID, model.QuizStatusActive)
+	}
+
+	next := env.seedCreatorQuiz(t, creatorID, model.QuizStatusWaiting)
+	if err := env.quizzes.StartQuiz(context.Background(), next.ID); err != nil {
+		t.Errorf("StartQuiz without a cap: %v", err)
+	}
+}
+
+func TestParallelCountdownsRespectTheActiveQuizCap(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.QuizConfig) { cfg.MaxActivePerCreator = 1 })
+	env.hub.countdownCompletes = true
+	ctx := context.Background()
+	creatorID := uuid.New()
+	first := env.seedCreatorQuiz(t, creatorID, model.QuizStatusWaiting)
+	second := env.seedCreatorQuiz(t, creatorID, model.QuizStatusWaiting)
+
+	// Neither quiz is active yet, so both countdowns pass the upfront check
+	for _, quiz := range []*model.Quiz{first, second} {
+		if err := env.quizzes.StartQuizAfterCountdown(ctx, quiz.ID, 3); err != nil {
+			t.Fatalf("StartQuizAfterCountdown: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		env.state.timersMu.Lock()
+		running := len(env.state.lobbyCountdowns)
+		env.state.timersMu.Unlock()
+		if running == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("lobby countdowns did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	active := 0
+	for _, quiz := range []*model.Quiz{first, second} {
+		if env.quizStatus(t, quiz.ID) == model.QuizStatusActive {
+			active++
+		}
+	}
+	if active != 1 {
+		t.Errorf("%d quizzes are active after two parallel countdowns, want 1", active)
+	}
+}
+
+func TestUpdateQuizSettingsAnnouncesTheNewSettingsByRole(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+
+	settings := model.QuizSettings{
+		AllowLateJoin:             true,
+		MaxParticipants:           40,
+		Anonymous:                 true,
+		RejectDisconnectedAnswers: true,
+		Tiebreak:                  model.LeaderboardTiebreakRandom,
+	}
+	if _, err := env.quizzes.UpdateQuizSettings(ctx, quiz.ID, settings); err != nil {
+		t.Fatalf("UpdateQuizSettings: %v", err)
+	}
+
+	events := env.hub.events(websocket.EventSettingsUpdated)
+	if len(events) != 2 {
+		t.Fatalf("got %d SETTINGS_UPDATED events, want one for creators and one for participants", len(events))
+	}
+	for _, event := range events {
+		payload := event.payload()
+		if payload["quizId"] != quiz.ID.String() {
+			t.Errorf("SETTINGS_UPDATED for %v, want quiz %s", payload["quizId"], quiz.ID)
+		}
+		if !event.Published {
+			t.Errorf("SETTINGS_UPDATED for %s was broadcast to this instance only, want it published", event.Audience)
+		}
+		switch event.Audience {
+		case hubAudienceCreators:
+			// Creators get the effective settings, including the generated tiebreak seed
+			sent, ok := payload["settings"].(model.QuizSettings)
+			if !ok {
+				t.Fatalf("creator settings are %T, want the full settings", payload["settings"])
+			}
+			if !sent.AllowLateJoin || sent.MaxParticipants != 40 || !sent.Anonymous || !sent.RejectDisconnectedAnswers || sent.TiebreakSeed == 0 {
+				t.Errorf("creators were sent %+v", sent)
+			}
+		case hubAudienceParticipants:
+			sent, ok := payload["settings"].(dto.ParticipantQuizSettings)
+			if !ok {
+				t.Fatalf("participant settings are %T, want only the participant settings", payload["settings"])
+			}
+			if !sent.AllowLateJoin {
+				t.Error("participants were not told late join is allowed")
+			}
+		default:
+			t.Errorf("SETTINGS_UPDATED sent to %s", event.Audience)
+		}
+	}
+
+	// Only the participant view is stored, since stored events are replayed to any client
+	stored, err := env.stateRepo.GetEventsByQuizID(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("GetEventsByQuizID: %v", err)
+	}
+	if len(stored) != 1 || stored[0].EventType != string(websocket.EventSettingsUpdated) {
+		t.Fatalf("stored events %v, want the SETTINGS_UPDATED event", stored)
+	}
+	var replayed struct {
+		Settings map[string]interface{} `json:"settings"`
+	}
+	if err := json.Unmarshal(stored[0].Payload, &replayed); err != nil {
+		t.Fatalf("stored payload: %v", err)
+	}
+	if len(replayed.Settings) != 1 || replayed.Settings["allowLateJoin"] != true {
+		t.Errorf("stored settings %v, want only allowLateJoin", replayed.Settings)
+	}
+}
+
+func TestUpdateQuizSettingsAnnouncesLocallyWhenPublishingFails(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	env.hub.publishErr = errors.New("redis: connection refused")
+
+	if _, err := env.quizzes.UpdateQuizSettings(context.Background(), quiz.ID, model.QuizSettings{AllowLateJoin: true}); err != nil {
+		t.Fatalf("UpdateQuizSettings: %v", err)
+	}
+
+	audiences := map[string]bool{}
+	for _, event := range env.hub.events(websocket.EventSettingsUpdated) {
+		if event.Published {
+			t.Errorf("SETTINGS_UPDATED for %s was published although publishing fails", event.Audience)
+		}
+		audiences[event.Audience] = true
+	}
+	if !audiences[hubAudienceCreators] || !audiences[hubAudienceParticipants] {
+		t.Errorf("SETTINGS_UPDATED reached %v locally, want both creators and participants", audiences)
+	}
+}
+
+// seedCreator stores a guest user who can create quizzes
+func (e *testEnv) seedCreator(t *testing.T) *model.User {
+	t.Helper()
+
+	creator := model.NewGuestUser("Host")
+	if err := (&fakeUserRepo{e.store}).CreateUser(context.Background(), creator); err != nil {
+		t.Fatalf("seed creator: %v", err)
+	}
+	return creator
+}
+
+// stubCodes makes the quiz service generate the given codes in turn, repeating the last
+func (e *testEnv) stubCodes(codes ...string) {
+	e.quizzes.generateCode = func() string {
+		code := codes[0]
+		if len(codes) > 1 {
+			codes = codes[1:]
+		}
+		return code
+	}
+}
+
+func TestCreateQuizRetriesOnACodeCollision(t *testing.T) {
+	env := newTestEnv(t)
+	creator := env.seedCreator(t)
+	env.stubCodes("TAKEN1", "TAKEN1", "FREE22")
+
+	first, err := env.quizzes.CreateQuiz(context.Background(), "First", "", creator.ID)
+	if err != nil {
+		t.Fatalf("CreateQuiz: %v", err)
+	}
+	second, err := env.quizzes.CreateQuiz(context.Background(), "Second", "", creator.ID)
+	if err != nil {
+		t.Fatalf("CreateQuiz after a collision: %v", err)
+	}
+
+	if first.Code != "TAKEN1" {
+		t.Errorf("first quiz got code %q, want TAKEN1", first.Code)
+	}
+	if second.Code != "FREE22" {
+		t.Errorf("colliding quiz got code %q, want the retried FREE22", second.Code)
+	}
+}
+
+func TestCreateQuizGivesUpWithoutAFreeCode(t *testing.T) {
+	env := newTestEnv(t)
+	creator := env.seedCreator(t)
+	env.stubCodes("TAKEN1")
+	if _, err := env.quizzes.CreateQuiz(context.Background(), "First", "", creator.ID); err != nil {
+		t.Fatalf("CreateQuiz: %v", err)
+	}
+
+	_, err := env.quizzes.CreateQuiz(context.Background(), "Second", "", creator.ID)
+	if !errors.Is(err, ErrCodeUnavailable) {
+		t.Errorf("CreateQuiz returned %v, want ErrCodeUnavailable", err)
+	}
+	if got := env.store.callCount("QuizCodeExists"); got != 1+maxCodeAttempts {
+		t.Errorf("checked %d codes, want %d", got, 1+maxCodeAttempts)
+	}
+}
+
+func TestCloneQuizPreservesQuestionsAndOptions(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	source := env.seedQuiz(t, model.QuizStatusCompleted, model.QuizSettings{ShuffleOptions: true, ShuffleSeed: 7})
+	first := env.seedQuestion(t, source, 1, "Right", "Wrong", "Also wrong")
+
+	// A multiple choice question whose correct options are not the first ones
+	second := model.NewQuestion(source.ID, "Pick two", model.QuestionTypeMultipleChoice, 20, 2)
+	second.Explanation = "B and C"
+	for i, correct := range []bool{false, true, true, false} {
+		second.Options = append(second.Options, model.NewQuestionOption(second.ID, string(rune('A'+i)), correct, i+1))
+	}
+	if err := env.questionRepo.CreateQuestion(ctx, second); err != nil {
+		t.Fatalf("seed question: %v", err)
+	}
+	for _, option := range second.Options {
+		if err := env.optionRepo.CreateQuestionOption(ctx, option); err != nil {
+			t.Fatalf("seed option: %v", err)
+		}
+	}
+	env.seedAnswer(t, env.seedParticipant(t, source, "Ann"), first, correctOption(first))
+
+	creator := env.seedCreator(t)
+	clone, err := env.quizzes.CloneQuiz(ctx, source.ID, creator.ID)
+	if err != nil {
+		t.Fatalf("CloneQuiz: %v", err)
+	}
+
+	if clone.ID == source.ID || clone.Code == source.Code || clone.CreatorID != creator.ID {
+		t.Error("clone reuses the source's identity")
+	}
+	if got := env.quizStatus(t, clone.ID); got != model.QuizStatusWaiting {
+		t.Errorf("clone is %s, want WAITING", got)
+	}
+	if clone.Settings != source.Settings {
+		t.Errorf("clone settings %+v, want %+v", clone.Settings, source.Settings)
+	}
+	session, err := env.quizRepo.GetQuizSession(ctx, clone.ID)
+	if err != nil {
+		t.Fatalf("clone has no session: %v", err)
+	}
+	if session.CurrentQuestionID != nil || session.StartedAt != nil {
+		t.Error("clone copied the source's session progress")
+	}
+	if participants, _ := env.participantRepo.GetParticipantsByQuizID(ctx, clone.ID); len(participants) != 0 {
+		t.Errorf("clone has %d participants", len(participants))
+	}
+
+	cloned, err := env.questions.GetQuestions(ctx, clone.ID)
+	if err != nil {
+		t.Fatalf("GetQuestions: %v", err)
+	}
+	sources := []*model.Question{first, second}
+	if len(cloned) != len(sources) {
+		t.Fatalf("clone has %d questions, want %d", len(cloned), len(sources))
+	}
+	for i, question := range cloned {
+		want := sources[i]
+		if question.ID == want.ID || question.Order != want.Order || question.Text != want.Text ||
+			question.QuestionType != want.QuestionType || question.TimeLimit != want.TimeLimit || question.Explanation != want.Explanation {
+			t.Errorf("question %d was cloned as %+v", want.Order, question)
+		}
+		if len(question.Options) != len(want.Options) {
+			t.Fatalf("question %d has %d options, want %d", want.Order, len(question.Options), len(want.Options))
+		}
+		for j, option := range question.Options {
+			wantOption := want.Options[j]
+			if option.ID == wantOption.ID || option.QuestionID != question.ID {
+				t.Errorf("option %d of question %d is not a fresh copy", j+1, want.Order)
+			}
+			if option.Text != wantOption.Text || option.IsCorrect != wantOption.IsCorrect || option.DisplayOrder != wantOption.DisplayOrder {
+				t.Errorf("option %d of question %d = %q correct %v order %d, want %q correct %v order %d", j+1, want.Order,
+					option.Text, option.IsCorrect, option.DisplayOrder, wantOption.Text, wantOption.IsCorrect, wantOption.DisplayOrder)
+			}
+		}
+	}
+}
+
+// exportQuiz exports a stored quiz the way the export endpoint does
+func (e *testEnv) exportQuiz(t *testing.T, quizID uuid.UUID) dto.QuizExport {
+	t.Helper()
+
+	ctx := context.Background()
+	quiz, err := e.quizzes.GetQuiz(ctx, quizID)
+	if err != nil {
+		t.Fatalf("GetQuiz: %v", err)
+	}
+	questions, err := e.questions.GetQuestions(ctx, quizID)
+	if err != nil {
+		t.Fatalf("GetQuestions: %v", err)
+	}
+	return dto.QuizExportFromModel(quiz, questions)
+}
+
+func TestExportedQuizImportsAsAnEquivalentQuiz(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	creator := env.seedCreator(t)
+	source, err := env.quizzes.CreateQuizWithQuestions(ctx, "Capitals", "Europe", creator.ID, []dto.QuestionCreateData{
+		{
+			Text:         "Capital of France?",
+			QuestionType: string(model.QuestionTypeSingleChoice),
+			TimeLimit:    20,
+			Explanation:  "Paris has been the capital since 987",
+			Options:      []dto.OptionCreateData{{Text: "Lyon"}, {Text: "Paris", IsCorrect: true}, {Text: "Nice"}},
+		},
+		{
+			Text:         "Which are on the Danube?",
+			QuestionType: string(model.QuestionTypeMultipleChoice),
+			TimeLimit:    45,
+			Required:     true,
+			Options:      []dto.OptionCreateData{{Text: "Vienna", IsCorrect: true}, {Text: "Rome"}, {Text: "Budapest", IsCorrect: true}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateQuizWithQuestions: %v", err)
+	}
+	env.seedParticipant(t, source, "Ann")
+
+	// Go through JSON as a backup file would
+	raw, err := json.Marshal(env.exportQuiz(t, source.ID))
+	if err != nil {
+		t.Fatalf("marshal export: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	for _, key := range []string{"participants", "answers", "session", "code", "id"} {
+		if _, ok := fields[key]; ok {
+			t.Errorf("export includes %q", key)
+		}
+	}
+	var exported dto.QuizExport
+	if err := json.Unmarshal(raw, &exported); err != nil {
+		t.Fatalf("unmarshal export: %v", err)
+	}
+
+	imported, err := env.quizzes.ImportQuiz(ctx, exported, creator.ID)
+	if err != nil {
+		t.Fatalf("ImportQuiz: %v", err)
+	}
+	if imported.ID == source.ID || imported.Code == source.Code {
+		t.Error("imported quiz reuses the source's identity")
+	}
+
+	if reexported := env.exportQuiz(t, imported.ID); !reflect.DeepEqual(reexported, exported) {
+		t.Errorf("imported quiz exports as\n%+v\nwant\n%+v", reexported, exported)
+	}
+	if participants, _ := env.participantRepo.GetParticipantsByQuizID(ctx, imported.ID); len(participants) != 0 {
+		t.Errorf("imported quiz has %d participants", len(participants))
+	}
+}
+
+func TestDeleteQuizClosesItsConnections(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+
+	if err := env.quizzes.DeleteQuiz(context.Background(), quiz.ID); err != nil {
+		t.Fatalf("DeleteQuiz: %v", err)
+	}
+
+	env.hub.mu.Lock()
+	closed := env.hub.closed
+	env.hub.mu.Unlock()
+	if len(closed) != 1 || closed[0] != quiz.ID {
+		t.Errorf("hub closed quizzes %v, want the deleted quiz", closed)
+	}
+}
+
+// validExport returns an export of a one-question quiz in the current format
+func validExport() dto.QuizExport {
+	return dto.QuizExport{
+		Version:  dto.QuizExportVersion,
+		Title:    "Shared quiz",
+		Settings: model.QuizSettings{MaxParticipants: 20, ShuffleOptions: true},
+		Questions: []dto.QuestionCreateData{{
+			Text:         "2 + 2?",
+			QuestionType: string(model.QuestionTypeSingleChoice),
+			TimeLimit:    15,
+			Options:      []dto.OptionCreateData{{Text: "4", IsCorrect: true}, {Text: "5"}},
+		}},
+	}
+}
+
+func TestImportQuizCreatesAWaitingQuizForTheImporter(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	importer := env.seedCreator(t)
+
+	imported, err := env.quizzes.ImportQuiz(ctx, validExport(), importer.ID)
+	if err != nil {
+		t.Fatalf("ImportQuiz: %v", err)
+	}
+
+	stored, err := env.quizzes.GetQuiz(ctx, imported.ID)
+	if err != nil {
+		t.Fatalf("imported quiz was not stored: %v", err)
+	}
+	if stored.CreatorID != importer.ID || stored.Status != model.QuizStatusWaiting || stored.Code == "" {
+		t.Errorf("imported quiz is owned by %s with status %s and code %q", stored.CreatorID, stored.Status, stored.Code)
+	}
+	if stored.Settings.MaxParticipants != 20 || !stored.Settings.ShuffleOptions {
+		t.Errorf("imported quiz settings = %+v", stored.Settings)
+	}
+	if reexported := env.exportQuiz(t, imported.ID); !reflect.DeepEqual(reexported.Questions, validExport().Questions) {
+		t.Errorf("imported questions export as %+v", reexported.Questions)
+	}
+}
+
+func TestImportQuizRejectsBadExports(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*dto.QuizExport)
+		wantErr error
+	}{
+		{name: "unversioned export", change: func(e *dto.QuizExport) { e.Version = 0 }, wantErr: ErrUnsupportedExportVersion},
+		{name: "newer export version", change: func(e *dto.QuizExport) { e.Version = dto.QuizExportVersion + 1 }, wantErr: ErrUnsupportedExportVersion},
+		{name: "no questions", change: func(e *dto.QuizExport) { e.Questions = nil }},
+		{name: "question without a correct option", change: func(e *dto.QuizExport) { e.Questions[0].Options[0].IsCorrect = false }},
+		{name: "negative participant cap", change: func(e *dto.QuizExport) { e.Settings.MaxParticipants = -1 }, wantErr: ErrInvalidSettings},
+		{name: "unknown late answer policy", change: func(e *dto.QuizExport) { e.Settings.LateAnswerPolicy = "ALWAYS" }, wantErr: ErrInvalidSettings},
+		{name: "unknown tiebreak", change: func(e *dto.QuizExport) { e.Settings.Tiebreak = "alphabetical" }, wantErr: ErrInvalidSettings},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			export := validExport()
+			tt.change(&export)
+
+			_, err := env.quizzes.ImportQuiz(context.Background(), export, env.seedCreator(t).ID)
+			if err == nil {
+				t.Fatal("import succeeded")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("import returned %v, want %v", err, tt.wantErr)
+			}
+			if env.store.callCount("CreateQuizWithContent") != 0 {
+				t.Error("a rejected export was saved")
+			}
+		})
+	}
+}
+
+func TestImportQuizSeedsSettingsLikeTheSettingsEndpoint(t *testing.T) {
+	env := newTestEnv(t)
+	export := validExport()
+	export.Settings = model.QuizSettings{
+		Tiebreak:         model.LeaderboardTiebreakRandom,
+		ShuffleQuestions: true,
+		LateAnswerPolicy: model.LateAnswerPolicyAcceptNoBonus,
+	}
+
+	imported, err := env.quizzes.ImportQuiz(context.Background(), export, env.seedCreator(t).ID)
+	if err != nil {
+		t.Fatalf("ImportQuiz: %v", err)
+	}
+	if imported.Settings.TiebreakSeed == 0 || imported.Settings.ShuffleSeed == 0 {
+		t.Errorf("imported settings %+v, want the random tiebreak and shuffle seeded", imported.Settings)
+	}
+	if imported.Settings.LateAnswerPolicy != model.LateAnswerPolicyAcceptNoBonus {
+		t.Errorf("late answer policy is %q, want it kept", imported.Settings.LateAnswerPolicy)
+	}
+
+	// Seeds that nothing uses are dropped, as the settings endpoint does
+	export.Settings = model.QuizSettings{Tiebreak: model.LeaderboardTiebreakTime, TiebreakSeed: 42, ShuffleSeed: 7}
+	imported, err = env.quizzes.ImportQuiz(context.Background(), export, env.seedCreator(t).ID)
+	if err != nil {
+		t.Fatalf("ImportQuiz: %v", err)
+	}
+	if imported.Settings.TiebreakSeed != 0 || imported.Settings.ShuffleSeed != 0 {
+		t.Errorf("imported settings %+v, want the unused seeds cleared", imported.Settings)
+	}
+}
+
+func TestUpdateQuizSettingsRejectsUnknownEnums(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+
+	if _, err := env.quizzes.UpdateQuizSettings(context.Background(), quiz.ID, model.QuizSettings{Tiebreak: "alphabetical"}); !errors.Is(err, ErrInvalidSettings) {
+		t.Errorf("unknown tiebreak: got %v, want ErrInvalidSettings", err)
+	}
+	if got := len(env.hub.events(websocket.EventSettingsUpdated)); got != 0 {
+		t.Errorf("rejected settings were announced %d times", got)
+	}
+}
+
+// questionsOfLength returns count single choice questions
+func questionsOfLength(count int) []dto.QuestionCreateData {
+	questions := make([]dto.QuestionCreateData, count)
+	for i := range questions {
+		questions[i] = dto.QuestionCreateData{
+			Text:         fmt.Sprintf("Question %d", i+1),
+			QuestionType: string(model.QuestionTypeSingleChoice),
+			TimeLimit:    20,
+			Options:      []dto.OptionCreateData{{Text: "Yes", IsCorrect: true}, {Text: "No"}},
+		}
+	}
+	return questions
+}
+
+func TestCreateQuizWithQuestionsEnforcesContentLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		title       string
+		description string
+		questions   []dto.QuestionCreateData
+		wantField   string
+	}{
+		{name: "at the question cap", title: "Quiz", questions: questionsOfLength(3)},
+		{name: "over the question cap", title: "Quiz", questions: questionsOfLength(4), wantField: "questions"},
+		{name: "title at the limit in multibyte characters", title: strings.Repeat("é", 10), questions: questionsOfLength(1)},
+		{name: "over-length title", title: strings.Repeat("t", 11), questions: questionsOfLength(1), wantField: "title"},
+		{name: "title of control characters only", title: "\x00\x07", questions: questionsOfLength(1), wantField: "title"},
+		{name: "over-length description", title: "Quiz", description: strings.Repeat("d", 21), questions: questionsOfLength(1), wantField: "description"},
+		{name: "over-length question text", title: "Quiz", questions: func() []dto.QuestionCreateData {
+			questions := questionsOfLength(2)
+			questions[1].Text = strings.Repeat("q", maxQuestionTextLength+1)
+			return questions
+		}(), wantField: "questions[1].text"},
+		{name: "over-length option text", title: "Quiz", questions: func() []dto.QuestionCreateData {
+			questions := questionsOfLength(1)
+			questions[0].Options[1].Text = strings.Repeat("o", maxOptionTextLength+1)
+			return questions
+		}(), wantField: "questions[0].options[1].text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.QuizConfig) {
+				cfg.MaxQuestionsPerQuiz = 3
+				cfg.MaxTitleLength = 10
+				cfg.MaxDescriptionLength = 20
+			})
+
+			_, err := env.quizzes.CreateQuizWithQuestions(context.Background(), tt.title, tt.description, env.seedCreator(t).ID, tt.questions)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("CreateQuizWithQuestions: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidContent) || !strings.Contains(err.Error(), tt.wantField) {
+				t.Errorf("CreateQuizWithQuestions returned %v, want ErrInvalidContent naming %s", err, tt.wantField)
+			}
+			if env.store.callCount("CreateQuizWithContent") != 0 {
+				t.Error("rejected content was saved")
+			}
+		})
+	}
+}
+
+func TestCreateGuestQuizStoresTheGuestWithTheQuiz(t *testing.T) {
+	env := newTestEnv(t)
+
+	quiz, guest, err := env.quizzes.CreateGuestQuiz(context.Background(), "  ", "Pub quiz", "", questionsOfLength(1))
+	if err != nil {
+		t.Fatalf("CreateGuestQuiz: %v", err)
+	}
+	if !guest.IsGuest || guest.Name != defaultGuestName || quiz.CreatorID != guest.ID {
+		t.Errorf("guest %+v created quiz owned by %s", guest, quiz.CreatorID)
+	}
+	stored, err := (&fakeUserRepo{env.store}).GetUserByID(context.Background(), guest.ID)
+	if err != nil || !stored.IsGuest {
+		t.Errorf("guest was not stored: %v", err)
+	}
+}
+
+func TestCreateGuestQuizLeavesNoGuestWhenTheQuizIsRejected(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.QuizConfig) { cfg.MaxTitleLength = 10 })
+
+	_, _, err := env.quizzes.CreateGuestQuiz(context.Background(), "Host", strings.Repeat("t", 11), "", questionsOfLength(1))
+	if !errors.Is(err, ErrInvalidContent) {
+		t.Fatalf("CreateGuestQuiz returned %v, want ErrInvalidContent", err)
+	}
+	env.store.mu.Lock()
+	users := len(env.store.users)
+	env.store.mu.Unlock()
+	if users != 0 || env.store.callCount("CreateGuestQuizWithContent") != 0 {
+		t.Errorf("a rejected guest quiz left %d users behind", users)
+	}
+}
+
+func TestCreateQuizWithQuestionsStripsControlCharacters(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	questions := questionsOfLength(1)
+	questions[0].Text = "  Line one\nline\x00 two\x1b "
+	questions[0].Options[0].Text = "Ye\ts\n"
+
+	quiz, err := env.quizzes.CreateQuizWithQuestions(ctx, " Title\x07\n", "First\nsecond\x00", env.seedCreator(t).ID, questions)
+	if err != nil {
+		t.Fatalf("CreateQuizWithQuestions: %v", err)
+	}
+	if quiz.Title != "Title" || quiz.Description != "First\nsecond" {
+		t.Errorf("quiz was saved with title %q and description %q", quiz.Title, quiz.Description)
+	}
+
+	stored, err := env.questions.GetQuestions(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("GetQuestions: %v", err)
+	}
+	if stored[0].Text != "Line one\nline two" || stored[0].Options[0].Text != "Yes" {
+		t.Errorf("question was saved as %q with option %q", stored[0].Text, stored[0].Options[0].Text)
+	}
+	if questions[0].Text != "  Line one\nline\x00 two\x1b " {
+		t.Error("sanitizing changed the caller's questions")
+	}
+}
+
+func TestImportQuizEnforcesTheQuestionCap(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.QuizConfig) { cfg.MaxQuestionsPerQuiz = 3 })
+	export := validExport()
+	export.Questions = questionsOfLength(4)
+
+	if _, err := env.quizzes.ImportQuiz(context.Background(), export, env.seedCreator(t).ID); !errors.Is(err, ErrInvalidContent) {
+		t.Errorf("importing too many questions returned %v, want ErrInvalidContent", err)
+	}
+}
+
+// newQuestionUpdates returns count new single choice questions to send in a quiz update
+func newQuestionUpdates(count int) []dto.QuestionUpdateData {
+	updates := make([]dto.QuestionUpdateData, count)
+	for i, q := range questionsOfLength(count) {
+		updates[i] = dto.QuestionUpdateData{
+			Text:         q.Text,
+			TimeLimit:    q.TimeLimit,
+			QuestionType: q.QuestionType,
+			Options:      []dto.OptionData{{Text: "Yes", IsCorrect: true}, {Text: "No"}},
+		}
+	}
+	return updates
+}
+
+func TestUpdateQuizWithQuestionsEnforcesContentLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		edit      func(updates []dto.QuestionUpdateData) []dto.QuestionUpdateData
+		wantField string
+	}{
+		{name: "at the question cap", edit: func(u []dto.QuestionUpdateData) []dto.QuestionUpdateData {
+			return append(u, newQuestionUpdates(2)...)
+		}},
+		{name: "over the question cap", edit: func(u []dto.QuestionUpdateData) []dto.QuestionUpdateData {
+			return append(u, newQuestionUpdates(3)...)
+		}, wantField: "questions"},
+		{name: "over-length question text", edit: func(u []dto.QuestionUpdateData) []dto.QuestionUpdateData {
+			u[0].Text = strings.Repeat("q", maxQuestionTextLength+1)
+			return u
+		}, wantField: "questions[0].text"},
+		{name: "question text of control characters only", edit: func(u []dto.QuestionUpdateData) []dto.QuestionUpdateData {
+			u[0].Text = "\x00\x1b"
+			return u
+		}, wantField: "questions[0].text"},
+		{name: "over-length option text of a new question", edit: func(u []dto.QuestionUpdateData) []dto.QuestionUpdateData {
+			added := newQuestionUpdates(1)
+			added[0].Options[1].Text = strings.Repeat("o", maxOptionTextLength+1)
+			return append(u, added...)
+		}, wantField: "questions[1].options[1].text"},
+		{name: "over-length explanation", edit: func(u []dto.QuestionUpdateData) []dto.QuestionUpdateData {
+			u[0].Explanation = strings.Repeat("e", maxExplanationLength+1)
+			return u
+		}, wantField: "questions[0].explanation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.QuizConfig) { cfg.MaxQuestionsPerQuiz = 3 })
+			quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+			question := env.seedQuestion(t, quiz, 1)
+			updates := tt.edit([]dto.QuestionUpdateData{questionUpdate(question)})
+
+			_, err := env.quizzes.UpdateQuizWithQuestions(context.Background(), quiz.ID, quiz.Title, "", updates, false)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("UpdateQuizWithQuestions: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidContent) || !strings.Contains(err.Error(), tt.wantField) {
+				t.Errorf("UpdateQuizWithQuestions returned %v, want ErrInvalidContent naming %s", err, tt.wantField)
+			}
+			for _, method := range []string{"UpdateQuiz", "UpdateQuestion", "CreateQuestion"} {
+				// The seeded question accounts for one CreateQuestion call
+				want := 0
+				if method == "CreateQuestion" {
+					want = 1
+				}
+				if got := env.store.callCount(method); got != want {
+					t.Errorf("rejected content was saved with %d %s calls", got-want, method)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateQuizWithQuestionsComparesSanitizedTextOnceAnswered(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1)
+	env.seedAnswer(t, env.seedParticipant(t, quiz, "Ann"), question, correctOption(question))
+
+	// Resending the stored text wrapped in whitespace and control characters is not an edit
+	update := questionUpdate(question)
+	update.Text = "  " + question.Text + "\x00 "
+	update.Options[0].Text = update.Options[0].Text + "\x07\n"
+	if _, err := env.quizzes.UpdateQuizWithQuestions(ctx, quiz.ID, quiz.Title, "", []dto.QuestionUpdateData{update}, false); err != nil {
+		t.Fatalf("UpdateQuizWithQuestions: %v", err)
+	}
+
+	stored, err := env.questions.GetQuestions(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("GetQuestions: %v", err)
+	}
+	if stored[0].Text != question.Text || stored[0].Options[0].Text != question.Options[0].Text {
+		t.Errorf("question was saved as %q with option %q", stored[0].Text, stored[0].Options[0].Text)
+	}
+}
+
+func TestUpdateQuizSettingsKeepsTheRandomTiebreakSeed(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+
+	random := model.QuizSettings{Tiebreak: model.LeaderboardTiebreakRandom}
+	updated, err := env.quizzes.UpdateQuizSettings(ctx, quiz.ID, random)
+	if err != nil {
+		t.Fatalf("UpdateQuizSettings: %v", err)
+	}
+	seed := updated.Settings.TiebreakSeed
+	if seed == 0 {
+		t.Fatal("random tiebreak was saved without a seed")
+	}
+
+	// Saving the settings again must not reshuffle tied participants
+	if updated, err = env.quizzes.UpdateQuizSettings(ctx, quiz.ID, random); err != nil {
+		t.Fatalf("UpdateQuizSettings: %v", err)
+	}
+	if updated.Settings.TiebreakSeed != seed {
+		t.Errorf("resaving changed the seed from %d to %d", seed, updated.Settings.TiebreakSeed)
+	}
+
+	// A seed the host picks is kept as given, so results can be reproduced elsewhere
+	random.TiebreakSeed = 99
+	if updated, err = env.quizzes.UpdateQuizSettings(ctx, quiz.ID, random); err != nil {
+		t.Fatalf("UpdateQuizSettings: %v", err)
+	}
+	if updated.Settings.TiebreakSeed != 99 {
+		t.Errorf("chosen seed was saved as %d", updated.Settings.TiebreakSeed)
+	}
+
+	updated, err = env.quizzes.UpdateQuizSettings(ctx, quiz.ID, model.QuizSettings{Tiebreak: model.LeaderboardTiebreakJoinOrder, TiebreakSeed: 99})
+	if err != nil {
+		t.Fatalf("UpdateQuizSettings: %v", err)
+	}
+	if updated.Settings.TiebreakSeed != 0 {
+		t.Errorf("join order tiebreak kept seed %d", updated.Settings.TiebreakSeed)
+	}
+}
+
+func TestArchivedQuizzesAreHiddenFromTheListByDefault(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	creator := env.seedCreator(t)
+	kept, err := env.quizzes.CreateQuiz(ctx, "Kept", "", creator.ID)
+	if err != nil {
+		t.Fatalf("CreateQuiz: %v", err)
+	}
+	finished, err := env.quizzes.CreateQuiz(ctx, "Finished", "", creator.ID)
+	if err != nil {
+		t.Fatalf("CreateQuiz: %v", err)
+	}
+	env.store.mu.Lock()
+	env.store.quizzes[finished.ID].Status = model.QuizStatusCompleted
+	env.store.mu.Unlock()
+
+	// Completed quizzes can be archived, unlike deleted
+	archived, err := env.quizzes.ArchiveQuiz(ctx, finished.ID)
+	if err != nil {
+		t.Fatalf("ArchiveQuiz of a completed quiz: %v", err)
+	}
+	if archived.ArchivedAt == nil {
+		t.Fatal("archived quiz has no archive time")
+	}
+
+	titles := func(filter model.QuizFilter) string {
+		t.Helper()
+		quizzes, total, err := env.quizzes.GetQuizzesByCreatorID(ctx, creator.ID, filter)
+		if err != nil {
+			t.Fatalf("GetQuizzesByCreatorID: %v", err)
+		}
+		var titles []string
+		for _, quiz := range quizzes {
+			titles = append(titles, quiz.Title)
+		}
+		if total != len(quizzes) {
+			t.Errorf("total of %d does not match the %d quizzes listed", total, len(quizzes))
+		}
+		return fmt.Sprint(titles)
+	}
+
+	if got := titles(model.QuizFilter{}); got != fmt.Sprint([]string{kept.Title}) {
+		t.Errorf("default listing = %s, want the archived quiz left out", got)
+	}
+	if got := titles(model.QuizFilter{IncludeArchived: true}); got != fmt.Sprint([]string{finished.Title, kept.Title}) {
+		t.Errorf("listing with archived quizzes = %s, want both", got)
+	}
+	if quiz, err := env.quizzes.GetQuiz(ctx, finished.ID); err != nil || quiz.ArchivedAt == nil {
+		t.Errorf("archived quiz can't be retrieved directly: %v", err)
+	}
+
+	if _, err := env.quizzes.UnarchiveQuiz(ctx, finished.ID); err != nil {
+		t.Fatalf("UnarchiveQuiz: %v", err)
+	}
+	if got := titles(model.QuizFilter{}); got != fmt.Sprint([]string{finished.Title, kept.Title}) {
+		t.Errorf("listing after unarchiving = %s, want both", got)
+	}
+}
+
+func TestArchiveQuizRefusesARunningQuiz(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+
+	if _, err := env.quizzes.ArchiveQuiz(context.Background(), quiz.ID); !errors.Is(err, ErrQuizRunning) {
+		t.Errorf("ArchiveQuiz of a running quiz = %v, want %v", err, ErrQuizRunning)
+	}
+}
+
+func TestQuizDetailsAreLimitedOnCreateAndUpdate(t *testing.T) {
+	write := map[string]func(env *testEnv, title, description string) (*model.Quiz, error){
+		"CreateQuiz": func(env *testEnv, title, description string) (*model.Quiz, error) {
+			return env.quizzes.CreateQuiz(context.Background(), title, description, env.seedCreator(t).ID)
+		},
+		"UpdateQuiz": func(env *testEnv, title, description string) (*model.Quiz, error) {
+			quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+			return env.quizzes.UpdateQuiz(context.Background(), quiz.ID, title, description)
+		},
+		"UpdateQuizWithQuestions": func(env *testEnv, title, description string) (*model.Quiz, error) {
+			quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+			return env.quizzes.UpdateQuizWithQuestions(context.Background(), quiz.ID, title, description, nil, false)
+		},
+	}
+
+	tests := []struct {
+		name        string
+		title       string
+		description string
+		wantField   string
+	}{
+		{name: "over-length title", title: strings.Repeat("t", 11), wantField: "title"},
+		{name: "over-length description", title: "Quiz", description: strings.Repeat("d", 21), wantField: "description"},
+		{name: "blank title", title: "  \t ", wantField: "title"},
+	}
+
+	for method, write := range write {
+		for _, tt := range tests {
+			t.Run(method+"/"+tt.name, func(t *testing.T) {
+				env := newTestEnv(t, func(cfg *config.QuizConfig) {
+					cfg.MaxTitleLength = 10
+					cfg.MaxDescriptionLength = 20
+				})
+				_, err := write(env, tt.title, tt.description)
+				if !errors.Is(err, ErrInvalidContent) || !strings.Contains(err.Error(), tt.wantField) {
+					t.Errorf("%s returned %v, want ErrInvalidContent naming %s", method, err, tt.wantField)
+				}
+				if env.store.callCount("CreateQuiz")+env.store.callCount("UpdateQuiz") != 0 {
+					t.Error("rejected details were saved")
+				}
+			})
+		}
+	}
+
+	t.Run("surrounding whitespace does not count", func(t *testing.T) {
+		env := newTestEnv(t, func(cfg *config.QuizConfig) {
+			cfg.MaxTitleLength = 10
+			cfg.MaxDescriptionLength = 20
+		})
+		for _, method := range []string{"CreateQuiz", "UpdateQuiz"} {
+			quiz, err := write[method](env, "  "+strings.Repeat("t", 10)+"\n", " "+strings.Repeat("d", 20)+"  ")
+			if err != nil {
+				t.Fatalf("%s: %v", method, err)
+			}
+			if quiz.Title != strings.Repeat("t", 10) || quiz.Description != strings.Repeat("d", 20) {
+				t.Errorf("%s saved title %q and description %q, want them trimmed", method, quiz.Title, quiz.Description)
+			}
+		}
+	})
+
+	t.Run("titles never exceed the column", func(t *testing.T) {
+		env := newTestEnv(t, func(cfg *config.QuizConfig) { cfg.MaxTitleLength = 0 })
+		if _, err := write["CreateQuiz"](env, strings.Repeat("t", titleColumnLength+1), ""); !errors.Is(err, ErrInvalidContent) {
+			t.Errorf("CreateQuiz of a title longer than the column returned %v, want ErrInvalidContent", err)
+		}
+		if _, err := write["CreateQuiz"](env, strings.Repeat("t", titleColumnLength), ""); err != nil {
+			t.Errorf("CreateQuiz of a title filling the column: %v", err)
+		}
+	})
+}
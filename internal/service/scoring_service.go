@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// scoringServiceImpl implements ScoringService interface
+type scoringServiceImpl struct {
+	quizRepo     repository.QuizRepository
+	questionRepo repository.QuestionRepository
+	answerRepo   repository.AnswerRepository
+}
+
+// NewScoringService creates a new scoring service
+func NewScoringService(
+	quizRepo repository.QuizRepository,
+	questionRepo repository.QuestionRepository,
+	answerRepo repository.AnswerRepository,
+) ScoringService {
+	return &scoringServiceImpl{
+		quizRepo:     quizRepo,
+		questionRepo: questionRepo,
+		answerRepo:   answerRepo,
+	}
+}
+
+// GetScoringReport aggregates the recorded answers of a quiz into per-question and overall scoring
+// figures. Voided questions are reported with the points their answers originally earned.
+func (s *scoringServiceImpl) GetScoringReport(ctx context.Context, quizID uuid.UUID) (*dto.ScoringReportDTO, error) {
+	if _, err := s.quizRepo.GetQuizByID(ctx, quizID); err != nil {
+		return nil, ErrQuizNotFound
+	}
+
+	questions, err := s.questionRepo.GetQuestionsByQuizID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.ScoringReportDTO{
+		QuizID:    quizID,
+		Questions: []dto.QuestionScoringDTO{},
+	}
+
+	var allAnswers []*model.Answer
+	for _, question := range questions {
+		answers, err := s.answerRepo.GetAnswersByQuestionID(ctx, question.ID)
+		if err != nil {
+			return nil, err
+		}
+		allAnswers = append(allAnswers, answers...)
+
+		report.Questions = append(report.Questions, dto.QuestionScoringDTO{
+			QuestionID:        question.ID,
+			Order:             question.Order,
+			Voided:            question.VoidedPoints != nil,
+			ScoringSummaryDTO: summarizeScoring(answers),
+		})
+	}
+	report.ScoringSummaryDTO = summarizeScoring(allAnswers)
+
+	return report, nil
+}
+
+// summarizeScoring computes the scoring aggregates of a set of answers. An answer earned the
+// time bonus when it was awarded more than the base points of a correct answer.
+func summarizeScoring(answers []*model.Answer) dto.ScoringSummaryDTO {
+	summary := dto.ScoringSummaryDTO{
+		TotalAnswers:       len(answers),
+		PointsDistribution: make(map[int]int),
+	}
+	if len(answers) == 0 {
+		return summary
+	}
+
+	totalScore := 0
+	for _, answer := range answers {
+		totalScore += answer.Score
+		summary.PointsDistribution[answer.Score]++
+		if answer.IsCorrect {
+			summary.CorrectAnswers++
+			if answer.Score > model.CorrectAnswerPoints {
+				summary.TimeBonusCount++
+			}
+		}
+	}
+
+	summary.AverageScore = float64(totalScore) / float64(len(answers))
+	summary.TimeBonusRate = float64(summary.TimeBonusCount) / float64(len(answers))
+
+	return summary
+}
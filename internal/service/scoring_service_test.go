@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+// seedScoredAnswer records an answer of participant awarded score points
+func (e *testEnv) seedScoredAnswer(t *testing.T, participant *model.Participant, question *model.Question, score int) {
+	t.Helper()
+
+	correct := score > 0
+	option := wrongOption(question)
+	if correct {
+		option = correctOption(question)
+	}
+	answer, err := model.NewAnswer(participant.ID, question.ID, []string{option}, 5, correct)
+	if err != nil {
+		t.Fatalf("seed answer: %v", err)
+	}
+	answer.Score = score
+	if err := e.answerRepo.CreateAnswer(context.Background(), answer); err != nil {
+		t.Fatalf("seed answer: %v", err)
+	}
+}
+
+// checkSummary compares scoring aggregates, allowing for rounding in the averages and rates
+func checkSummary(t *testing.T, label string, got dto.ScoringSummaryDTO, want dto.ScoringSummaryDTO) {
+	t.Helper()
+
+	if got.TotalAnswers != want.TotalAnswers || got.CorrectAnswers != want.CorrectAnswers || got.TimeBonusCount != want.TimeBonusCount {
+		t.Errorf("%s counts %d answers, %d correct, %d time bonuses; want %d, %d, %d", label,
+			got.TotalAnswers, got.CorrectAnswers, got.TimeBonusCount, want.TotalAnswers, want.CorrectAnswers, want.TimeBonusCount)
+	}
+	if math.Abs(got.AverageScore-want.AverageScore) > 1e-9 || math.Abs(got.TimeBonusRate-want.TimeBonusRate) > 1e-9 {
+		t.Errorf("%s averages %v points with a time bonus rate of %v; want %v and %v", label,
+			got.AverageScore, got.TimeBonusRate, want.AverageScore, want.TimeBonusRate)
+	}
+	if !reflect.DeepEqual(got.PointsDistribution, want.PointsDistribution) {
+		t.Errorf("%s points distribution = %v, want %v", label, got.PointsDistribution, want.PointsDistribution)
+	}
+}
+
+func TestScoringReportAggregatesKnownAnswers(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusCompleted, model.QuizSettings{})
+	first := env.seedQuestion(t, quiz, 1)
+	second := env.seedQuestion(t, quiz, 2)
+	unanswered := env.seedQuestion(t, quiz, 3)
+	ann := env.seedParticipant(t, quiz, "Ann")
+	bob := env.seedParticipant(t, quiz, "Bob")
+	cat := env.seedParticipant(t, quiz, "Cat")
+
+	bonus := model.CorrectAnswerPoints + model.TimeBonusPoints
+	env.seedScoredAnswer(t, ann, first, bonus)
+	env.seedScoredAnswer(t, bob, first, model.CorrectAnswerPoints)
+	env.seedScoredAnswer(t, cat, first, 0)
+	env.seedScoredAnswer(t, ann, second, model.CorrectAnswerPoints)
+	env.seedScoredAnswer(t, bob, second, 0)
+	// A voided question still reports the points its answers earned
+	if err := env.questionRepo.VoidQuestion(ctx, second.ID, 0); err != nil {
+		t.Fatalf("void question: %v", err)
+	}
+
+	report, err := NewScoringService(env.quizRepo, env.questionRepo, env.answerRepo).GetScoringReport(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("GetScoringReport: %v", err)
+	}
+
+	if len(report.Questions) != 3 {
+		t.Fatalf("report covers %d questions, want 3", len(report.Questions))
+	}
+	byQuestion := make(map[uuid.UUID]dto.QuestionScoringDTO)
+	for _, question := range report.Questions {
+		byQuestion[question.QuestionID] = question
+	}
+
+	checkSummary(t, "first question", byQuestion[first.ID].ScoringSummaryDTO, dto.ScoringSummaryDTO{
+		TotalAnswers:       3,
+		CorrectAnswers:     2,
+		AverageScore:       float64(bonus+model.CorrectAnswerPoints) / 3,
+		TimeBonusCount:     1,
+		TimeBonusRate:      1.0 / 3,
+		PointsDistribution: map[int]int{bonus: 1, model.CorrectAnswerPoints: 1, 0: 1},
+	})
+	checkSummary(t, "second question", byQuestion[second.ID].ScoringSummaryDTO, dto.ScoringSummaryDTO{
+		TotalAnswers:       2,
+		CorrectAnswers:     1,
+		AverageScore:       float64(model.CorrectAnswerPoints) / 2,
+		PointsDistribution: map[int]int{model.CorrectAnswerPoints: 1, 0: 1},
+	})
+	checkSummary(t, "unanswered question", byQuestion[unanswered.ID].ScoringSummaryDTO, dto.ScoringSummaryDTO{
+		PointsDistribution: map[int]int{},
+	})
+	checkSummary(t, "quiz", report.ScoringSummaryDTO, dto.ScoringSummaryDTO{
+		TotalAnswers:       5,
+		CorrectAnswers:     3,
+		AverageScore:       float64(bonus+2*model.CorrectAnswerPoints) / 5,
+		TimeBonusCount:     1,
+		TimeBonusRate:      1.0 / 5,
+		PointsDistribution: map[int]int{bonus: 1, model.CorrectAnswerPoints: 2, 0: 2},
+	})
+
+	if byQuestion[first.ID].Voided || !byQuestion[second.ID].Voided {
+		t.Errorf("voided flags are %v and %v, want only the second question voided", byQuestion[first.ID].Voided, byQuestion[second.ID].Voided)
+	}
+}
+
+func TestScoringReportOfUnknownQuiz(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := NewScoringService(env.quizRepo, env.questionRepo, env.answerRepo).GetScoringReport(context.Background(), uuid.New())
+	if !errors.Is(err, ErrQuizNotFound) {
+		t.Errorf("report of an unknown quiz returned %v, want ErrQuizNotFound", err)
+	}
+}
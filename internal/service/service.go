@@ -2,12 +2,54 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
 	"github.com/google/uuid"
 )
 
+// EventHub is the part of the WebSocket hub the services use to reach connected clients.
+// Both websocket.RedisHub and websocket.MemoryHub provide it, and services depend on nothing else,
+// so any implementation, including a fake recording the events, can stand in for them.
+type EventHub interface {
+	// BroadcastToQuiz, BroadcastToCreators and BroadcastToParticipants reach the clients on this instance only
+	BroadcastToQuiz(quizID uuid.UUID, event websocket.Event)
+	BroadcastToCreators(quizID uuid.UUID, event websocket.Event)
+	BroadcastToParticipants(quizID uuid.UUID, event websocket.Event)
+
+	// PublishToQuiz, PublishToCreators and PublishToParticipants reach the clients on every instance
+	PublishToQuiz(quizID uuid.UUID, event websocket.Event) error
+	PublishToCreators(quizID uuid.UUID, event websocket.Event) error
+	PublishToParticipants(quizID uuid.UUID, event websocket.Event) error
+
+	// CloseQuiz tells every client of a deleted quiz and closes their connections
+	CloseQuiz(quizID uuid.UUID) error
+	// DisconnectUser closes a user's or participant's connections to a quiz on this instance
+	DisconnectUser(quizID uuid.UUID, userID uuid.UUID, reason model.DisconnectReason)
+	// ReplaceConnection closes a participant's older connections held by other instances
+	ReplaceConnection(quizID uuid.UUID, participantID uuid.UUID) error
+	// KickParticipant sends a USER_KICKED event to every client of a quiz on every instance, then closes
+	// the kicked participant's connections wherever they are held
+	KickParticipant(quizID uuid.UUID, participantID uuid.UUID, event websocket.Event) error
+
+	// StartQuestionAcks and ClearQuestionAcks manage the delivery acknowledgements of the current question
+	StartQuestionAcks(quizID uuid.UUID, questionID uuid.UUID)
+	ClearQuestionAcks(quizID uuid.UUID)
+
+	// StartTimerBroadcastUntil and StartCountdownBroadcast tick until their deadline or until ctx is cancelled
+	StartTimerBroadcastUntil(ctx context.Context, quizID uuid.UUID, endTime time.Time, totalSeconds int)
+	StartCountdownBroadcast(ctx context.Context, quizID uuid.UUID, seconds int) bool
+}
+
+// QuizStateCache is the part of the state service that services writing to a quiz outside it use,
+// so connects after a join, kick or score change do not reuse a state computed before it
+type QuizStateCache interface {
+	// InvalidateQuizState drops the cached states of a quiz
+	InvalidateQuizState(quizID uuid.UUID)
+}
+
 // QuizService defines operations for quiz business logic
 type QuizService interface {
 	// CreateQuiz creates a new quiz
@@ -16,18 +58,31 @@ type QuizService interface {
 	// CreateQuizWithQuestions creates a new quiz with questions
 	CreateQuizWithQuestions(ctx context.Context, title string, description string, creatorID uuid.UUID, questions []dto.QuestionCreateData) (*model.Quiz, error)
 
+	// CreateGuestQuiz creates a quiz with questions and the temporary guest host who owns it, atomically
+	CreateGuestQuiz(ctx context.Context, hostName string, title string, description string, questions []dto.QuestionCreateData) (*model.Quiz, *model.User, error)
+
+	// ImportQuiz creates a new quiz owned by creatorID from an exported quiz definition
+	ImportQuiz(ctx context.Context, export dto.QuizExport, creatorID uuid.UUID) (*model.Quiz, error)
+
+	// CloneQuiz copies a quiz with its questions and options into a new waiting quiz owned by newCreatorID
+	CloneQuiz(ctx context.Context, quizID uuid.UUID, newCreatorID uuid.UUID) (*model.Quiz, error)
+
 	// GetQuiz retrieves a quiz by ID
 	GetQuiz(ctx context.Context, id uuid.UUID) (*model.Quiz, error)
 
 	// GetQuizByCode retrieves a quiz by its code
 	GetQuizByCode(ctx context.Context, code string) (*model.Quiz, error)
 
-	// GetQuizzesByCreatorID retrieves all quizzes created by a user
-	GetQuizzesByCreatorID(ctx context.Context, creatorID uuid.UUID) ([]*model.Quiz, error)
+	// GetQuizzesByCreatorID retrieves a page of the quizzes created by a user that match filter,
+	// along with how many match in total
+	GetQuizzesByCreatorID(ctx context.Context, creatorID uuid.UUID, filter model.QuizFilter) ([]*model.Quiz, int, error)
 
 	// StartQuiz starts a quiz session
 	StartQuiz(ctx context.Context, quizID uuid.UUID) error
 
+	// StartQuizAfterCountdown counts down in the lobby for the given number of seconds, then starts the quiz
+	StartQuizAfterCountdown(ctx context.Context, quizID uuid.UUID, seconds int) error
+
 	// EndQuiz ends a quiz session
 	EndQuiz(ctx context.Context, quizID uuid.UUID) error
 
@@ -37,52 +92,172 @@ type QuizService interface {
 	// UpdateQuiz updates an existing quiz's basic info
 	UpdateQuiz(ctx context.Context, quizID uuid.UUID, title string, description string) (*model.Quiz, error)
 
-	// UpdateQuizWithQuestions updates an existing quiz with its questions
-	UpdateQuizWithQuestions(ctx context.Context, quizID uuid.UUID, title string, description string, questions []dto.QuestionUpdateData) (*model.Quiz, error)
+	// UpdateQuizWithQuestions updates an existing quiz with its questions.
+	// Once answers are recorded structural edits are rejected and text edits require force.
+	UpdateQuizWithQuestions(ctx context.Context, quizID uuid.UUID, title string, description string, questions []dto.QuestionUpdateData, force bool) (*model.Quiz, error)
 
 	// DeleteQuiz deletes a quiz and all its related data
 	DeleteQuiz(ctx context.Context, quizID uuid.UUID) error
+
+	// ArchiveQuiz hides a quiz that is not running from its creator's quiz list, keeping all its data
+	ArchiveQuiz(ctx context.Context, quizID uuid.UUID) (*model.Quiz, error)
+
+	// UnarchiveQuiz returns an archived quiz to its creator's quiz list
+	UnarchiveQuiz(ctx context.Context, quizID uuid.UUID) (*model.Quiz, error)
+
+	// RegenerateCode replaces the join code of a waiting quiz with a fresh unique one
+	RegenerateCode(ctx context.Context, quizID uuid.UUID) (*model.Quiz, error)
+
+	// UpdateQuizSettings replaces a quiz's settings and notifies connected clients
+	UpdateQuizSettings(ctx context.Context, quizID uuid.UUID, settings model.QuizSettings) (*model.Quiz, error)
 }
 
 // QuestionService defines operations for question business logic
 type QuestionService interface {
-	// AddQuestion adds a question to a quiz
-	AddQuestion(ctx context.Context, quizID uuid.UUID, text string, options []dto.OptionCreateData, questionType string, timeLimit int) (*model.Question, error)
+	// AddQuestion adds a question to a quiz; imageURL and explanation are optional
+	AddQuestion(ctx context.Context, quizID uuid.UUID, text string, options []dto.OptionCreateData, questionType string, timeLimit int, imageURL string, explanation string, required bool) (*model.Question, error)
 
 	// GetQuestions retrieves all questions for a quiz
 	GetQuestions(ctx context.Context, quizID uuid.UUID) ([]*model.Question, error)
 
+	// GetQuestionsInRunOrder retrieves all questions for a quiz in the order they were shown during the run
+	GetQuestionsInRunOrder(ctx context.Context, quizID uuid.UUID) ([]*model.Question, error)
+
 	// GetQuestion retrieves a question by ID
 	GetQuestion(ctx context.Context, id uuid.UUID) (*model.Question, error)
 
 	// GetNextQuestion retrieves the next question in sequence
 	GetNextQuestion(ctx context.Context, quizID uuid.UUID) (*model.Question, error)
 
+	// GetUpcomingQuestions retrieves up to count upcoming questions that were not shown yet, for prefetching
+	GetUpcomingQuestions(ctx context.Context, quizID uuid.UUID, count int) ([]*model.Question, error)
+
+	// ReorderQuestions reassigns question order to match the given list of question IDs
+	ReorderQuestions(ctx context.Context, quizID uuid.UUID, questionIDs []uuid.UUID) ([]*model.Question, error)
+
+	// SetQuestionTimeLimits sets the time limit of the listed questions, or of all questions when none are listed
+	SetQuestionTimeLimits(ctx context.Context, quizID uuid.UUID, questionIDs []uuid.UUID, timeLimit int) ([]*model.Question, error)
+
 	// State Management Methods
-	StartQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) error
+	// StartQuestion rejects a question that already ran with ErrQuestionAlreadyRun unless force is set
+	StartQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID, force bool) error
 	EndQuestion(ctx context.Context, quizID uuid.UUID) error
 	MoveToNextQuestion(ctx context.Context, quizID uuid.UUID) error
+
+	// PreviewQuestion shows a question with its correct answers to the quiz's creators only
+	PreviewQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) (*model.Question, error)
+
+	// ExtendQuestionTime adds extraSeconds to the given question while it is running
+	ExtendQuestionTime(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID, extraSeconds int) error
+
+	// SkipQuestion ends the running question without revealing its answers, voids it and moves on
+	SkipQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) error
+
+	// VoidQuestion rescores a question that already ran so it counts the same for everyone, per the given policy
+	VoidQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID, policy model.VoidPolicy) error
 }
 
 // AnswerService defines operations for answer business logic
 type AnswerService interface {
-	// SubmitAnswer records a participant's answer to a question
-	SubmitAnswer(ctx context.Context, participantID uuid.UUID, questionID uuid.UUID, selectedOptions []string) (*model.Answer, error)
+	// Submit is the single entrypoint for answers arriving over WebSocket or HTTP: it validates,
+	// persists and scores the answer and reports it to creators.
+	// Repeats with the same non-empty clientToken return the original answer.
+	Submit(ctx context.Context, participantID uuid.UUID, questionID uuid.UUID, selectedOptions []string, clientToken string) (*model.Answer, error)
 
 	// GetAnswerStats retrieves statistics for answers to a question
 	GetAnswerStats(ctx context.Context, questionID uuid.UUID) (map[string]int, error)
 
+	// GetAnswerStatsForUser retrieves answer statistics, hiding them from non-creators while the question is active
+	GetAnswerStatsForUser(ctx context.Context, questionID uuid.UUID, userID uuid.UUID) (map[string]int, error)
+
 	// GetParticipantAnswer retrieves a participant's answer to a specific question
 	GetParticipantAnswer(ctx context.Context, participantID uuid.UUID, questionID uuid.UUID) (*model.Answer, error)
 }
 
 // LeaderboardService defines operations for leaderboard business logic
 type LeaderboardService interface {
-	// GetLeaderboard retrieves the top participants by score
-	GetLeaderboard(ctx context.Context, quizID uuid.UUID, limit int) ([]*model.Participant, error)
+	// GetLeaderboard retrieves a page of participants ranked by score, skipping the first offset
+	GetLeaderboard(ctx context.Context, quizID uuid.UUID, limit int, offset int) ([]*model.Participant, error)
+
+	// CountParticipants counts the participants ranked on a quiz's leaderboard
+	CountParticipants(ctx context.Context, quizID uuid.UUID) (int, error)
 
 	// UpdateParticipantScore updates a participant's total score
 	UpdateParticipantScore(ctx context.Context, participantID uuid.UUID, additionalScore int) error
+
+	// RecomputeScore recalculates a participant's total score from their answers and returns it
+	RecomputeScore(ctx context.Context, participantID uuid.UUID) (int, error)
+
+	// BroadcastLeaderboard sends the current top of the leaderboard to all clients in the quiz, unless it is frozen
+	BroadcastLeaderboard(ctx context.Context, quizID uuid.UUID) error
+}
+
+// TeamService defines operations for managing teams in team mode
+type TeamService interface {
+	// CreateTeam adds a team to a quiz
+	CreateTeam(ctx context.Context, quizID uuid.UUID, name string) (*model.Team, error)
+
+	// GetTeamsByQuizID retrieves all teams for a quiz
+	GetTeamsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.Team, error)
+}
+
+// TeamLeaderboardService defines operations for team-based scoring
+type TeamLeaderboardService interface {
+	// GetTeamLeaderboard retrieves the teams of a quiz ranked by total score
+	GetTeamLeaderboard(ctx context.Context, quizID uuid.UUID) ([]dto.TeamLeaderboardEntry, error)
+}
+
+// IntegrityService defines operations for detecting suspicious answer patterns
+type IntegrityService interface {
+	// GetIntegrityReport reports clusters of identical answers submitted within a short window
+	GetIntegrityReport(ctx context.Context, quizID uuid.UUID) (*dto.IntegrityReportDTO, error)
+}
+
+// ScoringService defines operations for analysing how points were awarded
+type ScoringService interface {
+	// GetScoringReport aggregates the points awarded per question and across a quiz
+	GetScoringReport(ctx context.Context, quizID uuid.UUID) (*dto.ScoringReportDTO, error)
+}
+
+// PresenterService defines operations for the creator's live control screen
+type PresenterService interface {
+	// GetPresenterView assembles the phase, active question, live answer counts, presence and leaderboard of a quiz
+	GetPresenterView(ctx context.Context, quizID uuid.UUID) (*dto.PresenterViewDTO, error)
+}
+
+// WebhookService defines operations for managing quiz webhooks
+type WebhookService interface {
+	// SetQuizWebhook registers the endpoint notified of a quiz's lifecycle events, replacing any previous one
+	SetQuizWebhook(ctx context.Context, quizID uuid.UUID, url string, secret string) (*model.QuizWebhook, error)
+
+	// GetQuizWebhook retrieves the webhook registered for a quiz
+	GetQuizWebhook(ctx context.Context, quizID uuid.UUID) (*model.QuizWebhook, error)
+
+	// DeleteQuizWebhook stops notifying a quiz's webhook
+	DeleteQuizWebhook(ctx context.Context, quizID uuid.UUID) error
+}
+
+// WebhookNotifier delivers quiz lifecycle events to external systems
+type WebhookNotifier interface {
+	// Notify sends the event to the quiz's webhook in the background if it is a lifecycle event.
+	// Delivery failures are logged and never reported to the caller.
+	Notify(quizID uuid.UUID, eventType string, payload interface{})
+}
+
+// AdminService defines maintenance operations for operators
+type AdminService interface {
+	// FindOrphanedOptions lists question options whose question no longer exists
+	FindOrphanedOptions(ctx context.Context) ([]*model.QuestionOption, error)
+
+	// CleanupOrphanedOptions deletes question options whose question no longer exists and reports how many were removed
+	CleanupOrphanedOptions(ctx context.Context) (int, error)
+
+	// FindStaleQuizzes lists the active quizzes without activity for longer than threshold,
+	// using the configured threshold when it is not positive
+	FindStaleQuizzes(ctx context.Context, threshold time.Duration) ([]*model.QuizActivity, error)
+
+	// TerminateQuiz ends an active quiz on behalf of an operator
+	TerminateQuiz(ctx context.Context, quizID uuid.UUID) error
 }
 
 // UserService defines operations for user business logic
@@ -96,6 +271,9 @@ type UserService interface {
 	// LoginWithToken authenticates a user and returns user data with JWT tokens
 	LoginWithToken(ctx context.Context, email string, password string) (*dto.UserLoginResponse, error)
 
+	// IssueGuestToken returns a stored guest host with their short-lived access token
+	IssueGuestToken(user *model.User) (*dto.UserLoginResponse, error)
+
 	// GetUserByID retrieves a user by ID
 	GetUserByID(ctx context.Context, id uuid.UUID) (*model.User, error)
 }
@@ -103,10 +281,10 @@ type UserService interface {
 // ParticipantService defines operations for participant business logic
 type ParticipantService interface {
 	// JoinQuiz allows a user to join a quiz as a participant
-	JoinQuiz(ctx context.Context, quizID uuid.UUID, name string) (*model.Participant, error)
+	JoinQuiz(ctx context.Context, quizID uuid.UUID, name string, teamID *uuid.UUID) (*model.Participant, error)
 
 	// JoinQuizByCode allows a user to join a quiz using its code
-	JoinQuizByCode(ctx context.Context, code string, name string) (*model.Participant, error)
+	JoinQuizByCode(ctx context.Context, code string, name string, teamID *uuid.UUID) (*model.Participant, error)
 
 	// GetParticipantByID retrieves a participant by ID
 	GetParticipantByID(ctx context.Context, id uuid.UUID) (*model.Participant, error)
@@ -114,21 +292,39 @@ type ParticipantService interface {
 	// GetParticipantsByQuizID retrieves all participants for a quiz
 	GetParticipantsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.Participant, error)
 
+	// CountParticipantsByQuizID counts the participants of a quiz without loading them
+	CountParticipantsByQuizID(ctx context.Context, quizID uuid.UUID) (int, error)
+
 	// RemoveParticipant removes a participant from a quiz
 	RemoveParticipant(ctx context.Context, id uuid.UUID) error
+
+	// KickParticipant removes a participant at any point of a quiz, optionally banning them from rejoining
+	KickParticipant(ctx context.Context, id uuid.UUID, ban bool) error
+
+	// IssueToken creates the token a participant presents to open WebSocket connections to their quiz
+	IssueToken(participant *model.Participant) (string, error)
+
+	// IssueSpectatorToken creates the ID and token a spectator display presents to watch a quiz
+	IssueSpectatorToken(ctx context.Context, quizID uuid.UUID) (uuid.UUID, string, error)
 }
 
 // StateService defines methods for managing quiz state
 type StateService interface {
 	// State Management
-	GetQuizState(ctx context.Context, quizID uuid.UUID) (*dto.QuizStateDTO, error)
+	// GetQuizState includes the active question's correct answers for creators; others see them once results are shown
+	GetQuizState(ctx context.Context, quizID uuid.UUID, forCreator bool) (*dto.QuizStateDTO, error)
+	GetLobbySnapshot(ctx context.Context, quizID uuid.UUID) (*dto.LobbySnapshotDTO, error)
+	GetQuizTimer(ctx context.Context, quizID uuid.UUID) (*dto.QuizTimerDTO, error)
+	QuizStateCache
 
 	// Events
 	PublishEvent(ctx context.Context, quizID uuid.UUID, eventType string, payload interface{}) error
 	GetMissedEvents(ctx context.Context, quizID uuid.UUID, lastSequence int64) ([]*model.QuizEvent, error)
+	GetQuizTimeline(ctx context.Context, quizID uuid.UUID) (*dto.QuizTimelineDTO, error)
+	PublishSettingsUpdate(ctx context.Context, quizID uuid.UUID, settings model.QuizSettings) error
 
 	// Participant Connection
-	UpdateParticipantConnection(ctx context.Context, participantID, quizID uuid.UUID, isConnected bool, instanceID string) error
+	UpdateParticipantConnection(ctx context.Context, participantID, quizID uuid.UUID, isConnected bool, instanceID string, reason model.DisconnectReason, connectedAt time.Time) error
 	GetActiveParticipants(ctx context.Context, quizID uuid.UUID) ([]model.Participant, error)
 
 	// Instance Management
@@ -136,11 +332,23 @@ type StateService interface {
 	UpdateInstanceHeartbeat(ctx context.Context, instanceID string) error
 
 	// State Transition Functions
-	StartQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) error
+	// StartQuestion and GoToQuestion reject a question that already ran with ErrQuestionAlreadyRun unless force is set
+	StartQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID, force bool) error
 	EndQuestion(ctx context.Context, quizID uuid.UUID) error
+	ExtendQuestionTime(ctx context.Context, quizID uuid.UUID, extraSeconds int) error
+	// SkipQuestion ends the running question without scoring it or revealing its answers, then moves to the next one
+	SkipQuestion(ctx context.Context, quizID uuid.UUID) error
+	// PreviewQuestion shows a question to the quiz's creators without changing the session or notifying participants
+	PreviewQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) (*model.Question, error)
 	MoveToNextQuestion(ctx context.Context, quizID uuid.UUID) error
+	GoToQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID, force bool) error
 
 	// Quiz Lifecycle Functions
 	StartQuiz(ctx context.Context, quizID uuid.UUID) error
+	StartLobbyCountdown(ctx context.Context, quizID uuid.UUID, seconds int) error
 	EndQuiz(ctx context.Context, quizID uuid.UUID) error
+
+	// Leaderboard Reveal
+	FreezeLeaderboard(ctx context.Context, quizID uuid.UUID) error
+	RevealFinalLeaderboard(ctx context.Context, quizID uuid.UUID) error
 }
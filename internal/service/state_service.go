@@ -4,15 +4,27 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"log/slog"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/repository"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/logger"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/metrics"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
 	"github.com/google/uuid"
 )
 
+// Errors
+var (
+	ErrLeaderboardFrozen    = errors.New("leaderboard is already frozen")
+	ErrLeaderboardNotFrozen = errors.New("leaderboard is not frozen")
+)
+
 // stateServiceImpl implements StateService interface
 type stateServiceImpl struct {
 	stateRepo          repository.StateRepository
@@ -20,8 +32,68 @@ type stateServiceImpl struct {
 	questionRepo       repository.QuestionRepository
 	questionOptionRepo repository.QuestionOptionRepository
 	participantRepo    repository.ParticipantRepository
-	wsHub              *websocket.RedisHub
+	answerRepo         repository.AnswerRepository
+	wsHub              EventHub
+	webhookNotifier    WebhookNotifier
 	instanceID         string
+	reconnectGrace     time.Duration
+	// maxActivePerCreator caps the active quizzes of one creator when a quiz is started; 0 disables the cap
+	maxActivePerCreator int
+	logger              *slog.Logger
+
+	// questionTimers holds the cancel function of the running auto-end timer for each quiz
+	questionTimers map[uuid.UUID]context.CancelFunc
+	// lobbyCountdowns holds the cancel function of the running lobby countdown for each quiz
+	lobbyCountdowns map[uuid.UUID]context.CancelFunc
+	timersMu        sync.Mutex
+
+	// pendingPresence holds the presence announcement of each participant whose connection is still settling
+	pendingPresence  map[uuid.UUID]*pendingPresence
+	presenceDebounce time.Duration
+	presenceMu       sync.Mutex
+
+	// stateCache holds the recently computed states of each quiz, so a burst of connects shares one computation
+	stateCache    map[stateCacheKey]*cachedState
+	stateCacheTTL time.Duration
+	stateCacheMu  sync.Mutex
+}
+
+// maxStateCacheTTL caps how long a quiz state is reused. Writes on other instances do not drop this
+// instance's cache, so a state must expire before the next timer tick to never be more than a tick behind.
+const maxStateCacheTTL = 500 * time.Millisecond
+
+// stateCacheKey identifies a cached quiz state; creators and participants see different states
+type stateCacheKey struct {
+	quizID     uuid.UUID
+	forCreator bool
+}
+
+// cachedState is a quiz state computed once for every caller asking while it loads or until it expires
+type cachedState struct {
+	ready   chan struct{} // closed once state and err are set
+	loaded  bool          // guarded by stateCacheMu, like expires
+	expires time.Time
+	state   *dto.QuizStateDTO
+	err     error
+}
+
+// pendingPresence collects a participant's connection changes within one debounce window
+type pendingPresence struct {
+	quizID uuid.UUID
+	// wasConnected is the presence clients were last told about, isConnected the latest one
+	wasConnected bool
+	isConnected  bool
+	// joinEvent is the first connect announcement of the window and leaveEvent the latest disconnect one
+	joinEvent  *presenceEvent
+	leaveEvent *presenceEvent
+	seq        int
+	timer      *time.Timer
+}
+
+// presenceEvent is a USER_JOINED, USER_RECONNECTED or USER_LEFT event waiting to be published
+type presenceEvent struct {
+	eventType string
+	payload   map[string]interface{}
 }
 
 // NewStateService creates a new state service
@@ -31,24 +103,117 @@ func NewStateService(
 	questionRepo repository.QuestionRepository,
 	questionOptionRepo repository.QuestionOptionRepository,
 	participantRepo repository.ParticipantRepository,
-	wsHub *websocket.RedisHub,
+	answerRepo repository.AnswerRepository,
+	wsHub EventHub,
+	webhookNotifier WebhookNotifier,
+	cfg config.QuizConfig,
+	log *slog.Logger,
 ) StateService {
 	// Generate a unique instance ID
 	instanceID := uuid.New().String()
 
+	stateCacheTTL := cfg.StateCacheTTL
+	if stateCacheTTL > maxStateCacheTTL {
+		stateCacheTTL = maxStateCacheTTL
+	}
+
 	return &stateServiceImpl{
-		stateRepo:          stateRepo,
-		quizRepo:           quizRepo,
-		questionRepo:       questionRepo,
-		questionOptionRepo: questionOptionRepo,
-		participantRepo:    participantRepo,
-		wsHub:              wsHub,
-		instanceID:         instanceID,
+		stateRepo:           stateRepo,
+		quizRepo:            quizRepo,
+		questionRepo:        questionRepo,
+		questionOptionRepo:  questionOptionRepo,
+		participantRepo:     participantRepo,
+		answerRepo:          answerRepo,
+		wsHub:               wsHub,
+		webhookNotifier:     webhookNotifier,
+		instanceID:          instanceID,
+		reconnectGrace:      cfg.ReconnectGracePeriod,
+		maxActivePerCreator: cfg.MaxActivePerCreator,
+		logger:              logger.OrDefault(log),
+		questionTimers:      make(map[uuid.UUID]context.CancelFunc),
+		lobbyCountdowns:     make(map[uuid.UUID]context.CancelFunc),
+		pendingPresence:     make(map[uuid.UUID]*pendingPresence),
+		presenceDebounce:    cfg.PresenceDebounce,
+		stateCache:          make(map[stateCacheKey]*cachedState),
+		stateCacheTTL:       stateCacheTTL,
+	}
+}
+
+// GetQuizState retrieves the current state of a quiz. The active question's correct answers are
+// included for creators, and for everyone else only once its results are shown.
+// Concurrent callers share one computation, which is reused until the cache TTL passes or the quiz changes.
+func (s *stateServiceImpl) GetQuizState(ctx context.Context, quizID uuid.UUID, forCreator bool) (*dto.QuizStateDTO, error) {
+	if s.stateCacheTTL <= 0 {
+		return s.buildQuizState(ctx, quizID, forCreator)
+	}
+
+	key := stateCacheKey{quizID: quizID, forCreator: forCreator}
+
+	s.stateCacheMu.Lock()
+	now := time.Now()
+	if entry, ok := s.stateCache[key]; ok && (!entry.loaded || now.Before(entry.expires)) {
+		s.stateCacheMu.Unlock()
+
+		select {
+		case <-entry.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		// The countdown keeps running while the state is cached
+		return dto.RefreshQuizStateTimer(entry.state, time.Now()), nil
+	}
+
+	// Drop expired states of other quizzes so the cache does not outgrow the quizzes in use
+	for k, entry := range s.stateCache {
+		if entry.loaded && !now.Before(entry.expires) {
+			delete(s.stateCache, k)
+		}
+	}
+
+	entry := &cachedState{ready: make(chan struct{})}
+	s.stateCache[key] = entry
+	s.stateCacheMu.Unlock()
+
+	state, err := s.buildQuizState(ctx, quizID, forCreator)
+
+	s.stateCacheMu.Lock()
+	entry.state, entry.err = state, err
+	entry.loaded = true
+	entry.expires = time.Now().Add(s.stateCacheTTL)
+	// Failures are not reused by later callers
+	if err != nil && s.stateCache[key] == entry {
+		delete(s.stateCache, key)
+	}
+	s.stateCacheMu.Unlock()
+	close(entry.ready)
+
+	return state, err
+}
+
+// InvalidateQuizState drops the cached states of a quiz after it changed. Callers already waiting on
+// a state being computed still receive it, but later callers compute a fresh one.
+func (s *stateServiceImpl) InvalidateQuizState(quizID uuid.UUID) {
+	s.stateCacheMu.Lock()
+	defer s.stateCacheMu.Unlock()
+
+	delete(s.stateCache, stateCacheKey{quizID: quizID, forCreator: false})
+	delete(s.stateCache, stateCacheKey{quizID: quizID, forCreator: true})
+}
+
+// updateSession stores a quiz's session and drops its cached states
+func (s *stateServiceImpl) updateSession(ctx context.Context, session *model.QuizSession) error {
+	if err := s.quizRepo.UpdateQuizSession(ctx, session); err != nil {
+		return err
 	}
+	s.InvalidateQuizState(session.QuizID)
+	return nil
 }
 
-// GetQuizState retrieves the current state of a quiz
-func (s *stateServiceImpl) GetQuizState(ctx context.Context, quizID uuid.UUID) (*dto.QuizStateDTO, error) {
+// buildQuizState computes the current state of a quiz from the database
+func (s *stateServiceImpl) buildQuizState(ctx context.Context, quizID uuid.UUID, forCreator bool) (*dto.QuizStateDTO, error) {
 	// Get quiz details
 	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
 	if err != nil {
@@ -90,7 +255,7 @@ func (s *stateServiceImpl) GetQuizState(ctx context.Context, quizID uuid.UUID) (
 	}
 
 	// Convert to state DTO
-	state := dto.ToQuizStateDTO(quiz, session, participants, activeQuestion, questionCount)
+	state := dto.ToQuizStateDTO(quiz, session, participants, activeQuestion, questionCount, forCreator)
 
 	// Update connected status from participant_connections table
 	cutoffTime := time.Now().Add(-30 * time.Second) // Consider connections within last 30 seconds
@@ -115,8 +280,105 @@ func (s *stateServiceImpl) GetQuizState(ctx context.Context, quizID uuid.UUID) (
 	return state, nil
 }
 
-// PublishEvent publishes an event for a quiz
+// GetLobbySnapshot assembles the participant roster of a quiz with each participant's presence, in join order
+func (s *stateServiceImpl) GetLobbySnapshot(ctx context.Context, quizID uuid.UUID) (*dto.LobbySnapshotDTO, error) {
+	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	participants, err := s.participantRepo.GetParticipantsByQuizID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use the same presence window as the state sync
+	cutoffTime := time.Now().Add(-30 * time.Second)
+	connections, err := s.stateRepo.GetActiveParticipantConnections(ctx, quizID, cutoffTime)
+	if err != nil {
+		return nil, err
+	}
+
+	connected := make(map[uuid.UUID]bool, len(connections))
+	for _, conn := range connections {
+		if conn.IsConnected {
+			connected[conn.ParticipantID] = true
+		}
+	}
+
+	sort.SliceStable(participants, func(i, j int) bool {
+		return participants[i].JoinedAt.Before(participants[j].JoinedAt)
+	})
+
+	snapshot := &dto.LobbySnapshotDTO{
+		QuizID:           quizID,
+		Status:           string(quiz.Status),
+		ParticipantCount: len(participants),
+		Participants:     make([]dto.LobbyParticipantDTO, 0, len(participants)),
+	}
+	for _, p := range participants {
+		isConnected := connected[p.ID]
+		if isConnected {
+			snapshot.ConnectedCount++
+		}
+		snapshot.Participants = append(snapshot.Participants, dto.LobbyParticipantDTO{
+			ParticipantID: p.ID,
+			Name:          p.Name,
+			TeamID:        p.TeamID,
+			JoinTime:      websocket.FormatTimestamp(p.JoinedAt),
+			IsConnected:   isConnected,
+		})
+	}
+
+	return snapshot, nil
+}
+
+// GetQuizTimer retrieves the current phase and countdown of a quiz without loading its full state
+func (s *stateServiceImpl) GetQuizTimer(ctx context.Context, quizID uuid.UUID) (*dto.QuizTimerDTO, error) {
+	session, err := s.quizRepo.GetQuizSession(ctx, quizID)
+	if err != nil {
+		if errors.Is(err, repository.ErrQuizSessionNotFound) {
+			return nil, ErrQuizNotFound
+		}
+		return nil, err
+	}
+
+	var activeQuestion *model.Question
+	if session.CurrentPhase == model.QuizPhaseQuestionActive && session.CurrentQuestionID != nil {
+		activeQuestion, err = s.questionRepo.GetQuestionByID(ctx, *session.CurrentQuestionID)
+		if err != nil {
+			return nil, ErrQuestionNotFound
+		}
+	}
+
+	return dto.NewQuizTimer(session, activeQuestion), nil
+}
+
+// PublishEvent records an event for a quiz and broadcasts it to the quiz's clients on every instance
 func (s *stateServiceImpl) PublishEvent(ctx context.Context, quizID uuid.UUID, eventType string, payload interface{}) error {
+	if err := s.recordEvent(ctx, quizID, eventType, payload); err != nil {
+		return err
+	}
+
+	// Also broadcast via WebSocket to every instance. The event is already stored and clients can
+	// replay it, so if publishing keeps failing the clients on this instance are still served directly.
+	wsEvent := websocket.Event{
+		Type:    websocket.EventType(eventType),
+		Payload: payload,
+	}
+	if err := s.wsHub.PublishToQuiz(quizID, wsEvent); err != nil {
+		s.logger.Error("Error publishing event; delivering it to this instance only", "quizId", quizID, "eventType", eventType, "error", err)
+		s.wsHub.BroadcastToQuiz(quizID, wsEvent)
+	}
+
+	// Let integrators react to lifecycle events
+	s.webhookNotifier.Notify(quizID, eventType, payload)
+
+	return nil
+}
+
+// recordEvent stores an event in the quiz event log without broadcasting it
+func (s *stateServiceImpl) recordEvent(ctx context.Context, quizID uuid.UUID, eventType string, payload interface{}) error {
 	// Generate sequence number
 	seqNum, err := s.stateRepo.IncrementSequenceNumber(ctx, quizID)
 	if err != nil {
@@ -135,13 +397,8 @@ func (s *stateServiceImpl) PublishEvent(ctx context.Context, quizID uuid.UUID, e
 		return err
 	}
 
-	// Also broadcast via WebSocket
-	wsEvent := websocket.Event{
-		Type:    websocket.EventType(eventType),
-		Payload: payload,
-	}
-	s.wsHub.BroadcastToQuiz(quizID, wsEvent)
-
+	// Every recorded event changes the state, so connects from now on must not reuse an older one
+	s.InvalidateQuizState(quizID)
 	return nil
 }
 
@@ -151,23 +408,57 @@ func (s *stateServiceImpl) GetMissedEvents(ctx context.Context, quizID uuid.UUID
 	return s.stateRepo.GetMissedEvents(ctx, quizID, lastSequence, 100)
 }
 
-// UpdateParticipantConnection updates a participant's connection status
+// UpdateParticipantConnection updates a participant's connection status.
+// connectedAt is when the connection being reported was opened. A participant coming back while still
+// marked connected, or within the reconnection grace period, is announced as reconnected rather than joined,
+// and the late disconnect of a connection that has since been replaced is ignored. A connection still
+// open on another instance is closed there, so each participant holds a single connection.
 func (s *stateServiceImpl) UpdateParticipantConnection(
 	ctx context.Context,
 	participantID, quizID uuid.UUID,
 	isConnected bool,
 	instanceID string,
+	reason model.DisconnectReason,
+	connectedAt time.Time,
 ) error {
+	previous, err := s.stateRepo.GetParticipantConnection(ctx, participantID, quizID)
+	if err != nil && !errors.Is(err, repository.ErrParticipantConnectionNotFound) {
+		return err
+	}
+
+	// A newer connection has already been recorded, so this disconnect is stale
+	if !isConnected && previous != nil && previous.IsConnected && previous.LastSeen.After(connectedAt) {
+		return nil
+	}
+
 	conn := model.NewParticipantConnection(participantID, quizID, instanceID)
 	conn.IsConnected = isConnected
+	if isConnected {
+		conn.LastSeen = connectedAt
+	} else {
+		conn.DisconnectReason = reason
+	}
 
 	// Update the connection in the database
-	err := s.stateRepo.UpdateParticipantConnection(ctx, conn)
+	err = s.stateRepo.UpdateParticipantConnection(ctx, conn)
 	if err != nil {
 		return err
 	}
 
-	// If connection state changed, broadcast participant joined/left event
+	// Presence is part of the state even while its announcement is debounced
+	s.InvalidateQuizState(quizID)
+
+	wasConnected := previous != nil && previous.IsConnected
+
+	// The latest connection wins: an older one still open on another instance is closed there.
+	// Its disconnect is then ignored as stale, so presence is unaffected.
+	if isConnected && wasConnected && previous.InstanceID != instanceID {
+		if err := s.wsHub.ReplaceConnection(quizID, participantID); err != nil {
+			s.logger.Error("Error replacing participant connection", "quizId", quizID, "participantId", participantID, "error", err)
+		}
+	}
+
+	// If connection state changed, announce participant joined/reconnected/left
 	if isConnected {
 		// Get participant details
 		participant, err := s.participantRepo.GetParticipantByID(ctx, participantID)
@@ -175,23 +466,110 @@ func (s *stateServiceImpl) UpdateParticipantConnection(
 			return err
 		}
 
-		// Broadcast user joined event
-		s.PublishEvent(ctx, quizID, string(websocket.EventUserJoined), map[string]interface{}{
-			"id":       participantID.String(),
-			"name":     participant.Name,
-			"joinTime": time.Now().Format(time.RFC3339),
+		reconnected := previous != nil &&
+			(previous.IsConnected || connectedAt.Sub(previous.LastSeen) <= s.reconnectGrace)
+		if reconnected {
+			s.announcePresence(ctx, quizID, participantID, wasConnected, true, presenceEvent{
+				eventType: string(websocket.EventUserReconnected),
+				payload: map[string]interface{}{
+					"id":            participantID.String(),
+					"name":          participant.Name,
+					"reconnectTime": websocket.FormatTimestamp(connectedAt),
+				},
+			})
+			return nil
+		}
+
+		// Announce user joined event
+		s.announcePresence(ctx, quizID, participantID, wasConnected, true, presenceEvent{
+			eventType: string(websocket.EventUserJoined),
+			payload: map[string]interface{}{
+				"id":       participantID.String(),
+				"name":     participant.Name,
+				"joinTime": websocket.FormatTimestamp(connectedAt),
+			},
 		})
 	} else {
-		// Broadcast user left event
-		s.PublishEvent(ctx, quizID, string(websocket.EventUserLeft), map[string]interface{}{
-			"id":        participantID.String(),
-			"leaveTime": time.Now().Format(time.RFC3339),
+		// Announce user left event
+		s.announcePresence(ctx, quizID, participantID, wasConnected, false, presenceEvent{
+			eventType: string(websocket.EventUserLeft),
+			payload: map[string]interface{}{
+				"id":        participantID.String(),
+				"leaveTime": websocket.FormatTimestamp(time.Now()),
+				"reason":    string(reason),
+			},
 		})
 	}
 
 	return nil
 }
 
+// announcePresence publishes a participant's connection change once it has settled for the debounce window.
+// Changes within the window collapse into one event, and none at all if the participant ends up where
+// clients last saw them. wasConnected is the stored presence before this change.
+func (s *stateServiceImpl) announcePresence(ctx context.Context, quizID, participantID uuid.UUID, wasConnected, isConnected bool, event presenceEvent) {
+	if s.presenceDebounce <= 0 {
+		s.PublishEvent(ctx, quizID, event.eventType, event.payload)
+		return
+	}
+
+	s.presenceMu.Lock()
+	defer s.presenceMu.Unlock()
+
+	pending, ok := s.pendingPresence[participantID]
+	if !ok {
+		pending = &pendingPresence{quizID: quizID, wasConnected: wasConnected}
+		s.pendingPresence[participantID] = pending
+	}
+
+	pending.isConnected = isConnected
+	if isConnected {
+		// Clients that saw the participant leave should hear how they first came back
+		if pending.joinEvent == nil {
+			pending.joinEvent = &event
+		}
+	} else {
+		pending.leaveEvent = &event
+	}
+
+	// Restart the window; seq tells a superseded timer that fired meanwhile to do nothing
+	pending.seq++
+	seq := pending.seq
+	if pending.timer != nil {
+		pending.timer.Stop()
+	}
+	pending.timer = time.AfterFunc(s.presenceDebounce, func() {
+		s.flushPresence(participantID, seq)
+	})
+}
+
+// flushPresence publishes the net presence change of a participant whose debounce window ended
+func (s *stateServiceImpl) flushPresence(participantID uuid.UUID, seq int) {
+	s.presenceMu.Lock()
+	pending, ok := s.pendingPresence[participantID]
+	if !ok || pending.seq != seq {
+		s.presenceMu.Unlock()
+		return
+	}
+	delete(s.pendingPresence, participantID)
+	s.presenceMu.Unlock()
+
+	// The participant flapped back to where they were
+	if pending.isConnected == pending.wasConnected {
+		return
+	}
+
+	event := pending.leaveEvent
+	if pending.isConnected {
+		event = pending.joinEvent
+	}
+
+	// The request that caused the change has finished by now
+	if err := s.PublishEvent(context.Background(), pending.quizID, event.eventType, event.payload); err != nil {
+		s.logger.Error("Error publishing presence event", "quizId", pending.quizID, "participantId", participantID, "event", event.eventType, "error", err)
+	}
+}
+
 // GetActiveParticipants retrieves all active participants for a quiz
 func (s *stateServiceImpl) GetActiveParticipants(ctx context.Context, quizID uuid.UUID) ([]model.Participant, error) {
 	// Get all active connections
@@ -226,8 +604,9 @@ func (s *stateServiceImpl) UpdateInstanceHeartbeat(ctx context.Context, instance
 	return s.stateRepo.UpdateInstanceHeartbeat(ctx, instanceID)
 }
 
-// StartQuestion starts a question and updates the phase
-func (s *stateServiceImpl) StartQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) error {
+// StartQuestion starts a question and updates the phase.
+// A question that already ran is only restarted when force is set.
+func (s *stateServiceImpl) StartQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID, force bool) error {
 	// Get current session
 	session, err := s.quizRepo.GetQuizSession(ctx, quizID)
 	if err != nil {
@@ -252,6 +631,12 @@ func (s *stateServiceImpl) StartQuestion(ctx context.Context, quizID uuid.UUID,
 		return errors.New("question does not belong to this quiz")
 	}
 
+	if !force {
+		if err := s.ensureQuestionNotRun(ctx, quizID, questionID); err != nil {
+			return err
+		}
+	}
+
 	// Load options for the question
 	options, err := s.questionOptionRepo.GetQuestionOptionsByQuestionID(ctx, questionID)
 	if err == nil {
@@ -263,11 +648,13 @@ func (s *stateServiceImpl) StartQuestion(ctx context.Context, quizID uuid.UUID,
 	session.CurrentQuestionID = &questionID
 	session.CurrentQuestionStartedAt = &now
 	session.CurrentQuestionEndedAt = nil // Clear any previous end time
+	session.CurrentQuestionExtraSeconds = 0
 	session.CurrentPhase = model.QuizPhaseQuestionActive
 
-	if err := s.quizRepo.UpdateQuizSession(ctx, session); err != nil {
+	if err := s.updateSession(ctx, session); err != nil {
 		return err
 	}
+	metrics.QuestionsStarted.Inc()
 
 	// Get total question count for better UI experience
 	questions, err := s.questionRepo.GetQuestionsByQuizID(ctx, quizID)
@@ -279,27 +666,21 @@ func (s *stateServiceImpl) StartQuestion(ctx context.Context, quizID uuid.UUID,
 
 	// Broadcast different payloads for creators and participants
 	// For creators (quiz admins), send full question details including correct answers
-	creatorOptions := make([]map[string]interface{}, len(question.Options))
-	for i, opt := range question.Options {
-		creatorOptions[i] = map[string]interface{}{
-			"id":        opt.ID.String(),
-			"text":      opt.Text,
-			"isCorrect": opt.IsCorrect,
-		}
-	}
-
 	creatorEvent := map[string]interface{}{
 		"quizId":       quiz.ID.String(),
 		"quizTitle":    quiz.Title,
 		"questionId":   question.ID.String(),
 		"text":         question.Text,
-		"options":      creatorOptions,
+		"imageUrl":     question.ImageURL,
+		"explanation":  question.Explanation,
+		"options":      creatorQuestionOptions(question),
 		"questionType": string(question.QuestionType),
 		"timeLimit":    question.TimeLimit,
+		"required":     question.Required,
 		"order":        question.Order,
 		"totalCount":   totalCount,
 		"currentPhase": string(session.CurrentPhase),
-		"startTime":    now.Format(time.RFC3339),
+		"startTime":    websocket.FormatTimestamp(now),
 	}
 
 	// Publish creator event directly to WebSocket as it's targeted only to creators
@@ -308,12 +689,14 @@ func (s *stateServiceImpl) StartQuestion(ctx context.Context, quizID uuid.UUID,
 		Payload: creatorEvent,
 	})
 
-	// For participants, send options without correct answer information
-	participantOptions := make([]map[string]interface{}, len(question.Options))
-	for i, opt := range question.Options {
+	// For participants, send options without correct answer information, shuffled if the quiz asks for it
+	shownOptions := quiz.Settings.ParticipantOptions(question)
+	participantOptions := make([]map[string]interface{}, len(shownOptions))
+	for i, opt := range shownOptions {
 		participantOptions[i] = map[string]interface{}{
-			"id":   opt.ID.String(),
-			"text": opt.Text,
+			"id":    opt.ID.String(),
+			"label": opt.Label(),
+			"text":  opt.Text,
 		}
 	}
 
@@ -322,13 +705,15 @@ func (s *stateServiceImpl) StartQuestion(ctx context.Context, quizID uuid.UUID,
 		"quizTitle":    quiz.Title,
 		"questionId":   question.ID.String(),
 		"text":         question.Text,
+		"imageUrl":     question.ImageURL,
 		"options":      participantOptions,
 		"questionType": string(question.QuestionType),
 		"timeLimit":    question.TimeLimit,
+		"required":     question.Required,
 		"order":        question.Order,
 		"totalCount":   totalCount,
 		"currentPhase": string(session.CurrentPhase),
-		"startTime":    now.Format(time.RFC3339),
+		"startTime":    websocket.FormatTimestamp(now),
 	}
 
 	// Publish participant event directly to WebSocket as it's targeted only to participants
@@ -337,25 +722,230 @@ func (s *stateServiceImpl) StartQuestion(ctx context.Context, quizID uuid.UUID,
 		Payload: participantEvent,
 	})
 
-	// Start a timer to broadcast countdown and end the question
-	go s.wsHub.StartTimerBroadcast(quizID, question.TimeLimit)
+	// Record the question start in the event log so the quiz timeline can be reconstructed.
+	// Only the participant payload is stored since stored events may be replayed to any client.
+	if err := s.recordEvent(ctx, quizID, string(websocket.EventQuestionStart), participantEvent); err != nil {
+		s.logger.Error("Error recording question start event", "quizId", quizID, "questionId", questionID, "error", err)
+	}
 
-	// Start a goroutine to automatically end the question after the time limit
-	go func() {
-		timer := time.NewTimer(time.Duration(question.TimeLimit) * time.Second)
-		<-timer.C
+	// Collect delivery acknowledgements for the new question
+	s.wsHub.StartQuestionAcks(quizID, questionID)
+
+	// Replace any previous question's timers; both goroutines stop when the question is ended early
+	timerCtx := s.startQuestionTimer(quizID)
+
+	// Count down to the deadline stored in the session, which state syncs report too
+	deadline, _ := session.CurrentQuestionDeadline(question.TimeLimit)
+	go s.wsHub.StartTimerBroadcastUntil(timerCtx, quizID, deadline, question.TimeLimit)
+
+	// Start a goroutine to automatically end the question after the time limit, unless the host advances manually
+	if !quiz.Settings.ManualAdvance {
+		go s.autoEndQuestion(timerCtx, quizID, questionID, deadline, 0)
+	}
 
-		// End the question automatically
-		if err := s.EndQuestion(context.Background(), quizID); err != nil {
-			// Log the error but don't stop execution
-			// In a real application, we would use a proper logger
-			// logger.Error("Error ending question", "error", err)
+	return nil
+}
+
+// PreviewQuestion sends a question with its correct answers to the quiz's creators only, so they can check
+// its wording before going live. The session, timers and event log are left untouched.
+func (s *stateServiceImpl) PreviewQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) (*model.Question, error) {
+	question, err := s.questionRepo.GetQuestionByID(ctx, questionID)
+	if err != nil || question.QuizID != quizID {
+		return nil, ErrQuestionNotFound
+	}
+
+	options, err := s.questionOptionRepo.GetQuestionOptionsByQuestionID(ctx, questionID)
+	if err != nil {
+		return nil, err
+	}
+	question.Options = options
+
+	// The preview is returned to the caller as well, so a failed broadcast only costs the other creator screens
+	if err := s.wsHub.PublishToCreators(quizID, websocket.NewEvent(websocket.EventQuestionPreview, map[string]interface{}{
+		"quizId":       quizID.String(),
+		"questionId":   question.ID.String(),
+		"text":         question.Text,
+		"imageUrl":     question.ImageURL,
+		"explanation":  question.Explanation,
+		"options":      creatorQuestionOptions(question),
+		"questionType": string(question.QuestionType),
+		"timeLimit":    question.TimeLimit,
+		"order":        question.Order,
+	})); err != nil {
+		s.logger.Warn("Error publishing question preview", "quizId", quizID, "questionId", questionID, "error", err)
+	}
+
+	return question, nil
+}
+
+// creatorQuestionOptions lists a question's options for creator events, including which are correct
+func creatorQuestionOptions(question *model.Question) []map[string]interface{} {
+	options := make([]map[string]interface{}, len(question.Options))
+	for i, opt := range question.Options {
+		options[i] = map[string]interface{}{
+			"id":        opt.ID.String(),
+			"label":     opt.Label(),
+			"text":      opt.Text,
+			"isCorrect": opt.IsCorrect,
 		}
-	}()
+	}
+	return options
+}
+
+// ExtendQuestionTime adds extraSeconds to the running question, moving its deadline and
+// rescheduling the auto-end timer, and broadcasts the new timer to everyone in the quiz
+func (s *stateServiceImpl) ExtendQuestionTime(ctx context.Context, quizID uuid.UUID, extraSeconds int) error {
+	session, err := s.quizRepo.GetQuizSession(ctx, quizID)
+	if err != nil {
+		return err
+	}
+	if session.CurrentPhase != model.QuizPhaseQuestionActive || session.CurrentQuestionID == nil ||
+		session.CurrentQuestionStartedAt == nil {
+		return ErrQuestionNotActive
+	}
+
+	question, err := s.questionRepo.GetQuestionByID(ctx, *session.CurrentQuestionID)
+	if err != nil {
+		return ErrQuestionNotFound
+	}
+
+	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		return ErrQuizNotFound
+	}
+
+	session.CurrentQuestionExtraSeconds += extraSeconds
+	if err := s.updateSession(ctx, session); err != nil {
+		return err
+	}
+
+	totalSeconds := question.TimeLimit + session.CurrentQuestionExtraSeconds
+	endTime, _ := session.CurrentQuestionDeadline(question.TimeLimit)
+
+	// Tell clients about the new deadline right away rather than on the next tick
+	s.wsHub.PublishToQuiz(quizID, websocket.NewEvent(websocket.EventTimerUpdate, map[string]interface{}{
+		"remainingSeconds": model.RemainingSeconds(endTime, time.Now()),
+		"totalSeconds":     totalSeconds,
+		"endTime":          websocket.FormatTimestamp(endTime),
+	}))
+
+	// Replace the question's timers with ones running to the new deadline
+	timerCtx := s.startQuestionTimer(quizID)
+	go s.wsHub.StartTimerBroadcastUntil(timerCtx, quizID, endTime, totalSeconds)
+	if !quiz.Settings.ManualAdvance {
+		go s.autoEndQuestion(timerCtx, quizID, question.ID, endTime, session.CurrentQuestionExtraSeconds)
+	}
+
+	return nil
+}
+
+// autoEndQuestion ends the question once its deadline passes unless ctx is cancelled first.
+// extraSeconds is the extension the deadline was computed with; if the question was extended
+// since (possibly by another instance) the timer leaves it running.
+func (s *stateServiceImpl) autoEndQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID, deadline time.Time, extraSeconds int) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		// The question was ended manually or replaced by another one
+		return
+	}
+
+	// The timer may fire while the question is being replaced, so only end it if it is still current
+	session, err := s.quizRepo.GetQuizSession(context.Background(), quizID)
+	if err != nil || ctx.Err() != nil {
+		return
+	}
+	if session.CurrentQuestionID == nil || *session.CurrentQuestionID != questionID ||
+		session.CurrentPhase != model.QuizPhaseQuestionActive ||
+		session.CurrentQuestionExtraSeconds != extraSeconds {
+		return
+	}
+
+	if err := s.EndQuestion(context.Background(), quizID); err != nil {
+		s.logger.Error("Error auto-ending question", "quizId", quizID, "questionId", questionID, "error", err)
+	}
+}
+
+// GoToQuestion jumps to any question of the quiz, cancelling the running auto-end timer.
+// Like StartQuestion, it only jumps back to a question that already ran when force is set.
+func (s *stateServiceImpl) GoToQuestion(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID, force bool) error {
+	question, err := s.questionRepo.GetQuestionByID(ctx, questionID)
+	if err != nil {
+		return ErrQuestionNotFound
+	}
+	if question.QuizID != quizID {
+		return errors.New("question does not belong to this quiz")
+	}
+
+	// Checked before the timers are stopped, so a rejected jump leaves the running question alone
+	if !force {
+		if err := s.ensureQuestionNotRun(ctx, quizID, questionID); err != nil {
+			return err
+		}
+	}
+
+	// Stop the current question's timers before showing the chosen one
+	s.cancelQuestionTimer(quizID)
+
+	return s.StartQuestion(ctx, quizID, questionID, force)
+}
+
+// ensureQuestionNotRun returns ErrQuestionAlreadyRun if the question has recorded answers or the quiz's
+// event log shows it was started before, whichever questions ran after it
+func (s *stateServiceImpl) ensureQuestionNotRun(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) error {
+	count, err := s.answerRepo.CountAnswersByQuestionID(ctx, questionID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrQuestionAlreadyRun
+	}
+
+	events, err := s.stateRepo.GetEventsByQuizID(ctx, quizID)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if websocket.EventType(event.EventType) != websocket.EventQuestionStart {
+			continue
+		}
+		if startedID, ok := eventQuestionID(event); ok && startedID == questionID {
+			return ErrQuestionAlreadyRun
+		}
+	}
 
 	return nil
 }
 
+// startQuestionTimer cancels any running auto-end timer for the quiz and registers a new one.
+// The returned context is cancelled when the timer is superseded.
+func (s *stateServiceImpl) startQuestionTimer(quizID uuid.UUID) context.Context {
+	s.timersMu.Lock()
+	defer s.timersMu.Unlock()
+
+	if cancel, ok := s.questionTimers[quizID]; ok {
+		cancel()
+	}
+
+	timerCtx, cancel := context.WithCancel(context.Background())
+	s.questionTimers[quizID] = cancel
+	return timerCtx
+}
+
+// cancelQuestionTimer stops the running auto-end timer for the quiz, if any
+func (s *stateServiceImpl) cancelQuestionTimer(quizID uuid.UUID) {
+	s.timersMu.Lock()
+	defer s.timersMu.Unlock()
+
+	if cancel, ok := s.questionTimers[quizID]; ok {
+		cancel()
+		delete(s.questionTimers, quizID)
+	}
+}
+
 // EndQuestion ends the current question and updates the phase
 func (s *stateServiceImpl) EndQuestion(ctx context.Context, quizID uuid.UUID) error {
 	// Get current session
@@ -394,10 +984,13 @@ func (s *stateServiceImpl) EndQuestion(ctx context.Context, quizID uuid.UUID) er
 	session.CurrentQuestionEndedAt = &now
 	session.CurrentPhase = model.QuizPhaseShowingResults
 
-	if err := s.quizRepo.UpdateQuizSession(ctx, session); err != nil {
+	if err := s.updateSession(ctx, session); err != nil {
 		return err
 	}
 
+	// Stop the countdown and auto-end timer in case the question was ended early
+	s.cancelQuestionTimer(quizID)
+
 	// Get correct options to send in the event
 	var correctOptions []*model.QuestionOption
 	for _, opt := range question.Options {
@@ -411,14 +1004,115 @@ func (s *stateServiceImpl) EndQuestion(ctx context.Context, quizID uuid.UUID) er
 		correctOptionIds[i] = opt.ID.String()
 	}
 
-	// Broadcast question end event with correct answers
-	return s.PublishEvent(ctx, quizID, string(websocket.EventQuestionEnd), map[string]interface{}{
+	// Broadcast question end event with correct answers and why they are correct
+	if err := s.PublishEvent(ctx, quizID, string(websocket.EventQuestionEnd), map[string]interface{}{
 		"questionId":       question.ID.String(),
 		"correctOptionIds": correctOptionIds,
+		"explanation":      question.Explanation,
 		"questionType":     string(question.QuestionType),
 		"currentPhase":     string(session.CurrentPhase),
-		"endTime":          now.Format(time.RFC3339),
+		"endTime":          websocket.FormatTimestamp(now),
+	}); err != nil {
+		return err
+	}
+
+	if question.Required {
+		s.reportMissingRequiredAnswers(ctx, quizID, question.ID)
+	}
+
+	return nil
+}
+
+// SkipQuestion ends the running question without revealing its correct answers or answer distribution,
+// voids it so none of its answers are scored, and moves the quiz on to the next question
+func (s *stateServiceImpl) SkipQuestion(ctx context.Context, quizID uuid.UUID) error {
+	session, err := s.quizRepo.GetQuizSession(ctx, quizID)
+	if err != nil {
+		return err
+	}
+
+	if session.CurrentQuestionID == nil || session.CurrentPhase != model.QuizPhaseQuestionActive {
+		return ErrQuestionNotActive
+	}
+
+	// Check if quiz exists and is active
+	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		return ErrQuizNotFound
+	}
+	if quiz.Status != model.QuizStatusActive {
+		return ErrQuizNotActive
+	}
+
+	question, err := s.questionRepo.GetQuestionByID(ctx, *session.CurrentQuestionID)
+	if err != nil {
+		return ErrQuestionNotFound
+	}
+
+	// Voiding with no points drops any answers already recorded from every score
+	// and makes answers still in flight get rejected
+	if question.VoidedPoints == nil {
+		if err := s.questionRepo.VoidQuestion(ctx, question.ID, 0); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	session.CurrentQuestionEndedAt = &now
+	if err := s.updateSession(ctx, session); err != nil {
+		return err
+	}
+
+	s.cancelQuestionTimer(quizID)
+
+	// Record the skip in the event log; unlike QUESTION_END it carries no correct answers
+	if err := s.PublishEvent(ctx, quizID, string(websocket.EventQuestionSkipped), map[string]interface{}{
+		"questionId": question.ID.String(),
+		"endTime":    websocket.FormatTimestamp(now),
+	}); err != nil {
+		return err
+	}
+
+	return s.MoveToNextQuestion(ctx, quizID)
+}
+
+// reportMissingRequiredAnswers tells creators which participants did not answer a required question,
+// in join order, so the host can follow up with them
+func (s *stateServiceImpl) reportMissingRequiredAnswers(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) {
+	participants, err := s.participantRepo.GetParticipantsByQuizID(ctx, quizID)
+	if err != nil {
+		s.logger.Error("Error loading participants for missing answers report", "quizId", quizID, "error", err)
+		return
+	}
+
+	answers, err := s.answerRepo.GetAnswersByQuestionID(ctx, questionID)
+	if err != nil {
+		s.logger.Error("Error loading answers for missing answers report", "quizId", quizID, "questionId", questionID, "error", err)
+		return
+	}
+
+	answered := make(map[uuid.UUID]bool, len(answers))
+	for _, answer := range answers {
+		answered[answer.ParticipantID] = true
+	}
+
+	sort.Slice(participants, func(i, j int) bool {
+		return participants[i].JoinedAt.Before(participants[j].JoinedAt)
 	})
+	missing := []string{}
+	for _, participant := range participants {
+		if !answered[participant.ID] {
+			missing = append(missing, participant.ID.String())
+		}
+	}
+
+	if err := s.wsHub.PublishToCreators(quizID, websocket.NewEvent(websocket.EventRequiredAnswersMissing, map[string]interface{}{
+		"questionId":     questionID.String(),
+		"participantIds": missing,
+		"missingCount":   len(missing),
+	})); err != nil {
+		s.logger.Error("Error publishing missing answers report", "quizId", quizID, "questionId", questionID, "error", err)
+	}
 }
 
 // MoveToNextQuestion prepares for the next question
@@ -444,8 +1138,14 @@ func (s *stateServiceImpl) MoveToNextQuestion(ctx context.Context, quizID uuid.U
 	// Try to determine the next question
 	var nextQuestion *model.Question
 
-	// If there's no current question, get the first question
-	if session.CurrentQuestionID == nil {
+	if quiz.Settings.ShuffleQuestions {
+		// Shuffled quizzes follow their seeded run order
+		questions, err := s.questionRepo.GetQuestionsByQuizID(ctx, quizID)
+		if err == nil {
+			nextQuestion = model.QuestionAfter(model.ShuffledQuestions(questions, quiz.Settings.ShuffleSeed), session.CurrentQuestionID)
+		}
+	} else if session.CurrentQuestionID == nil {
+		// If there's no current question, get the first question
 		questions, err := s.questionRepo.GetQuestionsByQuizID(ctx, quizID)
 		if err == nil && len(questions) > 0 {
 			// Find question with order 1
@@ -478,10 +1178,17 @@ func (s *stateServiceImpl) MoveToNextQuestion(ctx context.Context, quizID uuid.U
 	}
 
 	// Update session
-	if err := s.quizRepo.UpdateQuizSession(ctx, session); err != nil {
+	if err := s.updateSession(ctx, session); err != nil {
 		return err
 	}
 
+	// Show the standings after the question that just ended, unless they are held back for a reveal
+	if session.CurrentQuestionID != nil && !session.LeaderboardFrozen {
+		if err := s.publishStandingsSnapshot(ctx, quizID, *session.CurrentQuestionID); err != nil {
+			s.logger.Error("Error publishing leaderboard snapshot", "quizId", quizID, "questionId", *session.CurrentQuestionID, "error", err)
+		}
+	}
+
 	// Broadcast the phase change
 	return s.PublishEvent(ctx, quizID, "PHASE_CHANGE", map[string]interface{}{
 		"quizId":       quizID.String(),
@@ -490,7 +1197,86 @@ func (s *stateServiceImpl) MoveToNextQuestion(ctx context.Context, quizID uuid.U
 	})
 }
 
-// StartQuiz starts a quiz session
+// publishStandingsSnapshot publishes the full leaderboard after a question, with each participant's
+// score change since the previous snapshot. Snapshots are stored in the event log, which is also
+// where the previous scores are read back from.
+func (s *stateServiceImpl) publishStandingsSnapshot(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) error {
+	count, err := s.participantRepo.CountParticipantsByQuizID(ctx, quizID)
+	if err != nil {
+		return err
+	}
+
+	participants := []*model.Participant{}
+	if count > 0 {
+		participants, err = s.participantRepo.GetLeaderboard(ctx, quizID, count, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	previousScores, err := s.previousStandings(ctx, quizID, questionID)
+	if err != nil {
+		return err
+	}
+
+	leaderboardData := make([]map[string]interface{}, len(participants))
+	for i, participant := range participants {
+		leaderboardData[i] = map[string]interface{}{
+			"rank":  participant.Rank,
+			"id":    participant.ID.String(),
+			"name":  participant.Name,
+			"score": participant.Score,
+			"delta": participant.Score - previousScores[participant.ID.String()],
+		}
+	}
+
+	return s.PublishEvent(ctx, quizID, string(websocket.EventLeaderboardUpdate), map[string]interface{}{
+		"questionId":  questionID.String(),
+		"leaderboard": leaderboardData,
+	})
+}
+
+// previousStandings returns the scores of the latest leaderboard snapshot taken after a question
+// other than questionID, keyed by participant ID. Repeating the snapshot for the same question
+// therefore reports the same deltas.
+func (s *stateServiceImpl) previousStandings(ctx context.Context, quizID uuid.UUID, questionID uuid.UUID) (map[string]int, error) {
+	events, err := s.stateRepo.GetEventsByQuizID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]int)
+	for i := len(events) - 1; i >= 0; i-- {
+		event := events[i]
+		if event.EventType != string(websocket.EventLeaderboardUpdate) {
+			continue
+		}
+		if snapshotQuestionID, ok := eventQuestionID(event); !ok || snapshotQuestionID == questionID {
+			continue
+		}
+
+		var snapshot struct {
+			Leaderboard []struct {
+				ID    string `json:"id"`
+				Score int    `json:"score"`
+			} `json:"leaderboard"`
+		}
+		if err := json.Unmarshal(event.Payload, &snapshot); err != nil {
+			continue
+		}
+		for _, entry := range snapshot.Leaderboard {
+			scores[entry.ID] = entry.Score
+		}
+		break
+	}
+
+	return scores, nil
+}
+
+// StartQuiz starts a quiz session unless its creator already runs the maximum number of active quizzes.
+// The limit is checked in the same transaction that activates the quiz, so concurrent starts can't exceed it.
+// Starting a quiz that is already active succeeds without doing anything, so a repeated request such as
+// a double click does not surface an error.
 func (s *stateServiceImpl) StartQuiz(ctx context.Context, quizID uuid.UUID) error {
 	// Get the quiz and session
 	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
@@ -498,18 +1284,19 @@ func (s *stateServiceImpl) StartQuiz(ctx context.Context, quizID uuid.UUID) erro
 		return ErrQuizNotFound
 	}
 
+	if quiz.Status == model.QuizStatusActive {
+		return nil
+	}
 	if quiz.Status != model.QuizStatusWaiting {
 		return ErrQuizAlreadyStarted
 	}
 
-	// Update quiz status
-	if err := s.quizRepo.UpdateQuizStatus(ctx, quizID, model.QuizStatusActive); err != nil {
-		return err
-	}
-
-	// Update session
+	// Load the session before changing anything; recreate it if a partial failure left it missing
 	session, err := s.quizRepo.GetQuizSession(ctx, quizID)
-	if err != nil {
+	if errors.Is(err, repository.ErrQuizSessionNotFound) {
+		s.logger.Warn("Quiz has no session, creating one on start", "quizId", quizID)
+		session = model.NewQuizSession(quizID)
+	} else if err != nil {
 		return err
 	}
 
@@ -519,21 +1306,81 @@ func (s *stateServiceImpl) StartQuiz(ctx context.Context, quizID uuid.UUID) erro
 	// Set the initial phase to BETWEEN_QUESTIONS
 	session.CurrentPhase = model.QuizPhaseBetweenQuestions
 
-	if err := s.quizRepo.UpdateQuizSession(ctx, session); err != nil {
+	// Update quiz status and session together so a failure can't leave them inconsistent
+	if err := s.quizRepo.ActivateQuizWithSession(ctx, session, s.maxActivePerCreator); err != nil {
+		// A concurrent start won the race and is broadcasting the start itself
+		if errors.Is(err, repository.ErrQuizStatusChanged) {
+			return nil
+		}
+		if errors.Is(err, repository.ErrActiveQuizLimit) {
+			return ErrActiveQuizLimit
+		}
 		return err
 	}
+	s.InvalidateQuizState(quizID)
+	metrics.QuizzesStarted.Inc()
+
+	// A manual start makes any running lobby countdown obsolete
+	s.cancelLobbyCountdown(quizID)
 
 	// Broadcast quiz start event to all clients
 	return s.PublishEvent(ctx, quizID, string(websocket.EventQuizStart), map[string]interface{}{
 		"quizId":       quizID.String(),
 		"title":        quiz.Title,
 		"description":  quiz.Description,
-		"startTime":    now.Format(time.RFC3339),
+		"startTime":    websocket.FormatTimestamp(now),
 		"currentPhase": string(model.QuizPhaseBetweenQuestions),
 	})
 }
 
-// EndQuiz ends a quiz session
+// StartLobbyCountdown counts down from seconds in the lobby of a waiting quiz, then starts it
+func (s *stateServiceImpl) StartLobbyCountdown(ctx context.Context, quizID uuid.UUID, seconds int) error {
+	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		return ErrQuizNotFound
+	}
+
+	if quiz.Status != model.QuizStatusWaiting {
+		return ErrQuizAlreadyStarted
+	}
+
+	s.timersMu.Lock()
+	if _, running := s.lobbyCountdowns[quizID]; running {
+		s.timersMu.Unlock()
+		return ErrCountdownInProgress
+	}
+	countdownCtx, cancel := context.WithCancel(context.Background())
+	s.lobbyCountdowns[quizID] = cancel
+	s.timersMu.Unlock()
+
+	go func() {
+		defer s.cancelLobbyCountdown(quizID)
+
+		if !s.wsHub.StartCountdownBroadcast(countdownCtx, quizID, seconds) {
+			return
+		}
+
+		if err := s.StartQuiz(context.Background(), quizID); err != nil {
+			s.logger.Warn("Error starting quiz after lobby countdown", "quizId", quizID, "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// cancelLobbyCountdown stops the running lobby countdown for the quiz, if any
+func (s *stateServiceImpl) cancelLobbyCountdown(quizID uuid.UUID) {
+	s.timersMu.Lock()
+	defer s.timersMu.Unlock()
+
+	if cancel, ok := s.lobbyCountdowns[quizID]; ok {
+		cancel()
+		delete(s.lobbyCountdowns, quizID)
+	}
+}
+
+// EndQuiz ends a quiz session. Ending a quiz that is already completed succeeds without doing
+// anything, while ending a quiz that never started is still rejected.
 func (s *stateServiceImpl) EndQuiz(ctx context.Context, quizID uuid.UUID) error {
 	// Get the quiz and session
 	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
@@ -541,16 +1388,14 @@ func (s *stateServiceImpl) EndQuiz(ctx context.Context, quizID uuid.UUID) error
 		return ErrQuizNotFound
 	}
 
+	if quiz.Status == model.QuizStatusCompleted {
+		return nil
+	}
 	if quiz.Status != model.QuizStatusActive {
 		return ErrQuizNotActive
 	}
 
-	// Update quiz status
-	if err := s.quizRepo.UpdateQuizStatus(ctx, quizID, model.QuizStatusCompleted); err != nil {
-		return err
-	}
-
-	// Update session
+	// Load the session before changing the quiz status
 	session, err := s.quizRepo.GetQuizSession(ctx, quizID)
 	if err != nil {
 		return err
@@ -565,15 +1410,285 @@ func (s *stateServiceImpl) EndQuiz(ctx context.Context, quizID uuid.UUID) error
 	session.CurrentQuestionStartedAt = nil
 	session.CurrentQuestionEndedAt = nil
 
-	if err := s.quizRepo.UpdateQuizSession(ctx, session); err != nil {
+	// Update quiz status and session together
+	if err := s.quizRepo.UpdateQuizStatusWithSession(ctx, model.QuizStatusActive, model.QuizStatusCompleted, session); err != nil {
+		// A concurrent end won the race and is broadcasting the end itself
+		if errors.Is(err, repository.ErrQuizStatusChanged) {
+			return nil
+		}
 		return err
 	}
+	s.InvalidateQuizState(quizID)
+
+	// Stop any question timers still running for the quiz
+	s.cancelQuestionTimer(quizID)
+	s.wsHub.ClearQuestionAcks(quizID)
 
 	// Broadcast quiz end event to all clients
 	return s.PublishEvent(ctx, quizID, string(websocket.EventQuizEnd), map[string]interface{}{
+		"quizId":          quizID.String(),
+		"endTime":         websocket.FormatTimestamp(now),
+		"title":           quiz.Title,
+		"durationSeconds": int(session.EndedAt.Sub(*session.StartedAt).Seconds()),
+		"questions":       s.questionTimings(ctx, quizID, now),
+	})
+}
+
+// questionTimings reports how long each question of a quiz run stayed active, from the event log.
+// A question still running when the quiz ended is counted until endedAt.
+func (s *stateServiceImpl) questionTimings(ctx context.Context, quizID uuid.UUID, endedAt time.Time) []dto.QuestionTimelineDTO {
+	events, err := s.stateRepo.GetEventsByQuizID(ctx, quizID)
+	if err != nil {
+		s.logger.Error("Error loading events for question timings", "quizId", quizID, "error", err)
+		return []dto.QuestionTimelineDTO{}
+	}
+
+	questions := buildQuizTimeline(quizID, events).Questions
+	if last := len(questions) - 1; last >= 0 && questions[last].EndedAt == nil {
+		questions[last].EndedAt = &endedAt
+		questions[last].DurationSeconds = endedAt.Sub(questions[last].StartedAt).Seconds()
+	}
+
+	return questions
+}
+
+// PublishSettingsUpdate sends creators the full quiz settings and participants only the settings they may see
+func (s *stateServiceImpl) PublishSettingsUpdate(ctx context.Context, quizID uuid.UUID, settings model.QuizSettings) error {
+	participantEvent := map[string]interface{}{
+		"quizId":   quizID.String(),
+		"settings": dto.ParticipantQuizSettingsFromModel(settings),
+	}
+
+	// Only the participant payload is stored since stored events may be replayed to any client
+	if err := s.recordEvent(ctx, quizID, string(websocket.EventSettingsUpdated), participantEvent); err != nil {
+		return err
+	}
+
+	// Publish so the host's other devices and participants connected to other instances stay in sync
+	creatorEvent := websocket.NewEvent(websocket.EventSettingsUpdated, map[string]interface{}{
 		"quizId":   quizID.String(),
-		"endTime":  now.Format(time.RFC3339),
-		"title":    quiz.Title,
-		"duration": session.EndedAt.Sub(*session.StartedAt).Seconds(),
+		"settings": settings,
+	})
+	if err := s.wsHub.PublishToCreators(quizID, creatorEvent); err != nil {
+		s.logger.Error("Error publishing settings update to creators; delivering it to this instance only", "quizId", quizID, "error", err)
+		s.wsHub.BroadcastToCreators(quizID, creatorEvent)
+	}
+	wsEvent := websocket.NewEvent(websocket.EventSettingsUpdated, participantEvent)
+	if err := s.wsHub.PublishToParticipants(quizID, wsEvent); err != nil {
+		s.logger.Error("Error publishing settings update to participants; delivering it to this instance only", "quizId", quizID, "error", err)
+		s.wsHub.BroadcastToParticipants(quizID, wsEvent)
+	}
+
+	return nil
+}
+
+// GetQuizTimeline reconstructs the chronology of a quiz run from its event log
+func (s *stateServiceImpl) GetQuizTimeline(ctx context.Context, quizID uuid.UUID) (*dto.QuizTimelineDTO, error) {
+	if _, err := s.quizRepo.GetQuizByID(ctx, quizID); err != nil {
+		return nil, ErrQuizNotFound
+	}
+
+	events, err := s.stateRepo.GetEventsByQuizID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildQuizTimeline(quizID, events), nil
+}
+
+// buildQuizTimeline turns an ordered event log into a timeline of quiz and question lifecycle events
+func buildQuizTimeline(quizID uuid.UUID, events []*model.QuizEvent) *dto.QuizTimelineDTO {
+	timeline := &dto.QuizTimelineDTO{
+		QuizID:    quizID,
+		Questions: []dto.QuestionTimelineDTO{},
+		Entries:   []dto.TimelineEntryDTO{},
+	}
+
+	// Index of the question currently waiting for its end event
+	openQuestion := -1
+
+	for _, event := range events {
+		entry := dto.TimelineEntryDTO{
+			SequenceNumber: event.SequenceNumber,
+			EventType:      event.EventType,
+			Timestamp:      event.CreatedAt,
+		}
+
+		switch websocket.EventType(event.EventType) {
+		case websocket.EventQuizStart:
+			startedAt := event.CreatedAt
+			timeline.StartedAt = &startedAt
+
+		case websocket.EventQuestionStart:
+			questionID, ok := eventQuestionID(event)
+			if !ok {
+				continue
+			}
+			entry.QuestionID = &questionID
+
+			timeline.Questions = append(timeline.Questions, dto.QuestionTimelineDTO{
+				QuestionID: questionID,
+				StartedAt:  event.CreatedAt,
+			})
+			openQuestion = len(timeline.Questions) - 1
+
+		case websocket.EventQuestionEnd, websocket.EventQuestionSkipped:
+			questionID, ok := eventQuestionID(event)
+			if !ok {
+				continue
+			}
+			entry.QuestionID = &questionID
+
+			if openQuestion >= 0 && timeline.Questions[openQuestion].QuestionID == questionID {
+				endedAt := event.CreatedAt
+				question := &timeline.Questions[openQuestion]
+				question.EndedAt = &endedAt
+				question.DurationSeconds = endedAt.Sub(question.StartedAt).Seconds()
+				question.Skipped = websocket.EventType(event.EventType) == websocket.EventQuestionSkipped
+				openQuestion = -1
+			}
+
+		case websocket.EventQuizEnd:
+			endedAt := event.CreatedAt
+			timeline.EndedAt = &endedAt
+
+		default:
+			// Only lifecycle events are part of the timeline
+			continue
+		}
+
+		timeline.Entries = append(timeline.Entries, entry)
+	}
+
+	return timeline
+}
+
+// eventQuestionID extracts the question ID from a stored event payload
+func eventQuestionID(event *model.QuizEvent) (uuid.UUID, bool) {
+	var payload struct {
+		QuestionID string `json:"questionId"`
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return uuid.Nil, false
+	}
+
+	questionID, err := uuid.Parse(payload.QuestionID)
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	return questionID, true
+}
+
+// FreezeLeaderboard stops leaderboard broadcasts of an active quiz and snapshots the current standings.
+// Scores keep being recorded while frozen.
+func (s *stateServiceImpl) FreezeLeaderboard(ctx context.Context, quizID uuid.UUID) error {
+	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		return ErrQuizNotFound
+	}
+
+	if quiz.Status != model.QuizStatusActive {
+		return ErrQuizNotActive
+	}
+
+	session, err := s.quizRepo.GetQuizSession(ctx, quizID)
+	if err != nil {
+		return err
+	}
+
+	if session.LeaderboardFrozen {
+		return ErrLeaderboardFrozen
+	}
+
+	count, err := s.participantRepo.CountParticipantsByQuizID(ctx, quizID)
+	if err != nil {
+		return err
+	}
+
+	// Snapshot every participant so anyone can be compared on reveal, not only the leaders
+	standings := []model.LeaderboardStanding{}
+	if count > 0 {
+		participants, err := s.participantRepo.GetLeaderboard(ctx, quizID, count, 0)
+		if err != nil {
+			return err
+		}
+		for _, p := range participants {
+			standings = append(standings, model.LeaderboardStanding{ParticipantID: p.ID, Rank: p.Rank, Score: p.Score})
+		}
+	}
+
+	if err := s.quizRepo.SetLeaderboardFrozen(ctx, quizID, true, standings); err != nil {
+		return err
+	}
+
+	return s.PublishEvent(ctx, quizID, string(websocket.EventLeaderboardFrozen), map[string]interface{}{
+		"quizId":    quizID.String(),
+		"timestamp": websocket.FormatTimestamp(time.Now()),
+	})
+}
+
+// RevealFinalLeaderboard unfreezes the leaderboard and publishes the full standings,
+// with each participant's movement since the freeze
+func (s *stateServiceImpl) RevealFinalLeaderboard(ctx context.Context, quizID uuid.UUID) error {
+	session, err := s.quizRepo.GetQuizSession(ctx, quizID)
+	if err != nil {
+		return ErrQuizNotFound
+	}
+
+	if !session.LeaderboardFrozen {
+		return ErrLeaderboardNotFrozen
+	}
+
+	count, err := s.participantRepo.CountParticipantsByQuizID(ctx, quizID)
+	if err != nil {
+		return err
+	}
+
+	participants := []*model.Participant{}
+	if count > 0 {
+		participants, err = s.participantRepo.GetLeaderboard(ctx, quizID, count, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	previousRanks := make(map[uuid.UUID]int, len(session.FrozenLeaderboard))
+	for _, standing := range session.FrozenLeaderboard {
+		previousRanks[standing.ParticipantID] = standing.Rank
+	}
+
+	leaderboard := make([]map[string]interface{}, 0, len(participants))
+	for _, participant := range participants {
+		entry := map[string]interface{}{
+			"rank":     participant.Rank,
+			"id":       participant.ID.String(),
+			"name":     participant.Name,
+			"score":    participant.Score,
+			"movement": "new",
+		}
+
+		if previous, ok := previousRanks[participant.ID]; ok {
+			entry["previousRank"] = previous
+			switch {
+			case participant.Rank < previous:
+				entry["movement"] = "up"
+			case participant.Rank > previous:
+				entry["movement"] = "down"
+			default:
+				entry["movement"] = "same"
+			}
+		}
+
+		leaderboard = append(leaderboard, entry)
+	}
+
+	if err := s.quizRepo.SetLeaderboardFrozen(ctx, quizID, false, nil); err != nil {
+		return err
+	}
+
+	return s.PublishEvent(ctx, quizID, string(websocket.EventLeaderboardUpdate), map[string]interface{}{
+		"leaderboard": leaderboard,
+		"revealed":    true,
 	})
 }
@@ -0,0 +1,1216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
+	"github.com/google/uuid"
+)
+
+// hasCorrectFlags reports whether any option in a QUESTION_START payload carries isCorrect
+func hasCorrectFlags(payload map[string]interface{}) bool {
+	switch options := payload["options"].(type) {
+	case []map[string]interface{}:
+		for _, option := range options {
+			if _, ok := option["isCorrect"]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestStartQuestionSendsCorrectAnswersToCreatorsOnly(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{ManualAdvance: true})
+	question := env.seedQuestion(t, quiz, 1)
+
+	if err := env.state.StartQuestion(context.Background(), quiz.ID, question.ID, false); err != nil {
+		t.Fatalf("StartQuestion: %v", err)
+	}
+
+	events := env.hub.events(websocket.EventQuestionStart)
+	if len(events) != 2 {
+		t.Fatalf("got %d QUESTION_START events, want one for creators and one for participants", len(events))
+	}
+
+	for _, event := range events {
+		flagged := hasCorrectFlags(event.payload())
+		switch {
+		case event.reachesParticipants() && flagged:
+			t.Errorf("QUESTION_START sent to %s includes isCorrect", event.Audience)
+		case !event.reachesParticipants() && !flagged:
+			t.Errorf("creator QUESTION_START lacks isCorrect")
+		}
+	}
+}
+
+func TestQuizStateIncludesCorrectAnswersForCreatorReconnectOnly(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1)
+	env.runQuestion(t, question, 0)
+
+	creatorState, err := env.state.GetQuizState(context.Background(), quiz.ID, true)
+	if err != nil {
+		t.Fatalf("GetQuizState for creator: %v", err)
+	}
+	participantState, err := env.state.GetQuizState(context.Background(), quiz.ID, false)
+	if err != nil {
+		t.Fatalf("GetQuizState for participant: %v", err)
+	}
+
+	if creatorState.ActiveQuestion == nil || participantState.ActiveQuestion == nil {
+		t.Fatal("state sync has no active question")
+	}
+
+	creatorCorrect := 0
+	for _, option := range creatorState.ActiveQuestion.Options {
+		if option.IsCorrect {
+			creatorCorrect++
+		}
+	}
+	if creatorCorrect != 1 {
+		t.Errorf("creator state marks %d options correct, want 1", creatorCorrect)
+	}
+
+	for _, option := range participantState.ActiveQuestion.Options {
+		if option.IsCorrect {
+			t.Errorf("participant state marks option %s correct while the question is running", option.ID)
+		}
+	}
+}
+
+func TestEndQuestionReportsMissingRequiredAnswersToCreators(t *testing.T) {
+	tests := []struct {
+		name     string
+		required bool
+	}{
+		{name: "required", required: true},
+		{name: "optional", required: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+			question := env.seedQuestion(t, quiz, 1)
+			question.Required = tt.required
+			if err := env.questionRepo.UpdateQuestion(ctx, question); err != nil {
+				t.Fatalf("UpdateQuestion: %v", err)
+			}
+			env.runQuestion(t, question, time.Second)
+
+			silent := env.seedParticipant(t, quiz, "Silent")
+			answering := env.seedParticipant(t, quiz, "Answering")
+			late := env.seedParticipant(t, quiz, "Late joiner")
+			if _, err := env.answers.Submit(ctx, answering.ID, question.ID, []string{correctOption(question)}, ""); err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+
+			if err := env.state.EndQuestion(ctx, quiz.ID); err != nil {
+				t.Fatalf("EndQuestion: %v", err)
+			}
+
+			events := env.hub.events(websocket.EventRequiredAnswersMissing)
+			if !tt.required {
+				if len(events) != 0 {
+					t.Fatalf("optional question reported missing answers: %v", events[0].payload())
+				}
+				return
+			}
+			if len(events) != 1 {
+				t.Fatalf("got %d REQUIRED_ANSWERS_MISSING events, want 1", len(events))
+			}
+			if events[0].reachesParticipants() {
+				t.Fatalf("REQUIRED_ANSWERS_MISSING sent to %s, want creators only", events[0].Audience)
+			}
+
+			payload := events[0].payload()
+			want := []string{silent.ID.String(), late.ID.String()}
+			if fmt.Sprint(payload["participantIds"]) != fmt.Sprint(want) {
+				t.Errorf("participantIds = %v, want %v in join order", payload["participantIds"], want)
+			}
+			if payload["missingCount"] != len(want) {
+				t.Errorf("missingCount = %v, want %d", payload["missingCount"], len(want))
+			}
+			if payload["questionId"] != question.ID.String() {
+				t.Errorf("questionId = %v, want %s", payload["questionId"], question.ID)
+			}
+		})
+	}
+}
+
+// cachedStateConfig enables the state cache with its longest TTL
+func cachedStateConfig(cfg *config.QuizConfig) {
+	cfg.StateCacheTTL = time.Minute
+}
+
+func TestConcurrentConnectsComputeQuizStateOnce(t *testing.T) {
+	env := newTestEnv(t, cachedStateConfig)
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1)
+	env.runQuestion(t, question, time.Second)
+	for i := 0; i < 5; i++ {
+		env.seedParticipant(t, quiz, fmt.Sprintf("Player %d", i))
+	}
+	env.store.resetCalls()
+
+	const connects = 50
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	errs := make(chan error, connects)
+	for i := 0; i < connects; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			state, err := env.state.GetQuizState(context.Background(), quiz.ID, false)
+			if err == nil && len(state.Participants) != 5 {
+				err = fmt.Errorf("state has %d participants, want 5", len(state.Participants))
+			}
+			if err != nil {
+				errs <- err
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("GetQuizState: %v", err)
+	}
+
+	for _, method := range []string{"GetQuizByID", "GetQuizSession", "GetParticipantsByQuizID"} {
+		if got := env.store.callCount(method); got != 1 {
+			t.Errorf("%d connects called %s %d times, want 1", connects, method, got)
+		}
+	}
+}
+
+func TestStateCacheTTLStaysUnderOneTimerTick(t *testing.T) {
+	env := newTestEnv(t, cachedStateConfig)
+	if env.state.stateCacheTTL != maxStateCacheTTL || maxStateCacheTTL >= time.Second {
+		t.Errorf("state cache TTL = %s, want it capped below the 1s timer tick", env.state.stateCacheTTL)
+	}
+}
+
+func TestQuizWritesDropCachedState(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(t *testing.T, env *testEnv, quiz *model.Quiz, question *model.Question, participant *model.Participant)
+	}{
+		{
+			name: "start question",
+			write: func(t *testing.T, env *testEnv, quiz *model.Quiz, question *model.Question, participant *model.Participant) {
+				if err := env.state.StartQuestion(context.Background(), quiz.ID, question.ID, false); err != nil {
+					t.Fatalf("StartQuestion: %v", err)
+				}
+			},
+		},
+		{
+			name: "extend question time",
+			write: func(t *testing.T, env *testEnv, quiz *model.Quiz, question *model.Question, participant *model.Participant) {
+				env.runQuestion(t, question, time.Second)
+				env.state.InvalidateQuizState(quiz.ID)
+				if _, err := env.state.GetQuizState(context.Background(), quiz.ID, false); err != nil {
+					t.Fatalf("GetQuizState: %v", err)
+				}
+				if err := env.state.ExtendQuestionTime(context.Background(), quiz.ID, 10); err != nil {
+					t.Fatalf("ExtendQuestionTime: %v", err)
+				}
+			},
+		},
+		{
+			name: "settings update",
+			write: func(t *testing.T, env *testEnv, quiz *model.Quiz, question *model.Question, participant *model.Participant) {
+				if _, err := env.quizzes.UpdateQuizSettings(context.Background(), quiz.ID, model.QuizSettings{AllowLateJoin: true, Anonymous: true}); err != nil {
+					t.Fatalf("UpdateQuizSettings: %v", err)
+				}
+			},
+		},
+		{
+			name: "join",
+			write: func(t *testing.T, env *testEnv, quiz *model.Quiz, question *model.Question, participant *model.Participant) {
+				if _, err := env.participants.JoinQuiz(context.Background(), quiz.ID, "Latecomer", nil); err != nil {
+					t.Fatalf("JoinQuiz: %v", err)
+				}
+			},
+		},
+		{
+			name: "kick",
+			write: func(t *testing.T, env *testEnv, quiz *model.Quiz, question *model.Question, participant *model.Participant) {
+				if err := env.participants.KickParticipant(context.Background(), participant.ID, false); err != nil {
+					t.Fatalf("KickParticipant: %v", err)
+				}
+			},
+		},
+		{
+			name: "score recompute",
+			write: func(t *testing.T, env *testEnv, quiz *model.Quiz, question *model.Question, participant *model.Participant) {
+				env.runQuestion(t, question, time.Second)
+				env.state.InvalidateQuizState(quiz.ID)
+				if _, err := env.state.GetQuizState(context.Background(), quiz.ID, false); err != nil {
+					t.Fatalf("GetQuizState: %v", err)
+				}
+				if _, err := env.answers.Submit(context.Background(), participant.ID, question.ID, []string{correctOption(question)}, ""); err != nil {
+					t.Fatalf("Submit: %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, cachedStateConfig)
+			ctx := context.Background()
+			quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{AllowLateJoin: true, ManualAdvance: true})
+			question := env.seedQuestion(t, quiz, 1)
+			participant := env.seedParticipant(t, quiz, "Player")
+
+			before, err := env.state.GetQuizState(ctx, quiz.ID, false)
+			if err != nil {
+				t.Fatalf("GetQuizState: %v", err)
+			}
+			tt.write(t, env, quiz, question, participant)
+			env.store.resetCalls()
+
+			after, err := env.state.GetQuizState(ctx, quiz.ID, false)
+			if err != nil {
+				t.Fatalf("GetQuizState: %v", err)
+			}
+			if got := env.store.callCount("GetQuizSession"); got != 1 {
+				t.Errorf("state after the write came from the cache (%d session reads)", got)
+			}
+			if after == before {
+				t.Error("state after the write is the snapshot computed before it")
+			}
+		})
+	}
+}
+
+func TestStartingAQuestionThatAlreadyRunNeedsForce(t *testing.T) {
+	paths := []struct {
+		name  string
+		start func(env *testEnv, quizID, questionID uuid.UUID, force bool) error
+	}{
+		{
+			name: "start",
+			start: func(env *testEnv, quizID, questionID uuid.UUID, force bool) error {
+				return env.questions.StartQuestion(context.Background(), quizID, questionID, force)
+			},
+		},
+		{
+			name: "goto",
+			start: func(env *testEnv, quizID, questionID uuid.UUID, force bool) error {
+				return env.state.GoToQuestion(context.Background(), quizID, questionID, force)
+			},
+		},
+	}
+
+	for _, path := range paths {
+		t.Run(path.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{ManualAdvance: true})
+			first := env.seedQuestion(t, quiz, 1)
+			second := env.seedQuestion(t, quiz, 2)
+
+			if err := path.start(env, quiz.ID, first.ID, false); err != nil {
+				t.Fatalf("first start: %v", err)
+			}
+			if err := env.state.EndQuestion(ctx, quiz.ID); err != nil {
+				t.Fatalf("EndQuestion: %v", err)
+			}
+
+			// Another question ending since must not make the first one look new again
+			if err := path.start(env, quiz.ID, second.ID, false); err != nil {
+				t.Fatalf("start of the next question: %v", err)
+			}
+			if err := env.state.EndQuestion(ctx, quiz.ID); err != nil {
+				t.Fatalf("EndQuestion: %v", err)
+			}
+
+			starts := len(env.hub.events(websocket.EventQuestionStart))
+			if err := path.start(env, quiz.ID, first.ID, false); !errors.Is(err, ErrQuestionAlreadyRun) {
+				t.Fatalf("restart without force: got %v, want ErrQuestionAlreadyRun", err)
+			}
+			if got := len(env.hub.events(websocket.EventQuestionStart)); got != starts {
+				t.Errorf("rejected restart sent %d QUESTION_START events", got-starts)
+			}
+			session, err := env.quizRepo.GetQuizSession(ctx, quiz.ID)
+			if err != nil {
+				t.Fatalf("GetQuizSession: %v", err)
+			}
+			if *session.CurrentQuestionID != second.ID || session.CurrentPhase != model.QuizPhaseShowingResults {
+				t.Errorf("rejected restart changed the session to question %s in phase %s", session.CurrentQuestionID, session.CurrentPhase)
+			}
+
+			if err := path.start(env, quiz.ID, first.ID, true); err != nil {
+				t.Fatalf("restart with force: %v", err)
+			}
+			session, err = env.quizRepo.GetQuizSession(ctx, quiz.ID)
+			if err != nil {
+				t.Fatalf("GetQuizSession: %v", err)
+			}
+			if *session.CurrentQuestionID != first.ID || session.CurrentPhase != model.QuizPhaseQuestionActive {
+				t.Errorf("forced restart left question %s in phase %s", session.CurrentQuestionID, session.CurrentPhase)
+			}
+		})
+	}
+}
+
+func TestQuestionWithAnswersCountsAsRun(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{ManualAdvance: true})
+	question := env.seedQuestion(t, quiz, 1)
+	participant := env.seedParticipant(t, quiz, "Player")
+	env.runQuestion(t, question, time.Second)
+	if _, err := env.answers.Submit(context.Background(), participant.ID, question.ID, []string{correctOption(question)}, ""); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	if err := env.state.GoToQuestion(context.Background(), quiz.ID, question.ID, false); !errors.Is(err, ErrQuestionAlreadyRun) {
+		t.Errorf("GoToQuestion on a question with answers: got %v, want ErrQuestionAlreadyRun", err)
+	}
+}
+
+func TestBuildQuizTimelineFromEventLog(t *testing.T) {
+	quizID := uuid.New()
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+	start := time.Date(2025, 4, 28, 14, 0, 0, 0, time.UTC)
+
+	var events []*model.QuizEvent
+	add := func(eventType websocket.EventType, after time.Duration, payload string) {
+		event := model.NewQuizEvent(quizID, string(eventType), []byte(payload), int64(len(events)+1))
+		event.CreatedAt = start.Add(after)
+		events = append(events, event)
+	}
+	question := func(id uuid.UUID) string {
+		return fmt.Sprintf(`{"questionId":%q}`, id)
+	}
+
+	add(websocket.EventQuizStart, 0, `{}`)
+	add(websocket.EventUserJoined, 2*time.Second, `{"id":"someone"}`)
+	add(websocket.EventQuestionStart, 5*time.Second, question(first))
+	add(websocket.EventQuestionEnd, 35*time.Second, question(first))
+	add(websocket.EventQuestionStart, 40*time.Second, question(second))
+	add(websocket.EventQuestionSkipped, 50*time.Second, question(second))
+	add(websocket.EventQuestionStart, 55*time.Second, question(third))
+	add(websocket.EventQuizEnd, 70*time.Second, `{}`)
+
+	timeline := buildQuizTimeline(quizID, events)
+
+	if timeline.StartedAt == nil || !timeline.StartedAt.Equal(start) {
+		t.Errorf("startedAt = %v, want %v", timeline.StartedAt, start)
+	}
+	if timeline.EndedAt == nil || !timeline.EndedAt.Equal(start.Add(70*time.Second)) {
+		t.Errorf("endedAt = %v, want %v", timeline.EndedAt, start.Add(70*time.Second))
+	}
+
+	// Presence events are not part of the chronology
+	if len(timeline.Entries) != len(events)-1 {
+		t.Errorf("got %d entries, want %d lifecycle events", len(timeline.Entries), len(events)-1)
+	}
+	for i := 1; i < len(timeline.Entries); i++ {
+		if timeline.Entries[i].SequenceNumber <= timeline.Entries[i-1].SequenceNumber {
+			t.Errorf("entries out of sequence order at %d", i)
+		}
+	}
+
+	want := []struct {
+		id       uuid.UUID
+		duration float64
+		ended    bool
+		skipped  bool
+	}{
+		{id: first, duration: 30, ended: true},
+		{id: second, duration: 10, ended: true, skipped: true},
+		{id: third, ended: false},
+	}
+	if len(timeline.Questions) != len(want) {
+		t.Fatalf("got %d questions, want %d", len(timeline.Questions), len(want))
+	}
+	for i, w := range want {
+		got := timeline.Questions[i]
+		if got.QuestionID != w.id {
+			t.Errorf("question %d is %s, want %s", i, got.QuestionID, w.id)
+		}
+		if (got.EndedAt != nil) != w.ended || got.DurationSeconds != w.duration || got.Skipped != w.skipped {
+			t.Errorf("question %d: ended=%t duration=%v skipped=%t, want ended=%t duration=%v skipped=%t",
+				i, got.EndedAt != nil, got.DurationSeconds, got.Skipped, w.ended, w.duration, w.skipped)
+		}
+	}
+}
+
+func TestGetQuizTimelineOfUnknownQuiz(t *testing.T) {
+	env := newTestEnv(t)
+	if _, err := env.state.GetQuizTimeline(context.Background(), uuid.New()); !errors.Is(err, ErrQuizNotFound) {
+		t.Errorf("got %v, want ErrQuizNotFound", err)
+	}
+}
+
+func TestEndingAQuestionEarlyCancelsItsAutoEndTimer(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	first := env.seedQuestion(t, quiz, 1)
+	second := env.seedQuestion(t, quiz, 2)
+	first.TimeLimit = 1
+	if err := env.questionRepo.UpdateQuestion(ctx, first); err != nil {
+		t.Fatalf("UpdateQuestion: %v", err)
+	}
+
+	if err := env.state.StartQuestion(ctx, quiz.ID, first.ID, false); err != nil {
+		t.Fatalf("StartQuestion: %v", err)
+	}
+	if err := env.state.EndQuestion(ctx, quiz.ID); err != nil {
+		t.Fatalf("EndQuestion: %v", err)
+	}
+
+	env.state.timersMu.Lock()
+	_, running := env.state.questionTimers[quiz.ID]
+	env.state.timersMu.Unlock()
+	if running {
+		t.Error("auto-end timer still registered after the question was ended")
+	}
+
+	// The next question must outlive the first question's deadline
+	if err := env.state.StartQuestion(ctx, quiz.ID, second.ID, false); err != nil {
+		t.Fatalf("StartQuestion: %v", err)
+	}
+	time.Sleep(1500 * time.Millisecond)
+
+	if got := len(env.hub.events(websocket.EventQuestionEnd)); got != 1 {
+		t.Errorf("got %d QUESTION_END events, want only the manual one", got)
+	}
+	session, err := env.quizRepo.GetQuizSession(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("GetQuizSession: %v", err)
+	}
+	if session.CurrentQuestionID == nil || *session.CurrentQuestionID != second.ID || session.CurrentPhase != model.QuizPhaseQuestionActive {
+		t.Error("the first question's timer ended the question that replaced it")
+	}
+}
+
+func TestStartQuizRecreatesAMissingSession(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+
+	// Drop the session row, as a partial failure while creating the quiz would
+	env.store.mu.Lock()
+	delete(env.store.sessions, quiz.ID)
+	env.store.mu.Unlock()
+
+	if err := env.state.StartQuiz(ctx, quiz.ID); err != nil {
+		t.Fatalf("StartQuiz: %v", err)
+	}
+
+	stored, err := env.quizRepo.GetQuizByID(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("GetQuizByID: %v", err)
+	}
+	if stored.Status != model.QuizStatusActive {
+		t.Errorf("quiz status is %s, want ACTIVE", stored.Status)
+	}
+	session, err := env.quizRepo.GetQuizSession(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("quiz started without a session: %v", err)
+	}
+	if session.Status != model.QuizStatusActive || session.StartedAt == nil || session.CurrentPhase != model.QuizPhaseBetweenQuestions {
+		t.Errorf("recreated session is %s in phase %s, started %v", session.Status, session.CurrentPhase, session.StartedAt)
+	}
+	if got := len(env.hub.events(websocket.EventQuizStart)); got != 1 {
+		t.Errorf("got %d QUIZ_START events, want 1", got)
+	}
+}
+
+func TestEventTimestampsUseTheSharedFormat(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{ManualAdvance: true})
+	question := env.seedQuestion(t, quiz, 1)
+
+	if err := env.state.StartQuiz(ctx, quiz.ID); err != nil {
+		t.Fatalf("StartQuiz: %v", err)
+	}
+	if err := env.state.StartQuestion(ctx, quiz.ID, question.ID, false); err != nil {
+		t.Fatalf("StartQuestion: %v", err)
+	}
+	if err := env.state.EndQuestion(ctx, quiz.ID); err != nil {
+		t.Fatalf("EndQuestion: %v", err)
+	}
+
+	fields := []struct {
+		eventType websocket.EventType
+		field     string
+	}{
+		{eventType: websocket.EventQuizStart, field: "startTime"},
+		{eventType: websocket.EventQuestionStart, field: "startTime"},
+		{eventType: websocket.EventQuestionEnd, field: "endTime"},
+	}
+	for _, f := range fields {
+		events := env.hub.events(f.eventType)
+		if len(events) == 0 {
+			t.Errorf("no %s event sent", f.eventType)
+			continue
+		}
+		for _, event := range events {
+			raw, ok := event.payload()[f.field].(string)
+			if !ok {
+				t.Errorf("%s %s is %T, want a timestamp string", f.eventType, f.field, event.payload()[f.field])
+				continue
+			}
+			if _, err := time.Parse(websocket.TimestampFormat, raw); err != nil || raw[len(raw)-1] != 'Z' {
+				t.Errorf("%s %s %q is not a UTC timestamp", f.eventType, f.field, raw)
+			}
+		}
+	}
+}
+
+func TestGetQuizTimerOfAnActiveQuestion(t *testing.T) {
+	env := newTestEnv(t)
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	question := env.seedQuestion(t, quiz, 1)
+	env.runQuestion(t, question, 10*time.Second)
+
+	timer, err := env.state.GetQuizTimer(context.Background(), quiz.ID)
+	if err != nil {
+		t.Fatalf("GetQuizTimer: %v", err)
+	}
+	if timer.Phase != model.QuizPhaseQuestionActive {
+		t.Errorf("phase is %s, want QUESTION_ACTIVE", timer.Phase)
+	}
+
+	// The question has a 30 second limit and started 10 seconds ago
+	if timer.RemainingSeconds < 19 || timer.RemainingSeconds > 20 {
+		t.Errorf("%d seconds remaining, want 20", timer.RemainingSeconds)
+	}
+	if timer.EndTime == nil {
+		t.Fatal("active question has no end time")
+	}
+	if until := time.Until(*timer.EndTime); until < 19*time.Second || until > 20*time.Second {
+		t.Errorf("question ends in %v, want 20s", until)
+	}
+}
+
+func TestGetQuizTimerWithoutAnActiveQuestion(t *testing.T) {
+	tests := []struct {
+		name   string
+		status model.QuizStatus
+		phase  model.QuizPhase
+	}{
+		{name: "lobby", status: model.QuizStatusWaiting, phase: model.QuizPhaseBetweenQuestions},
+		{name: "between questions", status: model.QuizStatusActive, phase: model.QuizPhaseBetweenQuestions},
+		{name: "showing results", status: model.QuizStatusActive, phase: model.QuizPhaseShowingResults},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			quiz := env.seedQuiz(t, tt.status, model.QuizSettings{})
+			question := env.seedQuestion(t, quiz, 1)
+			if tt.phase == model.QuizPhaseShowingResults {
+				env.runQuestion(t, question, 40*time.Second)
+			}
+			env.setSession(t, quiz.ID, func(session *model.QuizSession) {
+				session.CurrentPhase = tt.phase
+			})
+
+			timer, err := env.state.GetQuizTimer(context.Background(), quiz.ID)
+			if err != nil {
+				t.Fatalf("GetQuizTimer: %v", err)
+			}
+			if timer.Phase != tt.phase {
+				t.Errorf("phase is %s, want %s", timer.Phase, tt.phase)
+			}
+			if timer.RemainingSeconds != 0 || timer.EndTime != nil {
+				t.Errorf("idle quiz reports %d seconds remaining until %v", timer.RemainingSeconds, timer.EndTime)
+			}
+		})
+	}
+}
+
+func TestGetQuizTimerOfUnknownQuiz(t *testing.T) {
+	env := newTestEnv(t)
+	if _, err := env.state.GetQuizTimer(context.Background(), uuid.New()); !errors.Is(err, ErrQuizNotFound) {
+		t.Errorf("GetQuizTimer returned %v, want ErrQuizNotFound", err)
+	}
+}
+
+// seedShuffledQuestion seeds a question whose options a quiz shuffling with its seed shows out of their stored order
+func (e *testEnv) seedShuffledQuestion(t *testing.T, quiz *model.Quiz, optionTexts ...string) *model.Question {
+	t.Helper()
+
+	for order := 1; order <= 10; order++ {
+		question := e.seedQuestion(t, quiz, order, optionTexts...)
+		for i, option := range model.ShuffledOptions(question, quiz.Settings.ShuffleSeed) {
+			if option.ID != question.Options[i].ID {
+				return question
+			}
+		}
+	}
+	t.Fatal("no seeded question had its options shuffled")
+	return nil
+}
+
+func TestOptionLabelsStayWithTheirOptionUnderShuffle(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{ManualAdvance: true, ShuffleOptions: true, ShuffleSeed: 7})
+	question := env.seedShuffledQuestion(t, quiz, "Paris", "London", "Berlin", "Madrid", "Rome", "Oslo")
+
+	// Labels follow the stored order: the first option is A whatever position it is shown at
+	wantLabels := make(map[string]string)
+	for i, option := range question.Options {
+		wantLabels[option.ID.String()] = string(rune('A' + i))
+	}
+
+	if err := env.state.StartQuestion(ctx, quiz.ID, question.ID, false); err != nil {
+		t.Fatalf("StartQuestion: %v", err)
+	}
+	for _, event := range env.hub.events(websocket.EventQuestionStart) {
+		options := event.payload()["options"].([]map[string]interface{})
+		if len(options) != len(question.Options) {
+			t.Fatalf("QUESTION_START sent to %s lists %d options", event.Audience, len(options))
+		}
+		for _, option := range options {
+			id := option["id"].(string)
+			if option["label"] != wantLabels[id] {
+				t.Errorf("QUESTION_START sent to %s labels option %s %v, want %s", event.Audience, option["text"], option["label"], wantLabels[id])
+			}
+		}
+	}
+
+	for _, forCreator := range []bool{true, false} {
+		state, err := env.state.GetQuizState(ctx, quiz.ID, forCreator)
+		if err != nil {
+			t.Fatalf("GetQuizState: %v", err)
+		}
+		if state.ActiveQuestion == nil {
+			t.Fatal("state has no active question")
+		}
+		shuffled := false
+		for i, option := range state.ActiveQuestion.Options {
+			if option.Label != wantLabels[option.ID.String()] {
+				t.Errorf("state sync (creator %v) labels option %s %q, want %s", forCreator, option.Text, option.Label, wantLabels[option.ID.String()])
+			}
+			if option.ID != question.Options[i].ID {
+				shuffled = true
+			}
+		}
+		if shuffled == forCreator {
+			t.Errorf("state sync (creator %v) shuffled the options: %v", forCreator, shuffled)
+		}
+	}
+}
+
+// presenceEvents returns the types of the presence events handed to the hub, in order
+func (e *testEnv) presenceEvents() []websocket.EventType {
+	e.hub.mu.Lock()
+	defer e.hub.mu.Unlock()
+
+	var types []websocket.EventType
+	for _, sent := range e.hub.sent {
+		switch sent.Event.Type {
+		case websocket.EventUserJoined, websocket.EventUserReconnected, websocket.EventUserLeft:
+			types = append(types, sent.Event.Type)
+		}
+	}
+	return types
+}
+
+func TestPresenceFlappingCollapses(t *testing.T) {
+	const debounce = 20 * time.Millisecond
+
+	tests := []struct {
+		name string
+		// connectedBefore has the participant connected before the flapping starts
+		connectedBefore bool
+		flaps           []bool
+		want            []websocket.EventType
+	}{
+		{name: "back where they started", connectedBefore: true, flaps: []bool{false, true, false, true}, want: nil},
+		{name: "net disconnect", connectedBefore: true, flaps: []bool{false, true, false}, want: []websocket.EventType{websocket.EventUserLeft}},
+		{name: "flaky first join", flaps: []bool{true, false, true, false, true}, want: []websocket.EventType{websocket.EventUserJoined}},
+		{name: "join that does not stick", flaps: []bool{true, false}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.QuizConfig) {
+				cfg.PresenceDebounce = debounce
+				cfg.ReconnectGracePeriod = time.Minute
+			})
+			ctx := context.Background()
+			quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+			participant := env.seedParticipant(t, quiz, "Ann")
+
+			connectedAt := time.Now()
+			update := func(connected bool) {
+				t.Helper()
+				if connected {
+					connectedAt = time.Now()
+				}
+				if err := env.state.UpdateParticipantConnection(ctx, participant.ID, quiz.ID, connected, "instance-1", model.DisconnectReasonTimeout, connectedAt); err != nil {
+					t.Fatalf("UpdateParticipantConnection: %v", err)
+				}
+			}
+
+			if tt.connectedBefore {
+				update(true)
+				time.Sleep(5 * debounce)
+			}
+			settled := len(env.presenceEvents())
+			for _, connected := range tt.flaps {
+				update(connected)
+			}
+
+			if got := env.presenceEvents()[settled:]; len(got) != 0 {
+				t.Errorf("presence events %v were published before the connection settled", got)
+			}
+			time.Sleep(5 * debounce)
+			if got := env.presenceEvents()[settled:]; fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("flapping published %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPresenceChangesAfterTheWindowAreEachAnnounced(t *testing.T) {
+	const debounce = 20 * time.Millisecond
+	env := newTestEnv(t, func(cfg *config.QuizConfig) { cfg.PresenceDebounce = debounce })
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	participant := env.seedParticipant(t, quiz, "Ann")
+
+	connectedAt := time.Now()
+	if err := env.state.UpdateParticipantConnection(ctx, participant.ID, quiz.ID, true, "instance-1", "", connectedAt); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	time.Sleep(5 * debounce)
+	if err := env.state.UpdateParticipantConnection(ctx, participant.ID, quiz.ID, false, "instance-1", model.DisconnectReasonClosed, connectedAt); err != nil {
+		t.Fatalf("disconnect: %v", err)
+	}
+	time.Sleep(5 * debounce)
+
+	want := []websocket.EventType{websocket.EventUserJoined, websocket.EventUserLeft}
+	if got := env.presenceEvents(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("settled changes published %v, want %v", got, want)
+	}
+}
+
+func TestPresenceWithoutDebounceAnnouncesEveryChange(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.QuizConfig) {
+		cfg.PresenceDebounce = 0
+		cfg.ReconnectGracePeriod = time.Minute
+	})
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	participant := env.seedParticipant(t, quiz, "Ann")
+
+	for i := 0; i < 2; i++ {
+		connectedAt := time.Now()
+		if err := env.state.UpdateParticipantConnection(ctx, participant.ID, quiz.ID, true, "instance-1", "", connectedAt); err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+		if err := env.state.UpdateParticipantConnection(ctx, participant.ID, quiz.ID, false, "instance-1", model.DisconnectReasonTimeout, connectedAt); err != nil {
+			t.Fatalf("disconnect: %v", err)
+		}
+	}
+
+	want := []websocket.EventType{websocket.EventUserJoined, websocket.EventUserLeft, websocket.EventUserReconnected, websocket.EventUserLeft}
+	if got := env.presenceEvents(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("undebounced changes published %v, want %v", got, want)
+	}
+}
+
+func TestQuizStartAndEndTolerateDuplicates(t *testing.T) {
+	services := []struct {
+		name  string
+		start func(env *testEnv, quizID uuid.UUID) error
+		end   func(env *testEnv, quizID uuid.UUID) error
+	}{
+		{
+			name:  "quiz service",
+			start: func(env *testEnv, quizID uuid.UUID) error { return env.quizzes.StartQuiz(context.Background(), quizID) },
+			end:   func(env *testEnv, quizID uuid.UUID) error { return env.quizzes.EndQuiz(context.Background(), quizID) },
+		},
+		{
+			name:  "state service",
+			start: func(env *testEnv, quizID uuid.UUID) error { return env.state.StartQuiz(context.Background(), quizID) },
+			end:   func(env *testEnv, quizID uuid.UUID) error { return env.state.EndQuiz(context.Background(), quizID) },
+		},
+	}
+
+	for _, svc := range services {
+		t.Run(svc.name, func(t *testing.T) {
+			t.Run("double start and end", func(t *testing.T) {
+				env := newTestEnv(t)
+				quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+
+				for i := 0; i < 2; i++ {
+					if err := svc.start(env, quiz.ID); err != nil {
+						t.Fatalf("start %d: %v", i+1, err)
+					}
+				}
+				if got := len(env.hub.events(websocket.EventQuizStart)); got != 1 {
+					t.Errorf("double start broadcast %d QUIZ_START events, want 1", got)
+				}
+
+				for i := 0; i < 2; i++ {
+					if err := svc.end(env, quiz.ID); err != nil {
+						t.Fatalf("end %d: %v", i+1, err)
+					}
+				}
+				if got := len(env.hub.events(websocket.EventQuizEnd)); got != 1 {
+					t.Errorf("double end broadcast %d QUIZ_END events, want 1", got)
+				}
+				if got := env.quizStatus(t, quiz.ID); got != model.QuizStatusCompleted {
+					t.Errorf("quiz is %s, want COMPLETED", got)
+				}
+			})
+
+			t.Run("concurrent starts", func(t *testing.T) {
+				env := newTestEnv(t)
+				quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+
+				var wg sync.WaitGroup
+				errs := make(chan error, 5)
+				for i := 0; i < 5; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						errs <- svc.start(env, quiz.ID)
+					}()
+				}
+				wg.Wait()
+				close(errs)
+
+				for err := range errs {
+					if err != nil {
+						t.Errorf("concurrent start failed: %v", err)
+					}
+				}
+				if got := len(env.hub.events(websocket.EventQuizStart)); got != 1 {
+					t.Errorf("concurrent starts broadcast %d QUIZ_START events, want 1", got)
+				}
+			})
+
+			t.Run("invalid transitions", func(t *testing.T) {
+				env := newTestEnv(t)
+				waiting := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+				completed := env.seedQuiz(t, model.QuizStatusCompleted, model.QuizSettings{})
+
+				if err := svc.end(env, waiting.ID); !errors.Is(err, ErrQuizNotActive) {
+					t.Errorf("ending a waiting quiz returned %v, want ErrQuizNotActive", err)
+				}
+				if err := svc.start(env, completed.ID); !errors.Is(err, ErrQuizAlreadyStarted) {
+					t.Errorf("starting a completed quiz returned %v, want ErrQuizAlreadyStarted", err)
+				}
+				if got := len(env.hub.events(websocket.EventQuizStart)) + len(env.hub.events(websocket.EventQuizEnd)); got != 0 {
+					t.Errorf("rejected transitions broadcast %d events", got)
+				}
+			})
+		})
+	}
+}
+
+func TestExplanationIsOnlyRevealedWhenTheQuestionEnds(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{ManualAdvance: true})
+	question := env.seedQuestion(t, quiz, 1)
+	question.Explanation = "Because it is right"
+	if err := env.questionRepo.UpdateQuestion(ctx, question); err != nil {
+		t.Fatalf("set explanation: %v", err)
+	}
+
+	if err := env.state.StartQuestion(ctx, quiz.ID, question.ID, false); err != nil {
+		t.Fatalf("StartQuestion: %v", err)
+	}
+	for _, event := range env.hub.events(websocket.EventQuestionStart) {
+		explanation, included := event.payload()["explanation"]
+		if event.reachesParticipants() && included {
+			t.Errorf("QUESTION_START sent to %s includes the explanation", event.Audience)
+		}
+		if !event.reachesParticipants() && explanation != question.Explanation {
+			t.Errorf("creator QUESTION_START has explanation %v, want %q", explanation, question.Explanation)
+		}
+	}
+	participantState, err := env.state.GetQuizState(ctx, quiz.ID, false)
+	if err != nil {
+		t.Fatalf("GetQuizState: %v", err)
+	}
+	if participantState.ActiveQuestion == nil || participantState.ActiveQuestion.Explanation != "" {
+		t.Errorf("participant state of the running question = %+v, want it without the explanation", participantState.ActiveQuestion)
+	}
+
+	if err := env.state.EndQuestion(ctx, quiz.ID); err != nil {
+		t.Fatalf("EndQuestion: %v", err)
+	}
+	ends := env.hub.events(websocket.EventQuestionEnd)
+	if len(ends) != 1 || !ends[0].reachesParticipants() || ends[0].payload()["explanation"] != question.Explanation {
+		t.Fatalf("QUESTION_END events = %+v, want one to everyone with the explanation", ends)
+	}
+	participantState, err = env.state.GetQuizState(ctx, quiz.ID, false)
+	if err != nil {
+		t.Fatalf("GetQuizState: %v", err)
+	}
+	if participantState.ActiveQuestion == nil || participantState.ActiveQuestion.Explanation != question.Explanation {
+		t.Errorf("participant state after the question ended = %+v, want it with the explanation", participantState.ActiveQuestion)
+	}
+}
+
+func TestConnectingOnAnotherInstanceReplacesTheOlderConnection(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	participant := env.seedParticipant(t, quiz, "Ann")
+
+	firstConnectedAt := time.Now().Add(-time.Minute)
+	if err := env.state.UpdateParticipantConnection(ctx, participant.ID, quiz.ID, true, "instance-1", "", firstConnectedAt); err != nil {
+		t.Fatalf("connect on instance-1: %v", err)
+	}
+	if err := env.state.UpdateParticipantConnection(ctx, participant.ID, quiz.ID, true, "instance-2", "", time.Now()); err != nil {
+		t.Fatalf("connect on instance-2: %v", err)
+	}
+	if len(env.hub.replaced) != 1 || env.hub.replaced[0] != participant.ID {
+		t.Fatalf("replaced connections of %v, want the participant's", env.hub.replaced)
+	}
+
+	// The replaced connection's disconnect arrives from the old instance and must not count
+	if err := env.state.UpdateParticipantConnection(ctx, participant.ID, quiz.ID, false, "instance-1", model.DisconnectReasonReplaced, firstConnectedAt); err != nil {
+		t.Fatalf("disconnect on instance-1: %v", err)
+	}
+
+	snapshot, err := env.state.GetLobbySnapshot(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("GetLobbySnapshot: %v", err)
+	}
+	if snapshot.ConnectedCount != 1 || !snapshot.Participants[0].IsConnected {
+		t.Errorf("lobby counts %d connected with the participant connected %v, want them counted once",
+			snapshot.ConnectedCount, snapshot.Participants[0].IsConnected)
+	}
+	for _, eventType := range env.presenceEvents() {
+		if eventType == websocket.EventUserLeft {
+			t.Error("the replaced connection was announced as the participant leaving")
+		}
+	}
+
+	// Reconnecting on the same instance replaces nothing elsewhere
+	if err := env.state.UpdateParticipantConnection(ctx, participant.ID, quiz.ID, true, "instance-2", "", time.Now()); err != nil {
+		t.Fatalf("reconnect on instance-2: %v", err)
+	}
+	if len(env.hub.replaced) != 1 {
+		t.Errorf("a reconnect on the same instance replaced %d connections", len(env.hub.replaced)-1)
+	}
+}
+
+// timerDeadline returns the end time of the n-th question timer the hub was asked to count down
+func (e *testEnv) timerDeadline(t *testing.T, n int) time.Time {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		e.hub.mu.Lock()
+		if len(e.hub.deadlines) > n {
+			defer e.hub.mu.Unlock()
+			return e.hub.deadlines[n]
+		}
+		e.hub.mu.Unlock()
+		if time.Now().After(deadline) {
+			t.Fatalf("the hub was never asked to run question timer %d", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReconnectTimerMatchesTheLiveCountdown(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{ManualAdvance: true})
+	question := env.seedQuestion(t, quiz, 1)
+
+	if err := env.state.StartQuestion(ctx, quiz.ID, question.ID, false); err != nil {
+		t.Fatalf("StartQuestion: %v", err)
+	}
+
+	// checkReconnect asserts that a participant syncing now is told the deadline the live countdown runs to
+	checkReconnect := func(live time.Time, totalSeconds int) {
+		t.Helper()
+
+		state, err := env.state.GetQuizState(ctx, quiz.ID, false)
+		if err != nil {
+			t.Fatalf("GetQuizState: %v", err)
+		}
+		if state.Timer == nil {
+			t.Fatal("state of a running question has no timer")
+		}
+		if !state.Timer.EndTime.Equal(live) {
+			t.Errorf("reconnect ends the question at %v, the live countdown at %v", state.Timer.EndTime, live)
+		}
+		if state.Timer.DurationSeconds != totalSeconds {
+			t.Errorf("reconnect reports a %ds question, want %ds", state.Timer.DurationSeconds, totalSeconds)
+		}
+		if want := model.RemainingSeconds(live, time.Now()); state.Timer.RemainingSeconds != want {
+			t.Errorf("reconnect reports %d seconds remaining, the live countdown %d", state.Timer.RemainingSeconds, want)
+		}
+	}
+
+	started := env.timerDeadline(t, 0)
+	checkReconnect(started, question.TimeLimit)
+
+	// Extending moves both countdowns to the same new deadline
+	if err := env.state.ExtendQuestionTime(ctx, quiz.ID, 15); err != nil {
+		t.Fatalf("ExtendQuestionTime: %v", err)
+	}
+	extended := env.timerDeadline(t, 1)
+	if want := started.Add(15 * time.Second); !extended.Equal(want) {
+		t.Errorf("extended countdown runs to %v, want %v", extended, want)
+	}
+	updates := env.hub.events(websocket.EventTimerUpdate)
+	if len(updates) != 1 || updates[0].payload()["endTime"] != websocket.FormatTimestamp(extended) {
+		t.Errorf("TIMER_UPDATE events after extending = %v, want one ending at the new deadline", updates)
+	}
+	checkReconnect(extended, question.TimeLimit+15)
+}
+
+func TestManualAdvanceLeavesQuestionsOpenPastTheirLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		manual    bool
+		wantEnded bool
+		wantPhase model.QuizPhase
+	}{
+		{name: "manual advance", manual: true, wantPhase: model.QuizPhaseQuestionActive},
+		{name: "auto advance", manual: false, wantEnded: true, wantPhase: model.QuizPhaseShowingResults},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{ManualAdvance: tt.manual})
+			question := env.seedQuestion(t, quiz, 1)
+			question.TimeLimit = 1
+			if err := env.questionRepo.UpdateQuestion(ctx, question); err != nil {
+				t.Fatalf("UpdateQuestion: %v", err)
+			}
+
+			if err := env.state.StartQuestion(ctx, quiz.ID, question.ID, false); err != nil {
+				t.Fatalf("StartQuestion: %v", err)
+			}
+			time.Sleep(1500 * time.Millisecond)
+
+			ended := len(env.hub.events(websocket.EventQuestionEnd)) > 0
+			if ended != tt.wantEnded {
+				t.Errorf("question ended by itself: %v, want %v", ended, tt.wantEnded)
+			}
+			session, err := env.quizRepo.GetQuizSession(ctx, quiz.ID)
+			if err != nil {
+				t.Fatalf("GetQuizSession: %v", err)
+			}
+			if session.CurrentPhase != tt.wantPhase {
+				t.Errorf("question is in phase %s after its limit elapsed, want %s", session.CurrentPhase, tt.wantPhase)
+			}
+			// The countdown is still shown either way
+			if env.timerDeadline(t, 0).IsZero() {
+				t.Error("question ran without a countdown")
+			}
+
+			if tt.manual {
+				if err := env.state.EndQuestion(ctx, quiz.ID); err != nil {
+					t.Fatalf("EndQuestion: %v", err)
+				}
+				if got := len(env.hub.events(websocket.EventQuestionEnd)); got != 1 {
+					t.Errorf("got %d QUESTION_END events after the host ended the question, want 1", got)
+				}
+			}
+		})
+	}
+}
+
+func TestPublishEventDeliversLocallyWhenPublishingFails(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	env.hub.publishErr = errors.New("redis: connection refused")
+
+	payload := map[string]interface{}{"quizId": quiz.ID.String()}
+	if err := env.state.PublishEvent(ctx, quiz.ID, string(websocket.EventQuizStart), payload); err != nil {
+		t.Fatalf("PublishEvent: %v", err)
+	}
+
+	events := env.hub.events(websocket.EventQuizStart)
+	if len(events) != 1 || events[0].Published || events[0].Audience != hubAudienceQuiz {
+		t.Errorf("QUIZ_START was sent as %+v, want one broadcast to this instance's clients", events)
+	}
+	// The event is stored either way, so clients elsewhere replay it when they resync
+	missed, err := env.state.GetMissedEvents(ctx, quiz.ID, 0)
+	if err != nil {
+		t.Fatalf("GetMissedEvents: %v", err)
+	}
+	if len(missed) != 1 || missed[0].EventType != string(websocket.EventQuizStart) {
+		t.Errorf("stored events = %v, want the QUIZ_START", missed)
+	}
+}
+
+func TestQuizEndReportsTheTimingOfEachQuestion(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusActive, model.QuizSettings{})
+	first := env.seedQuestion(t, quiz, 1)
+	second := env.seedQuestion(t, quiz, 2)
+	env.runQuestion(t, second, 20*time.Second)
+
+	// The first question ran for 30 seconds; the second is still running when the quiz ends
+	now := time.Now()
+	for i, e := range []struct {
+		eventType websocket.EventType
+		ago       time.Duration
+		question  *model.Question
+	}{
+		{websocket.EventQuestionStart, 60 * time.Second, first},
+		{websocket.EventQuestionEnd, 30 * time.Second, first},
+		{websocket.EventQuestionStart, 20 * time.Second, second},
+	} {
+		event := model.NewQuizEvent(quiz.ID, string(e.eventType), []byte(fmt.Sprintf(`{"questionId":%q}`, e.question.ID)), int64(i+1))
+		event.CreatedAt = now.Add(-e.ago)
+		if err := env.stateRepo.StoreEvent(ctx, event); err != nil {
+			t.Fatalf("StoreEvent: %v", err)
+		}
+	}
+
+	if err := env.state.EndQuiz(ctx, quiz.ID); err != nil {
+		t.Fatalf("EndQuiz: %v", err)
+	}
+
+	ended := env.hub.events(websocket.EventQuizEnd)
+	if len(ended) != 1 {
+		t.Fatalf("got %d QUIZ_END events, want 1", len(ended))
+	}
+	timings, ok := ended[0].payload()["questions"].([]dto.QuestionTimelineDTO)
+	if !ok || len(timings) != 2 {
+		t.Fatalf("QUIZ_END questions = %v, want two timing entries", ended[0].payload()["questions"])
+	}
+	if timings[0].QuestionID != first.ID || timings[1].QuestionID != second.ID {
+		t.Errorf("timings are for %s and %s, want %s and %s", timings[0].QuestionID, timings[1].QuestionID, first.ID, second.ID)
+	}
+	if timings[0].EndedAt == nil || timings[0].DurationSeconds != 30 {
+		t.Errorf("first question ended at %v after %vs, want 30s", timings[0].EndedAt, timings[0].DurationSeconds)
+	}
+	if timings[1].EndedAt == nil || timings[1].DurationSeconds < 20 || timings[1].DurationSeconds > 21 {
+		t.Errorf("running question ended at %v after %vs, want about 20s up to the quiz end", timings[1].EndedAt, timings[1].DurationSeconds)
+	}
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Error definitions for the team services
+var (
+	ErrTeamNotFound  = errors.New("team not found")
+	ErrTeamNameTaken = errors.New("team name is already taken in this quiz")
+)
+
+// teamServiceImpl implements TeamService interface
+type teamServiceImpl struct {
+	teamRepo repository.TeamRepository
+	quizRepo repository.QuizRepository
+}
+
+// NewTeamService creates a new team service
+func NewTeamService(
+	teamRepo repository.TeamRepository,
+	quizRepo repository.QuizRepository,
+) TeamService {
+	return &teamServiceImpl{
+		teamRepo: teamRepo,
+		quizRepo: quizRepo,
+	}
+}
+
+// CreateTeam adds a team to a quiz, switching the quiz into team mode
+func (s *teamServiceImpl) CreateTeam(ctx context.Context, quizID uuid.UUID, name string) (*model.Team, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("team name is required")
+	}
+
+	quiz, err := s.quizRepo.GetQuizByID(ctx, quizID)
+	if err != nil {
+		return nil, ErrQuizNotFound
+	}
+	if quiz.Status == model.QuizStatusCompleted {
+		return nil, errors.New("cannot add teams to a completed quiz")
+	}
+
+	teams, err := s.teamRepo.GetTeamsByQuizID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range teams {
+		if strings.EqualFold(t.Name, name) {
+			return nil, ErrTeamNameTaken
+		}
+	}
+
+	team := model.NewTeam(name, quizID)
+	if err := s.teamRepo.CreateTeam(ctx, team); err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+// GetTeamsByQuizID retrieves all teams for a quiz
+func (s *teamServiceImpl) GetTeamsByQuizID(ctx context.Context, quizID uuid.UUID) ([]*model.Team, error) {
+	return s.teamRepo.GetTeamsByQuizID(ctx, quizID)
+}
+
+// teamLeaderboardServiceImpl implements TeamLeaderboardService interface
+type teamLeaderboardServiceImpl struct {
+	teamRepo        repository.TeamRepository
+	participantRepo repository.ParticipantRepository
+}
+
+// NewTeamLeaderboardService creates a new team leaderboard service
+func NewTeamLeaderboardService(
+	teamRepo repository.TeamRepository,
+	participantRepo repository.ParticipantRepository,
+) TeamLeaderboardService {
+	return &teamLeaderboardServiceImpl{
+		teamRepo:        teamRepo,
+		participantRepo: participantRepo,
+	}
+}
+
+// GetTeamLeaderboard ranks the teams of a quiz by the summed score of their members.
+// Participants without a team play solo and are not counted towards any team.
+func (s *teamLeaderboardServiceImpl) GetTeamLeaderboard(ctx context.Context, quizID uuid.UUID) ([]dto.TeamLeaderboardEntry, error) {
+	teams, err := s.teamRepo.GetTeamsByQuizID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	participants, err := s.participantRepo.GetParticipantsByQuizID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	return aggregateTeamScores(teams, participants), nil
+}
+
+// aggregateTeamScores sums participant scores per team and ranks the teams by total score
+func aggregateTeamScores(teams []*model.Team, participants []*model.Participant) []dto.TeamLeaderboardEntry {
+	entries := make([]dto.TeamLeaderboardEntry, 0, len(teams))
+	index := make(map[uuid.UUID]int, len(teams))
+	for _, team := range teams {
+		index[team.ID] = len(entries)
+		entries = append(entries, dto.TeamLeaderboardEntry{
+			TeamID: team.ID,
+			Name:   team.Name,
+		})
+	}
+
+	for _, p := range participants {
+		if p.TeamID == nil {
+			continue
+		}
+		i, ok := index[*p.TeamID]
+		if !ok {
+			continue
+		}
+		entries[i].TotalScore += p.Score
+		entries[i].MemberCount++
+	}
+
+	for i := range entries {
+		if entries[i].MemberCount > 0 {
+			entries[i].AverageScore = float64(entries[i].TotalScore) / float64(entries[i].MemberCount)
+		}
+	}
+
+	sort.SliceStable(entries, func(a, b int) bool {
+		return entries[a].TotalScore > entries[b].TotalScore
+	})
+
+	// Teams tied on total score share a rank and the next rank skips, like the participant leaderboard
+	for i := range entries {
+		if i > 0 && entries[i].TotalScore == entries[i-1].TotalScore {
+			entries[i].Rank = entries[i-1].Rank
+		} else {
+			entries[i].Rank = i + 1
+		}
+	}
+
+	return entries
+}
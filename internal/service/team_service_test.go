@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+func TestAggregateTeamScoresSharesRanksBetweenTiedTeams(t *testing.T) {
+	quizID := uuid.New()
+	red := model.NewTeam("Red", quizID)
+	blue := model.NewTeam("Blue", quizID)
+	green := model.NewTeam("Green", quizID)
+	gold := model.NewTeam("Gold", quizID)
+
+	member := func(team *model.Team, score int) *model.Participant {
+		p := model.NewParticipant(team.Name+" member", quizID)
+		p.TeamID = &team.ID
+		p.Score = score
+		return p
+	}
+	participants := []*model.Participant{
+		member(red, 300),
+		member(blue, 100), member(blue, 200),
+		member(green, 150),
+		member(gold, 50), member(gold, 100),
+	}
+
+	entries := aggregateTeamScores([]*model.Team{red, blue, green, gold}, participants)
+
+	want := []struct {
+		name string
+		rank int
+	}{
+		{"Red", 1}, {"Blue", 1}, {"Green", 3}, {"Gold", 3},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, w := range want {
+		if entries[i].Name != w.name || entries[i].Rank != w.rank {
+			t.Errorf("entry %d = %s ranked %d, want %s ranked %d", i, entries[i].Name, entries[i].Rank, w.name, w.rank)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
@@ -11,6 +12,11 @@ import (
 	"github.com/google/uuid"
 )
 
+// Errors
+var (
+	ErrEmailTaken = errors.New("email already in use")
+)
+
 // userServiceImpl implements UserService interface
 type userServiceImpl struct {
 	userRepo   repository.UserRepository
@@ -27,6 +33,9 @@ func NewUserService(userRepo repository.UserRepository, jwtManager *auth.JWTMana
 
 // Register creates a new user account
 func (s *userServiceImpl) Register(ctx context.Context, name string, email string, password string) (*model.User, error) {
+	// Emails are compared case-insensitively, so store them in one canonical form
+	email = strings.ToLower(strings.TrimSpace(email))
+
 	// Validate inputs
 	if name == "" {
 		return nil, errors.New("name is required")
@@ -41,7 +50,7 @@ func (s *userServiceImpl) Register(ctx context.Context, name string, email strin
 	// Check if email is already registered
 	existingUser, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err == nil && existingUser != nil {
-		return nil, errors.New("email is already registered")
+		return nil, ErrEmailTaken
 	}
 
 	// Create new user
@@ -50,8 +59,11 @@ func (s *userServiceImpl) Register(ctx context.Context, name string, email strin
 		return nil, errors.New("failed to create user: " + err.Error())
 	}
 
-	// Save to repository
+	// Save to repository; a concurrent registration may have claimed the email since the check above
 	if err := s.userRepo.CreateUser(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrEmailTaken) {
+			return nil, ErrEmailTaken
+		}
 		return nil, err
 	}
 
@@ -107,6 +119,22 @@ func (s *userServiceImpl) LoginWithToken(ctx context.Context, email string, pass
 	return response, nil
 }
 
+// IssueGuestToken returns a guest host, stored along with their quiz, with a short-lived access token.
+// No refresh token is issued, so the guest identity ends when the token expires.
+func (s *userServiceImpl) IssueGuestToken(user *model.User) (*dto.UserLoginResponse, error) {
+	accessToken, err := s.jwtManager.GenerateGuestToken(user.ID, user.Email)
+	if err != nil {
+		return nil, errors.New("failed to generate access token")
+	}
+
+	return &dto.UserLoginResponse{
+		User:        dto.UserResponseFromModel(user),
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.jwtManager.GetConfig().GuestExpTime.Seconds()),
+	}, nil
+}
+
 // GetUserByID retrieves a user by ID
 func (s *userServiceImpl) GetUserByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
 	user, err := s.userRepo.GetUserByID(ctx, id)
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+)
+
+// racingUserRepo misses existing emails on lookup, as a registration racing another one would
+type racingUserRepo struct{ fakeUserRepo }
+
+func (r *racingUserRepo) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	return nil, errors.New("user not found")
+}
+
+func TestRegisterRejectsADuplicateEmail(t *testing.T) {
+	users := NewUserService(&fakeUserRepo{newFakeStore()}, nil)
+	ctx := context.Background()
+
+	user, err := users.Register(ctx, "Ann", "ann@example.com", "secret-1")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if user.PasswordHash == "" || user.PasswordHash == "secret-1" || !user.ComparePassword("secret-1") {
+		t.Error("password was not stored as a matching hash")
+	}
+
+	for _, email := range []string{"ann@example.com", " ANN@Example.com "} {
+		if _, err := users.Register(ctx, "Another Ann", email, "secret-2"); !errors.Is(err, ErrEmailTaken) {
+			t.Errorf("registering %q again: got %v, want ErrEmailTaken", email, err)
+		}
+	}
+}
+
+func TestRegisterRejectsADuplicateEmailRacingTheLookup(t *testing.T) {
+	store := newFakeStore()
+	users := NewUserService(&racingUserRepo{fakeUserRepo{store}}, nil)
+	ctx := context.Background()
+
+	if _, err := users.Register(ctx, "Ann", "ann@example.com", "secret-1"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	_, err := users.Register(ctx, "Another Ann", "ann@example.com", "secret-2")
+	if !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("duplicate insert: got %v, want ErrEmailTaken", err)
+	}
+	if len(store.users) != 1 {
+		t.Errorf("%d users stored, want 1", len(store.users))
+	}
+}
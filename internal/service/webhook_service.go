@@ -0,0 +1,293 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/repository"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/logger"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
+	"github.com/google/uuid"
+)
+
+// Error definitions for the webhook services
+var (
+	ErrWebhookNotFound           = errors.New("webhook not found")
+	ErrInvalidWebhookURL         = errors.New("webhook URL must be an absolute http or https URL")
+	ErrWebhookDestinationBlocked = errors.New("webhook URL must not point to a loopback, private or link-local address")
+)
+
+// WebhookSignatureHeader carries the hex HMAC-SHA256 of the delivery body keyed with the webhook secret
+const WebhookSignatureHeader = "X-Webhook-Signature"
+
+// webhookEvents lists the lifecycle events delivered to webhooks
+var webhookEvents = map[string]bool{
+	string(websocket.EventQuizStart):   true,
+	string(websocket.EventQuizEnd):     true,
+	string(websocket.EventQuestionEnd): true,
+}
+
+// webhookRetryDelay is the wait before the second delivery attempt; it doubles after each failure
+const webhookRetryDelay = time.Second
+
+// blockedWebhookPrefixes are ranges netip does not classify as private that webhooks may not reach either:
+// "this network" and carrier-grade NAT
+var blockedWebhookPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// isPublicAddr reports whether addr is a public unicast address a webhook may be delivered to
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	// IsGlobalUnicast already excludes loopback, link-local, multicast and unspecified addresses
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range blockedWebhookPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// webhookDialControl refuses connections to non-public addresses. It runs after DNS resolution, so a
+// public host name resolving into the server's network, or a redirect to one, is refused too
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !isPublicAddr(addr) {
+		return fmt.Errorf("%w: %s", ErrWebhookDestinationBlocked, host)
+	}
+	return nil
+}
+
+// isBlockedWebhookHost reports whether a webhook URL names a non-public host outright, so it can be
+// rejected when it is saved rather than on every delivery
+func isBlockedWebhookHost(webhookURL string) bool {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && !isPublicAddr(addr)
+}
+
+// webhookServiceImpl implements WebhookService interface
+type webhookServiceImpl struct {
+	webhookRepo          repository.WebhookRepository
+	quizRepo             repository.QuizRepository
+	allowPrivateNetworks bool
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(
+	webhookRepo repository.WebhookRepository,
+	quizRepo repository.QuizRepository,
+	cfg config.WebhookConfig,
+) WebhookService {
+	return &webhookServiceImpl{
+		webhookRepo:          webhookRepo,
+		quizRepo:             quizRepo,
+		allowPrivateNetworks: cfg.AllowPrivateNetworks,
+	}
+}
+
+// SetQuizWebhook registers the endpoint notified of a quiz's lifecycle events, replacing any previous one
+func (s *webhookServiceImpl) SetQuizWebhook(ctx context.Context, quizID uuid.UUID, webhookURL string, secret string) (*model.QuizWebhook, error) {
+	if !isHTTPURL(webhookURL) {
+		return nil, ErrInvalidWebhookURL
+	}
+	if !s.allowPrivateNetworks && isBlockedWebhookHost(webhookURL) {
+		return nil, ErrWebhookDestinationBlocked
+	}
+
+	if _, err := s.quizRepo.GetQuizByID(ctx, quizID); err != nil {
+		return nil, ErrQuizNotFound
+	}
+
+	webhook := model.NewQuizWebhook(quizID, webhookURL, secret)
+	if err := s.webhookRepo.SaveQuizWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// GetQuizWebhook retrieves the webhook registered for a quiz
+func (s *webhookServiceImpl) GetQuizWebhook(ctx context.Context, quizID uuid.UUID) (*model.QuizWebhook, error) {
+	webhook, err := s.webhookRepo.GetQuizWebhook(ctx, quizID)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	return webhook, nil
+}
+
+// DeleteQuizWebhook stops notifying a quiz's webhook
+func (s *webhookServiceImpl) DeleteQuizWebhook(ctx context.Context, quizID uuid.UUID) error {
+	if err := s.webhookRepo.DeleteQuizWebhook(ctx, quizID); err != nil {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// webhookDelivery is the JSON body POSTed to a webhook
+type webhookDelivery struct {
+	Event     string      `json:"event"`
+	QuizID    uuid.UUID   `json:"quizId"`
+	Timestamp time.Time   `json:"timestamp"`
+	Payload   interface{} `json:"payload"`
+}
+
+// webhookNotifierImpl implements WebhookNotifier by POSTing signed deliveries in the background
+type webhookNotifierImpl struct {
+	webhookRepo repository.WebhookRepository
+	client      *http.Client
+	maxAttempts int
+	retryDelay  time.Duration
+	logger      *slog.Logger
+}
+
+// NewWebhookNotifier creates a webhook notifier, or one that does nothing when webhooks are disabled
+func NewWebhookNotifier(
+	webhookRepo repository.WebhookRepository,
+	cfg config.WebhookConfig,
+	log *slog.Logger,
+) WebhookNotifier {
+	if !cfg.Enabled {
+		return noopWebhookNotifier{}
+	}
+
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	client := &http.Client{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateNetworks {
+		dialer := &net.Dialer{Timeout: cfg.Timeout, Control: webhookDialControl}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		// A proxy would make the real connection and get around the address check
+		transport.Proxy = nil
+		transport.DialContext = dialer.DialContext
+		client.Transport = transport
+	}
+
+	return &webhookNotifierImpl{
+		webhookRepo: webhookRepo,
+		client:      client,
+		maxAttempts: maxAttempts,
+		retryDelay:  webhookRetryDelay,
+		logger:      logger.OrDefault(log),
+	}
+}
+
+// Notify delivers lifecycle events to the quiz's webhook without waiting for the delivery
+func (n *webhookNotifierImpl) Notify(quizID uuid.UUID, eventType string, payload interface{}) {
+	if !webhookEvents[eventType] {
+		return
+	}
+
+	body, err := json.Marshal(webhookDelivery{
+		Event:     eventType,
+		QuizID:    quizID,
+		Timestamp: time.Now(),
+		Payload:   payload,
+	})
+	if err != nil {
+		n.logger.Error("Failed to encode webhook delivery", "quizId", quizID, "event", eventType, "error", err)
+		return
+	}
+
+	go n.deliver(quizID, eventType, body)
+}
+
+// deliver POSTs a delivery to the quiz's webhook, retrying with a growing delay until maxAttempts is reached
+func (n *webhookNotifierImpl) deliver(quizID uuid.UUID, eventType string, body []byte) {
+	// The request that triggered the event may already be finished
+	ctx := context.Background()
+
+	webhook, err := n.webhookRepo.GetQuizWebhook(ctx, quizID)
+	if err != nil {
+		// Most quizzes have no webhook
+		return
+	}
+
+	delay := n.retryDelay
+	for attempt := 1; attempt <= n.maxAttempts; attempt++ {
+		err = n.post(ctx, webhook, eventType, body)
+		if err == nil {
+			return
+		}
+
+		if attempt < n.maxAttempts {
+			n.logger.Warn("Webhook delivery failed, retrying", "quizId", quizID, "event", eventType, "attempt", attempt, "error", err)
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+
+	n.logger.Error("Webhook delivery dropped", "quizId", quizID, "event", eventType, "attempts", n.maxAttempts, "error", err)
+}
+
+// post sends a single signed delivery attempt, failing on any non-2xx response
+func (n *webhookNotifierImpl) post(ctx context.Context, webhook *model.QuizWebhook, eventType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", eventType)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+signWebhookBody(webhook.Secret, body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// signWebhookBody returns the hex HMAC-SHA256 of body keyed with secret
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// noopWebhookNotifier is the WebhookNotifier used when webhooks are disabled
+type noopWebhookNotifier struct{}
+
+// Notify does nothing
+func (noopWebhookNotifier) Notify(quizID uuid.UUID, eventType string, payload interface{}) {}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/config"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/websocket"
+	"github.com/google/uuid"
+)
+
+// notified returns the events the fake notifier was asked to deliver
+func (n *fakeWebhookNotifier) notified() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.events...)
+}
+
+// webhookReceiver is a webhook endpoint answering with the given statuses in turn, then 200
+type webhookReceiver struct {
+	mu         sync.Mutex
+	statuses   []int
+	deliveries []*http.Request
+	bodies     [][]byte
+	received   chan struct{}
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) (*webhookReceiver, string) {
+	t.Helper()
+
+	receiver := &webhookReceiver{statuses: statuses, received: make(chan struct{}, 10)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		receiver.mu.Lock()
+		receiver.deliveries = append(receiver.deliveries, r)
+		receiver.bodies = append(receiver.bodies, body)
+		status := http.StatusOK
+		if len(receiver.statuses) > 0 {
+			status, receiver.statuses = receiver.statuses[0], receiver.statuses[1:]
+		}
+		receiver.mu.Unlock()
+
+		w.WriteHeader(status)
+		receiver.received <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+
+	return receiver, server.URL
+}
+
+// wait waits for n more delivery attempts
+func (r *webhookReceiver) wait(t *testing.T, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-r.received:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d of %d webhook deliveries", i, n)
+		}
+	}
+}
+
+// newTestNotifier creates a webhook notifier for a quiz whose webhook points at url; private networks
+// are allowed because the test receivers listen on loopback
+func newTestNotifier(t *testing.T, url string, maxAttempts int) (WebhookNotifier, uuid.UUID) {
+	t.Helper()
+
+	store := newFakeStore()
+	quizID := uuid.New()
+	repo := &fakeWebhookRepo{store}
+	if err := repo.SaveQuizWebhook(context.Background(), model.NewQuizWebhook(quizID, url, "s3cret")); err != nil {
+		t.Fatalf("save webhook: %v", err)
+	}
+	notifier := NewWebhookNotifier(repo, config.WebhookConfig{Enabled: true, Timeout: time.Second, MaxAttempts: maxAttempts, AllowPrivateNetworks: true}, nil)
+	return notifier, quizID
+}
+
+func TestQuizLifecycleNotifiesTheWebhookNotifier(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{ManualAdvance: true})
+	question := env.seedQuestion(t, quiz, 1)
+
+	if err := env.state.StartQuiz(ctx, quiz.ID); err != nil {
+		t.Fatalf("StartQuiz: %v", err)
+	}
+	env.showQuestion(t, question)
+	if err := env.state.EndQuiz(ctx, quiz.ID); err != nil {
+		t.Fatalf("EndQuiz: %v", err)
+	}
+
+	want := []string{string(websocket.EventQuizStart), string(websocket.EventQuestionEnd), string(websocket.EventQuizEnd)}
+	got := map[string]bool{}
+	for _, event := range env.webhooks.notified() {
+		got[event] = true
+	}
+	for _, event := range want {
+		if !got[event] {
+			t.Errorf("notifier was not told about %s; it got %v", event, env.webhooks.notified())
+		}
+	}
+}
+
+func TestWebhookNotifierSignsLifecycleDeliveries(t *testing.T) {
+	receiver, url := newWebhookReceiver(t)
+	notifier, quizID := newTestNotifier(t, url, 1)
+
+	notifier.Notify(quizID, string(websocket.EventTimerUpdate), map[string]interface{}{})
+	notifier.Notify(quizID, string(websocket.EventQuizEnd), map[string]interface{}{"quizId": quizID.String()})
+	receiver.wait(t, 1)
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	if len(receiver.deliveries) != 1 {
+		t.Fatalf("got %d deliveries, want only the lifecycle event", len(receiver.deliveries))
+	}
+	delivery, body := receiver.deliveries[0], receiver.bodies[0]
+	if got := delivery.Header.Get("X-Webhook-Event"); got != string(websocket.EventQuizEnd) {
+		t.Errorf("delivery is for event %q, want QUIZ_END", got)
+	}
+	if got, want := delivery.Header.Get(WebhookSignatureHeader), "sha256="+signWebhookBody("s3cret", body); got != want {
+		t.Errorf("signature header is %q, want %q", got, want)
+	}
+
+	var decoded webhookDelivery
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("decode delivery: %v", err)
+	}
+	if decoded.Event != string(websocket.EventQuizEnd) || decoded.QuizID != quizID {
+		t.Errorf("delivery body is %+v", decoded)
+	}
+}
+
+func TestWebhookNotifierRetriesFailedDeliveries(t *testing.T) {
+	receiver, url := newWebhookReceiver(t, http.StatusInternalServerError)
+	notifier, quizID := newTestNotifier(t, url, 3)
+	notifier.(*webhookNotifierImpl).retryDelay = 10 * time.Millisecond
+
+	notifier.Notify(quizID, string(websocket.EventQuizStart), nil)
+	receiver.wait(t, 2)
+
+	// The second attempt succeeded, so there is no third
+	select {
+	case <-receiver.received:
+		t.Error("delivery was retried after it succeeded")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDisabledWebhookNotifierDeliversNothing(t *testing.T) {
+	receiver, url := newWebhookReceiver(t)
+	store := newFakeStore()
+	quizID := uuid.New()
+	repo := &fakeWebhookRepo{store}
+	if err := repo.SaveQuizWebhook(context.Background(), model.NewQuizWebhook(quizID, url, "s3cret")); err != nil {
+		t.Fatalf("save webhook: %v", err)
+	}
+
+	NewWebhookNotifier(repo, config.WebhookConfig{Enabled: false}, nil).Notify(quizID, string(websocket.EventQuizStart), nil)
+
+	select {
+	case <-receiver.received:
+		t.Error("disabled notifier delivered an event")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWebhookNotifierGivesUpAfterMaxAttempts(t *testing.T) {
+	receiver, url := newWebhookReceiver(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	notifier, quizID := newTestNotifier(t, url, 2)
+	notifier.(*webhookNotifierImpl).retryDelay = 10 * time.Millisecond
+
+	notifier.Notify(quizID, string(websocket.EventQuestionEnd), nil)
+	receiver.wait(t, 2)
+
+	select {
+	case <-receiver.received:
+		t.Error("delivery was attempted more than MaxAttempts times")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWebhookNotifierRefusesPrivateDestinations(t *testing.T) {
+	receiver, url := newWebhookReceiver(t)
+	store := newFakeStore()
+	quizID := uuid.New()
+	repo := &fakeWebhookRepo{store}
+	webhook := model.NewQuizWebhook(quizID, url, "s3cret")
+	if err := repo.SaveQuizWebhook(context.Background(), webhook); err != nil {
+		t.Fatalf("save webhook: %v", err)
+	}
+	notifier := NewWebhookNotifier(repo, config.WebhookConfig{Enabled: true, Timeout: time.Second, MaxAttempts: 1}, nil)
+
+	err := notifier.(*webhookNotifierImpl).post(context.Background(), webhook, string(websocket.EventQuizStart), []byte("{}"))
+	if !errors.Is(err, ErrWebhookDestinationBlocked) {
+		t.Fatalf("post to a loopback receiver returned %v, want ErrWebhookDestinationBlocked", err)
+	}
+	select {
+	case <-receiver.received:
+		t.Error("the loopback receiver got a delivery")
+	default:
+	}
+}
+
+func TestWebhookDialControlAllowsOnlyPublicAddresses(t *testing.T) {
+	blocked := []string{
+		"127.0.0.1:80", "10.1.2.3:443", "172.16.0.1:80", "192.168.1.1:80", "169.254.169.254:80",
+		"0.0.0.0:80", "100.64.0.1:80", "224.0.0.1:80", "[::1]:80", "[fe80::1]:80", "[fd00::1]:80",
+		"[::ffff:127.0.0.1]:80", "[::ffff:169.254.169.254]:80",
+	}
+	for _, address := range blocked {
+		if err := webhookDialControl("tcp", address, nil); !errors.Is(err, ErrWebhookDestinationBlocked) {
+			t.Errorf("dialing %s returned %v, want ErrWebhookDestinationBlocked", address, err)
+		}
+	}
+
+	for _, address := range []string{"93.184.216.34:443", "[2606:4700::1111]:443"} {
+		if err := webhookDialControl("tcp", address, nil); err != nil {
+			t.Errorf("dialing %s returned %v, want it allowed", address, err)
+		}
+	}
+}
+
+func TestSetQuizWebhookRejectsPrivateHosts(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	quiz := env.seedQuiz(t, model.QuizStatusWaiting, model.QuizSettings{})
+	webhooks := NewWebhookService(&fakeWebhookRepo{env.store}, env.quizRepo, config.WebhookConfig{Enabled: true})
+
+	for _, url := range []string{
+		"http://169.254.169.254/latest/meta-data",
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://[::1]/hook",
+		"https://10.0.0.5/hook",
+	} {
+		if _, err := webhooks.SetQuizWebhook(ctx, quiz.ID, url, "0123456789abcdef"); !errors.Is(err, ErrWebhookDestinationBlocked) {
+			t.Errorf("SetQuizWebhook(%s) returned %v, want ErrWebhookDestinationBlocked", url, err)
+		}
+	}
+
+	if _, err := webhooks.SetQuizWebhook(ctx, quiz.ID, "https://hooks.example.com/quiz", "0123456789abcdef"); err != nil {
+		t.Errorf("SetQuizWebhook with a public host: %v", err)
+	}
+
+	allowing := NewWebhookService(&fakeWebhookRepo{env.store}, env.quizRepo, config.WebhookConfig{Enabled: true, AllowPrivateNetworks: true})
+	if _, err := allowing.SetQuizWebhook(ctx, quiz.ID, "http://10.0.0.5/hook", "0123456789abcdef"); err != nil {
+		t.Errorf("SetQuizWebhook with private networks allowed: %v", err)
+	}
+}
@@ -0,0 +1,3 @@
+-- Remove disconnect reason from participant connections
+ALTER TABLE participant_connections
+DROP COLUMN IF EXISTS disconnect_reason;
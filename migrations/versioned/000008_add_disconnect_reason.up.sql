@@ -0,0 +1,3 @@
+-- Record why a participant's connection ended
+ALTER TABLE participant_connections
+ADD COLUMN disconnect_reason VARCHAR(20) NULL;
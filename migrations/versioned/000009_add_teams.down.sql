@@ -0,0 +1,5 @@
+-- Remove team assignment from participants
+ALTER TABLE participants DROP COLUMN IF EXISTS team_id;
+
+-- Drop teams table
+DROP TABLE IF EXISTS teams;
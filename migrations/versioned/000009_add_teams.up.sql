@@ -0,0 +1,13 @@
+-- Create teams table for team mode
+CREATE TABLE IF NOT EXISTS teams (
+    id UUID PRIMARY KEY,
+    quiz_id UUID NOT NULL REFERENCES quizzes(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (quiz_id, name)
+);
+CREATE INDEX idx_teams_quiz_id ON teams(quiz_id);
+
+-- Allow participants to be assigned to a team
+ALTER TABLE participants
+ADD COLUMN team_id UUID NULL REFERENCES teams(id) ON DELETE SET NULL;
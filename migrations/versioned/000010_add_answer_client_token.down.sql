@@ -0,0 +1,3 @@
+-- Remove the idempotency token from answers
+DROP INDEX IF EXISTS idx_answers_client_token;
+ALTER TABLE answers DROP COLUMN IF EXISTS client_token;
@@ -0,0 +1,7 @@
+-- Store the client-supplied token used to make answer submissions idempotent
+ALTER TABLE answers
+ADD COLUMN client_token VARCHAR(100) NULL;
+
+-- A token identifies at most one answer of a participant to a question
+CREATE UNIQUE INDEX idx_answers_client_token ON answers(participant_id, question_id, client_token)
+WHERE client_token IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Remove per-quiz settings
+ALTER TABLE quizzes DROP COLUMN IF EXISTS settings;
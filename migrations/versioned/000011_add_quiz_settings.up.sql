@@ -0,0 +1,3 @@
+-- Store per-quiz settings a creator can change before and during a quiz
+ALTER TABLE quizzes
+ADD COLUMN settings JSONB NOT NULL DEFAULT '{}'::jsonb;
@@ -0,0 +1,2 @@
+-- Remove participant bans
+DROP TABLE IF EXISTS quiz_bans;
@@ -0,0 +1,9 @@
+-- Remember participants a creator banned so they cannot rejoin the quiz
+CREATE TABLE IF NOT EXISTS quiz_bans (
+    quiz_id UUID NOT NULL REFERENCES quizzes(id) ON DELETE CASCADE,
+    participant_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    banned_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (quiz_id, participant_id)
+);
+CREATE INDEX idx_quiz_bans_quiz_name ON quiz_bans(quiz_id, name);
@@ -0,0 +1,2 @@
+-- Remove question time extensions
+ALTER TABLE quiz_sessions DROP COLUMN IF EXISTS current_question_extra_seconds;
@@ -0,0 +1,3 @@
+-- Track seconds the creator added to the running question on top of its time limit
+ALTER TABLE quiz_sessions
+ADD COLUMN current_question_extra_seconds INTEGER NOT NULL DEFAULT 0;
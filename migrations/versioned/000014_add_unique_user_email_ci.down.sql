@@ -0,0 +1,2 @@
+-- Remove the case-insensitive email uniqueness index
+DROP INDEX IF EXISTS idx_users_email_lower;
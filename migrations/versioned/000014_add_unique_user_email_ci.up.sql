@@ -0,0 +1,26 @@
+-- Treat emails that differ only in case as the same account; registrations are
+-- normalized to lower case, so existing mixed-case rows are lowered first.
+-- Lowering would collide with the existing email UNIQUE constraint when two
+-- accounts differ only in case, so refuse to run until those are resolved.
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(lowered, ', ')
+    INTO duplicates
+    FROM (
+        SELECT LOWER(email) AS lowered
+        FROM users
+        GROUP BY LOWER(email)
+        HAVING COUNT(*) > 1
+    ) AS dup;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'users share an email that differs only in case: %', duplicates
+            USING HINT = 'Merge or rename these accounts so each email is unique ignoring case, then rerun the migration.';
+    END IF;
+END
+$$;
+
+UPDATE users SET email = LOWER(email) WHERE email <> LOWER(email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (LOWER(email));
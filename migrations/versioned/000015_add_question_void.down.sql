@@ -0,0 +1,2 @@
+-- Remove question voiding
+ALTER TABLE questions DROP COLUMN IF EXISTS voided_points;
@@ -0,0 +1,3 @@
+-- Points every participant is credited for a voided question; NULL while the question counts normally
+ALTER TABLE questions
+ADD COLUMN voided_points INTEGER NULL;
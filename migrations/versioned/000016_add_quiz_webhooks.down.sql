@@ -0,0 +1,2 @@
+-- Remove quiz webhooks
+DROP TABLE IF EXISTS quiz_webhooks;
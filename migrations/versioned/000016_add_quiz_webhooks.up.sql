@@ -0,0 +1,8 @@
+-- Webhook a creator registered to be notified of a quiz's lifecycle events
+CREATE TABLE IF NOT EXISTS quiz_webhooks (
+    quiz_id UUID PRIMARY KEY REFERENCES quizzes(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
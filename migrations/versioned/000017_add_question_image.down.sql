@@ -0,0 +1,2 @@
+-- Remove question images
+ALTER TABLE questions DROP COLUMN IF EXISTS image_url;
@@ -0,0 +1,3 @@
+-- Optional image shown with a question; empty when there is none
+ALTER TABLE questions
+ADD COLUMN image_url TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Remove the leaderboard freeze
+ALTER TABLE quiz_sessions
+DROP COLUMN IF EXISTS frozen_leaderboard,
+DROP COLUMN IF EXISTS leaderboard_frozen;
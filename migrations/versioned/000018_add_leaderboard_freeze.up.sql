@@ -0,0 +1,4 @@
+-- Leaderboard broadcasts are held back while frozen; the snapshot holds the standings at freeze time
+ALTER TABLE quiz_sessions
+ADD COLUMN leaderboard_frozen BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN frozen_leaderboard JSONB;
@@ -0,0 +1,3 @@
+-- Guest hosts are created without registering and cannot log in
+ALTER TABLE users
+ADD COLUMN is_guest BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+-- Remove question explanations
+ALTER TABLE questions DROP COLUMN IF EXISTS explanation;
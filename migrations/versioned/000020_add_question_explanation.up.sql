@@ -0,0 +1,3 @@
+-- Optional explanation of the correct answer, shown once a question ends; empty when there is none
+ALTER TABLE questions
+ADD COLUMN explanation TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Remove required questions
+ALTER TABLE questions DROP COLUMN IF EXISTS is_required;
@@ -0,0 +1,3 @@
+-- Whether the host expects every participant to answer a question, as on survey questions
+ALTER TABLE questions
+ADD COLUMN is_required BOOLEAN NOT NULL DEFAULT FALSE;
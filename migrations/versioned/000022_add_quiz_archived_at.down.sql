@@ -0,0 +1,2 @@
+-- Remove quiz archiving
+ALTER TABLE quizzes DROP COLUMN IF EXISTS archived_at;
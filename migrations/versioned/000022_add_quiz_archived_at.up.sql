@@ -0,0 +1,3 @@
+-- When a creator archived a quiz; archived quizzes are hidden from their quiz list but keep their results
+ALTER TABLE quizzes
+ADD COLUMN archived_at TIMESTAMP NULL;
@@ -0,0 +1,3 @@
+-- Restore the token index
+CREATE UNIQUE INDEX IF NOT EXISTS idx_answers_client_token ON answers(participant_id, question_id, client_token)
+WHERE client_token IS NOT NULL;
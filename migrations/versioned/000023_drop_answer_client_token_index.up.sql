@@ -0,0 +1,3 @@
+-- UNIQUE(participant_id, question_id) already allows one answer, and so one token, per participant and question,
+-- and its index serves token lookups too
+DROP INDEX IF EXISTS idx_answers_client_token;
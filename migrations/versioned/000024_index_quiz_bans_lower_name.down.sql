@@ -0,0 +1,3 @@
+-- Restore the exact-name ban index
+DROP INDEX IF EXISTS idx_quiz_bans_quiz_lower_name;
+CREATE INDEX IF NOT EXISTS idx_quiz_bans_quiz_name ON quiz_bans(quiz_id, name);
@@ -0,0 +1,3 @@
+-- Bans are matched ignoring case, so index the lower-cased name instead
+DROP INDEX IF EXISTS idx_quiz_bans_quiz_name;
+CREATE INDEX IF NOT EXISTS idx_quiz_bans_quiz_lower_name ON quiz_bans(quiz_id, LOWER(name));
@@ -29,6 +29,39 @@ type RefreshClaims struct {
 	jwt.RegisteredClaims
 }
 
+// IsGuest reports whether the token belongs to a guest host rather than a registered user
+func (c *Claims) IsGuest() bool {
+	for _, audience := range c.Audience {
+		if audience == guestAudience {
+			return true
+		}
+	}
+	return false
+}
+
+// ParticipantClaims defines the claims of the token a participant receives when joining a quiz
+type ParticipantClaims struct {
+	ParticipantID uuid.UUID `json:"participant_id"`
+	QuizID        uuid.UUID `json:"quiz_id"`
+	jwt.RegisteredClaims
+}
+
+// SpectatorClaims defines the claims of the token a spectator display uses to watch a quiz
+type SpectatorClaims struct {
+	SpectatorID uuid.UUID `json:"spectator_id"`
+	QuizID      uuid.UUID `json:"quiz_id"`
+	jwt.RegisteredClaims
+}
+
+// participantAudience marks participant tokens so they are never accepted as user tokens and vice versa
+const participantAudience = "participant"
+
+// spectatorAudience marks spectator tokens so they are never accepted as user or participant tokens
+const spectatorAudience = "spectator"
+
+// guestAudience marks the user tokens of guest hosts, which may only run the quiz they were created with
+const guestAudience = "guest"
+
 // JWTManager handles JWT token generation and validation
 type JWTManager struct {
 	config config.JWTConfig
@@ -48,8 +81,18 @@ func (m *JWTManager) GetConfig() config.JWTConfig {
 
 // GenerateToken generates a new JWT token for a user
 func (m *JWTManager) GenerateToken(userID uuid.UUID, email string) (string, error) {
+	return m.generateAccessToken(userID, email, m.config.ExpirationTime, nil)
+}
+
+// GenerateGuestToken generates the short-lived token of a guest quiz host, marked with the guest audience
+func (m *JWTManager) GenerateGuestToken(userID uuid.UUID, email string) (string, error) {
+	return m.generateAccessToken(userID, email, m.config.GuestExpTime, jwt.ClaimStrings{guestAudience})
+}
+
+// generateAccessToken signs user claims valid for the given duration
+func (m *JWTManager) generateAccessToken(userID uuid.UUID, email string, validFor time.Duration, audience jwt.ClaimStrings) (string, error) {
 	now := time.Now()
-	expiresAt := now.Add(m.config.ExpirationTime)
+	expiresAt := now.Add(validFor)
 
 	claims := Claims{
 		UserID: userID,
@@ -60,6 +103,7 @@ func (m *JWTManager) GenerateToken(userID uuid.UUID, email string) (string, erro
 			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    m.config.Issuer,
 			Subject:   userID.String(),
+			Audience:  audience,
 		},
 	}
 
@@ -115,6 +159,13 @@ func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
+	// Participant and spectator tokens are signed with the same secret but do not identify a user
+	for _, audience := range claims.Audience {
+		if audience == participantAudience || audience == spectatorAudience {
+			return nil, ErrInvalidToken
+		}
+	}
+
 	return claims, nil
 }
 
@@ -148,3 +199,112 @@ func (m *JWTManager) ValidateRefreshToken(tokenString string) (*RefreshClaims, e
 
 	return claims, nil
 }
+
+// GenerateParticipantToken generates a token identifying a participant of a quiz
+func (m *JWTManager) GenerateParticipantToken(participantID uuid.UUID, quizID uuid.UUID) (string, error) {
+	now := time.Now()
+	expiresAt := now.Add(m.config.ParticipantExpTime)
+
+	claims := ParticipantClaims{
+		ParticipantID: participantID,
+		QuizID:        quizID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    m.config.Issuer,
+			Subject:   participantID.String(),
+			Audience:  jwt.ClaimStrings{participantAudience},
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(m.config.Secret))
+}
+
+// ValidateParticipantToken validates a participant token and returns its claims
+func (m *JWTManager) ValidateParticipantToken(tokenString string) (*ParticipantClaims, error) {
+	// Parse the token
+	token, err := jwt.ParseWithClaims(
+		tokenString,
+		&ParticipantClaims{},
+		func(token *jwt.Token) (interface{}, error) {
+			// Validate signing method
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return []byte(m.config.Secret), nil
+		},
+		jwt.WithAudience(participantAudience),
+	)
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	// Verify and get the claims
+	claims, ok := token.Claims.(*ParticipantClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// GenerateSpectatorToken generates a token letting a spectator display watch a quiz.
+// It lives as long as a participant token.
+func (m *JWTManager) GenerateSpectatorToken(spectatorID uuid.UUID, quizID uuid.UUID) (string, error) {
+	now := time.Now()
+	expiresAt := now.Add(m.config.ParticipantExpTime)
+
+	claims := SpectatorClaims{
+		SpectatorID: spectatorID,
+		QuizID:      quizID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    m.config.Issuer,
+			Subject:   spectatorID.String(),
+			Audience:  jwt.ClaimStrings{spectatorAudience},
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(m.config.Secret))
+}
+
+// ValidateSpectatorToken validates a spectator token and returns its claims
+func (m *JWTManager) ValidateSpectatorToken(tokenString string) (*SpectatorClaims, error) {
+	// Parse the token
+	token, err := jwt.ParseWithClaims(
+		tokenString,
+		&SpectatorClaims{},
+		func(token *jwt.Token) (interface{}, error) {
+			// Validate signing method
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return []byte(m.config.Secret), nil
+		},
+		jwt.WithAudience(spectatorAudience),
+	)
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	// Verify and get the claims
+	claims, ok := token.Claims.(*SpectatorClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
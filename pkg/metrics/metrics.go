@@ -0,0 +1,107 @@
+// Package metrics defines the Prometheus metrics exposed by the application
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "quiz"
+
+// Answer results used as label values of AnswersSubmitted
+const (
+	ResultCorrect   = "correct"
+	ResultIncorrect = "incorrect"
+)
+
+var (
+	// QuizzesStarted counts quizzes that were started
+	QuizzesStarted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quizzes_started_total",
+		Help:      "Number of quizzes started.",
+	})
+
+	// QuestionsStarted counts questions that were shown to participants
+	QuestionsStarted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "questions_started_total",
+		Help:      "Number of questions started.",
+	})
+
+	// AnswersSubmitted counts recorded answers by result
+	AnswersSubmitted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "answers_submitted_total",
+		Help:      "Number of answers recorded, by result.",
+	}, []string{"result"})
+
+	// AnswerPoints observes the points awarded to each recorded answer, including any time bonus
+	AnswerPoints = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "answer_points",
+		Help:      "Points awarded to recorded answers.",
+		Buckets:   prometheus.LinearBuckets(0, 20, 7),
+	})
+
+	// TimeBonusesAwarded counts correct answers that earned the time bonus
+	TimeBonusesAwarded = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "time_bonuses_awarded_total",
+		Help:      "Number of answers awarded the time bonus.",
+	})
+
+	// AnswerSubmissionDuration observes how long answer submissions take to process
+	AnswerSubmissionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "answer_submission_duration_seconds",
+		Help:      "Time taken to process an answer submission.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	// ActiveConnections tracks the WebSocket clients registered with this instance's hub, by role
+	ActiveConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "websocket_connections_active",
+		Help:      "Number of WebSocket clients registered with the hub, by role.",
+	}, []string{"role"})
+)
+
+// Registry holds every application metric along with the Go runtime and process collectors
+var Registry = NewRegistry()
+
+// NewRegistry creates a registry with all application metrics registered
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		QuizzesStarted,
+		QuestionsStarted,
+		AnswersSubmitted,
+		AnswerPoints,
+		TimeBonusesAwarded,
+		AnswerSubmissionDuration,
+		ActiveConnections,
+	)
+	return registry
+}
+
+// Handler returns an HTTP handler serving the metrics in Registry
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ConnectionRole returns the role label used for a WebSocket client
+func ConnectionRole(isCreator bool, isSpectator bool) string {
+	if isCreator {
+		return "creator"
+	}
+	if isSpectator {
+		return "spectator"
+	}
+	return "participant"
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNewRegistryRegistersApplicationMetrics(t *testing.T) {
+	registry := NewRegistry()
+
+	// Vectors are only gathered once they have a labelled child
+	AnswersSubmitted.WithLabelValues(ResultCorrect)
+	ActiveConnections.WithLabelValues(ConnectionRole(false, false))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	gathered := make(map[string]bool, len(families))
+	for _, family := range families {
+		gathered[family.GetName()] = true
+	}
+
+	for _, name := range []string{
+		"quiz_quizzes_started_total",
+		"quiz_questions_started_total",
+		"quiz_answers_submitted_total",
+		"quiz_answer_points",
+		"quiz_time_bonuses_awarded_total",
+		"quiz_answer_submission_duration_seconds",
+		"quiz_websocket_connections_active",
+		"go_goroutines",
+	} {
+		if !gathered[name] {
+			t.Errorf("registry does not gather %s", name)
+		}
+	}
+}
+
+func TestAnswersSubmittedCountsByResult(t *testing.T) {
+	correct := testutil.ToFloat64(AnswersSubmitted.WithLabelValues(ResultCorrect))
+	incorrect := testutil.ToFloat64(AnswersSubmitted.WithLabelValues(ResultIncorrect))
+
+	AnswersSubmitted.WithLabelValues(ResultCorrect).Inc()
+
+	if got := testutil.ToFloat64(AnswersSubmitted.WithLabelValues(ResultCorrect)) - correct; got != 1 {
+		t.Errorf("correct answers grew by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(AnswersSubmitted.WithLabelValues(ResultIncorrect)) - incorrect; got != 0 {
+		t.Errorf("incorrect answers grew by %v, want 0", got)
+	}
+}
+
+func TestHandlerServesMetrics(t *testing.T) {
+	QuizzesStarted.Inc()
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("metrics endpoint returned %d", recorder.Code)
+	}
+	body, _ := io.ReadAll(recorder.Body)
+	if !strings.Contains(string(body), "quiz_quizzes_started_total") {
+		t.Error("metrics endpoint does not expose quiz_quizzes_started_total")
+	}
+}
+
+func TestConnectionRole(t *testing.T) {
+	tests := []struct {
+		isCreator   bool
+		isSpectator bool
+		want        string
+	}{
+		{isCreator: true, want: "creator"},
+		{isSpectator: true, want: "spectator"},
+		{want: "participant"},
+	}
+
+	for _, tt := range tests {
+		if got := ConnectionRole(tt.isCreator, tt.isSpectator); got != tt.want {
+			t.Errorf("ConnectionRole(%v, %v) = %q, want %q", tt.isCreator, tt.isSpectator, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ackReportInterval is the minimum time between acknowledgement reports sent to creators for a quiz
+const ackReportInterval = 500 * time.Millisecond
+
+// QuestionAckPayload represents a participant's acknowledgement that a question was received
+type QuestionAckPayload struct {
+	QuestionID string `json:"questionId"`
+}
+
+// questionAcks holds the acknowledgements collected for the current question of a quiz
+type questionAcks struct {
+	questionID uuid.UUID
+	acked      map[uuid.UUID]struct{}
+	// reportPending is set while a throttled report is scheduled
+	reportPending bool
+}
+
+// AckTracker aggregates QUESTION_ACK messages per quiz for the question currently shown
+type AckTracker struct {
+	mu      sync.Mutex
+	quizzes map[uuid.UUID]*questionAcks
+}
+
+// NewAckTracker creates a new acknowledgement tracker
+func NewAckTracker() *AckTracker {
+	return &AckTracker{
+		quizzes: make(map[uuid.UUID]*questionAcks),
+	}
+}
+
+// StartQuestion resets the acknowledgements of a quiz for a newly started question
+func (t *AckTracker) StartQuestion(quizID uuid.UUID, questionID uuid.UUID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.quizzes[quizID] = &questionAcks{
+		questionID: questionID,
+		acked:      make(map[uuid.UUID]struct{}),
+	}
+}
+
+// Clear forgets the acknowledgements of a quiz
+func (t *AckTracker) Clear(quizID uuid.UUID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.quizzes, quizID)
+}
+
+// Ack records a participant's acknowledgement. Acks for any question other than the
+// current one are stale and rejected. scheduleReport is true when the caller should
+// schedule a report because none is pending yet.
+func (t *AckTracker) Ack(quizID uuid.UUID, questionID uuid.UUID, participantID uuid.UUID) (accepted bool, scheduleReport bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	acks, ok := t.quizzes[quizID]
+	if !ok || acks.questionID != questionID {
+		return false, false
+	}
+
+	if _, dup := acks.acked[participantID]; dup {
+		return true, false
+	}
+	acks.acked[participantID] = struct{}{}
+
+	if acks.reportPending {
+		return true, false
+	}
+	acks.reportPending = true
+	return true, true
+}
+
+// TakeReport returns the current question and its acknowledgement count, clearing the pending report
+func (t *AckTracker) TakeReport(quizID uuid.UUID) (questionID uuid.UUID, count int, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	acks, exists := t.quizzes[quizID]
+	if !exists {
+		return uuid.Nil, 0, false
+	}
+
+	acks.reportPending = false
+	return acks.questionID, len(acks.acked), true
+}
@@ -0,0 +1,89 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAckTrackerAggregatesAcksForTheCurrentQuestion(t *testing.T) {
+	tracker := NewAckTracker()
+	quizID := uuid.New()
+	questionID := uuid.New()
+	tracker.StartQuestion(quizID, questionID)
+
+	first, second := uuid.New(), uuid.New()
+	if accepted, schedule := tracker.Ack(quizID, questionID, first); !accepted || !schedule {
+		t.Errorf("first ack: accepted %v, schedule %v; want both", accepted, schedule)
+	}
+	// Further acks ride on the report already scheduled
+	if accepted, schedule := tracker.Ack(quizID, questionID, second); !accepted || schedule {
+		t.Errorf("second ack: accepted %v, schedule %v; want accepted only", accepted, schedule)
+	}
+	// A repeated ack is accepted but not counted twice
+	if accepted, _ := tracker.Ack(quizID, questionID, second); !accepted {
+		t.Error("repeated ack was rejected")
+	}
+
+	reported, count, ok := tracker.TakeReport(quizID)
+	if !ok || reported != questionID || count != 2 {
+		t.Errorf("report is %d acks for %v (ok %v), want 2 for the current question", count, reported, ok)
+	}
+
+	// Taking the report lets the next ack schedule another one
+	if _, schedule := tracker.Ack(quizID, questionID, uuid.New()); !schedule {
+		t.Error("ack after a report did not schedule the next one")
+	}
+	if _, count, _ := tracker.TakeReport(quizID); count != 3 {
+		t.Errorf("second report counts %d acks, want 3", count)
+	}
+}
+
+func TestAckTrackerRejectsStaleAcks(t *testing.T) {
+	tracker := NewAckTracker()
+	quizID := uuid.New()
+	previous := uuid.New()
+	current := uuid.New()
+	participantID := uuid.New()
+
+	if accepted, _ := tracker.Ack(quizID, current, participantID); accepted {
+		t.Error("ack accepted before any question started")
+	}
+
+	tracker.StartQuestion(quizID, previous)
+	tracker.Ack(quizID, previous, participantID)
+	tracker.StartQuestion(quizID, current)
+
+	if accepted, schedule := tracker.Ack(quizID, previous, participantID); accepted || schedule {
+		t.Error("ack for the previous question was accepted")
+	}
+	if accepted, _ := tracker.Ack(quizID, current, participantID); !accepted {
+		t.Error("ack for the current question was rejected after a new question started")
+	}
+	if _, count, _ := tracker.TakeReport(quizID); count != 1 {
+		t.Errorf("report counts %d acks, want only the one for the current question", count)
+	}
+
+	tracker.Clear(quizID)
+	if accepted, _ := tracker.Ack(quizID, current, uuid.New()); accepted {
+		t.Error("ack accepted after the quiz's acks were cleared")
+	}
+	if _, _, ok := tracker.TakeReport(quizID); ok {
+		t.Error("cleared quiz still has a report")
+	}
+}
+
+func TestAckTrackerKeepsQuizzesApart(t *testing.T) {
+	tracker := NewAckTracker()
+	quizID, otherQuizID := uuid.New(), uuid.New()
+	questionID := uuid.New()
+	tracker.StartQuestion(quizID, questionID)
+	tracker.StartQuestion(otherQuizID, uuid.New())
+
+	if accepted, _ := tracker.Ack(otherQuizID, questionID, uuid.New()); accepted {
+		t.Error("ack for another quiz's question was accepted")
+	}
+	if _, count, _ := tracker.TakeReport(quizID); count != 0 {
+		t.Errorf("quiz counts %d acks sent to another quiz", count)
+	}
+}
@@ -4,10 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
 	"time"
 
 	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/dto"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/logger"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
@@ -22,8 +27,8 @@ const (
 	// Send pings to peer with this period (must be less than pongWait)
 	pingPeriod = (pongWait * 9) / 10
 
-	// Maximum message size allowed from peer
-	maxMessageSize = 512
+	// Maximum message size allowed from peer when the client does not set one
+	defaultMaxMessageBytes = 4096
 )
 
 // EventType defines the type of WebSocket events
@@ -36,33 +41,99 @@ const (
 	// EventQuestionStart is sent when a new question becomes active
 	EventQuestionStart EventType = "QUESTION_START"
 
+	// EventQuestionPreview is sent to creators only when one of them previews a question before going live
+	EventQuestionPreview EventType = "QUESTION_PREVIEW"
+
 	// EventQuestionEnd is sent when the time for a question ends
 	EventQuestionEnd EventType = "QUESTION_END"
+	// EventQuestionSkipped is sent when the creator skips a question without revealing its answers
+	EventQuestionSkipped EventType = "QUESTION_SKIPPED"
 
-	// EventAnswerReceived is sent to confirm an answer was received
+	// EventAnswerReceived is sent to confirm an answer was recorded, with its score and correctness
 	EventAnswerReceived EventType = "ANSWER_RECEIVED"
 
+	// EventClientAnswer is published to hosts whenever a participant submits an answer, over WebSocket or HTTP
+	EventClientAnswer EventType = "CLIENT_ANSWER"
+
 	// EventLeaderboardUpdate is sent when the leaderboard changes
 	EventLeaderboardUpdate EventType = "LEADERBOARD_UPDATE"
 
+	// EventLeaderboardFrozen is sent when the creator freezes the leaderboard ahead of a reveal
+	EventLeaderboardFrozen EventType = "LEADERBOARD_FROZEN"
+
 	// EventQuizEnd is sent when the quiz ends
 	EventQuizEnd EventType = "QUIZ_END"
 
 	// EventUserJoined is sent when a new user joins
 	EventUserJoined EventType = "USER_JOINED"
 
+	// EventUserReconnected is sent when a participant comes back within the reconnection grace period
+	EventUserReconnected EventType = "USER_RECONNECTED"
+
+	// EventUserKicked is sent when the creator removes a participant
+	EventUserKicked EventType = "USER_KICKED"
+
 	// EventUserLeft is sent when a user leaves the quiz
 	EventUserLeft EventType = "USER_LEFT"
 
 	// EventTimerUpdate is sent to update the remaining time
 	EventTimerUpdate EventType = "TIMER_UPDATE"
 
+	// EventQuestionAckUpdate is sent to creators with how many participants received the current question
+	EventQuestionAckUpdate EventType = "QUESTION_ACK_UPDATE"
+
+	// EventAnswerLockUpdate is sent to creators with the participants who have answered the current question
+	EventAnswerLockUpdate EventType = "ANSWER_LOCK_UPDATE"
+
+	// EventRequiredAnswersMissing is sent to creators when a required question ends with the participants who did not answer it
+	EventRequiredAnswersMissing EventType = "REQUIRED_ANSWERS_MISSING"
+
+	// EventAnswerCountUpdate is sent to creators after each answer with how many participants have answered
+	EventAnswerCountUpdate EventType = "ANSWER_COUNT_UPDATE"
+
+	// EventSettingsUpdated is sent when a creator changes the quiz settings
+	EventSettingsUpdated EventType = "SETTINGS_UPDATED"
+
+	// EventLobbyCountdown is sent every second while a quiz counts down to its automatic start
+	EventLobbyCountdown EventType = "LOBBY_COUNTDOWN"
+
+	// EventLobbySnapshot is sent to a creator on connect with the lobby roster and presence
+	EventLobbySnapshot EventType = "LOBBY_SNAPSHOT"
+
+	// EventQuizDeleted is sent to every client of a quiz right before it is deleted and their connections are closed
+	EventQuizDeleted EventType = "QUIZ_DELETED"
+
+	// EventConnectionReplaced tells the other server instances that a participant connected elsewhere.
+	// It is handled by the instances themselves and never delivered to clients.
+	EventConnectionReplaced EventType = "CONNECTION_REPLACED"
+
 	// EventError is sent when an error occurs
 	EventError EventType = "ERROR"
 
 	EventStateSync = "STATE_SYNC"
 )
 
+// Codes of the ERROR events sent when an answer is rejected
+const (
+	// ErrorCodeAnswerRejected is sent when the answer was refused, e.g. because it is too large or too late
+	ErrorCodeAnswerRejected = "ANSWER_REJECTED"
+
+	// ErrorCodeInvalidPayload is sent when the answer payload is not valid JSON of the expected shape
+	ErrorCodeInvalidPayload = "INVALID_PAYLOAD"
+
+	// ErrorCodeNoOptionSelected is sent when the answer selects no option
+	ErrorCodeNoOptionSelected = "NO_OPTION_SELECTED"
+
+	// ErrorCodeBadQuestionID is sent when the answer's question ID is not a valid UUID
+	ErrorCodeBadQuestionID = "BAD_QUESTION_ID"
+
+	// ErrorCodeCreatorCannotAnswer is sent when a creator connection submits an answer
+	ErrorCodeCreatorCannotAnswer = "CREATOR_CANNOT_ANSWER"
+
+	// ErrorCodeSpectatorCannotAnswer is sent when a spectator connection submits an answer
+	ErrorCodeSpectatorCannotAnswer = "SPECTATOR_CANNOT_ANSWER"
+)
+
 var (
 	newline = []byte{'\n'}
 	space   = []byte{' '}
@@ -72,6 +143,7 @@ var (
 type HubInterface interface {
 	BroadcastToQuiz(quizID uuid.UUID, event Event)
 	SendToClient(userID uuid.UUID, quizID uuid.UUID, event Event)
+	AckQuestion(quizID uuid.UUID, questionID uuid.UUID, participantID uuid.UUID) bool
 	Run(ctx context.Context)
 
 	// Methods to access registration channels
@@ -79,6 +151,38 @@ type HubInterface interface {
 	GetUnregisterChan() chan<- *Client
 }
 
+// QuizHub is the hub the application runs on: RedisHub fans events out to every instance,
+// while MemoryHub keeps them within a single process
+type QuizHub interface {
+	HubInterface
+
+	GetInstanceID() string
+	SubscribeToQuiz(quizID uuid.UUID) error
+	SelfTest(ctx context.Context) error
+
+	BroadcastToCreators(quizID uuid.UUID, event Event)
+	BroadcastToParticipants(quizID uuid.UUID, event Event)
+	PublishToQuiz(quizID uuid.UUID, event Event) error
+	PublishToCreators(quizID uuid.UUID, event Event) error
+	PublishToParticipants(quizID uuid.UUID, event Event) error
+
+	CloseQuiz(quizID uuid.UUID) error
+	DisconnectUser(quizID uuid.UUID, userID uuid.UUID, reason model.DisconnectReason)
+	ReplaceConnection(quizID uuid.UUID, participantID uuid.UUID) error
+	KickParticipant(quizID uuid.UUID, participantID uuid.UUID, event Event) error
+
+	StartQuestionAcks(quizID uuid.UUID, questionID uuid.UUID)
+	ClearQuestionAcks(quizID uuid.UUID)
+
+	StartTimerBroadcast(ctx context.Context, quizID uuid.UUID, durationSeconds int)
+	StartTimerBroadcastUntil(ctx context.Context, quizID uuid.UUID, endTime time.Time, totalSeconds int)
+	StartCountdownBroadcast(ctx context.Context, quizID uuid.UUID, seconds int) bool
+}
+
+// AnswerSubmitter records a participant's answer; the answer service provides it so WebSocket
+// and HTTP submissions share one validation, scoring and broadcast path
+type AnswerSubmitter func(ctx context.Context, participantID uuid.UUID, questionID uuid.UUID, selectedOptions []string, clientToken string) (*model.Answer, error)
+
 // ClientMessage represents a message sent from client to server
 type ClientMessage struct {
 	Type    string          `json:"type"`
@@ -90,6 +194,7 @@ type AnswerPayload struct {
 	QuestionID      string   `json:"questionId"`
 	SelectedOptions []string `json:"selectedOptions"`
 	TimeTaken       float64  `json:"timeTaken"`
+	ClientToken     string   `json:"clientToken,omitempty"`
 }
 
 // Event represents a WebSocket event message
@@ -137,6 +242,10 @@ type Client struct {
 	// IsCreator indicates if this client is connected as a quiz creator (user) or participant
 	IsCreator bool
 
+	// IsSpectator marks a display such as a projector: it receives participant events but is not
+	// a participant, so it never answers or acknowledges questions
+	IsSpectator bool
+
 	// Hub manages the clients
 	Hub HubInterface
 
@@ -151,6 +260,117 @@ type Client struct {
 
 	// Cancel function to clean up the context
 	Cancel context.CancelFunc
+
+	// AnswerLimiter throttles answer submissions from this client (nil means unlimited)
+	AnswerLimiter *RateLimiter
+
+	// Logger receives the client's log output (nil uses the default logger)
+	Logger *slog.Logger
+
+	// SubmitAnswer records answers sent by this client (nil drops them)
+	SubmitAnswer AnswerSubmitter
+
+	// MaxMessageBytes is the largest message read from the peer before the connection is closed
+	// (0 means defaultMaxMessageBytes)
+	MaxMessageBytes int
+
+	// MaxAnswerPayloadBytes rejects larger ANSWER payloads (0 means unlimited)
+	MaxAnswerPayloadBytes int
+
+	// MaxSelectedOptions rejects answers selecting more options (0 means unlimited)
+	MaxSelectedOptions int
+
+	// disconnectReason records why the connection ended, guarded by reasonMu
+	disconnectReason model.DisconnectReason
+	reasonMu         sync.Mutex
+
+	// closeRequested is closed by Close to have a running WritePump flush the queued messages and then
+	// close the connection; writePumping and closing are guarded by reasonMu
+	closeRequested chan struct{}
+	writePumping   bool
+	closing        bool
+}
+
+// log returns the client's logger annotated with its connection fields
+func (c *Client) log() *slog.Logger {
+	return logger.OrDefault(c.Logger).With("clientId", c.ID, "quizId", c.QuizID, "userId", c.UserID)
+}
+
+// SetDisconnectReason records why the connection ended. Only the first reason is kept,
+// so a kick is not overwritten by the read error caused by closing the socket.
+func (c *Client) SetDisconnectReason(reason model.DisconnectReason) {
+	c.reasonMu.Lock()
+	defer c.reasonMu.Unlock()
+
+	if c.disconnectReason == "" {
+		c.disconnectReason = reason
+	}
+}
+
+// DisconnectReason returns why the connection ended, defaulting to an error
+func (c *Client) DisconnectReason() model.DisconnectReason {
+	c.reasonMu.Lock()
+	defer c.reasonMu.Unlock()
+
+	if c.disconnectReason == "" {
+		return model.DisconnectReasonError
+	}
+	return c.disconnectReason
+}
+
+// Close terminates the connection with a close frame carrying the given reason, which it also records.
+// When WritePump is running it first writes the messages already queued, so an event sent right before
+// the close, such as USER_KICKED or QUIZ_DELETED, still reaches the client.
+func (c *Client) Close(reason model.DisconnectReason) {
+	c.SetDisconnectReason(reason)
+
+	c.reasonMu.Lock()
+	if c.writePumping {
+		if !c.closing {
+			c.closing = true
+			close(c.closeRequested)
+		}
+		c.reasonMu.Unlock()
+		return
+	}
+	c.reasonMu.Unlock()
+
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, string(reason))
+	c.Conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(writeWait))
+	c.Conn.Close()
+}
+
+// flushAndClose writes the messages queued on the send channel, then a close frame with the disconnect reason.
+// Only WritePump calls it, after Close asked it to.
+func (c *Client) flushAndClose() {
+	c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+	for n := len(c.Send); n > 0; n-- {
+		message, ok := <-c.Send
+		if !ok {
+			break
+		}
+		if err := c.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			c.log().Warn("Error flushing message before close", "error", err)
+			return
+		}
+	}
+
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, string(c.DisconnectReason()))
+	c.Conn.WriteMessage(websocket.CloseMessage, closeMessage)
+}
+
+// disconnectReasonFromError classifies the error that ended a read loop
+func disconnectReasonFromError(err error) model.DisconnectReason {
+	if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+		return model.DisconnectReasonClosed
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return model.DisconnectReasonTimeout
+	}
+
+	return model.DisconnectReasonError
 }
 
 // IncomingMessage represents a message received from the client
@@ -167,13 +387,17 @@ func (c *Client) ReadPump() {
 		if c.Cancel != nil {
 			c.Cancel()
 		}
-		log.Printf("Client disconnected: %s for quiz %s", c.UserID, c.QuizID)
+		c.log().Info("Client disconnected", "reason", c.DisconnectReason())
 	}()
 
-	c.Conn.SetReadLimit(maxMessageSize)
+	maxMessageBytes := c.MaxMessageBytes
+	if maxMessageBytes <= 0 {
+		maxMessageBytes = defaultMaxMessageBytes
+	}
+	c.Conn.SetReadLimit(int64(maxMessageBytes))
 	c.Conn.SetReadDeadline(time.Now().Add(pongWait))
 	c.Conn.SetPongHandler(func(string) error {
-		log.Printf("Received pong from client: %s", c.UserID)
+		c.log().Debug("Received pong from client")
 		c.Conn.SetReadDeadline(time.Now().Add(pongWait))
 		return nil
 	})
@@ -181,8 +405,9 @@ func (c *Client) ReadPump() {
 	for {
 		_, message, err := c.Conn.ReadMessage()
 		if err != nil {
+			c.SetDisconnectReason(disconnectReasonFromError(err))
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("error: %v", err)
+				c.log().Warn("Unexpected websocket close", "error", err)
 			}
 			break
 		}
@@ -191,7 +416,7 @@ func (c *Client) ReadPump() {
 		// Process incoming message
 		var incomingMsg IncomingMessage
 		if err := json.Unmarshal(message, &incomingMsg); err != nil {
-			log.Printf("Error unmarshaling message: %v", err)
+			c.log().Warn("Error unmarshaling message", "error", err)
 			continue
 		}
 
@@ -199,88 +424,163 @@ func (c *Client) ReadPump() {
 		switch incomingMsg.Type {
 		case "ping":
 			// Send pong response back
-			event := NewEvent("pong", map[string]interface{}{"time": time.Now().Unix()})
+			event := NewEvent("pong", map[string]interface{}{"time": FormatTimestamp(time.Now())})
 			eventData, _ := json.Marshal(event)
 			c.Send <- eventData
+		case "QUESTION_ACK":
+			// Only participants acknowledge questions
+			if c.IsCreator || c.IsSpectator {
+				continue
+			}
+
+			var ackPayload QuestionAckPayload
+			if err := json.Unmarshal(incomingMsg.Payload, &ackPayload); err != nil {
+				c.log().Warn("Error unmarshaling question ack payload", "error", err)
+				continue
+			}
+
+			questionID, err := uuid.Parse(ackPayload.QuestionID)
+			if err != nil {
+				c.log().Warn("Invalid question ID in ack", "error", err)
+				continue
+			}
+
+			// Acks for anything but the current question are ignored
+			if !c.Hub.AckQuestion(c.QuizID, questionID, c.UserID) {
+				c.log().Debug("Ignoring stale question ack", "questionId", questionID)
+			}
 		case "ANSWER":
-			// Only participants can submit answers
+			// Only participants can submit answers. Creators are told so, at most as often as the answer rate limit allows.
 			if c.IsCreator {
-				log.Printf("Creator attempted to submit answer: %s", c.UserID)
+				c.log().Warn("Creator attempted to submit answer")
+				if c.AnswerLimiter.Allow() {
+					c.sendAnswerError(ErrorCodeCreatorCannotAnswer, "", "creators cannot answer questions; join as a participant to play")
+				}
+				continue
+			}
+			if c.IsSpectator {
+				c.log().Warn("Spectator attempted to submit answer")
+				if c.AnswerLimiter.Allow() {
+					c.sendAnswerError(ErrorCodeSpectatorCannotAnswer, "", "spectators cannot answer questions; join as a participant to play")
+				}
+				continue
+			}
+
+			// Drop answers that exceed the client's rate limit. Every answer, malformed or not, takes a token
+			// before it is checked, so rejections are never sent back faster than the limit either.
+			if !c.AnswerLimiter.Allow() {
+				c.log().Warn("Answer rate limit exceeded, dropping message")
+				continue
+			}
+
+			// Reject oversized payloads before decoding them
+			if c.MaxAnswerPayloadBytes > 0 && len(incomingMsg.Payload) > c.MaxAnswerPayloadBytes {
+				c.log().Warn("Answer payload too large", "size", len(incomingMsg.Payload))
+				c.sendAnswerError(ErrorCodeAnswerRejected, "", "answer payload is too large")
 				continue
 			}
 
 			// Process answer submission
 			var answerPayload AnswerPayload
 			if err := json.Unmarshal(incomingMsg.Payload, &answerPayload); err != nil {
-				log.Printf("Error unmarshaling answer payload: %v", err)
+				c.log().Warn("Error unmarshaling answer payload", "error", err)
+				c.sendAnswerError(ErrorCodeInvalidPayload, "", "answer payload must be an object with questionId, selectedOptions and an optional clientToken")
 				continue
 			}
 
 			// Validate the answer payload
 			if len(answerPayload.SelectedOptions) == 0 {
-				log.Printf("No options selected in answer: %s", c.UserID)
+				c.log().Warn("No options selected in answer")
+				c.sendAnswerError(ErrorCodeNoOptionSelected, answerPayload.QuestionID, "select at least one option")
+				continue
+			}
+
+			if c.MaxSelectedOptions > 0 && len(answerPayload.SelectedOptions) > c.MaxSelectedOptions {
+				c.log().Warn("Too many options selected in answer", "count", len(answerPayload.SelectedOptions))
+				c.sendAnswerError(ErrorCodeAnswerRejected, answerPayload.QuestionID, "too many options selected")
 				continue
 			}
 
 			// Convert questionId string to UUID
 			questionID, err := uuid.Parse(answerPayload.QuestionID)
 			if err != nil {
-				log.Printf("Invalid question ID: %v", err)
+				c.log().Warn("Invalid question ID in answer", "error", err)
+				c.sendAnswerError(ErrorCodeBadQuestionID, answerPayload.QuestionID, "questionId must be a valid question ID")
 				continue
 			}
 
-			// Publish answer event to Redis
-			event := NewEvent("CLIENT_ANSWER", map[string]interface{}{
-				"participantId":   c.UserID.String(),
-				"questionId":      questionID.String(),
-				"selectedOptions": answerPayload.SelectedOptions,
-				"timeTaken":       answerPayload.TimeTaken,
-			})
+			if c.SubmitAnswer == nil {
+				c.log().Error("No answer submitter configured, dropping answer")
+				continue
+			}
 
-			// Handle the event based on hub type
-			c.Hub.BroadcastToQuiz(c.QuizID, event)
+			// Record the answer through the same path as HTTP submissions
+			answer, err := c.SubmitAnswer(c.Ctx, c.UserID, questionID, answerPayload.SelectedOptions, answerPayload.ClientToken)
+			if err != nil {
+				c.log().Warn("Error submitting answer", "questionId", questionID, "error", err)
+				c.sendAnswerError(ErrorCodeAnswerRejected, questionID.String(), err.Error())
+				continue
+			}
 
-			// Also send direct confirmation to the client
+			// Confirm the recorded answer to the client with how it was scored
 			confirmEvent := NewEvent(EventAnswerReceived, map[string]interface{}{
+				"answerId":        answer.ID.String(),
 				"questionId":      questionID.String(),
-				"selectedOptions": answerPayload.SelectedOptions,
-				"timeTaken":       answerPayload.TimeTaken,
+				"selectedOptions": answer.SelectedOptions,
+				"timeTaken":       answer.TimeTaken,
+				"isCorrect":       answer.IsCorrect,
+				"score":           answer.Score,
 			})
 			c.Hub.SendToClient(c.UserID, c.QuizID, confirmEvent)
 		}
 	}
 }
 
+// sendAnswerError tells the client its answer to questionID was rejected, with a machine-readable code
+func (c *Client) sendAnswerError(code string, questionID string, message string) {
+	c.Hub.SendToClient(c.UserID, c.QuizID, NewEvent(EventError, map[string]interface{}{
+		"code":       code,
+		"questionId": questionID,
+		"message":    message,
+	}))
+}
+
 // WritePump pumps messages from the hub to the WebSocket connection
 func (c *Client) WritePump() {
+	c.reasonMu.Lock()
+	c.closeRequested = make(chan struct{})
+	closeRequested := c.closeRequested
+	c.writePumping = true
+	c.reasonMu.Unlock()
+
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
+		c.reasonMu.Lock()
+		c.writePumping = false
+		c.reasonMu.Unlock()
+
 		ticker.Stop()
 		c.Conn.Close()
 		if c.Cancel != nil {
 			c.Cancel()
 		}
-		log.Printf("WritePump terminated for client: %s", c.UserID)
+		c.log().Debug("WritePump terminated")
 	}()
 
 	for {
 		select {
 		case message, ok := <-c.Send:
-			// Check if the hub is still sending messages
-			// log.Printf("Sending message to client: %s", c.UserID)
-			// log.Printf("Message: %s", string(message))
-
 			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
 				// The hub closed the channel
-				log.Printf("Send channel closed for client: %s", c.UserID)
+				c.log().Debug("Send channel closed")
 				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
 			}
 
 			w, err := c.Conn.NextWriter(websocket.TextMessage)
 			if err != nil {
-				log.Printf("Error getting writer for client %s: %v", c.UserID, err)
+				c.log().Error("Error getting writer", "error", err)
 				return
 			}
 			w.Write(message)
@@ -293,18 +593,22 @@ func (c *Client) WritePump() {
 			}
 
 			if err := w.Close(); err != nil {
-				log.Printf("Error closing writer for client %s: %v", c.UserID, err)
+				c.log().Error("Error closing writer", "error", err)
 				return
 			}
 		case <-ticker.C:
 			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-				log.Printf("Error sending ping to client %s: %v", c.UserID, err)
+				c.log().Warn("Error sending ping", "error", err)
 				return
 			}
-			log.Printf("Sent ping to client: %s", c.UserID)
+			c.log().Debug("Sent ping to client")
+		case <-closeRequested:
+			c.log().Debug("Close requested")
+			c.flushAndClose()
+			return
 		case <-c.Ctx.Done():
-			log.Printf("Context done for client: %s", c.UserID)
+			c.log().Debug("Client context done")
 			return
 		}
 	}
@@ -0,0 +1,604 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// recordingHub is a HubInterface recording what a client's ReadPump sends through it
+type recordingHub struct {
+	mu         sync.Mutex
+	sent       []Event
+	broadcast  []Event
+	acks       []uuid.UUID
+	ackResult  bool
+	unregister chan *Client
+}
+
+func newRecordingHub() *recordingHub {
+	return &recordingHub{unregister: make(chan *Client, 1)}
+}
+
+func (h *recordingHub) BroadcastToQuiz(quizID uuid.UUID, event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.broadcast = append(h.broadcast, event)
+}
+
+func (h *recordingHub) SendToClient(userID uuid.UUID, quizID uuid.UUID, event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sent = append(h.sent, event)
+}
+
+func (h *recordingHub) AckQuestion(quizID uuid.UUID, questionID uuid.UUID, participantID uuid.UUID) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.acks = append(h.acks, questionID)
+	return h.ackResult
+}
+
+func (h *recordingHub) Run(ctx context.Context) {}
+
+func (h *recordingHub) GetRegisterChan() chan<- *Client { return make(chan *Client, 1) }
+
+func (h *recordingHub) GetUnregisterChan() chan<- *Client { return h.unregister }
+
+// sentEvents returns the events sent to the client with the given type
+func (h *recordingHub) sentEvents(eventType EventType) []Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var events []Event
+	for _, event := range h.sent {
+		if event.Type == eventType {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// errorCodes returns the codes of the ERROR events sent to the client
+func (h *recordingHub) errorCodes() []string {
+	var codes []string
+	for _, event := range h.sentEvents(EventError) {
+		codes = append(codes, event.Payload.(map[string]interface{})["code"].(string))
+	}
+	return codes
+}
+
+// recordingSubmitter is an AnswerSubmitter recording the answers it is given
+type recordingSubmitter struct {
+	mu      sync.Mutex
+	answers []AnswerPayload
+	err     error
+}
+
+func (s *recordingSubmitter) submit(ctx context.Context, participantID uuid.UUID, questionID uuid.UUID, selectedOptions []string, clientToken string) (*model.Answer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.answers = append(s.answers, AnswerPayload{QuestionID: questionID.String(), SelectedOptions: selectedOptions, ClientToken: clientToken})
+	if s.err != nil {
+		return nil, s.err
+	}
+	return model.NewAnswer(participantID, questionID, selectedOptions, 1, true)
+}
+
+func (s *recordingSubmitter) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.answers)
+}
+
+// pumpedClient is a client whose ReadPump reads what a test writes to peer
+type pumpedClient struct {
+	client *Client
+	hub    *recordingHub
+	peer   *websocket.Conn
+	done   chan struct{}
+}
+
+// startReadPump connects client to a test peer over a real WebSocket and runs its ReadPump
+func startReadPump(t *testing.T, client *Client) *pumpedClient {
+	t.Helper()
+
+	hub := newRecordingHub()
+	client.Hub = hub
+	if client.Send == nil {
+		client.Send = make(chan []byte, 16)
+	}
+
+	pumped := &pumpedClient{client: client, hub: hub, done: make(chan struct{})}
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		client.Conn = conn
+		go func() {
+			defer close(pumped.done)
+			client.ReadPump()
+		}()
+	}))
+	t.Cleanup(server.Close)
+
+	peer, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { peer.Close() })
+	pumped.peer = peer
+
+	return pumped
+}
+
+// write sends a message of the given type and payload to the client
+func (p *pumpedClient) write(t *testing.T, messageType string, payload interface{}) {
+	t.Helper()
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	p.writeRaw(t, []byte(`{"type":"`+messageType+`","payload":`+string(raw)+`}`))
+}
+
+// writeRaw sends a message to the client as is
+func (p *pumpedClient) writeRaw(t *testing.T, message []byte) {
+	t.Helper()
+
+	if err := p.peer.WriteMessage(websocket.TextMessage, message); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+// sync waits until the client has handled every message written so far, using the ping it answers directly
+func (p *pumpedClient) sync(t *testing.T) {
+	t.Helper()
+
+	p.write(t, "ping", map[string]interface{}{})
+	for {
+		select {
+		case message := <-p.client.Send:
+			if strings.Contains(string(message), `"pong"`) {
+				return
+			}
+		case <-p.done:
+			t.Fatal("ReadPump stopped before answering the ping")
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for the client to handle its messages")
+		}
+	}
+}
+
+// waitClosed waits for ReadPump to return
+func (p *pumpedClient) waitClosed(t *testing.T) {
+	t.Helper()
+
+	select {
+	case <-p.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("ReadPump kept reading")
+	}
+}
+
+// connectClient gives client a server-side connection to a test peer and returns the peer
+func connectClient(t *testing.T, client *Client) *websocket.Conn {
+	t.Helper()
+
+	connected := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		client.Conn = conn
+		close(connected)
+	}))
+	t.Cleanup(server.Close)
+
+	peer, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { peer.Close() })
+	<-connected
+
+	return peer
+}
+
+// connectWritingClient connects client to a test peer like connectClient and runs its WritePump
+func connectWritingClient(t *testing.T, client *Client) *websocket.Conn {
+	t.Helper()
+
+	peer := connectClient(t, client)
+	client.Ctx, client.Cancel = context.WithCancel(context.Background())
+	t.Cleanup(client.Cancel)
+	go client.WritePump()
+
+	// Closes only wait for the queued messages once the pump is running
+	deadline := time.Now().Add(time.Second)
+	for {
+		client.reasonMu.Lock()
+		pumping := client.writePumping
+		client.reasonMu.Unlock()
+		if pumping {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("WritePump did not start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	return peer
+}
+
+// eventsBeforeClose reads the peer's connection until it is closed and returns the types of the events
+// it received along with the close frame
+func eventsBeforeClose(t *testing.T, peer *websocket.Conn) ([]EventType, *websocket.CloseError) {
+	t.Helper()
+
+	var types []EventType
+	peer.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, message, err := peer.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) {
+				t.Fatalf("connection ended without a close frame: %v", err)
+			}
+			return types, closeErr
+		}
+		for _, line := range strings.Split(string(message), "\n") {
+			var event struct {
+				Type EventType `json:"type"`
+			}
+			if err := json.Unmarshal([]byte(line), &event); err != nil {
+				t.Fatalf("peer received invalid JSON %q: %v", line, err)
+			}
+			types = append(types, event.Type)
+		}
+	}
+}
+
+// closeText waits for the peer's connection to be closed and returns the text of the close frame
+func closeText(t *testing.T, peer *websocket.Conn) string {
+	t.Helper()
+	return closeFrame(t, peer).Text
+}
+
+// closeFrame waits for the peer's connection to be closed and returns its close frame
+func closeFrame(t *testing.T, peer *websocket.Conn) *websocket.CloseError {
+	t.Helper()
+
+	peer.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, _, err := peer.ReadMessage()
+		if err == nil {
+			continue
+		}
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) {
+			t.Fatalf("connection ended without a close frame: %v", err)
+		}
+		return closeErr
+	}
+}
+
+// newParticipantClient creates a participant client of a new quiz that submits through submitter
+func newParticipantClient(submitter *recordingSubmitter) *Client {
+	client := newTestClient(uuid.New(), false, false)
+	client.SubmitAnswer = submitter.submit
+	return client
+}
+
+// answer builds an ANSWER payload selecting the given options
+func answer(questionID uuid.UUID, options ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"questionId":      questionID.String(),
+		"selectedOptions": options,
+	}
+}
+
+func TestReadPumpDropsAnswersOverTheRateLimit(t *testing.T) {
+	submitter := &recordingSubmitter{}
+	client := newParticipantClient(submitter)
+	client.AnswerLimiter = NewRateLimiter(3)
+	pumped := startReadPump(t, client)
+
+	questionID := uuid.New()
+	for i := 0; i < 10; i++ {
+		pumped.write(t, "ANSWER", answer(questionID, uuid.New().String()))
+	}
+	pumped.sync(t)
+
+	if got := submitter.count(); got != 3 {
+		t.Errorf("submitted %d of 10 rapid answers, want the 3 the limit allows", got)
+	}
+	if got := len(pumped.hub.sentEvents(EventError)); got != 0 {
+		t.Errorf("dropped answers were answered with %d errors", got)
+	}
+}
+
+func TestReadPumpSubmitsAnswersThroughTheSharedSubmitter(t *testing.T) {
+	submitter := &recordingSubmitter{}
+	client := newParticipantClient(submitter)
+	pumped := startReadPump(t, client)
+
+	questionID := uuid.New()
+	optionID := uuid.New().String()
+	payload := answer(questionID, optionID)
+	payload["clientToken"] = "retry-1"
+	pumped.write(t, "ANSWER", payload)
+	pumped.sync(t)
+
+	if submitter.count() != 1 {
+		t.Fatalf("submitted %d answers, want 1", submitter.count())
+	}
+	submitted := submitter.answers[0]
+	if submitted.QuestionID != questionID.String() || len(submitted.SelectedOptions) != 1 ||
+		submitted.SelectedOptions[0] != optionID || submitted.ClientToken != "retry-1" {
+		t.Errorf("submitter was given %+v", submitted)
+	}
+
+	received := pumped.hub.sentEvents(EventAnswerReceived)
+	if len(received) != 1 {
+		t.Fatalf("client was sent %d ANSWER_RECEIVED events, want 1", len(received))
+	}
+	confirmation := received[0].Payload.(map[string]interface{})
+	if confirmation["questionId"] != questionID.String() || confirmation["isCorrect"] != true {
+		t.Errorf("ANSWER_RECEIVED payload = %v", confirmation)
+	}
+}
+
+func TestReadPumpReportsRejectedAnswers(t *testing.T) {
+	submitter := &recordingSubmitter{err: errors.New("question is not currently active")}
+	pumped := startReadPump(t, newParticipantClient(submitter))
+
+	pumped.write(t, "ANSWER", answer(uuid.New(), uuid.New().String()))
+	pumped.sync(t)
+
+	if codes := pumped.hub.errorCodes(); len(codes) != 1 || codes[0] != ErrorCodeAnswerRejected {
+		t.Errorf("client was sent error codes %v, want [%s]", codes, ErrorCodeAnswerRejected)
+	}
+	if got := len(pumped.hub.sentEvents(EventAnswerReceived)); got != 0 {
+		t.Errorf("rejected answer was confirmed %d times", got)
+	}
+}
+
+func TestReadPumpRejectsOversizedAnswers(t *testing.T) {
+	manyOptions := make([]string, 5)
+	for i := range manyOptions {
+		manyOptions[i] = uuid.New().String()
+	}
+
+	tests := []struct {
+		name        string
+		options     []string
+		wantCode    string
+		wantSubmits int
+	}{
+		{name: "at the option cap", options: manyOptions[:4], wantSubmits: 1},
+		{name: "over the option cap", options: manyOptions, wantCode: ErrorCodeAnswerRejected},
+		{name: "over the payload size", options: []string{strings.Repeat("x", 600)}, wantCode: ErrorCodeAnswerRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			submitter := &recordingSubmitter{}
+			client := newParticipantClient(submitter)
+			client.MaxSelectedOptions = 4
+			client.MaxAnswerPayloadBytes = 512
+			pumped := startReadPump(t, client)
+
+			pumped.write(t, "ANSWER", answer(uuid.New(), tt.options...))
+			pumped.sync(t)
+
+			if got := submitter.count(); got != tt.wantSubmits {
+				t.Errorf("submitted %d answers, want %d", got, tt.wantSubmits)
+			}
+			codes := pumped.hub.errorCodes()
+			if tt.wantCode == "" && len(codes) != 0 {
+				t.Errorf("client was sent error codes %v", codes)
+			}
+			if tt.wantCode != "" && (len(codes) != 1 || codes[0] != tt.wantCode) {
+				t.Errorf("client was sent error codes %v, want [%s]", codes, tt.wantCode)
+			}
+		})
+	}
+}
+
+// answerOfSize builds an ANSWER message of exactly size bytes, padding its client token
+func answerOfSize(t *testing.T, size int, options ...string) []byte {
+	t.Helper()
+
+	raw, err := json.Marshal(options)
+	if err != nil {
+		t.Fatalf("marshal options: %v", err)
+	}
+	prefix := `{"type":"ANSWER","payload":{"questionId":"` + uuid.New().String() + `","selectedOptions":` + string(raw) + `,"clientToken":"`
+	suffix := `"}}`
+	padding := size - len(prefix) - len(suffix)
+	if padding < 0 {
+		t.Fatalf("an answer with %d options does not fit in %d bytes", len(options), size)
+	}
+	return []byte(prefix + strings.Repeat("x", padding) + suffix)
+}
+
+func TestReadPumpReadLimit(t *testing.T) {
+	manyOptions := make([]string, 10)
+	for i := range manyOptions {
+		manyOptions[i] = uuid.New().String()
+	}
+
+	tests := []struct {
+		name       string
+		limit      int
+		size       int
+		wantClosed bool
+	}{
+		{name: "just under the limit", limit: 1024, size: 1023},
+		{name: "at the limit", limit: 1024, size: 1024},
+		{name: "just over the limit", limit: 1024, size: 1025, wantClosed: true},
+		{name: "default limit fits many options", size: 600},
+		{name: "over the default limit", size: defaultMaxMessageBytes + 1, wantClosed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			submitter := &recordingSubmitter{}
+			client := newParticipantClient(submitter)
+			client.MaxMessageBytes = tt.limit
+			pumped := startReadPump(t, client)
+
+			pumped.writeRaw(t, answerOfSize(t, tt.size, manyOptions...))
+
+			if tt.wantClosed {
+				pumped.waitClosed(t)
+				if got := closeFrame(t, pumped.peer).Code; got != websocket.CloseMessageTooBig {
+					t.Errorf("oversized message closed the connection with code %d, want %d", got, websocket.CloseMessageTooBig)
+				}
+				if submitter.count() != 0 {
+					t.Error("oversized answer was submitted")
+				}
+				return
+			}
+			pumped.sync(t)
+			if submitter.count() != 1 {
+				t.Errorf("submitted %d answers of %d bytes, want 1", submitter.count(), tt.size)
+			}
+		})
+	}
+}
+
+func TestReadPumpReportsMalformedAnswers(t *testing.T) {
+	questionID := uuid.New().String()
+
+	tests := []struct {
+		name           string
+		payload        string
+		wantCode       string
+		wantQuestionID string
+	}{
+		{name: "payload not an object", payload: `"answer"`, wantCode: ErrorCodeInvalidPayload},
+		{name: "options not a list", payload: `{"questionId":"` + questionID + `","selectedOptions":"a"}`, wantCode: ErrorCodeInvalidPayload},
+		{name: "no options", payload: `{"questionId":"` + questionID + `","selectedOptions":[]}`, wantCode: ErrorCodeNoOptionSelected, wantQuestionID: questionID},
+		{name: "options missing", payload: `{"questionId":"` + questionID + `"}`, wantCode: ErrorCodeNoOptionSelected, wantQuestionID: questionID},
+		{name: "question ID not a UUID", payload: `{"questionId":"question-1","selectedOptions":["a"]}`, wantCode: ErrorCodeBadQuestionID, wantQuestionID: "question-1"},
+		{name: "question ID missing", payload: `{"selectedOptions":["a"]}`, wantCode: ErrorCodeBadQuestionID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			submitter := &recordingSubmitter{}
+			pumped := startReadPump(t, newParticipantClient(submitter))
+
+			pumped.writeRaw(t, []byte(`{"type":"ANSWER","payload":`+tt.payload+`}`))
+			pumped.sync(t)
+
+			reported := pumped.hub.sentEvents(EventError)
+			if len(reported) != 1 {
+				t.Fatalf("client was sent %d ERROR events, want 1", len(reported))
+			}
+			payload := reported[0].Payload.(map[string]interface{})
+			if payload["code"] != tt.wantCode || payload["questionId"] != tt.wantQuestionID || payload["message"] == "" {
+				t.Errorf("ERROR payload = %v, want code %s for question %q with a message", payload, tt.wantCode, tt.wantQuestionID)
+			}
+			if submitter.count() != 0 {
+				t.Error("malformed answer was submitted")
+			}
+		})
+	}
+}
+
+func TestReadPumpReportsMalformedAnswersNoFasterThanTheRateLimit(t *testing.T) {
+	submitter := &recordingSubmitter{}
+	client := newParticipantClient(submitter)
+	client.AnswerLimiter = NewRateLimiter(3)
+	pumped := startReadPump(t, client)
+
+	for i := 0; i < 10; i++ {
+		pumped.writeRaw(t, []byte(`{"type":"ANSWER","payload":"flood"}`))
+	}
+	pumped.sync(t)
+
+	if codes := pumped.hub.errorCodes(); len(codes) != 3 {
+		t.Errorf("10 rapid malformed answers were answered with %d errors, want the 3 the limit allows", len(codes))
+	}
+}
+
+func TestReadPumpTellsCreatorsTheyCannotAnswer(t *testing.T) {
+	submitter := &recordingSubmitter{}
+	client := newTestClient(uuid.New(), true, false)
+	client.SubmitAnswer = submitter.submit
+	client.AnswerLimiter = NewRateLimiter(3)
+	pumped := startReadPump(t, client)
+
+	pumped.write(t, "ANSWER", answer(uuid.New(), uuid.New().String()))
+	pumped.sync(t)
+
+	if submitter.count() != 0 {
+		t.Error("creator's answer was submitted")
+	}
+	pumped.hub.mu.Lock()
+	broadcast := len(pumped.hub.broadcast)
+	pumped.hub.mu.Unlock()
+	if broadcast != 0 {
+		t.Errorf("creator's answer was broadcast %d times", broadcast)
+	}
+	if codes := pumped.hub.errorCodes(); len(codes) != 1 || codes[0] != ErrorCodeCreatorCannotAnswer {
+		t.Errorf("creator was sent error codes %v, want [%s]", codes, ErrorCodeCreatorCannotAnswer)
+	}
+
+	// Repeated attempts are refused no faster than the answer rate limit
+	for i := 0; i < 10; i++ {
+		pumped.write(t, "ANSWER", answer(uuid.New(), uuid.New().String()))
+	}
+	pumped.sync(t)
+
+	if codes := pumped.hub.errorCodes(); len(codes) != 3 {
+		t.Errorf("11 creator answers were answered with %d errors, want the 3 the limit allows", len(codes))
+	}
+}
+
+func TestReadPumpSpectatorsNeitherAnswerNorAcknowledge(t *testing.T) {
+	submitter := &recordingSubmitter{}
+	client := newTestClient(uuid.New(), false, true)
+	client.SubmitAnswer = submitter.submit
+	pumped := startReadPump(t, client)
+
+	questionID := uuid.New()
+	pumped.write(t, "ANSWER", answer(questionID, uuid.New().String()))
+	pumped.write(t, "QUESTION_ACK", map[string]interface{}{"questionId": questionID.String()})
+	pumped.sync(t)
+
+	if submitter.count() != 0 {
+		t.Error("spectator's answer was submitted")
+	}
+	if codes := pumped.hub.errorCodes(); len(codes) != 1 || codes[0] != ErrorCodeSpectatorCannotAnswer {
+		t.Errorf("spectator was sent error codes %v, want [%s]", codes, ErrorCodeSpectatorCannotAnswer)
+	}
+	pumped.hub.mu.Lock()
+	acks := len(pumped.hub.acks)
+	pumped.hub.mu.Unlock()
+	if acks != 0 {
+		t.Errorf("spectator acknowledged %d questions", acks)
+	}
+}
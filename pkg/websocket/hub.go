@@ -3,10 +3,13 @@ package websocket
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/logger"
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/metrics"
 	"github.com/google/uuid"
 )
 
@@ -21,16 +24,27 @@ type Hub struct {
 	// Unregister requests from clients
 	Unregister chan *Client
 
+	// Acknowledgements of the current question per quiz
+	acks *AckTracker
+
+	// Structured logger
+	logger *slog.Logger
+
+	// onQuizEmpty is called from Run, outside the lock, once the last client of a quiz unregisters
+	onQuizEmpty func(quizID uuid.UUID)
+
 	// Mutex for safe concurrent access
 	mu sync.Mutex
 }
 
-// NewHub creates a new WebSocket hub
-func NewHub() *Hub {
+// NewHub creates a new WebSocket hub; a nil logger uses the default logger
+func NewHub(log *slog.Logger) *Hub {
 	return &Hub{
 		Clients:    make(map[uuid.UUID]map[uuid.UUID]*Client),
 		Register:   make(chan *Client),
 		Unregister: make(chan *Client),
+		acks:       NewAckTracker(),
+		logger:     logger.OrDefault(log),
 	}
 }
 
@@ -60,24 +74,46 @@ func (h *Hub) registerClient(client *Client) {
 	}
 
 	quizClients[client.ID] = client
+	metrics.ActiveConnections.WithLabelValues(metrics.ConnectionRole(client.IsCreator, client.IsSpectator)).Inc()
 }
 
 // unregisterClient removes a client from the hub
 func (h *Hub) unregisterClient(client *Client) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
-
+	empty := false
 	if quizClients, exists := h.Clients[client.QuizID]; exists {
+		// A slow client may already have been dropped by a broadcast
 		if _, ok := quizClients[client.ID]; ok {
-			delete(quizClients, client.ID)
-			close(client.Send)
+			h.dropClient(quizClients, client)
+		}
 
-			// If no more clients in the quiz, remove the quiz entry
-			if len(quizClients) == 0 {
-				delete(h.Clients, client.QuizID)
-			}
+		// If no more clients in the quiz, remove the quiz entry
+		if len(quizClients) == 0 {
+			delete(h.Clients, client.QuizID)
+			empty = true
 		}
 	}
+	h.mu.Unlock()
+
+	// Release per-quiz resources outside the lock
+	if empty && h.onQuizEmpty != nil {
+		h.onQuizEmpty(client.QuizID)
+	}
+}
+
+// hasClients reports whether any client is registered for a quiz
+func (h *Hub) hasClients(quizID uuid.UUID) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.Clients[quizID]) > 0
+}
+
+// dropClient removes a client from its quiz and closes its send channel. Callers must hold h.mu.
+func (h *Hub) dropClient(quizClients map[uuid.UUID]*Client, client *Client) {
+	delete(quizClients, client.ID)
+	close(client.Send)
+	metrics.ActiveConnections.WithLabelValues(metrics.ConnectionRole(client.IsCreator, client.IsSpectator)).Dec()
 }
 
 // BroadcastToQuiz sends an event to all clients in a quiz
@@ -92,7 +128,7 @@ func (h *Hub) BroadcastToQuiz(quizID uuid.UUID, event Event) {
 
 	message, err := json.Marshal(event)
 	if err != nil {
-		fmt.Printf("Error marshaling event: %v\n", err)
+		h.logger.Error("Error marshaling event", "quizId", quizID, "eventType", event.Type, "error", err)
 		return
 	}
 
@@ -100,8 +136,7 @@ func (h *Hub) BroadcastToQuiz(quizID uuid.UUID, event Event) {
 		select {
 		case client.Send <- message:
 		default:
-			close(client.Send)
-			delete(quizClients, client.ID)
+			h.dropClient(quizClients, client)
 		}
 	}
 }
@@ -118,7 +153,7 @@ func (h *Hub) BroadcastToCreators(quizID uuid.UUID, event Event) {
 
 	message, err := json.Marshal(event)
 	if err != nil {
-		fmt.Printf("Error marshaling event: %v\n", err)
+		h.logger.Error("Error marshaling event", "quizId", quizID, "eventType", event.Type, "error", err)
 		return
 	}
 
@@ -130,13 +165,13 @@ func (h *Hub) BroadcastToCreators(quizID uuid.UUID, event Event) {
 		select {
 		case client.Send <- message:
 		default:
-			close(client.Send)
-			delete(quizClients, client.ID)
+			h.dropClient(quizClients, client)
 		}
 	}
 }
 
-// BroadcastToParticipants sends an event only to participant clients in a quiz
+// BroadcastToParticipants sends an event only to participant clients in a quiz.
+// Spectators see what participants see, so they receive it too.
 func (h *Hub) BroadcastToParticipants(quizID uuid.UUID, event Event) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -148,7 +183,7 @@ func (h *Hub) BroadcastToParticipants(quizID uuid.UUID, event Event) {
 
 	message, err := json.Marshal(event)
 	if err != nil {
-		fmt.Printf("Error marshaling event: %v\n", err)
+		h.logger.Error("Error marshaling event", "quizId", quizID, "eventType", event.Type, "error", err)
 		return
 	}
 
@@ -160,8 +195,7 @@ func (h *Hub) BroadcastToParticipants(quizID uuid.UUID, event Event) {
 		select {
 		case client.Send <- message:
 		default:
-			close(client.Send)
-			delete(quizClients, client.ID)
+			h.dropClient(quizClients, client)
 		}
 	}
 }
@@ -191,49 +225,174 @@ func (h *Hub) SendToClient(userID uuid.UUID, quizID uuid.UUID, event Event) {
 
 	message, err := json.Marshal(event)
 	if err != nil {
-		fmt.Printf("Error marshaling event: %v\n", err)
+		h.logger.Error("Error marshaling event", "quizId", quizID, "eventType", event.Type, "error", err)
 		return
 	}
 
 	select {
 	case targetClient.Send <- message:
 	default:
-		close(targetClient.Send)
-		delete(quizClients, targetClient.ID)
+		h.dropClient(quizClients, targetClient)
 	}
 }
 
-// StartTimerBroadcast starts a timer that broadcasts updates to all clients in a quiz
-func (h *Hub) StartTimerBroadcast(quizID uuid.UUID, durationSeconds int) {
-	startTime := time.Now()
-	endTime := startTime.Add(time.Duration(durationSeconds) * time.Second)
+// DisconnectUser closes every connection a user or participant has open in a quiz
+func (h *Hub) DisconnectUser(quizID uuid.UUID, userID uuid.UUID, reason model.DisconnectReason) {
+	h.mu.Lock()
+	var targets []*Client
+	for _, client := range h.Clients[quizID] {
+		if client.UserID == userID {
+			targets = append(targets, client)
+		}
+	}
+	h.mu.Unlock()
+
+	// Close outside the lock; the read pumps unregister the clients themselves
+	for _, client := range targets {
+		client.Close(reason)
+	}
+}
+
+// DisconnectQuiz closes every connection open in a quiz
+func (h *Hub) DisconnectQuiz(quizID uuid.UUID, reason model.DisconnectReason) {
+	h.mu.Lock()
+	targets := make([]*Client, 0, len(h.Clients[quizID]))
+	for _, client := range h.Clients[quizID] {
+		targets = append(targets, client)
+	}
+	h.mu.Unlock()
+
+	// Close outside the lock; the read pumps unregister the clients themselves
+	for _, client := range targets {
+		client.Close(reason)
+	}
+}
+
+// StartQuestionAcks starts collecting acknowledgements for a newly started question
+func (h *Hub) StartQuestionAcks(quizID uuid.UUID, questionID uuid.UUID) {
+	h.acks.StartQuestion(quizID, questionID)
+}
+
+// ClearQuestionAcks stops collecting acknowledgements for a quiz
+func (h *Hub) ClearQuestionAcks(quizID uuid.UUID) {
+	h.acks.Clear(quizID)
+}
+
+// AckQuestion records that a participant received a question. Reports to creators are
+// throttled to one per ackReportInterval and carry the latest count.
+func (h *Hub) AckQuestion(quizID uuid.UUID, questionID uuid.UUID, participantID uuid.UUID) bool {
+	accepted, scheduleReport := h.acks.Ack(quizID, questionID, participantID)
+	if scheduleReport {
+		time.AfterFunc(ackReportInterval, func() {
+			h.reportQuestionAcks(quizID)
+		})
+	}
+	return accepted
+}
 
+// reportQuestionAcks sends the acknowledgement count of the current question to creators
+func (h *Hub) reportQuestionAcks(quizID uuid.UUID) {
+	questionID, count, ok := h.acks.TakeReport(quizID)
+	if !ok {
+		return
+	}
+
+	// Count the participants connected to this instance; spectators never acknowledge
+	h.mu.Lock()
+	participantCount := 0
+	for _, client := range h.Clients[quizID] {
+		if !client.IsCreator && !client.IsSpectator {
+			participantCount++
+		}
+	}
+	h.mu.Unlock()
+
+	h.BroadcastToCreators(quizID, NewEvent(EventQuestionAckUpdate, map[string]interface{}{
+		"questionId":       questionID.String(),
+		"ackCount":         count,
+		"participantCount": participantCount,
+	}))
+}
+
+// StartTimerBroadcast starts a timer that broadcasts updates to all clients in a quiz.
+// It stops early without a final update when ctx is cancelled.
+func (h *Hub) StartTimerBroadcast(ctx context.Context, quizID uuid.UUID, durationSeconds int) {
+	endTime := time.Now().Add(time.Duration(durationSeconds) * time.Second)
+	h.StartTimerBroadcastUntil(ctx, quizID, endTime, durationSeconds)
+}
+
+// StartTimerBroadcastUntil broadcasts timer updates every second until endTime, reporting totalSeconds
+// as the question's full duration. It is used when a running question's deadline moves.
+func (h *Hub) StartTimerBroadcastUntil(ctx context.Context, quizID uuid.UUID, endTime time.Time, totalSeconds int) {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
 	for {
-		<-ticker.C
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
 
 		now := time.Now()
 		if now.After(endTime) {
 			// Time's up
 			h.BroadcastToQuiz(quizID, NewEvent(EventTimerUpdate, map[string]interface{}{
 				"remainingSeconds": 0,
-				"totalSeconds":     durationSeconds,
-				"endTime":          endTime.Format(time.RFC3339),
+				"totalSeconds":     totalSeconds,
+				"endTime":          FormatTimestamp(endTime),
 			}))
 			return
 		}
 
-		remainingSeconds := int(endTime.Sub(now).Seconds())
+		remainingSeconds := model.RemainingSeconds(endTime, now)
 		h.BroadcastToQuiz(quizID, NewEvent(EventTimerUpdate, map[string]interface{}{
 			"remainingSeconds": remainingSeconds,
-			"totalSeconds":     durationSeconds,
-			"endTime":          endTime.Format(time.RFC3339),
+			"totalSeconds":     totalSeconds,
+			"endTime":          FormatTimestamp(endTime),
 		}))
 	}
 }
 
+// StartCountdownBroadcast broadcasts a LOBBY_COUNTDOWN tick every second until the countdown reaches zero.
+// It reports whether the countdown completed; it stops early without a final tick when ctx is cancelled.
+func (h *Hub) StartCountdownBroadcast(ctx context.Context, quizID uuid.UUID, seconds int) bool {
+	startTime := time.Now()
+	endTime := startTime.Add(time.Duration(seconds) * time.Second)
+
+	h.BroadcastToQuiz(quizID, NewEvent(EventLobbyCountdown, map[string]interface{}{
+		"remainingSeconds": seconds,
+		"totalSeconds":     seconds,
+		"quizStartTime":    FormatTimestamp(endTime),
+	}))
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return false
+		}
+
+		remainingSeconds := int(time.Until(endTime).Round(time.Second).Seconds())
+		if remainingSeconds < 0 {
+			remainingSeconds = 0
+		}
+
+		h.BroadcastToQuiz(quizID, NewEvent(EventLobbyCountdown, map[string]interface{}{
+			"remainingSeconds": remainingSeconds,
+			"totalSeconds":     seconds,
+			"quizStartTime":    FormatTimestamp(endTime),
+		}))
+
+		if remainingSeconds == 0 {
+			return true
+		}
+	}
+}
+
 // GetRegisterChan returns the channel for registering clients
 func (h *Hub) GetRegisterChan() chan<- *Client {
 	return h.Register
@@ -0,0 +1,93 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/pkg/metrics"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// activeConnections returns the active connection gauge for a role
+func activeConnections(role string) float64 {
+	return testutil.ToFloat64(metrics.ActiveConnections.WithLabelValues(role))
+}
+
+func TestHubTracksActiveConnectionsByRole(t *testing.T) {
+	h := NewHub(nil)
+	quizID := uuid.New()
+	creators := activeConnections("creator")
+	participants := activeConnections("participant")
+
+	creator := newTestClient(quizID, true, false)
+	participant := newTestClient(quizID, false, false)
+	h.registerClient(creator)
+	h.registerClient(participant)
+
+	if got := activeConnections("creator") - creators; got != 1 {
+		t.Errorf("creator gauge grew by %v, want 1", got)
+	}
+	if got := activeConnections("participant") - participants; got != 1 {
+		t.Errorf("participant gauge grew by %v, want 1", got)
+	}
+
+	h.unregisterClient(participant)
+	// A second unregister of the same client must not count it again
+	h.unregisterClient(participant)
+
+	if got := activeConnections("participant") - participants; got != 0 {
+		t.Errorf("participant gauge is %v off after unregistering", got)
+	}
+	if got := activeConnections("creator") - creators; got != 1 {
+		t.Errorf("creator gauge changed by %v when a participant left", got-1)
+	}
+}
+
+func TestHubQuestionAckReportsDoNotCountSpectators(t *testing.T) {
+	h := NewHub(nil)
+	quizID := uuid.New()
+	questionID := uuid.New()
+	spectators := activeConnections("spectator")
+	participants := activeConnections("participant")
+
+	creator := newTestClient(quizID, true, false)
+	participant := newTestClient(quizID, false, false)
+	spectator := newTestClient(quizID, false, true)
+	for _, client := range []*Client{creator, participant, spectator} {
+		h.registerClient(client)
+	}
+
+	if got := activeConnections("spectator") - spectators; got != 1 {
+		t.Errorf("spectator gauge grew by %v, want 1", got)
+	}
+	if got := activeConnections("participant") - participants; got != 1 {
+		t.Errorf("participant gauge grew by %v with one participant and one spectator", got)
+	}
+
+	h.StartQuestionAcks(quizID, questionID)
+	h.AckQuestion(quizID, questionID, participant.UserID)
+	h.reportQuestionAcks(quizID)
+
+	select {
+	case message := <-creator.Send:
+		var event struct {
+			Type    EventType `json:"type"`
+			Payload struct {
+				AckCount         int `json:"ackCount"`
+				ParticipantCount int `json:"participantCount"`
+			} `json:"payload"`
+		}
+		if err := json.Unmarshal(message, &event); err != nil {
+			t.Fatalf("creator received invalid JSON %q: %v", message, err)
+		}
+		if event.Type != EventQuestionAckUpdate || event.Payload.AckCount != 1 || event.Payload.ParticipantCount != 1 {
+			t.Errorf("creator was sent %s with %+v, want %s with 1 of 1 participants", event.Type, event.Payload, EventQuestionAckUpdate)
+		}
+	default:
+		t.Fatal("creator was sent no ack report")
+	}
+	if got := receivedTypes(t, spectator); len(got) != 0 {
+		t.Errorf("spectator was sent %v", got)
+	}
+}
@@ -0,0 +1,79 @@
+package websocket
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+// MemoryHub is a WebSocket hub that delivers events only to the clients connected to this process.
+// It needs no Redis, which suits local development and demos, but events never reach other instances,
+// so it must not be used when more than one instance serves the same quizzes.
+type MemoryHub struct {
+	*Hub
+	instanceID string // Unique identifier for this server instance
+}
+
+// NewMemoryHub creates a new in-memory WebSocket hub; a nil logger uses the default logger
+func NewMemoryHub(logger *slog.Logger) *MemoryHub {
+	return &MemoryHub{
+		Hub:        NewHub(logger),
+		instanceID: uuid.New().String(),
+	}
+}
+
+// GetInstanceID returns the unique identifier for this server instance
+func (h *MemoryHub) GetInstanceID() string {
+	return h.instanceID
+}
+
+// SubscribeToQuiz does nothing since every client of the quiz is local
+func (h *MemoryHub) SubscribeToQuiz(quizID uuid.UUID) error {
+	return nil
+}
+
+// SelfTest always succeeds since there is no pub/sub to verify
+func (h *MemoryHub) SelfTest(ctx context.Context) error {
+	return nil
+}
+
+// PublishToQuiz sends an event to all local clients in a quiz
+func (h *MemoryHub) PublishToQuiz(quizID uuid.UUID, event Event) error {
+	h.BroadcastToQuiz(quizID, event)
+	return nil
+}
+
+// PublishToCreators sends an event only to local creator clients
+func (h *MemoryHub) PublishToCreators(quizID uuid.UUID, event Event) error {
+	h.BroadcastToCreators(quizID, event)
+	return nil
+}
+
+// PublishToParticipants sends an event only to local participant clients
+func (h *MemoryHub) PublishToParticipants(quizID uuid.UUID, event Event) error {
+	h.BroadcastToParticipants(quizID, event)
+	return nil
+}
+
+// CloseQuiz tells every client of a quiz that it was deleted, then closes their connections
+func (h *MemoryHub) CloseQuiz(quizID uuid.UUID) error {
+	h.BroadcastToQuiz(quizID, NewEvent(EventQuizDeleted, map[string]interface{}{
+		"quizId": quizID.String(),
+	}))
+	h.DisconnectQuiz(quizID, model.DisconnectReasonQuizDeleted)
+	return nil
+}
+
+// KickParticipant tells every client of a quiz that a participant was kicked, then closes the participant's connections
+func (h *MemoryHub) KickParticipant(quizID uuid.UUID, participantID uuid.UUID, event Event) error {
+	h.BroadcastToQuiz(quizID, event)
+	h.DisconnectUser(quizID, participantID, model.DisconnectReasonKicked)
+	return nil
+}
+
+// ReplaceConnection does nothing since there are no other instances holding older connections
+func (h *MemoryHub) ReplaceConnection(quizID uuid.UUID, participantID uuid.UUID) error {
+	return nil
+}
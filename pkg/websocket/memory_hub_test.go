@@ -0,0 +1,107 @@
+package websocket
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+func TestMemoryHubCloseQuizDisconnectsItsClients(t *testing.T) {
+	h := NewMemoryHub(nil)
+	quizID := uuid.New()
+	creator := newTestClient(quizID, true, false)
+	participant := newTestClient(quizID, false, false)
+	bystander := newTestClient(uuid.New(), false, false)
+
+	peers := make(map[*Client]*websocket.Conn)
+	for _, client := range []*Client{creator, participant, bystander} {
+		peers[client] = connectClient(t, client)
+		h.registerClient(client)
+	}
+
+	if err := h.CloseQuiz(quizID); err != nil {
+		t.Fatalf("CloseQuiz: %v", err)
+	}
+
+	for _, client := range []*Client{creator, participant} {
+		if got := receivedTypes(t, client); len(got) != 1 || got[0] != EventQuizDeleted {
+			t.Errorf("client was sent %v before being disconnected, want [%s]", got, EventQuizDeleted)
+		}
+		if got := closeText(t, peers[client]); got != string(model.DisconnectReasonQuizDeleted) {
+			t.Errorf("connection was closed with %q, want %q", got, model.DisconnectReasonQuizDeleted)
+		}
+		if got := client.DisconnectReason(); got != model.DisconnectReasonQuizDeleted {
+			t.Errorf("client disconnect reason = %s, want %s", got, model.DisconnectReasonQuizDeleted)
+		}
+	}
+
+	if got := receivedTypes(t, bystander); len(got) != 0 {
+		t.Errorf("client of another quiz was sent %v", got)
+	}
+	if bystander.DisconnectReason() == model.DisconnectReasonQuizDeleted {
+		t.Error("client of another quiz was disconnected")
+	}
+}
+
+func TestMemoryHubCloseQuizDeliversTheEventBeforeClosing(t *testing.T) {
+	h := NewMemoryHub(nil)
+	quizID := uuid.New()
+	participant := newTestClient(quizID, false, false)
+	peer := connectWritingClient(t, participant)
+	h.registerClient(participant)
+
+	if err := h.CloseQuiz(quizID); err != nil {
+		t.Fatalf("CloseQuiz: %v", err)
+	}
+
+	got, closeErr := eventsBeforeClose(t, peer)
+	if len(got) != 1 || got[0] != EventQuizDeleted {
+		t.Errorf("peer received %v before the close, want [%s]", got, EventQuizDeleted)
+	}
+	if closeErr.Text != string(model.DisconnectReasonQuizDeleted) {
+		t.Errorf("connection was closed with %q, want %q", closeErr.Text, model.DisconnectReasonQuizDeleted)
+	}
+}
+
+func TestMemoryHubBroadcastsToLocalClients(t *testing.T) {
+	h := NewMemoryHub(nil)
+	quizID := uuid.New()
+	creator := newTestClient(quizID, true, false)
+	participant := newTestClient(quizID, false, false)
+	bystander := newTestClient(uuid.New(), false, false)
+	for _, client := range []*Client{creator, participant, bystander} {
+		h.registerClient(client)
+	}
+	if err := h.SubscribeToQuiz(quizID); err != nil {
+		t.Fatalf("SubscribeToQuiz: %v", err)
+	}
+
+	if err := h.PublishToQuiz(quizID, NewEvent(EventQuizStart, nil)); err != nil {
+		t.Fatalf("PublishToQuiz: %v", err)
+	}
+	if err := h.PublishToCreators(quizID, NewEvent(EventAnswerLockUpdate, nil)); err != nil {
+		t.Fatalf("PublishToCreators: %v", err)
+	}
+	if err := h.PublishToParticipants(quizID, NewEvent(EventQuestionStart, nil)); err != nil {
+		t.Fatalf("PublishToParticipants: %v", err)
+	}
+	h.SendToClient(participant.UserID, quizID, NewEvent(EventAnswerReceived, nil))
+
+	tests := []struct {
+		name   string
+		client *Client
+		want   []EventType
+	}{
+		{name: "creator", client: creator, want: []EventType{EventQuizStart, EventAnswerLockUpdate}},
+		{name: "participant", client: participant, want: []EventType{EventQuizStart, EventQuestionStart, EventAnswerReceived}},
+		{name: "client of another quiz", client: bystander},
+	}
+	for _, tt := range tests {
+		if got := receivedTypes(t, tt.client); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s was sent %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter is a simple token bucket limiting how often a client may perform an action
+type RateLimiter struct {
+	mu       sync.Mutex
+	rate     float64 // tokens added per second
+	capacity float64 // maximum number of tokens in the bucket
+	tokens   float64
+	last     time.Time
+}
+
+// NewRateLimiter creates a limiter allowing up to perSecond actions per second.
+// A non-positive value disables limiting and returns nil.
+func NewRateLimiter(perSecond int) *RateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+
+	return &RateLimiter{
+		rate:     float64(perSecond),
+		capacity: float64(perSecond),
+		tokens:   float64(perSecond),
+		last:     time.Now(),
+	}
+}
+
+// Allow reports whether an action may happen now, consuming a token if so.
+// A nil limiter always allows the action.
+func (l *RateLimiter) Allow() bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.capacity {
+		l.tokens = l.capacity
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
+}
@@ -0,0 +1,40 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllowsBurstThenRefills(t *testing.T) {
+	limiter := NewRateLimiter(5)
+
+	for i := 0; i < 5; i++ {
+		if !limiter.Allow() {
+			t.Fatalf("action %d of the first burst was refused", i+1)
+		}
+	}
+	if limiter.Allow() {
+		t.Fatal("action beyond the burst was allowed")
+	}
+
+	// One token comes back every 200ms
+	time.Sleep(250 * time.Millisecond)
+	if !limiter.Allow() {
+		t.Error("no action allowed after a token refilled")
+	}
+	if limiter.Allow() {
+		t.Error("more actions allowed than tokens refilled")
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiter := NewRateLimiter(0)
+	if limiter != nil {
+		t.Fatal("a non-positive rate should disable the limiter")
+	}
+	for i := 0; i < 100; i++ {
+		if !limiter.Allow() {
+			t.Fatal("a disabled limiter refused an action")
+		}
+	}
+}
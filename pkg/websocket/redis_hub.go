@@ -4,12 +4,22 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 )
 
+// Publishing to Redis is retried with exponential backoff so a brief outage does not drop live events
+const (
+	publishAttempts       = 3
+	publishInitialBackoff = 50 * time.Millisecond
+)
+
 // RedisHub is a WebSocket hub implementation that uses Redis for pub/sub
 type RedisHub struct {
 	*Hub
@@ -17,19 +27,28 @@ type RedisHub struct {
 	pubsub      *redis.PubSub
 	ctx         context.Context
 	instanceID  string // Unique identifier for this server instance
+
+	// subscribed holds the quizzes whose channel the shared pubsub is subscribed to
+	subMu      sync.Mutex
+	subscribed map[uuid.UUID]bool
 }
 
-// NewRedisHub creates a new Redis-based WebSocket hub
-func NewRedisHub(redisClient *redis.Client, ctx context.Context) *RedisHub {
+// NewRedisHub creates a new Redis-based WebSocket hub; a nil logger uses the default logger
+func NewRedisHub(redisClient *redis.Client, ctx context.Context, logger *slog.Logger) *RedisHub {
 	// Generate a unique instance ID for this server
 	instanceID := uuid.New().String()
 
-	return &RedisHub{
-		Hub:         NewHub(),
+	h := &RedisHub{
+		Hub:         NewHub(logger),
 		redisClient: redisClient,
 		ctx:         ctx,
 		instanceID:  instanceID,
+		subscribed:  make(map[uuid.UUID]bool),
 	}
+
+	// Stop receiving a quiz's events once this instance has no clients left for it
+	h.Hub.onQuizEmpty = h.unsubscribeFromQuiz
+	return h
 }
 
 // GetInstanceID returns the unique identifier for this server instance
@@ -37,81 +56,302 @@ func (h *RedisHub) GetInstanceID() string {
 	return h.instanceID
 }
 
-// SubscribeToQuiz subscribes to Redis events for a quiz
+// quizChannelPrefix starts the name of the Redis channel carrying a quiz's events
+const quizChannelPrefix = "quiz:"
+
+// quizChannel returns the name of the Redis channel carrying a quiz's events
+func quizChannel(quizID uuid.UUID) string {
+	return quizChannelPrefix + quizID.String()
+}
+
+// quizIDFromChannel returns the quiz whose events a Redis channel carries
+func quizIDFromChannel(channel string) (uuid.UUID, bool) {
+	if !strings.HasPrefix(channel, quizChannelPrefix) {
+		return uuid.Nil, false
+	}
+
+	quizID, err := uuid.Parse(strings.TrimPrefix(channel, quizChannelPrefix))
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return quizID, true
+}
+
+// Audience names which clients of a quiz an event published to Redis is delivered to
+type Audience string
+
+const (
+	AudienceAll          Audience = ""
+	AudienceCreators     Audience = "creators"
+	AudienceParticipants Audience = "participants"
+)
+
+// redisEnvelope is the message published on a quiz's channel: the event and the clients it is for
+type redisEnvelope struct {
+	Audience Audience        `json:"audience,omitempty"`
+	Event    json.RawMessage `json:"event"`
+}
+
+// encodeEnvelope builds the message publishing an event to the given clients of a quiz
+func encodeEnvelope(audience Audience, event Event) ([]byte, error) {
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling event: %w", err)
+	}
+
+	message, err := json.Marshal(redisEnvelope{Audience: audience, Event: eventJSON})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling event: %w", err)
+	}
+	return message, nil
+}
+
+// SubscribeToQuiz subscribes to Redis events for a quiz unless this instance already is
 func (h *RedisHub) SubscribeToQuiz(quizID uuid.UUID) error {
-	channel := fmt.Sprintf("quiz:%s", quizID.String())
+	channel := quizChannel(quizID)
+
+	h.subMu.Lock()
+	defer h.subMu.Unlock()
+
+	if h.subscribed[quizID] {
+		return nil
+	}
 
-	// Check if we already have a subscription
+	// Add the channel to the existing subscription
 	if h.pubsub != nil {
-		// Since Channels() method is not available, we'll just resubscribe
-		// as redis client handles duplicate subscriptions gracefully
 		if err := h.pubsub.Subscribe(h.ctx, channel); err != nil {
 			return fmt.Errorf("error subscribing to channel: %w", err)
 		}
+		h.subscribed[quizID] = true
 		return nil
 	}
 
-	// Create a new subscription
+	// Create the subscription shared by all quizzes and a single loop receiving from it
 	h.pubsub = h.redisClient.Subscribe(h.ctx, channel)
+	h.subscribed[quizID] = true
+	go h.receive(h.pubsub)
+
+	return nil
+}
 
-	// Start a goroutine to handle messages from Redis
-	go func() {
-		defer func() {
-			if h.pubsub != nil {
-				h.pubsub.Close()
+// receive reads messages from the shared subscription until the hub's context is done,
+// dispatching each one to the clients of the quiz named by its channel
+func (h *RedisHub) receive(pubsub *redis.PubSub) {
+	defer pubsub.Close()
+
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		default:
+			msg, err := pubsub.ReceiveMessage(h.ctx)
+			if err != nil {
+				h.logger.Error("Error receiving message from Redis", "error", err)
+				time.Sleep(time.Second) // Add a small delay to prevent CPU spinning
+				continue
 			}
-		}()
-
-		for {
-			select {
-			case <-h.ctx.Done():
-				return
-			default:
-				msg, err := h.pubsub.ReceiveMessage(h.ctx)
-				if err != nil {
-					fmt.Printf("Error receiving message: %v\n", err)
-					time.Sleep(time.Second) // Add a small delay to prevent CPU spinning
-					continue
-				}
-
-				// Skip empty messages
-				if msg.Payload == "" {
-					continue
-				}
-
-				// Skip messages with null bytes
-				if len(msg.Payload) > 0 && msg.Payload[0] == 0 {
-					fmt.Printf("Skipping message with null bytes\n")
-					continue
-				}
-
-				var event Event
-				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
-					fmt.Printf("Error unmarshaling event: %v, payload: %q\n", err, msg.Payload)
-					continue
-				}
-
-				// Forward the event to all WebSocket clients for this quiz
-				h.BroadcastToQuiz(quizID, event)
+
+			quizID, ok := quizIDFromChannel(msg.Channel)
+			if !ok {
+				h.logger.Warn("Skipping Redis message on unexpected channel", "channel", msg.Channel)
+				continue
 			}
+
+			h.dispatch(quizID, msg.Payload)
 		}
-	}()
+	}
+}
+
+// dispatch forwards an event received on a quiz's channel to the quiz's clients on this instance
+func (h *RedisHub) dispatch(quizID uuid.UUID, payload string) {
+	// Skip empty messages
+	if payload == "" {
+		return
+	}
+
+	// Skip messages with null bytes
+	if payload[0] == 0 {
+		h.logger.Warn("Skipping Redis message with null bytes", "quizId", quizID)
+		return
+	}
+
+	var envelope redisEnvelope
+	var event Event
+	if err := json.Unmarshal([]byte(payload), &envelope); err != nil {
+		h.logger.Error("Error unmarshaling Redis event", "quizId", quizID, "payload", payload, "error", err)
+		return
+	}
+	if err := json.Unmarshal(envelope.Event, &event); err != nil {
+		h.logger.Error("Error unmarshaling Redis event", "quizId", quizID, "payload", payload, "error", err)
+		return
+	}
+
+	// Control messages between instances are not meant for clients
+	if event.Type == EventConnectionReplaced {
+		h.handleConnectionReplaced(event)
+		return
+	}
+
+	// Forward the event to the WebSocket clients of this quiz it is meant for
+	switch envelope.Audience {
+	case AudienceCreators:
+		h.BroadcastToCreators(quizID, event)
+	case AudienceParticipants:
+		h.BroadcastToParticipants(quizID, event)
+	default:
+		h.BroadcastToQuiz(quizID, event)
+	}
+
+	// A deleted quiz also closes the connections this instance holds for it, and a kick those of the participant
+	switch event.Type {
+	case EventQuizDeleted:
+		h.DisconnectQuiz(quizID, model.DisconnectReasonQuizDeleted)
+	case EventUserKicked:
+		h.handleUserKicked(quizID, event)
+	}
+}
+
+// unsubscribeFromQuiz stops receiving Redis events for a quiz that no longer has clients on this instance.
+// A client that registered in the meantime keeps the subscription.
+func (h *RedisHub) unsubscribeFromQuiz(quizID uuid.UUID) {
+	h.subMu.Lock()
+	defer h.subMu.Unlock()
 
+	if !h.subscribed[quizID] || h.pubsub == nil || h.hasClients(quizID) {
+		return
+	}
+
+	channel := quizChannel(quizID)
+	if err := h.pubsub.Unsubscribe(h.ctx, channel); err != nil {
+		h.logger.Warn("Error unsubscribing from quiz channel", "quizId", quizID, "error", err)
+		return
+	}
+	delete(h.subscribed, quizID)
+}
+
+// ReplaceConnection tells the other instances that a participant now has its latest connection to a quiz
+// on this instance, so they close the participant's older connections there
+func (h *RedisHub) ReplaceConnection(quizID uuid.UUID, participantID uuid.UUID) error {
+	return h.PublishToQuiz(quizID, NewEvent(EventConnectionReplaced, map[string]interface{}{
+		"quizId":        quizID.String(),
+		"participantId": participantID.String(),
+		"instanceId":    h.instanceID,
+	}))
+}
+
+// handleConnectionReplaced closes the local connections of a participant that connected on another instance
+func (h *RedisHub) handleConnectionReplaced(event Event) {
+	payload, ok := event.Payload.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	// The instance holding the newest connection keeps its clients
+	if instanceID, _ := payload["instanceId"].(string); instanceID == h.instanceID {
+		return
+	}
+
+	quizIDStr, _ := payload["quizId"].(string)
+	participantIDStr, _ := payload["participantId"].(string)
+	quizID, err := uuid.Parse(quizIDStr)
+	if err != nil {
+		h.logger.Warn("Ignoring connection replacement with invalid quiz ID", "quizId", quizIDStr)
+		return
+	}
+	participantID, err := uuid.Parse(participantIDStr)
+	if err != nil {
+		h.logger.Warn("Ignoring connection replacement with invalid participant ID", "quizId", quizID, "participantId", participantIDStr)
+		return
+	}
+
+	h.DisconnectUser(quizID, participantID, model.DisconnectReasonReplaced)
+}
+
+// KickParticipant publishes a USER_KICKED event that every instance delivers to the quiz's clients before
+// closing the kicked participant's connections it holds. The event must name the participant in its
+// participantId field. If publishing fails, the kick is still carried out on this instance.
+func (h *RedisHub) KickParticipant(quizID uuid.UUID, participantID uuid.UUID, event Event) error {
+	if err := h.PublishToQuiz(quizID, event); err != nil {
+		h.BroadcastToQuiz(quizID, event)
+		h.DisconnectUser(quizID, participantID, model.DisconnectReasonKicked)
+		return err
+	}
 	return nil
 }
 
-// PublishToQuiz publishes an event to Redis for a quiz
+// handleUserKicked closes the local connections of the participant a USER_KICKED event names
+func (h *RedisHub) handleUserKicked(quizID uuid.UUID, event Event) {
+	payload, ok := event.Payload.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	participantIDStr, _ := payload["participantId"].(string)
+	participantID, err := uuid.Parse(participantIDStr)
+	if err != nil {
+		h.logger.Warn("Ignoring kick with invalid participant ID", "quizId", quizID, "participantId", participantIDStr)
+		return
+	}
+
+	h.DisconnectUser(quizID, participantID, model.DisconnectReasonKicked)
+}
+
+// SelfTest publishes a probe on a throwaway channel and waits for it to come back through a
+// subscription, verifying that Redis pub/sub, not just Redis itself, is working.
+// The channel lives outside the quiz namespace and is unsubscribed before returning.
+func (h *RedisHub) SelfTest(ctx context.Context) error {
+	channel := fmt.Sprintf("selftest:%s:%s", h.instanceID, uuid.New().String())
+	probe := uuid.New().String()
+
+	pubsub := h.redisClient.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	// Wait for the subscription to be confirmed so the probe cannot be published before it
+	if _, err := pubsub.ReceiveTimeout(ctx, time.Until(deadlineOf(ctx))); err != nil {
+		return fmt.Errorf("error subscribing to self-test channel: %w", err)
+	}
+
+	if err := h.redisClient.Publish(ctx, channel, probe).Err(); err != nil {
+		return fmt.Errorf("error publishing self-test probe: %w", err)
+	}
+
+	for {
+		msg, err := pubsub.ReceiveTimeout(ctx, time.Until(deadlineOf(ctx)))
+		if err != nil {
+			return fmt.Errorf("self-test probe not received: %w", err)
+		}
+		if m, ok := msg.(*redis.Message); ok && m.Payload == probe {
+			return nil
+		}
+	}
+}
+
+// deadlineOf returns the context's deadline, or a default one for contexts without it
+func deadlineOf(ctx context.Context) time.Time {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline
+	}
+	return time.Now().Add(5 * time.Second)
+}
+
+// PublishToQuiz publishes an event to Redis for all clients of a quiz
 func (h *RedisHub) PublishToQuiz(quizID uuid.UUID, event Event) error {
-	channel := fmt.Sprintf("quiz:%s", quizID.String())
+	return h.publish(quizID, AudienceAll, event)
+}
+
+// publish publishes an event to Redis for the given clients of a quiz, retrying failed publishes a few times.
+// Clients on this instance receive it through the subscription like those on every other instance.
+func (h *RedisHub) publish(quizID uuid.UUID, audience Audience, event Event) error {
+	channel := quizChannel(quizID)
 
 	// Validate event fields to ensure we have a valid event
 	if event.Type == "" {
 		return fmt.Errorf("event type cannot be empty")
 	}
 
-	message, err := json.Marshal(event)
+	message, err := encodeEnvelope(audience, event)
 	if err != nil {
-		return fmt.Errorf("error marshaling event: %w", err)
+		return err
 	}
 
 	// Sanity check - ensure we're not sending null bytes
@@ -119,67 +359,130 @@ func (h *RedisHub) PublishToQuiz(quizID uuid.UUID, event Event) error {
 		return fmt.Errorf("invalid message format: starts with null byte")
 	}
 
-	// Log what we're publishing for debugging
-	fmt.Printf("Publishing to channel %s: %s\n", channel, string(message))
+	h.logger.Debug("Publishing event to Redis", "channel", channel, "eventType", event.Type, "audience", audience)
 
-	return h.redisClient.Publish(h.ctx, channel, message).Err()
-}
+	backoff := publishInitialBackoff
+	for attempt := 1; ; attempt++ {
+		err = h.redisClient.Publish(h.ctx, channel, message).Err()
+		if err == nil {
+			return nil
+		}
+		if attempt == publishAttempts {
+			return fmt.Errorf("error publishing event after %d attempts: %w", attempt, err)
+		}
 
-// PublishToCreators publishes an event to Redis and broadcasts only to creator clients
-func (h *RedisHub) PublishToCreators(quizID uuid.UUID, event Event) error {
-	// First publish to Redis to record the event
-	if err := h.PublishToQuiz(quizID, event); err != nil {
-		return err
+		h.logger.Warn("Retrying Redis publish", "channel", channel, "eventType", event.Type, "attempt", attempt, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-h.ctx.Done():
+			return fmt.Errorf("error publishing event: %w", err)
+		}
+		backoff *= 2
 	}
+}
 
-	// Then filter and only broadcast to creator clients locally
-	h.BroadcastToCreators(quizID, event)
-	return nil
+// CloseQuiz tells every client of a quiz on every instance that it was deleted, then closes their connections.
+// Local clients are handled directly so they are dropped even when Redis is unavailable.
+func (h *RedisHub) CloseQuiz(quizID uuid.UUID) error {
+	event := NewEvent(EventQuizDeleted, map[string]interface{}{
+		"quizId": quizID.String(),
+	})
+
+	h.BroadcastToQuiz(quizID, event)
+	h.DisconnectQuiz(quizID, model.DisconnectReasonQuizDeleted)
+
+	return h.PublishToQuiz(quizID, event)
 }
 
-// PublishToParticipants publishes an event to Redis and broadcasts only to participant clients
-func (h *RedisHub) PublishToParticipants(quizID uuid.UUID, event Event) error {
-	// First publish to Redis to record the event
-	if err := h.PublishToQuiz(quizID, event); err != nil {
-		return err
-	}
+// PublishToCreators publishes an event to Redis that only the creator clients of a quiz receive
+func (h *RedisHub) PublishToCreators(quizID uuid.UUID, event Event) error {
+	return h.publish(quizID, AudienceCreators, event)
+}
 
-	// Then filter and only broadcast to participant clients locally
-	h.BroadcastToParticipants(quizID, event)
-	return nil
+// PublishToParticipants publishes an event to Redis that only the participant and spectator clients of a quiz receive
+func (h *RedisHub) PublishToParticipants(quizID uuid.UUID, event Event) error {
+	return h.publish(quizID, AudienceParticipants, event)
 }
 
-// StartTimerBroadcast starts a timer that broadcasts updates to all clients in a quiz
-func (h *RedisHub) StartTimerBroadcast(quizID uuid.UUID, durationSeconds int) {
-	startTime := time.Now()
-	endTime := startTime.Add(time.Duration(durationSeconds) * time.Second)
+// StartTimerBroadcast starts a timer that broadcasts updates to all clients in a quiz.
+// It stops early without a final update when ctx is cancelled.
+func (h *RedisHub) StartTimerBroadcast(ctx context.Context, quizID uuid.UUID, durationSeconds int) {
+	endTime := time.Now().Add(time.Duration(durationSeconds) * time.Second)
+	h.StartTimerBroadcastUntil(ctx, quizID, endTime, durationSeconds)
+}
 
+// StartTimerBroadcastUntil broadcasts timer updates every second until endTime, reporting totalSeconds
+// as the question's full duration. It is used when a running question's deadline moves.
+func (h *RedisHub) StartTimerBroadcastUntil(ctx context.Context, quizID uuid.UUID, endTime time.Time, totalSeconds int) {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
 	for {
-		<-ticker.C
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
 
 		now := time.Now()
 		if now.After(endTime) {
 			// Time's up
 			h.PublishToQuiz(quizID, NewEvent(EventTimerUpdate, map[string]interface{}{
 				"remainingSeconds": 0,
-				"totalSeconds":     durationSeconds,
-				"endTime":          endTime.Format(time.RFC3339),
+				"totalSeconds":     totalSeconds,
+				"endTime":          FormatTimestamp(endTime),
 			}))
 			return
 		}
 
-		remainingSeconds := int(endTime.Sub(now).Seconds())
+		remainingSeconds := model.RemainingSeconds(endTime, now)
 		h.PublishToQuiz(quizID, NewEvent(EventTimerUpdate, map[string]interface{}{
 			"remainingSeconds": remainingSeconds,
-			"totalSeconds":     durationSeconds,
-			"endTime":          endTime.Format(time.RFC3339),
+			"totalSeconds":     totalSeconds,
+			"endTime":          FormatTimestamp(endTime),
 		}))
 	}
 }
 
+// StartCountdownBroadcast broadcasts a LOBBY_COUNTDOWN tick every second until the countdown reaches zero.
+// It reports whether the countdown completed; it stops early without a final tick when ctx is cancelled.
+func (h *RedisHub) StartCountdownBroadcast(ctx context.Context, quizID uuid.UUID, seconds int) bool {
+	startTime := time.Now()
+	endTime := startTime.Add(time.Duration(seconds) * time.Second)
+
+	h.PublishToQuiz(quizID, NewEvent(EventLobbyCountdown, map[string]interface{}{
+		"remainingSeconds": seconds,
+		"totalSeconds":     seconds,
+		"quizStartTime":    FormatTimestamp(endTime),
+	}))
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return false
+		}
+
+		remainingSeconds := int(time.Until(endTime).Round(time.Second).Seconds())
+		if remainingSeconds < 0 {
+			remainingSeconds = 0
+		}
+
+		h.PublishToQuiz(quizID, NewEvent(EventLobbyCountdown, map[string]interface{}{
+			"remainingSeconds": remainingSeconds,
+			"totalSeconds":     seconds,
+			"quizStartTime":    FormatTimestamp(endTime),
+		}))
+
+		if remainingSeconds == 0 {
+			return true
+		}
+	}
+}
+
 // GetRegisterChan returns the channel for registering clients
 func (h *RedisHub) GetRegisterChan() chan<- *Client {
 	return h.Register
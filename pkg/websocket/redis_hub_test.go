@@ -0,0 +1,552 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dinhkhaphancs/real-time-quiz-backend/internal/model"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// newTestClient creates a client with a buffered send channel and no connection
+func newTestClient(quizID uuid.UUID, isCreator bool, isSpectator bool) *Client {
+	return &Client{
+		ID:          uuid.New(),
+		QuizID:      quizID,
+		UserID:      uuid.New(),
+		IsCreator:   isCreator,
+		IsSpectator: isSpectator,
+		Send:        make(chan []byte, 16),
+	}
+}
+
+// receivedTypes drains a test client's send channel and returns the types of the events it was sent
+func receivedTypes(t *testing.T, client *Client) []EventType {
+	t.Helper()
+
+	var types []EventType
+	for {
+		select {
+		case message := <-client.Send:
+			var event struct {
+				Type EventType `json:"type"`
+			}
+			if err := json.Unmarshal(message, &event); err != nil {
+				t.Fatalf("client received invalid JSON %q: %v", message, err)
+			}
+			types = append(types, event.Type)
+		default:
+			return types
+		}
+	}
+}
+
+// newTestRedisHub creates a Redis hub whose client points at an address nothing listens on
+func newTestRedisHub(t *testing.T) *RedisHub {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+
+	return NewRedisHub(client, ctx, nil)
+}
+
+func TestRedisHubDispatchRoutesByAudience(t *testing.T) {
+	quizID := uuid.New()
+
+	tests := []struct {
+		name                string
+		audience            Audience
+		creatorReceives     bool
+		participantReceives bool
+	}{
+		{name: "all", audience: AudienceAll, creatorReceives: true, participantReceives: true},
+		{name: "creators", audience: AudienceCreators, creatorReceives: true, participantReceives: false},
+		{name: "participants", audience: AudienceParticipants, creatorReceives: false, participantReceives: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestRedisHub(t)
+			creator := newTestClient(quizID, true, false)
+			participant := newTestClient(quizID, false, false)
+			spectator := newTestClient(quizID, false, true)
+			for _, client := range []*Client{creator, participant, spectator} {
+				h.registerClient(client)
+			}
+
+			message, err := encodeEnvelope(tt.audience, NewEvent(EventQuestionPreview, map[string]interface{}{
+				"questionId": uuid.New().String(),
+			}))
+			if err != nil {
+				t.Fatalf("encodeEnvelope: %v", err)
+			}
+			h.dispatch(quizID, string(message))
+
+			if got := len(receivedTypes(t, creator)); got != boolCount(tt.creatorReceives) {
+				t.Errorf("creator received %d events, want %d", got, boolCount(tt.creatorReceives))
+			}
+			if got := len(receivedTypes(t, participant)); got != boolCount(tt.participantReceives) {
+				t.Errorf("participant received %d events, want %d", got, boolCount(tt.participantReceives))
+			}
+			if got := len(receivedTypes(t, spectator)); got != boolCount(tt.participantReceives) {
+				t.Errorf("spectator received %d events, want %d", got, boolCount(tt.participantReceives))
+			}
+		})
+	}
+}
+
+func TestRedisHubCreatorEventsNeverReachParticipants(t *testing.T) {
+	h := newTestRedisHub(t)
+	quizID := uuid.New()
+	creator := newTestClient(quizID, true, false)
+	participant := newTestClient(quizID, false, false)
+	h.registerClient(creator)
+	h.registerClient(participant)
+
+	creatorOnly := []EventType{EventQuestionPreview, EventQuestionStart, EventAnswerCountUpdate, EventAnswerLockUpdate, EventRequiredAnswersMissing}
+	for _, eventType := range creatorOnly {
+		message, err := encodeEnvelope(AudienceCreators, NewEvent(eventType, map[string]interface{}{}))
+		if err != nil {
+			t.Fatalf("encodeEnvelope: %v", err)
+		}
+		h.dispatch(quizID, string(message))
+	}
+
+	if got := receivedTypes(t, participant); len(got) != 0 {
+		t.Errorf("participant received creator-only events %v", got)
+	}
+	if got := receivedTypes(t, creator); len(got) != len(creatorOnly) {
+		t.Errorf("creator received %d events, want %d", len(got), len(creatorOnly))
+	}
+}
+
+func TestRedisHubPublishToCreatorsDoesNotDeliverLocally(t *testing.T) {
+	h := newTestRedisHub(t)
+	quizID := uuid.New()
+	creator := newTestClient(quizID, true, false)
+	h.registerClient(creator)
+
+	// Local clients receive the event through the subscription, so a failed publish reaches nobody
+	if err := h.PublishToCreators(quizID, NewEvent(EventQuestionPreview, map[string]interface{}{})); err == nil {
+		t.Fatal("expected publishing without Redis to fail")
+	}
+
+	if got := receivedTypes(t, creator); len(got) != 0 {
+		t.Errorf("creator received %v before the event came back from Redis", got)
+	}
+}
+
+func TestRedisHubDispatchSkipsOtherQuizzes(t *testing.T) {
+	h := newTestRedisHub(t)
+	quizID := uuid.New()
+	otherQuizID := uuid.New()
+	participant := newTestClient(otherQuizID, false, false)
+	h.registerClient(participant)
+
+	message, err := encodeEnvelope(AudienceAll, NewEvent(EventQuestionStart, map[string]interface{}{}))
+	if err != nil {
+		t.Fatalf("encodeEnvelope: %v", err)
+	}
+	h.dispatch(quizID, string(message))
+
+	if got := receivedTypes(t, participant); len(got) != 0 {
+		t.Errorf("participant of another quiz received %v", got)
+	}
+}
+
+func boolCount(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestRedisHubSelfTest(t *testing.T) {
+	t.Run("working pub/sub", func(t *testing.T) {
+		stub := startRedisStub(t)
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		if err := stub.hub(t).SelfTest(ctx); err != nil {
+			t.Errorf("SelfTest failed against working pub/sub: %v", err)
+		}
+	})
+
+	t.Run("messages not delivered", func(t *testing.T) {
+		stub := startRedisStub(t)
+		stub.dropMessages = true
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		if err := stub.hub(t).SelfTest(ctx); err == nil {
+			t.Error("SelfTest passed although published messages never arrive")
+		}
+	})
+
+	t.Run("redis unreachable", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		if err := newTestRedisHub(t).SelfTest(ctx); err == nil {
+			t.Error("SelfTest passed without Redis")
+		}
+	})
+}
+
+func TestRedisHubSelfTestCleansUpItsChannel(t *testing.T) {
+	stub := startRedisStub(t)
+	h := stub.hub(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := h.SelfTest(ctx); err != nil {
+		t.Fatalf("SelfTest: %v", err)
+	}
+
+	// Closing the subscription drops the connection; wait for the stub to notice
+	deadline := time.Now().Add(time.Second)
+	for {
+		stub.mu.Lock()
+		var left []string
+		for channel, subscribers := range stub.channels {
+			if len(subscribers) > 0 {
+				left = append(left, channel)
+			}
+		}
+		stub.mu.Unlock()
+
+		if len(left) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("self-test left subscriptions to %v", left)
+		}
+		if !strings.HasPrefix(left[0], "selftest:") {
+			t.Errorf("self-test used channel %q outside its namespace", left[0])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRedisHubCloseQuizDisconnectsClientsOnOtherInstances(t *testing.T) {
+	stub := startRedisStub(t)
+	deleting := stub.hub(t)
+	other := stub.hub(t)
+	quizID := uuid.New()
+
+	participant := newTestClient(quizID, false, false)
+	peer := connectWritingClient(t, participant)
+	other.registerClient(participant)
+	if err := other.SubscribeToQuiz(quizID); err != nil {
+		t.Fatalf("SubscribeToQuiz: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for stub.subscribers(quizChannel(quizID)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the other instance never subscribed to the quiz")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := deleting.CloseQuiz(quizID); err != nil {
+		t.Fatalf("CloseQuiz: %v", err)
+	}
+
+	got, closeErr := eventsBeforeClose(t, peer)
+	if len(got) != 1 || got[0] != EventQuizDeleted {
+		t.Errorf("peer on the other instance received %v before the close, want [%s]", got, EventQuizDeleted)
+	}
+	if closeErr.Text != string(model.DisconnectReasonQuizDeleted) {
+		t.Errorf("connection on the other instance was closed with %q, want %q", closeErr.Text, model.DisconnectReasonQuizDeleted)
+	}
+}
+
+func TestRedisHubReplaceConnectionClosesTheOlderConnectionOnly(t *testing.T) {
+	stub := startRedisStub(t)
+	oldInstance := stub.hub(t)
+	newInstance := stub.hub(t)
+	quizID := uuid.New()
+
+	// The participant reached both instances, the new one last
+	older := newTestClient(quizID, false, false)
+	olderPeer := connectClient(t, older)
+	newer := newTestClient(quizID, false, false)
+	newer.UserID = older.UserID
+	connectClient(t, newer)
+	oldInstance.registerClient(older)
+	newInstance.registerClient(newer)
+	for _, h := range []*RedisHub{oldInstance, newInstance} {
+		if err := h.SubscribeToQuiz(quizID); err != nil {
+			t.Fatalf("SubscribeToQuiz: %v", err)
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for stub.subscribers(quizChannel(quizID)) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("the instances never both subscribed to the quiz")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := newInstance.ReplaceConnection(quizID, older.UserID); err != nil {
+		t.Fatalf("ReplaceConnection: %v", err)
+	}
+
+	if got := closeText(t, olderPeer); got != string(model.DisconnectReasonReplaced) {
+		t.Errorf("older connection was closed with %q, want %q", got, model.DisconnectReasonReplaced)
+	}
+	if got := receivedTypes(t, older); len(got) != 0 {
+		t.Errorf("the control message reached the older client as %v", got)
+	}
+
+	newInstance.mu.Lock()
+	_, kept := newInstance.Clients[quizID][newer.ID]
+	newInstance.mu.Unlock()
+	if !kept {
+		t.Error("the instance holding the newest connection closed it too")
+	}
+	if got := receivedTypes(t, newer); len(got) != 0 {
+		t.Errorf("the control message reached the newer client as %v", got)
+	}
+}
+
+func TestRedisHubKickParticipantClosesTheirConnectionOnOtherInstances(t *testing.T) {
+	stub := startRedisStub(t)
+	kicking := stub.hub(t)
+	other := stub.hub(t)
+	quizID := uuid.New()
+
+	kicked := newTestClient(quizID, false, false)
+	kickedPeer := connectWritingClient(t, kicked)
+	bystander := newTestClient(quizID, false, false)
+	other.registerClient(kicked)
+	other.registerClient(bystander)
+	if err := other.SubscribeToQuiz(quizID); err != nil {
+		t.Fatalf("SubscribeToQuiz: %v", err)
+	}
+	waitForSubscribers(t, stub, quizID, 1)
+
+	event := NewEvent(EventUserKicked, map[string]interface{}{
+		"participantId": kicked.UserID.String(),
+		"name":          "Player",
+		"banned":        false,
+	})
+	if err := kicking.KickParticipant(quizID, kicked.UserID, event); err != nil {
+		t.Fatalf("KickParticipant: %v", err)
+	}
+
+	got, closeErr := eventsBeforeClose(t, kickedPeer)
+	if len(got) != 1 || got[0] != EventUserKicked {
+		t.Errorf("kicked peer received %v before the close, want [%s]", got, EventUserKicked)
+	}
+	if closeErr.Text != string(model.DisconnectReasonKicked) {
+		t.Errorf("kicked connection was closed with %q, want %q", closeErr.Text, model.DisconnectReasonKicked)
+	}
+
+	if got := receivedTypes(t, bystander); len(got) != 1 || got[0] != EventUserKicked {
+		t.Errorf("other participant was sent %v, want [%s]", got, EventUserKicked)
+	}
+	if bystander.DisconnectReason() == model.DisconnectReasonKicked {
+		t.Error("other participant was disconnected too")
+	}
+}
+
+func TestRedisHubPublishRetriesFailedPublishes(t *testing.T) {
+	tests := []struct {
+		name          string
+		failPublishes int
+		wantErr       bool
+	}{
+		{name: "recovers on the first retry", failPublishes: 1},
+		{name: "recovers on the last retry", failPublishes: publishAttempts - 1},
+		{name: "fails for longer than the retries", failPublishes: publishAttempts + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := startRedisStub(t)
+			h := stub.hub(t)
+			quizID := uuid.New()
+			client := newTestClient(quizID, false, false)
+			h.registerClient(client)
+			if err := h.SubscribeToQuiz(quizID); err != nil {
+				t.Fatalf("SubscribeToQuiz: %v", err)
+			}
+			deadline := time.Now().Add(time.Second)
+			for stub.subscribers(quizChannel(quizID)) == 0 {
+				if time.Now().After(deadline) {
+					t.Fatal("the hub never subscribed to the quiz")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			stub.mu.Lock()
+			stub.failPublishes = tt.failPublishes
+			stub.mu.Unlock()
+
+			err := h.PublishToQuiz(quizID, NewEvent(EventQuizStart, nil))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PublishToQuiz returned %v, want an error %v", err, tt.wantErr)
+			}
+			wantPublishes := tt.failPublishes + 1
+			if wantPublishes > publishAttempts {
+				wantPublishes = publishAttempts
+			}
+			if got := stub.publishCount(); got != wantPublishes {
+				t.Errorf("published %d times, want %d", got, wantPublishes)
+			}
+
+			var want []EventType
+			if !tt.wantErr {
+				want = []EventType{EventQuizStart}
+			}
+			time.Sleep(50 * time.Millisecond)
+			if got := receivedTypes(t, client); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("client was sent %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// waitForSubscribers waits until count connections are subscribed to a quiz's channel
+func waitForSubscribers(t *testing.T, stub *redisStub, quizID uuid.UUID, count int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for stub.subscribers(quizChannel(quizID)) != count {
+		if time.Now().After(deadline) {
+			t.Fatalf("quiz channel has %d subscribers, want %d", stub.subscribers(quizChannel(quizID)), count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRedisHubUnsubscribesWhenTheLastClientLeaves(t *testing.T) {
+	stub := startRedisStub(t)
+	h := stub.hub(t)
+	quizID := uuid.New()
+	first := newTestClient(quizID, false, false)
+	second := newTestClient(quizID, false, false)
+	for _, client := range []*Client{first, second} {
+		h.registerClient(client)
+		if err := h.SubscribeToQuiz(quizID); err != nil {
+			t.Fatalf("SubscribeToQuiz: %v", err)
+		}
+	}
+	waitForSubscribers(t, stub, quizID, 1)
+
+	h.unregisterClient(first)
+	time.Sleep(50 * time.Millisecond)
+	if got := stub.subscribers(quizChannel(quizID)); got != 1 {
+		t.Fatalf("quiz channel has %d subscribers while a client remains, want 1", got)
+	}
+
+	h.unregisterClient(second)
+	waitForSubscribers(t, stub, quizID, 0)
+	h.subMu.Lock()
+	tracked := h.subscribed[quizID]
+	h.subMu.Unlock()
+	if tracked {
+		t.Error("the hub still tracks the quiz it unsubscribed from")
+	}
+
+	// A client joining later subscribes again and receives the quiz's events
+	rejoined := newTestClient(quizID, false, false)
+	h.registerClient(rejoined)
+	if err := h.SubscribeToQuiz(quizID); err != nil {
+		t.Fatalf("SubscribeToQuiz after unsubscribing: %v", err)
+	}
+	waitForSubscribers(t, stub, quizID, 1)
+	if err := h.PublishToQuiz(quizID, NewEvent(EventQuizStart, nil)); err != nil {
+		t.Fatalf("PublishToQuiz: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := receivedTypes(t, rejoined); len(got) != 1 || got[0] != EventQuizStart {
+		t.Errorf("client that rejoined was sent %v, want [%s]", got, EventQuizStart)
+	}
+}
+
+func TestRedisHubKeepsSubscriptionsForClientsJoiningDuringTeardown(t *testing.T) {
+	stub := startRedisStub(t)
+	h := stub.hub(t)
+	quizID := uuid.New()
+	joining := newTestClient(quizID, false, false)
+	h.registerClient(joining)
+	if err := h.SubscribeToQuiz(quizID); err != nil {
+		t.Fatalf("SubscribeToQuiz: %v", err)
+	}
+	waitForSubscribers(t, stub, quizID, 1)
+
+	// The teardown after the previous last client left only runs once this client has registered
+	h.unsubscribeFromQuiz(quizID)
+
+	time.Sleep(50 * time.Millisecond)
+	if got := stub.subscribers(quizChannel(quizID)); got != 1 {
+		t.Errorf("quiz channel has %d subscribers with a client registered, want 1", got)
+	}
+}
+
+func TestRedisHubDeliversEventsOnlyToTheirQuiz(t *testing.T) {
+	stub := startRedisStub(t)
+	h := stub.hub(t)
+	first, second := uuid.New(), uuid.New()
+	firstClient := newTestClient(first, false, false)
+	secondClient := newTestClient(second, false, false)
+	// The first quiz creates the shared subscription, the second joins it
+	for _, client := range []*Client{firstClient, secondClient} {
+		h.registerClient(client)
+		if err := h.SubscribeToQuiz(client.QuizID); err != nil {
+			t.Fatalf("SubscribeToQuiz: %v", err)
+		}
+		waitForSubscribers(t, stub, client.QuizID, 1)
+	}
+
+	if err := h.PublishToQuiz(second, NewEvent(EventQuestionStart, nil)); err != nil {
+		t.Fatalf("PublishToQuiz: %v", err)
+	}
+	if err := h.PublishToQuiz(first, NewEvent(EventQuizEnd, nil)); err != nil {
+		t.Fatalf("PublishToQuiz: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if got := receivedTypes(t, firstClient); len(got) != 1 || got[0] != EventQuizEnd {
+		t.Errorf("client of the first quiz was sent %v, want only its [%s]", got, EventQuizEnd)
+	}
+	if got := receivedTypes(t, secondClient); len(got) != 1 || got[0] != EventQuestionStart {
+		t.Errorf("client of the second quiz was sent %v, want only its [%s]", got, EventQuestionStart)
+	}
+}
+
+func TestRedisHubSubscribingMoreQuizzesStartsNoGoroutines(t *testing.T) {
+	stub := startRedisStub(t)
+	h := stub.hub(t)
+	if err := h.SubscribeToQuiz(uuid.New()); err != nil {
+		t.Fatalf("SubscribeToQuiz: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 20; i++ {
+		quizID := uuid.New()
+		for j := 0; j < 2; j++ {
+			if err := h.SubscribeToQuiz(quizID); err != nil {
+				t.Fatalf("SubscribeToQuiz: %v", err)
+			}
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("subscribing 20 more quizzes grew the goroutines from %d to %d", before, after)
+	}
+}
@@ -0,0 +1,234 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisStub is a minimal Redis server speaking just enough RESP for the hub: PING, PUBLISH,
+// SUBSCRIBE and UNSUBSCRIBE. It can be told to drop or fail publishes to simulate broken pub/sub.
+type redisStub struct {
+	listener net.Listener
+
+	mu sync.Mutex
+	// channels maps each channel to the connections subscribed to it
+	channels map[string]map[*stubConn]bool
+	// dropMessages acknowledges publishes without delivering them
+	dropMessages bool
+	// failPublishes is how many of the next publishes are answered with an error
+	failPublishes int
+	publishes     int
+}
+
+// stubConn is a client connection to the stub
+type stubConn struct {
+	conn     net.Conn
+	writeMu  sync.Mutex
+	channels map[string]bool
+}
+
+// startRedisStub starts a stub on a free local port; it stops when the test ends
+func startRedisStub(t *testing.T) *redisStub {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	stub := &redisStub{listener: listener, channels: make(map[string]map[*stubConn]bool)}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go stub.serve(&stubConn{conn: conn, channels: make(map[string]bool)})
+		}
+	}()
+
+	return stub
+}
+
+// client returns a Redis client connected to the stub
+func (s *redisStub) client(t *testing.T) *redis.Client {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: s.listener.Addr().String(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// hub returns a Redis hub connected to the stub
+func (s *redisStub) hub(t *testing.T) *RedisHub {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return NewRedisHub(s.client(t), ctx, nil)
+}
+
+// subscribers returns how many connections are subscribed to channel
+func (s *redisStub) subscribers(channel string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.channels[channel])
+}
+
+// publishCount returns how many publishes the stub was sent, failed ones included
+func (s *redisStub) publishCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.publishes
+}
+
+func (s *redisStub) serve(c *stubConn) {
+	defer func() {
+		s.mu.Lock()
+		for channel := range c.channels {
+			delete(s.channels[channel], c)
+		}
+		s.mu.Unlock()
+		c.conn.Close()
+	}()
+
+	reader := bufio.NewReader(c.conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		s.handle(c, args)
+	}
+}
+
+func (s *redisStub) handle(c *stubConn, args []string) {
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		if len(c.channels) > 0 {
+			c.write("*2\r\n$4\r\npong\r\n$0\r\n\r\n")
+		} else {
+			c.write("+PONG\r\n")
+		}
+
+	case "PUBLISH":
+		s.mu.Lock()
+		s.publishes++
+		if s.failPublishes > 0 {
+			s.failPublishes--
+			s.mu.Unlock()
+			c.write("-ERR publish failed\r\n")
+			return
+		}
+		var receivers []*stubConn
+		if !s.dropMessages {
+			for receiver := range s.channels[args[1]] {
+				receivers = append(receivers, receiver)
+			}
+		}
+		s.mu.Unlock()
+
+		c.write(fmt.Sprintf(":%d\r\n", len(receivers)))
+		for _, receiver := range receivers {
+			receiver.write(array("message", args[1], args[2]))
+		}
+
+	case "SUBSCRIBE":
+		for _, channel := range args[1:] {
+			s.mu.Lock()
+			if s.channels[channel] == nil {
+				s.channels[channel] = make(map[*stubConn]bool)
+			}
+			s.channels[channel][c] = true
+			c.channels[channel] = true
+			count := len(c.channels)
+			s.mu.Unlock()
+			c.write(subscriptionReply("subscribe", channel, count))
+		}
+
+	case "UNSUBSCRIBE":
+		channels := args[1:]
+		if len(channels) == 0 {
+			s.mu.Lock()
+			for channel := range c.channels {
+				channels = append(channels, channel)
+			}
+			s.mu.Unlock()
+		}
+		for _, channel := range channels {
+			s.mu.Lock()
+			delete(s.channels[channel], c)
+			delete(c.channels, channel)
+			count := len(c.channels)
+			s.mu.Unlock()
+			c.write(subscriptionReply("unsubscribe", channel, count))
+		}
+
+	default:
+		c.write("-ERR unknown command '" + args[0] + "'\r\n")
+	}
+}
+
+func (c *stubConn) write(reply string) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	io.WriteString(c.conn, reply)
+}
+
+// array encodes a RESP array of bulk strings
+func array(items ...string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(items))
+	for _, item := range items {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(item), item)
+	}
+	return b.String()
+}
+
+// subscriptionReply encodes the reply to SUBSCRIBE or UNSUBSCRIBE for one channel
+func subscriptionReply(kind string, channel string, count int) string {
+	return fmt.Sprintf("*3\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n:%d\r\n", len(kind), kind, len(channel), channel, count)
+}
+
+// readCommand reads one RESP array of bulk strings
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected command line %q", line)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(header[1:]))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
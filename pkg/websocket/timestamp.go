@@ -0,0 +1,12 @@
+package websocket
+
+import "time"
+
+// TimestampFormat is the layout of every timestamp carried in an event payload.
+// Durations are sent as numbers with the unit in the field name (e.g. remainingSeconds).
+const TimestampFormat = time.RFC3339
+
+// FormatTimestamp renders t in UTC using TimestampFormat
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format(TimestampFormat)
+}
@@ -0,0 +1,95 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// assertTimestamp fails unless value is a string in TimestampFormat, in UTC
+func assertTimestamp(t *testing.T, field string, value interface{}) time.Time {
+	t.Helper()
+
+	raw, ok := value.(string)
+	if !ok {
+		t.Fatalf("%s is %T %v, want a timestamp string", field, value, value)
+	}
+	parsed, err := time.Parse(TimestampFormat, raw)
+	if err != nil {
+		t.Fatalf("%s %q is not in the timestamp format: %v", field, raw, err)
+	}
+	if parsed.Location() != time.UTC {
+		t.Errorf("%s %q is not in UTC", field, raw)
+	}
+	return parsed
+}
+
+// decodeEvent decodes a message sent to a client
+func decodeEvent(t *testing.T, message []byte) (EventType, map[string]interface{}) {
+	t.Helper()
+
+	var event struct {
+		Type    EventType              `json:"type"`
+		Payload map[string]interface{} `json:"payload"`
+	}
+	if err := json.Unmarshal(message, &event); err != nil {
+		t.Fatalf("client received invalid JSON %q: %v", message, err)
+	}
+	return event.Type, event.Payload
+}
+
+func TestFormatTimestampRendersUTC(t *testing.T) {
+	local := time.Date(2024, 3, 1, 14, 30, 15, 500, time.FixedZone("UTC+7", 7*60*60))
+
+	if got, want := FormatTimestamp(local), "2024-03-01T07:30:15Z"; got != want {
+		t.Errorf("FormatTimestamp = %q, want %q", got, want)
+	}
+	if parsed := assertTimestamp(t, "formatted", FormatTimestamp(local)); !parsed.Equal(local.Truncate(time.Second)) {
+		t.Errorf("formatted timestamp parses as %v, want %v", parsed, local)
+	}
+}
+
+func TestPongCarriesATimestamp(t *testing.T) {
+	pumped := startReadPump(t, newParticipantClient(&recordingSubmitter{}))
+	pumped.write(t, "ping", map[string]interface{}{})
+
+	select {
+	case message := <-pumped.client.Send:
+		eventType, payload := decodeEvent(t, message)
+		if eventType != "pong" {
+			t.Fatalf("client was sent %s, want pong", eventType)
+		}
+		assertTimestamp(t, "pong time", payload["time"])
+	case <-time.After(2 * time.Second):
+		t.Fatal("no pong sent")
+	}
+}
+
+func TestLobbyCountdownCarriesATimestamp(t *testing.T) {
+	h := NewHub(nil)
+	quizID := uuid.New()
+	client := newTestClient(quizID, false, false)
+	h.registerClient(client)
+
+	// A cancelled countdown still announces its start
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	h.StartCountdownBroadcast(ctx, quizID, 10)
+
+	select {
+	case message := <-client.Send:
+		eventType, payload := decodeEvent(t, message)
+		if eventType != EventLobbyCountdown {
+			t.Fatalf("client was sent %s, want %s", eventType, EventLobbyCountdown)
+		}
+		if _, ok := payload["remainingSeconds"].(float64); !ok {
+			t.Errorf("remainingSeconds is %T, want a number", payload["remainingSeconds"])
+		}
+		assertTimestamp(t, "quizStartTime", payload["quizStartTime"])
+	default:
+		t.Fatal("no countdown sent")
+	}
+}